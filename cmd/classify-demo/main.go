@@ -1,16 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/solvaholic/threadmine/internal/classify"
 	"github.com/solvaholic/threadmine/internal/normalize"
 )
 
 func main() {
+	since := flag.String("since", "", "Classify stored normalized messages from this date (YYYY-MM-DD)")
+	until := flag.String("until", "", "Last date to classify (YYYY-MM-DD, default: today)")
+	flag.Parse()
+
 	fmt.Println("ThreadMine - Message Classification Demo")
 	fmt.Println()
 
+	if *since != "" {
+		if err := classifyStored(*since, *until); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Example messages to classify
 	examples := []struct {
 		content string
@@ -79,3 +95,56 @@ func main() {
 
 	fmt.Println("Classification complete! 🎉")
 }
+
+// classifyStored classifies normalized messages saved between since and until
+// and prints a count per classification type
+func classifyStored(since, until string) error {
+	from, err := time.ParseInLocation("2006-01-02", since, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid -since date: %w", err)
+	}
+	to := time.Now()
+	if until != "" {
+		to, err = time.ParseInLocation("2006-01-02", until, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -until date: %w", err)
+		}
+	}
+
+	messages, err := normalize.LoadMessagesByDateRange(from, to)
+	if err != nil {
+		return err
+	}
+
+	// Group messages into threads so answers can be detected
+	threads := make(map[string][]*normalize.NormalizedMessage)
+	for _, msg := range messages {
+		key := msg.ThreadID
+		if key == "" {
+			key = msg.ID
+		}
+		threads[key] = append(threads[key], msg)
+	}
+
+	counts := make(map[string]int)
+	for _, thread := range threads {
+		sort.SliceStable(thread, func(i, j int) bool {
+			if thread[i].IsThreadRoot != thread[j].IsThreadRoot {
+				return thread[i].IsThreadRoot
+			}
+			return thread[i].Timestamp.Before(thread[j].Timestamp)
+		})
+		for _, msg := range thread {
+			for _, c := range classify.ClassifyMessage(msg, classify.BuildThreadContext(thread, msg)) {
+				counts[c.Type]++
+			}
+		}
+	}
+
+	fmt.Printf("Classified %d messages in %d threads (%s to %s)\n\n",
+		len(messages), len(threads), from.Format("2006-01-02"), to.Format("2006-01-02"))
+	for _, typ := range []string{"question", "answer", "solution", "acknowledgment"} {
+		fmt.Printf("  %-15s %d\n", typ+":", counts[typ])
+	}
+	return nil
+}
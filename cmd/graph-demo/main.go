@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 
@@ -9,6 +10,11 @@ import (
 )
 
 func main() {
+	top := flag.Int("top", 10, "Number of threads with replies to show (0 for all)")
+	sortBy := flag.String("sort", graph.SortBySize, "Thread order: size or recent")
+	full := flag.Bool("full", false, "Also print the full graph as JSON")
+	flag.Parse()
+
 	// Load the graph
 	g, err := graph.LoadReplyGraph()
 	if err != nil {
@@ -28,31 +34,40 @@ func main() {
 	fmt.Printf("Average Thread Depth: %.2f\n", stats["average_thread_depth"])
 	fmt.Printf("Updated: %v\n\n", stats["updated_at"])
 
-	// Find threads with replies
+	// Find the top threads with replies
+	threads, err := g.TopThreads(0, *sortBy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	fmt.Printf("Threads with Replies:\n")
 	fmt.Printf("---------------------\n")
 	threadsWithReplies := 0
-	for _, rootID := range g.ThreadRoots {
-		children := g.GetChildren(rootID)
-		if len(children) > 0 {
-			threadsWithReplies++
-			thread := g.GetThread(rootID)
-			depth := g.GetThreadDepth(rootID)
-			
-			fmt.Printf("\nThread Root: %s\n", rootID)
-			fmt.Printf("  Messages: %d\n", len(thread))
-			fmt.Printf("  Depth: %d\n", depth)
-			fmt.Printf("  Direct Replies: %d\n", len(children))
-			
-			// Display thread structure
-			fmt.Printf("  Structure:\n")
-			for i, node := range thread {
-				indent := ""
-				if i > 0 {
-					indent = "    "
-				}
-				fmt.Printf("    %s- %s (%s)\n", indent, node.MessageID, node.Timestamp.Format("15:04:05"))
+	for _, activity := range threads {
+		if activity.ReplyCount == 0 {
+			continue
+		}
+		if *top > 0 && threadsWithReplies == *top {
+			break
+		}
+		threadsWithReplies++
+		rootID := activity.RootID
+		thread := g.GetThread(rootID)
+
+		fmt.Printf("\nThread Root: %s\n", rootID)
+		fmt.Printf("  Messages: %d\n", len(thread))
+		fmt.Printf("  Depth: %d\n", activity.MaxDepth)
+		fmt.Printf("  Direct Replies: %d\n", len(g.GetChildren(rootID)))
+
+		// Display thread structure
+		fmt.Printf("  Structure:\n")
+		for i, node := range thread {
+			indent := ""
+			if i > 0 {
+				indent = "    "
 			}
+			fmt.Printf("    %s- %s (%s)\n", indent, node.MessageID, node.Timestamp.Format("15:04:05"))
 		}
 	}
 
@@ -60,6 +75,10 @@ func main() {
 		fmt.Printf("No threads with replies found.\n")
 	}
 
+	if !*full {
+		return
+	}
+
 	// Output full graph as JSON
 	fmt.Printf("\n\nFull Graph Data:\n")
 	fmt.Printf("================\n")
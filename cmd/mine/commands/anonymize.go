@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"regexp"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+var (
+	// Raw Slack mentions as stored in message content: <@U123> or <@U123|name>
+	slackRawMentionPattern = regexp.MustCompile(`<@([A-Z0-9]+)(\|[^>]*)?>`)
+	// GitHub @login mentions
+	githubLoginMentionPattern = regexp.MustCompile(`(^|[^a-zA-Z0-9.])@([a-zA-Z0-9][-a-zA-Z0-9]*)`)
+)
+
+// anonymizeMessages replaces author and mention IDs in selected messages with
+// stable pseudonyms (see normalize.Pseudonyms), including who edited them.
+// Message, thread, parent, and channel IDs are kept so thread and graph
+// structure is preserved.
+// Messages are modified in place.
+func anonymizeMessages(messages []*db.Message, redactContent bool) {
+	// Collect every user ID referenced by the result set
+	var ids []string
+	for _, msg := range messages {
+		ids = append(ids, msg.AuthorID)
+		ids = append(ids, msg.Mentions...)
+		if msg.EditedBy != nil {
+			ids = append(ids, *msg.EditedBy)
+		}
+		if !redactContent {
+			ids = append(ids, contentMentionIDs(msg)...)
+		}
+	}
+	pseudonyms := normalize.Pseudonyms(ids)
+
+	for _, msg := range messages {
+		msg.AuthorID = pseudonyms[msg.AuthorID]
+		for i, mention := range msg.Mentions {
+			msg.Mentions[i] = pseudonyms[mention]
+		}
+		if msg.EditedBy != nil {
+			editedBy := pseudonyms[*msg.EditedBy]
+			msg.EditedBy = &editedBy
+		}
+
+		msg.ContentHTML = nil
+		// Shared messages are titled with their author's name
+		for i := range msg.Attachments {
+			if msg.Attachments[i].Type == normalize.AttachmentTypeMessage {
+				msg.Attachments[i].Title = ""
+			}
+		}
+		if redactContent {
+			msg.Content = normalize.RedactedContent
+			msg.CodeBlocks = []db.CodeBlock{}
+			msg.URLs = []string{}
+			for i := range msg.Attachments {
+				msg.Attachments[i].URL = ""
+				msg.Attachments[i].Title = ""
+			}
+			continue
+		}
+
+		msg.Content = replaceContentMentions(msg, pseudonyms)
+	}
+}
+
+// contentMentionIDs returns the user IDs of users mentioned in a message's content
+func contentMentionIDs(msg *db.Message) []string {
+	var ids []string
+	switch msg.SourceType {
+	case "slack":
+		for _, match := range slackRawMentionPattern.FindAllStringSubmatch(msg.Content, -1) {
+			ids = append(ids, "user_slack_"+match[1])
+		}
+	case "github":
+		for _, match := range githubLoginMentionPattern.FindAllStringSubmatch(msg.Content, -1) {
+			ids = append(ids, "user_github_"+match[2])
+		}
+	}
+	return ids
+}
+
+// replaceContentMentions rewrites user mentions in content to pseudonyms
+func replaceContentMentions(msg *db.Message, pseudonyms map[string]string) string {
+	switch msg.SourceType {
+	case "slack":
+		return slackRawMentionPattern.ReplaceAllStringFunc(msg.Content, func(match string) string {
+			parts := slackRawMentionPattern.FindStringSubmatch(match)
+			return "<@" + pseudonyms["user_slack_"+parts[1]] + ">"
+		})
+	case "github":
+		return githubLoginMentionPattern.ReplaceAllStringFunc(msg.Content, func(match string) string {
+			parts := githubLoginMentionPattern.FindStringSubmatch(match)
+			return parts[1] + "@" + pseudonyms["user_github_"+parts[2]]
+		})
+	}
+	return msg.Content
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// testAnonymizeThread returns bob's Slack question with a link, alice's answer
+// mentioning bob, and bob's thanks, which alice edited
+func testAnonymizeThread() []*db.Message {
+	root := "msg_slack_C1_1.0"
+	alice := "user_slack_U1"
+	html := "<p>run <code>make deploy</code></p>"
+	return []*db.Message{
+		{ID: root, SourceType: "slack", AuthorID: "user_slack_U2", ThreadID: &root, IsThreadRoot: true,
+			Content: "How do I deploy?", URLs: []string{"https://internal.example.com"}},
+		{ID: "msg_slack_C1_2.0", SourceType: "slack", AuthorID: alice, ThreadID: &root, ParentID: &root,
+			Content: "<@U2> run make deploy", ContentHTML: &html, Mentions: []string{"user_slack_U2"},
+			CodeBlocks:  []db.CodeBlock{{Code: "make deploy"}},
+			Attachments: []db.Attachment{{Type: "image", URL: "https://files.example.com/x.png", Title: "secret.png"}}},
+		{ID: "msg_slack_C1_3.0", SourceType: "slack", AuthorID: "user_slack_U2", ThreadID: &root, ParentID: &root,
+			Content: "Thanks!", EditedBy: &alice},
+	}
+}
+
+func TestAnonymizeMessages(t *testing.T) {
+	messages := testAnonymizeThread()
+	anonymizeMessages(messages, false)
+
+	pseudonyms := normalize.Pseudonyms([]string{"user_slack_U1", "user_slack_U2"})
+	alice, bob := pseudonyms["user_slack_U1"], pseudonyms["user_slack_U2"]
+
+	// The same user gets the same pseudonym as author, mention, and editor
+	if messages[0].AuthorID != bob || messages[2].AuthorID != bob || messages[1].AuthorID != alice {
+		t.Errorf("authors = %s, %s, %s; want %s, %s, %s",
+			messages[0].AuthorID, messages[1].AuthorID, messages[2].AuthorID, bob, alice, bob)
+	}
+	if len(messages[1].Mentions) != 1 || messages[1].Mentions[0] != bob {
+		t.Errorf("mentions = %v, want [%s]", messages[1].Mentions, bob)
+	}
+	if got := deref(messages[2].EditedBy); got != alice {
+		t.Errorf("edited by %q, want %s", got, alice)
+	}
+	if want := "<@" + bob + "> run make deploy"; messages[1].Content != want {
+		t.Errorf("content = %q, want %q", messages[1].Content, want)
+	}
+	if messages[1].ContentHTML != nil {
+		t.Errorf("rendered HTML kept: %q", *messages[1].ContentHTML)
+	}
+
+	// The thread structure is kept
+	if deref(messages[2].ParentID) != "msg_slack_C1_1.0" || deref(messages[1].ThreadID) != "msg_slack_C1_1.0" {
+		t.Errorf("thread structure changed: %+v", messages[2])
+	}
+
+	// Anonymizing the same messages again gives the same pseudonyms
+	again := testAnonymizeThread()
+	anonymizeMessages(again, false)
+	for i := range again {
+		if again[i].AuthorID != messages[i].AuthorID {
+			t.Errorf("%s: pseudonym changed between runs: %s vs %s", again[i].ID, again[i].AuthorID, messages[i].AuthorID)
+		}
+	}
+}
+
+func TestAnonymizeMessages_RedactContent(t *testing.T) {
+	messages := testAnonymizeThread()
+	anonymizeMessages(messages, true)
+
+	for _, msg := range messages {
+		if msg.Content != normalize.RedactedContent {
+			t.Errorf("%s: content not redacted: %q", msg.ID, msg.Content)
+		}
+		if len(msg.CodeBlocks) != 0 || len(msg.URLs) != 0 {
+			t.Errorf("%s: code blocks or URLs not redacted", msg.ID)
+		}
+		if strings.Contains(msg.AuthorID, "slack") {
+			t.Errorf("%s: author not anonymized: %s", msg.ID, msg.AuthorID)
+		}
+	}
+	if att := messages[1].Attachments[0]; att.URL != "" || att.Title != "" || att.Type != "image" {
+		t.Errorf("unexpected attachment after redaction: %+v", att)
+	}
+}
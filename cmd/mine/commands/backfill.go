@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/eventlog"
+	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// errHistoryRateLimited stops a backfill when the self-imposed
+// conversations.history limit is reached; progress so far is kept
+var errHistoryRateLimited = errors.New("rate limit reached for conversations.history")
+
+// rateLimitedHistoryPager checks and records rate limits around each history page
+type rateLimitedHistoryPager struct {
+	client      *slack.Client
+	database    *db.DB
+	workspaceID string
+}
+
+func (p *rateLimitedHistoryPager) FetchHistoryPage(ctx context.Context, channelID, latest string, limit int) (*slack.HistoryPage, error) {
+	canProceed, err := p.database.CheckRateLimit("slack", &p.workspaceID, "conversations.history")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if !canProceed {
+		return nil, errHistoryRateLimited
+	}
+
+	page, err := p.client.FetchHistoryPage(ctx, channelID, latest, limit)
+	if err != nil {
+		return nil, err
+	}
+	p.database.RecordRequest("slack", &p.workspaceID, "conversations.history")
+	return page, nil
+}
+
+// runSlackBackfill fetches a channel's history backward from its saved cursor,
+// storing each page and then advancing the cursor so later runs resume
+func runSlackBackfill(cmd *cobra.Command, database *db.DB, recorder *threadRecorder, event *eventlog.Event) error {
+	if slackChannel == "" {
+		return usageErrorf("--backfill requires --channel")
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Authenticating with Slack...\n")
+	authResult, err := slack.Authenticate(slackWorkspace)
+	if err != nil {
+		return fmt.Errorf("Slack authentication failed: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Authenticated as %s in %s (Team ID: %s)\n",
+		authResult.UserName, authResult.TeamName, authResult.TeamID)
+
+	ctx := context.Background()
+	channel, err := findSlackChannel(ctx, database, authResult.Client, authResult.TeamID, slackChannel)
+	if err != nil {
+		return err
+	}
+
+	// Initialize rate limiting for conversations.history (50/min, self-limit to 25/min)
+	workspaceID := fmt.Sprintf("ws_slack_%s", authResult.TeamID)
+	saveAuthenticatedUser(cmd, database, &db.Workspace{
+		ID:                  workspaceID,
+		SourceType:          "slack",
+		SourceID:            authResult.TeamID,
+		Name:                authResult.TeamName,
+		AuthenticatedUserID: optionalString("user_slack_" + authResult.UserID),
+	})
+	if err := database.InitRateLimit("slack", &workspaceID, "conversations.history", 60, 50, 25); err != nil {
+		return fmt.Errorf("failed to initialize conversations.history rate limiting: %w", err)
+	}
+	if err := database.InitRateLimit("slack", &workspaceID, "conversations.replies", 60, 50, 25); err != nil {
+		return fmt.Errorf("failed to initialize conversations.replies rate limiting: %w", err)
+	}
+
+	saved, err := database.GetFetchCursor("slack", workspaceID, channel.ID)
+	if err != nil {
+		return err
+	}
+	cursor := slack.BackfillCursor{}
+	if saved != nil {
+		cursor = slack.BackfillCursor{OldestTS: saved.OldestTS, Complete: saved.Complete}
+	}
+
+	if cursor.Complete {
+		fmt.Fprintf(cmd.OutOrStderr(), "History of #%s is already fully backfilled\n", channel.Name)
+		return nil
+	}
+	if cursor.OldestTS != "" {
+		fmt.Fprintf(cmd.OutOrStderr(), "Resuming backfill of #%s before %s\n", channel.Name, formatSlackTS(cursor.OldestTS))
+	} else {
+		fmt.Fprintf(cmd.OutOrStderr(), "Starting backfill of #%s\n", channel.Name)
+	}
+
+	var st store.Store = recorder
+	pager := &rateLimitedHistoryPager{client: authResult.Client, database: database, workspaceID: workspaceID}
+	messageCount := 0
+	threadCount := 0
+	failures := &fetchFailures{database: database}
+
+	cursor, err = slack.Backfill(ctx, pager, channel.ID, cursor, slack.BackfillOptions{MaxMessages: fetchLimit},
+		func(messages []slack.Message, next slack.BackfillCursor) error {
+			for _, msg := range messages {
+				if slackThreads && msg.ThreadTS != "" && msg.ThreadTS == msg.Timestamp {
+					if stored := backfillThread(cmd, database, st, failures, authResult, workspaceID, channel, msg.ThreadTS); stored > 0 {
+						messageCount += stored
+						threadCount++
+						continue
+					}
+				}
+				if err := storeSlackMessage(database, st, msg, authResult.TeamID, channel.ID, channel); err != nil {
+					failures.add(cmd, "message", err)
+					continue
+				}
+				messageCount++
+			}
+
+			if err := database.SaveFetchCursor(&db.FetchCursor{
+				SourceType:  "slack",
+				WorkspaceID: workspaceID,
+				ChannelID:   channel.ID,
+				OldestTS:    next.OldestTS,
+				Complete:    next.Complete,
+			}); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStderr(), "  Fetched %d messages (back to %s)\n", len(messages), formatSlackTS(next.OldestTS))
+			return nil
+		})
+
+	event.Messages = messageCount
+	event.Threads = threadCount
+
+	if errors.Is(err, errHistoryRateLimited) {
+		fmt.Fprintf(cmd.OutOrStderr(), "  Rate limit reached, stopping; run again to continue\n")
+	} else if err != nil {
+		return fmt.Errorf("backfill failed: %w", err)
+	}
+
+	upsertEntities(cmd, database, recorder, workspaceID, authResult.Client)
+	resolveContentMentions(cmd, database, recorder, authResult.Client)
+	event.Coverage = summarizeThreads(cmd, database, recorder)
+	relateReferences(cmd, database, recorder)
+	if slackFindDuplicates {
+		relateCrossPosts(cmd, database, recorder)
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "\nCompleted!\n")
+	fmt.Fprintf(cmd.OutOrStderr(), "Messages stored: %d\n", messageCount)
+	fmt.Fprintf(cmd.OutOrStderr(), "Threads processed: %d\n", threadCount)
+	reportSkippedStages(cmd, recorder, event)
+	failures.report(cmd, event)
+	if cursor.Complete {
+		fmt.Fprintf(cmd.OutOrStderr(), "Reached the start of #%s\n", channel.Name)
+	} else if cursor.OldestTS != "" {
+		fmt.Fprintf(cmd.OutOrStderr(), "Backfilled to %s; run again to continue\n", formatSlackTS(cursor.OldestTS))
+	}
+
+	return exportReplyGraph(cmd, recorder)
+}
+
+// backfillThread stores a thread root and its replies, returning the number
+// of messages stored, or 0 if the thread could not be fetched
+func backfillThread(cmd *cobra.Command, database *db.DB, st store.Store, failures *fetchFailures, authResult *slack.AuthResult, workspaceID string, channel *slack.Channel, threadTS string) int {
+	canProceed, err := database.CheckRateLimit("slack", &workspaceID, "conversations.replies")
+	if err != nil || !canProceed {
+		return 0
+	}
+
+	replies, err := authResult.Client.GetThreadReplies(context.Background(), channel.ID, threadTS)
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch thread %s: %v\n", threadTS, err)
+		return 0
+	}
+	database.RecordRequest("slack", &workspaceID, "conversations.replies")
+
+	stored := 0
+	for _, reply := range replies {
+		if err := storeSlackMessage(database, st, reply, authResult.TeamID, channel.ID, channel); err != nil {
+			failures.add(cmd, "message", err)
+			continue
+		}
+		stored++
+	}
+	return stored
+}
+
+// slackChannelLookup finds Slack channels by listing them or by ID
+type slackChannelLookup interface {
+	ListChannels(ctx context.Context) ([]slack.Channel, error)
+	GetChannelInfo(ctx context.Context, channelID string) (*slack.Channel, error)
+}
+
+// slackChannelIDPattern matches Slack conversation IDs: public (C) and
+// private (G) channels and direct messages (D). Channel names are lowercase.
+var slackChannelIDPattern = regexp.MustCompile(`^[CDG][A-Z0-9]{8,}$`)
+
+// findSlackChannel looks up a channel by ID or name. IDs are fetched directly.
+// Names are resolved through the channels stored for workspace teamID, and
+// otherwise by listing the channels the user is a member of (an expensive
+// call). Listed channels are stored, so later lookups of their names skip it.
+func findSlackChannel(ctx context.Context, database *db.DB, lookup slackChannelLookup, teamID, nameOrID string) (*slack.Channel, error) {
+	if slackChannelIDPattern.MatchString(nameOrID) {
+		channel, err := lookup.GetChannelInfo(ctx, nameOrID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up channel %s: %w", nameOrID, err)
+		}
+		return channel, nil
+	}
+
+	name := strings.TrimPrefix(nameOrID, "#")
+	if id := storedSlackChannelID(database, teamID, name); id != "" {
+		// The stored name is stale if the channel was renamed since
+		if channel, err := lookup.GetChannelInfo(ctx, id); err == nil && channel.Name == name {
+			return channel, nil
+		}
+	}
+
+	channels, err := lookup.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]*db.Channel, len(channels))
+	for i := range channels {
+		listed[i] = slackDBChannel(&channels[i], teamID)
+	}
+	// Storing the names is only an optimization for later lookups
+	database.SaveChannels(listed)
+
+	for i := range channels {
+		if channels[i].ID == nameOrID || channels[i].Name == name {
+			return &channels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("channel %s not found among channels you are a member of", nameOrID)
+}
+
+// storedSlackChannelID returns the ID of the stored Slack channel of
+// workspace teamID named name, or "" if there is none
+func storedSlackChannelID(database *db.DB, teamID, name string) string {
+	channels, err := database.FindChannelsByName(name, true)
+	if err != nil {
+		return ""
+	}
+	workspaceID := fmt.Sprintf("ws_slack_%s", teamID)
+	for _, channel := range channels {
+		if channel.SourceType == "slack" && channel.Name == name &&
+			channel.WorkspaceID != nil && *channel.WorkspaceID == workspaceID {
+			return channel.SourceID
+		}
+	}
+	return ""
+}
+
+// formatSlackTS formats a Slack timestamp for progress output
+func formatSlackTS(ts string) string {
+	t, err := parseSlackTimestamp(ts)
+	if err != nil {
+		return ts
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
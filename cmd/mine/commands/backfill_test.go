@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/slack"
+)
+
+// fakeSlackChannels serves channel lookups from a fixed list and counts the calls
+type fakeSlackChannels struct {
+	channels []slack.Channel
+	lists    int
+	infos    int
+}
+
+func (f *fakeSlackChannels) ListChannels(ctx context.Context) ([]slack.Channel, error) {
+	f.lists++
+	return f.channels, nil
+}
+
+func (f *fakeSlackChannels) GetChannelInfo(ctx context.Context, channelID string) (*slack.Channel, error) {
+	f.infos++
+	for i := range f.channels {
+		if f.channels[i].ID == channelID {
+			return &f.channels[i], nil
+		}
+	}
+	return nil, &slack.APIError{Code: "channel_not_found"}
+}
+
+func TestFindSlackChannel(t *testing.T) {
+	requireFTS5(t)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	lookup := &fakeSlackChannels{channels: []slack.Channel{
+		{ID: "C0123ABCD", Name: "general", IsChannel: true, IsMember: true},
+		{ID: "C0456EFGH", Name: "random", IsChannel: true, IsMember: true},
+	}}
+	ctx := context.Background()
+
+	// An ID is fetched directly
+	channel, err := findSlackChannel(ctx, database, lookup, "T1", "C0456EFGH")
+	if err != nil || channel.Name != "random" {
+		t.Fatalf("findSlackChannel(ID) = %+v, %v; want random", channel, err)
+	}
+	if lookup.lists != 0 || lookup.infos != 1 {
+		t.Errorf("expected 1 info call and no list, got %d info and %d list calls", lookup.infos, lookup.lists)
+	}
+
+	// The first name lookup lists the channels and stores their names
+	channel, err = findSlackChannel(ctx, database, lookup, "T1", "#general")
+	if err != nil || channel.ID != "C0123ABCD" {
+		t.Fatalf("findSlackChannel(name) = %+v, %v; want C0123ABCD", channel, err)
+	}
+	if lookup.lists != 1 {
+		t.Errorf("expected 1 list call, got %d", lookup.lists)
+	}
+
+	// Later name lookups use the stored names, for any listed channel
+	for _, name := range []string{"general", "random"} {
+		if _, err := findSlackChannel(ctx, database, lookup, "T1", name); err != nil {
+			t.Fatalf("findSlackChannel(%s) failed: %v", name, err)
+		}
+	}
+	if lookup.lists != 1 {
+		t.Errorf("expected stored names to skip the list, got %d list calls", lookup.lists)
+	}
+
+	// Stored names belong to their workspace
+	if _, err := findSlackChannel(ctx, database, lookup, "T2", "general"); err != nil {
+		t.Fatalf("findSlackChannel(other workspace) failed: %v", err)
+	}
+	if lookup.lists != 2 {
+		t.Errorf("expected another workspace to list its channels, got %d list calls", lookup.lists)
+	}
+
+	// A renamed channel's stale name falls back to the list
+	lookup.channels[1].Name = "watercooler"
+	if _, err := findSlackChannel(ctx, database, lookup, "T1", "random"); err == nil {
+		t.Error("expected the old name of a renamed channel not to be found")
+	}
+	if lookup.lists != 3 {
+		t.Errorf("expected a stale name to list the channels, got %d list calls", lookup.lists)
+	}
+	channel, err = findSlackChannel(ctx, database, lookup, "T1", "watercooler")
+	if err != nil || channel.ID != "C0456EFGH" {
+		t.Fatalf("findSlackChannel(new name) = %+v, %v; want C0456EFGH", channel, err)
+	}
+	if lookup.lists != 3 {
+		t.Errorf("expected the relisted names to be stored, got %d list calls", lookup.lists)
+	}
+}
@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/solvaholic/threadmine/internal/cache"
+	"github.com/solvaholic/threadmine/internal/clock"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Archive the whole ThreadMine home directory",
+	Long: `Write everything under ~/.threadmine (raw cache, normalized messages,
+graphs, logs, the database, and config) to a gzipped tar archive, with a
+manifest recording when it was made and the schema versions of the mine that
+made it. Restore it with mine restore.
+
+The database's write-ahead log is checkpointed first, so the archive holds a
+consistent copy; no other mine command should use the database meanwhile.
+A --db outside ~/.threadmine isn't backed up.
+
+Examples:
+  # Back up before an experiment
+  mine backup --out tm-backup.tar.gz
+
+  # Restore it
+  mine restore tm-backup.tar.gz --force`,
+	RunE: runBackup,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore the ThreadMine home directory from a backup",
+	Long: `Replace ~/.threadmine with the contents of an archive written by
+mine backup. A home directory that isn't empty is only replaced with --force;
+everything in it is removed. The archive is extracted next to the home
+directory first, so a damaged archive leaves it as it was.
+
+Backups made by a mine with a newer database schema can't be restored.
+
+Examples:
+  # Restore into an empty (or missing) home directory
+  mine restore tm-backup.tar.gz
+
+  # Replace the current home directory
+  mine restore tm-backup.tar.gz --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+var (
+	backupOut    string
+	restoreForce bool
+)
+
+// backupResult is a backup manifest with the archive it describes
+type backupResult struct {
+	Archive string `json:"archive"`
+	*cache.BackupManifest
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+
+	backupCmd.Flags().StringVar(&backupOut, "out", "", "Archive to write (default threadmine-backup-<date>-<time>.tar.gz)")
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Replace a home directory that isn't empty")
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	if err := checkBackupFormat(); err != nil {
+		return err
+	}
+
+	out := backupOut
+	if out == "" {
+		out = fmt.Sprintf("threadmine-backup-%s.tar.gz", clock.Now().Format("20060102-150405"))
+	}
+	out, err := utils.ExpandPath(out)
+	if err != nil {
+		return usageErrorf("invalid --out path: %w", err)
+	}
+
+	home, err := cache.CacheDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(home); err != nil {
+		return fmt.Errorf("nothing to back up: %w", err)
+	}
+
+	// Fold the write-ahead log into the database file
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+	if !isUnder(dbPathResolved, home) {
+		fmt.Fprintf(cmd.OutOrStderr(), "Warning: the database at %s is outside %s and isn't backed up\n", dbPathResolved, home)
+	} else if _, err := os.Stat(dbPathResolved); err == nil {
+		database, err := db.Open(dbPathResolved)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		err = database.Checkpoint()
+		database.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	manifest, err := cache.Backup(f, home, cache.BackupManifest{
+		CreatedAt:               clock.Now().UTC(),
+		DatabaseSchemaVersion:   db.SchemaVersion,
+		NormalizedSchemaVersion: normalize.SchemaVersion,
+	}, out)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write backup: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(out)
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Backed up %d files from %s to %s\n", manifest.Files, home, out)
+	return outputBackupResult(&backupResult{Archive: out, BackupManifest: manifest})
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	if err := checkBackupFormat(); err != nil {
+		return err
+	}
+
+	archive, err := utils.ExpandPath(args[0])
+	if err != nil {
+		return usageErrorf("invalid archive path: %w", err)
+	}
+	home, err := cache.CacheDir()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	manifest, err := cache.ReadBackupManifest(f)
+	if err != nil {
+		return err
+	}
+	if manifest.DatabaseSchemaVersion > db.SchemaVersion {
+		return fmt.Errorf("backup has database schema version %d, newer than this mine's %d; upgrade mine to restore it", manifest.DatabaseSchemaVersion, db.SchemaVersion)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	manifest, err = cache.Restore(f, home, restoreForce)
+	if errors.Is(err, cache.ErrHomeNotEmpty) {
+		return fmt.Errorf("%w (use --force to replace it)", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Restored %d files from %s (backed up %s) to %s\n",
+		manifest.Files, archive, manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"), home)
+	return outputBackupResult(&backupResult{Archive: archive, BackupManifest: manifest})
+}
+
+// checkBackupFormat rejects output formats backup and restore can't write
+func checkBackupFormat() error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+		return nil
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+}
+
+// outputBackupResult writes a backup result in the output format
+func outputBackupResult(result *backupResult) error {
+	switch outputFormat {
+	case "json":
+		return OutputJSON(result)
+	case "jsonl", "ndjson":
+		return OutputJSONL([]*backupResult{result})
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "ARCHIVE\tCREATED\tFILES\tBYTES\tDB SCHEMA\tNORMALIZED SCHEMA\n")
+		fmt.Fprintf(w, "-------\t-------\t-----\t-----\t---------\t-----------------\n")
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", result.Archive, result.CreatedAt.Format("2006-01-02 15:04"),
+			result.Files, result.Bytes, result.DatabaseSchemaVersion, result.NormalizedSchemaVersion)
+		return nil
+	}
+}
+
+// isUnder reports whether path is inside dir
+func isUnder(path, dir string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
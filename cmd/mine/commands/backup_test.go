@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	requireFTS5(t)
+	t.Setenv("HOME", t.TempDir())
+	home := filepath.Join(os.Getenv("HOME"), ".threadmine")
+
+	database, err := db.Open(db.DefaultDBPath())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rootID := "msg_slack_C1_1.0"
+	if err := database.SaveMessage(&db.Message{ID: rootID, SourceType: "slack", SourceID: "C1_1.0", Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		AuthorID: "user_slack_U1", ChannelID: "chan_slack_C1", Content: "How do I deploy?", ThreadID: &rootID, IsThreadRoot: true}); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+	database.Close()
+	if err := os.WriteFile(filepath.Join(home, "config"), []byte("[select]\nlimit = 10\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "tm-backup.tar.gz")
+	err, out := execute(t, "backup", "--out", archive)
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	var result struct {
+		Archive               string `json:"archive"`
+		Files                 int    `json:"files"`
+		DatabaseSchemaVersion int    `json:"database_schema_version"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	if result.Archive != archive || result.Files < 2 || result.DatabaseSchemaVersion != db.SchemaVersion {
+		t.Errorf("unexpected backup result: %s", out)
+	}
+
+	// Lose the data
+	if err := os.RemoveAll(home); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, "store"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err, _ := execute(t, "restore", archive); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected restore into a non-empty home to need --force, got %v", err)
+	}
+	if err, _ := execute(t, "restore", archive, "--force"); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, "store")); !os.IsNotExist(err) {
+		t.Errorf("expected restore to replace the home directory, store is still there (%v)", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(home, "config")); err != nil || !strings.Contains(string(data), "limit = 10") {
+		t.Errorf("config not restored: %q, %v", data, err)
+	}
+	err, out = execute(t, "select")
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if !strings.Contains(out, rootID) {
+		t.Errorf("expected the restored database to hold %s, got %s", rootID, out)
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/cache"
+	"github.com/solvaholic/threadmine/internal/clock"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect the raw API response cache",
+	Long: `Inspect the raw API responses cached under ~/.threadmine/raw.
+
+Fetches reuse cached responses while they are fresh: Slack message files
+for a day, GitHub issue and pull request indexes for an hour.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("please specify a subcommand: status")
+	},
+}
+
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show cache freshness per channel and repo",
+	Long: `Show, for each cached Slack channel and GitHub repo, when it was last
+fetched, how old that is, whether it is within the cache TTL, and how many
+messages are cached.
+
+Examples:
+  # Show cache status as JSON
+  mine cache status
+
+  # Show cache status as a table
+  mine cache status --format table`,
+	RunE: runCacheStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatusCmd)
+}
+
+func runCacheStatus(cmd *cobra.Command, args []string) error {
+	statuses, err := cache.Status(clock.Now())
+	if err != nil {
+		return fmt.Errorf("failed to read cache status: %w", err)
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(statuses)
+	case "jsonl", "ndjson":
+		return OutputJSONL(statuses)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "SOURCE\tCONTAINER\tFETCHED\tAGE\tFRESH\tMESSAGES\n")
+		fmt.Fprintf(w, "------\t---------\t-------\t---\t-----\t--------\n")
+		for _, status := range statuses {
+			container := status.Container
+			if status.Workspace != "" {
+				container = status.Workspace + "/" + status.Container
+			}
+			fresh := "no"
+			if status.Fresh {
+				fresh = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
+				status.Source,
+				container,
+				status.FetchedAt.Local().Format("2006-01-02 15:04"),
+				(time.Duration(status.AgeSeconds) * time.Second).String(),
+				fresh,
+				status.Messages,
+			)
+		}
+		return nil
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var channelsCmd = &cobra.Command{
+	Use:   "channels",
+	Short: "Summarize channels",
+	Long:  `Summarize the channels (GitHub repositories) of stored messages.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("please specify a subcommand: activity")
+	},
+}
+
+var channelsActivityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Show which channels are busiest and whether they get answers",
+	Long: `Report, for each channel or GitHub repository, its message count, distinct
+participants, questions, threads, the share of threads resolved, and the
+average depth of its threads, busiest channels first.
+
+Questions come from enrichment (see mine enrich), resolved threads and depths
+from the thread summaries fetch records. A message outside any thread counts
+as a thread of its own.
+
+Examples:
+  # Busiest channels of the last 30 days
+  mine channels activity --since 30d --format table
+
+  # The ten busiest channels ever
+  mine channels activity --limit 10`,
+	RunE: runChannelsActivity,
+}
+
+var (
+	channelsActivitySince string
+	channelsActivityLimit int
+)
+
+func init() {
+	rootCmd.AddCommand(channelsCmd)
+	channelsCmd.AddCommand(channelsActivityCmd)
+
+	channelsActivityCmd.Flags().StringVar(&channelsActivitySince, "since", "", "Only count messages since this date (YYYY-MM-DD, RFC3339, or relative like 30d)")
+	channelsActivityCmd.Flags().IntVar(&channelsActivityLimit, "limit", 0, "Maximum number of channels (0 for all)")
+}
+
+func runChannelsActivity(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+
+	opts := db.SelectMessagesOptions{Limit: channelsActivityLimit}
+	if channelsActivitySince != "" {
+		since, err := utils.ParseSinceDate(channelsActivitySince)
+		if err != nil {
+			return usageErrorf("invalid --since value: %w", err)
+		}
+		opts.Since = &since
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	activity, err := st.ChannelActivity(opts)
+	if err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(activity)
+	case "jsonl", "ndjson":
+		return OutputJSONL(activity)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "CHANNEL\tMESSAGES\tPARTICIPANTS\tQUESTIONS\tTHREADS\tRESOLVED\tAVG DEPTH\n")
+		fmt.Fprintf(w, "-------\t--------\t------------\t---------\t-------\t--------\t---------\n")
+		for _, a := range activity {
+			name := a.ChannelName
+			if name == "" {
+				name = a.ChannelID
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.0f%%\t%.1f\n", name, a.Messages, a.Participants, a.Questions,
+				a.Threads, a.ResolutionRate*100, a.AvgThreadDepth)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var databaseCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Maintain the ThreadMine database",
+	Long:  `Maintain the SQLite database at --db (default ~/.threadmine/threadmine.db).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("please specify a subcommand: vacuum")
+	},
+}
+
+var databaseVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Shrink the database file",
+	Long: `Rebuild the database file without the free pages that deletes and updates
+leave behind, and report its size before and after. SQLite never shrinks the
+file on its own. The write-ahead log is checkpointed into the database first,
+and sizes include it.
+
+Vacuuming needs free disk space about the size of the database, and no other
+mine command should use the database meanwhile.
+
+Examples:
+  # Shrink the database
+  mine db vacuum --format table
+
+  # Also refresh the query planner's statistics
+  mine db vacuum --analyze`,
+	RunE: runDatabaseVacuum,
+}
+
+var databaseVacuumAnalyze bool
+
+func init() {
+	rootCmd.AddCommand(databaseCmd)
+	databaseCmd.AddCommand(databaseVacuumCmd)
+
+	databaseVacuumCmd.Flags().BoolVar(&databaseVacuumAnalyze, "analyze", false, "Also run ANALYZE and PRAGMA optimize to refresh query planner statistics")
+}
+
+func runDatabaseVacuum(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	result, err := database.Vacuum(databaseVacuumAnalyze)
+	if err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(result)
+	case "jsonl", "ndjson":
+		return OutputJSONL([]*db.VacuumResult{result})
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "DATABASE\tBEFORE\tAFTER\tRECLAIMED\n")
+		fmt.Fprintf(w, "--------\t------\t-----\t---------\n")
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", result.Path, result.SizeBefore, result.SizeAfter, result.Reclaimed())
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/github"
+)
+
+// githubCommitLister lists the commits of a pull request
+type githubCommitLister interface {
+	GetPullRequestCommits(ctx context.Context, prNumber int) ([]github.PullRequestCommit, error)
+}
+
+// githubEmailResolver resolves GitHub logins to email addresses from the git
+// author emails of their pull request commits, since GitHub's user objects
+// usually omit the email. Emails already stored for a user are reused, and
+// each login is looked up at most once per run, found or not.
+type githubEmailResolver struct {
+	database *db.DB
+	emails   map[string]string // Lowercased login -> email, "" if none was found
+}
+
+func newGitHubEmailResolver(database *db.DB) *githubEmailResolver {
+	return &githubEmailResolver{database: database, emails: make(map[string]string)}
+}
+
+// prAuthorEmail returns the email of login, the author of pull request
+// prNumber, looking it up in the PR's commits with lister if it isn't known
+// yet. It returns "" if the commits don't have a usable email.
+func (r *githubEmailResolver) prAuthorEmail(ctx context.Context, lister githubCommitLister, login string, prNumber int) (string, error) {
+	key := strings.ToLower(login)
+	if email, ok := r.emails[key]; ok {
+		return email, nil
+	}
+
+	user, err := r.database.GetUser("user_github_" + login)
+	if err != nil {
+		return "", err
+	}
+	if user != nil && user.Email != nil && *user.Email != "" {
+		r.emails[key] = *user.Email
+		return *user.Email, nil
+	}
+
+	commits, err := lister.GetPullRequestCommits(ctx, prNumber)
+	if err != nil {
+		return "", err
+	}
+	email := github.CommitAuthorEmail(commits, login)
+	r.emails[key] = email
+	return email, nil
+}
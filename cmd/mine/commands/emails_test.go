@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/github"
+)
+
+// fakePRCommits serves pull request commits from fixed JSON and counts the calls
+type fakePRCommits struct {
+	commits map[int]string
+	calls   int
+}
+
+func (f *fakePRCommits) GetPullRequestCommits(ctx context.Context, prNumber int) ([]github.PullRequestCommit, error) {
+	f.calls++
+	var commits []github.PullRequestCommit
+	if err := json.Unmarshal([]byte(f.commits[prNumber]), &commits); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+func TestGitHubEmailResolver(t *testing.T) {
+	requireFTS5(t)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	lister := &fakePRCommits{commits: map[int]string{
+		1: `[{"sha": "a1", "commit": {"author": {"email": "hubot@example.com"}}, "author": {"login": "hubot"}}]`,
+		2: `[{"sha": "b2", "commit": {"author": {"email": "1+octocat@users.noreply.github.com"}}, "author": {"login": "octocat"}}]`,
+		3: `[{"sha": "c3", "commit": {"author": {"email": "octocat@example.com"}}, "author": {"login": "octocat"}}]`,
+	}}
+	resolver := newGitHubEmailResolver(database)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		login     string
+		pr        int
+		want      string
+		wantCalls int
+	}{
+		{"resolvable commit email", "hubot", 1, "hubot@example.com", 1},
+		{"resolved login is cached", "Hubot", 3, "hubot@example.com", 1},
+		{"only a noreply email", "octocat", 2, "", 2},
+		{"unresolved login is cached", "octocat", 3, "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.prAuthorEmail(ctx, lister, tt.login, tt.pr)
+			if err != nil {
+				t.Fatalf("prAuthorEmail() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("prAuthorEmail(%s, #%d) = %q, want %q", tt.login, tt.pr, got, tt.want)
+			}
+			if lister.calls != tt.wantCalls {
+				t.Errorf("expected %d commit lookups, got %d", tt.wantCalls, lister.calls)
+			}
+		})
+	}
+
+	// A stored email is reused across runs
+	login, email := "monalisa", "mona@example.com"
+	now := time.Now()
+	if err := database.SaveUser(&db.User{ID: "user_github_" + login, SourceType: "github", SourceID: login,
+		DisplayName: &login, Email: &email, FetchedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	lister.calls = 0
+	got, err := newGitHubEmailResolver(database).prAuthorEmail(ctx, lister, login, 4)
+	if err != nil || got != email || lister.calls != 0 {
+		t.Errorf("prAuthorEmail(stored) = %q, %v after %d lookups; want %q without lookups", got, err, lister.calls, email)
+	}
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var enrichCmd = &cobra.Command{
+	Use:   "enrich",
+	Short: "Recompute enrichments of stored messages",
+	Long: `Compute the enrichments of stored messages (is_question, has_code,
+has_links, has_quotes, is_code_only, is_link_only, and character and word
+counts) and save them, without calling any API.
+
+Fetch enriches the messages it stores; use enrich to backfill messages stored
+before enrichments existed, or to apply improved enrichment rules, so filters
+like select --has-code work over all stored messages.
+
+Examples:
+  # Recompute enrichments of every stored message
+  mine enrich
+
+  # Only Slack messages from the last month
+  mine enrich --source slack --since 30d
+
+  # Only messages that have no enrichments yet
+  mine enrich --missing`,
+	RunE: runEnrich,
+}
+
+var (
+	enrichSource  string
+	enrichSince   string
+	enrichMissing bool
+)
+
+func init() {
+	rootCmd.AddCommand(enrichCmd)
+
+	enrichCmd.Flags().StringVar(&enrichSource, "source", "", "Only messages from this source type: slack, github")
+	enrichCmd.Flags().StringVar(&enrichSince, "since", "", "Only messages since this date (YYYY-MM-DD, RFC3339, or relative like 7d)")
+	enrichCmd.Flags().BoolVar(&enrichMissing, "missing", false, "Only messages that have no enrichments yet")
+}
+
+func runEnrich(cmd *cobra.Command, args []string) error {
+	var opts db.SelectMessagesOptions
+	if enrichSource != "" {
+		opts.SourceType = &enrichSource
+	}
+	if enrichSince != "" {
+		since, err := utils.ParseSinceDate(enrichSince)
+		if err != nil {
+			return usageErrorf("invalid --since value: %w", err)
+		}
+		opts.Since = &since
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	messages, err := st.SelectMessages(opts)
+	if err != nil {
+		return fmt.Errorf("failed to select messages: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStderr(), "Enriching %d messages...\n", len(messages))
+
+	enriched, skipped, failed := 0, 0, 0
+	for _, msg := range messages {
+		if enrichMissing {
+			existing, err := st.LoadEnrichment(msg.ID)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to load enrichment of %s: %v\n", msg.ID, err)
+				failed++
+				continue
+			}
+			if existing != nil {
+				skipped++
+				continue
+			}
+		}
+
+		if err := st.SaveEnrichment(messageEnrichment(msg)); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save enrichment of %s: %v\n", msg.ID, err)
+			failed++
+			continue
+		}
+		enriched++
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "\nCompleted!\n")
+	fmt.Fprintf(cmd.OutOrStderr(), "Messages enriched: %d\n", enriched)
+	if skipped > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Messages already enriched: %d\n", skipped)
+	}
+	if failed > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Messages that failed: %d\n", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestEnrich(t *testing.T) {
+	requireFTS5(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// Messages stored without enrichments, as before enrichment existed
+	messages := []*db.Message{
+		{ID: "msg_slack_C1_1", SourceType: "slack", Content: "How do I restart the worker?"},
+		{ID: "msg_slack_C1_2", SourceType: "slack", Content: "Run this:\n```\nsystemctl restart worker\n```",
+			CodeBlocks: []db.CodeBlock{{Code: "systemctl restart worker"}}},
+		{ID: "msg_github_acme_widgets_1", SourceType: "github", Content: "See https://example.com/docs",
+			URLs: []string{"https://example.com/docs"}},
+		{ID: "msg_github_acme_widgets_2", SourceType: "github", Content: "> quoted\nagreed"},
+	}
+	for i, msg := range messages {
+		msg.SourceID = msg.ID
+		msg.AuthorID = "user_a"
+		msg.ChannelID = "chan_" + msg.SourceType + "_x"
+		msg.Timestamp = time.Date(2024, 1, 15, 10, i, 0, 0, time.UTC)
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "enrich"); err != nil {
+		t.Fatalf("enrich failed: %v", err)
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"--is-question", []string{"msg_slack_C1_1"}},
+		{"--has-code", []string{"msg_slack_C1_2"}},
+		{"--has-links", []string{"msg_github_acme_widgets_1"}},
+		{"--has-quotes", []string{"msg_github_acme_widgets_2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			err, out := execute(t, "--db", dbFile, "--format", "json", "select", tt.filter)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+			if got := selectedIDs(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("select %s = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+
+	enrich, err := database.GetEnrichment("msg_slack_C1_1")
+	if err != nil {
+		t.Fatalf("GetEnrichment failed: %v", err)
+	}
+	if enrich.WordCount != 6 {
+		t.Errorf("expected word count 6, got %d", enrich.WordCount)
+	}
+}
+
+func TestEnrich_Filters(t *testing.T) {
+	requireFTS5(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, msg := range []*db.Message{
+		{ID: "msg_slack_C1_1", SourceType: "slack", Content: "Is it down?"},
+		{ID: "msg_github_acme_widgets_1", SourceType: "github", Content: "Is it fixed?"},
+		{ID: "msg_github_acme_widgets_2", SourceType: "github", Content: "Why does it fail?"},
+	} {
+		msg.SourceID = msg.ID
+		msg.AuthorID = "user_a"
+		msg.ChannelID = "chan_" + msg.SourceType + "_x"
+		msg.Timestamp = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	// An existing enrichment --missing must leave alone
+	if err := database.SaveEnrichment(&db.Enrichment{MessageID: "msg_github_acme_widgets_2", WordCount: 99}); err != nil {
+		t.Fatalf("SaveEnrichment failed: %v", err)
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "enrich", "--source", "github", "--missing"); err != nil {
+		t.Fatalf("enrich failed: %v", err)
+	}
+
+	if enrich, err := database.GetEnrichment("msg_github_acme_widgets_1"); err != nil || !enrich.IsQuestion {
+		t.Errorf("expected msg_github_acme_widgets_1 to be enriched as a question, got %+v, %v", enrich, err)
+	}
+	if enrich, err := database.GetEnrichment("msg_github_acme_widgets_2"); err != nil || enrich.WordCount != 99 {
+		t.Errorf("expected msg_github_acme_widgets_2 to keep its enrichment, got %+v, %v", enrich, err)
+	}
+	if _, err := database.GetEnrichment("msg_slack_C1_1"); err == nil {
+		t.Error("expected msg_slack_C1_1 not to be enriched with --source github")
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "enrich", "--since", "yesterday-ish"); ExitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error for an invalid --since, got %v", err)
+	}
+}
+
+// selectedIDs returns the sorted IDs of the messages in select's JSON output
+func selectedIDs(t *testing.T, out string) []string {
+	t.Helper()
+
+	var messages []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(out), &messages); err != nil {
+		t.Fatalf("failed to parse select output %q: %v", out, err)
+	}
+	ids := make([]string, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/clock"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/spf13/cobra"
+)
+
+// slackUserLookup fetches Slack profiles for users whose names aren't stored yet
+type slackUserLookup interface {
+	GetUserInfo(ctx context.Context, userID string) (*slack.UserInfo, error)
+}
+
+// upsertEntities saves a user for every author and a channel for every channel
+// of the recorded messages, so select can resolve them by name. Users and
+// channels that are already stored with a name are left alone. Slack users are
+// looked up with lookup (if not nil); Slack channels belong to workspaceID.
+// Each batch is written in one transaction.
+func upsertEntities(cmd *cobra.Command, database *db.DB, recorder *threadRecorder, workspaceID string, lookup slackUserLookup) {
+	var users []*db.User
+	for _, id := range recorder.authors {
+		user, err := entityUser(database, id, lookup)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to look up user %s: %v\n", id, err)
+		}
+		if user != nil {
+			users = append(users, user)
+		}
+	}
+	if len(users) > 0 {
+		if err := database.SaveUsers(users); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save users: %v\n", err)
+		}
+	}
+
+	var channels []*db.Channel
+	for _, id := range recorder.channels {
+		channel, err := entityChannel(database, id, workspaceID)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to look up channel %s: %v\n", id, err)
+			continue
+		}
+		if channel != nil {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) > 0 {
+		if err := database.SaveChannels(channels); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save channels: %v\n", err)
+		}
+	}
+}
+
+// entityUser returns the user to save for a message author ID
+// (user_<source>_<source ID>), or nil if it's already stored with a name.
+// A failed Slack lookup still returns the user, without a name.
+func entityUser(database *db.DB, id string, lookup slackUserLookup) (*db.User, error) {
+	source, sourceID, ok := strings.Cut(strings.TrimPrefix(id, "user_"), "_")
+	if !ok || sourceID == "" {
+		return nil, nil
+	}
+
+	existing, err := database.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.DisplayName != nil && *existing.DisplayName != "" {
+		return nil, nil
+	}
+
+	now := clock.Now()
+	user := &db.User{ID: id, SourceType: source, SourceID: sourceID, FetchedAt: now, UpdatedAt: now}
+	switch source {
+	case "github":
+		// Logins are the names people use on GitHub
+		user.DisplayName = &sourceID
+	case "slack":
+		if lookup == nil {
+			return user, nil
+		}
+		info, err := lookup.GetUserInfo(context.Background(), sourceID)
+		if err != nil {
+			return user, err
+		}
+		user.DisplayName = optionalString(info.Name)
+		user.RealName = optionalString(info.RealName)
+		user.Email = optionalString(info.Profile.Email)
+		user.AvatarURL = optionalString(info.Profile.Image192)
+	}
+	return user, nil
+}
+
+// entityChannel returns the channel to save for a message channel ID
+// (chan_<source>_<source ID>), or nil if it's already stored
+func entityChannel(database *db.DB, id, workspaceID string) (*db.Channel, error) {
+	source, sourceID, ok := strings.Cut(strings.TrimPrefix(id, "chan_"), "_")
+	if !ok || sourceID == "" {
+		return nil, nil
+	}
+
+	existing, err := database.GetChannel(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, nil
+	}
+
+	now := clock.Now()
+	channel := &db.Channel{ID: id, SourceType: source, SourceID: sourceID, Name: sourceID, FetchedAt: now, UpdatedAt: now}
+	switch source {
+	case "github":
+		// GitHub owners can't contain underscores, so the first one ends the owner
+		owner, repo, ok := strings.Cut(sourceID, "_")
+		if !ok {
+			return nil, nil
+		}
+		repoName := owner + "/" + repo
+		orgID := fmt.Sprintf("org_github_%s", owner)
+		chanType := "repository"
+		channel.SourceID = repoName
+		channel.Name = repoName
+		channel.DisplayName = &repoName
+		channel.Type = &chanType
+		channel.WorkspaceID = &orgID
+		channel.ParentSpace = &orgID
+	case "slack":
+		chanType := "channel"
+		channel.Type = &chanType
+		if workspaceID != "" {
+			channel.WorkspaceID = &workspaceID
+			channel.ParentSpace = &workspaceID
+		}
+	}
+	return channel, nil
+}
+
+// optionalString returns a pointer to s, or nil if s is empty
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// saveAuthenticatedUser records the workspace a fetch authenticated in, with
+// the user it authenticated as (the "me" of select --mentions-me)
+func saveAuthenticatedUser(cmd *cobra.Command, database *db.DB, workspace *db.Workspace) {
+	if err := database.SaveWorkspace(workspace); err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save workspace %s: %v\n", workspace.ID, err)
+	}
+}
+
+// mentionIDs returns the IDs (user_<source>_<source ID>) of the users
+// mentioned in content from sourceType, once each, in order of first mention.
+// They're taken from the content as fetched, before
+// normalize.resolve_mentions_in_content rewrites it.
+func mentionIDs(sourceType, content string) []string {
+	ids := []string{}
+	seen := make(map[string]bool)
+	for _, id := range contentMentionIDs(&db.Message{SourceType: sourceType, Content: content}) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// resolveContentMentions rewrites user mentions in the content of the recorded
+// messages to display names, when normalize.resolve_mentions_in_content is set.
+// Names come from stored users; Slack users who aren't stored with a name are
+// looked up with lookup (if not nil) and saved. Unresolvable mentions are kept.
+func resolveContentMentions(cmd *cobra.Command, database *db.DB, recorder *threadRecorder, lookup slackUserLookup) {
+	if !normalize.ResolveMentionsInContent {
+		return
+	}
+
+	names := make(map[string]string) // user ID -> display name ("" if unresolvable)
+	var lookedUp []*db.User
+	resolved := 0
+	for _, threadID := range recorder.order {
+		id := threadID
+		messages, err := recorder.Store.SelectMessages(db.SelectMessagesOptions{ThreadID: &id})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to load thread %s: %v\n", threadID, err)
+			continue
+		}
+
+		for _, msg := range messages {
+			tokens := normalize.MentionTokens(msg.Content)
+			if len(tokens) == 0 {
+				continue
+			}
+			tokenNames := make(map[string]string, len(tokens))
+			for _, token := range tokens {
+				userID := fmt.Sprintf("user_%s_%s", msg.SourceType, token)
+				name, ok := names[userID]
+				if !ok {
+					var user *db.User
+					name, user, err = mentionName(database, userID, lookup)
+					if err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to look up user %s: %v\n", userID, err)
+					}
+					if user != nil {
+						lookedUp = append(lookedUp, user)
+					}
+					names[userID] = name
+				}
+				tokenNames[token] = name
+			}
+
+			content := normalize.ResolveMentions(msg.Content, tokenNames)
+			if content == msg.Content {
+				continue
+			}
+			msg.Content = content
+			if err := recorder.Store.SaveMessage(msg); err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save message %s: %v\n", msg.ID, err)
+				continue
+			}
+			resolved++
+		}
+	}
+
+	if len(lookedUp) > 0 {
+		if err := database.SaveUsers(lookedUp); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save users: %v\n", err)
+		}
+	}
+	if resolved > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Messages with resolved mentions: %d\n", resolved)
+	}
+}
+
+// mentionName returns the display name of a mentioned user, or "" if it's
+// unknown. A Slack user fetched with lookup is returned too, to be saved.
+func mentionName(database *db.DB, userID string, lookup slackUserLookup) (string, *db.User, error) {
+	user, err := entityUser(database, userID, lookup)
+	if err != nil {
+		return "", nil, err
+	}
+	if user == nil {
+		// Already stored with a name
+		existing, err := database.GetUser(userID)
+		if err != nil || existing == nil || existing.DisplayName == nil {
+			return "", nil, err
+		}
+		return *existing.DisplayName, nil, nil
+	}
+	if user.SourceType != "slack" || user.DisplayName == nil {
+		// GitHub logins are only names for users we've stored
+		return "", nil, nil
+	}
+	return *user.DisplayName, user, nil
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var entitiesCmd = &cobra.Command{
+	Use:   "entities",
+	Short: "Query entities extracted from messages",
+	Long:  `Query the entities (assignees, issue references, versions, ...) stored for messages.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("please specify a subcommand: list")
+	},
+}
+
+var entitiesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List extracted entities and the messages they're in",
+	Long: `List the entities stored for messages, with the message each is in, ordered
+by type and value. Select the messages themselves with mine select --has-entity.
+
+Examples:
+  # Every issue reference
+  mine entities list --type issue_ref --format table
+
+  # Where issue 123 is referenced
+  mine entities list --type issue_ref --value 123
+
+  # Messages referencing v1.2.3
+  mine select --has-entity version=v1.2.3`,
+	RunE: runEntitiesList,
+}
+
+var (
+	entitiesListType  string
+	entitiesListValue string
+	entitiesListLimit int
+)
+
+func init() {
+	rootCmd.AddCommand(entitiesCmd)
+	entitiesCmd.AddCommand(entitiesListCmd)
+
+	entitiesListCmd.Flags().StringVar(&entitiesListType, "type", "", "Only entities of this type, e.g. issue_ref, version, assignee")
+	entitiesListCmd.Flags().StringVar(&entitiesListValue, "value", "", "Only entities with this value")
+	entitiesListCmd.Flags().IntVar(&entitiesListLimit, "limit", 100, "Maximum number of entities (0 for all)")
+}
+
+func runEntitiesList(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	entities, err := database.ListEntities(db.ListEntitiesOptions{
+		Type:  entitiesListType,
+		Value: entitiesListValue,
+		Limit: entitiesListLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(entities)
+	case "jsonl", "ndjson":
+		return OutputJSONL(entities)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "TYPE\tVALUE\tMESSAGE\n")
+		fmt.Fprintf(w, "----\t-----\t-------\n")
+		for _, entity := range entities {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entity.Type, entity.Value, entity.MessageID)
+		}
+		return nil
+	}
+}
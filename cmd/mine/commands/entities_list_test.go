@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestEntities(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	entities := map[string][]db.Entity{
+		"msg_github_acme_widgets_1": {{Type: "issue_ref", Value: "123"}, {Type: "version", Value: "v1.2.3"}},
+		"msg_github_acme_widgets_2": {{Type: "issue_ref", Value: "123"}, {Type: "sha", Value: "a1b2c3d"}},
+		"msg_slack_C1_1":            {{Type: "version", Value: "v1.2.2"}},
+		"msg_slack_C1_2":            nil,
+	}
+	i := 0
+	for id, list := range entities {
+		msg := &db.Message{ID: id, SourceID: id, AuthorID: "user_a", ChannelID: "chan_x", Content: "content",
+			Timestamp: time.Date(2024, 1, 15, 10, i, 0, 0, time.UTC)}
+		i++
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+		for _, entity := range list {
+			entity.MessageID = id
+			if err := database.SaveEntity(&entity); err != nil {
+				t.Fatalf("SaveEntity failed: %v", err)
+			}
+		}
+	}
+
+	t.Run("list", func(t *testing.T) {
+		err, out := execute(t, "--db", dbFile, "--format", "json", "entities", "list", "--type", "issue_ref", "--value", "123")
+		if err != nil {
+			t.Fatalf("entities list failed: %v", err)
+		}
+		var listed []db.Entity
+		if err := json.Unmarshal([]byte(out), &listed); err != nil {
+			t.Fatalf("invalid output %q: %v", out, err)
+		}
+		var ids []string
+		for _, e := range listed {
+			if e.Type != "issue_ref" || e.Value != "123" {
+				t.Errorf("listed %+v", e)
+			}
+			ids = append(ids, e.MessageID)
+		}
+		if want := []string{"msg_github_acme_widgets_1", "msg_github_acme_widgets_2"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("listed messages %v, want %v", ids, want)
+		}
+	})
+
+	tests := []struct {
+		filters []string
+		want    []string
+	}{
+		{[]string{"version"}, []string{"msg_github_acme_widgets_1", "msg_slack_C1_1"}},
+		{[]string{"version=v1.2.3"}, []string{"msg_github_acme_widgets_1"}},
+		{[]string{"issue_ref=123", "sha"}, []string{"msg_github_acme_widgets_2"}},
+		{[]string{"url"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run("select "+tt.filters[0], func(t *testing.T) {
+			args := []string{"--db", dbFile, "--format", "json", "select"}
+			for _, f := range tt.filters {
+				args = append(args, "--has-entity", f)
+			}
+			err, out := execute(t, args...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+			if got := selectedIDs(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("select --has-entity %v = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// stubGHFetch installs a fake gh that answers the calls fetch github makes
+// for one issue (#1 in acme/widgets, searched or listed) with one comment,
+// one commit comment, and, when fetched by number, a pull request (#2) with
+// one review and one commit. #3 doesn't exist. It returns the directory of
+// the JSON files gh answers with.
+func stubGHFetch(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"rate_limit.json": `{"resources": {"core": {"limit": 5000, "remaining": 5000}, "search": {"limit": 30, "remaining": 30}}}`,
+		"search.json": `{"total_count": 1, "items": [{"number": 1, "title": "Widgets crash", "body": "They crash on start",
+			"state": "open", "user": {"login": "octocat"}, "created_at": "2024-01-15T10:00:00Z",
+			"updated_at": "2024-01-15T11:00:00Z", "repository_url": "https://api.github.com/repos/acme/widgets"}]}`,
+		"comments.json": `[{"id": 100, "body": "Fixed by upgrading", "user": {"login": "hubot"},
+			"created_at": "2024-01-15T11:00:00Z", "updated_at": "2024-01-15T11:00:00Z"}]`,
+		"repo_comments.json": `[{"id": 100, "body": "Fixed by upgrading", "user": {"login": "hubot"},
+			"created_at": "2024-01-15T11:00:00Z", "updated_at": "2024-01-15T11:00:00Z",
+			"issue_url": "https://api.github.com/repos/acme/widgets/issues/1"}]`,
+		"issue.json": `{"number": 1, "title": "Widgets crash", "body": "They crash on start", "state": "open",
+			"user": {"login": "octocat"}, "created_at": "2024-01-15T10:00:00Z", "updated_at": "2024-01-15T11:00:00Z"}`,
+		"pr.json": `{"number": 2, "title": "Fix the crash", "body": "Fixes #1", "state": "open",
+			"user": {"login": "hubot"}, "created_at": "2024-01-16T10:00:00Z", "updated_at": "2024-01-16T11:00:00Z",
+			"pull_request": {"url": "https://api.github.com/repos/acme/widgets/pulls/2"}}`,
+		"reviews.json": `[{"id": 300, "body": "Looks good", "user": {"login": "octocat"}, "state": "APPROVED",
+			"submitted_at": "2024-01-16T12:00:00Z"}]`,
+		"files.json": `[{"filename": "auth/login.go", "status": "modified", "additions": 10, "deletions": 2},
+			{"filename": "auth/oauth/token.go", "status": "added", "additions": 40, "deletions": 0}]`,
+		"commits.json": `[{"sha": "def456", "commit": {"author": {"name": "Hubot", "email": "hubot@example.com"}},
+			"author": {"login": "hubot"}}]`,
+		"commit_comments.json": `[{"id": 200, "body": "This broke the build", "user": {"login": "octocat"}, "commit_id": "abc123",
+			"created_at": "2024-01-15T12:00:00Z", "updated_at": "2024-01-15T12:00:00Z"}]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	script := `#!/bin/sh
+case "$*" in
+  "auth status") exit 0 ;;
+  "api user --jq .login") echo tester ;;
+  "api rate_limit") cat ` + dir + `/rate_limit.json ;;
+  *search/issues*) cat ` + dir + `/search.json ;;
+  *widgets/issues?state=all*) echo "[$(cat ` + dir + `/issue.json)]" ;;
+  *widgets/issues/1) cat ` + dir + `/issue.json ;;
+  *widgets/issues/2) cat ` + dir + `/pr.json ;;
+  *widgets/issues/3) echo "gh: Not Found (HTTP 404)" >&2; exit 1 ;;
+  *widgets/issues/comments*) cat ` + dir + `/repo_comments.json ;;
+  *issues/1/comments*) cat ` + dir + `/comments.json ;;
+  *issues/2/comments*) echo '[]' ;;
+  *pulls/2/requested_reviewers*) echo '{"users": [{"login": "octocat"}], "teams": []}' ;;
+  *pulls/2/comments*) echo '[]' ;;
+  *pulls/2/reviews*) cat ` + dir + `/reviews.json ;;
+  *pulls/2/files*) cat ` + dir + `/files.json ;;
+  *pulls/2/commits*) cat ` + dir + `/commits.json ;;
+  *widgets/comments*) cat ` + dir + `/commit_comments.json ;;
+  *timeline*) echo '[]' ;;
+  *graphql*) echo '{"data": {"search": {"nodes": []}}}' ;;
+  *) echo "unexpected gh call: $*" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0700); err != nil {
+		t.Fatalf("failed to write stub gh: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("HOME", t.TempDir())
+
+	return dir
+}
+
+func TestFetchGitHub_SelectByName(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "30d"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"author of the issue", []string{"--author", "octocat"}, []string{"msg_github_acme_widgets_1"}},
+		{"author of the comment", []string{"--author", "hubot"}, []string{"msg_github_acme_widgets_1_comment_100"}},
+		{"channel", []string{"--channel", "acme/widgets"}, []string{"msg_github_acme_widgets_1_comment_100", "msg_github_acme_widgets_1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--db", dbFile, "select"}, tt.args...)
+			err, out := execute(t, args...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+
+			var messages []struct {
+				ID string `json:"ID"`
+			}
+			if err := json.Unmarshal([]byte(out), &messages); err != nil {
+				t.Fatalf("select output is not a JSON array: %v\n%s", err, out)
+			}
+			if len(messages) != len(tt.want) {
+				t.Fatalf("expected %d messages, got %d: %s", len(tt.want), len(messages), out)
+			}
+			for i, id := range tt.want {
+				if messages[i].ID != id {
+					t.Errorf("message %d = %s, want %s", i, messages[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+// fakeSlackUsers answers users.info from a map
+type fakeSlackUsers map[string]string
+
+func (f fakeSlackUsers) GetUserInfo(ctx context.Context, userID string) (*slack.UserInfo, error) {
+	name, ok := f[userID]
+	if !ok {
+		return nil, errors.New("user_not_found")
+	}
+	return &slack.UserInfo{ID: userID, Name: name, RealName: name + " Example"}, nil
+}
+
+func TestUpsertEntities_Slack(t *testing.T) {
+	requireFTS5(t)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// Thread replies carry only the user ID, so fetch stores U1 without a name
+	if err := database.SaveUser(&db.User{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1"}); err != nil {
+		t.Fatalf("SaveUser failed: %v", err)
+	}
+
+	recorder := newThreadRecorder(store.NewDBStore(database))
+	for _, msg := range []*db.Message{
+		{ID: "msg_slack_C1_1.0", SourceType: "slack", SourceID: "C1_1.0", Timestamp: time.Now(), AuthorID: "user_slack_U1", ChannelID: "chan_slack_C1"},
+		{ID: "msg_slack_C1_2.0", SourceType: "slack", SourceID: "C1_2.0", Timestamp: time.Now(), AuthorID: "user_slack_U2", ChannelID: "chan_slack_C1"},
+		{ID: "msg_slack_C1_3.0", SourceType: "slack", SourceID: "C1_3.0", Timestamp: time.Now(), AuthorID: "user_slack_U3", ChannelID: "chan_slack_C1"},
+	} {
+		if err := recorder.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(io.Discard)
+	upsertEntities(cmd, database, recorder, "ws_slack_T1", fakeSlackUsers{"U1": "alice", "U2": "bob"})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"alice", "user_slack_U1"},
+		{"bob Example", "user_slack_U2"},
+		{"U3", "user_slack_U3"}, // Lookup failed, still stored by ID
+	}
+	for _, tt := range tests {
+		users, err := database.FindUsersByName(tt.name, false)
+		if err != nil {
+			t.Fatalf("FindUsersByName failed: %v", err)
+		}
+		if len(users) != 1 || users[0].ID != tt.want {
+			t.Errorf("FindUsersByName(%q) = %v, want %s", tt.name, users, tt.want)
+		}
+	}
+
+	channels, err := database.FindChannelsByName("C1", false)
+	if err != nil {
+		t.Fatalf("FindChannelsByName failed: %v", err)
+	}
+	if len(channels) != 1 || channels[0].WorkspaceID == nil || *channels[0].WorkspaceID != "ws_slack_T1" {
+		t.Errorf("expected channel C1 in ws_slack_T1, got %v", channels)
+	}
+}
+
+func TestEntityUserAndChannel_FetchedAt(t *testing.T) {
+	requireFTS5(t)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(clock.Freeze(now))
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	user, err := entityUser(database, "user_github_octocat", nil)
+	if err != nil || user == nil {
+		t.Fatalf("entityUser = %v, %v", user, err)
+	}
+	if !user.FetchedAt.Equal(now) || !user.UpdatedAt.Equal(now) {
+		t.Errorf("user fetched at %v, updated at %v, want %v", user.FetchedAt, user.UpdatedAt, now)
+	}
+
+	channel, err := entityChannel(database, "chan_github_acme_widgets", "")
+	if err != nil || channel == nil {
+		t.Fatalf("entityChannel = %v, %v", channel, err)
+	}
+	if !channel.FetchedAt.Equal(now) || !channel.UpdatedAt.Equal(now) {
+		t.Errorf("channel fetched at %v, updated at %v, want %v", channel.FetchedAt, channel.UpdatedAt, now)
+	}
+}
+
+func TestResolveContentMentions(t *testing.T) {
+	requireFTS5(t)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	name := "Mona Lisa"
+	if err := database.SaveUser(&db.User{ID: "user_github_monalisa", SourceType: "github", SourceID: "monalisa", DisplayName: &name}); err != nil {
+		t.Fatalf("SaveUser failed: %v", err)
+	}
+
+	threadID, issueID := "msg_slack_C1_1.0", "msg_github_acme_widgets_1"
+	recorder := newThreadRecorder(store.NewDBStore(database))
+	for _, msg := range []*db.Message{
+		{ID: threadID, SourceType: "slack", SourceID: "C1_1.0", Timestamp: time.Now(), AuthorID: "user_slack_U1", ChannelID: "chan_slack_C1",
+			Content: "<@U2> can you check? cc <@U9>", ThreadID: &threadID, IsThreadRoot: true},
+		{ID: issueID, SourceType: "github", SourceID: "acme/widgets#1", Timestamp: time.Now(), AuthorID: "user_github_octocat", ChannelID: "chan_github_acme_widgets",
+			Content: "Thanks @monalisa and @stranger", ThreadID: &issueID, IsThreadRoot: true},
+	} {
+		if err := recorder.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(io.Discard)
+	lookup := fakeSlackUsers{"U2": "bob"}
+
+	resolveContentMentions(cmd, database, recorder, lookup)
+	if msg, _ := database.GetMessage(threadID); msg.Content != "<@U2> can you check? cc <@U9>" {
+		t.Errorf("content rewritten while disabled: %q", msg.Content)
+	}
+
+	normalize.ResolveMentionsInContent = true
+	t.Cleanup(func() { normalize.ResolveMentionsInContent = false })
+	resolveContentMentions(cmd, database, recorder, lookup)
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{threadID, "@bob can you check? cc <@U9>"},   // U9 can't be looked up
+		{issueID, "Thanks @Mona Lisa and @stranger"}, // stranger isn't stored
+	}
+	for _, tt := range tests {
+		msg, err := database.GetMessage(tt.id)
+		if err != nil || msg == nil {
+			t.Fatalf("GetMessage(%s) failed: %v", tt.id, err)
+		}
+		if msg.Content != tt.want {
+			t.Errorf("content of %s = %q, want %q", tt.id, msg.Content, tt.want)
+		}
+	}
+
+	// The mentioned Slack user was saved by the lookup
+	if user, err := database.GetUser("user_slack_U2"); err != nil || user == nil || user.DisplayName == nil || *user.DisplayName != "bob" {
+		t.Errorf("expected user_slack_U2 saved as bob, got %+v (%v)", user, err)
+	}
+}
+
+func TestMentionIDs(t *testing.T) {
+	tests := []struct {
+		source  string
+		content string
+		want    []string
+	}{
+		{"slack", "<@U123> can you look? cc <@U456|bob> <@U123>", []string{"user_slack_U123", "user_slack_U456"}},
+		{"slack", "email me@example.com", []string{}},
+		{"github", "@octocat see #12, cc @hubot and @octocat", []string{"user_github_octocat", "user_github_hubot"}},
+		{"github", "mail octocat@example.com", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := mentionIDs(tt.source, tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mentionIDs(%q, %q) = %v, want %v", tt.source, tt.content, got, tt.want)
+		}
+	}
+}
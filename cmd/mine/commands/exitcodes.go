@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/solvaholic/threadmine/internal/github"
+	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes returned by mine, so scripts can tell failures apart
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitNotAuthorized = 2
+	ExitRateLimited   = 3
+	ExitUsage         = 64 // EX_USAGE from sysexits.h
+)
+
+// usageError marks an error caused by invalid command-line usage
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// usageErrorf formats an error for an invalid flag or argument
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
+
+var markUsageErrors sync.Once
+
+// markUsage makes cobra's flag parsing and argument validation errors usage errors
+func markUsage(cmd *cobra.Command) {
+	if !cmd.HasParent() {
+		cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+			return &usageError{err: err}
+		})
+	}
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(c *cobra.Command, args []string) error {
+			if err := validate(c, args); err != nil {
+				return &usageError{err: err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsage(sub)
+	}
+}
+
+// ExitCode maps an error returned by Execute to the process exit code
+func ExitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, github.ErrNotAuthenticated), errors.Is(err, slack.ErrNotAuthenticated):
+		return ExitNotAuthorized
+	case errors.Is(err, github.ErrRateLimited), errors.Is(err, slack.ErrRateLimited):
+		return ExitRateLimited
+	default:
+		return ExitError
+	}
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/github"
+	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// execute runs the CLI with args and returns the error and anything written to stdout
+func execute(t *testing.T, args ...string) (error, string) {
+	t.Helper()
+	t.Cleanup(func() { resetFlags(rootCmd) })
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	rootCmd.SetArgs(args)
+	rootCmd.SetErr(io.Discard)
+	runErr := Execute()
+
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return runErr, string(out)
+}
+
+// requireFTS5 skips tests that open a database when SQLite lacks FTS5
+func requireFTS5(t *testing.T) {
+	t.Helper()
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "fts5.db"))
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			t.Skip("SQLite built without FTS5; run tests with -tags fts5")
+		}
+		t.Fatalf("failed to open database: %v", err)
+	}
+	database.Close()
+}
+
+// resetFlags restores every flag to its default so runs don't leak into each other
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestExitCode_CommandLayer(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+
+	tests := []struct {
+		name    string
+		args    []string
+		needsDB bool
+		want    int
+	}{
+		{"unknown command", []string{"frobnicate"}, false, ExitUsage},
+		{"unknown flag", []string{"select", "--bogus"}, false, ExitUsage},
+		{"bad flag value", []string{"select", "--limit", "lots"}, false, ExitUsage},
+		{"wrong argument count", []string{"explain"}, false, ExitUsage},
+		{"invalid --since", []string{"--db", dbFile, "select", "--since", "yesterday-ish"}, true, ExitUsage},
+		{"unknown format", []string{"--db", dbFile, "--format", "xml", "select"}, true, ExitUsage},
+		{"unknown color mode", []string{"--db", dbFile, "--format", "table", "select", "--color", "rainbow"}, false, ExitUsage},
+		{"unknown channel type", []string{"--db", dbFile, "select", "--channel-type", "ticket"}, true, ExitUsage},
+		{"invalid language", []string{"--db", dbFile, "select", "--lang", "english"}, true, ExitUsage},
+		{"case-sensitive search operator", []string{"--db", dbFile, "select", "--case-sensitive", "--search", "deploy OR release"}, true, ExitUsage},
+		{"conflicting flags", []string{"--db", dbFile, "select", "--redact-content"}, true, ExitUsage},
+		{"conflicting thread states", []string{"--db", dbFile, "select", "--only-open", "--only-resolved"}, true, ExitUsage},
+		{"entity filter without a type", []string{"--db", dbFile, "select", "--has-entity", "=123"}, true, ExitUsage},
+		{"unknown pr state", []string{"--db", dbFile, "select", "--pr-state", "draft"}, true, ExitUsage},
+		{"preview in table output", []string{"--db", dbFile, "--format", "table", "select", "--preview", "80"}, true, ExitUsage},
+		{"negative preview", []string{"--db", dbFile, "select", "--preview", "-1"}, true, ExitUsage},
+		{"threads in graph output", []string{"--db", dbFile, "--format", "graph", "select", "--threads-only"}, true, ExitUsage},
+		{"vacuum in graph output", []string{"--db", dbFile, "--format", "graph", "db", "vacuum"}, false, ExitUsage},
+		{"channel activity with a bad since", []string{"--db", dbFile, "channels", "activity", "--since", "soon"}, false, ExitUsage},
+		{"threads without a thread or root", []string{"--db", dbFile, "threads"}, false, ExitUsage},
+		{"threads with both a thread and a root", []string{"--db", dbFile, "threads", "--thread", "a", "--root", "b"}, false, ExitUsage},
+		{"thread without a message", []string{"--db", dbFile, "report", "thread"}, false, ExitUsage},
+		{"response time with a bad since", []string{"--db", dbFile, "report", "response-time", "--since", "soon"}, false, ExitUsage},
+		{"unknown graph export format", []string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--export-graph", "g.svg", "--export-graph-format", "svg"}, false, ExitUsage},
+		{"graph export without the graph stage", []string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--no-graph", "--export-graph", "g.json"}, false, ExitUsage},
+		{"issues and PRs only", []string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--issues-only", "--prs-only"}, false, ExitUsage},
+		{"issues only with a type", []string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--issues-only", "--type", "pr"}, false, ExitUsage},
+		{"restore without an archive", []string{"--db", dbFile, "restore"}, false, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.needsDB {
+				requireFTS5(t)
+			}
+			err, _ := execute(t, tt.args...)
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode_NoResults(t *testing.T) {
+	requireFTS5(t)
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+
+	err, out := execute(t, "--db", dbFile, "select", "--since", "1d")
+	if got := ExitCode(err); got != ExitOK {
+		t.Fatalf("ExitCode(%v) = %d, want %d", err, got, ExitOK)
+	}
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected an empty JSON array, got %q", out)
+	}
+}
+
+func TestExitCode_ClientErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", errors.New("disk full"), ExitError},
+		{"github auth", fmt.Errorf("GitHub authentication failed: %w", github.ErrNotAuthenticated), ExitNotAuthorized},
+		{"slack auth", fmt.Errorf("Slack authentication failed: %w", &slack.APIError{Code: "invalid_auth"}), ExitNotAuthorized},
+		{"github rate limit", fmt.Errorf("failed to search GitHub: %w", github.ErrRateLimited), ExitRateLimited},
+		{"slack rate limit", &slack.APIError{Code: "ratelimited"}, ExitRateLimited},
+		{"github not found", fmt.Errorf("failed to fetch repository: %w", github.ErrRepoNotFound), ExitError},
+		{"usage", usageErrorf("unknown format: %s", "xml"), ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/solvaholic/threadmine/internal/classify"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <message-id>",
+	Short: "Explain how a message was classified",
+	Long: `Classify a stored message and explain each signal behind its classifications.
+
+The message is classified in the context of its thread, so answers are only
+detected for replies in threads that start with a question.
+
+Examples:
+  # Explain a message's classifications
+  mine explain msg_slack_C123_1700000000.000100
+
+  # Human-readable output
+  mine explain msg_github_owner_repo_42 --format table
+
+  # Hide low-confidence classifications
+  mine explain msg_github_owner_repo_42 --min-confidence 0.6`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+// classificationExplanation is a classification with sentence-form explanations of its signals
+type classificationExplanation struct {
+	classify.Classification
+	Explanations []string `json:"explanations"`
+}
+
+// messageExplanation is the output of mine explain
+type messageExplanation struct {
+	MessageID       string                      `json:"message_id"`
+	Classifications []classificationExplanation `json:"classifications"`
+}
+
+var explainMinConfidence float64
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().Float64Var(&explainMinConfidence, "min-confidence", 0, "Only show classifications with at least this confidence (0-1)")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	messageID := args[0]
+	if explainMinConfidence < 0 || explainMinConfidence > 1 {
+		return usageErrorf("--min-confidence must be between 0 and 1, got %g", explainMinConfidence)
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	msg, err := st.LoadMessage(messageID)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return fmt.Errorf("message not found: %s", messageID)
+	}
+
+	thread, err := loadThread(st, msg)
+	if err != nil {
+		return err
+	}
+
+	normalizedThread := make([]*normalize.NormalizedMessage, 0, len(thread))
+	var target *normalize.NormalizedMessage
+	for _, m := range thread {
+		n := toNormalizedMessage(m)
+		normalizedThread = append(normalizedThread, n)
+		if m.ID == msg.ID {
+			target = n
+		}
+	}
+
+	ctx := classify.BuildThreadContext(normalizedThread, target)
+	ctx.References = classify.ResolveReferences(target, &storeReferenceResolver{database: database, st: st})
+	result := messageExplanation{
+		MessageID:       msg.ID,
+		Classifications: []classificationExplanation{},
+	}
+	for _, c := range classify.FilterByConfidence(classify.ClassifyMessage(target, ctx), explainMinConfidence) {
+		result.Classifications = append(result.Classifications, classificationExplanation{
+			Classification: c,
+			Explanations:   classify.Explain(c),
+		})
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(result)
+	case "jsonl", "ndjson":
+		return OutputJSONL([]messageExplanation{result})
+	case "table":
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Message: %s\n", result.MessageID)
+		if len(result.Classifications) == 0 {
+			fmt.Fprintln(out, "\nNo classifications matched.")
+			return nil
+		}
+		for _, c := range result.Classifications {
+			fmt.Fprintf(out, "\n%s (confidence %.2f)\n", c.Type, c.Confidence)
+			for _, explanation := range c.Explanations {
+				fmt.Fprintf(out, "  - %s\n", explanation)
+			}
+		}
+		return nil
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+}
+
+// loadThread returns the messages in msg's thread ordered by timestamp with the
+// root first. A message outside any thread is returned on its own.
+func loadThread(st store.Store, msg *db.Message) ([]*db.Message, error) {
+	if msg.ThreadID == nil {
+		return []*db.Message{msg}, nil
+	}
+
+	thread, err := st.SelectMessages(db.SelectMessagesOptions{ThreadID: msg.ThreadID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thread: %w", err)
+	}
+
+	// The root may not carry its own thread ID
+	hasRoot := false
+	for _, m := range thread {
+		if m.ID == *msg.ThreadID {
+			hasRoot = true
+			break
+		}
+	}
+	if !hasRoot {
+		root, err := st.LoadMessage(*msg.ThreadID)
+		if err != nil {
+			return nil, err
+		}
+		if root != nil {
+			thread = append(thread, root)
+		}
+	}
+
+	sort.SliceStable(thread, func(i, j int) bool {
+		if thread[i].IsThreadRoot != thread[j].IsThreadRoot {
+			return thread[i].IsThreadRoot
+		}
+		return thread[i].Timestamp.Before(thread[j].Timestamp)
+	})
+
+	if len(thread) == 0 {
+		thread = []*db.Message{msg}
+	}
+	return thread, nil
+}
+
+// toNormalizedMessage converts a stored message to the normalized schema used by the classifiers
+func toNormalizedMessage(msg *db.Message) *normalize.NormalizedMessage {
+	codeBlocks := make([]normalize.CodeBlock, len(msg.CodeBlocks))
+	for i, cb := range msg.CodeBlocks {
+		codeBlocks[i] = normalize.CodeBlock{
+			Language: cb.Language,
+			Code:     cb.Code,
+		}
+	}
+
+	normalized := &normalize.NormalizedMessage{
+		ID:           msg.ID,
+		SourceType:   msg.SourceType,
+		SourceID:     msg.SourceID,
+		Timestamp:    msg.Timestamp,
+		Author:       &normalize.User{ID: msg.AuthorID, SourceType: msg.SourceType},
+		Content:      msg.Content,
+		IsThreadRoot: msg.IsThreadRoot,
+		Mentions:     msg.Mentions,
+		URLs:         msg.URLs,
+		CodeBlocks:   codeBlocks,
+	}
+	if msg.ChannelID != "" {
+		normalized.Channel = &normalize.Channel{ID: msg.ChannelID, SourceType: msg.SourceType}
+	}
+	if msg.ThreadID != nil {
+		normalized.ThreadID = *msg.ThreadID
+	}
+	if msg.ParentID != nil {
+		normalized.ParentID = *msg.ParentID
+	}
+	for _, att := range msg.Attachments {
+		if att.Type != normalize.AttachmentTypeMessage {
+			continue
+		}
+		channelID, ts, ok := normalize.ParseSlackPermalink(att.URL)
+		if !ok {
+			continue
+		}
+		normalized.SharedMessages = append(normalized.SharedMessages, normalize.SharedMessage{
+			MessageID:  fmt.Sprintf("msg_slack_%s_%s", channelID, ts),
+			URL:        att.URL,
+			ChannelID:  channelID,
+			TS:         ts,
+			AuthorName: att.Title,
+		})
+	}
+	return normalized
+}
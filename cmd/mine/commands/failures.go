@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/eventlog"
+	"github.com/solvaholic/threadmine/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var retryFailuresCmd = &cobra.Command{
+	Use:   "retry-failures",
+	Short: "Retry messages that failed to normalize or store",
+	Long: `Re-attempt the messages earlier fetches saved to the raw cache but failed
+to normalize or store, without calling any API.
+
+Fetches record these failures, report their count at the end, and log them
+in the fetch event log (see mine log tail). Messages that are stored
+successfully are removed from the list; the others keep their latest error.
+
+Examples:
+  # List the failed messages
+  mine retry-failures --list
+
+  # Retry them all
+  mine retry-failures
+
+  # Retry failed Slack messages only
+  mine retry-failures --source slack`,
+	RunE: runRetryFailures,
+}
+
+var (
+	retryFailuresSource string
+	retryFailuresList   bool
+)
+
+func init() {
+	rootCmd.AddCommand(retryFailuresCmd)
+
+	retryFailuresCmd.Flags().StringVar(&retryFailuresSource, "source", "", "Only failures from this source type: slack, github")
+	retryFailuresCmd.Flags().BoolVar(&retryFailuresList, "list", false, "List the failures instead of retrying them")
+}
+
+// messageError is a failure to normalize or store a message after its raw
+// data was saved, which retry-failures can re-attempt from the raw cache
+type messageError struct {
+	sourceType string
+	messageID  string
+	sourceID   string
+	err        error
+}
+
+func (e *messageError) Error() string { return e.err.Error() }
+func (e *messageError) Unwrap() error { return e.err }
+
+// cachedMessageError marks err as a failure of the cached raw message messageID
+func cachedMessageError(sourceType, messageID, sourceID string, err error) error {
+	return &messageError{sourceType: sourceType, messageID: messageID, sourceID: sourceID, err: err}
+}
+
+// fetchFailures accumulates the messages a fetch fails to store, recording
+// the cached ones in the database for retry-failures
+type fetchFailures struct {
+	database *db.DB
+	failures []eventlog.Failure
+}
+
+// add reports and records the failure to store a message (what describes it)
+func (f *fetchFailures) add(cmd *cobra.Command, what string, err error) {
+	fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store %s: %v\n", what, err)
+
+	failure := eventlog.Failure{Error: err.Error()}
+	var msgErr *messageError
+	if errors.As(err, &msgErr) {
+		failure.MessageID = msgErr.messageID
+		failure.SourceID = msgErr.sourceID
+		if saveErr := f.database.SaveFetchFailure(&db.FetchFailure{
+			MessageID:  msgErr.messageID,
+			SourceType: msgErr.sourceType,
+			SourceID:   msgErr.sourceID,
+			Error:      failure.Error,
+		}); saveErr != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: %v\n", saveErr)
+		}
+	}
+	f.failures = append(f.failures, failure)
+}
+
+// report adds the failures to the fetch event and prints their count
+func (f *fetchFailures) report(cmd *cobra.Command, event *eventlog.Event) {
+	event.Failed = len(f.failures)
+	event.Failures = f.failures
+	if len(f.failures) > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Messages that failed: %d (see mine retry-failures)\n", len(f.failures))
+	}
+}
+
+func runRetryFailures(cmd *cobra.Command, args []string) error {
+	switch retryFailuresSource {
+	case "", "github", "slack":
+	default:
+		return usageErrorf("unknown --source value: %s (expected slack or github)", retryFailuresSource)
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	failures, err := database.SelectFetchFailures(retryFailuresSource)
+	if err != nil {
+		return err
+	}
+
+	if retryFailuresList {
+		return outputFetchFailures(failures)
+	}
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+	recorder := newThreadRecorder(st)
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Retrying %d failed messages...\n", len(failures))
+
+	retried, failed := 0, 0
+	reviewComments := make(map[string][]github.ReviewComment)
+	for _, failure := range failures {
+		raw, err := database.GetRawMessage(failure.MessageID)
+		if err == nil && raw == nil {
+			err = fmt.Errorf("raw message not cached")
+		}
+		if err == nil {
+			err = reprocessRawMessage(database, recorder, raw, reviewComments)
+		}
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store %s: %v\n", failure.MessageID, err)
+			failure.Error = err.Error()
+			if err := database.SaveFetchFailure(failure); err != nil {
+				return err
+			}
+			failed++
+			continue
+		}
+		if err := database.DeleteFetchFailure(failure.MessageID); err != nil {
+			return err
+		}
+		retried++
+	}
+
+	finishReprocess(cmd, database, recorder, reviewComments, false)
+
+	fmt.Fprintf(cmd.OutOrStderr(), "\nCompleted!\n")
+	fmt.Fprintf(cmd.OutOrStderr(), "Messages stored: %d\n", retried)
+	if failed > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Messages that failed again: %d\n", failed)
+	}
+
+	return nil
+}
+
+// outputFetchFailures writes failures in the selected output format
+func outputFetchFailures(failures []*db.FetchFailure) error {
+	switch outputFormat {
+	case "json":
+		return OutputJSON(failures)
+	case "jsonl", "ndjson":
+		return OutputJSONL(failures)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "MESSAGE\tSOURCE\tATTEMPTS\tFAILED\tERROR\n")
+		fmt.Fprintf(w, "-------\t------\t--------\t------\t-----\n")
+		for _, failure := range failures {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+				failure.MessageID,
+				failure.SourceID,
+				failure.Attempts,
+				failure.FailedAt.Local().Format("2006-01-02 15:04"),
+				failure.Error,
+			)
+		}
+		return nil
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/eventlog"
+)
+
+func TestFetchGitHub_RetryFailures(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// Make storing the issue's comment fail after its raw data was saved
+	commentID := "msg_github_acme_widgets_1_comment_100"
+	if _, err := database.Exec(`CREATE TRIGGER fail_comment BEFORE INSERT ON messages
+		WHEN NEW.id = '` + commentID + `' BEGIN SELECT RAISE(ABORT, 'injected failure'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	// The fetch summary reports the failure
+	logPath, err := eventlog.DefaultLogPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := eventlog.Tail(logPath, 1)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected a fetch event, got %v, %v", events, err)
+	}
+	if events[0].Failed != 1 || len(events[0].Failures) != 1 {
+		t.Fatalf("expected 1 failure in the fetch event, got %d: %+v", events[0].Failed, events[0].Failures)
+	}
+	if got := events[0].Failures[0]; got.MessageID != commentID || got.SourceID != "acme/widgets#1-comment-100" || got.Error == "" {
+		t.Errorf("unexpected failure: %+v", got)
+	}
+	if msg, err := database.GetMessage(commentID); err != nil || msg != nil {
+		t.Fatalf("expected the comment not to be stored, got %v, %v", msg, err)
+	}
+
+	// Failures are listed, and retried from the raw cache
+	t.Run("list", func(t *testing.T) {
+		err, out := execute(t, "--db", dbFile, "--format", "json", "retry-failures", "--list")
+		if err != nil {
+			t.Fatalf("retry-failures --list failed: %v", err)
+		}
+		var listed []*db.FetchFailure
+		if err := json.Unmarshal([]byte(out), &listed); err != nil {
+			t.Fatalf("failed to parse output: %v\n%s", err, out)
+		}
+		if len(listed) != 1 || listed[0].MessageID != commentID || listed[0].Attempts != 1 {
+			t.Errorf("unexpected failures listed: %s", out)
+		}
+	})
+
+	tests := []struct {
+		name         string
+		fix          bool
+		wantStored   bool
+		wantAttempts int // Attempts of the remaining failure, 0 if none remains
+	}{
+		{"still failing", false, false, 2},
+		{"fixed", true, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fix {
+				if _, err := database.Exec("DROP TRIGGER fail_comment"); err != nil {
+					t.Fatalf("failed to drop trigger: %v", err)
+				}
+			}
+
+			if err, _ := execute(t, "--db", dbFile, "retry-failures"); err != nil {
+				t.Fatalf("retry-failures failed: %v", err)
+			}
+
+			msg, err := database.GetMessage(commentID)
+			if err != nil {
+				t.Fatalf("GetMessage failed: %v", err)
+			}
+			if (msg != nil) != tt.wantStored {
+				t.Errorf("comment stored = %v, want %v", msg != nil, tt.wantStored)
+			}
+
+			remaining, err := database.SelectFetchFailures("")
+			if err != nil {
+				t.Fatalf("SelectFetchFailures failed: %v", err)
+			}
+			switch {
+			case tt.wantAttempts == 0 && len(remaining) != 0:
+				t.Errorf("expected no failures left, got %+v", remaining[0])
+			case tt.wantAttempts > 0 && (len(remaining) != 1 || remaining[0].Attempts != tt.wantAttempts):
+				t.Errorf("expected 1 failure with %d attempts, got %d failures", tt.wantAttempts, len(remaining))
+			}
+		})
+	}
+}
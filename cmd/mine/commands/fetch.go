@@ -4,14 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/solvaholic/threadmine/internal/classify"
+	"github.com/solvaholic/threadmine/internal/clock"
 	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/eventlog"
 	"github.com/solvaholic/threadmine/internal/github"
 	"github.com/solvaholic/threadmine/internal/normalize"
 	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/solvaholic/threadmine/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -44,6 +49,8 @@ var fetchSlackCmd = &cobra.Command{
 
 This command uses Slack's search API to find messages matching criteria.
 Use --threads to also fetch complete threads for messages that are part of threads.
+Use --backfill to walk a channel's history backward page by page instead of
+searching; progress is saved so later runs resume where the last one stopped.
 Rate limiting is automatically applied to stay within Slack's API limits
 (self-limited to 1/2 of published rates).
 
@@ -55,7 +62,10 @@ Examples:
   mine fetch slack --workspace myteam --search "kubernetes" --since 30d --threads
 
   # Fetch messages in a date range
-  mine fetch slack --workspace myteam --channel engineering --since 2024-01-01 --until 2024-02-01`,
+  mine fetch slack --workspace myteam --channel engineering --since 2024-01-01 --until 2024-02-01
+
+  # Backfill a channel's full history, --limit messages per run; rerun to continue
+  mine fetch slack --workspace myteam --channel engineering --backfill --limit 5000`,
 	RunE: runFetchSlack,
 }
 
@@ -79,6 +89,9 @@ Examples:
   # Fetch issues with comments from a specific user
   mine fetch github --repo org/repo --commenter bob --since 14d
 
+  # Fetch issues and PRs that mention a user
+  mine fetch github --repo org/repo --mentions carol --since 30d
+
   # Fetch from a repo using separate org and repo flags
   mine fetch github --org myorg --repo myrepo --since 7d
 
@@ -89,26 +102,45 @@ Examples:
 
 var (
 	// Common fetch flags
-	fetchSince  string
-	fetchUntil  string
-	fetchLimit  int
+	fetchSince string
+	fetchUntil string
+	fetchLimit int
+
+	fetchExportGraph       string // Write the reply graph of the fetched threads here
+	fetchExportGraphFormat string // json, dot, or mermaid ("" to go by the file extension)
+	fetchNoClassify        bool   // Skip enrichment, answer relations, and classification coverage
+	fetchNoGraph           bool   // Skip thread summaries and --export-graph
 
 	// Slack-specific flags
-	slackWorkspace string
-	slackUser      string
-	slackChannel   string
-	slackSearch    string
-	slackThreads   bool
+	slackWorkspace      string
+	slackUser           string
+	slackChannel        string
+	slackSearch         string
+	slackThreads        bool
+	slackBackfill       bool
+	slackFindDuplicates bool // Relate copies of messages cross-posted to several channels
 
 	// GitHub-specific flags
 	githubOrg       string
 	githubRepo      string
 	githubAuthor    string
 	githubCommenter string
+	githubMentions  string
 	githubReviewer  string
 	githubLabel     string
 	githubSearch    string
 	githubType      string // issue, pr, or all
+
+	githubIssuesOnly     bool // Same as --type issue
+	githubPRsOnly        bool // Same as --type pr
+	githubCommitComments bool
+	githubEditedComments bool // Refresh stored comments edited since --since
+	githubInferThreads   bool // Attach quoting comments to the comment they quote
+	githubAuthorEmail    bool // Resolve PR authors' emails from their commits
+	githubIncludeFiles   bool // Record the files PRs change
+	githubIssue          int  // Fetch only this issue (0 for a search)
+	githubPR             int  // Fetch only this pull request (0 for a search)
+	githubConcurrency    int  // Items whose comments are fetched at once
 )
 
 func init() {
@@ -117,13 +149,21 @@ func init() {
 	fetchCmd.AddCommand(fetchGitHubCmd)
 
 	// Common flags
-	fetchSlackCmd.Flags().StringVar(&fetchSince, "since", "7d", "Start date (YYYY-MM-DD or relative like 7d)")
-	fetchSlackCmd.Flags().StringVar(&fetchUntil, "until", "", "End date (YYYY-MM-DD)")
+	fetchSlackCmd.Flags().StringVar(&fetchSince, "since", "7d", "Start date (YYYY-MM-DD, RFC3339, or relative like 7d)")
+	fetchSlackCmd.Flags().StringVar(&fetchUntil, "until", "", "End date (YYYY-MM-DD or RFC3339)")
 	fetchSlackCmd.Flags().IntVar(&fetchLimit, "limit", 1000, "Maximum number of messages to fetch")
+	fetchSlackCmd.Flags().StringVar(&fetchExportGraph, "export-graph", "", "Also write the reply graph of the fetched threads to this file")
+	fetchSlackCmd.Flags().StringVar(&fetchExportGraphFormat, "export-graph-format", "", "Format of --export-graph: json, dot, or mermaid (default: from the file extension, else json)")
+	fetchSlackCmd.Flags().BoolVar(&fetchNoClassify, "no-classify", false, "Skip enrichment and classification of the fetched messages (run mine reprocess or mine enrich later)")
+	fetchSlackCmd.Flags().BoolVar(&fetchNoGraph, "no-graph", false, "Skip building thread summaries from the reply graph (run mine reprocess later)")
 
-	fetchGitHubCmd.Flags().StringVar(&fetchSince, "since", "7d", "Start date (YYYY-MM-DD or relative like 7d)")
-	fetchGitHubCmd.Flags().StringVar(&fetchUntil, "until", "", "End date (YYYY-MM-DD)")
+	fetchGitHubCmd.Flags().StringVar(&fetchSince, "since", "7d", "Start date (YYYY-MM-DD, RFC3339, or relative like 7d)")
+	fetchGitHubCmd.Flags().StringVar(&fetchUntil, "until", "", "End date (YYYY-MM-DD or RFC3339)")
 	fetchGitHubCmd.Flags().IntVar(&fetchLimit, "limit", 100, "Maximum number of items to fetch")
+	fetchGitHubCmd.Flags().StringVar(&fetchExportGraph, "export-graph", "", "Also write the reply graph of the fetched threads to this file")
+	fetchGitHubCmd.Flags().StringVar(&fetchExportGraphFormat, "export-graph-format", "", "Format of --export-graph: json, dot, or mermaid (default: from the file extension, else json)")
+	fetchGitHubCmd.Flags().BoolVar(&fetchNoClassify, "no-classify", false, "Skip enrichment and classification of the fetched messages (run mine reprocess or mine enrich later)")
+	fetchGitHubCmd.Flags().BoolVar(&fetchNoGraph, "no-graph", false, "Skip building thread summaries from the reply graph (run mine reprocess later)")
 
 	// Slack flags
 	fetchSlackCmd.Flags().StringVar(&slackWorkspace, "workspace", "", "Slack workspace/team name (required unless set in config)")
@@ -131,21 +171,34 @@ func init() {
 	fetchSlackCmd.Flags().StringVar(&slackChannel, "channel", "", "Filter by channel name")
 	fetchSlackCmd.Flags().StringVar(&slackSearch, "search", "", "Search query text")
 	fetchSlackCmd.Flags().BoolVar(&slackThreads, "threads", false, "Fetch complete threads for messages that are part of threads")
+	fetchSlackCmd.Flags().BoolVar(&slackFindDuplicates, "find-duplicates", false, "Record copies of fetched messages their author cross-posted to other channels, for select --dedupe")
+	fetchSlackCmd.Flags().BoolVar(&slackBackfill, "backfill", false, "Fetch --channel history backward from where the last backfill stopped (ignores --since, --until, --user, --search)")
 
 	// GitHub flags
 	fetchGitHubCmd.Flags().StringVar(&githubOrg, "org", "", "Organization name (use with --repo for single repo, or alone for org-wide search)")
 	fetchGitHubCmd.Flags().StringVar(&githubOrg, "owner", "", "Alias for --org")
 	fetchGitHubCmd.Flags().StringVar(&githubRepo, "repo", "", "Repository name (use with --org, or use org/repo format)")
-	fetchGitHubCmd.Flags().StringVar(&githubAuthor, "author", "", "Filter by issue/PR author username")
+	fetchGitHubCmd.Flags().StringVar(&githubAuthor, "author", "", "Filter by issue/PR author username (creator= on the issues endpoint with --type issue)")
 	fetchGitHubCmd.Flags().StringVar(&githubCommenter, "commenter", "", "Filter by comment author username")
+	fetchGitHubCmd.Flags().StringVar(&githubMentions, "mentions", "", "Filter by issues/PRs that mention this username (mentioned= on the issues endpoint with --type issue)")
 	fetchGitHubCmd.Flags().StringVar(&githubReviewer, "reviewer", "", "Filter by PR reviewer (PRs only)")
 	fetchGitHubCmd.Flags().StringVar(&githubLabel, "label", "", "Filter by label")
 	fetchGitHubCmd.Flags().StringVar(&githubSearch, "search", "", "Search query text")
 	fetchGitHubCmd.Flags().StringVar(&githubType, "type", "all", "Type: issue, pr, or all")
+	fetchGitHubCmd.Flags().BoolVar(&githubIssuesOnly, "issues-only", false, "Fetch only issues (same as --type issue)")
+	fetchGitHubCmd.Flags().BoolVar(&githubPRsOnly, "prs-only", false, "Fetch only pull requests (same as --type pr)")
+	fetchGitHubCmd.Flags().BoolVar(&githubCommitComments, "include-commit-comments", false, "Also fetch comments on commits (single repo only)")
+	fetchGitHubCmd.Flags().BoolVar(&githubEditedComments, "include-edited-comments", false, "Also refresh stored comments edited since --since on issues and pull requests the search didn't return (single repo only)")
+	fetchGitHubCmd.Flags().IntVar(&githubIssue, "issue", 0, "Fetch only this issue number, with its comments (single repo only)")
+	fetchGitHubCmd.Flags().BoolVar(&githubInferThreads, "infer-threads", false, "Attach comments that start by quoting an earlier comment to it, instead of the issue or pull request")
+	fetchGitHubCmd.Flags().BoolVar(&githubAuthorEmail, "author-email", false, "Resolve pull request authors' emails from their commits, when GitHub doesn't expose them")
+	fetchGitHubCmd.Flags().BoolVar(&githubIncludeFiles, "include-files", false, "Also record the files pull requests change, as file_path and directory entities")
+	fetchGitHubCmd.Flags().IntVar(&githubPR, "pr", 0, "Fetch only this pull request number, with its comments and reviews (single repo only)")
+	fetchGitHubCmd.Flags().IntVar(&githubConcurrency, "concurrency", 4, "Number of issues and pull requests whose comments are fetched at once")
 	// Note: Either --org or --repo (with org/repo format) is required, validated at runtime
 }
 
-func runFetchSlack(cmd *cobra.Command, args []string) error {
+func runFetchSlack(cmd *cobra.Command, args []string) (err error) {
 	// Apply config defaults for flags that weren't explicitly set
 	if globalConfig != nil {
 		if !cmd.Flags().Changed("workspace") && globalConfig.HasKey("fetch.slack.workspace") {
@@ -172,11 +225,40 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 		if !cmd.Flags().Changed("threads") && globalConfig.HasKey("fetch.slack.threads") {
 			slackThreads = globalConfig.GetBool("fetch.slack.threads")
 		}
+		if !cmd.Flags().Changed("find-duplicates") && globalConfig.HasKey("fetch.slack.find-duplicates") {
+			slackFindDuplicates = globalConfig.GetBool("fetch.slack.find-duplicates")
+		}
 	}
 
+	// Record this fetch in the event log when it finishes
+	event := &eventlog.Event{
+		Timestamp: clock.Now(),
+		Command:   "fetch slack",
+		Source:    "slack",
+		Params: nonEmptyParams(map[string]string{
+			"workspace":       slackWorkspace,
+			"user":            slackUser,
+			"channel":         slackChannel,
+			"search":          slackSearch,
+			"since":           fetchSince,
+			"until":           fetchUntil,
+			"limit":           strconv.Itoa(fetchLimit),
+			"threads":         strconv.FormatBool(slackThreads),
+			"backfill":        strconv.FormatBool(slackBackfill),
+			"find-duplicates": strconv.FormatBool(slackFindDuplicates),
+		}),
+	}
+	defer func() { recordFetchEvent(cmd, event, err) }()
+
 	// Validate required fields
 	if slackWorkspace == "" {
-		return fmt.Errorf("--workspace is required (or set fetch.slack.workspace in config)")
+		return usageErrorf("--workspace is required (or set fetch.slack.workspace in config)")
+	}
+	if _, err := exportGraphFormat(); err != nil {
+		return err
+	}
+	if fetchNoGraph && fetchExportGraph != "" {
+		return usageErrorf("--export-graph cannot be combined with --no-graph")
 	}
 
 	// Open database
@@ -191,10 +273,22 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 	}
 	defer database.Close()
 
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+	recorder := newThreadRecorder(st)
+	recorder.skipClassify, recorder.skipGraph = fetchNoClassify, fetchNoGraph
+	st = recorder
+
+	if slackBackfill {
+		return runSlackBackfill(cmd, database, recorder, event)
+	}
+
 	// Parse time range
-	since, err := parseTimeSpec(fetchSince)
+	since, err := utils.ParseSinceDate(fetchSince)
 	if err != nil {
-		return fmt.Errorf("invalid --since value: %w", err)
+		return usageErrorf("invalid --since value: %w", err)
 	}
 
 	// Build search query for Slack
@@ -236,9 +330,9 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 		queryParts = append(queryParts, fmt.Sprintf("after:%s", sinceAdjusted.Format("2006-01-02")))
 	}
 	if fetchUntil != "" {
-		until, err := parseTimeSpec(fetchUntil)
+		until, err := utils.ParseSinceDate(fetchUntil)
 		if err != nil {
-			return fmt.Errorf("invalid --until value: %w", err)
+			return usageErrorf("invalid --until value: %w", err)
 		}
 		// For Slack's "before:" to be inclusive, we need to add one day.
 		// E.g., if user wants "until 7d" (up to 7 days ago),
@@ -252,6 +346,7 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 	}
 
 	searchQuery := strings.Join(queryParts, " ")
+	event.Query = searchQuery
 
 	fmt.Fprintf(cmd.OutOrStderr(), "Fetching Slack messages with query: %s\n", searchQuery)
 	fmt.Fprintf(cmd.OutOrStderr(), "Workspace: %s\n", slackWorkspace)
@@ -269,6 +364,13 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 	// Initialize rate limiting for search.messages
 	endpoint := "search.messages"
 	workspaceID := fmt.Sprintf("ws_slack_%s", authResult.TeamID)
+	saveAuthenticatedUser(cmd, database, &db.Workspace{
+		ID:                  workspaceID,
+		SourceType:          "slack",
+		SourceID:            authResult.TeamID,
+		Name:                authResult.TeamName,
+		AuthenticatedUserID: optionalString("user_slack_" + authResult.UserID),
+	})
 	err = database.InitRateLimit("slack", &workspaceID, endpoint, 60, 20, 10)
 	if err != nil {
 		return fmt.Errorf("failed to initialize rate limiting: %w", err)
@@ -304,6 +406,7 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 
 	// Process each search result
 	messageCount := 0
+	failures := &fetchFailures{database: database}
 	threadCount := 0
 	threadsProcessed := make(map[string]bool)
 
@@ -341,8 +444,8 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch thread: %v\n", err)
 				// Fall back to storing just this message
-				if err := storeSlackMessage(database, result, authResult.TeamID, result.Channel.ID, &result.Channel); err != nil {
-					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store message: %v\n", err)
+				if err := storeSlackMessage(database, st, result, authResult.TeamID, result.Channel.ID, &result.Channel); err != nil {
+					failures.add(cmd, "message", err)
 					continue
 				}
 				messageCount++
@@ -355,8 +458,8 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 
 				// Store all messages in thread
 				for _, msg := range threadMessages {
-					if err := storeSlackMessage(database, msg, authResult.TeamID, result.Channel.ID, &result.Channel); err != nil {
-						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store message: %v\n", err)
+					if err := storeSlackMessage(database, st, msg, authResult.TeamID, result.Channel.ID, &result.Channel); err != nil {
+						failures.add(cmd, "message", err)
 						continue
 					}
 					messageCount++
@@ -367,23 +470,62 @@ func runFetchSlack(cmd *cobra.Command, args []string) error {
 		} else {
 			// Either --threads not set, or message not part of a thread, or thread already processed
 			// Just store this single message
-			if err := storeSlackMessage(database, result, authResult.TeamID, result.Channel.ID, &result.Channel); err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store message: %v\n", err)
+			if err := storeSlackMessage(database, st, result, authResult.TeamID, result.Channel.ID, &result.Channel); err != nil {
+				failures.add(cmd, "message", err)
 				continue
 			}
 			messageCount++
 		}
 	}
 
+	event.Messages = messageCount
+	event.Threads = threadCount
+
+	upsertEntities(cmd, database, recorder, workspaceID, authResult.Client)
+	resolveContentMentions(cmd, database, recorder, authResult.Client)
+	event.Coverage = summarizeThreads(cmd, database, recorder)
+	relateReferences(cmd, database, recorder)
+	if slackFindDuplicates {
+		relateCrossPosts(cmd, database, recorder)
+	}
+
 	fmt.Fprintf(cmd.OutOrStderr(), "\nCompleted!\n")
 	fmt.Fprintf(cmd.OutOrStderr(), "Messages stored: %d\n", messageCount)
 	fmt.Fprintf(cmd.OutOrStderr(), "Threads processed: %d\n", threadCount)
+	reportSkippedStages(cmd, recorder, event)
+	failures.report(cmd, event)
 
-	return nil
+	return exportReplyGraph(cmd, recorder)
+}
+
+// slackDBChannel converts a Slack channel of workspace teamID to a db channel
+func slackDBChannel(channel *slack.Channel, teamID string) *db.Channel {
+	chanName := channel.Name
+	displayName := "#" + channel.Name
+	chanType := "channel"
+	if !channel.IsChannel {
+		chanType = "dm"
+		displayName = channel.Name // DMs don't get # prefix
+	}
+	workspaceID := fmt.Sprintf("ws_slack_%s", teamID)
+
+	return &db.Channel{
+		ID:          fmt.Sprintf("chan_slack_%s", channel.ID),
+		SourceType:  "slack",
+		SourceID:    channel.ID,
+		WorkspaceID: &workspaceID,
+		Name:        chanName,
+		DisplayName: &displayName,
+		Type:        &chanType,
+		IsPrivate:   channel.IsPrivate,
+		ParentSpace: &workspaceID,
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
+	}
 }
 
 // storeSlackMessage stores a Slack message (raw + normalized) in the database
-func storeSlackMessage(database *db.DB, msg interface{}, teamID, channelID string, channel *slack.Channel) error {
+func storeSlackMessage(database *db.DB, st store.Store, msg interface{}, teamID, channelID string, channel *slack.Channel) error {
 	// Extract message details based on type
 	var msgID, timestamp, userID, username string
 
@@ -398,6 +540,10 @@ func storeSlackMessage(database *db.DB, msg interface{}, teamID, channelID strin
 		userID = m.User
 		username = "" // ThreadMessage doesn't have username field
 		msgID = fmt.Sprintf("msg_slack_%s_%s", channelID, timestamp)
+	case slack.Message:
+		timestamp = m.Timestamp
+		userID = m.User
+		msgID = fmt.Sprintf("msg_slack_%s_%s", channelID, timestamp)
 	default:
 		return fmt.Errorf("unsupported message type: %T", msg)
 	}
@@ -405,11 +551,11 @@ func storeSlackMessage(database *db.DB, msg interface{}, teamID, channelID strin
 	// Store user info if we have it
 	if userID != "" {
 		user := &db.User{
-			ID:          fmt.Sprintf("user_slack_%s", userID),
-			SourceType:  "slack",
-			SourceID:    userID,
-			FetchedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			ID:         fmt.Sprintf("user_slack_%s", userID),
+			SourceType: "slack",
+			SourceID:   userID,
+			FetchedAt:  clock.Now(),
+			UpdatedAt:  clock.Now(),
 		}
 		if username != "" {
 			user.DisplayName = &username
@@ -420,29 +566,7 @@ func storeSlackMessage(database *db.DB, msg interface{}, teamID, channelID strin
 
 	// Store channel info
 	if channel != nil {
-		chanName := channel.Name
-		displayName := "#" + channel.Name
-		chanType := "channel"
-		if !channel.IsChannel {
-			chanType = "dm"
-			displayName = channel.Name // DMs don't get # prefix
-		}
-		workspaceID := fmt.Sprintf("ws_slack_%s", teamID)
-
-		dbChannel := &db.Channel{
-			ID:          fmt.Sprintf("chan_slack_%s", channelID),
-			SourceType:  "slack",
-			SourceID:    channelID,
-			WorkspaceID: &workspaceID,
-			Name:        chanName,
-			DisplayName: &displayName,
-			Type:        &chanType,
-			IsPrivate:   channel.IsPrivate,
-			ParentSpace: &workspaceID,
-			FetchedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-		database.SaveChannel(dbChannel)
+		database.SaveChannel(slackDBChannel(channel, teamID))
 	}
 
 	// Store raw message
@@ -462,22 +586,29 @@ func storeSlackMessage(database *db.DB, msg interface{}, teamID, channelID strin
 	// Normalize and store
 	normalized, err := normalizeSlackMessage(msg, teamID, channelID)
 	if err != nil {
-		return fmt.Errorf("failed to normalize message: %w", err)
+		return cachedMessageError("slack", msgID, sourceID, fmt.Errorf("failed to normalize message: %w", err))
 	}
 
-	err = database.SaveMessage(normalized)
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save normalized message: %w", err)
+		return cachedMessageError("slack", msgID, sourceID, fmt.Errorf("failed to save normalized message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
 
 // enrichAndSaveMessage enriches a message and saves the enrichment metadata
-func enrichAndSaveMessage(database *db.DB, msg *db.Message) error {
+func enrichAndSaveMessage(st store.Store, msg *db.Message) error {
+	// Silently ignore enrichment errors - they're not critical
+	_ = st.SaveEnrichment(messageEnrichment(msg))
+	return nil
+}
+
+// messageEnrichment computes the enrichment metadata of a stored message
+func messageEnrichment(msg *db.Message) *db.Enrichment {
 	// Convert db.CodeBlock to normalize.CodeBlock
 	codeBlocks := make([]normalize.CodeBlock, len(msg.CodeBlocks))
 	for i, cb := range msg.CodeBlocks {
@@ -498,8 +629,7 @@ func enrichAndSaveMessage(database *db.DB, msg *db.Message) error {
 	// Enrich the message
 	enrichment := classify.EnrichMessage(normalized)
 
-	// Save enrichment to database
-	dbEnrichment := &db.Enrichment{
+	return &db.Enrichment{
 		MessageID:  enrichment.MessageID,
 		IsQuestion: enrichment.IsQuestion,
 		CharCount:  enrichment.CharCount,
@@ -507,16 +637,18 @@ func enrichAndSaveMessage(database *db.DB, msg *db.Message) error {
 		HasCode:    enrichment.HasCode,
 		HasLinks:   enrichment.HasLinks,
 		HasQuotes:  enrichment.HasQuotes,
+		IsCodeOnly: enrichment.IsCodeOnly,
+		IsLinkOnly: enrichment.IsLinkOnly,
+		Language:   enrichment.Language,
 	}
-
-	// Silently ignore enrichment errors - they're not critical
-	_ = database.SaveEnrichment(dbEnrichment)
-	return nil
 }
 
 // normalizeSlackMessage converts a Slack message to normalized format
 func normalizeSlackMessage(msg interface{}, teamID, channelID string) (*db.Message, error) {
 	var timestamp, user, text, threadTS, permalink string
+	var slackAttachments []slack.Attachment
+	var blocks []map[string]interface{}
+	var edited *slack.Edited
 
 	switch m := msg.(type) {
 	case slack.SearchResult:
@@ -525,11 +657,25 @@ func normalizeSlackMessage(msg interface{}, teamID, channelID string) (*db.Messa
 		text = m.Text
 		threadTS = m.ThreadTS
 		permalink = m.Permalink
+		slackAttachments = m.Attachments
+		blocks = m.Blocks
+		edited = m.Edited
 	case slack.ThreadMessage:
 		timestamp = m.Timestamp
 		user = m.User
 		text = m.Text
 		threadTS = m.ThreadTS
+		slackAttachments = m.Attachments
+		blocks = m.Blocks
+		edited = m.Edited
+	case slack.Message:
+		timestamp = m.Timestamp
+		user = m.User
+		text = m.Text
+		threadTS = m.ThreadTS
+		slackAttachments = m.Attachments
+		blocks = m.Blocks
+		edited = m.Edited
 	default:
 		return nil, fmt.Errorf("unsupported message type: %T", msg)
 	}
@@ -577,8 +723,12 @@ func normalizeSlackMessage(msg interface{}, teamID, channelID string) (*db.Messa
 		}
 	}
 
+	// Plain text: entities decoded, zero-width characters and smart quotes
+	// replaced. Mentions are still read from the Slack markup.
+	content := normalize.CleanText(text)
+
 	// Extract code blocks and URLs from content
-	normalizeCodeBlocks := normalize.ExtractCodeBlocks(text)
+	normalizeCodeBlocks := normalize.ExtractCodeBlocks(content)
 	codeBlocks := make([]db.CodeBlock, len(normalizeCodeBlocks))
 	for i, cb := range normalizeCodeBlocks {
 		codeBlocks[i] = db.CodeBlock{
@@ -587,28 +737,71 @@ func normalizeSlackMessage(msg interface{}, teamID, channelID string) (*db.Messa
 		}
 	}
 
-	urls := normalize.ExtractURLs(text)
+	urls := normalize.ExtractURLs(content)
+
+	// Messages shared into this one are kept as attachments linking to them
+	attachments := []db.Attachment{}
+	for _, shared := range sharedSlackMessages(slackAttachments, teamID) {
+		attachments = append(attachments, db.Attachment{
+			Type:  normalize.AttachmentTypeMessage,
+			URL:   shared.URL,
+			Title: shared.AuthorName,
+		})
+	}
+	// So are the images and files in its blocks
+	for _, att := range normalize.SlackBlockAttachments(blocks) {
+		attachments = append(attachments, db.Attachment{
+			Type:  att.Type,
+			URL:   att.URL,
+			Title: att.Title,
+		})
+	}
+
+	// Edited messages record who last changed them and when
+	var editedAt *time.Time
+	var editedBy *string
+	if edited != nil {
+		if t, err := parseSlackTimestamp(edited.Timestamp); err == nil {
+			editedAt = &t
+		}
+		if edited.User != "" {
+			by := fmt.Sprintf("user_slack_%s", edited.User)
+			editedBy = &by
+		}
+	}
 
 	return &db.Message{
-		ID:           msgID,
-		SourceType:   "slack",
-		SourceID:     fmt.Sprintf("%s_%s", channelID, timestamp),
-		Timestamp:    ts,
-		AuthorID:     userID,
-		Content:      text, // Use the text variable
-		ChannelID:    chanID,
-		ThreadID:     threadID,
-		ParentID:     parentID,
-		IsThreadRoot: isThreadRoot,
-		Mentions:     []string{},
-		URLs:         urls,
-		CodeBlocks:   codeBlocks,
-		Attachments:  []db.Attachment{},
-		NormalizedAt: time.Now(),
-		SchemaVersion: "2.0",
+		ID:              msgID,
+		SourceType:      "slack",
+		SourceID:        fmt.Sprintf("%s_%s", channelID, timestamp),
+		Timestamp:       ts,
+		SourceTimestamp: timestamp,
+		AuthorID:        userID,
+		Content:         content,
+		ChannelID:       chanID,
+		ThreadID:        threadID,
+		ParentID:        parentID,
+		IsThreadRoot:    isThreadRoot,
+		Mentions:        mentionIDs("slack", text),
+		URLs:            urls,
+		CodeBlocks:      codeBlocks,
+		Attachments:     attachments,
+		EditedAt:        editedAt,
+		EditedBy:        editedBy,
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
 	}, nil
 }
 
+// sharedSlackMessages returns the messages shared into a Slack message by its attachments
+func sharedSlackMessages(attachments []slack.Attachment, teamID string) []normalize.SharedMessage {
+	raw := make([]normalize.SlackAttachment, len(attachments))
+	for i, att := range attachments {
+		raw[i] = normalize.SlackAttachment(att)
+	}
+	return normalize.SlackSharedMessages(raw, teamID)
+}
+
 // parseSlackTimestamp converts Slack timestamp to time.Time
 func parseSlackTimestamp(ts string) (time.Time, error) {
 	var sec, usec int64
@@ -619,11 +812,16 @@ func parseSlackTimestamp(ts string) (time.Time, error) {
 	return time.Unix(sec, usec*1000), nil
 }
 
-func runFetchGitHub(cmd *cobra.Command, args []string) error {
+func runFetchGitHub(cmd *cobra.Command, args []string) (err error) {
 	// Apply config defaults for flags that weren't explicitly set
 	if globalConfig != nil {
-		if !cmd.Flags().Changed("org") && globalConfig.HasKey("fetch.github.org") {
-			githubOrg = globalConfig.GetString("fetch.github.org")
+		// --owner is an alias for --org, and fetch.github.owner for fetch.github.org
+		if !cmd.Flags().Changed("org") && !cmd.Flags().Changed("owner") {
+			if globalConfig.HasKey("fetch.github.org") {
+				githubOrg = globalConfig.GetString("fetch.github.org")
+			} else if globalConfig.HasKey("fetch.github.owner") {
+				githubOrg = globalConfig.GetString("fetch.github.owner")
+			}
 		}
 		if !cmd.Flags().Changed("repo") && globalConfig.HasKey("fetch.github.repo") {
 			githubRepo = globalConfig.GetString("fetch.github.repo")
@@ -634,6 +832,9 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		if !cmd.Flags().Changed("commenter") && globalConfig.HasKey("fetch.github.commenter") {
 			githubCommenter = globalConfig.GetString("fetch.github.commenter")
 		}
+		if !cmd.Flags().Changed("mentions") && globalConfig.HasKey("fetch.github.mentions") {
+			githubMentions = globalConfig.GetString("fetch.github.mentions")
+		}
 		if !cmd.Flags().Changed("reviewer") && globalConfig.HasKey("fetch.github.reviewer") {
 			githubReviewer = globalConfig.GetString("fetch.github.reviewer")
 		}
@@ -655,6 +856,71 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		if !cmd.Flags().Changed("limit") && globalConfig.HasKey("fetch.github.limit") {
 			fetchLimit = globalConfig.GetIntWithFallback("fetch.github.limit", fetchLimit)
 		}
+		if !cmd.Flags().Changed("include-commit-comments") && globalConfig.HasKey("fetch.github.include-commit-comments") {
+			githubCommitComments = globalConfig.GetBool("fetch.github.include-commit-comments")
+		}
+		if !cmd.Flags().Changed("include-edited-comments") && globalConfig.HasKey("fetch.github.include-edited-comments") {
+			githubEditedComments = globalConfig.GetBool("fetch.github.include-edited-comments")
+		}
+		if !cmd.Flags().Changed("infer-threads") && globalConfig.HasKey("fetch.github.infer-threads") {
+			githubInferThreads = globalConfig.GetBool("fetch.github.infer-threads")
+		}
+		if !cmd.Flags().Changed("author-email") && globalConfig.HasKey("fetch.github.author-email") {
+			githubAuthorEmail = globalConfig.GetBool("fetch.github.author-email")
+		}
+		if !cmd.Flags().Changed("include-files") && globalConfig.HasKey("fetch.github.include-files") {
+			githubIncludeFiles = globalConfig.GetBool("fetch.github.include-files")
+		}
+		if !cmd.Flags().Changed("concurrency") && globalConfig.HasKey("fetch.github.concurrency") {
+			githubConcurrency = globalConfig.GetIntWithFallback("fetch.github.concurrency", githubConcurrency)
+		}
+	}
+
+	// --issues-only and --prs-only are shorthands for --type
+	switch {
+	case githubIssuesOnly && githubPRsOnly:
+		return usageErrorf("--issues-only and --prs-only cannot be combined")
+	case (githubIssuesOnly || githubPRsOnly) && cmd.Flags().Changed("type"):
+		return usageErrorf("--issues-only and --prs-only cannot be combined with --type")
+	case githubIssuesOnly:
+		githubType = "issue"
+	case githubPRsOnly:
+		githubType = "pr"
+	}
+
+	// Record this fetch in the event log when it finishes
+	event := &eventlog.Event{
+		Timestamp: clock.Now(),
+		Command:   "fetch github",
+		Source:    "github",
+		Params: nonEmptyParams(map[string]string{
+			"org":       githubOrg,
+			"repo":      githubRepo,
+			"author":    githubAuthor,
+			"commenter": githubCommenter,
+			"mentions":  githubMentions,
+			"reviewer":  githubReviewer,
+			"label":     githubLabel,
+			"search":    githubSearch,
+			"type":      githubType,
+			"since":     fetchSince,
+			"until":     fetchUntil,
+			"limit":     strconv.Itoa(fetchLimit),
+
+			"include-commit-comments": strconv.FormatBool(githubCommitComments),
+			"include-edited-comments": strconv.FormatBool(githubEditedComments),
+			"include-files":           strconv.FormatBool(githubIncludeFiles),
+			"issue":                   positiveInt(githubIssue),
+			"pr":                      positiveInt(githubPR),
+		}),
+	}
+	defer func() { recordFetchEvent(cmd, event, err) }()
+
+	if _, err := exportGraphFormat(); err != nil {
+		return err
+	}
+	if fetchNoGraph && fetchExportGraph != "" {
+		return usageErrorf("--export-graph cannot be combined with --no-graph")
 	}
 
 	// Open database
@@ -669,10 +935,18 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 	}
 	defer database.Close()
 
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+	recorder := newThreadRecorder(st)
+	recorder.skipClassify, recorder.skipGraph = fetchNoClassify, fetchNoGraph
+	st = recorder
+
 	// Parse time range
-	since, err := parseTimeSpec(fetchSince)
+	since, err := utils.ParseSinceDate(fetchSince)
 	if err != nil {
-		return fmt.Errorf("invalid --since value: %w", err)
+		return usageErrorf("invalid --since value: %w", err)
 	}
 
 	// Parse org and repo
@@ -685,7 +959,7 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 			// Format: org/repo
 			parts := strings.Split(githubRepo, "/")
 			if len(parts) != 2 {
-				return fmt.Errorf("invalid --repo format: %s (expected org/repo or just repo with --org)", githubRepo)
+				return usageErrorf("invalid --repo format: %s (expected org/repo or just repo with --org)", githubRepo)
 			}
 			owner = parts[0]
 			repo = parts[1]
@@ -705,7 +979,28 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		repo = "" // No specific repo
 		searchScope = fmt.Sprintf("org:%s", owner)
 	} else {
-		return fmt.Errorf("either --org or --repo is required (or set fetch.github.org in config)")
+		return usageErrorf("either --org or --repo is required (or set fetch.github.org or fetch.github.repo in config)")
+	}
+
+	// --issue and --pr fetch one item instead of searching
+	itemNumber := 0
+	switch {
+	case githubIssue < 0 || githubPR < 0:
+		return usageErrorf("--issue and --pr must be positive numbers")
+	case githubIssue > 0 && githubPR > 0:
+		return usageErrorf("--issue and --pr cannot be combined")
+	case githubIssue > 0:
+		itemNumber = githubIssue
+		githubType = "issue"
+	case githubPR > 0:
+		itemNumber = githubPR
+		githubType = "pr"
+	}
+	if itemNumber > 0 && repo == "" {
+		return usageErrorf("--issue and --pr need a single repository (--repo org/repo)")
+	}
+	if githubConcurrency < 1 {
+		return usageErrorf("--concurrency must be at least 1")
 	}
 
 	// When --reviewer is set, automatically assume --type pr
@@ -722,6 +1017,9 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 	if githubCommenter != "" {
 		queryParts = append(queryParts, fmt.Sprintf("commenter:%s", githubCommenter))
 	}
+	if githubMentions != "" {
+		queryParts = append(queryParts, fmt.Sprintf("mentions:%s", githubMentions))
+	}
 	if githubReviewer != "" {
 		queryParts = append(queryParts, fmt.Sprintf("reviewed-by:%s", githubReviewer))
 	}
@@ -744,8 +1042,14 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 	// For githubType == "all", don't add a type filter
 
 	searchQuery := strings.Join(queryParts, " ")
+	event.Query = searchQuery
 
-	fmt.Fprintf(cmd.OutOrStderr(), "Fetching GitHub items with query: %s\n", searchQuery)
+	if itemNumber > 0 {
+		event.Query = fmt.Sprintf("%s/%s#%d", owner, repo, itemNumber)
+		fmt.Fprintf(cmd.OutOrStderr(), "Fetching GitHub %s %s\n", githubType, event.Query)
+	} else {
+		fmt.Fprintf(cmd.OutOrStderr(), "Fetching GitHub items with query: %s\n", searchQuery)
+	}
 	if repo != "" {
 		fmt.Fprintf(cmd.OutOrStderr(), "Repository: %s/%s\n", owner, repo)
 	} else {
@@ -762,6 +1066,11 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 
 	fmt.Fprintf(cmd.OutOrStderr(), "Authenticated as %s\n", authResult.User)
 
+	// Check GitHub's own rate limit budget so the fetch doesn't fail partway through
+	if err := waitForGitHubRateLimit(ctx, cmd, authResult.Client); err != nil {
+		return err
+	}
+
 	// Create client for this repo (if specific repo was specified)
 	// For org-wide searches, we'll create clients per-issue
 	var client *github.Client
@@ -769,46 +1078,78 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		client = github.NewClient(owner, repo)
 	}
 
-	// Search for issues/PRs
-	fmt.Fprintf(cmd.OutOrStderr(), "Searching GitHub...\n")
+	var results []github.Issue
+	if itemNumber > 0 {
+		item, err := fetchGitHubItem(ctx, client, itemNumber, githubType == "pr")
+		if err != nil {
+			return err
+		}
+		results = []github.Issue{*item}
+	} else if githubListsIssues(repo) {
+		// The issues endpoint filters by author and mention itself
+		fmt.Fprintf(cmd.OutOrStderr(), "Listing issues...\n")
+		results, err = client.GetIssues(ctx, since, github.IssueFilter{Creator: githubAuthor, Mentioned: githubMentions})
+		if err != nil {
+			return fmt.Errorf("failed to list GitHub issues: %w", err)
+		}
+		if fetchLimit > 0 && len(results) > fetchLimit {
+			results = results[:fetchLimit]
+		}
+	} else {
+		// Search for issues/PRs
+		fmt.Fprintf(cmd.OutOrStderr(), "Searching GitHub...\n")
 
-	// For org-wide search, we need to search without a specific repo client
-	// Use a temporary client just for search
-	searchClient := github.NewClient(owner, "")
-	results, err := searchClient.SearchIssues(ctx, searchQuery, fetchLimit)
-	if err != nil {
-		return fmt.Errorf("failed to search GitHub: %w", err)
+		// For org-wide search, we need to search without a specific repo client
+		// Use a temporary client just for search
+		searchClient := github.NewClient(owner, "")
+		results, err = searchClient.SearchIssues(ctx, searchQuery, fetchLimit)
+		if err != nil {
+			return fmt.Errorf("failed to search GitHub: %w", err)
+		}
 	}
 
 	fmt.Fprintf(cmd.OutOrStderr(), "Found %d items\n", len(results))
 
 	// Process each result
 	messageCount := 0
+	issueCount, prCount := 0, 0
+	failures := &fetchFailures{database: database}
 	orgID := fmt.Sprintf("org_github_%s", owner)
+	saveAuthenticatedUser(cmd, database, &db.Workspace{
+		ID:                  orgID,
+		SourceType:          "github",
+		SourceID:            owner,
+		Name:                owner,
+		AuthenticatedUserID: optionalString("user_github_" + authResult.User),
+	})
+	emails := newGitHubEmailResolver(database)
+
+	// Fetch the comments of all items up front, several items at a time. Items
+	// fetched before only need the comments created or edited since the
+	// latest one stored.
+	fmt.Fprintf(cmd.OutOrStderr(), "Fetching comments (%d items at a time)...\n", githubConcurrency)
+	itemComments, err := utils.Map(ctx, results, githubConcurrency, func(ctx context.Context, item github.Issue) (githubComments, error) {
+		itemOwner, itemRepo, err := githubItemRepo(&item, owner, repo)
+		if err != nil {
+			return githubComments{}, nil // The item is skipped below
+		}
+		latest, err := database.LatestRawUpdate("github", fmt.Sprintf("%s/%s#%d-comment-", itemOwner, itemRepo, item.Number))
+		if err != nil {
+			return githubComments{err: err}, nil
+		}
+		comments, err := github.NewClient(itemOwner, itemRepo).GetIssueComments(ctx, item.Number, item.UpdatedAt, latest)
+		return githubComments{comments: comments, err: err}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch comments: %w", err)
+	}
 
 	for i, item := range results {
 		// For org-wide search, extract repo info from the issue
-		var itemOwner, itemRepo string
-		if repo == "" {
-			// Org-wide search: extract from RepositoryURL field
-			// Format: https://api.github.com/repos/owner/repo
-			if item.RepositoryURL == "" {
-				fmt.Fprintf(cmd.OutOrStderr(), "Warning: item #%d has no repository URL, skipping\n", item.Number)
-				continue
-			}
-
-			// Extract owner/repo from URL
-			// Example: https://api.github.com/repos/github/hub -> github, hub
-			parts := strings.Split(item.RepositoryURL, "/")
-			if len(parts) < 2 {
-				fmt.Fprintf(cmd.OutOrStderr(), "Warning: invalid repository URL for item #%d, skipping\n", item.Number)
-				continue
-			}
-			itemRepo = parts[len(parts)-1]
-			itemOwner = parts[len(parts)-2]
-		} else {
-			itemOwner = owner
-			itemRepo = repo
+		itemOwner, itemRepo, err := githubItemRepo(&item, owner, repo)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "Warning: %v, skipping\n", err)
+			continue
 		}
 
 		fmt.Fprintf(cmd.OutOrStderr(), "Processing item %d/%d: #%d %s\n", i+1, len(results), item.Number, item.Title)
@@ -826,22 +1167,54 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		// Determine if this is an issue or PR
 		isPR := githubType == "pr" || (githubType == "all" && strings.Contains(searchQuery, "is:pr"))
 
+		// For PRs, look up requested reviewers (search results don't include
+		// them). Search results may mix issues and PRs, so check the item itself.
+		if item.IsPullRequest() {
+			reviewers, err := client.GetRequestedReviewers(ctx, item.Number)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch requested reviewers: %v\n", err)
+			} else {
+				item.RequestedReviewers = reviewers
+			}
+
+			if githubAuthorEmail && item.User.Email == "" {
+				email, err := emails.prAuthorEmail(ctx, client, item.User.Login, item.Number)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to resolve author email: %v\n", err)
+				}
+				item.User.Email = email
+			}
+
+			if githubIncludeFiles {
+				files, err := client.GetPullRequestFiles(ctx, item.Number)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch changed files: %v\n", err)
+				} else {
+					item.SetFiles(files)
+				}
+			}
+		}
+
 		// Store the issue/PR body as a message
-		if err := storeGitHubIssue(database, &item, itemOwner, itemRepo, orgID); err != nil {
-			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store issue: %v\n", err)
+		if err := storeGitHubIssue(database, st, &item, itemOwner, itemRepo, orgID); err != nil {
+			failures.add(cmd, "issue", err)
 			continue
 		}
+		recorder.setResolution(fmt.Sprintf("msg_github_%s_%s_%d", itemOwner, itemRepo, item.Number), githubIssueResolution(&item))
 		messageCount++
+		if item.IsPullRequest() {
+			prCount++
+		} else {
+			issueCount++
+		}
 
-		// Fetch and store comments
-		fmt.Fprintf(cmd.OutOrStderr(), "  Fetching comments...\n")
-		comments, err := client.GetIssueComments(ctx, item.Number)
-		if err != nil {
+		// Store the comments fetched above
+		if err := itemComments[i].err; err != nil {
 			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch comments: %v\n", err)
 		} else {
-			for _, comment := range comments {
-				if err := storeGitHubComment(database, &comment, &item, itemOwner, itemRepo, orgID); err != nil {
-					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store comment: %v\n", err)
+			for _, comment := range itemComments[i].comments {
+				if err := storeGitHubComment(database, st, &comment, &item, itemOwner, itemRepo, orgID); err != nil {
+					failures.add(cmd, "comment", err)
 					continue
 				}
 				messageCount++
@@ -855,13 +1228,29 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch review comments: %v\n", err)
 			} else {
+				// Resolved and outdated state comes from the review threads
+				if len(reviewComments) > 0 {
+					reviewThreads, err := client.GetPullRequestReviewThreads(ctx, item.Number)
+					if err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch review threads: %v\n", err)
+					}
+					states := github.ReviewThreadStates(reviewThreads)
+					for i := range reviewComments {
+						reviewComments[i].ReviewThread = states[reviewComments[i].ID]
+					}
+				}
 				for _, rc := range reviewComments {
-					if err := storeGitHubReviewComment(database, &rc, &item, itemOwner, itemRepo, orgID); err != nil {
-						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store review comment: %v\n", err)
+					if err := storeGitHubReviewComment(database, st, &rc, &item, itemOwner, itemRepo, orgID); err != nil {
+						failures.add(cmd, "review comment", err)
 						continue
 					}
 					messageCount++
 				}
+				for _, thread := range summarizeReviewThreads(reviewComments, itemOwner, itemRepo, item.Number) {
+					if err := database.SaveThread(thread); err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save review thread %s: %v\n", thread.ID, err)
+					}
+				}
 			}
 
 			fmt.Fprintf(cmd.OutOrStderr(), "  Fetching PR reviews...\n")
@@ -870,8 +1259,8 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch reviews: %v\n", err)
 			} else {
 				for _, review := range reviews {
-					if err := storeGitHubReview(database, &review, &item, itemOwner, itemRepo, orgID); err != nil {
-						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store review: %v\n", err)
+					if err := storeGitHubReview(database, st, &review, &item, itemOwner, itemRepo, orgID); err != nil {
+						failures.add(cmd, "review", err)
 						continue
 					}
 					messageCount++
@@ -889,8 +1278,8 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 			significantCount := 0
 			for _, event := range timeline {
 				if event.IsSignificant() {
-					if err := storeGitHubTimelineEvent(database, &event, &item, itemOwner, itemRepo, orgID); err != nil {
-						fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store timeline event: %v\n", err)
+					if err := storeGitHubTimelineEvent(database, st, &event, &item, itemOwner, itemRepo, orgID); err != nil {
+						failures.add(cmd, "timeline event", err)
 						continue
 					}
 					significantCount++
@@ -901,8 +1290,8 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Search for discussions (only for specific repos, not org-wide)
-	if repo != "" {
+	// Search for discussions (only for specific repos, not org-wide or single items)
+	if repo != "" && itemNumber == 0 {
 		fmt.Fprintf(cmd.OutOrStderr(), "\nSearching for discussions...\n")
 		discussions, err := client.SearchDiscussions(ctx, searchQuery, fetchLimit)
 		if err != nil {
@@ -914,8 +1303,8 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 				fmt.Fprintf(cmd.OutOrStderr(), "Processing discussion %d/%d: #%d %s\n", i+1, len(discussions), discussion.Number, discussion.Title)
 
 				// Store the discussion as a message
-				if err := storeGitHubDiscussion(database, &discussion, owner, repo, orgID); err != nil {
-					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store discussion: %v\n", err)
+				if err := storeGitHubDiscussion(database, st, &discussion, owner, repo, orgID); err != nil {
+					failures.add(cmd, "discussion", err)
 					continue
 				}
 				messageCount++
@@ -927,8 +1316,8 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch discussion comments: %v\n", err)
 				} else {
 					for _, comment := range comments {
-						if err := storeGitHubDiscussionComment(database, &comment, &discussion, owner, repo, orgID); err != nil {
-							fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to store discussion comment: %v\n", err)
+						if err := storeGitHubDiscussionComment(database, st, &comment, &discussion, owner, repo, orgID); err != nil {
+							failures.add(cmd, "discussion comment", err)
 							continue
 						}
 						messageCount++
@@ -938,14 +1327,219 @@ func runFetchGitHub(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Fetch commit comments (only for specific repos, not org-wide)
+	commitThreads := 0
+	if githubCommitComments {
+		if repo == "" {
+			fmt.Fprintf(cmd.OutOrStderr(), "\nWarning: --include-commit-comments needs --repo; skipping commit comments\n")
+		} else {
+			fmt.Fprintf(cmd.OutOrStderr(), "\nFetching commit comments...\n")
+			comments, err := github.NewClient(owner, repo).FetchCommitComments(ctx, "")
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "Warning: failed to fetch commit comments: %v\n", err)
+			} else {
+				commits := make(map[string]bool)
+				stored := 0
+				for _, comment := range comments {
+					if comment.CreatedAt.Before(since) || (fetchLimit > 0 && stored >= fetchLimit) {
+						continue
+					}
+					if err := storeGitHubCommitComment(database, st, &comment, owner, repo, orgID); err != nil {
+						failures.add(cmd, "commit comment", err)
+						continue
+					}
+					commits[comment.CommitID] = true
+					stored++
+					messageCount++
+				}
+				commitThreads = len(commits)
+				fmt.Fprintf(cmd.OutOrStderr(), "Found %d commit comments (%d stored on %d commits)\n", len(comments), stored, commitThreads)
+			}
+		}
+	}
+
+	// Refresh comments edited on issues the search didn't return, whose
+	// updated_at doesn't change when a comment is edited
+	if githubEditedComments && itemNumber == 0 {
+		if repo == "" {
+			fmt.Fprintf(cmd.OutOrStderr(), "\nWarning: --include-edited-comments needs --repo; skipping edited comments\n")
+		} else {
+			fmt.Fprintf(cmd.OutOrStderr(), "\nFetching edited comments...\n")
+			fetched := make(map[int]bool, len(results))
+			for _, item := range results {
+				fetched[item.Number] = true
+			}
+			stored, err := refreshEditedGitHubComments(ctx, cmd, database, st, github.NewClient(owner, repo), owner, repo, orgID, since, fetched, failures)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "Warning: failed to fetch edited comments: %v\n", err)
+			}
+			messageCount += stored
+		}
+	}
+
+	event.Messages = messageCount
+	event.Threads = len(results) + commitThreads
+
+	upsertEntities(cmd, database, recorder, "", nil)
+	resolveContentMentions(cmd, database, recorder, nil)
+	if githubInferThreads {
+		inferQuoteReplies(cmd, recorder)
+	}
+	event.Coverage = summarizeThreads(cmd, database, recorder)
+	relateReferences(cmd, database, recorder)
+
 	fmt.Fprintf(cmd.OutOrStderr(), "\nCompleted!\n")
 	fmt.Fprintf(cmd.OutOrStderr(), "Messages stored: %d\n", messageCount)
+	fmt.Fprintf(cmd.OutOrStderr(), "Issues: %d, pull requests: %d\n", issueCount, prCount)
+	reportSkippedStages(cmd, recorder, event)
+	failures.report(cmd, event)
 
-	return nil
+	return exportReplyGraph(cmd, recorder)
+}
+
+// githubComments holds the comments of an issue or pull request, or the error
+// fetching them
+type githubComments struct {
+	comments []github.Comment
+	err      error
+}
+
+// githubItemRepo returns the owner and name of the repository of a search
+// result: owner/repo, or for an org-wide search (repo is empty) the one in the
+// item's repository URL
+func githubItemRepo(item *github.Issue, owner, repo string) (string, string, error) {
+	if repo != "" {
+		return owner, repo, nil
+	}
+
+	// Format: https://api.github.com/repos/owner/repo
+	if item.RepositoryURL == "" {
+		return "", "", fmt.Errorf("item #%d has no repository URL", item.Number)
+	}
+	// Example: https://api.github.com/repos/github/hub -> github, hub
+	parts := strings.Split(item.RepositoryURL, "/")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("invalid repository URL for item #%d", item.Number)
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+// refreshEditedGitHubComments stores again the comments created or edited
+// since since on issues and pull requests of owner/repo that weren't fetched
+// (by number), but whose threads are already stored. Comments on threads that
+// aren't stored are left out, as the search chose not to fetch them. It
+// returns the number of comments stored.
+func refreshEditedGitHubComments(ctx context.Context, cmd *cobra.Command, database *db.DB, st store.Store, client *github.Client, owner, repo, orgID string, since time.Time, fetched map[int]bool, failures *fetchFailures) (int, error) {
+	comments, err := client.FetchRepoIssueComments(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	// Issues and PRs by number, nil for those whose thread isn't stored
+	issues := make(map[int]*github.Issue)
+	stored := 0
+	for _, comment := range comments {
+		number := comment.IssueNumber()
+		if number == 0 || fetched[number] {
+			continue
+		}
+
+		issue, seen := issues[number]
+		if !seen {
+			root, err := st.LoadMessage(fmt.Sprintf("msg_github_%s_%s_%d", owner, repo, number))
+			if err != nil {
+				return stored, err
+			}
+			if root != nil {
+				if issue, err = client.GetIssue(ctx, number); err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to fetch #%d: %v\n", number, err)
+				}
+			}
+			issues[number] = issue
+		}
+		if issue == nil {
+			continue
+		}
+
+		if err := storeGitHubComment(database, st, &comment, issue, owner, repo, orgID); err != nil {
+			failures.add(cmd, "comment", err)
+			continue
+		}
+		stored++
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Found %d comments updated since %s (%d stored on earlier fetched threads)\n", len(comments), since.Format("2006-01-02"), stored)
+	return stored, nil
+}
+
+// githubRateLimitReserve is the number of requests left in a GitHub rate limit
+// budget at or below which fetch github waits for the budget to reset
+const githubRateLimitReserve = 10
+
+// githubListsIssues reports whether fetch github lists the issues of repo
+// from the issues endpoint, passing --author and --mentions as its creator=
+// and mentioned= parameters, instead of searching. The endpoint leaves out
+// pull requests and has no commenter, reviewer, or free text filters, so it
+// only serves --type issue fetches filtered by nothing else.
+func githubListsIssues(repo string) bool {
+	return repo != "" && githubType == "issue" && (githubAuthor != "" || githubMentions != "") &&
+		githubCommenter == "" && githubReviewer == "" && githubLabel == "" && githubSearch == ""
+}
+
+// fetchGitHubItem fetches the issue (or, if wantPR, pull request) number,
+// failing with a usage error when it's the other kind
+func fetchGitHubItem(ctx context.Context, client *github.Client, number int, wantPR bool) (*github.Issue, error) {
+	item, err := client.GetIssue(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case wantPR && !item.IsPullRequest():
+		return nil, usageErrorf("#%d is an issue, not a pull request; use --issue", number)
+	case !wantPR && item.IsPullRequest():
+		return nil, usageErrorf("#%d is a pull request; use --pr", number)
+	}
+	return item, nil
+}
+
+// positiveInt formats n for event log params, or returns "" if n isn't positive
+func positiveInt(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// waitForGitHubRateLimit prints GitHub's remaining API budget and, if the core
+// or search budget is nearly spent, waits until it resets. Failing to read the
+// budget is only a warning.
+func waitForGitHubRateLimit(ctx context.Context, cmd *cobra.Command, client *github.Client) error {
+	status, err := client.RateLimitStatus(ctx)
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "Warning: failed to check GitHub rate limit: %v\n", err)
+		return nil
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "GitHub API budget: core %d/%d (resets %s), search %d/%d (resets %s)\n",
+		status.Core.Remaining, status.Core.Limit, status.Core.Reset.Local().Format("15:04:05"),
+		status.Search.Remaining, status.Search.Limit, status.Search.Reset.Local().Format("15:04:05"))
+
+	wait := status.PauseDuration(clock.Now(), githubRateLimitReserve)
+	if wait == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "GitHub rate limit nearly exhausted, waiting %s for it to reset...\n", wait.Round(time.Second))
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // storeGitHubIssue stores a GitHub issue/PR as a message
-func storeGitHubIssue(database *db.DB, issue *github.Issue, owner, repo, orgID string) error {
+func storeGitHubIssue(database *db.DB, st store.Store, issue *github.Issue, owner, repo, orgID string) error {
 	// Store user info
 	username := issue.User.Login
 	user := &db.User{
@@ -953,8 +1547,9 @@ func storeGitHubIssue(database *db.DB, issue *github.Issue, owner, repo, orgID s
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		Email:       optionalString(issue.User.Email),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -972,8 +1567,8 @@ func storeGitHubIssue(database *db.DB, issue *github.Issue, owner, repo, orgID s
 		Type:        &chanType,
 		IsPrivate:   false,
 		ParentSpace: &orgID,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveChannel(dbChannel)
 
@@ -1007,36 +1602,80 @@ func storeGitHubIssue(database *db.DB, issue *github.Issue, owner, repo, orgID s
 	urls := normalize.ExtractURLs(content)
 
 	normalized := &db.Message{
-		ID:           msgID,
-		SourceType:   "github",
-		SourceID:     sourceID,
-		Timestamp:    issue.CreatedAt,
-		AuthorID:     user.ID,
-		Content:      content,
-		ChannelID:    dbChannel.ID,
-		ThreadID:     &msgID, // Issue is the thread root
-		IsThreadRoot: true,
-		Mentions:     []string{},
-		URLs:         urls,
-		CodeBlocks:   codeBlocks,
-		Attachments:  []db.Attachment{},
-		NormalizedAt: time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       issue.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(issue.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(content),
+		ChannelID:       dbChannel.ID,
+		ThreadID:        &msgID, // Issue is the thread root
+		IsThreadRoot:    true,
+		Mentions:        mentionIDs("github", content),
+		URLs:            urls,
+		CodeBlocks:      codeBlocks,
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
+	}
+
+	// Store assignees and requested reviewers against the thread root
+	if err := saveGitHubUserEntities(database, msgID, db.EntityTypeAssignee, issue.Assignees); err != nil {
+		return cachedMessageError("github", msgID, sourceID, err)
+	}
+	if err := saveGitHubUserEntities(database, msgID, db.EntityTypeRequestedReviewer, issue.RequestedReviewers); err != nil {
+		return cachedMessageError("github", msgID, sourceID, err)
+	}
+
+	// Store the files a PR changes, when they were fetched
+	if issue.Files != nil {
+		paths := normalize.ChangedFilePaths(issue.Files)
+		if err := database.ReplaceEntities(msgID, db.EntityTypeFilePath, paths); err != nil {
+			return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save %s entities: %w", db.EntityTypeFilePath, err))
+		}
+		if err := database.ReplaceEntities(msgID, db.EntityTypeDirectory, normalize.ChangedDirectories(paths)); err != nil {
+			return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save %s entities: %w", db.EntityTypeDirectory, err))
+		}
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
+
+	return nil
+}
+
+// saveGitHubUserEntities saves GitHub users as db users and records them as entities of a message
+func saveGitHubUserEntities(database *db.DB, msgID, entityType string, users []github.User) error {
+	userIDs := make([]string, 0, len(users))
+	for _, u := range users {
+		login := u.Login
+		user := &db.User{
+			ID:          fmt.Sprintf("user_github_%s", login),
+			SourceType:  "github",
+			SourceID:    login,
+			DisplayName: &login,
+			FetchedAt:   clock.Now(),
+			UpdatedAt:   clock.Now(),
+		}
+		database.SaveUser(user)
+		userIDs = append(userIDs, user.ID)
+	}
+
+	if err := database.ReplaceEntities(msgID, entityType, userIDs); err != nil {
+		return fmt.Errorf("failed to save %s entities: %w", entityType, err)
+	}
 
 	return nil
 }
 
 // storeGitHubComment stores a GitHub issue comment
-func storeGitHubComment(database *db.DB, comment *github.Comment, issue *github.Issue, owner, repo, orgID string) error {
+func storeGitHubComment(database *db.DB, st store.Store, comment *github.Comment, issue *github.Issue, owner, repo, orgID string) error {
 	// Store user info
 	username := comment.User.Login
 	user := &db.User{
@@ -1044,8 +1683,8 @@ func storeGitHubComment(database *db.DB, comment *github.Comment, issue *github.
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -1079,45 +1718,46 @@ func storeGitHubComment(database *db.DB, comment *github.Comment, issue *github.
 	urls := normalize.ExtractURLs(comment.Body)
 
 	normalized := &db.Message{
-		ID:           msgID,
-		SourceType:   "github",
-		SourceID:     sourceID,
-		Timestamp:    comment.CreatedAt,
-		AuthorID:     user.ID,
-		Content:      comment.Body,
-		ChannelID:    channelID,
-		ThreadID:     &threadID,
-		ParentID:     &threadID, // Reply to the issue
-		IsThreadRoot: false,
-		Mentions:     []string{},
-		URLs:         urls,
-		CodeBlocks:   codeBlocks,
-		Attachments:  []db.Attachment{},
-		NormalizedAt: time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       comment.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(comment.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(comment.Body),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		ParentID:        &threadID, // Reply to the issue
+		IsThreadRoot:    false,
+		Mentions:        mentionIDs("github", comment.Body),
+		URLs:            urls,
+		CodeBlocks:      codeBlocks,
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
 
 // storeGitHubReviewComment stores a GitHub PR review comment
-func storeGitHubReviewComment(database *db.DB, comment *github.ReviewComment, pr *github.Issue, owner, repo, orgID string) error {
+func storeGitHubReviewComment(database *db.DB, st store.Store, comment *github.ReviewComment, pr *github.Issue, owner, repo, orgID string) error {
 	username := comment.User.Login
 	user := &db.User{
 		ID:          fmt.Sprintf("user_github_%s", username),
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -1126,11 +1766,17 @@ func storeGitHubReviewComment(database *db.DB, comment *github.ReviewComment, pr
 		return fmt.Errorf("failed to marshal review comment: %w", err)
 	}
 
-	msgID := fmt.Sprintf("msg_github_%s_%s_%d_review_comment_%d", owner, repo, pr.Number, comment.ID)
+	msgID := reviewCommentMessageID(owner, repo, pr.Number, comment.ID)
 	sourceID := fmt.Sprintf("%s/%s#%d-review-comment-%d", owner, repo, pr.Number, comment.ID)
 	channelID := fmt.Sprintf("chan_github_%s_%s", owner, repo)
 	threadID := fmt.Sprintf("msg_github_%s_%s_%d", owner, repo, pr.Number)
 
+	// Replies within a review thread hang off the comment they answer
+	parentID := threadID
+	if comment.InReplyToID != 0 {
+		parentID = reviewCommentMessageID(owner, repo, pr.Number, comment.InReplyToID)
+	}
+
 	err = database.SaveRawMessage(msgID, "github", sourceID, orgID, channelID, string(rawData), "")
 	if err != nil {
 		return fmt.Errorf("failed to save raw review comment: %w", err)
@@ -1152,37 +1798,38 @@ func storeGitHubReviewComment(database *db.DB, comment *github.ReviewComment, pr
 	urls := normalize.ExtractURLs(content)
 
 	normalized := &db.Message{
-		ID:           msgID,
-		SourceType:   "github",
-		SourceID:     sourceID,
-		Timestamp:    comment.CreatedAt,
-		AuthorID:     user.ID,
-		Content:      content,
-		ChannelID:    channelID,
-		ThreadID:     &threadID,
-		ParentID:     &threadID,
-		IsThreadRoot: false,
-		Mentions:     []string{},
-		URLs:         urls,
-		CodeBlocks:   codeBlocks,
-		Attachments:  []db.Attachment{},
-		NormalizedAt: time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       comment.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(comment.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(content),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		ParentID:        &parentID,
+		IsThreadRoot:    false,
+		Mentions:        mentionIDs("github", content),
+		URLs:            urls,
+		CodeBlocks:      codeBlocks,
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
 
 // storeGitHubReview stores a GitHub PR review
-func storeGitHubReview(database *db.DB, review *github.Review, pr *github.Issue, owner, repo, orgID string) error {
+func storeGitHubReview(database *db.DB, st store.Store, review *github.Review, pr *github.Issue, owner, repo, orgID string) error {
 	// Skip reviews with no body
 	if review.Body == "" {
 		return nil
@@ -1194,8 +1841,8 @@ func storeGitHubReview(database *db.DB, review *github.Review, pr *github.Issue,
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -1218,45 +1865,46 @@ func storeGitHubReview(database *db.DB, review *github.Review, pr *github.Issue,
 	content := fmt.Sprintf("[%s] %s", review.State, review.Body)
 
 	normalized := &db.Message{
-		ID:           msgID,
-		SourceType:   "github",
-		SourceID:     sourceID,
-		Timestamp:    review.SubmittedAt,
-		AuthorID:     user.ID,
-		Content:      content,
-		ChannelID:    channelID,
-		ThreadID:     &threadID,
-		ParentID:     &threadID,
-		IsThreadRoot: false,
-		Mentions:     []string{},
-		URLs:         []string{},
-		CodeBlocks:   []db.CodeBlock{},
-		Attachments:  []db.Attachment{},
-		NormalizedAt: time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       review.SubmittedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(review.SubmittedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(content),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		ParentID:        &threadID,
+		IsThreadRoot:    false,
+		Mentions:        mentionIDs("github", content),
+		URLs:            []string{},
+		CodeBlocks:      []db.CodeBlock{},
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
 
 // storeGitHubDiscussion stores a GitHub discussion as a message
-func storeGitHubDiscussion(database *db.DB, discussion *github.Discussion, owner, repo, orgID string) error {
+func storeGitHubDiscussion(database *db.DB, st store.Store, discussion *github.Discussion, owner, repo, orgID string) error {
 	username := discussion.Author.Login
 	user := &db.User{
 		ID:          fmt.Sprintf("user_github_%s", username),
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -1272,8 +1920,8 @@ func storeGitHubDiscussion(database *db.DB, discussion *github.Discussion, owner
 		Name:        repoName,
 		DisplayName: &displayName,
 		Type:        &chanType,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveChannel(dbChannel)
 
@@ -1299,45 +1947,46 @@ func storeGitHubDiscussion(database *db.DB, discussion *github.Discussion, owner
 	}
 
 	normalized := &db.Message{
-		ID:            msgID,
-		SourceType:    "github",
-		SourceID:      sourceID,
-		Timestamp:     discussion.CreatedAt,
-		AuthorID:      user.ID,
-		Content:       content,
-		ChannelID:     channelID,
-		ThreadID:      &threadID,
-		ParentID:      nil, // No parent, this is the root
-		IsThreadRoot:  true,
-		Mentions:      []string{},
-		URLs:          []string{},
-		CodeBlocks:    []db.CodeBlock{},
-		Attachments:   []db.Attachment{},
-		NormalizedAt:  time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       discussion.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(discussion.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(content),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		ParentID:        nil, // No parent, this is the root
+		IsThreadRoot:    true,
+		Mentions:        mentionIDs("github", content),
+		URLs:            []string{},
+		CodeBlocks:      []db.CodeBlock{},
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
 
 // storeGitHubDiscussionComment stores a discussion comment or reply as a message
-func storeGitHubDiscussionComment(database *db.DB, comment *github.DiscussionComment, discussion *github.Discussion, owner, repo, orgID string) error {
+func storeGitHubDiscussionComment(database *db.DB, st store.Store, comment *github.DiscussionComment, discussion *github.Discussion, owner, repo, orgID string) error {
 	username := comment.Author.Login
 	user := &db.User{
 		ID:          fmt.Sprintf("user_github_%s", username),
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -1357,45 +2006,118 @@ func storeGitHubDiscussionComment(database *db.DB, comment *github.DiscussionCom
 	}
 
 	normalized := &db.Message{
-		ID:            msgID,
-		SourceType:    "github",
-		SourceID:      sourceID,
-		Timestamp:     comment.CreatedAt,
-		AuthorID:      user.ID,
-		Content:       comment.Body,
-		ChannelID:     channelID,
-		ThreadID:      &threadID,
-		ParentID:      &threadID, // All comments point to discussion as parent
-		IsThreadRoot:  false,
-		Mentions:      []string{},
-		URLs:          []string{},
-		CodeBlocks:    []db.CodeBlock{},
-		Attachments:   []db.Attachment{},
-		NormalizedAt:  time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       comment.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(comment.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(comment.Body),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		ParentID:        &threadID, // All comments point to discussion as parent
+		IsThreadRoot:    false,
+		Mentions:        mentionIDs("github", comment.Body),
+		URLs:            []string{},
+		CodeBlocks:      []db.CodeBlock{},
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
+	if err != nil {
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
+	}
+
+	// Enrich the message
+	enrichAndSaveMessage(st, normalized)
+
+	return nil
+}
+
+// storeGitHubCommitComment stores a comment on a commit. Comments on the same
+// commit share a thread, which has no root message.
+func storeGitHubCommitComment(database *db.DB, st store.Store, comment *github.CommitComment, owner, repo, orgID string) error {
+	if comment.CommitID == "" {
+		return fmt.Errorf("commit comment %d has no commit ID", comment.ID)
+	}
+
+	username := comment.User.Login
+	user := &db.User{
+		ID:          fmt.Sprintf("user_github_%s", username),
+		SourceType:  "github",
+		SourceID:    username,
+		DisplayName: &username,
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
+	}
+	database.SaveUser(user)
+
+	rawData, err := json.Marshal(comment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal commit comment: %w", err)
+	}
+
+	msgID := fmt.Sprintf("msg_github_%s_%s_commit_%s_comment_%d", owner, repo, comment.CommitID, comment.ID)
+	sourceID := fmt.Sprintf("%s/%s@%s-comment-%d", owner, repo, comment.CommitID, comment.ID)
+	channelID := fmt.Sprintf("chan_github_%s_%s", owner, repo)
+	threadID := fmt.Sprintf("msg_github_%s_%s_commit_%s", owner, repo, comment.CommitID)
+
+	err = database.SaveRawMessage(msgID, "github", sourceID, orgID, channelID, string(rawData), "")
+	if err != nil {
+		return fmt.Errorf("failed to save raw commit comment: %w", err)
+	}
+
+	normalizeCodeBlocks := normalize.ExtractCodeBlocks(comment.Body)
+	codeBlocks := make([]db.CodeBlock, len(normalizeCodeBlocks))
+	for i, cb := range normalizeCodeBlocks {
+		codeBlocks[i] = db.CodeBlock{
+			Language: cb.Language,
+			Code:     cb.Code,
+		}
+	}
+
+	normalized := &db.Message{
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       comment.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(comment.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(comment.Body),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		IsThreadRoot:    false,
+		Mentions:        mentionIDs("github", comment.Body),
+		URLs:            normalize.ExtractURLs(comment.Body),
+		CodeBlocks:      codeBlocks,
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
 
 // storeGitHubTimelineEvent stores a significant timeline event as a message
-func storeGitHubTimelineEvent(database *db.DB, event *github.TimelineEvent, issue *github.Issue, owner, repo, orgID string) error {
+func storeGitHubTimelineEvent(database *db.DB, st store.Store, event *github.TimelineEvent, issue *github.Issue, owner, repo, orgID string) error {
 	username := event.Actor.Login
 	user := &db.User{
 		ID:          fmt.Sprintf("user_github_%s", username),
 		SourceType:  "github",
 		SourceID:    username,
 		DisplayName: &username,
-		FetchedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		FetchedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
 	}
 	database.SaveUser(user)
 
@@ -1434,31 +2156,32 @@ func storeGitHubTimelineEvent(database *db.DB, event *github.TimelineEvent, issu
 	}
 
 	normalized := &db.Message{
-		ID:            msgID,
-		SourceType:    "github",
-		SourceID:      sourceID,
-		Timestamp:     event.CreatedAt,
-		AuthorID:      user.ID,
-		Content:       content,
-		ChannelID:     channelID,
-		ThreadID:      &threadID,
-		ParentID:      &threadID,
-		IsThreadRoot:  false,
-		Mentions:      []string{},
-		URLs:          []string{},
-		CodeBlocks:    []db.CodeBlock{},
-		Attachments:   []db.Attachment{},
-		NormalizedAt:  time.Now(),
-		SchemaVersion: "2.0",
-	}
-
-	err = database.SaveMessage(normalized)
+		ID:              msgID,
+		SourceType:      "github",
+		SourceID:        sourceID,
+		Timestamp:       event.CreatedAt,
+		SourceTimestamp: normalize.GitHubSourceTimestamp(event.CreatedAt),
+		AuthorID:        user.ID,
+		Content:         normalize.CleanText(content),
+		ChannelID:       channelID,
+		ThreadID:        &threadID,
+		ParentID:        &threadID,
+		IsThreadRoot:    false,
+		Mentions:        mentionIDs("github", content),
+		URLs:            []string{},
+		CodeBlocks:      []db.CodeBlock{},
+		Attachments:     []db.Attachment{},
+		NormalizedAt:    clock.Now(),
+		SchemaVersion:   "2.0",
+	}
+
+	err = st.SaveMessage(normalized)
 	if err != nil {
-		return fmt.Errorf("failed to save message: %w", err)
+		return cachedMessageError("github", msgID, sourceID, fmt.Errorf("failed to save message: %w", err))
 	}
 
 	// Enrich the message
-	enrichAndSaveMessage(database, normalized)
+	enrichAndSaveMessage(st, normalized)
 
 	return nil
 }
@@ -0,0 +1,800 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/config"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestFetchGitHub_CommitComments(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	commentID := "msg_github_acme_widgets_commit_abc123_comment_200"
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"not fetched by default", nil, false},
+		{"fetched with the flag", []string{"--include-commit-comments"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbFile := filepath.Join(t.TempDir(), "test.db")
+			args := append([]string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01"}, tt.args...)
+			if err, _ := execute(t, args...); err != nil {
+				t.Fatalf("fetch github failed: %v", err)
+			}
+
+			database, err := db.Open(dbFile)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer database.Close()
+
+			msg, err := database.GetMessage(commentID)
+			if err != nil {
+				t.Fatalf("GetMessage failed: %v", err)
+			}
+			if (msg != nil) != tt.want {
+				t.Fatalf("commit comment stored = %v, want %v", msg != nil, tt.want)
+			}
+			if msg == nil {
+				return
+			}
+			if msg.ThreadID == nil || *msg.ThreadID != "msg_github_acme_widgets_commit_abc123" {
+				t.Errorf("expected thread keyed by commit, got %v", msg.ThreadID)
+			}
+			if msg.AuthorID != "user_github_octocat" || msg.ChannelID != "chan_github_acme_widgets" {
+				t.Errorf("unexpected author/channel: %s, %s", msg.AuthorID, msg.ChannelID)
+			}
+		})
+	}
+}
+
+func TestFetchGitHub_SingleItem(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string // Stored message IDs
+		wantErr int      // Exit code, if the fetch should fail
+	}{
+		{
+			name: "issue",
+			args: []string{"--issue", "1"},
+			want: []string{"msg_github_acme_widgets_1", "msg_github_acme_widgets_1_comment_100"},
+		},
+		{
+			name: "pull request",
+			args: []string{"--pr", "2"},
+			want: []string{"msg_github_acme_widgets_2", "msg_github_acme_widgets_2_review_300"},
+		},
+		{name: "pull request fetched as an issue", args: []string{"--issue", "2"}, wantErr: ExitUsage},
+		{name: "issue fetched as a pull request", args: []string{"--pr", "1"}, wantErr: ExitUsage},
+		{name: "both", args: []string{"--issue", "1", "--pr", "2"}, wantErr: ExitUsage},
+		{name: "missing", args: []string{"--issue", "3"}, wantErr: ExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbFile := filepath.Join(t.TempDir(), "test.db")
+			args := append([]string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets"}, tt.args...)
+			err, _ := execute(t, args...)
+			if tt.wantErr != 0 {
+				if code := ExitCode(err); code != tt.wantErr {
+					t.Fatalf("exit code = %d (%v), want %d", code, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch github failed: %v", err)
+			}
+
+			database, err := db.Open(dbFile)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer database.Close()
+
+			messages, err := database.SelectMessages(db.SelectMessagesOptions{})
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			got := make(map[string]bool)
+			for _, msg := range messages {
+				got[msg.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("stored %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("message %s not stored (got %v)", id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchGitHub_SingleItemNeedsRepo(t *testing.T) {
+	requireFTS5(t)
+
+	err, _ := execute(t, "--db", filepath.Join(t.TempDir(), "test.db"), "fetch", "github", "--org", "acme", "--issue", "1")
+	if ExitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error, got %v", err)
+	}
+}
+
+func TestFetchGitHub_Concurrency(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	for _, concurrency := range []string{"1", "8"} {
+		t.Run(concurrency, func(t *testing.T) {
+			dbFile := filepath.Join(t.TempDir(), "test.db")
+			if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01", "--concurrency", concurrency); err != nil {
+				t.Fatalf("fetch github failed: %v", err)
+			}
+
+			database, err := db.Open(dbFile)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer database.Close()
+
+			if msg, err := database.GetMessage("msg_github_acme_widgets_1_comment_100"); err != nil || msg == nil {
+				t.Errorf("expected the issue's comment to be stored, got %v, %v", msg, err)
+			}
+		})
+	}
+
+	err, _ := execute(t, "--db", filepath.Join(t.TempDir(), "test.db"), "fetch", "github", "--repo", "acme/widgets", "--concurrency", "0")
+	if ExitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error, got %v", err)
+	}
+}
+
+func TestFetchGitHub_EditedComments(t *testing.T) {
+	requireFTS5(t)
+	dir := stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	fetch := func(args ...string) {
+		t.Helper()
+		args = append([]string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01"}, args...)
+		if err, _ := execute(t, args...); err != nil {
+			t.Fatalf("fetch github failed: %v", err)
+		}
+	}
+	commentContent := func() string {
+		t.Helper()
+		database, err := db.Open(dbFile)
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.Close()
+
+		msg, err := database.GetMessage("msg_github_acme_widgets_1_comment_100")
+		if err != nil || msg == nil {
+			t.Fatalf("GetMessage = %v, %v; want the comment", msg, err)
+		}
+		return msg.Content
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	t.Run("first fetch", func(t *testing.T) { fetch() })
+	if got := commentContent(); got != "Fixed by upgrading" {
+		t.Fatalf("comment content = %q after the first fetch", got)
+	}
+
+	// The comment is edited, but the issue isn't updated so the search no
+	// longer returns it. A comment on an issue that was never fetched isn't
+	// stored.
+	writeFile("search.json", `{"total_count": 0, "items": []}`)
+	writeFile("repo_comments.json", `[
+		{"id": 100, "body": "Fixed by upgrading to 2.0", "user": {"login": "hubot"},
+		 "created_at": "2024-01-15T11:00:00Z", "updated_at": "2024-02-01T09:00:00Z",
+		 "issue_url": "https://api.github.com/repos/acme/widgets/issues/1"},
+		{"id": 101, "body": "Same here", "user": {"login": "octocat"},
+		 "created_at": "2024-02-01T10:00:00Z", "updated_at": "2024-02-01T10:00:00Z",
+		 "issue_url": "https://api.github.com/repos/acme/widgets/issues/3"}]`)
+
+	t.Run("without the flag", func(t *testing.T) { fetch() })
+	if got := commentContent(); got != "Fixed by upgrading" {
+		t.Errorf("comment content = %q without --include-edited-comments, want it unchanged", got)
+	}
+
+	t.Run("with the flag", func(t *testing.T) { fetch("--include-edited-comments") })
+	if got := commentContent(); got != "Fixed by upgrading to 2.0" {
+		t.Errorf("comment content = %q with --include-edited-comments, want the edit", got)
+	}
+
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+	if msg, err := database.GetMessage("msg_github_acme_widgets_3_comment_101"); err != nil || msg != nil {
+		t.Errorf("GetMessage(comment on #3) = %v, %v; want no message", msg, err)
+	}
+}
+
+func TestFetchGitHub_CleansContent(t *testing.T) {
+	requireFTS5(t)
+	dir := stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// Entities, a zero-width space, and smart quotes, as pasted from a browser
+	comments := `[{"id": 100, "body": "Don\u2019t use \u201clatest\u201d &amp; pin\u200b 2.0", "user": {"login": "hubot"},
+		"created_at": "2024-01-15T11:00:00Z", "updated_at": "2024-01-15T11:00:00Z"}]`
+	if err := os.WriteFile(filepath.Join(dir, "comments.json"), []byte(comments), 0600); err != nil {
+		t.Fatalf("failed to write comments.json: %v", err)
+	}
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--issue", "1"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+	msg, err := database.GetMessage("msg_github_acme_widgets_1_comment_100")
+	if err != nil || msg == nil {
+		t.Fatalf("GetMessage = %v, %v; want the comment", msg, err)
+	}
+	if want := `Don't use "latest" & pin 2.0`; msg.Content != want {
+		t.Errorf("content = %q, want %q", msg.Content, want)
+	}
+}
+
+func TestFetchGitHub_FSStore(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	t.Setenv("HOME", t.TempDir())
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "--store", "fs", "fetch", "github", "--repo", "acme/widgets", "--issue", "1"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	// Messages go to the normalized storage, the reply graph beside it
+	const issueID = "msg_github_acme_widgets_1"
+	if msg, err := normalize.LoadMessageByID(issueID); err != nil || msg.ID != issueID {
+		t.Errorf("LoadMessageByID = %v, %v", msg, err)
+	}
+	if messages, err := normalize.LoadMessagesBySource("github"); err != nil || len(messages) < 2 {
+		t.Errorf("expected the issue and its comments by source, got %d (err %v)", len(messages), err)
+	}
+	g, err := graph.LoadReplyGraph()
+	if err != nil {
+		t.Fatalf("LoadReplyGraph failed: %v", err)
+	}
+	if thread := g.GetThread(issueID); len(thread) < 2 {
+		t.Errorf("expected the issue's thread in the saved graph, got %v", thread)
+	}
+
+	// Select reads them back from the same store
+	err, out := execute(t, "--db", dbFile, "--store", "fs", "--format", "jsonl", "select", "--thread", issueID)
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if !strings.Contains(out, issueID) {
+		t.Errorf("select --store fs doesn't return %s:\n%s", issueID, out)
+	}
+}
+
+func TestFetchGitHub_FSStoreCompressed(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	normalize.CompressBySource = true
+	t.Cleanup(func() { normalize.CompressBySource = false })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "--store", "fs", "fetch", "github", "--repo", "acme/widgets", "--issue", "1"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	bySource := filepath.Join(home, ".threadmine", "normalized", "messages", "by_source")
+	if _, err := os.Stat(filepath.Join(bySource, "github.jsonl.zst")); err != nil {
+		t.Errorf("expected github.jsonl.zst: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(bySource, "github.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("expected no uncompressed github.jsonl, got err %v", err)
+	}
+
+	const issueID = "msg_github_acme_widgets_1"
+	err, out := execute(t, "--db", dbFile, "--store", "fs", "--format", "jsonl", "select", "--thread", issueID)
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if !strings.Contains(out, issueID) {
+		t.Errorf("select --store fs doesn't return %s:\n%s", issueID, out)
+	}
+}
+
+func TestFetchGitHub_ExportGraph(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "test.db")
+	jsonFile := filepath.Join(dir, "graph.json")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01", "--export-graph", jsonFile); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	data, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("graph not exported: %v", err)
+	}
+	var exported graph.ReplyGraph
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("exported graph doesn't parse: %v", err)
+	}
+
+	// The graph of the stored thread, built the same way
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+	threadID := "msg_github_acme_widgets_1"
+	messages, err := database.SelectMessages(db.SelectMessagesOptions{ThreadID: &threadID})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	want := graph.BuildFromNormalizedMessages(toNormalizedMessages(messages))
+	if len(want.Nodes) < 2 {
+		t.Fatalf("expected the issue and its comment to be stored, got %d messages", len(want.Nodes))
+	}
+	if !reflect.DeepEqual(exported.Nodes, want.Nodes) || !reflect.DeepEqual(exported.Adjacency, want.Adjacency) {
+		t.Errorf("exported graph doesn't match the stored thread:\n%s", data)
+	}
+
+	// Other formats go by the file extension, or --export-graph-format
+	for name, format := range map[string]string{"graph.mmd": "", "graph.txt": "dot"} {
+		path := filepath.Join(dir, name)
+		args := []string{"--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01", "--export-graph", path}
+		if format != "" {
+			args = append(args, "--export-graph-format", format)
+		}
+		// Flags keep their values between runs; don't carry --export-graph-format over
+		resetFlags(rootCmd)
+		if err, _ := execute(t, args...); err != nil {
+			t.Fatalf("fetch github failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("graph not exported: %v", err)
+		}
+		wantPrefix := map[string]string{"graph.mmd": "flowchart LR", "graph.txt": "digraph replies {"}[name]
+		if !strings.HasPrefix(string(data), wantPrefix) {
+			t.Errorf("%s: expected %q, got:\n%s", name, wantPrefix, data)
+		}
+	}
+}
+
+func TestFetchGitHub_SkipStages(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	var summary bytes.Buffer
+	rootCmd.SetOut(&summary)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01", "--no-classify", "--no-graph"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+	if !strings.Contains(summary.String(), "Skipped stages: classify, graph") {
+		t.Errorf("summary doesn't note the skipped stages:\n%s", summary.String())
+	}
+	if strings.Contains(summary.String(), "Classification coverage") {
+		t.Errorf("summary reports coverage without classification:\n%s", summary.String())
+	}
+
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// Messages are stored, without enrichments or thread summaries
+	const issueID = "msg_github_acme_widgets_1"
+	threadID := issueID
+	messages, err := database.SelectMessages(db.SelectMessagesOptions{ThreadID: &threadID})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	if len(messages) < 2 {
+		t.Fatalf("expected the issue and its comment to be stored, got %d messages", len(messages))
+	}
+	var enrichments int
+	if err := database.QueryRow("SELECT COUNT(*) FROM enrichments").Scan(&enrichments); err != nil {
+		t.Fatalf("failed to count enrichments: %v", err)
+	}
+	if enrichments != 0 {
+		t.Errorf("expected no enrichments, got %d", enrichments)
+	}
+	if thread, err := database.GetThread(issueID); err != nil || thread != nil {
+		t.Errorf("thread %s has summary %+v (err %v)", issueID, thread, err)
+	}
+
+	// Reprocessing fills them in
+	if err, _ := execute(t, "--db", dbFile, "reprocess"); err != nil {
+		t.Fatalf("reprocess failed: %v", err)
+	}
+	if enrichment, err := database.GetEnrichment(issueID); err != nil || enrichment == nil {
+		t.Errorf("reprocess didn't enrich %s (err %v)", issueID, err)
+	}
+	if thread, err := database.GetThread(issueID); err != nil || thread == nil {
+		t.Errorf("reprocess didn't summarize thread %s (err %v)", issueID, err)
+	}
+}
+
+// logGHCalls puts a gh on PATH that logs its calls, one per line, on the way
+// to the stub gh in stub, and returns the path of the log
+func logGHCalls(t *testing.T, stub string) string {
+	t.Helper()
+
+	logDir := t.TempDir()
+	callLog := filepath.Join(logDir, "calls.log")
+	script := "#!/bin/sh\necho \"$*\" >> " + callLog + "\nexec " + filepath.Join(stub, "gh") + " \"$@\"\n"
+	if err := os.WriteFile(filepath.Join(logDir, "gh"), []byte(script), 0700); err != nil {
+		t.Fatalf("failed to write logging gh: %v", err)
+	}
+	t.Setenv("PATH", logDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return callLog
+}
+
+func TestFetchGitHub_SearchQualifiers(t *testing.T) {
+	requireFTS5(t)
+	callLog := logGHCalls(t, stubGHFetch(t))
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01",
+		"--author", "alice", "--mentions", "carol"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	data, err := os.ReadFile(callLog)
+	if err != nil {
+		t.Fatalf("failed to read call log: %v", err)
+	}
+	searched := false
+	for _, call := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.Contains(call, "search/issues") {
+			continue
+		}
+		searched = true
+		for _, want := range []string{"repo%3Aacme%2Fwidgets", "author%3Aalice", "mentions%3Acarol", "updated%3A%3E%3D2024-01-01"} {
+			if !strings.Contains(call, want) {
+				t.Errorf("search %q doesn't filter on %s", call, want)
+			}
+		}
+	}
+	if !searched {
+		t.Errorf("no search call in:\n%s", data)
+	}
+}
+
+func TestFetchGitHub_IssueFilterParams(t *testing.T) {
+	requireFTS5(t)
+	callLog := logGHCalls(t, stubGHFetch(t))
+
+	// Issues filtered by author and mention are listed, not searched
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01",
+		"--issues-only", "--author", "octocat", "--mentions", "carol"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	data, err := os.ReadFile(callLog)
+	if err != nil {
+		t.Fatalf("failed to read call log: %v", err)
+	}
+	listed := false
+	for _, call := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.Contains(call, "search/issues") {
+			t.Errorf("unexpected search %q", call)
+		}
+		if !strings.Contains(call, "repos/acme/widgets/issues?state=all") {
+			continue
+		}
+		listed = true
+		for _, want := range []string{"since=2024-01-01T00:00:00Z", "&creator=octocat", "&mentioned=carol"} {
+			if !strings.Contains(call, want) {
+				t.Errorf("issues call %q doesn't pass %s", call, want)
+			}
+		}
+	}
+	if !listed {
+		t.Errorf("no issues call in:\n%s", data)
+	}
+
+	err, out := execute(t, "--db", dbFile, "--format", "json", "select", "--author", "octocat")
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if got, want := selectedIDs(t, out), []string{"msg_github_acme_widgets_1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("select --author octocat = %v, want %v", got, want)
+	}
+}
+
+func TestFetchGitHub_IssuesOrPRsOnly(t *testing.T) {
+	requireFTS5(t)
+	callLog := logGHCalls(t, stubGHFetch(t))
+
+	tests := []struct {
+		flag        string
+		wantQuery   string
+		unwanted    []string // Substrings of calls that must not be made
+		wantSummary string
+	}{
+		{"--issues-only", "is%3Aissue", []string{"is%3Apr", "pulls/"}, "Issues: 1, pull requests: 0"},
+		{"--prs-only", "is%3Apr", []string{"is%3Aissue"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			os.Remove(callLog)
+			var summary bytes.Buffer
+			rootCmd.SetOut(&summary)
+			t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+			dbFile := filepath.Join(t.TempDir(), "test.db")
+			if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01", tt.flag); err != nil {
+				t.Fatalf("fetch github failed: %v", err)
+			}
+
+			data, err := os.ReadFile(callLog)
+			if err != nil {
+				t.Fatalf("failed to read call log: %v", err)
+			}
+			searched := false
+			for _, call := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				if strings.Contains(call, "search/issues") {
+					searched = true
+					if !strings.Contains(call, tt.wantQuery) {
+						t.Errorf("search %q doesn't filter on %s", call, tt.wantQuery)
+					}
+				}
+				for _, unwanted := range tt.unwanted {
+					if strings.Contains(call, unwanted) {
+						t.Errorf("unexpected call %q", call)
+					}
+				}
+			}
+			if !searched {
+				t.Errorf("no search call in:\n%s", data)
+			}
+			if !strings.Contains(summary.String(), tt.wantSummary) {
+				t.Errorf("summary doesn't report %q:\n%s", tt.wantSummary, summary.String())
+			}
+		})
+	}
+}
+
+func TestFetchGitHub_IncludeFiles(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--pr", "2", "--include-files"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	// The files and their directories are entities of the pull request
+	err, out := execute(t, "--db", dbFile, "--format", "json", "select", "--has-entity", "directory=auth/oauth/")
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if got, want := selectedIDs(t, out), []string{"msg_github_acme_widgets_2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("select --has-entity directory=auth/oauth/ = %v, want %v", got, want)
+	}
+
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	entities, err := database.ListEntities(db.ListEntitiesOptions{})
+	if err != nil {
+		t.Fatalf("ListEntities failed: %v", err)
+	}
+	var got []string
+	for _, e := range entities {
+		if e.Type == db.EntityTypeFilePath || e.Type == db.EntityTypeDirectory {
+			got = append(got, e.Type+"="+e.Value)
+		}
+	}
+	want := []string{"directory=auth/", "directory=auth/oauth/", "file_path=auth/login.go", "file_path=auth/oauth/token.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entities = %v, want %v", got, want)
+	}
+
+	// The totals are in the raw pull request, for select --meta
+	err, out = execute(t, "--db", dbFile, "--format", "json", "select", "--meta", "additions=50")
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if got, want := selectedIDs(t, out), []string{"msg_github_acme_widgets_2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("select --meta additions=50 = %v, want %v", got, want)
+	}
+}
+
+// searchIssueAndPR makes the search of the stub gh from stubGHFetch find
+// pull request #2 next to issue #1
+func searchIssueAndPR(t *testing.T, stub string) {
+	t.Helper()
+
+	search := `{"total_count": 2, "items": [{"number": 1, "title": "Widgets crash", "body": "They crash on start",
+		"state": "open", "user": {"login": "octocat"}, "created_at": "2024-01-15T10:00:00Z",
+		"updated_at": "2024-01-15T11:00:00Z", "repository_url": "https://api.github.com/repos/acme/widgets"},
+		{"number": 2, "title": "Fix the crash", "body": "Fixes #1", "state": "open", "user": {"login": "hubot"},
+		"created_at": "2024-01-16T10:00:00Z", "updated_at": "2024-01-16T11:00:00Z",
+		"repository_url": "https://api.github.com/repos/acme/widgets",
+		"pull_request": {"url": "https://api.github.com/repos/acme/widgets/pulls/2"}}]}`
+	if err := os.WriteFile(filepath.Join(stub, "search.json"), []byte(search), 0600); err != nil {
+		t.Fatalf("failed to write search.json: %v", err)
+	}
+}
+
+func TestFetchGitHub_PullRequestsOfTypeAll(t *testing.T) {
+	requireFTS5(t)
+	searchIssueAndPR(t, stubGHFetch(t))
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// Issues and PRs both, so the PR-only lookups go by each item
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "2024-01-01",
+		"--type", "all", "--include-files", "--author-email"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+
+	for _, entity := range []string{"file_path=auth/login.go", "requested_reviewer=user_github_octocat"} {
+		err, out := execute(t, "--db", dbFile, "--format", "json", "select", "--has-entity", entity)
+		if err != nil {
+			t.Fatalf("select failed: %v", err)
+		}
+		if got, want := selectedIDs(t, out), []string{"msg_github_acme_widgets_2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("select --has-entity %s = %v, want %v", entity, got, want)
+		}
+	}
+
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	author, err := database.GetUser("user_github_hubot")
+	if err != nil || author == nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if author.Email == nil || *author.Email != "hubot@example.com" {
+		t.Errorf("PR author email = %v, want hubot@example.com", author.Email)
+	}
+}
+
+// useConfig replaces the loaded config with one read from content for the test
+func useConfig(t *testing.T, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	saved := globalConfig
+	globalConfig = cfg
+	t.Cleanup(func() { globalConfig = saved })
+}
+
+func TestFetch_ConfigDefaults(t *testing.T) {
+	requireFTS5(t)
+	stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// The stub only serves acme/widgets, so a fetch succeeds only if it
+	// resolves to that repository
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		wantErr int // Exit code, if the fetch should fail
+	}{
+		{name: "config owner and repo", config: "[fetch.github]\nowner = acme\nrepo = widgets\n"},
+		{name: "config org and repo", config: "[fetch.github]\norg = acme\nrepo = widgets\n"},
+		{name: "repo flag over config", config: "[fetch.github]\nrepo = acme/gadgets\n", args: []string{"--repo", "acme/widgets"}},
+		{name: "owner flag over config", config: "[fetch.github]\norg = other\nrepo = widgets\n", args: []string{"--owner", "acme"}},
+		{name: "org flag over config", config: "[fetch.github]\norg = other\nrepo = widgets\n", args: []string{"--org", "acme"}},
+		{name: "neither flag nor config", config: "", wantErr: ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, tt.config)
+			dbFile := filepath.Join(t.TempDir(), "test.db")
+			args := append([]string{"--db", dbFile, "fetch", "github", "--issue", "1"}, tt.args...)
+			err, _ := execute(t, args...)
+			if tt.wantErr != 0 {
+				if code := ExitCode(err); code != tt.wantErr {
+					t.Fatalf("exit code = %d (%v), want %d", code, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch github failed: %v", err)
+			}
+
+			database, err := db.Open(dbFile)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer database.Close()
+			msg, err := database.GetMessage("msg_github_acme_widgets_1")
+			if err != nil || msg == nil {
+				t.Errorf("issue not stored from acme/widgets: %v", err)
+			}
+		})
+	}
+
+	t.Run("slack workspace", func(t *testing.T) {
+		useConfig(t, "")
+		err, _ := execute(t, "--db", filepath.Join(t.TempDir(), "test.db"), "fetch", "slack", "--channel", "general")
+		if code := ExitCode(err); code != ExitUsage {
+			t.Fatalf("without a workspace: exit code = %d (%v), want %d", code, err, ExitUsage)
+		}
+		if !strings.Contains(err.Error(), "fetch.slack.workspace") {
+			t.Errorf("error doesn't mention the config key: %v", err)
+		}
+	})
+}
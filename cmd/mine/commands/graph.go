@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Summarize the reply graph of stored messages",
+	Long: `Build the reply graph of stored messages and show its statistics and the
+largest or most recently active threads.
+
+Only the top threads are listed (10 by default); the full graph (every node
+and reply edge) is included only with --full.
+
+Examples:
+  # The 10 largest threads
+  mine graph --format table
+
+  # The 5 most recently active Slack threads of the last month
+  mine graph --source slack --since 30d --sort recent --top 5
+
+  # Everything, including the full graph
+  mine graph --limit 0 --full`,
+	RunE: runGraph,
+}
+
+var (
+	graphLimit  int
+	graphSort   string
+	graphFull   bool
+	graphSource string
+	graphSince  string
+)
+
+// graphSummary is the output of mine graph
+type graphSummary struct {
+	Stats   map[string]interface{}  `json:"stats"`
+	Threads []*graph.ThreadActivity `json:"threads"`
+	Graph   *graph.ReplyGraph       `json:"graph,omitempty"` // Only with --full
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().IntVar(&graphLimit, "limit", 10, "Number of threads to list (0 for all)")
+	graphCmd.Flags().IntVar(&graphLimit, "top", 10, "Alias for --limit")
+	graphCmd.Flags().StringVar(&graphSort, "sort", graph.SortBySize, "Thread order: size (most replies first) or recent (latest activity first)")
+	graphCmd.Flags().BoolVar(&graphFull, "full", false, "Include every node and reply edge of the graph")
+	graphCmd.Flags().StringVar(&graphSource, "source", "", "Only messages from this source type: slack, github")
+	graphCmd.Flags().StringVar(&graphSince, "since", "", "Only messages since this date (YYYY-MM-DD, RFC3339, or relative like 7d)")
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	if graphLimit < 0 {
+		return usageErrorf("--limit must not be negative")
+	}
+	if graphSort != graph.SortBySize && graphSort != graph.SortByRecent {
+		return usageErrorf("unknown --sort value: %s (expected %s or %s)", graphSort, graph.SortBySize, graph.SortByRecent)
+	}
+
+	var opts db.SelectMessagesOptions
+	if graphSince != "" {
+		since, err := utils.ParseSinceDate(graphSince)
+		if err != nil {
+			return usageErrorf("invalid --since value: %w", err)
+		}
+		opts.Since = &since
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	if graphSource != "" {
+		opts.SourceType = &graphSource
+	}
+	messages, err := st.SelectMessages(opts)
+	if err != nil {
+		return fmt.Errorf("failed to select messages: %w", err)
+	}
+
+	g := graph.BuildFromNormalizedMessages(toNormalizedMessages(messages))
+	threads, err := g.TopThreads(graphLimit, graphSort)
+	if err != nil {
+		return err
+	}
+
+	summary := graphSummary{Stats: g.Stats(), Threads: threads}
+	if graphFull {
+		summary.Graph = g
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(summary)
+	case "jsonl", "ndjson":
+		return OutputJSONL([]graphSummary{summary})
+	case "table":
+		return outputGraphTable(os.Stdout, g, summary)
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+}
+
+// outputGraphTable writes the graph statistics and listed threads, followed by
+// the structure of each listed thread when the summary includes the graph
+func outputGraphTable(out io.Writer, g *graph.ReplyGraph, summary graphSummary) error {
+	fmt.Fprintf(out, "Messages: %v\n", summary.Stats["total_messages"])
+	fmt.Fprintf(out, "Threads: %v\n", summary.Stats["thread_count"])
+	fmt.Fprintf(out, "Average thread depth: %.2f\n\n", summary.Stats["average_thread_depth"])
+
+	if len(summary.Threads) == 0 {
+		fmt.Fprintln(out, "No threads found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ROOT\tREPLIES\tPARTICIPANTS\tDEPTH\tLAST ACTIVITY\n")
+	fmt.Fprintf(w, "----\t-------\t------------\t-----\t-------------\n")
+	for _, thread := range summary.Threads {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n",
+			thread.RootID,
+			thread.ReplyCount,
+			len(thread.Participants),
+			thread.MaxDepth,
+			thread.LastActivityAt.Local().Format("2006-01-02 15:04"),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if summary.Graph == nil {
+		return nil
+	}
+	for _, thread := range summary.Threads {
+		fmt.Fprintf(out, "\n%s\n", thread.RootID)
+		writeReplyTree(out, g, thread.RootID, 1)
+	}
+	return nil
+}
+
+// writeReplyTree writes the replies to messageID, indented by depth
+func writeReplyTree(out io.Writer, g *graph.ReplyGraph, messageID string, depth int) {
+	for _, childID := range g.GetChildren(messageID) {
+		node, ok := g.Nodes[childID]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(out, "%*s- %s (%s)\n", 2*depth, "", childID, node.Timestamp.Local().Format("2006-01-02 15:04"))
+		writeReplyTree(out, g, childID, depth+1)
+	}
+}
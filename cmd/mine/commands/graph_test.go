@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+// seedGraphDB stores two Slack threads: a large one (2 replies) that went quiet,
+// and a small one (1 reply) active an hour later
+func seedGraphDB(t *testing.T) string {
+	t.Helper()
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	big, small := "msg_slack_C1_1.0", "msg_slack_C1_2.0"
+	reply := "msg_slack_C1_1.1"
+	for _, msg := range []*db.Message{
+		{ID: big, Timestamp: base, ThreadID: &big, IsThreadRoot: true},
+		{ID: reply, Timestamp: base.Add(time.Minute), ThreadID: &big, ParentID: &big},
+		{ID: "msg_slack_C1_1.2", Timestamp: base.Add(2 * time.Minute), ThreadID: &big, ParentID: &reply},
+		{ID: small, Timestamp: base, ThreadID: &small, IsThreadRoot: true},
+		{ID: "msg_slack_C1_2.1", Timestamp: base.Add(time.Hour), ThreadID: &small, ParentID: &small},
+	} {
+		msg.SourceType, msg.SourceID, msg.AuthorID, msg.ChannelID = "slack", msg.ID, "user_slack_U1", "chan_slack_C1"
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	return dbFile
+}
+
+func TestGraph_TopThreads(t *testing.T) {
+	requireFTS5(t)
+	dbFile := seedGraphDB(t)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantRoots []string
+		wantFull  bool
+	}{
+		{"default lists all by size", nil, []string{"msg_slack_C1_1.0", "msg_slack_C1_2.0"}, false},
+		{"top 1 by size", []string{"--top", "1"}, []string{"msg_slack_C1_1.0"}, false},
+		{"limit 1 by recent", []string{"--limit", "1", "--sort", "recent"}, []string{"msg_slack_C1_2.0"}, false},
+		{"full graph", []string{"--limit", "1", "--full"}, []string{"msg_slack_C1_1.0"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, out := execute(t, append([]string{"--db", dbFile, "graph"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("graph failed: %v", err)
+			}
+
+			var summary struct {
+				Threads []struct {
+					RootID string `json:"root_id"`
+				} `json:"threads"`
+				Graph *struct {
+					Nodes map[string]json.RawMessage `json:"nodes"`
+				} `json:"graph"`
+			}
+			if err := json.Unmarshal([]byte(out), &summary); err != nil {
+				t.Fatalf("output is not a graph summary: %v\n%s", err, out)
+			}
+
+			var roots []string
+			for _, thread := range summary.Threads {
+				roots = append(roots, thread.RootID)
+			}
+			if strings.Join(roots, ",") != strings.Join(tt.wantRoots, ",") {
+				t.Errorf("threads = %v, want %v", roots, tt.wantRoots)
+			}
+			if (summary.Graph != nil) != tt.wantFull {
+				t.Errorf("graph included = %v, want %v", summary.Graph != nil, tt.wantFull)
+			}
+			if summary.Graph != nil && len(summary.Graph.Nodes) != 5 {
+				t.Errorf("expected 5 nodes in the full graph, got %d", len(summary.Graph.Nodes))
+			}
+		})
+	}
+}
+
+func TestGraph_Table(t *testing.T) {
+	requireFTS5(t)
+	dbFile := seedGraphDB(t)
+
+	err, out := execute(t, "--db", dbFile, "graph", "--format", "table", "--top", "1", "--full")
+	if err != nil {
+		t.Fatalf("graph failed: %v", err)
+	}
+	if !strings.Contains(out, "msg_slack_C1_1.0") || strings.Contains(out, "msg_slack_C1_2.0") {
+		t.Errorf("expected only the largest thread:\n%s", out)
+	}
+	if !strings.Contains(out, "    - msg_slack_C1_1.2") {
+		t.Errorf("expected the nested reply in the thread structure:\n%s", out)
+	}
+}
+
+func TestGraph_InvalidSort(t *testing.T) {
+	err, _ := execute(t, "graph", "--sort", "oldest")
+	if err == nil || ExitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error, got %v", err)
+	}
+}
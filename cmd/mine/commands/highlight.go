@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+// Values of --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// useColor reports whether output is colored under --color: always, never,
+// or (auto) when stdout is a terminal and NO_COLOR isn't set
+func useColor() bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// highlighter marks the terms of a search in rendered content
+type highlighter struct {
+	pattern    *regexp.Regexp // nil when there's nothing to mark
+	start, end string
+}
+
+// newHighlighter returns a highlighter for the words and phrases of a
+// --search query (see db.SearchTerms), which marks them in bold yellow with
+// color and as **term** without. Queries with operators other than AND mark
+// nothing, since not every term has to match.
+func newHighlighter(query string, caseSensitive, color bool) *highlighter {
+	h := &highlighter{start: "**", end: "**"}
+	if color {
+		h.start, h.end = "\x1b[1;33m", "\x1b[0m"
+	}
+
+	terms, err := db.SearchTerms(query)
+	if err != nil || len(terms) == 0 {
+		return h
+	}
+	// Longer terms first, so a phrase wins over the words in it
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	expr := strings.Join(quoted, "|")
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	h.pattern = regexp.MustCompile(expr)
+	return h
+}
+
+// highlight marks the search terms in s
+func (h *highlighter) highlight(s string) string {
+	if h == nil || h.pattern == nil {
+		return s
+	}
+	return h.pattern.ReplaceAllStringFunc(s, func(match string) string {
+		return h.start + match + h.end
+	})
+}
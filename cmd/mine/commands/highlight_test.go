@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestHighlighter(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		caseSensitive bool
+		content       string
+		want          string
+	}{
+		{"word", "deploy", false, "The deploy failed", "The **deploy** failed"},
+		{"any case", "deploy", false, "Deploy failed, redeploy", "**Deploy** failed, re**deploy**"},
+		{"case-sensitive", "Deploy", true, "Deploy failed, redeploy", "**Deploy** failed, redeploy"},
+		{"several words", "deploy AND staging", false, "deploy to staging", "**deploy** to **staging**"},
+		{"phrase over its words", `"staging deploy" deploy`, false, "the staging deploy, a deploy", "the **staging deploy**, a **deploy**"},
+		{"prefix", "deploy*", false, "deployment", "**deploy**ment"},
+		{"special characters", "c++", false, "written in c++", "written in **c++**"},
+		{"no match", "deploy", false, "All quiet", "All quiet"},
+		{"no search", "", false, "The deploy failed", "The deploy failed"},
+		{"OR query", "deploy OR release", false, "The deploy failed", "The deploy failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newHighlighter(tt.query, tt.caseSensitive, false).highlight(tt.content)
+			if got != tt.want {
+				t.Errorf("highlight(%q) with search %q = %q, want %q", tt.content, tt.query, got, tt.want)
+			}
+		})
+	}
+
+	if got, want := newHighlighter("deploy", false, true).highlight("a deploy"), "a \x1b[1;33mdeploy\x1b[0m"; got != want {
+		t.Errorf("color highlight = %q, want %q", got, want)
+	}
+}
+
+func TestSelect_HighlightsSearchInTable(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	for i, content := range []string{"The deploy to staging failed", "Lunch?"} {
+		msg := &db.Message{ID: "msg_" + string(rune('a'+i)), SourceType: "slack", SourceID: "s", AuthorID: "user_a",
+			ChannelID: "chan_x", Content: content, IsThreadRoot: true,
+			Timestamp: time.Date(2024, 1, 15, 10, i, 0, 0, time.UTC)}
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"select", "--search", "deploy"}, "The **deploy** to staging failed"},
+		{[]string{"select", "--search", "deploy", "--threads-only"}, "The **deploy** to staging failed"},
+		{[]string{"select", "--search", "deploy", "--color", "always"}, "The \x1b[1;33mdeploy\x1b[0m to staging failed"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			err, out := execute(t, append([]string{"--db", dbFile, "--format", "table"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output doesn't contain %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/eventlog"
+	"github.com/spf13/cobra"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Inspect the fetch event log",
+	Long: `Inspect the append-only log of fetch operations.
+
+Each fetch records its parameters, message counts, duration, and any error
+to ~/.threadmine/logs/fetch.jsonl.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("please specify a subcommand: tail")
+	},
+}
+
+var logTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Show the most recent fetch events",
+	Long: `Show the most recent fetch events, oldest first.
+
+Examples:
+  # Show the last 10 fetches
+  mine log tail
+
+  # Show the last 50 fetches as a table
+  mine log tail -n 50 --format table`,
+	RunE: runLogTail,
+}
+
+var (
+	logTailLines int
+)
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.AddCommand(logTailCmd)
+
+	logTailCmd.Flags().IntVarP(&logTailLines, "lines", "n", 10, "Number of events to show (0 for all)")
+}
+
+func runLogTail(cmd *cobra.Command, args []string) error {
+	path, err := eventlog.DefaultLogPath()
+	if err != nil {
+		return err
+	}
+
+	events, err := eventlog.Tail(path, logTailLines)
+	if err != nil {
+		return fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(events)
+	case "jsonl", "ndjson":
+		return OutputJSONL(events)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "TIMESTAMP\tCOMMAND\tMESSAGES\tTHREADS\tDURATION\tERROR\n")
+		fmt.Fprintf(w, "---------\t-------\t--------\t-------\t--------\t-----\n")
+		for _, event := range events {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n",
+				event.Timestamp.Local().Format("2006-01-02 15:04"),
+				event.Command,
+				event.Messages,
+				event.Threads,
+				(time.Duration(event.DurationMS) * time.Millisecond).String(),
+				event.Error,
+			)
+		}
+		return nil
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+}
+
+// recordFetchEvent appends a completed fetch to the event log.
+// Failures to write the log are reported but never fail the fetch itself.
+func recordFetchEvent(cmd *cobra.Command, event *eventlog.Event, fetchErr error) {
+	event.DurationMS = time.Since(event.Timestamp).Milliseconds()
+	if fetchErr != nil {
+		event.Error = fetchErr.Error()
+	}
+
+	path, err := eventlog.DefaultLogPath()
+	if err == nil {
+		err = eventlog.Append(path, event)
+	}
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "Warning: failed to write fetch event log: %v\n", err)
+	}
+}
+
+// nonEmptyParams returns a copy of params without empty values
+func nonEmptyParams(params map[string]string) map[string]string {
+	result := make(map[string]string)
+	for k, v := range params {
+		if v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
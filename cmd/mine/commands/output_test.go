@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONL(t *testing.T) {
+	type record struct {
+		ID      string `json:"id"`
+		Content string `json:"content"`
+	}
+	records := []record{
+		{ID: "msg_1", Content: "first"},
+		{ID: "msg_2", Content: "second\nwith a newline"},
+		{ID: "msg_3", Content: `<html> & "quotes"`},
+	}
+
+	tests := []struct {
+		name   string
+		pretty bool
+	}{
+		{"compact", false},
+		{"pretty", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeJSONL(&buf, records, tt.pretty); err != nil {
+				t.Fatalf("writeJSONL failed: %v", err)
+			}
+			out := buf.String()
+
+			if !strings.HasSuffix(out, "}\n") {
+				t.Errorf("expected output to end with a newline-terminated record, got %q", out)
+			}
+
+			// Every record decodes as one JSON value, in order
+			dec := json.NewDecoder(strings.NewReader(out))
+			for i, want := range records {
+				var got record
+				if err := dec.Decode(&got); err != nil {
+					t.Fatalf("record %d is not valid JSON: %v", i, err)
+				}
+				if got != want {
+					t.Errorf("record %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if err := dec.Decode(&struct{}{}); err != io.EOF {
+				t.Errorf("expected exactly %d records, got extra data (%v)", len(records), err)
+			}
+
+			if !tt.pretty {
+				lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+				if len(lines) != len(records) {
+					t.Fatalf("expected %d lines, got %d", len(records), len(lines))
+				}
+				for i, line := range lines {
+					if !json.Valid([]byte(line)) {
+						t.Errorf("line %d is not valid JSON: %q", i, line)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJSONL_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSONL(&buf, []int{}, false); err != nil {
+		t.Fatalf("writeJSONL failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output for no records, got %q", buf.String())
+	}
+}
+
+func TestSelect_NDJSONAlias(t *testing.T) {
+	requireFTS5(t)
+
+	err, out := execute(t, "--db", t.TempDir()+"/test.db", "select", "--format", "ndjson", "--count-by", "source")
+	if err != nil {
+		t.Fatalf("select --format ndjson failed: %v", err)
+	}
+	if out != "" {
+		t.Errorf("expected no records from an empty database, got %q", out)
+	}
+}
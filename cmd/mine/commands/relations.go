@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/classify"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// storeReferenceResolver resolves references against stored messages and channels
+type storeReferenceResolver struct {
+	database *db.DB
+	st       store.Store
+}
+
+func (r *storeReferenceResolver) ResolveReference(ref classify.Reference) (string, bool) {
+	var id string
+	switch {
+	case ref.Source == "github":
+		owner, repo, ok := strings.Cut(ref.Repo, "/")
+		if !ok {
+			return "", false
+		}
+		id = fmt.Sprintf("msg_github_%s_%s_%d", owner, repo, ref.Number)
+	case ref.TS != "":
+		id = fmt.Sprintf("msg_slack_%s_%s", ref.Channel, ref.TS)
+	default:
+		channel, err := r.database.GetChannel(fmt.Sprintf("chan_slack_%s", ref.Channel))
+		if err != nil || channel == nil {
+			return "", false
+		}
+		return channel.ID, true
+	}
+
+	msg, err := r.st.LoadMessage(id)
+	if err != nil || msg == nil {
+		return "", false
+	}
+	return msg.ID, true
+}
+
+// relateReferences records a resolves_via relation for each answer in the
+// recorded threads that points to another message or channel in the corpus,
+// a shares relation for each message shared into one that is in it, and a
+// references relation for each Slack permalink to one that is in it, unless
+// the recorder skips classification
+func relateReferences(cmd *cobra.Command, database *db.DB, recorder *threadRecorder) {
+	if recorder.skipClassify {
+		return
+	}
+	resolver := &storeReferenceResolver{database: database, st: recorder.Store}
+
+	counts := make(map[string]int)
+	for _, threadID := range recorder.order {
+		id := threadID
+		thread, err := recorder.Store.SelectMessages(db.SelectMessagesOptions{ThreadID: &id})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to load thread %s: %v\n", threadID, err)
+			continue
+		}
+
+		messages := toNormalizedMessages(thread)
+		relations := classify.ResolvesViaRelations(messages, resolver)
+		relations = append(relations, classify.SharesRelations(messages, resolver)...)
+		relations = append(relations, classify.ReferencesRelations(messages, resolver)...)
+		for _, rel := range relations {
+			err := database.SaveMessageRelation(&db.MessageRelation{
+				FromMessageID: rel.FromID,
+				ToMessageID:   rel.ToID,
+				RelationType:  rel.Type,
+				Confidence:    rel.Confidence,
+			})
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save relation: %v\n", err)
+				continue
+			}
+			counts[rel.Type]++
+		}
+	}
+
+	if counts[classify.RelationResolvesVia] > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Answers by reference: %d\n", counts[classify.RelationResolvesVia])
+	}
+	if counts[classify.RelationShares] > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Shared messages in the corpus: %d\n", counts[classify.RelationShares])
+	}
+	if counts[classify.RelationReferences] > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Links to messages in the corpus: %d\n", counts[classify.RelationReferences])
+	}
+}
+
+// relateCrossPosts records a duplicate_of relation from each copy of a Slack
+// message in the recorded threads that its author cross-posted to other
+// channels to the copy posted first. Copies are looked up among all stored
+// messages, so a message fetched from one channel is related to copies
+// fetched earlier from others.
+func relateCrossPosts(cmd *cobra.Command, database *db.DB, recorder *threadRecorder) {
+	related := make(map[string]bool) // Copies already related, by ID
+	for _, threadID := range recorder.order {
+		thread, err := recorder.loadThread(threadID)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to load thread %s: %v\n", threadID, err)
+			continue
+		}
+
+		for _, msg := range thread {
+			if msg.SourceType != "slack" {
+				continue
+			}
+			author := msg.AuthorID
+			since, until := msg.Timestamp.Add(-classify.CrossPostWindow), msg.Timestamp.Add(classify.CrossPostWindow)
+			candidates, err := recorder.Store.SelectMessages(db.SelectMessagesOptions{AuthorID: &author, Since: &since, Until: &until})
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to look up copies of %s: %v\n", msg.ID, err)
+				continue
+			}
+
+			for _, rel := range classify.DuplicateRelations(toNormalizedMessage(msg), toNormalizedMessages(candidates)) {
+				if related[rel.FromID] {
+					continue
+				}
+				err := database.SaveMessageRelation(&db.MessageRelation{
+					FromMessageID: rel.FromID,
+					ToMessageID:   rel.ToID,
+					RelationType:  rel.Type,
+					Confidence:    rel.Confidence,
+				})
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save relation: %v\n", err)
+					continue
+				}
+				related[rel.FromID] = true
+			}
+		}
+	}
+
+	if len(related) > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Cross-posted copies: %d\n", len(related))
+	}
+}
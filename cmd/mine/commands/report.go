@@ -0,0 +1,289 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report on how channels are served",
+	Long:  `Report metrics computed over the stored messages and threads.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("please specify a subcommand: response-time, task-progress, thread")
+	},
+}
+
+var reportResponseTimeCmd = &cobra.Command{
+	Use:   "response-time",
+	Short: "Show how quickly threads get a first response",
+	Long: `Report, for each channel or GitHub repository, how many threads got a reply
+from someone other than the person who started them, the median time to that
+first reply, and who replied first most often. Threads nobody else replied to
+count toward the threads but not the median.
+
+--since filters on when threads started.
+
+Examples:
+  # First response times of the last 30 days
+  mine report response-time --since 30d --format table
+
+  # Who answers first in one channel
+  mine report response-time --channel help`,
+	RunE: runReportResponseTime,
+}
+
+var reportTaskProgressCmd = &cobra.Command{
+	Use:   "task-progress",
+	Short: "Show how far along issues with task lists are",
+	Long: `Report the GitHub issues and pull requests whose descriptions have task
+lists ("- [x] done", "- [ ] todo"), with how many of their tasks are checked.
+Unfinished ones come first, the closest to done at the top; finished ones
+follow. Progress is computed from the stored description, so refetching an
+edited issue updates it.
+
+Examples:
+  # Open issues that are nearly done
+  mine report task-progress --only-open --format table
+
+  # Task lists in one repository
+  mine report task-progress --channel acme/widgets`,
+	RunE: runReportTaskProgress,
+}
+
+var (
+	reportResponseTimeSince   string
+	reportResponseTimeChannel string
+
+	reportTaskProgressChannel  string
+	reportTaskProgressOnlyOpen bool
+	reportTaskProgressLimit    int
+)
+
+// issueTaskProgress is the task list completion of an issue or pull request
+type issueTaskProgress struct {
+	MessageID string `json:"message_id"`
+	Issue     string `json:"issue"` // owner/repo#number
+	Title     string `json:"title"`
+	*normalize.TaskProgress
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportResponseTimeCmd)
+	reportCmd.AddCommand(reportTaskProgressCmd)
+
+	reportResponseTimeCmd.Flags().StringVar(&reportResponseTimeSince, "since", "", "Only count threads started since this date (YYYY-MM-DD, RFC3339, or relative like 30d)")
+	reportResponseTimeCmd.Flags().StringVar(&reportResponseTimeChannel, "channel", "", "Only count threads in this channel (name or ID)")
+
+	reportTaskProgressCmd.Flags().StringVar(&reportTaskProgressChannel, "channel", "", "Only issues in this repository (name or ID)")
+	reportTaskProgressCmd.Flags().BoolVar(&reportTaskProgressOnlyOpen, "only-open", false, "Only issues neither resolved nor dismissed")
+	reportTaskProgressCmd.Flags().IntVar(&reportTaskProgressLimit, "limit", 0, "Maximum number of issues (0 for all)")
+}
+
+func runReportResponseTime(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+
+	opts := db.SelectMessagesOptions{}
+	if reportResponseTimeSince != "" {
+		since, err := utils.ParseSinceDate(reportResponseTimeSince)
+		if err != nil {
+			return usageErrorf("invalid --since value: %w", err)
+		}
+		opts.Since = &since
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	if reportResponseTimeChannel != "" {
+		channelID, err := resolveChannelID(database, reportResponseTimeChannel, false)
+		if err != nil {
+			return err
+		}
+		opts.ChannelID = &channelID
+	}
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	responseTimes, err := st.ResponseTimes(opts)
+	if err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(responseTimes)
+	case "jsonl", "ndjson":
+		return OutputJSONL(responseTimes)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		userNames := make(map[string]string)
+		fmt.Fprintf(w, "CHANNEL\tTHREADS\tANSWERED\tMEDIAN RESPONSE\tTOP RESPONDERS\n")
+		fmt.Fprintf(w, "-------\t-------\t--------\t---------------\t--------------\n")
+		for _, r := range responseTimes {
+			name := r.ChannelName
+			if name == "" {
+				name = r.ChannelID
+			}
+			median := "-"
+			if r.Answered > 0 {
+				median = time.Duration(r.MedianResponseSeconds * float64(time.Second)).Round(time.Second).String()
+			}
+			var responders []string
+			for _, responder := range r.TopResponders {
+				responders = append(responders, fmt.Sprintf("%s (%d)", userName(database, userNames, responder.UserID), responder.Responses))
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", name, r.Threads, r.Answered, median, strings.Join(responders, ", "))
+		}
+		return nil
+	}
+}
+
+func runReportTaskProgress(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+
+	source := "github"
+	opts := db.SelectMessagesOptions{SourceType: &source}
+	if reportTaskProgressOnlyOpen {
+		opts.ThreadState = db.ThreadStateOpen
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	if reportTaskProgressChannel != "" {
+		channelID, err := resolveChannelID(database, reportTaskProgressChannel, false)
+		if err != nil {
+			return err
+		}
+		opts.ChannelID = &channelID
+	}
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	messages, err := st.SelectMessages(opts)
+	if err != nil {
+		return err
+	}
+	progress := taskProgressOf(messages)
+	if reportTaskProgressLimit > 0 && len(progress) > reportTaskProgressLimit {
+		progress = progress[:reportTaskProgressLimit]
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(progress)
+	case "jsonl", "ndjson":
+		return OutputJSONL(progress)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "ISSUE\tDONE\tTOTAL\tPROGRESS\tTITLE\n")
+		fmt.Fprintf(w, "-----\t----\t-----\t--------\t-----\n")
+		for _, p := range progress {
+			title := p.Title
+			if runes := []rune(title); len(runes) > 60 {
+				title = string(runes[:57]) + "..."
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.0f%%\t%s\n", p.Issue, p.Done, p.Total, p.Ratio*100, title)
+		}
+		return nil
+	}
+}
+
+// taskProgressOf returns the task list completion of the thread roots among
+// messages that have task lists: unfinished ones first, the closest to done
+// (then the fewest tasks left) at the top, then finished ones
+func taskProgressOf(messages []*db.Message) []*issueTaskProgress {
+	progress := []*issueTaskProgress{}
+	for _, msg := range messages {
+		if !msg.IsThreadRoot {
+			continue
+		}
+		// GitHub issue content is the title, a blank line, and the body
+		title, body, _ := strings.Cut(msg.Content, "\n")
+		p := normalize.ExtractTaskProgress(body)
+		if p == nil {
+			continue
+		}
+		progress = append(progress, &issueTaskProgress{MessageID: msg.ID, Issue: msg.SourceID, Title: strings.TrimSpace(title), TaskProgress: p})
+	}
+
+	sort.SliceStable(progress, func(i, j int) bool {
+		a, b := progress[i], progress[j]
+		if doneA, doneB := a.Done == a.Total, b.Done == b.Total; doneA != doneB {
+			return doneB
+		}
+		if a.Ratio != b.Ratio {
+			return a.Ratio > b.Ratio
+		}
+		if leftA, leftB := a.Total-a.Done, b.Total-b.Done; leftA != leftB {
+			return leftA < leftB
+		}
+		return a.Issue < b.Issue
+	})
+	return progress
+}
+
+// userName returns the display or real name of a user, or their ID if they
+// have neither, caching lookups in names
+func userName(database *db.DB, names map[string]string, userID string) string {
+	if name, ok := names[userID]; ok {
+		return name
+	}
+	name := userID
+	if user, err := database.GetUser(userID); err == nil && user != nil {
+		if user.DisplayName != nil && *user.DisplayName != "" {
+			name = *user.DisplayName
+		} else if user.RealName != nil && *user.RealName != "" {
+			name = *user.RealName
+		}
+	}
+	names[userID] = name
+	return name
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestReportTaskProgress(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	save := func(id, sourceID, content string, root bool) {
+		t.Helper()
+		msg := &db.Message{ID: id, SourceType: "github", SourceID: sourceID, AuthorID: "user_github_alice",
+			ChannelID: "chan_github_acme_widgets", Content: content, IsThreadRoot: root,
+			Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)}
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	save("msg_github_acme_widgets_1", "acme/widgets#1", "Release 1.2\n\n- [x] tag\n- [ ] changelog\n- [ ] announce", true)
+	save("msg_github_acme_widgets_2", "acme/widgets#2", "Migrate CI\n\n- [x] build\n- [x] test\n- [x] lint\n- [ ] deploy", true)
+	save("msg_github_acme_widgets_3", "acme/widgets#3", "Docs pass\n\n- [x] intro\n- [x] reference", true)
+	save("msg_github_acme_widgets_4", "acme/widgets#4", "Flaky test\n\nNo tasks here", true)
+	save("msg_github_acme_widgets_1_comment_100", "acme/widgets#1-comment-100", "Mine:\n- [ ] review", false)
+
+	report := func() []string {
+		t.Helper()
+		err, out := execute(t, "--db", dbFile, "--format", "json", "report", "task-progress")
+		if err != nil {
+			t.Fatalf("report task-progress failed: %v", err)
+		}
+		var rows []struct {
+			Issue string  `json:"issue"`
+			Title string  `json:"title"`
+			Done  int     `json:"done"`
+			Total int     `json:"total"`
+			Ratio float64 `json:"ratio"`
+		}
+		if err := json.Unmarshal([]byte(out), &rows); err != nil {
+			t.Fatalf("invalid output %q: %v", out, err)
+		}
+		var got []string
+		for _, r := range rows {
+			got = append(got, fmt.Sprintf("%s %s %d/%d", r.Issue, r.Title, r.Done, r.Total))
+		}
+		return got
+	}
+
+	// Unfinished issues closest to done first, finished ones last
+	want := []string{"acme/widgets#2 Migrate CI 3/4", "acme/widgets#1 Release 1.2 1/3", "acme/widgets#3 Docs pass 2/2"}
+	if got := report(); !reflect.DeepEqual(got, want) {
+		t.Errorf("report = %v, want %v", got, want)
+	}
+
+	// A refetched body with every task done moves to the finished ones
+	save("msg_github_acme_widgets_1", "acme/widgets#1", "Release 1.2\n\n- [x] tag\n- [x] changelog\n- [x] announce", true)
+	want = []string{"acme/widgets#2 Migrate CI 3/4", "acme/widgets#1 Release 1.2 3/3", "acme/widgets#3 Docs pass 2/2"}
+	if got := report(); !reflect.DeepEqual(got, want) {
+		t.Errorf("report after refetch = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/classify"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/spf13/cobra"
+)
+
+var reportThreadCmd = &cobra.Command{
+	Use:   "thread <message-id>",
+	Short: "Show a whole thread, replies indented under what they answer",
+	Long: `Report the thread a stored message belongs to, root first, each reply
+indented by its depth in the reply graph and labeled with its classifications.
+
+--format html renders the thread as a standalone HTML page to share: messages
+with their author, time, and content (the source's rendered HTML if it has
+any, otherwise the escaped text), classifications as badges.
+
+Examples:
+  # Read a thread in the terminal
+  mine report thread msg_slack_C123_1700000000.000100 --format table
+
+  # Share a thread as a web page
+  mine report thread msg_github_acme_widgets_42 --format html > thread.html`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReportThread,
+}
+
+func init() {
+	reportCmd.AddCommand(reportThreadCmd)
+}
+
+// threadEntry is a message of a thread as mine threads and mine report thread show it
+type threadEntry struct {
+	MessageID       string    `json:"message_id"`
+	Depth           int       `json:"depth"` // 0 for the root, 1 for its replies, ...
+	AuthorID        string    `json:"author_id"`
+	Author          string    `json:"author"` // Display name, or the ID if unknown
+	Timestamp       time.Time `json:"timestamp"`
+	Content         string    `json:"content"`
+	ContentHTML     string    `json:"content_html,omitempty"`
+	Classifications []string  `json:"classifications,omitempty"`
+}
+
+func runReportThread(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table", "html":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	msg, err := st.LoadMessage(args[0])
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return fmt.Errorf("message not found: %s", args[0])
+	}
+	thread, err := loadThread(st, msg)
+	if err != nil {
+		return err
+	}
+	entries := threadEntries(database, thread)
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(entries)
+	case "jsonl", "ndjson":
+		return OutputJSONL(entries)
+	case "html":
+		return writeThreadHTML(os.Stdout, threadTitle(database, msg, thread), entries)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "TIMESTAMP\tAUTHOR\tCLASSIFICATIONS\tCONTENT\n")
+		fmt.Fprintf(w, "---------\t------\t---------------\t-------\n")
+		for _, e := range entries {
+			content := strings.ReplaceAll(e.Content, "\n", " ")
+			if runes := []rune(content); len(runes) > 60 {
+				content = string(runes[:57]) + "..."
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s%s\n",
+				e.Timestamp.Local().Format("2006-01-02 15:04"),
+				e.Author,
+				strings.Join(e.Classifications, ","),
+				strings.Repeat("  ", e.Depth),
+				content,
+			)
+		}
+		return nil
+	}
+}
+
+// threadEntries orders thread (as loadThread returns it) by its reply
+// graph, each reply after the message it answers, and classifies it. Replies
+// whose parent isn't stored follow the rest, from depth 0.
+func threadEntries(database *db.DB, thread []*db.Message) []threadEntry {
+	normalized := toNormalizedMessages(thread)
+	g := graph.BuildFromClassifiedMessages(normalized, classify.ClassifyThreads(normalized))
+
+	byID := make(map[string]*db.Message, len(thread))
+	for _, msg := range thread {
+		byID[msg.ID] = msg
+	}
+
+	// The thread's own root first, then roots standing in for missing parents
+	roots := append([]string(nil), g.ThreadRoots...)
+	sort.SliceStable(roots, func(i, j int) bool {
+		if byID[roots[i]].IsThreadRoot != byID[roots[j]].IsThreadRoot {
+			return byID[roots[i]].IsThreadRoot
+		}
+		return byID[roots[i]].Timestamp.Before(byID[roots[j]].Timestamp)
+	})
+
+	names := make(map[string]string)
+	depths := make(map[string]int)
+	entries := make([]threadEntry, 0, len(thread))
+	add := func(msg *db.Message, node *graph.MessageNode) {
+		if _, done := depths[msg.ID]; done {
+			return
+		}
+		depth := 0
+		if parentDepth, ok := depths[node.ParentID]; ok && node.ParentID != "" {
+			depth = parentDepth + 1
+		}
+		depths[msg.ID] = depth
+
+		entry := threadEntry{
+			MessageID:       msg.ID,
+			Depth:           depth,
+			AuthorID:        msg.AuthorID,
+			Author:          userName(database, names, msg.AuthorID),
+			Timestamp:       msg.Timestamp,
+			Content:         msg.Content,
+			Classifications: node.Classifications,
+		}
+		if msg.ContentHTML != nil {
+			entry.ContentHTML = *msg.ContentHTML
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, rootID := range roots {
+		for _, node := range g.GetThread(rootID) {
+			if msg := byID[node.MessageID]; msg != nil {
+				add(msg, node)
+			}
+		}
+	}
+	// Anything the walk missed, so no message is left out
+	for _, msg := range thread {
+		if node := g.Nodes[msg.ID]; node != nil {
+			add(msg, node)
+		}
+	}
+	return entries
+}
+
+// threadHTML renders mine report thread --format html
+var threadHTML = template.Must(template.New("thread").Funcs(template.FuncMap{
+	// The source's rendered HTML (GitHub's is sanitized); plain content is escaped
+	"sourceHTML": func(s string) template.HTML { return template.HTML(s) },
+	"indent":     func(depth int) float64 { return 1.5 * float64(depth) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 52em; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+.message { border-left: 3px solid #d0d7de; margin: 1em 0; padding: 0.25em 0 0.25em 0.75em; }
+.meta { color: #59636e; font-size: 0.875em; }
+.author { color: #1f2328; font-weight: 600; }
+.badge { background: #ddf4ff; border-radius: 1em; color: #0969da; font-size: 0.75em; margin-left: 0.5em; padding: 0 0.5em; }
+.plain { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- range .Messages}}
+<article class="message" id="{{.MessageID}}" style="margin-left: {{indent .Depth}}em">
+<div class="meta"><span class="author">{{.Author}}</span> <time datetime="{{.Timestamp.UTC.Format "2006-01-02T15:04:05Z07:00"}}">{{.Timestamp.UTC.Format "2006-01-02 15:04 UTC"}}</time>
+{{- range .Classifications}} <span class="badge {{.}}">{{.}}</span>{{end}}</div>
+{{if .ContentHTML}}<div class="content">{{sourceHTML .ContentHTML}}</div>{{else}}<div class="content plain">{{.Content}}</div>{{end}}
+</article>
+{{- end}}
+</body>
+</html>
+`))
+
+// threadTitle returns the title of the thread msg belongs to (see summaryTitle)
+func threadTitle(database *db.DB, msg *db.Message, thread []*db.Message) string {
+	threadID := msg.ID
+	if msg.ThreadID != nil {
+		threadID = *msg.ThreadID
+	}
+	var root *normalize.NormalizedMessage
+	for _, m := range thread {
+		if m.ID == threadID {
+			root = toNormalizedMessage(m)
+		}
+	}
+	return summaryTitle(database, threadID, root)
+}
+
+// summaryTitle returns the title stored in the summary of thread threadID,
+// or, for a thread not summarized yet, derives one from its root, if known
+func summaryTitle(database *db.DB, threadID string, root *normalize.NormalizedMessage) string {
+	if summary, err := database.GetThread(threadID); err == nil && summary != nil && summary.Title != "" {
+		return summary.Title
+	}
+	if root == nil {
+		return ""
+	}
+	return normalize.DeriveThreadTitle(root)
+}
+
+// writeThreadHTML writes entries as a standalone HTML page with title
+func writeThreadHTML(w io.Writer, title string, entries []threadEntry) error {
+	if title == "" {
+		title = "Thread"
+	}
+	if err := threadHTML.Execute(w, struct {
+		Title    string
+		Messages []threadEntry
+	}{title, entries}); err != nil {
+		return fmt.Errorf("failed to render thread: %w", err)
+	}
+	return nil
+}
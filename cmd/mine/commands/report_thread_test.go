@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// saveTestThread stores a small Slack thread: a question, an answer with
+// rendered HTML, the asker's thanks replying to the answer, and a bystander's
+// reply to the question, and its summary, titled by hand. It returns the
+// database path and the IDs of the question and the answer.
+func saveTestThread(t *testing.T) (dbFile, rootID, answerID string) {
+	t.Helper()
+
+	dbFile = filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	alice, bob := "Alice", "Bob"
+	for _, user := range []*db.User{
+		{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1", DisplayName: &alice},
+		{ID: "user_slack_U2", SourceType: "slack", SourceID: "U2", DisplayName: &bob},
+	} {
+		if err := database.SaveUser(user); err != nil {
+			t.Fatalf("SaveUser failed: %v", err)
+		}
+	}
+
+	rootID, answerID = "msg_slack_C1_1.0", "msg_slack_C1_2.0"
+	rendered := "<p>Run <code>keys rotate --env prod</code></p>"
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	messages := []*db.Message{
+		{ID: rootID, SourceID: "C1_1.0", AuthorID: "user_slack_U1", IsThreadRoot: true, Timestamp: base,
+			Content: "How do I rotate the deploy key?\nIt fails with <script>alert(1)</script>"},
+		{ID: answerID, SourceID: "C1_2.0", AuthorID: "user_slack_U2", ParentID: &rootID, Timestamp: base.Add(5 * time.Minute),
+			Content: "You can run keys rotate --env prod", ContentHTML: &rendered},
+		{ID: "msg_slack_C1_3.0", SourceID: "C1_3.0", AuthorID: "user_slack_U1", ParentID: &answerID, Timestamp: base.Add(9 * time.Minute),
+			Content: "Thanks, that worked!"},
+		{ID: "msg_slack_C1_4.0", SourceID: "C1_4.0", AuthorID: "user_slack_U9", ParentID: &rootID, Timestamp: base.Add(7 * time.Minute),
+			Content: "Same problem here"},
+	}
+	for _, msg := range messages {
+		msg.SourceType, msg.ChannelID, msg.ThreadID = "slack", "chan_slack_C1", &rootID
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	if err := database.SaveThread(&db.Thread{ID: rootID, RootMessageID: rootID, Title: "Deploy key rotation fails",
+		ChannelID: "chan_slack_C1", StartedAt: base, LastActivityAt: base.Add(9 * time.Minute)}); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+	return dbFile, rootID, answerID
+}
+
+func TestReportThread(t *testing.T) {
+	requireFTS5(t)
+	dbFile, rootID, answerID := saveTestThread(t)
+
+	t.Run("json", func(t *testing.T) {
+		err, out := execute(t, "--db", dbFile, "--format", "json", "report", "thread", "msg_slack_C1_3.0")
+		if err != nil {
+			t.Fatalf("report thread failed: %v", err)
+		}
+		var entries []threadEntry
+		if err := json.Unmarshal([]byte(out), &entries); err != nil {
+			t.Fatalf("invalid output %q: %v", out, err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, strings.Repeat(">", e.Depth)+e.MessageID)
+		}
+		// Replies follow what they answer, in time order
+		want := []string{rootID, ">" + answerID, ">>msg_slack_C1_3.0", ">msg_slack_C1_4.0"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("thread = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		err, got := execute(t, "--db", dbFile, "--format", "html", "report", "thread", rootID)
+		if err != nil {
+			t.Fatalf("report thread failed: %v", err)
+		}
+
+		path := filepath.Join("testdata", "report_thread.html")
+		if *updateGolden {
+			if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+				t.Fatalf("failed to update golden file: %v", err)
+			}
+			return
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+		}
+		if got != string(want) {
+			t.Errorf("HTML does not match %s (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+		}
+	})
+
+	if err, _ := execute(t, "--db", dbFile, "report", "thread", "msg_missing"); err == nil {
+		t.Errorf("expected an error for a missing message")
+	}
+}
@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/github"
+	"github.com/solvaholic/threadmine/internal/slack"
+	"github.com/spf13/cobra"
+)
+
+var reprocessCmd = &cobra.Command{
+	Use:   "reprocess",
+	Short: "Re-derive stored messages from the raw cache",
+	Long: `Re-run normalization, enrichment, and thread summaries for the raw API
+responses saved by earlier fetches, without calling any API.
+
+Use it after upgrading mine to apply improved normalization to messages
+that were already fetched. Normalized messages, enrichments, thread
+summaries, and reference relations are rewritten; the raw cache is left
+as it is. Messages whose raw data wasn't cached can't be reprocessed.
+
+Examples:
+  # Reprocess everything
+  mine reprocess
+
+  # Reprocess one GitHub repository
+  mine reprocess --owner cli --repo cli
+
+  # Reprocess Slack messages only
+  mine reprocess --source slack`,
+	RunE: runReprocess,
+}
+
+var (
+	reprocessSource string
+	reprocessOwner  string
+	reprocessRepo   string
+
+	reprocessInferThreads   bool
+	reprocessFindDuplicates bool
+)
+
+func init() {
+	rootCmd.AddCommand(reprocessCmd)
+
+	reprocessCmd.Flags().StringVar(&reprocessSource, "source", "", "Only raw messages from this source type: slack, github")
+	reprocessCmd.Flags().StringVar(&reprocessOwner, "owner", "", "Only GitHub raw messages from this owner's repositories")
+	reprocessCmd.Flags().StringVar(&reprocessOwner, "org", "", "Alias for --owner")
+	reprocessCmd.Flags().StringVar(&reprocessRepo, "repo", "", "Only GitHub raw messages from this repository (use with --owner, or use owner/repo format)")
+	reprocessCmd.Flags().BoolVar(&reprocessInferThreads, "infer-threads", false, "Attach GitHub comments that start by quoting an earlier comment to it, like fetch github --infer-threads")
+	reprocessCmd.Flags().BoolVar(&reprocessFindDuplicates, "find-duplicates", false, "Record copies of Slack messages cross-posted to several channels, like fetch slack --find-duplicates")
+}
+
+func runReprocess(cmd *cobra.Command, args []string) error {
+	opts := db.RawMessagesOptions{SourceType: reprocessSource}
+	switch reprocessSource {
+	case "", "github", "slack":
+	default:
+		return usageErrorf("unknown --source value: %s (expected slack or github)", reprocessSource)
+	}
+
+	owner, repo := reprocessOwner, reprocessRepo
+	if strings.Contains(repo, "/") {
+		owner, repo, _ = strings.Cut(repo, "/")
+	}
+	if repo != "" && owner == "" {
+		return usageErrorf("--repo requires --owner (or owner/repo format)")
+	}
+	if owner != "" {
+		if reprocessSource == "slack" {
+			return usageErrorf("--owner and --repo only apply to --source github")
+		}
+		opts.SourceType = "github"
+		if repo != "" {
+			opts.ContainerID = fmt.Sprintf("chan_github_%s_%s", owner, repo)
+		} else {
+			opts.ContainerPrefix = fmt.Sprintf("chan_github_%s_", owner)
+		}
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+	recorder := newThreadRecorder(st)
+
+	raws, err := database.SelectRawMessages(opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStderr(), "Reprocessing %d raw messages...\n", len(raws))
+
+	reprocessed, failed := 0, 0
+	reviewComments := make(map[string][]github.ReviewComment) // owner/repo#N -> the PR's review comments
+	for _, raw := range raws {
+		if err := reprocessRawMessage(database, recorder, raw, reviewComments); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to reprocess %s: %v\n", raw.ID, err)
+			failed++
+			continue
+		}
+		reprocessed++
+	}
+
+	finishReprocess(cmd, database, recorder, reviewComments, reprocessInferThreads)
+	if reprocessFindDuplicates {
+		relateCrossPosts(cmd, database, recorder)
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "\nCompleted!\n")
+	fmt.Fprintf(cmd.OutOrStderr(), "Messages reprocessed: %d\n", reprocessed)
+	fmt.Fprintf(cmd.OutOrStderr(), "Threads summarized: %d\n", len(recorder.order))
+	if failed > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Messages that failed: %d\n", failed)
+	}
+
+	return nil
+}
+
+// finishReprocess derives what fetch derives from a batch of stored messages,
+// for the messages reprocessed into recorder: review threads, entities,
+// mentions, thread summaries, and reference relations
+func finishReprocess(cmd *cobra.Command, database *db.DB, recorder *threadRecorder, reviewComments map[string][]github.ReviewComment, inferThreads bool) {
+	// Review threads are summarized per pull request, like fetch does
+	for key, comments := range reviewComments {
+		ref, ok := parseGitHubSourceID(key)
+		if !ok {
+			continue
+		}
+		for _, thread := range summarizeReviewThreads(comments, ref.owner, ref.repo, ref.number) {
+			if err := database.SaveThread(thread); err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save review thread %s: %v\n", thread.ID, err)
+			}
+		}
+	}
+
+	upsertEntities(cmd, database, recorder, "", nil)
+	resolveContentMentions(cmd, database, recorder, nil)
+	if inferThreads {
+		inferQuoteReplies(cmd, recorder)
+	}
+	summarizeThreads(cmd, database, recorder)
+	relateReferences(cmd, database, recorder)
+}
+
+// reprocessRawMessage decodes a raw message into the API type it was saved
+// from and stores it again the way fetch does. PR review comments are also
+// collected into reviewComments, keyed by the PR's source ID.
+func reprocessRawMessage(database *db.DB, recorder *threadRecorder, raw *db.RawMessage, reviewComments map[string][]github.ReviewComment) error {
+	switch raw.SourceType {
+	case "slack":
+		return reprocessSlackMessage(database, recorder, raw)
+	case "github":
+		return reprocessGitHubMessage(database, recorder, raw, reviewComments)
+	default:
+		return fmt.Errorf("unsupported source type: %s", raw.SourceType)
+	}
+}
+
+// reprocessSlackMessage stores a raw Slack message again. Search results are
+// told apart from history and thread messages by their channel object.
+func reprocessSlackMessage(database *db.DB, recorder *threadRecorder, raw *db.RawMessage) error {
+	teamID := strings.TrimPrefix(raw.WorkspaceID, "ws_slack_")
+	if teamID == "" || raw.ContainerID == "" {
+		return fmt.Errorf("raw message has no workspace or channel")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw.RawData), &fields); err != nil {
+		return fmt.Errorf("failed to parse raw data: %w", err)
+	}
+
+	var msg interface{}
+	if _, ok := fields["channel"]; ok {
+		var result slack.SearchResult
+		if err := json.Unmarshal([]byte(raw.RawData), &result); err != nil {
+			return fmt.Errorf("failed to parse search result: %w", err)
+		}
+		msg = result
+	} else {
+		var threadMsg slack.ThreadMessage
+		if err := json.Unmarshal([]byte(raw.RawData), &threadMsg); err != nil {
+			return fmt.Errorf("failed to parse message: %w", err)
+		}
+		msg = threadMsg
+	}
+
+	return storeSlackMessage(database, recorder, msg, teamID, raw.ContainerID, nil)
+}
+
+// githubRef identifies a GitHub raw message by its source ID
+type githubRef struct {
+	owner  string
+	repo   string
+	kind   string // issue, comment, review-comment, review, event, discussion, discussion-comment, commit-comment
+	number int    // Issue, PR, or discussion number
+}
+
+// githubSourceIDPattern matches the source IDs fetch saves GitHub raw messages under:
+//
+//	owner/repo#12, owner/repo#12-comment-1, owner/repo#12-review-comment-1,
+//	owner/repo#12-review-1, owner/repo#12-event-1, owner/repo/discussions/3,
+//	owner/repo/discussions/3#DC_1, owner/repo@sha-comment-1
+var githubSourceIDPattern = regexp.MustCompile(`^([^/]+)/([^/#@]+)(?:#(\d+)(?:-(comment|review-comment|review|event)-\d+)?|/discussions/(\d+)(#.+)?|@[0-9a-f]+-comment-\d+)$`)
+
+// parseGitHubSourceID returns what a GitHub raw message's source ID refers to
+func parseGitHubSourceID(sourceID string) (githubRef, bool) {
+	match := githubSourceIDPattern.FindStringSubmatch(sourceID)
+	if match == nil {
+		return githubRef{}, false
+	}
+
+	ref := githubRef{owner: match[1], repo: match[2]}
+	switch {
+	case match[3] != "":
+		ref.number, _ = strconv.Atoi(match[3])
+		ref.kind = "issue"
+		if match[4] != "" {
+			ref.kind = match[4]
+		}
+	case match[5] != "":
+		ref.number, _ = strconv.Atoi(match[5])
+		ref.kind = "discussion"
+		if match[6] != "" {
+			ref.kind = "discussion-comment"
+		}
+	default:
+		ref.kind = "commit-comment"
+	}
+	return ref, true
+}
+
+// reprocessGitHubMessage stores a raw GitHub message again, with the store
+// function fetch used for its kind
+func reprocessGitHubMessage(database *db.DB, recorder *threadRecorder, raw *db.RawMessage, reviewComments map[string][]github.ReviewComment) error {
+	ref, ok := parseGitHubSourceID(raw.SourceID)
+	if !ok {
+		return fmt.Errorf("unrecognized source ID: %s", raw.SourceID)
+	}
+	data := []byte(raw.RawData)
+	issue := &github.Issue{Number: ref.number}
+	discussion := &github.Discussion{Number: ref.number}
+
+	switch ref.kind {
+	case "issue":
+		if err := json.Unmarshal(data, issue); err != nil {
+			return fmt.Errorf("failed to parse issue: %w", err)
+		}
+		if err := storeGitHubIssue(database, recorder, issue, ref.owner, ref.repo, raw.WorkspaceID); err != nil {
+			return err
+		}
+		recorder.setResolution(raw.ID, githubIssueResolution(issue))
+		return nil
+	case "comment":
+		var comment github.Comment
+		if err := json.Unmarshal(data, &comment); err != nil {
+			return fmt.Errorf("failed to parse comment: %w", err)
+		}
+		return storeGitHubComment(database, recorder, &comment, issue, ref.owner, ref.repo, raw.WorkspaceID)
+	case "review-comment":
+		var comment github.ReviewComment
+		if err := json.Unmarshal(data, &comment); err != nil {
+			return fmt.Errorf("failed to parse review comment: %w", err)
+		}
+		if err := storeGitHubReviewComment(database, recorder, &comment, issue, ref.owner, ref.repo, raw.WorkspaceID); err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%s/%s#%d", ref.owner, ref.repo, ref.number)
+		reviewComments[key] = append(reviewComments[key], comment)
+		return nil
+	case "review":
+		var review github.Review
+		if err := json.Unmarshal(data, &review); err != nil {
+			return fmt.Errorf("failed to parse review: %w", err)
+		}
+		return storeGitHubReview(database, recorder, &review, issue, ref.owner, ref.repo, raw.WorkspaceID)
+	case "event":
+		var event github.TimelineEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to parse timeline event: %w", err)
+		}
+		return storeGitHubTimelineEvent(database, recorder, &event, issue, ref.owner, ref.repo, raw.WorkspaceID)
+	case "discussion":
+		if err := json.Unmarshal(data, discussion); err != nil {
+			return fmt.Errorf("failed to parse discussion: %w", err)
+		}
+		return storeGitHubDiscussion(database, recorder, discussion, ref.owner, ref.repo, raw.WorkspaceID)
+	case "discussion-comment":
+		var comment github.DiscussionComment
+		if err := json.Unmarshal(data, &comment); err != nil {
+			return fmt.Errorf("failed to parse discussion comment: %w", err)
+		}
+		return storeGitHubDiscussionComment(database, recorder, &comment, discussion, ref.owner, ref.repo, raw.WorkspaceID)
+	default:
+		var comment github.CommitComment
+		if err := json.Unmarshal(data, &comment); err != nil {
+			return fmt.Errorf("failed to parse commit comment: %w", err)
+		}
+		return storeGitHubCommitComment(database, recorder, &comment, ref.owner, ref.repo, raw.WorkspaceID)
+	}
+}
@@ -0,0 +1,368 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/classify"
+	"github.com/solvaholic/threadmine/internal/clock"
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+// seedRawCache opens a database at dbFile holding only the raw messages in
+// testdata/reprocess_raw.json, as an earlier fetch would have saved them
+func seedRawCache(t *testing.T, dbFile string) *db.DB {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "reprocess_raw.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	var raws []struct {
+		ID          string          `json:"id"`
+		SourceType  string          `json:"source_type"`
+		SourceID    string          `json:"source_id"`
+		WorkspaceID string          `json:"workspace_id"`
+		ContainerID string          `json:"container_id"`
+		RawData     json.RawMessage `json:"raw_data"`
+	}
+	if err := json.Unmarshal(data, &raws); err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	for _, raw := range raws {
+		if err := database.SaveRawMessage(raw.ID, raw.SourceType, raw.SourceID, raw.WorkspaceID, raw.ContainerID, string(raw.RawData), ""); err != nil {
+			t.Fatalf("SaveRawMessage failed: %v", err)
+		}
+	}
+	return database
+}
+
+func TestReprocess(t *testing.T) {
+	requireFTS5(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database := seedRawCache(t, dbFile)
+
+	// A message normalized by an older version, with stale content
+	stale := "msg_github_acme_widgets_7"
+	if err := database.SaveMessage(&db.Message{ID: stale, SourceType: "github", SourceID: "acme/widgets#7",
+		AuthorID: "user_github_octocat", ChannelID: "chan_github_acme_widgets", Content: "stale", ThreadID: &stale, IsThreadRoot: true}); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "reprocess"); err != nil {
+		t.Fatalf("reprocess failed: %v", err)
+	}
+
+	tests := []struct {
+		id      string
+		thread  string
+		parent  string
+		content string
+	}{
+		{"msg_github_acme_widgets_7", "msg_github_acme_widgets_7", "", "Crash on start\n\nIt crashes, see https://example.com/log"},
+		{"msg_github_acme_widgets_7_comment_100", "msg_github_acme_widgets_7", "msg_github_acme_widgets_7", "Fixed in the next release"},
+		{"msg_github_acme_widgets_7_timeline_500", "msg_github_acme_widgets_7", "msg_github_acme_widgets_7", "[closed] Issue closed"},
+		{"msg_github_acme_widgets_8", "msg_github_acme_widgets_8", "", "Fix crash\n\nFixes #7"},
+		{"msg_github_acme_widgets_8_review_comment_200", "msg_github_acme_widgets_8", "msg_github_acme_widgets_8", "[main.go:12] Nil check here?"},
+		{"msg_github_acme_widgets_8_review_comment_201", "msg_github_acme_widgets_8", "msg_github_acme_widgets_8_review_comment_200", "[main.go:12] Added"},
+		{"msg_github_acme_widgets_8_review_300", "msg_github_acme_widgets_8", "msg_github_acme_widgets_8", "[APPROVED] Looks good"},
+		{"msg_github_acme_widgets_discussion_3", "msg_github_acme_widgets_discussion_3", "", "[Ideas] What is next?"},
+		{"msg_github_acme_widgets_discussion_3_comment_DC_1", "msg_github_acme_widgets_discussion_3", "msg_github_acme_widgets_discussion_3", "Plugins"},
+		{"msg_github_acme_widgets_commit_abc123_comment_400", "msg_github_acme_widgets_commit_abc123", "", "Why this change?"},
+		{"msg_slack_C1_1709287200.000100", "msg_slack_C1_1709287200.000100", "", "Deploys are failing"},
+		{"msg_slack_C1_1709287260.000200", "msg_slack_C1_1709287200.000100", "msg_slack_C1_1709287200.000100", "Retry with `--force`"},
+		{"msg_slack_C1_1709287290.000250", "msg_slack_C1_1709287200.000100", "msg_slack_C1_1709287200.000100", "That didn't help & it still fails with <timeout>"},
+		{"msg_slack_C2_1709287320.000300", "msg_slack_C2_1709287320.000300", "", "Seen in help too"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			msg, err := database.GetMessage(tt.id)
+			if err != nil || msg == nil {
+				t.Fatalf("GetMessage = %v, %v", msg, err)
+			}
+			if got := deref(msg.ThreadID); got != tt.thread {
+				t.Errorf("thread = %q, want %q", got, tt.thread)
+			}
+			if got := deref(msg.ParentID); got != tt.parent {
+				t.Errorf("parent = %q, want %q", got, tt.parent)
+			}
+			if msg.Content != tt.content {
+				t.Errorf("content = %q, want %q", msg.Content, tt.content)
+			}
+		})
+	}
+
+	// Timestamps are also kept as the source wrote them
+	for id, want := range map[string]string{
+		"msg_github_acme_widgets_7":             "2024-03-01T10:00:00Z",
+		"msg_github_acme_widgets_7_comment_100": "2024-03-01T12:00:00Z",
+		"msg_github_acme_widgets_8_review_300":  "2024-03-01T16:00:00Z",
+		"msg_slack_C1_1709287200.000100":        "1709287200.000100",
+	} {
+		msg, err := database.GetMessage(id)
+		if err != nil || msg == nil {
+			t.Fatalf("GetMessage(%s) = %v, %v", id, msg, err)
+		}
+		if msg.SourceTimestamp != want {
+			t.Errorf("%s: source timestamp = %q, want %q", id, msg.SourceTimestamp, want)
+		}
+	}
+
+	// Slack edits are kept: when, and by whom
+	edited, err := database.GetMessage("msg_slack_C1_1709287290.000250")
+	if err != nil || edited == nil {
+		t.Fatalf("GetMessage = %v, %v", edited, err)
+	}
+	if edited.EditedAt == nil || !edited.EditedAt.Equal(time.Unix(1709287350, 0)) || deref(edited.EditedBy) != "user_slack_U1" {
+		t.Errorf("edited = %v by %q, want %v by user_slack_U1", edited.EditedAt, deref(edited.EditedBy), time.Unix(1709287350, 0))
+	}
+	if unedited, err := database.GetMessage("msg_slack_C1_1709287260.000200"); err != nil || unedited == nil || unedited.EditedAt != nil || unedited.EditedBy != nil {
+		t.Errorf("unedited message = %+v (err %v), want no edit", unedited, err)
+	}
+
+	// Thread summaries are derived again, including review threads
+	issue, err := database.GetThread("msg_github_acme_widgets_7")
+	if err != nil || issue == nil {
+		t.Fatalf("GetThread = %v, %v", issue, err)
+	}
+	if issue.ReplyCount != 2 || !issue.Resolved || issue.Title != "Crash on start" {
+		t.Errorf("issue thread: replies=%d resolved=%v title=%q, want 2, resolved, and %q",
+			issue.ReplyCount, issue.Resolved, issue.Title, "Crash on start")
+	}
+	slackThread, err := database.GetThread("msg_slack_C1_1709287200.000100")
+	if err != nil || slackThread == nil {
+		t.Fatalf("GetThread = %v, %v", slackThread, err)
+	}
+	if slackThread.Title != "Deploys are failing" {
+		t.Errorf("slack thread title = %q, want %q", slackThread.Title, "Deploys are failing")
+	}
+	review, err := database.GetThread("msg_github_acme_widgets_8_review_comment_200")
+	if err != nil || review == nil {
+		t.Fatalf("GetThread = %v, %v", review, err)
+	}
+	if review.ReplyCount != 1 || !review.Resolved {
+		t.Errorf("review thread: replies=%d resolved=%v, want 1 and resolved", review.ReplyCount, review.Resolved)
+	}
+
+	// A message shared from another channel is linked to the original
+	relations, err := database.GetMessageRelations("msg_slack_C2_1709287320.000300", nil)
+	if err != nil {
+		t.Fatalf("GetMessageRelations failed: %v", err)
+	}
+	shared := false
+	for _, rel := range relations {
+		if rel.RelationType == classify.RelationShares && rel.ToMessageID == "msg_slack_C1_1709287200.000100" {
+			shared = true
+		}
+	}
+	if !shared {
+		t.Errorf("expected a shares relation to the original message, got %+v", relations)
+	}
+}
+
+func TestReprocess_NormalizedAt(t *testing.T) {
+	requireFTS5(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(clock.Freeze(now))
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database := seedRawCache(t, dbFile)
+	if err, _ := execute(t, "--db", dbFile, "reprocess"); err != nil {
+		t.Fatalf("reprocess failed: %v", err)
+	}
+
+	for _, id := range []string{"msg_github_acme_widgets_7", "msg_slack_C1_1709287200.000100"} {
+		msg, err := database.GetMessage(id)
+		if err != nil || msg == nil {
+			t.Fatalf("GetMessage(%s) = %v, %v", id, msg, err)
+		}
+		if !msg.NormalizedAt.Equal(now) {
+			t.Errorf("%s: normalized at %v, want %v", id, msg.NormalizedAt, now)
+		}
+	}
+}
+
+func TestReprocess_Filters(t *testing.T) {
+	requireFTS5(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantGitHub bool
+		wantSlack  bool
+	}{
+		{"slack only", []string{"--source", "slack"}, false, true},
+		{"one repo", []string{"--owner", "acme", "--repo", "widgets"}, true, false},
+		{"owner/repo format", []string{"--repo", "acme/widgets"}, true, false},
+		{"other owner", []string{"--owner", "other"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbFile := filepath.Join(t.TempDir(), "test.db")
+			database := seedRawCache(t, dbFile)
+
+			args := append([]string{"--db", dbFile, "reprocess"}, tt.args...)
+			if err, _ := execute(t, args...); err != nil {
+				t.Fatalf("reprocess failed: %v", err)
+			}
+
+			for id, want := range map[string]bool{
+				"msg_github_acme_widgets_7":      tt.wantGitHub,
+				"msg_slack_C1_1709287200.000100": tt.wantSlack,
+			} {
+				msg, err := database.GetMessage(id)
+				if err != nil {
+					t.Fatalf("GetMessage failed: %v", err)
+				}
+				if (msg != nil) != want {
+					t.Errorf("%s reprocessed = %v, want %v", id, msg != nil, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReprocess_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown source", []string{"--source", "email"}},
+		{"repo without owner", []string{"--repo", "widgets"}},
+		{"owner with slack", []string{"--source", "slack", "--owner", "acme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--db", filepath.Join(t.TempDir(), "test.db"), "reprocess"}, tt.args...)
+			err, _ := execute(t, args...)
+			if code := ExitCode(err); code != ExitUsage {
+				t.Errorf("exit code = %d, want %d (err %v)", code, ExitUsage, err)
+			}
+		})
+	}
+}
+
+func TestParseGitHubSourceID(t *testing.T) {
+	tests := []struct {
+		sourceID string
+		want     githubRef
+		ok       bool
+	}{
+		{"acme/widgets#7", githubRef{"acme", "widgets", "issue", 7}, true},
+		{"acme/my_repo.go#7-comment-1", githubRef{"acme", "my_repo.go", "comment", 7}, true},
+		{"acme/widgets#8-review-comment-2", githubRef{"acme", "widgets", "review-comment", 8}, true},
+		{"acme/widgets#8-review-3", githubRef{"acme", "widgets", "review", 8}, true},
+		{"acme/widgets#7-event-4", githubRef{"acme", "widgets", "event", 7}, true},
+		{"acme/widgets/discussions/3", githubRef{"acme", "widgets", "discussion", 3}, true},
+		{"acme/widgets/discussions/3#DC_kw", githubRef{"acme", "widgets", "discussion-comment", 3}, true},
+		{"acme/widgets@abc123-comment-5", githubRef{"acme", "widgets", "commit-comment", 0}, true},
+		{"C1_1709287200.000100", githubRef{}, false},
+		{"acme/widgets#7-reaction-1", githubRef{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sourceID, func(t *testing.T) {
+			got, ok := parseGitHubSourceID(tt.sourceID)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseGitHubSourceID(%q) = %+v, %v, want %+v, %v", tt.sourceID, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// deref returns *s, or "" if s is nil
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func TestReprocess_FindDuplicates(t *testing.T) {
+	requireFTS5(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// An announcement cross-posted to two channels a minute apart, and an
+	// unrelated message
+	announcement := "Deploys are frozen until Monday for the datacenter move."
+	for _, raw := range []struct{ channel, ts, text string }{
+		{"C1", "1709287200.000100", announcement},
+		{"C2", "1709287260.000200", announcement},
+		{"C3", "1709287320.000300", "Thanks for the help with the move, everyone"},
+	} {
+		rawData := fmt.Sprintf(`{"type": "message", "user": "U1", "text": %q, "ts": %q}`, raw.text, raw.ts)
+		if err := database.SaveRawMessage(fmt.Sprintf("msg_slack_%s_%s", raw.channel, raw.ts), "slack", raw.channel+"_"+raw.ts,
+			"ws_slack_T1", raw.channel, rawData, ""); err != nil {
+			t.Fatalf("SaveRawMessage failed: %v", err)
+		}
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "reprocess", "--find-duplicates"); err != nil {
+		t.Fatalf("reprocess failed: %v", err)
+	}
+
+	relationType := classify.RelationDuplicateOf
+	relations, err := database.GetMessageRelations("msg_slack_C2_1709287260.000200", &relationType)
+	if err != nil {
+		t.Fatalf("GetMessageRelations failed: %v", err)
+	}
+	if len(relations) != 1 || relations[0].ToMessageID != "msg_slack_C1_1709287200.000100" {
+		t.Errorf("expected the second copy to be a duplicate of the first, got %+v", relations)
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"msg_slack_C3_1709287320.000300", "msg_slack_C2_1709287260.000200", "msg_slack_C1_1709287200.000100"}},
+		{[]string{"--dedupe"}, []string{"msg_slack_C3_1709287320.000300", "msg_slack_C1_1709287200.000100"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			err, out := execute(t, append([]string{"--db", dbFile, "select"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+			var messages []struct{ ID string }
+			if err := json.Unmarshal([]byte(out), &messages); err != nil {
+				t.Fatalf("invalid output %q: %v", out, err)
+			}
+			var got []string
+			for _, msg := range messages {
+				got = append(got, msg.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
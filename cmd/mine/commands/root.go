@@ -1,18 +1,30 @@
 package commands
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/solvaholic/threadmine/internal/classify"
 	"github.com/solvaholic/threadmine/internal/config"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/solvaholic/threadmine/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Global flags
 	outputFormat string
+	prettyOutput bool
 	dbPath       string
+	storeBackend string
+	colorMode    string
 
 	// Global config
 	globalConfig *config.Config
@@ -28,14 +40,46 @@ The tool has two main modes:
   - fetch: Search and retrieve messages from upstream sources (Slack, GitHub, etc.)
   - select: Query and analyze locally cached messages
 
-All data is stored in a local SQLite database for fast querying and analysis.`,
+All data is stored in a local SQLite database for fast querying and analysis.
+
+Exit codes:
+  0   success (including queries that match nothing)
+  1   any other error
+  2   not authenticated to Slack or GitHub
+  3   Slack or GitHub API rate limit exceeded
+  64  invalid usage: unknown command or flag, bad flag value, wrong arguments`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Expand ~ and environment variables, which the shell doesn't always do
+		expanded, err := utils.ExpandPath(dbPath)
+		if err != nil {
+			return usageErrorf("invalid --db path: %w", err)
+		}
+		dbPath = expanded
+		switch colorMode {
+		case colorAuto, colorAlways, colorNever:
+		default:
+			return usageErrorf("unknown --color value: %s (expected auto, always, or never)", colorMode)
+		}
+
+		if !cmd.Flags().Changed("store") && globalConfig != nil && globalConfig.HasKey("store.backend") {
+			storeBackend = globalConfig.GetString("store.backend")
+		}
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
-	return rootCmd.Execute()
+	markUsageErrors.Do(func() { markUsage(rootCmd) })
+
+	err := rootCmd.Execute()
+	// cobra reports unknown subcommands with a plain error from Find
+	if err != nil && strings.HasPrefix(err.Error(), "unknown command ") {
+		return &usageError{err: err}
+	}
+	return err
 }
 
 func init() {
@@ -46,10 +90,53 @@ func init() {
 		fmt.Fprintf(os.Stderr, "Warning: failed to load config: %v\n", err)
 	}
 	globalConfig = cfg
+	if globalConfig != nil {
+		classify.MinContentLength = globalConfig.GetIntWithFallback("classify.min_content_length", classify.MinContentLength)
+		classify.ThreadContextWindow = globalConfig.GetIntWithFallback("classify.thread_context_window", classify.ThreadContextWindow)
+		if globalConfig.HasKey("classify.aggregation") {
+			if err := classify.SetAggregation(globalConfig.GetString("classify.aggregation")); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: classify.aggregation: %v\n", err)
+			}
+		}
+		if globalConfig.HasKey("classify.acknowledgment_resolvers") {
+			if err := classify.SetAcknowledgmentResolvers(strings.Split(globalConfig.GetString("classify.acknowledgment_resolvers"), ",")); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: classify.acknowledgment_resolvers: %v\n", err)
+			}
+		}
+		normalize.ResolveMentionsInContent = globalConfig.GetBool("normalize.resolve_mentions_in_content")
+		normalize.CompressBySource = globalConfig.GetBool("normalize.compress_by_source")
+		if globalConfig.HasKey("store.fts_tokenizer") {
+			db.FTSTokenizer = globalConfig.GetString("store.fts_tokenizer")
+		}
+		if ms := globalConfig.GetIntWithFallback("store.busy_timeout_ms", -1); ms >= 0 {
+			db.BusyTimeout = time.Duration(ms) * time.Millisecond
+		}
+		if retries := globalConfig.GetIntWithFallback("store.busy_retries", -1); retries >= 0 {
+			db.BusyRetries = retries
+		}
+		for _, meaning := range []string{classify.EmojiAcknowledgment, classify.EmojiResolved, classify.EmojiCelebration, classify.EmojiSeen} {
+			if globalConfig.HasKey("classify.emoji." + meaning) {
+				classify.SetEmojiMeaning(meaning, strings.Split(globalConfig.GetString("classify.emoji."+meaning), ","))
+			}
+		}
+	}
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "json", "Output format (json, jsonl, table)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "json", "Output format (json, jsonl or ndjson, table)")
+	rootCmd.PersistentFlags().BoolVar(&prettyOutput, "pretty", false, "Indent each jsonl/ndjson record (records stay newline-terminated)")
 	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "Database path (default: ~/.threadmine/threadmine.db)")
+	rootCmd.PersistentFlags().StringVar(&storeBackend, "store", store.BackendDB, "Message store backend (db, fs)")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", colorAuto, "Highlight search matches in table output in color: auto (when stdout is a terminal), always, or never")
+}
+
+// openStore returns the message store selected by --store. Users, channels,
+// rate limits, and raw API responses are always kept in database.
+func openStore(database *db.DB) (store.Store, error) {
+	st, err := store.Open(storeBackend, database)
+	if err != nil {
+		return nil, usageErrorf("invalid --store value: %w", err)
+	}
+	return st, nil
 }
 
 // OutputJSON writes JSON to stdout with optional pretty printing
@@ -71,6 +158,36 @@ func OutputJSON(data interface{}) error {
 	return nil
 }
 
+// OutputJSONL writes one JSON record per item to stdout (newline-delimited
+// JSON, also called ndjson), indenting each record with --pretty
+func OutputJSONL[T any](records []T) error {
+	w := bufio.NewWriter(os.Stdout)
+	if err := writeJSONL(w, records, prettyOutput); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// writeJSONL writes each record as JSON terminated by a newline. Pretty
+// records span several lines but each still ends with a newline, so tools
+// that decode a stream of JSON values read one value per record.
+func writeJSONL[T any](w io.Writer, records []T, pretty bool) error {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	for _, record := range records {
+		// Encode appends the trailing newline
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write JSON record: %w", err)
+		}
+	}
+	return nil
+}
+
 // OutputError writes error message to stderr
 func OutputError(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for normalized messages",
+	Long: `Print a JSON Schema document describing the normalized message format.
+
+The schema covers NormalizedMessage and the User, Channel, Attachment, and
+CodeBlock types it references, and is stamped with the normalized schema
+version. Use it to validate or generate importers for ThreadMine data.
+
+Examples:
+  # Print the schema
+  mine schema
+
+  # Write the schema to a file
+  mine schema --fields-file threadmine.schema.json`,
+	RunE: runSchema,
+}
+
+var (
+	schemaFieldsFile string
+)
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().StringVar(&schemaFieldsFile, "fields-file", "", "Write the schema to this file instead of stdout")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	schema := normalize.JSONSchema()
+
+	if schemaFieldsFile == "" {
+		return OutputJSON(schema)
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	path, err := utils.ExpandPath(schemaFieldsFile)
+	if err != nil {
+		return usageErrorf("invalid --fields-file path: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write schema file: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Schema version %s written to %s\n", normalize.SchemaVersion, path)
+	return nil
+}
@@ -1,14 +1,20 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
+	"unicode/utf8"
 
+	"github.com/solvaholic/threadmine/internal/classify"
 	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/solvaholic/threadmine/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +30,10 @@ Examples:
   # Select threads mentioning a keyword
   mine select --search "kubernetes"
 
-  # Select threads with multiple participants
+  # Field qualifiers and quoted phrases in one search
+  mine select --search 'author:alice has:code "exact phrase" kubernetes'
+
+  # Select messages by any of several authors
   mine select --author alice --author bob --author charlie
 
   # Select messages that look like questions
@@ -33,53 +42,148 @@ Examples:
   # Select messages with code blocks
   mine select --has-code --since 7d
 
+  # Select GitHub threads assigned to a user
+  mine select --assignee alice --source github
+
+  # Leave out pasted code and logs
+  mine select --channel help --since 7d --code-only=false
+
   # Combine filters
   mine select --author alice --is-question --has-code --since 30d
 
+  # Only pull request conversations, or only Slack direct messages
+  mine select --source github --channel-type pr --since 30d
+  mine select --channel-type dm --since 7d
+
+  # Only messages detected to be in Spanish (run mine enrich first for
+  # messages enriched before language detection)
+  mine select --lang es --since 30d
+
+  # Exclude a noisy channel and a bot account
+  mine select --since 7d --exclude-channel alerts --exclude-author deploybot
+
+  # Closed GitHub issues and pull requests, by source metadata
+  mine select --source github --meta state=closed --format table
+
+  # Messages referencing v1.2.3, or any issue
+  mine select --has-entity version=v1.2.3
+  mine select --has-entity issue_ref --since 30d
+
+  # Most recently active threads first
+  mine select --source slack --since 30d --sort last-activity
+
+  # One row per conversation: thread roots with reply and participant counts
+  mine select --search "deploy" --since 30d --threads-only --format table
+
+  # Count messages per author, or per day, matching the filters
+  mine select --since 30d --count-by author --format table
+  mine select --source slack --since 7d --count-by day
+
+  # Graph with only confident classifications
+  mine select --thread msg_slack_C123_1700000000.000100 --format graph --min-confidence 0.7
+
+  # Share thread structure without identities
+  mine select --source slack --since 30d --anonymize --redact-content
+
+  # List messages with the first 200 characters of their content
+  mine select --since 7d --format jsonl --preview 200
+
 Output formats:
   - json: Normalized messages with annotations (default, for tools)
-  - jsonl: One message per line (for streaming/piping)
+  - jsonl (or ndjson): One message per line (for streaming/piping); --pretty indents each record
   - table: Human-readable table
   - graph: Graph format for visualization tools`,
 	RunE: runSelect,
 }
 
 var (
-	selectAuthors  []string
-	selectChannels []string
-	selectSources  []string
-	selectSearch   string
-	selectSince    string
-	selectUntil    string
-	selectThreadID string
-	selectLimit    int
-	selectOffset   int
+	selectAuthors         []string
+	selectChannels        []string
+	selectExcludeAuthors  []string
+	selectExcludeChannels []string
+	selectMentionsMe      bool
+	selectChannelTypes    []string
+	selectSources         []string
+	selectSearch          string
+	selectCaseSensitive   bool
+	selectSince           string
+	selectUntil           string
+	selectThreadID        string
+	selectAssignee        string
+	selectPRState         string
+	selectMeta            []string
+	selectHasEntity       []string
+	selectLimit           int
+	selectOffset          int
+	selectCountBy         string
+	selectSort            string
+	selectThreadsOnly     bool
+	selectDedupe          bool
+	selectOnlyOpen        bool
+	selectOnlyResolved    bool
+	selectOnlyDismissed   bool
+
+	// Export options
+	selectAnonymize     bool
+	selectRedactContent bool
+
+	// Display options
+	selectMinConfidence float64
+	selectPreview       int
+	selectNoPreview     bool
 
 	// Enrichment filters
 	selectIsQuestion bool
 	selectHasCode    bool
 	selectHasLinks   bool
 	selectHasQuotes  bool
+	selectCodeOnly   bool
+	selectLinkOnly   bool
+	selectLang       string
 )
 
 func init() {
 	rootCmd.AddCommand(selectCmd)
 
-	selectCmd.Flags().StringSliceVar(&selectAuthors, "author", nil, "Filter by author (can be repeated)")
+	selectCmd.Flags().StringSliceVar(&selectAuthors, "author", nil, "Filter by author (can be repeated; messages by any of them)")
 	selectCmd.Flags().StringSliceVar(&selectChannels, "channel", nil, "Filter by channel (can be repeated)")
-	selectCmd.Flags().StringSliceVar(&selectSources, "source", nil, "Filter by source type: slack, github, email")
-	selectCmd.Flags().StringVar(&selectSearch, "search", "", "Full-text search query")
-	selectCmd.Flags().StringVar(&selectSince, "since", "", "Start date (YYYY-MM-DD or relative like 7d)")
-	selectCmd.Flags().StringVar(&selectUntil, "until", "", "End date (YYYY-MM-DD)")
+	selectCmd.Flags().StringSliceVar(&selectExcludeAuthors, "exclude-author", nil, "Exclude messages by this author (can be repeated)")
+	selectCmd.Flags().StringSliceVar(&selectExcludeChannels, "exclude-channel", nil, "Exclude messages in this channel (can be repeated)")
+	selectCmd.Flags().BoolVar(&selectMentionsMe, "mentions-me", false, "Filter to messages mentioning you: the users fetches authenticated as, in any source")
+	selectCmd.Flags().StringSliceVar(&selectChannelTypes, "channel-type", nil, "Filter by channel type: channel, dm (Slack), issue, pr, discussion, commit (GitHub) (can be repeated)")
+	selectCmd.Flags().StringSliceVar(&selectSources, "source", nil, "Filter by source type: slack, github, email (can be repeated; messages from any of them)")
+	selectCmd.Flags().StringVar(&selectSearch, "search", "", "Search query: words, \"quoted phrases\", and qualifiers like author:alice channel:general has:code is:question since:7d")
+	selectCmd.Flags().BoolVar(&selectCaseSensitive, "case-sensitive", false, "Match --search words and phrases, and author and channel names, with the same case")
+	selectCmd.Flags().StringVar(&selectSince, "since", "", "Start date (YYYY-MM-DD, RFC3339, or relative like 7d)")
+	selectCmd.Flags().StringVar(&selectUntil, "until", "", "End date (YYYY-MM-DD or RFC3339)")
 	selectCmd.Flags().StringVar(&selectThreadID, "thread", "", "Filter by thread ID")
+	selectCmd.Flags().StringVar(&selectAssignee, "assignee", "", "Filter to threads assigned to a user (GitHub)")
+	selectCmd.Flags().StringVar(&selectPRState, "pr-state", "", "Filter to pull requests that are open, merged, or closed (closed without merging) (GitHub)")
+	selectCmd.Flags().StringArrayVar(&selectMeta, "meta", nil, "Filter by source metadata key=value, e.g. state=closed or user.login=alice (can be repeated)")
+	selectCmd.Flags().StringArrayVar(&selectHasEntity, "has-entity", nil, "Filter to messages with an extracted entity of this type, or type=value, e.g. issue_ref or version=v1.2.3 (see mine entities list) (can be repeated)")
 	selectCmd.Flags().IntVar(&selectLimit, "limit", 100, "Maximum number of results")
 	selectCmd.Flags().IntVar(&selectOffset, "offset", 0, "Offset for pagination")
+	selectCmd.Flags().StringVar(&selectSort, "sort", db.SortTimestamp, "Sort order: timestamp (newest first) or last-activity (most recently active threads first)")
+	selectCmd.Flags().StringVar(&selectCountBy, "count-by", "", "Return message counts grouped by author, channel, source, day, or type instead of messages")
+	selectCmd.Flags().BoolVar(&selectThreadsOnly, "threads-only", false, "Return one row per thread with matching messages: its root, reply and participant counts, resolved state, and last activity")
+	selectCmd.Flags().BoolVar(&selectDedupe, "dedupe", false, "Leave out copies of messages cross-posted to several channels, keeping the first (see fetch slack --find-duplicates)")
+	selectCmd.Flags().BoolVar(&selectOnlyOpen, "only-open", false, "Filter to threads neither resolved nor dismissed by their source")
+	selectCmd.Flags().BoolVar(&selectOnlyResolved, "only-resolved", false, "Filter to threads their source resolved (e.g. issues closed as completed)")
+	selectCmd.Flags().BoolVar(&selectOnlyDismissed, "only-dismissed", false, "Filter to threads their source closed without resolving (e.g. issues closed as not planned)")
+	selectCmd.Flags().BoolVar(&selectAnonymize, "anonymize", false, "Replace user IDs with stable pseudonyms (user_0001) for sharing")
+	selectCmd.Flags().BoolVar(&selectRedactContent, "redact-content", false, "With --anonymize, also replace message content, code, and URLs")
+	selectCmd.Flags().Float64Var(&selectMinConfidence, "min-confidence", 0, "Only show classifications with at least this confidence (0-1) in graph output and --count-by type")
+	selectCmd.Flags().IntVar(&selectPreview, "preview", 0, "In json and jsonl output, cut message content to its first N characters, marking cut messages ContentTruncated with their FullLength")
+	selectCmd.Flags().BoolVar(&selectNoPreview, "no-preview", false, "Output full message content, overriding a select.preview config default")
 
 	// Enrichment filters
 	selectCmd.Flags().BoolVar(&selectIsQuestion, "is-question", false, "Filter to messages that look like questions")
 	selectCmd.Flags().BoolVar(&selectHasCode, "has-code", false, "Filter to messages containing code blocks")
 	selectCmd.Flags().BoolVar(&selectHasLinks, "has-links", false, "Filter to messages containing URLs")
 	selectCmd.Flags().BoolVar(&selectHasQuotes, "has-quotes", false, "Filter to messages containing quote blocks")
+	selectCmd.Flags().BoolVar(&selectCodeOnly, "code-only", false, "Filter to messages that are nothing but code, like pasted logs (--code-only=false to leave them out)")
+	selectCmd.Flags().BoolVar(&selectLinkOnly, "link-only", false, "Filter to messages that are nothing but links (--link-only=false to leave them out)")
+	selectCmd.Flags().StringVar(&selectLang, "lang", "", "Filter to messages detected to be in this language (ISO 639-1 code, e.g. en, es, de)")
 }
 
 func runSelect(cmd *cobra.Command, args []string) error {
@@ -97,12 +201,21 @@ func runSelect(cmd *cobra.Command, args []string) error {
 		if !cmd.Flags().Changed("offset") && globalConfig.HasKey("select.offset") {
 			selectOffset = globalConfig.GetIntWithFallback("select.offset", selectOffset)
 		}
+		if !cmd.Flags().Changed("sort") && globalConfig.HasKey("select.sort") {
+			selectSort = globalConfig.GetString("select.sort")
+		}
 		if !cmd.Flags().Changed("search") && globalConfig.HasKey("select.search") {
 			selectSearch = globalConfig.GetString("select.search")
 		}
 		if !cmd.Flags().Changed("thread") && globalConfig.HasKey("select.thread") {
 			selectThreadID = globalConfig.GetString("select.thread")
 		}
+		if !cmd.Flags().Changed("assignee") && globalConfig.HasKey("select.assignee") {
+			selectAssignee = globalConfig.GetString("select.assignee")
+		}
+		if !cmd.Flags().Changed("pr-state") && globalConfig.HasKey("select.pr-state") {
+			selectPRState = globalConfig.GetString("select.pr-state")
+		}
 		// Handle format flag from root command
 		if !cmd.Flags().Changed("format") && globalConfig.HasKey("select.format") {
 			outputFormat = globalConfig.GetString("select.format")
@@ -127,6 +240,18 @@ func runSelect(cmd *cobra.Command, args []string) error {
 				}
 			}
 		}
+		if !cmd.Flags().Changed("exclude-author") && globalConfig.HasKey("select.exclude-author") {
+			selectExcludeAuthors = splitConfigList(globalConfig.GetString("select.exclude-author"))
+		}
+		if !cmd.Flags().Changed("exclude-channel") && globalConfig.HasKey("select.exclude-channel") {
+			selectExcludeChannels = splitConfigList(globalConfig.GetString("select.exclude-channel"))
+		}
+		if !cmd.Flags().Changed("mentions-me") && globalConfig.HasKey("select.mentions-me") {
+			selectMentionsMe = globalConfig.GetBool("select.mentions-me")
+		}
+		if !cmd.Flags().Changed("channel-type") && globalConfig.HasKey("select.channel-type") {
+			selectChannelTypes = splitConfigList(globalConfig.GetString("select.channel-type"))
+		}
 		if !cmd.Flags().Changed("source") && globalConfig.HasKey("select.source") {
 			sources := globalConfig.GetString("select.source")
 			if sources != "" {
@@ -149,6 +274,126 @@ func runSelect(cmd *cobra.Command, args []string) error {
 		if !cmd.Flags().Changed("has-quotes") && globalConfig.HasKey("select.has-quotes") {
 			selectHasQuotes = globalConfig.GetBool("select.has-quotes")
 		}
+		if !cmd.Flags().Changed("code-only") && globalConfig.HasKey("select.code-only") {
+			selectCodeOnly = globalConfig.GetBool("select.code-only")
+		}
+		if !cmd.Flags().Changed("link-only") && globalConfig.HasKey("select.link-only") {
+			selectLinkOnly = globalConfig.GetBool("select.link-only")
+		}
+		if !cmd.Flags().Changed("lang") && globalConfig.HasKey("select.lang") {
+			selectLang = globalConfig.GetString("select.lang")
+		}
+		if !cmd.Flags().Changed("case-sensitive") && globalConfig.HasKey("select.case-sensitive") {
+			selectCaseSensitive = globalConfig.GetBool("select.case-sensitive")
+		}
+		if !cmd.Flags().Changed("threads-only") && globalConfig.HasKey("select.threads-only") {
+			selectThreadsOnly = globalConfig.GetBool("select.threads-only")
+		}
+		if !cmd.Flags().Changed("dedupe") && globalConfig.HasKey("select.dedupe") {
+			selectDedupe = globalConfig.GetBool("select.dedupe")
+		}
+		if !cmd.Flags().Changed("only-open") && globalConfig.HasKey("select.only-open") {
+			selectOnlyOpen = globalConfig.GetBool("select.only-open")
+		}
+		if !cmd.Flags().Changed("only-resolved") && globalConfig.HasKey("select.only-resolved") {
+			selectOnlyResolved = globalConfig.GetBool("select.only-resolved")
+		}
+		if !cmd.Flags().Changed("only-dismissed") && globalConfig.HasKey("select.only-dismissed") {
+			selectOnlyDismissed = globalConfig.GetBool("select.only-dismissed")
+		}
+		if !cmd.Flags().Changed("preview") && globalConfig.HasKey("select.preview") {
+			selectPreview = globalConfig.GetIntWithFallback("select.preview", selectPreview)
+		}
+	}
+
+	for _, t := range selectChannelTypes {
+		if !db.IsChannelType(t) {
+			return usageErrorf("unknown --channel-type value: %s (expected one of %s)", t, strings.Join(db.ChannelTypes, ", "))
+		}
+	}
+
+	if selectPRState != "" && !db.IsPRState(selectPRState) {
+		return usageErrorf("unknown --pr-state value: %s (expected one of %s)", selectPRState, strings.Join(db.PRStates, ", "))
+	}
+
+	selectLang = strings.ToLower(strings.TrimSpace(selectLang))
+	if selectLang != "" && !languageCodePattern.MatchString(selectLang) {
+		return usageErrorf("invalid --lang value: %s (expected a two-letter ISO 639-1 code, e.g. en)", selectLang)
+	}
+
+	threadState := ""
+	for _, state := range []struct {
+		set   bool
+		value string
+	}{
+		{selectOnlyOpen, db.ThreadStateOpen},
+		{selectOnlyResolved, db.ThreadStateResolved},
+		{selectOnlyDismissed, db.ThreadStateDismissed},
+	} {
+		if !state.set {
+			continue
+		}
+		if threadState != "" {
+			return usageErrorf("--only-%s cannot be combined with --only-%s", threadState, state.value)
+		}
+		threadState = state.value
+	}
+
+	if selectMinConfidence < 0 || selectMinConfidence > 1 {
+		return usageErrorf("--min-confidence must be between 0 and 1, got %g", selectMinConfidence)
+	}
+
+	if selectPreview < 0 {
+		return usageErrorf("--preview must not be negative, got %d", selectPreview)
+	}
+	if selectNoPreview {
+		if cmd.Flags().Changed("preview") {
+			return usageErrorf("--preview cannot be combined with --no-preview")
+		}
+		selectPreview = 0
+	}
+	// A select.preview default only applies to the formats it can
+	if cmd.Flags().Changed("preview") {
+		switch outputFormat {
+		case "json", "jsonl", "ndjson":
+		default:
+			return usageErrorf("--preview only applies to json and jsonl output, not %s", outputFormat)
+		}
+	}
+
+	// Split field qualifiers (author:alice has:code ...) out of the search
+	// text and merge them with the matching flags
+	if selectSearch != "" {
+		query, err := store.ParseQuery(selectSearch)
+		if err != nil {
+			return usageErrorf("invalid --search value: %w", err)
+		}
+		selectAuthors = append(selectAuthors, query.Authors...)
+		selectChannels = append(selectChannels, query.Channels...)
+		selectExcludeAuthors = append(selectExcludeAuthors, query.ExcludeAuthors...)
+		selectExcludeChannels = append(selectExcludeChannels, query.ExcludeChannels...)
+		selectSources = append(selectSources, query.Sources...)
+		if query.ThreadID != "" {
+			selectThreadID = query.ThreadID
+		}
+		if query.Since != "" {
+			selectSince = query.Since
+		}
+		if query.Until != "" {
+			selectUntil = query.Until
+		}
+		selectIsQuestion = selectIsQuestion || query.IsQuestion
+		selectHasCode = selectHasCode || query.HasCode
+		selectHasLinks = selectHasLinks || query.HasLinks
+		selectHasQuotes = selectHasQuotes || query.HasQuotes
+		selectCodeOnly = selectCodeOnly || query.IsCodeOnly
+		selectLinkOnly = selectLinkOnly || query.IsLinkOnly
+		selectSearch = query.Text
+	}
+	if selectCaseSensitive && selectSearch != "" {
+		if _, err := db.SearchTerms(selectSearch); err != nil {
+			return usageErrorf("invalid --search value: %w with --case-sensitive", err)
+		}
 	}
 
 	// Open database
@@ -163,127 +408,450 @@ func runSelect(cmd *cobra.Command, args []string) error {
 	}
 	defer database.Close()
 
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
 	// Build query options
 	opts := db.SelectMessagesOptions{
 		Limit:  selectLimit,
 		Offset: selectOffset,
+		Sort:   selectSort,
 	}
 
 	// Parse since/until dates
 	if selectSince != "" {
-		since, err := parseTimeSpec(selectSince)
+		since, err := utils.ParseSinceDate(selectSince)
 		if err != nil {
-			return fmt.Errorf("invalid --since value: %w", err)
+			return usageErrorf("invalid --since value: %w", err)
 		}
 		opts.Since = &since
 	}
 
 	if selectUntil != "" {
-		until, err := parseTimeSpec(selectUntil)
+		until, err := utils.ParseSinceDate(selectUntil)
 		if err != nil {
-			return fmt.Errorf("invalid --until value: %w", err)
+			return usageErrorf("invalid --until value: %w", err)
 		}
 		opts.Until = &until
 	}
 
-	// Handle source filter
-	if len(selectSources) > 0 {
-		// For now, just use the first source
-		// TODO: Support multiple sources with OR logic
-		opts.SourceType = &selectSources[0]
-	}
+	// Handle source filter: messages from any of the sources
+	opts.SourceTypes = selectSources
 
-	// Handle author filter
-	if len(selectAuthors) > 0 {
-		// Look up author by name to get user ID
-		// For now, just use the first author
-		// TODO: Support multiple authors
-		authorName := selectAuthors[0]
-		users, err := database.FindUsersByName(authorName)
+	// Handle author filter: messages by any of the authors, and by every user
+	// an ambiguous name matches
+	seenAuthors := make(map[string]bool)
+	for _, name := range selectAuthors {
+		authorIDs, err := resolveUserIDs(database, name, selectCaseSensitive)
 		if err != nil {
-			return fmt.Errorf("failed to find user '%s': %w", authorName, err)
+			return err
 		}
-		if len(users) == 0 {
-			return fmt.Errorf("no user found with name '%s'", authorName)
+		for _, id := range authorIDs {
+			if !seenAuthors[id] {
+				seenAuthors[id] = true
+				opts.AuthorIDs = append(opts.AuthorIDs, id)
+			}
 		}
-		// If multiple users found, use the first one
-		// TODO: Let user disambiguate if multiple matches
-		opts.AuthorID = &users[0].ID
 	}
 
 	// Handle channel filter
 	if len(selectChannels) > 0 {
 		// Look up channel by name to get channel ID
-		channelName := selectChannels[0]
-		channels, err := database.FindChannelsByName(channelName)
+		channelID, err := resolveChannelID(database, selectChannels[0], selectCaseSensitive)
+		if err != nil {
+			return err
+		}
+		opts.ChannelID = &channelID
+	}
+
+	// Handle exclusion filters, resolving names like the filters above
+	for _, name := range selectExcludeAuthors {
+		authorID, err := resolveUserID(database, name, selectCaseSensitive)
 		if err != nil {
-			return fmt.Errorf("failed to find channel '%s': %w", channelName, err)
+			return err
 		}
-		if len(channels) == 0 {
-			return fmt.Errorf("no channel found with name '%s'", channelName)
+		opts.ExcludeAuthorIDs = append(opts.ExcludeAuthorIDs, authorID)
+	}
+	for _, name := range selectExcludeChannels {
+		channelID, err := resolveChannelID(database, name, selectCaseSensitive)
+		if err != nil {
+			return err
 		}
-		// If multiple channels found, use the first one
-		// TODO: Let user disambiguate if multiple matches
-		opts.ChannelID = &channels[0].ID
+		opts.ExcludeChannelIDs = append(opts.ExcludeChannelIDs, channelID)
 	}
 
-	// Handle thread filter
+	// Handle the mentions-me filter, with the users fetches authenticated as
+	if selectMentionsMe {
+		me, err := database.AuthenticatedUserIDs()
+		if err != nil {
+			return err
+		}
+		if len(me) == 0 {
+			return fmt.Errorf("no authenticated user recorded; run mine fetch first")
+		}
+		opts.MentionsAnyOf = me
+	}
+
+	opts.ChannelTypes = selectChannelTypes
+	opts.PRState = selectPRState
+	opts.CollapseDuplicates = selectDedupe
+
+	// Handle source metadata filters
+	for _, spec := range selectMeta {
+		filter, err := db.ParseMetadataFilter(spec)
+		if err != nil {
+			return usageErrorf("invalid --meta value: %w", err)
+		}
+		opts.Metadata = append(opts.Metadata, filter)
+	}
+
+	// Handle entity filters
+	for _, spec := range selectHasEntity {
+		filter, err := db.ParseEntityFilter(spec)
+		if err != nil {
+			return usageErrorf("invalid --has-entity value: %w", err)
+		}
+		opts.Entities = append(opts.Entities, filter)
+	}
+
+	// Handle assignee filter
+	if selectAssignee != "" {
+		users, err := database.FindUsersByName(selectAssignee, selectCaseSensitive)
+		if err != nil {
+			return fmt.Errorf("failed to find user '%s': %w", selectAssignee, err)
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("no user found with name '%s'", selectAssignee)
+		}
+		// Assignments only exist on GitHub, so prefer a GitHub user when the name is ambiguous
+		assignee := users[0]
+		for _, u := range users {
+			if u.SourceType == "github" {
+				assignee = u
+				break
+			}
+		}
+		opts.AssigneeID = &assignee.ID
+	}
+
+	// Handle thread filters
 	if selectThreadID != "" {
 		opts.ThreadID = &selectThreadID
 	}
+	opts.ThreadState = threadState
 
 	// Handle search
 	if selectSearch != "" {
 		opts.SearchText = &selectSearch
+		opts.CaseSensitive = selectCaseSensitive
 	}
 
 	// Handle enrichment filters (only if explicitly set)
-	if cmd.Flags().Changed("is-question") {
+	if cmd.Flags().Changed("is-question") || selectIsQuestion {
 		opts.IsQuestion = &selectIsQuestion
 	}
-	if cmd.Flags().Changed("has-code") {
+	if cmd.Flags().Changed("has-code") || selectHasCode {
 		opts.HasCode = &selectHasCode
 	}
-	if cmd.Flags().Changed("has-links") {
+	if cmd.Flags().Changed("has-links") || selectHasLinks {
 		opts.HasLinks = &selectHasLinks
 	}
-	if cmd.Flags().Changed("has-quotes") {
+	if cmd.Flags().Changed("has-quotes") || selectHasQuotes {
 		opts.HasQuotes = &selectHasQuotes
 	}
+	if cmd.Flags().Changed("code-only") || selectCodeOnly {
+		opts.IsCodeOnly = &selectCodeOnly
+	}
+	if cmd.Flags().Changed("link-only") || selectLinkOnly {
+		opts.IsLinkOnly = &selectLinkOnly
+	}
+	if selectLang != "" {
+		opts.Language = &selectLang
+	}
+
+	// Return grouped counts instead of messages
+	if selectCountBy != "" {
+		if selectAnonymize {
+			return usageErrorf("--count-by cannot be combined with --anonymize")
+		}
+		if selectThreadsOnly {
+			return usageErrorf("--count-by cannot be combined with --threads-only")
+		}
+		counts, err := countMessages(st, opts, selectCountBy)
+		if err != nil {
+			return err
+		}
+		return outputCounts(counts)
+	}
+
+	// Return thread roots with aggregates instead of messages
+	if selectThreadsOnly {
+		if cmd.Flags().Changed("preview") {
+			return usageErrorf("--preview cannot be combined with --threads-only")
+		}
+		if selectRedactContent && !selectAnonymize {
+			return usageErrorf("--redact-content requires --anonymize")
+		}
+		threads, err := st.SelectThreads(opts)
+		if err != nil {
+			return fmt.Errorf("failed to select threads: %w", err)
+		}
+		if err := addThreadResolutions(database, threads); err != nil {
+			return err
+		}
+		if selectAnonymize {
+			roots := make([]*db.Message, 0, len(threads))
+			for _, thread := range threads {
+				if thread.Root != nil {
+					roots = append(roots, thread.Root)
+				}
+			}
+			anonymizeMessages(roots, selectRedactContent)
+		}
+		return outputThreads(threads, newHighlighter(selectSearch, selectCaseSensitive, useColor()))
+	}
 
 	// Execute query
-	messages, err := database.SelectMessages(opts)
+	messages, err := st.SelectMessages(opts)
 	if err != nil {
 		return fmt.Errorf("failed to select messages: %w", err)
 	}
 
+	if selectRedactContent && !selectAnonymize {
+		return usageErrorf("--redact-content requires --anonymize")
+	}
+	if selectAnonymize {
+		anonymizeMessages(messages, selectRedactContent)
+	}
+
 	// Output results
 	switch outputFormat {
 	case "json":
+		if selectPreview > 0 {
+			return OutputJSON(previewMessages(messages, selectPreview))
+		}
 		return OutputJSON(messages)
-	case "jsonl":
-		return outputJSONL(messages)
+	case "jsonl", "ndjson":
+		if selectPreview > 0 {
+			return OutputJSONL(previewMessages(messages, selectPreview))
+		}
+		return OutputJSONL(messages)
 	case "table":
-		return outputTable(messages)
+		return outputTable(messages, newHighlighter(selectSearch, selectCaseSensitive, useColor()))
 	case "graph":
 		return outputGraph(messages)
 	default:
-		return fmt.Errorf("unknown format: %s", outputFormat)
+		return usageErrorf("unknown format: %s", outputFormat)
 	}
 }
 
-func outputJSONL(messages []*db.Message) error {
-	for _, msg := range messages {
-		data, err := json.Marshal(msg)
+// addThreadResolutions fills in the resolved state of threads from their
+// summaries in the database, which every store backend shares
+func addThreadResolutions(database *db.DB, threads []*db.ThreadMatch) error {
+	for _, thread := range threads {
+		summary, err := database.GetThread(thread.ThreadID)
 		if err != nil {
-			return fmt.Errorf("failed to marshal message: %w", err)
+			return err
+		}
+		if summary != nil {
+			thread.Resolved = summary.Resolved
+			thread.Dismissed = summary.Dismissed
 		}
-		fmt.Println(string(data))
 	}
 	return nil
 }
 
-func outputTable(messages []*db.Message) error {
+// outputThreads writes --threads-only results in the selected format, with
+// the search terms marked by hl in table output
+func outputThreads(threads []*db.ThreadMatch, hl *highlighter) error {
+	switch outputFormat {
+	case "json":
+		return OutputJSON(threads)
+	case "jsonl", "ndjson":
+		return OutputJSONL(threads)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "LAST ACTIVITY\tREPLIES\tPARTICIPANTS\tSTATUS\tTHREAD\n")
+		fmt.Fprintf(w, "-------------\t-------\t------------\t------\t------\n")
+		for _, thread := range threads {
+			status := "open"
+			switch {
+			case thread.Resolved:
+				status = "resolved"
+			case thread.Dismissed:
+				status = "dismissed"
+			}
+			content := ""
+			if thread.Root != nil {
+				content = strings.ReplaceAll(thread.Root.Content, "\n", " ")
+				if runes := []rune(content); len(runes) > 60 {
+					content = string(runes[:57]) + "..."
+				}
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n",
+				thread.LastActivityAt.Local().Format("2006-01-02 15:04"),
+				thread.ReplyCount,
+				thread.ParticipantCount,
+				status,
+				hl.highlight(content),
+			)
+		}
+		return nil
+	default:
+		return usageErrorf("--threads-only supports json, jsonl, and table output, not %s", outputFormat)
+	}
+}
+
+// messagePreview is a message in json and jsonl output with its content cut
+// to a preview by --preview
+type messagePreview struct {
+	*db.Message
+	ContentTruncated bool // Content is the first --preview characters of the message
+	FullLength       int  // Length of the full content, in characters
+}
+
+// previewMessages cuts the content of messages to its first n characters.
+// Content is cut between runes, so multi-byte characters are never split.
+func previewMessages(messages []*db.Message, n int) []messagePreview {
+	previews := make([]messagePreview, len(messages))
+	for i, msg := range messages {
+		length := utf8.RuneCountInString(msg.Content)
+		previews[i] = messagePreview{Message: msg, FullLength: length}
+		if length <= n {
+			continue
+		}
+
+		cut := *msg
+		cut.Content = string([]rune(msg.Content)[:n])
+		previews[i].Message = &cut
+		previews[i].ContentTruncated = true
+	}
+	return previews
+}
+
+// languageCodePattern matches ISO 639-1 language codes, like --lang takes
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// splitConfigList splits a comma-separated config value into trimmed, non-empty items
+func splitConfigList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// countByType groups --count-by results by classification type
+const countByType = "type"
+
+// countMessages counts the messages matching opts, grouped by groupBy. Most
+// dimensions are counted by the store; types are computed by classifying the
+// matching messages in their threads, since classifications aren't stored.
+func countMessages(st store.Store, opts db.SelectMessagesOptions, groupBy string) ([]db.MessageCount, error) {
+	if groupBy != countByType {
+		counts, err := st.CountMessages(opts, groupBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count messages: %w", err)
+		}
+		return counts, nil
+	}
+
+	// Limit and Offset apply to the groups, not the messages
+	all := opts
+	all.Limit, all.Offset = 0, 0
+	messages, err := st.SelectMessages(all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select messages: %w", err)
+	}
+
+	counts := []db.MessageCount{}
+	for typ, n := range classify.CountTypesWithMinConfidence(toNormalizedMessages(messages), selectMinConfidence) {
+		counts = append(counts, db.MessageCount{Key: typ, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	return db.PageCounts(counts, opts.Limit, opts.Offset), nil
+}
+
+// outputCounts writes grouped message counts in the selected format
+func outputCounts(counts []db.MessageCount) error {
+	switch outputFormat {
+	case "json":
+		return OutputJSON(counts)
+	case "jsonl", "ndjson":
+		return OutputJSONL(counts)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\tCOUNT\n", strings.ToUpper(selectCountBy))
+		fmt.Fprintf(w, "%s\t-----\n", strings.Repeat("-", len(selectCountBy)))
+		for _, c := range counts {
+			fmt.Fprintf(w, "%s\t%d\n", c.Key, c.Count)
+		}
+		return nil
+	default:
+		return usageErrorf("unknown format for --count-by: %s", outputFormat)
+	}
+}
+
+// resolveUserID looks up a user by name (see db.FindUsersByName) and returns their ID
+func resolveUserID(database *db.DB, name string, caseSensitive bool) (string, error) {
+	users, err := database.FindUsersByName(name, caseSensitive)
+	if err != nil {
+		return "", fmt.Errorf("failed to find user '%s': %w", name, err)
+	}
+	if len(users) == 0 {
+		return "", fmt.Errorf("no user found with name '%s'", name)
+	}
+	// If multiple users found, use the first one
+	// TODO: Let user disambiguate if multiple matches
+	return users[0].ID, nil
+}
+
+// resolveUserIDs looks up users by name (see db.FindUsersByName) and returns
+// the IDs of all of them
+func resolveUserIDs(database *db.DB, name string, caseSensitive bool) ([]string, error) {
+	users, err := database.FindUsersByName(name, caseSensitive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user '%s': %w", name, err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no user found with name '%s'", name)
+	}
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids, nil
+}
+
+// resolveChannelID looks up a channel by name (see db.FindChannelsByName) and returns its ID
+func resolveChannelID(database *db.DB, name string, caseSensitive bool) (string, error) {
+	channels, err := database.FindChannelsByName(name, caseSensitive)
+	if err != nil {
+		return "", fmt.Errorf("failed to find channel '%s': %w", name, err)
+	}
+	if len(channels) == 0 {
+		return "", fmt.Errorf("no channel found with name '%s'", name)
+	}
+	// If multiple channels found, use the first one
+	// TODO: Let user disambiguate if multiple matches
+	return channels[0].ID, nil
+}
+
+// outputTable writes messages as a table, with the search terms marked by hl
+func outputTable(messages []*db.Message, hl *highlighter) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	defer w.Flush()
 
@@ -309,7 +877,7 @@ func outputTable(messages []*db.Message) error {
 				msg.Timestamp.Format("2006-01-02 15:04"),
 				msg.AuthorID,
 				msg.ChannelID,
-				content,
+				hl.highlight(content),
 			)
 		}
 		return nil
@@ -364,7 +932,7 @@ func outputTable(messages []*db.Message) error {
 			msg.Timestamp.Format("2006-01-02 15:04"),
 			authorName,
 			channelName,
-			content,
+			hl.highlight(content),
 		)
 	}
 
@@ -374,10 +942,11 @@ func outputTable(messages []*db.Message) error {
 func outputGraph(messages []*db.Message) error {
 	// Simple graph format: nodes and edges
 	type Node struct {
-		ID      string    `json:"id"`
-		Type    string    `json:"type"`
-		Content string    `json:"content"`
-		Time    time.Time `json:"timestamp"`
+		ID              string    `json:"id"`
+		Type            string    `json:"type"`
+		Content         string    `json:"content"`
+		Time            time.Time `json:"timestamp"`
+		Classifications []string  `json:"classifications,omitempty"`
 	}
 
 	type Edge struct {
@@ -396,13 +965,16 @@ func outputGraph(messages []*db.Message) error {
 		Edges: make([]Edge, 0),
 	}
 
+	classifications := classifyMessageTypes(messages)
+
 	// Add message nodes
 	for _, msg := range messages {
 		graph.Nodes = append(graph.Nodes, Node{
-			ID:      msg.ID,
-			Type:    "message",
-			Content: msg.Content,
-			Time:    msg.Timestamp,
+			ID:              msg.ID,
+			Type:            "message",
+			Content:         msg.Content,
+			Time:            msg.Timestamp,
+			Classifications: classifications[msg.ID],
 		})
 
 		// Add reply edges
@@ -418,47 +990,18 @@ func outputGraph(messages []*db.Message) error {
 	return OutputJSON(graph)
 }
 
-// parseTimeSpec parses time specifications like "7d", "2024-01-01", "3w"
-func parseTimeSpec(spec string) (time.Time, error) {
-	// Try parsing as RFC3339 or common date formats
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02",
-		"2006-01-02T15:04:05",
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, spec); err == nil {
-			return t, nil
-		}
-	}
-
-	// Try parsing as relative time (e.g., "7d", "3w", "2h")
-	if len(spec) < 2 {
-		return time.Time{}, fmt.Errorf("invalid time specification: %s", spec)
-	}
-
-	unit := spec[len(spec)-1]
-	valueStr := spec[:len(spec)-1]
-
-	var value int
-	if _, err := fmt.Sscanf(valueStr, "%d", &value); err != nil {
-		return time.Time{}, fmt.Errorf("invalid time value: %s", spec)
-	}
+// classifyMessageTypes classifies messages within the threads present in the
+// result set and returns message_id -> classification types, leaving out those
+// below --min-confidence
+func classifyMessageTypes(messages []*db.Message) map[string][]string {
+	return classify.ClassifyThreadsWithMinConfidence(toNormalizedMessages(messages), selectMinConfidence)
+}
 
-	now := time.Now()
-	switch unit {
-	case 'h':
-		return now.Add(-time.Duration(value) * time.Hour), nil
-	case 'd':
-		return now.AddDate(0, 0, -value), nil
-	case 'w':
-		return now.AddDate(0, 0, -value*7), nil
-	case 'm':
-		return now.AddDate(0, -value, 0), nil
-	case 'y':
-		return now.AddDate(-value, 0, 0), nil
-	default:
-		return time.Time{}, fmt.Errorf("unknown time unit: %c (use h, d, w, m, y)", unit)
+// toNormalizedMessages converts stored messages for use with the classifiers
+func toNormalizedMessages(messages []*db.Message) []*normalize.NormalizedMessage {
+	normalized := make([]*normalize.NormalizedMessage, len(messages))
+	for i, msg := range messages {
+		normalized[i] = toNormalizedMessage(msg)
 	}
+	return normalized
 }
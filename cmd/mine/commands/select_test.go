@@ -0,0 +1,458 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestSelect_MinConfidence(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	// The reply is a strong answer (0.8), a solution (0.7), and a weak acknowledgment (0.4)
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	rootID := "msg_slack_C1_1.0"
+	for _, msg := range []*db.Message{
+		{ID: rootID, SourceType: "slack", SourceID: "C1_1.0", Timestamp: base, AuthorID: "user_slack_U1", ChannelID: "chan_slack_C1",
+			Content: "How do I change the deploy timeout?", ThreadID: &rootID, IsThreadRoot: true},
+		{ID: "msg_slack_C1_2.0", SourceType: "slack", SourceID: "C1_2.0", Timestamp: base.Add(time.Minute), AuthorID: "user_slack_U2", ChannelID: "chan_slack_C1",
+			Content: "Try this, it fixed it for me:", ThreadID: &rootID, ParentID: &rootID,
+			CodeBlocks: []db.CodeBlock{{Language: "bash", Code: "deploy --timeout 600"}}},
+	} {
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	database.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		replyTypes []string
+		counts     map[string]int
+	}{
+		{
+			name:       "graph without threshold",
+			args:       []string{"--format", "graph"},
+			replyTypes: []string{"answer", "solution", "acknowledgment"},
+		},
+		{
+			name:       "graph hides low confidence",
+			args:       []string{"--format", "graph", "--min-confidence", "0.75"},
+			replyTypes: []string{"answer"},
+		},
+		{
+			name:   "count by type",
+			args:   []string{"--count-by", "type", "--min-confidence", "0.5"},
+			counts: map[string]int{"question": 1, "answer": 1, "solution": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--db", dbFile, "select", "--thread", rootID}, tt.args...)
+			err, out := execute(t, args...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+
+			if tt.counts != nil {
+				var counts []db.MessageCount
+				if err := json.Unmarshal([]byte(out), &counts); err != nil {
+					t.Fatalf("output is not JSON counts: %v\n%s", err, out)
+				}
+				got := make(map[string]int)
+				for _, c := range counts {
+					got[c.Key] = c.Count
+				}
+				if len(got) != len(tt.counts) {
+					t.Errorf("counts = %v, want %v", got, tt.counts)
+				}
+				for key, n := range tt.counts {
+					if got[key] != n {
+						t.Errorf("counts = %v, want %v", got, tt.counts)
+					}
+				}
+				return
+			}
+
+			var graph struct {
+				Nodes []struct {
+					ID              string   `json:"id"`
+					Classifications []string `json:"classifications"`
+				} `json:"nodes"`
+			}
+			if err := json.Unmarshal([]byte(out), &graph); err != nil {
+				t.Fatalf("output is not a graph: %v\n%s", err, out)
+			}
+			for _, node := range graph.Nodes {
+				if node.ID != "msg_slack_C1_2.0" {
+					continue
+				}
+				if len(node.Classifications) != len(tt.replyTypes) {
+					t.Fatalf("reply classifications = %v, want %v", node.Classifications, tt.replyTypes)
+				}
+				for i, typ := range tt.replyTypes {
+					if node.Classifications[i] != typ {
+						t.Errorf("reply classifications = %v, want %v", node.Classifications, tt.replyTypes)
+					}
+				}
+				return
+			}
+			t.Fatalf("reply missing from graph: %s", out)
+		})
+	}
+}
+
+func TestSelect_MinConfidenceOutOfRange(t *testing.T) {
+	err, _ := execute(t, "select", "--min-confidence", "1.5")
+	if err == nil || ExitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error, got %v", err)
+	}
+}
+
+func TestSelect_Preview(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for _, msg := range []*db.Message{
+		{ID: "msg_slack_C1_1.0", SourceType: "slack", SourceID: "C1_1.0", Timestamp: base, AuthorID: "user_slack_U1", ChannelID: "chan_slack_C1",
+			Content: "Déploiement échoué 🚀 après la mise à jour"},
+		{ID: "msg_slack_C1_2.0", SourceType: "slack", SourceID: "C1_2.0", Timestamp: base.Add(time.Minute), AuthorID: "user_slack_U1", ChannelID: "chan_slack_C1",
+			Content: "Merci"},
+	} {
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	database.Close()
+
+	type record struct {
+		ID               string
+		Content          string
+		ContentTruncated bool
+		FullLength       int
+	}
+	tests := []struct {
+		name string
+		args []string
+		want map[string]record
+	}{
+		{
+			name: "cut between runes",
+			args: []string{"--preview", "20"},
+			want: map[string]record{
+				"msg_slack_C1_1.0": {Content: "Déploiement échoué 🚀", ContentTruncated: true, FullLength: 41},
+				"msg_slack_C1_2.0": {Content: "Merci", FullLength: 5},
+			},
+		},
+		{
+			name: "no preview",
+			args: []string{"--no-preview"},
+			want: map[string]record{
+				"msg_slack_C1_1.0": {Content: "Déploiement échoué 🚀 après la mise à jour"},
+				"msg_slack_C1_2.0": {Content: "Merci"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--db", dbFile, "--format", "jsonl", "select"}, tt.args...)
+			err, out := execute(t, args...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+
+			dec := json.NewDecoder(strings.NewReader(out))
+			got := make(map[string]record)
+			for dec.More() {
+				var r record
+				if err := dec.Decode(&r); err != nil {
+					t.Fatalf("invalid output %q: %v", out, err)
+				}
+				id := r.ID
+				r.ID = ""
+				got[id] = r
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelect_ThreadsOnly(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	rootID, otherID := "msg_github_acme_widgets_7", "msg_github_acme_widgets_8"
+	for _, msg := range []*db.Message{
+		{ID: rootID, SourceType: "github", SourceID: "acme/widgets#7", Timestamp: base, AuthorID: "user_github_octocat",
+			ChannelID: "chan_github_acme_widgets", Content: "Crash on start", ThreadID: &rootID, IsThreadRoot: true},
+		{ID: rootID + "_comment_1", SourceType: "github", SourceID: "acme/widgets#7-comment-1", Timestamp: base.Add(time.Hour), AuthorID: "user_github_monalisa",
+			ChannelID: "chan_github_acme_widgets", Content: "Which version?", ThreadID: &rootID, ParentID: &rootID},
+		{ID: rootID + "_comment_2", SourceType: "github", SourceID: "acme/widgets#7-comment-2", Timestamp: base.Add(2 * time.Hour), AuthorID: "user_github_octocat",
+			ChannelID: "chan_github_acme_widgets", Content: "Fixed in 4.2", ThreadID: &rootID, ParentID: &rootID},
+		{ID: otherID, SourceType: "github", SourceID: "acme/widgets#8", Timestamp: base.Add(3 * time.Hour), AuthorID: "user_github_hubot",
+			ChannelID: "chan_github_acme_widgets", Content: "Add docs", ThreadID: &otherID, IsThreadRoot: true},
+	} {
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+	if err := database.SaveThread(&db.Thread{ID: rootID, RootMessageID: rootID, ChannelID: "chan_github_acme_widgets",
+		ReplyCount: 2, ParticipantCount: 2, StartedAt: base, LastActivityAt: base.Add(2 * time.Hour), Resolved: true}); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+	database.Close()
+
+	err, out := execute(t, "--db", dbFile, "--format", "jsonl", "select", "--threads-only")
+	if err != nil {
+		t.Fatalf("select --threads-only failed: %v", err)
+	}
+
+	type record struct {
+		ThreadID         string
+		Root             struct{ ID, Content string }
+		MatchCount       int
+		ReplyCount       int
+		ParticipantCount int
+		LastActivityAt   time.Time
+		Resolved         bool
+	}
+	var got []record
+	dec := json.NewDecoder(strings.NewReader(out))
+	for dec.More() {
+		var r record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid output %q: %v", out, err)
+		}
+		got = append(got, r)
+	}
+
+	// One row per thread, newest first
+	if len(got) != 2 || got[0].ThreadID != otherID || got[1].ThreadID != rootID {
+		t.Fatalf("expected threads %s and %s, got %+v", otherID, rootID, got)
+	}
+	issue := got[1]
+	if issue.Root.ID != rootID || issue.Root.Content != "Crash on start" {
+		t.Errorf("expected the issue as root, got %+v", issue.Root)
+	}
+	if issue.MatchCount != 3 || issue.ReplyCount != 2 || issue.ParticipantCount != 2 || !issue.Resolved {
+		t.Errorf("expected 3 matches, 2 replies, 2 participants, and resolved, got %+v", issue)
+	}
+	if !issue.LastActivityAt.Equal(base.Add(2 * time.Hour)) {
+		t.Errorf("LastActivityAt = %v, want %v", issue.LastActivityAt, base.Add(2*time.Hour))
+	}
+	if got[0].ReplyCount != 0 || got[0].Resolved {
+		t.Errorf("expected an open thread without replies, got %+v", got[0])
+	}
+}
+
+func TestSelect_ThreadState(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	resolvedID, openID, dismissedID := "msg_github_acme_widgets_7", "msg_github_acme_widgets_8", "msg_github_acme_widgets_9"
+	for i, id := range []string{resolvedID, openID, dismissedID} {
+		threadID := id
+		for _, msg := range []*db.Message{
+			{ID: id, SourceType: "github", SourceID: id, Timestamp: base.Add(time.Duration(i) * time.Hour), AuthorID: "user_github_octocat",
+				ChannelID: "chan_github_acme_widgets", Content: "Crash on start", ThreadID: &threadID, IsThreadRoot: true},
+			{ID: id + "_comment_1", SourceType: "github", SourceID: id + "-comment-1", Timestamp: base.Add(time.Duration(i)*time.Hour + time.Minute),
+				AuthorID: "user_github_hubot", ChannelID: "chan_github_acme_widgets", Content: "Looking", ThreadID: &threadID, ParentID: &threadID},
+		} {
+			if err := database.SaveMessage(msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+	}
+	for _, thread := range []*db.Thread{
+		{ID: resolvedID, RootMessageID: resolvedID, ChannelID: "chan_github_acme_widgets", ReplyCount: 1, StartedAt: base, LastActivityAt: base, Resolved: true},
+		{ID: dismissedID, RootMessageID: dismissedID, ChannelID: "chan_github_acme_widgets", ReplyCount: 1, StartedAt: base, LastActivityAt: base, Dismissed: true},
+	} {
+		if err := database.SaveThread(thread); err != nil {
+			t.Fatalf("SaveThread failed: %v", err)
+		}
+	}
+	database.Close()
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantThreads  []string
+		wantMessages int
+	}{
+		{"all", nil, []string{dismissedID, openID, resolvedID}, 6},
+		{"only open", []string{"--only-open"}, []string{openID}, 2},
+		{"only resolved", []string{"--only-resolved"}, []string{resolvedID}, 2},
+		{"only dismissed", []string{"--only-dismissed"}, []string{dismissedID}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("threads", func(t *testing.T) {
+				err, out := execute(t, append([]string{"--db", dbFile, "select", "--threads-only"}, tt.args...)...)
+				if err != nil {
+					t.Fatalf("select failed: %v", err)
+				}
+				var threads []struct{ ThreadID string }
+				if err := json.Unmarshal([]byte(out), &threads); err != nil {
+					t.Fatalf("invalid output %q: %v", out, err)
+				}
+				var got []string
+				for _, thread := range threads {
+					got = append(got, thread.ThreadID)
+				}
+				if !reflect.DeepEqual(got, tt.wantThreads) {
+					t.Errorf("threads = %v, want %v", got, tt.wantThreads)
+				}
+			})
+			t.Run("messages", func(t *testing.T) {
+				err, out := execute(t, append([]string{"--db", dbFile, "select"}, tt.args...)...)
+				if err != nil {
+					t.Fatalf("select failed: %v", err)
+				}
+				var messages []struct{ ID string }
+				if err := json.Unmarshal([]byte(out), &messages); err != nil {
+					t.Fatalf("invalid output %q: %v", out, err)
+				}
+				if len(messages) != tt.wantMessages {
+					t.Errorf("got %d messages, want %d", len(messages), tt.wantMessages)
+				}
+			})
+		})
+	}
+}
+
+func TestSelect_MentionsMe(t *testing.T) {
+	requireFTS5(t)
+	dir := stubGHFetch(t)
+	rootCmd.SetOut(io.Discard)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	comments := `[{"id": 100, "body": "Fixed by upgrading, thanks @Tester", "user": {"login": "hubot"},
+		"created_at": "2024-01-15T11:00:00Z", "updated_at": "2024-01-15T11:00:00Z"}]`
+	if err := os.WriteFile(filepath.Join(dir, "comments.json"), []byte(comments), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody is "me" until a fetch authenticates
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	if err, _ := execute(t, "--db", dbFile, "select", "--mentions-me"); err == nil || !strings.Contains(err.Error(), "no authenticated user") {
+		t.Fatalf("expected an error before any fetch, got %v", err)
+	}
+
+	// The stub gh authenticates as tester
+	if err, _ := execute(t, "--db", dbFile, "fetch", "github", "--repo", "acme/widgets", "--since", "30d"); err != nil {
+		t.Fatalf("fetch github failed: %v", err)
+	}
+	err, out := execute(t, "--db", dbFile, "select", "--mentions-me")
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	var messages []struct {
+		ID       string
+		Mentions []string
+	}
+	if err := json.Unmarshal([]byte(out), &messages); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	if len(messages) != 1 || messages[0].ID != "msg_github_acme_widgets_1_comment_100" {
+		t.Fatalf("expected only the comment mentioning @Tester, got %s", out)
+	}
+	if want := []string{"user_github_Tester"}; !reflect.DeepEqual(messages[0].Mentions, want) {
+		t.Errorf("Mentions = %v, want %v", messages[0].Mentions, want)
+	}
+}
+
+func TestSelect_Authors(t *testing.T) {
+	requireFTS5(t)
+
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// Two users go by charlie: a GitHub login and a Slack display name
+	charlie := "charlie"
+	users := []*db.User{
+		{ID: "user_github_alice", SourceType: "github", SourceID: "alice"},
+		{ID: "user_github_bob", SourceType: "github", SourceID: "bob"},
+		{ID: "user_github_charlie", SourceType: "github", SourceID: "charlie"},
+		{ID: "user_slack_U3", SourceType: "slack", SourceID: "U3", DisplayName: &charlie},
+		{ID: "user_github_dave", SourceType: "github", SourceID: "dave"},
+	}
+	for i, user := range users {
+		if err := database.SaveUser(user); err != nil {
+			t.Fatalf("SaveUser failed: %v", err)
+		}
+		msg := &db.Message{ID: "msg_" + user.SourceID, SourceType: user.SourceType, SourceID: user.SourceID, AuthorID: user.ID,
+			ChannelID: "chan_x", Content: "content", Timestamp: time.Date(2024, 1, 15, 10, i, 0, 0, time.UTC)}
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		authors []string
+		want    []string
+	}{
+		{"one", []string{"alice"}, []string{"msg_alice"}},
+		{"any of several", []string{"alice", "bob"}, []string{"msg_alice", "msg_bob"}},
+		{"comma-separated", []string{"alice,dave"}, []string{"msg_alice", "msg_dave"}},
+		{"every user a name matches", []string{"charlie"}, []string{"msg_U3", "msg_charlie"}},
+		{"repeated", []string{"bob", "BOB"}, []string{"msg_bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"--db", dbFile, "--format", "json", "select"}
+			for _, author := range tt.authors {
+				args = append(args, "--author", author)
+			}
+			err, out := execute(t, args...)
+			if err != nil {
+				t.Fatalf("select failed: %v", err)
+			}
+			if got := selectedIDs(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("select --author %v = %v, want %v", tt.authors, got, tt.want)
+			}
+		})
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "select", "--author", "alice", "--author", "nobody"); err == nil || !strings.Contains(err.Error(), "no user found with name 'nobody'") {
+		t.Errorf("expected an error for an unknown author, got %v", err)
+	}
+}
@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/classify"
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/eventlog"
+	"github.com/solvaholic/threadmine/internal/github"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/solvaholic/threadmine/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// threadResolution is how the source closed a thread
+type threadResolution int
+
+const (
+	threadOpen      threadResolution = iota
+	threadResolved                   // Closed with the problem solved
+	threadDismissed                  // Closed without a solution
+)
+
+// githubIssueResolution returns how a GitHub issue was closed. Only issues
+// closed as completed are resolved; issues closed as not planned are dismissed.
+func githubIssueResolution(issue *github.Issue) threadResolution {
+	if issue.State != "closed" {
+		return threadOpen
+	}
+	switch issue.StateReason {
+	case github.StateReasonCompleted:
+		return threadResolved
+	case github.StateReasonNotPlanned:
+		return threadDismissed
+	}
+	return threadOpen
+}
+
+// threadRecorder is a store that remembers the threads, authors, and channels
+// of saved messages, so they can be summarized once a fetch finishes
+type threadRecorder struct {
+	store.Store
+	threads     map[string]bool
+	order       []string
+	seen        map[string]bool
+	authors     []string
+	channels    []string
+	resolutions map[string]threadResolution
+
+	// Stages fetch --no-classify and --no-graph skip
+	skipClassify bool // Enrichments, answer relations, and classification coverage
+	skipGraph    bool // Thread summaries
+}
+
+// Names of the stages a fetch can skip, as reported in its summary
+const (
+	stageClassify = "classify"
+	stageGraph    = "graph"
+)
+
+func newThreadRecorder(st store.Store) *threadRecorder {
+	return &threadRecorder{
+		Store:       st,
+		threads:     make(map[string]bool),
+		seen:        make(map[string]bool),
+		resolutions: make(map[string]threadResolution),
+	}
+}
+
+// setResolution records how the source closed a thread, for its summary
+func (r *threadRecorder) setResolution(threadID string, resolution threadResolution) {
+	r.resolutions[threadID] = resolution
+}
+
+// loadThread returns the stored messages of a recorded thread. A message
+// outside any thread is recorded as a thread of its own.
+func (r *threadRecorder) loadThread(threadID string) ([]*db.Message, error) {
+	thread, err := r.Store.SelectMessages(db.SelectMessagesOptions{ThreadID: &threadID})
+	if err != nil || len(thread) > 0 {
+		return thread, err
+	}
+	msg, err := r.Store.LoadMessage(threadID)
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	return []*db.Message{msg}, nil
+}
+
+// SaveEnrichment saves enrich, unless classification is skipped
+func (r *threadRecorder) SaveEnrichment(enrich *db.Enrichment) error {
+	if r.skipClassify {
+		return nil
+	}
+	return r.Store.SaveEnrichment(enrich)
+}
+
+// skippedStages returns the names of the stages the recorder skips
+func (r *threadRecorder) skippedStages() []string {
+	var stages []string
+	if r.skipClassify {
+		stages = append(stages, stageClassify)
+	}
+	if r.skipGraph {
+		stages = append(stages, stageGraph)
+	}
+	return stages
+}
+
+// reportSkippedStages records the stages a fetch skipped in its event, and
+// notes them in its summary
+func reportSkippedStages(cmd *cobra.Command, recorder *threadRecorder, event *eventlog.Event) {
+	event.Skipped = recorder.skippedStages()
+	if len(event.Skipped) > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Skipped stages: %s (run mine reprocess to fill them in)\n", strings.Join(event.Skipped, ", "))
+	}
+}
+
+// SaveMessage saves msg and records its thread, author, and channel
+func (r *threadRecorder) SaveMessage(msg *db.Message) error {
+	if err := r.Store.SaveMessage(msg); err != nil {
+		return err
+	}
+	threadID := msg.ID
+	if msg.ThreadID != nil && *msg.ThreadID != "" {
+		threadID = *msg.ThreadID
+	}
+	if !r.threads[threadID] {
+		r.threads[threadID] = true
+		r.order = append(r.order, threadID)
+	}
+	if msg.AuthorID != "" && !r.seen[msg.AuthorID] {
+		r.seen[msg.AuthorID] = true
+		r.authors = append(r.authors, msg.AuthorID)
+	}
+	if msg.ChannelID != "" && !r.seen[msg.ChannelID] {
+		r.seen[msg.ChannelID] = true
+		r.channels = append(r.channels, msg.ChannelID)
+	}
+	return nil
+}
+
+// inferQuoteReplies attaches GitHub comments that start by quoting an earlier
+// comment of their thread to that comment, instead of the thread root (see
+// normalize.InferQuoteParents). Run it before summarizeThreads, so thread
+// depths include the inferred replies.
+func inferQuoteReplies(cmd *cobra.Command, recorder *threadRecorder) {
+	inferred := 0
+	for _, threadID := range recorder.order {
+		id := threadID
+		thread, err := recorder.Store.SelectMessages(db.SelectMessagesOptions{ThreadID: &id})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to load thread %s: %v\n", threadID, err)
+			continue
+		}
+
+		parents := normalize.InferQuoteParents(toNormalizedMessages(thread))
+		for _, msg := range thread {
+			parentID, ok := parents[msg.ID]
+			if !ok || msg.SourceType != "github" || (msg.ParentID != nil && *msg.ParentID == parentID) {
+				continue
+			}
+			msg.ParentID = &parentID
+			if err := recorder.Store.SaveMessage(msg); err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save message %s: %v\n", msg.ID, err)
+				continue
+			}
+			inferred++
+		}
+	}
+
+	if inferred > 0 {
+		fmt.Fprintf(cmd.OutOrStderr(), "Replies inferred from quotes: %d\n", inferred)
+	}
+}
+
+// summarizeThreads stores the title, reply count, and latest activity of each
+// recorded thread, computed from its root and reply graph, and how the source
+// closed it, and saves the reply graph of the recorded threads to the store,
+// labeled with their classifications. It
+// reports and returns the classification coverage of the recorded threads,
+// or nil if there are none. A recorder that skips the graph or classification
+// stage stores no summaries or graph, or labels and coverage, respectively.
+func summarizeThreads(cmd *cobra.Command, database *db.DB, recorder *threadRecorder) *classify.Coverage {
+	var all []*normalize.NormalizedMessage
+	for _, threadID := range recorder.order {
+		id := threadID
+		messages, err := recorder.Store.SelectMessages(db.SelectMessagesOptions{ThreadID: &id})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to load thread %s: %v\n", threadID, err)
+			continue
+		}
+		all = append(all, toNormalizedMessages(messages)...)
+		if recorder.skipGraph {
+			continue
+		}
+
+		var root *db.Message
+		for _, msg := range messages {
+			if msg.ID == threadID {
+				root = msg
+			}
+		}
+		// Replies whose root wasn't fetched can't be summarized yet
+		if root == nil {
+			continue
+		}
+
+		activity := graph.BuildFromNormalizedMessages(toNormalizedMessages(messages)).GetThreadActivity(root.ID)
+		if activity == nil {
+			continue
+		}
+
+		err = database.SaveThread(&db.Thread{
+			ID:               threadID,
+			RootMessageID:    root.ID,
+			Title:            normalize.DeriveThreadTitle(toNormalizedMessage(root)),
+			ChannelID:        root.ChannelID,
+			ReplyCount:       activity.ReplyCount,
+			ParticipantCount: len(activity.Participants),
+			MaxDepth:         activity.MaxDepth,
+			StartedAt:        activity.StartedAt,
+			LastActivityAt:   activity.LastActivityAt,
+			Participants:     activity.Participants,
+			Resolved:         recorder.resolutions[threadID] == threadResolved,
+			Dismissed:        recorder.resolutions[threadID] == threadDismissed,
+		})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save thread %s: %v\n", threadID, err)
+		}
+	}
+
+	if !recorder.skipGraph && len(all) > 0 {
+		var classifications map[string][]string
+		if !recorder.skipClassify {
+			classifications = classify.ClassifyThreads(all)
+		}
+		if err := recorder.Store.SaveReplyGraph(graph.BuildFromClassifiedMessages(all, classifications)); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "  Warning: failed to save reply graph: %v\n", err)
+		}
+	}
+
+	resolved := make(map[string]bool)
+	for threadID, resolution := range recorder.resolutions {
+		resolved[threadID] = resolution == threadResolved
+	}
+	if len(all) == 0 || recorder.skipClassify {
+		return nil
+	}
+	coverage := classify.ComputeCoverage(all, resolved)
+	writeCoverage(cmd.OutOrStderr(), coverage)
+	return &coverage
+}
+
+// exportGraphFormat returns the format to write --export-graph in:
+// --export-graph-format, or the one its file extension suggests
+func exportGraphFormat() (string, error) {
+	if fetchExportGraphFormat == "" {
+		return graph.ExportFormatForPath(fetchExportGraph), nil
+	}
+	if fetchExportGraph == "" {
+		return "", usageErrorf("--export-graph-format needs --export-graph")
+	}
+	if !graph.IsExportFormat(fetchExportGraphFormat) {
+		return "", usageErrorf("unknown --export-graph-format value: %s (expected %s)", fetchExportGraphFormat, strings.Join(graph.ExportFormats, ", "))
+	}
+	return fetchExportGraphFormat, nil
+}
+
+// exportReplyGraph writes the reply graph of the recorded threads to
+// --export-graph, if it's set
+func exportReplyGraph(cmd *cobra.Command, recorder *threadRecorder) error {
+	if fetchExportGraph == "" {
+		return nil
+	}
+	format, err := exportGraphFormat()
+	if err != nil {
+		return err
+	}
+	path, err := utils.ExpandPath(fetchExportGraph)
+	if err != nil {
+		return err
+	}
+
+	var messages []*db.Message
+	for _, threadID := range recorder.order {
+		thread, err := recorder.loadThread(threadID)
+		if err != nil {
+			return fmt.Errorf("failed to load thread %s: %w", threadID, err)
+		}
+		messages = append(messages, thread...)
+	}
+
+	g := graph.BuildFromNormalizedMessages(toNormalizedMessages(messages))
+	if err := g.ExportFile(path, format); err != nil {
+		return fmt.Errorf("failed to export reply graph: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStderr(), "Reply graph of %d messages written to %s\n", len(g.Nodes), path)
+	return nil
+}
+
+// writeCoverage writes the classification coverage block of a fetch summary
+func writeCoverage(out io.Writer, coverage classify.Coverage) {
+	fmt.Fprintf(out, "Classification coverage:\n")
+	fmt.Fprintf(out, "  Classified messages: %d/%d (%.0f%%)\n", coverage.ClassifiedMessages, coverage.Messages, 100*coverage.ClassifiedRatio)
+	fmt.Fprintf(out, "  Threads with a question: %d/%d\n", coverage.ThreadsWithQuestion, coverage.Threads)
+	fmt.Fprintf(out, "  Resolved threads: %d/%d\n", coverage.ResolvedThreads, coverage.Threads)
+}
+
+// reviewCommentMessageID returns the message ID of a PR review comment
+func reviewCommentMessageID(owner, repo string, prNumber int, commentID int64) string {
+	return fmt.Sprintf("msg_github_%s_%s_%d_review_comment_%d", owner, repo, prNumber, commentID)
+}
+
+// summarizeReviewThreads builds a thread summary for each PR review thread,
+// rooted at its first comment and marked resolved when GitHub says it is.
+// Comments without review thread state are skipped.
+func summarizeReviewThreads(comments []github.ReviewComment, owner, repo string, prNumber int) []*db.Thread {
+	var threads []*db.Thread
+	byRoot := make(map[int64]*db.Thread)
+	seen := make(map[string]map[string]bool)
+
+	for _, comment := range comments {
+		state := comment.ReviewThread
+		if state == nil {
+			continue
+		}
+
+		thread := byRoot[state.RootCommentID]
+		if thread == nil {
+			rootID := reviewCommentMessageID(owner, repo, prNumber, state.RootCommentID)
+			thread = &db.Thread{
+				ID:             rootID,
+				RootMessageID:  rootID,
+				ChannelID:      fmt.Sprintf("chan_github_%s_%s", owner, repo),
+				ReplyCount:     -1,
+				StartedAt:      comment.CreatedAt,
+				LastActivityAt: comment.CreatedAt,
+				Participants:   []string{},
+				Resolved:       state.Resolved,
+			}
+			byRoot[state.RootCommentID] = thread
+			seen[rootID] = make(map[string]bool)
+			threads = append(threads, thread)
+		}
+
+		thread.ReplyCount++
+		if thread.ReplyCount > 0 {
+			// GitHub review threads are flat: every reply answers the first comment
+			thread.MaxDepth = 1
+		}
+		if comment.CreatedAt.Before(thread.StartedAt) {
+			thread.StartedAt = comment.CreatedAt
+		}
+		if comment.CreatedAt.After(thread.LastActivityAt) {
+			thread.LastActivityAt = comment.CreatedAt
+		}
+		userID := fmt.Sprintf("user_github_%s", comment.User.Login)
+		if !seen[thread.ID][userID] {
+			seen[thread.ID][userID] = true
+			thread.Participants = append(thread.Participants, userID)
+			thread.ParticipantCount++
+		}
+	}
+
+	return threads
+}
@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/github"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func TestSummarizeReviewThreads(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	resolved := &github.ReviewThreadState{ID: "RT_1", RootCommentID: 10, Resolved: true}
+	open := &github.ReviewThreadState{ID: "RT_2", RootCommentID: 20}
+	outdated := &github.ReviewThreadState{ID: "RT_3", RootCommentID: 30, Outdated: true}
+
+	comments := []github.ReviewComment{
+		{ID: 10, User: github.User{Login: "alice"}, CreatedAt: start, ReviewThread: resolved},
+		{ID: 11, User: github.User{Login: "bob"}, CreatedAt: start.Add(time.Hour), InReplyToID: 10, ReviewThread: resolved},
+		{ID: 12, User: github.User{Login: "alice"}, CreatedAt: start.Add(2 * time.Hour), InReplyToID: 10, ReviewThread: resolved},
+		{ID: 20, User: github.User{Login: "carol"}, CreatedAt: start.Add(3 * time.Hour), ReviewThread: open},
+		{ID: 30, User: github.User{Login: "bob"}, CreatedAt: start.Add(4 * time.Hour), ReviewThread: outdated},
+		{ID: 31, User: github.User{Login: "alice"}, CreatedAt: start.Add(5 * time.Hour), InReplyToID: 30, ReviewThread: outdated},
+		{ID: 40, User: github.User{Login: "dave"}, CreatedAt: start},
+	}
+
+	threads := summarizeReviewThreads(comments, "acme", "widgets", 7)
+	if len(threads) != 3 {
+		t.Fatalf("expected 3 review threads, got %d", len(threads))
+	}
+
+	tests := []struct {
+		name         string
+		index        int
+		id           string
+		replies      int
+		participants int
+		resolved     bool
+		last         time.Time
+	}{
+		{"resolved", 0, "msg_github_acme_widgets_7_review_comment_10", 2, 2, true, start.Add(2 * time.Hour)},
+		{"unresolved", 1, "msg_github_acme_widgets_7_review_comment_20", 0, 1, false, start.Add(3 * time.Hour)},
+		{"outdated but unresolved", 2, "msg_github_acme_widgets_7_review_comment_30", 1, 2, false, start.Add(5 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := threads[tt.index]
+			if got.ID != tt.id || got.RootMessageID != tt.id {
+				t.Errorf("expected thread rooted at %s, got %s (root %s)", tt.id, got.ID, got.RootMessageID)
+			}
+			if got.ReplyCount != tt.replies {
+				t.Errorf("expected %d replies, got %d", tt.replies, got.ReplyCount)
+			}
+			if got.ParticipantCount != tt.participants || len(got.Participants) != tt.participants {
+				t.Errorf("expected %d participants, got %d %v", tt.participants, got.ParticipantCount, got.Participants)
+			}
+			if got.Resolved != tt.resolved {
+				t.Errorf("expected resolved=%v, got %v", tt.resolved, got.Resolved)
+			}
+			if !got.LastActivityAt.Equal(tt.last) {
+				t.Errorf("expected last activity %v, got %v", tt.last, got.LastActivityAt)
+			}
+		})
+	}
+}
+
+func TestGitHubIssueResolution(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue github.Issue
+		want  threadResolution
+	}{
+		{"closed as completed", github.Issue{State: "closed", StateReason: github.StateReasonCompleted}, threadResolved},
+		{"closed as not planned", github.Issue{State: "closed", StateReason: github.StateReasonNotPlanned}, threadDismissed},
+		{"open", github.Issue{State: "open"}, threadOpen},
+		{"reopened", github.Issue{State: "open", StateReason: "reopened"}, threadOpen},
+		{"closed without a reason", github.Issue{State: "closed"}, threadOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := githubIssueResolution(&tt.issue); got != tt.want {
+				t.Errorf("githubIssueResolution() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeThreads_Resolution(t *testing.T) {
+	requireFTS5(t)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	recorder := newThreadRecorder(store.NewDBStore(database))
+	issues := []github.Issue{
+		{Number: 1, State: "closed", StateReason: github.StateReasonCompleted},
+		{Number: 2, State: "closed", StateReason: github.StateReasonNotPlanned},
+		{Number: 3, State: "open"},
+	}
+	for _, issue := range issues {
+		id := fmt.Sprintf("msg_github_acme_widgets_%d", issue.Number)
+		msg := &db.Message{ID: id, SourceType: "github", SourceID: id, Timestamp: time.Now(), AuthorID: "user_github_octocat",
+			ChannelID: "chan_github_acme_widgets", Content: "An issue", ThreadID: &id, IsThreadRoot: true}
+		if err := recorder.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+		recorder.setResolution(id, githubIssueResolution(&issue))
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(io.Discard)
+	coverage := summarizeThreads(cmd, database, recorder)
+	if coverage == nil || coverage.Threads != 3 || coverage.ResolvedThreads != 1 {
+		t.Errorf("expected coverage of 3 threads with 1 resolved by its source, got %+v", coverage)
+	}
+
+	tests := []struct {
+		id        string
+		resolved  bool
+		dismissed bool
+	}{
+		{"msg_github_acme_widgets_1", true, false},
+		{"msg_github_acme_widgets_2", false, true},
+		{"msg_github_acme_widgets_3", false, false},
+	}
+	for _, tt := range tests {
+		thread, err := database.GetThread(tt.id)
+		if err != nil || thread == nil {
+			t.Fatalf("GetThread(%s) = %v, %v", tt.id, thread, err)
+		}
+		if thread.Resolved != tt.resolved || thread.Dismissed != tt.dismissed {
+			t.Errorf("%s: resolved=%v dismissed=%v, want resolved=%v dismissed=%v",
+				tt.id, thread.Resolved, thread.Dismissed, tt.resolved, tt.dismissed)
+		}
+	}
+}
+
+func TestInferQuoteReplies(t *testing.T) {
+	requireFTS5(t)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	recorder := newThreadRecorder(store.NewDBStore(database))
+	root := "msg_github_acme_widgets_1"
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	comments := []struct {
+		id      string
+		content string
+	}{
+		{root, "Crash on start\n\nIt crashes without a config file."},
+		{root + "_comment_1", "Which version are you running?"},
+		{root + "_comment_2", "> Which version are you running?\n\n2.1"},
+		{root + "_comment_3", "Same here."},
+	}
+	for i, c := range comments {
+		msg := &db.Message{ID: c.id, SourceType: "github", SourceID: c.id, Timestamp: start.Add(time.Duration(i) * time.Minute),
+			AuthorID: "user_github_octocat", ChannelID: "chan_github_acme_widgets", Content: c.content,
+			ThreadID: &root, ParentID: &root, IsThreadRoot: c.id == root}
+		if i == 0 {
+			msg.ParentID = nil
+		}
+		if err := recorder.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(io.Discard)
+	inferQuoteReplies(cmd, recorder)
+	summarizeThreads(cmd, database, recorder)
+
+	tests := []struct {
+		id     string
+		parent string
+	}{
+		{root + "_comment_1", root},
+		{root + "_comment_2", root + "_comment_1"},
+		{root + "_comment_3", root},
+	}
+	for _, tt := range tests {
+		msg, err := database.GetMessage(tt.id)
+		if err != nil || msg == nil {
+			t.Fatalf("GetMessage(%s) = %v, %v", tt.id, msg, err)
+		}
+		if msg.ParentID == nil || *msg.ParentID != tt.parent {
+			t.Errorf("%s: parent = %v, want %s", tt.id, msg.ParentID, tt.parent)
+		}
+	}
+
+	thread, err := database.GetThread(root)
+	if err != nil || thread == nil {
+		t.Fatalf("GetThread(%s) = %v, %v", root, thread, err)
+	}
+	if thread.MaxDepth != 2 {
+		t.Errorf("expected the inferred reply to deepen the thread to 2, got %d", thread.MaxDepth)
+	}
+}
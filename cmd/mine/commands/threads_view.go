@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+	"github.com/solvaholic/threadmine/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var threadsCmd = &cobra.Command{
+	Use:   "threads",
+	Short: "Print a whole conversation in reply order",
+	Long: `Reconstruct a thread from the reply graph of the stored messages and print
+it in order, each reply indented under the message it answers, so a Q&A
+exchange reads top to bottom without following parent IDs by hand.
+
+--thread prints a whole thread; --root prints one message and the replies
+under it. The table format heads the conversation with the thread's title.
+
+With --store fs, the thread is read from the reply graph fetch saved
+(~/.threadmine/graph) and the messages from the normalized storage. The db
+store saves no graph, so the thread's reply graph is rebuilt from the
+messages in the database.
+
+Examples:
+  # Read a Slack thread
+  mine threads --thread msg_slack_C123_1700000000.000100 --format table
+
+  # Just the discussion under one GitHub comment
+  mine threads --root msg_github_acme_widgets_42_comment_1001 --format table`,
+	RunE: runThreads,
+}
+
+var (
+	threadsThread string
+	threadsRoot   string
+)
+
+func init() {
+	rootCmd.AddCommand(threadsCmd)
+
+	threadsCmd.Flags().StringVar(&threadsThread, "thread", "", "Thread ID (the ID of its root message) to print")
+	threadsCmd.Flags().StringVar(&threadsRoot, "root", "", "Message ID to print with the replies under it")
+}
+
+func runThreads(cmd *cobra.Command, args []string) error {
+	switch outputFormat {
+	case "json", "jsonl", "ndjson", "table":
+	default:
+		return usageErrorf("unknown format: %s", outputFormat)
+	}
+	if (threadsThread == "") == (threadsRoot == "") {
+		return usageErrorf("specify one of --thread or --root")
+	}
+
+	// Open database
+	dbPathResolved := dbPath
+	if dbPathResolved == "" {
+		dbPathResolved = db.DefaultDBPath()
+	}
+
+	database, err := db.Open(dbPathResolved)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.Close()
+
+	st, err := openStore(database)
+	if err != nil {
+		return err
+	}
+
+	id := threadsThread
+	if threadsRoot != "" {
+		id = threadsRoot
+	}
+	var entries []threadEntry
+	var title string
+	if storeBackend == store.BackendFS {
+		entries, title, err = savedThreadEntries(database, id)
+	} else {
+		entries, title, err = storedThreadEntries(database, st, id)
+	}
+	if err != nil {
+		return err
+	}
+	if threadsRoot != "" {
+		entries = subthread(entries, threadsRoot)
+	}
+
+	switch outputFormat {
+	case "json":
+		return OutputJSON(entries)
+	case "jsonl", "ndjson":
+		return OutputJSONL(entries)
+	default:
+		out := cmd.OutOrStdout()
+		if title != "" {
+			fmt.Fprintf(out, "%s\n%s\n", title, strings.Repeat("=", len([]rune(title))))
+		}
+		for i, e := range entries {
+			if i > 0 || title != "" {
+				fmt.Fprintln(out)
+			}
+			indent := strings.Repeat("    ", e.Depth)
+			header := fmt.Sprintf("%s  %s", e.Author, e.Timestamp.Local().Format("2006-01-02 15:04"))
+			if len(e.Classifications) > 0 {
+				header += "  [" + strings.Join(e.Classifications, ", ") + "]"
+			}
+			fmt.Fprintf(out, "%s%s\n", indent, header)
+			for _, line := range strings.Split(strings.TrimRight(e.Content, "\n"), "\n") {
+				fmt.Fprintf(out, "%s  %s\n", indent, line)
+			}
+		}
+		return nil
+	}
+}
+
+// storedThreadEntries returns the entries (see threadEntries) and title of
+// the thread of message id, read from st
+func storedThreadEntries(database *db.DB, st store.Store, id string) ([]threadEntry, string, error) {
+	msg, err := st.LoadMessage(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if msg == nil {
+		return nil, "", fmt.Errorf("message not found: %s", id)
+	}
+	thread, err := loadThread(st, msg)
+	if err != nil {
+		return nil, "", err
+	}
+	return threadEntries(database, thread), threadTitle(database, msg, thread), nil
+}
+
+// savedThreadEntries returns the entries and title of the thread of message
+// id, ordered like threadEntries orders them, from the saved reply graph
+// (graph.LoadReplyGraph) and the normalized storage (normalize.LoadMessagesByIDs)
+func savedThreadEntries(database *db.DB, id string) ([]threadEntry, string, error) {
+	g, err := graph.LoadReplyGraph()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load reply graph: %w", err)
+	}
+	node := g.Nodes[id]
+	if node == nil {
+		return nil, "", fmt.Errorf("message not found: %s", id)
+	}
+
+	// Up to the thread's root, or the earliest message the graph has of it
+	rootID := id
+	for parent := g.Nodes[node.ParentID]; node.ParentID != "" && parent != nil; parent = g.Nodes[node.ParentID] {
+		rootID, node = parent.MessageID, parent
+	}
+
+	// The thread in reply order, then its messages in one batch
+	type placed struct {
+		node  *graph.MessageNode
+		depth int
+	}
+	var order []placed
+	var walk func(node *graph.MessageNode, depth int)
+	walk = func(node *graph.MessageNode, depth int) {
+		order = append(order, placed{node, depth})
+		var replies []*graph.MessageNode
+		for _, childID := range g.GetChildren(node.MessageID) {
+			if child := g.Nodes[childID]; child != nil {
+				replies = append(replies, child)
+			}
+		}
+		sort.SliceStable(replies, func(i, j int) bool { return replies[i].Timestamp.Before(replies[j].Timestamp) })
+		for _, reply := range replies {
+			walk(reply, depth+1)
+		}
+	}
+	walk(g.Nodes[rootID], 0)
+
+	ids := make([]string, len(order))
+	for i, p := range order {
+		ids[i] = p.node.MessageID
+	}
+	messages, err := normalize.LoadMessagesByIDs(ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := make(map[string]string)
+	entries := make([]threadEntry, 0, len(order))
+	for _, p := range order {
+		msg := messages[p.node.MessageID]
+		if msg == nil {
+			return nil, "", fmt.Errorf("message not found: %s", p.node.MessageID)
+		}
+		entries = append(entries, threadEntry{
+			MessageID:       msg.ID,
+			Depth:           p.depth,
+			AuthorID:        p.node.Author,
+			Author:          userName(database, names, p.node.Author),
+			Timestamp:       msg.Timestamp,
+			Content:         msg.Content,
+			ContentHTML:     msg.ContentHTML,
+			Classifications: p.node.Classifications,
+		})
+	}
+
+	threadID := g.Nodes[rootID].ThreadID
+	if threadID == "" {
+		threadID = rootID
+	}
+	return entries, summaryTitle(database, threadID, messages[rootID]), nil
+}
+
+// subthread returns the entry of rootID and the replies under it from
+// entries (as threadEntries orders them), with depths counted from rootID
+func subthread(entries []threadEntry, rootID string) []threadEntry {
+	for i, root := range entries {
+		if root.MessageID != rootID {
+			continue
+		}
+		end := i + 1
+		for end < len(entries) && entries[end].Depth > root.Depth {
+			end++
+		}
+		sub := append([]threadEntry(nil), entries[i:end]...)
+		for j := range sub {
+			sub[j].Depth -= root.Depth
+		}
+		return sub
+	}
+	return nil
+}
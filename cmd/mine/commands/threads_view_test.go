@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestThreads(t *testing.T) {
+	requireFTS5(t)
+	dbFile, rootID, answerID := saveTestThread(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string // Message IDs, one > per level of depth
+	}{
+		{"whole thread", []string{"--thread", rootID}, []string{rootID, ">" + answerID, ">>msg_slack_C1_3.0", ">msg_slack_C1_4.0"}},
+		{"thread of a reply", []string{"--thread", "msg_slack_C1_4.0"}, []string{rootID, ">" + answerID, ">>msg_slack_C1_3.0", ">msg_slack_C1_4.0"}},
+		{"under a reply", []string{"--root", answerID}, []string{answerID, ">msg_slack_C1_3.0"}},
+		{"a leaf", []string{"--root", "msg_slack_C1_4.0"}, []string{"msg_slack_C1_4.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, out := execute(t, append([]string{"--db", dbFile, "--format", "json", "threads"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("threads failed: %v", err)
+			}
+			var entries []threadEntry
+			if err := json.Unmarshal([]byte(out), &entries); err != nil {
+				t.Fatalf("invalid output %q: %v", out, err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, strings.Repeat(">", e.Depth)+e.MessageID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("threads %v = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("table", func(t *testing.T) {
+		err, out := execute(t, "--db", dbFile, "--format", "table", "threads", "--root", answerID)
+		if err != nil {
+			t.Fatalf("threads failed: %v", err)
+		}
+		for _, want := range []string{"Deploy key rotation fails\n=========================\n\nBob  ", "  You can run keys rotate --env prod\n", "\n    Alice  ", "\n      Thanks, that worked!\n"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("table output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	if err, _ := execute(t, "--db", dbFile, "threads", "--thread", "msg_missing"); err == nil || !strings.Contains(err.Error(), "message not found") {
+		t.Errorf("expected an error for a missing message, got %v", err)
+	}
+}
+
+func TestThreads_FSStore(t *testing.T) {
+	requireFTS5(t)
+	t.Setenv("HOME", t.TempDir())
+	dbFile, rootID, answerID := saveTestThread(t)
+
+	// The saved graph and normalized messages, not the database's, make up
+	// the thread
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_slack_U1"}
+	bob := &normalize.User{ID: "user_slack_U2"}
+	messages := []*normalize.NormalizedMessage{
+		{ID: rootID, Author: alice, IsThreadRoot: true, Timestamp: base, Content: "Deploy key rotation fails in staging"},
+		{ID: answerID, Author: bob, ParentID: rootID, Timestamp: base.Add(5 * time.Minute), Content: "Rotate it from the staging runner"},
+		{ID: "msg_slack_C1_5.0", Author: alice, ParentID: answerID, Timestamp: base.Add(8 * time.Minute), Content: "That fixed it"},
+		{ID: "msg_slack_C1_6.0", Author: bob, ParentID: rootID, Timestamp: base.Add(3 * time.Minute), Content: "Which environment?"},
+	}
+	for _, msg := range messages {
+		msg.SourceType, msg.ThreadID = "slack", rootID
+		if err := normalize.SaveNormalizedMessage(msg); err != nil {
+			t.Fatalf("SaveNormalizedMessage failed: %v", err)
+		}
+	}
+	g := graph.BuildFromClassifiedMessages(messages, map[string][]string{rootID: {"question"}})
+	if err := graph.SaveReplyGraph(g); err != nil {
+		t.Fatalf("SaveReplyGraph failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string // Message IDs, one > per level of depth
+	}{
+		{"whole thread", []string{"--thread", "msg_slack_C1_5.0"}, []string{rootID, ">msg_slack_C1_6.0", ">" + answerID, ">>msg_slack_C1_5.0"}},
+		{"under a reply", []string{"--root", answerID}, []string{answerID, ">msg_slack_C1_5.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, out := execute(t, append([]string{"--db", dbFile, "--store", "fs", "--format", "json", "threads"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("threads failed: %v", err)
+			}
+			var entries []threadEntry
+			if err := json.Unmarshal([]byte(out), &entries); err != nil {
+				t.Fatalf("invalid output %q: %v", out, err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, strings.Repeat(">", e.Depth)+e.MessageID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("threads %v = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	err, out := execute(t, "--db", dbFile, "--store", "fs", "--format", "table", "threads", "--thread", rootID)
+	if err != nil {
+		t.Fatalf("threads failed: %v", err)
+	}
+	for _, want := range []string{"Deploy key rotation fails\n=====", "Alice  2024-01-15 10:00  [question]\n  Deploy key rotation fails in staging\n", "\n          That fixed it\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err, _ := execute(t, "--db", dbFile, "--store", "fs", "threads", "--thread", "msg_missing"); err == nil || !strings.Contains(err.Error(), "message not found") {
+		t.Errorf("expected an error for a missing message, got %v", err)
+	}
+}
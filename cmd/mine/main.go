@@ -9,6 +9,6 @@ import (
 func main() {
 	if err := commands.Execute(); err != nil {
 		commands.OutputError("%v", err)
-		os.Exit(1)
+		os.Exit(commands.ExitCode(err))
 	}
 }
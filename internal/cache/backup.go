@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BackupManifestName is the archive entry holding a backup's manifest. It's
+// the first entry, and isn't restored into the home directory.
+const BackupManifestName = ".threadmine-backup.json"
+
+// ErrHomeNotEmpty is returned by Restore when the home directory already has
+// files and force wasn't set
+var ErrHomeNotEmpty = errors.New("home directory is not empty")
+
+// BackupManifest describes a backup of the ThreadMine home directory
+type BackupManifest struct {
+	CreatedAt               time.Time `json:"created_at"`
+	Home                    string    `json:"home"`                      // Directory that was backed up
+	DatabaseSchemaVersion   int       `json:"database_schema_version"`   // db.SchemaVersion of the mine that wrote it
+	NormalizedSchemaVersion string    `json:"normalized_schema_version"` // normalize.SchemaVersion of the mine that wrote it
+	Files                   int       `json:"files"`
+	Bytes                   int64     `json:"bytes"` // Total size of the files, uncompressed
+}
+
+// Backup writes a gzipped tar archive of every file under home to w, after
+// manifest with the file count and size filled in. Paths in exclude (such as
+// the archive itself) are left out, as are SQLite shared-memory files, which
+// SQLite rebuilds.
+func Backup(w io.Writer, home string, manifest BackupManifest, exclude ...string) (*BackupManifest, error) {
+	excluded := make(map[string]bool, len(exclude))
+	for _, p := range exclude {
+		if abs, err := filepath.Abs(p); err == nil {
+			excluded[abs] = true
+		}
+	}
+
+	// Collect the files first, so the manifest can lead the archive
+	var files []string
+	err := filepath.WalkDir(home, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if abs, err := filepath.Abs(p); err == nil && excluded[abs] {
+			return nil
+		}
+		if d.Type().IsRegular() && !strings.HasSuffix(p, "-shm") {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, p)
+			manifest.Bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", home, err)
+	}
+	manifest.Home = home
+	manifest.Files = len(files)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    BackupManifestName,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	for _, p := range files {
+		if err := addBackupFile(tw, home, p); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish backup: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// addBackupFile writes the file at p to tw, named by its path under home
+func addBackupFile(tw *tar.Writer, home, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", p, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", p, err)
+	}
+	rel, err := filepath.Rel(home, p)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", p, err)
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", p, err)
+	}
+	header.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", p, err)
+	}
+	// The file may have grown since it was stat'ed; the header's size wins
+	if _, err := io.CopyN(tw, f, header.Size); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", p, err)
+	}
+	return nil
+}
+
+// Restore extracts a backup written by Backup into home and returns its
+// manifest. A home directory with files in it is only replaced when force is
+// set. The archive is extracted next to home first, so a bad archive leaves
+// home as it was.
+func Restore(r io.Reader, home string, force bool) (*BackupManifest, error) {
+	entries, err := os.ReadDir(home)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", home, err)
+	}
+	if len(entries) > 0 && !force {
+		return nil, fmt.Errorf("%w: %s", ErrHomeNotEmpty, home)
+	}
+
+	parent := filepath.Dir(home)
+	if err := os.MkdirAll(parent, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+	staging, err := os.MkdirTemp(parent, filepath.Base(home)+".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := extractBackup(r, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(home); err != nil {
+		return nil, fmt.Errorf("failed to remove %s: %w", home, err)
+	}
+	if err := os.Rename(staging, home); err != nil {
+		return nil, fmt.Errorf("failed to move restored files to %s: %w", home, err)
+	}
+
+	return manifest, nil
+}
+
+// ReadBackupManifest returns the manifest of a backup written by Backup,
+// reading no further than the manifest
+func ReadBackupManifest(r io.Reader) (*BackupManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil || header.Name != BackupManifestName {
+		return nil, fmt.Errorf("failed to read backup: no %s, not a mine backup", BackupManifestName)
+	}
+	manifest := &BackupManifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// extractBackup extracts the files of a backup archive into dir and returns
+// its manifest
+func extractBackup(r io.Reader, dir string) (*BackupManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	var manifest *BackupManifest
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		if header.Name == BackupManifestName {
+			manifest = &BackupManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+			}
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Entries must stay inside dir
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("failed to read backup: unsafe path %q", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.FileMode(header.Mode).Perm())
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		_, err = io.Copy(f, tr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		os.Chtimes(target, header.ModTime, header.ModTime)
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("failed to read backup: no %s, not a mine backup", BackupManifestName)
+	}
+	return manifest, nil
+}
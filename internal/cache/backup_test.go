@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// readTree returns the contents of every file under dir by relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	home := filepath.Join(t.TempDir(), ".threadmine")
+	writeFiles(t, home, map[string]string{
+		"config":                       "[select]\nlimit = 10\n",
+		"threadmine.db":                "sqlite",
+		"threadmine.db-shm":            "shared memory",
+		"raw/github/repos/acme/x.json": `{"id": 1}`,
+		"normalized/slack/msg.json":    `{"id": "msg_1"}`,
+		"backups/old.tar.gz":           "archive",
+	})
+	want := readTree(t, home)
+	delete(want, "threadmine.db-shm")
+	delete(want, "backups/old.tar.gz")
+
+	var archive bytes.Buffer
+	created := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	manifest, err := Backup(&archive, home, BackupManifest{CreatedAt: created, DatabaseSchemaVersion: 2, NormalizedSchemaVersion: "1.0"},
+		filepath.Join(home, "backups", "old.tar.gz"))
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if manifest.Files != len(want) || manifest.Home != home {
+		t.Errorf("manifest = %+v, want %d files from %s", manifest, len(want), home)
+	}
+
+	read, err := ReadBackupManifest(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBackupManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(read, manifest) {
+		t.Errorf("ReadBackupManifest = %+v, want %+v", read, manifest)
+	}
+
+	t.Run("into a missing home", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), ".threadmine")
+		restored, err := Restore(bytes.NewReader(archive.Bytes()), target, false)
+		if err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if !restored.CreatedAt.Equal(created) || restored.DatabaseSchemaVersion != 2 {
+			t.Errorf("restored manifest = %+v", restored)
+		}
+		if got := readTree(t, target); !reflect.DeepEqual(got, want) {
+			t.Errorf("restored files = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("into a home with files", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), ".threadmine")
+		writeFiles(t, target, map[string]string{"config": "changed", "store/extra.json": "{}"})
+
+		if _, err := Restore(bytes.NewReader(archive.Bytes()), target, false); !errors.Is(err, ErrHomeNotEmpty) {
+			t.Fatalf("expected ErrHomeNotEmpty, got %v", err)
+		}
+		if got := readTree(t, target)["config"]; got != "changed" {
+			t.Errorf("refused restore changed config to %q", got)
+		}
+
+		if _, err := Restore(bytes.NewReader(archive.Bytes()), target, true); err != nil {
+			t.Fatalf("Restore with force failed: %v", err)
+		}
+		if got := readTree(t, target); !reflect.DeepEqual(got, want) {
+			t.Errorf("restored files = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRestore_BadArchive(t *testing.T) {
+	// A tar entry that would land outside the home directory
+	var unsafe bytes.Buffer
+	gz := gzip.NewWriter(&unsafe)
+	tw := tar.NewWriter(gz)
+	for _, entry := range []struct{ name, content string }{
+		{BackupManifestName, "{}"},
+		{"../escaped", "oops"},
+	} {
+		tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0600, Size: int64(len(entry.content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(entry.content))
+	}
+	tw.Close()
+	gz.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not gzip", []byte("not an archive")},
+		{"unsafe path", unsafe.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, ".threadmine")
+			writeFiles(t, target, map[string]string{"config": "kept"})
+
+			if _, err := Restore(bytes.NewReader(tt.data), target, true); err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := readTree(t, target)["config"]; got != "kept" {
+				t.Errorf("failed restore changed config to %q", got)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "escaped")); err == nil {
+				t.Error("entry was written outside the home directory")
+			}
+		})
+	}
+}
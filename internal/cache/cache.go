@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
 // CacheDir returns the root cache directory path
@@ -61,14 +63,14 @@ func SaveMessages(teamID, channelID string, messages []interface{}) error {
 	}
 
 	// Use today's date for the filename
-	date := time.Now().Format("2006-01-02")
+	date := clock.Now().Format("2006-01-02")
 	filePath := filepath.Join(msgDir, fmt.Sprintf("%s.json", date))
 
 	cache := MessageCache{
 		TeamID:    teamID,
 		ChannelID: channelID,
 		Date:      date,
-		FetchedAt: time.Now(),
+		FetchedAt: clock.Now(),
 		Messages:  messages,
 	}
 
@@ -135,7 +137,7 @@ func LoadMessages(teamID, channelID string, since time.Time) (*MessageCache, err
 
 	// Check if the cache file exists for the requested date
 	// For simplicity, check today's date first
-	date := time.Now().Format("2006-01-02")
+	date := clock.Now().Format("2006-01-02")
 	filePath := filepath.Join(msgDir, fmt.Sprintf("%s.json", date))
 
 	// Check if file exists
@@ -177,7 +179,7 @@ func SaveChannelsList(teamID string, channels interface{}) error {
 	filePath := filepath.Join(channelsDir, "_index.json")
 
 	indexData := map[string]interface{}{
-		"fetched_at": time.Now(),
+		"fetched_at": clock.Now(),
 		"channels":   channels,
 	}
 
@@ -255,7 +257,7 @@ func SaveWorkspaceUser(teamID string, userID, userName, teamName string) error {
 		UserName: userName,
 		TeamID:   teamID,
 		TeamName: teamName,
-		CachedAt: time.Now(),
+		CachedAt: clock.Now(),
 	}
 
 	data, err := json.MarshalIndent(user, "", "  ")
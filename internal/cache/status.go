@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cache lifetimes, matching how the Slack and GitHub clients reuse cached data
+const (
+	SlackMessagesTTL = 24 * time.Hour // Slack message caches are written per day
+	GitHubIndexTTL   = time.Hour      // GitHub issue and PR indexes are reused for an hour
+)
+
+// ContainerStatus describes how fresh the cache is for one Slack channel or GitHub repo
+type ContainerStatus struct {
+	Source     string    `json:"source"`              // slack or github
+	Workspace  string    `json:"workspace,omitempty"` // Slack team ID
+	Container  string    `json:"container"`           // Slack channel ID or GitHub owner-repo
+	FetchedAt  time.Time `json:"fetched_at"`          // Most recent fetch
+	AgeSeconds int64     `json:"age_seconds"`
+	TTLSeconds int64     `json:"ttl_seconds"`
+	Fresh      bool      `json:"fresh"` // FetchedAt is within the TTL
+	Messages   int       `json:"messages"`
+}
+
+// Status reports cache freshness for every cached Slack channel and GitHub repo,
+// relative to now. Containers are sorted by source, workspace, and name.
+func Status(now time.Time) ([]ContainerStatus, error) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []ContainerStatus{}
+
+	slack, err := slackStatus(filepath.Join(cacheDir, "raw", "slack", "workspaces"), now)
+	if err != nil {
+		return nil, err
+	}
+	statuses = append(statuses, slack...)
+
+	github, err := githubStatus(filepath.Join(cacheDir, "raw", "github", "repos"), now)
+	if err != nil {
+		return nil, err
+	}
+	statuses = append(statuses, github...)
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.Source != b.Source {
+			return a.Source > b.Source // slack before github
+		}
+		if a.Workspace != b.Workspace {
+			return a.Workspace < b.Workspace
+		}
+		return a.Container < b.Container
+	})
+
+	return statuses, nil
+}
+
+// slackStatus reads per-day message caches under workspaces/<team>/channels/<channel>/messages
+func slackStatus(workspacesDir string, now time.Time) ([]ContainerStatus, error) {
+	teams, err := subdirectories(workspacesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []ContainerStatus
+	for _, team := range teams {
+		channels, err := subdirectories(filepath.Join(workspacesDir, team, "channels"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, channel := range channels {
+			files, err := filepath.Glob(filepath.Join(workspacesDir, team, "channels", channel, "messages", "*.json"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list cache files: %w", err)
+			}
+			if len(files) == 0 {
+				continue
+			}
+
+			status := ContainerStatus{Source: "slack", Workspace: team, Container: channel}
+			for _, file := range files {
+				var cached MessageCache
+				if err := readCacheFile(file, &cached); err != nil {
+					return nil, err
+				}
+				status.Messages += len(cached.Messages)
+				if cached.FetchedAt.After(status.FetchedAt) {
+					status.FetchedAt = cached.FetchedAt
+				}
+			}
+			statuses = append(statuses, withAge(status, now, SlackMessagesTTL))
+		}
+	}
+
+	return statuses, nil
+}
+
+// githubStatus reads issue and pull request indexes under repos/<owner-repo>
+func githubStatus(reposDir string, now time.Time) ([]ContainerStatus, error) {
+	repos, err := subdirectories(reposDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []ContainerStatus
+	for _, repo := range repos {
+		indexes, err := filepath.Glob(filepath.Join(reposDir, repo, "issues", "_index*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cache files: %w", err)
+		}
+		indexes = append(indexes, filepath.Join(reposDir, repo, "pull_requests", "_index.json"))
+
+		status := ContainerStatus{Source: "github", Container: repo}
+		issues := make(map[int]bool)
+		prs := make(map[int]bool)
+		found := false
+		for _, index := range indexes {
+			var cached struct {
+				FetchedAt    time.Time              `json:"fetched_at"`
+				Issues       []struct{ Number int } `json:"issues"`
+				PullRequests []struct{ Number int } `json:"pull_requests"`
+			}
+			if err := readCacheFile(index, &cached); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			found = true
+
+			for _, issue := range cached.Issues {
+				issues[issue.Number] = true
+			}
+			for _, pr := range cached.PullRequests {
+				prs[pr.Number] = true
+			}
+			if cached.FetchedAt.After(status.FetchedAt) {
+				status.FetchedAt = cached.FetchedAt
+			}
+		}
+		if !found {
+			continue
+		}
+
+		status.Messages = len(issues) + len(prs)
+		statuses = append(statuses, withAge(status, now, GitHubIndexTTL))
+	}
+
+	return statuses, nil
+}
+
+// withAge fills in the age and freshness fields of status
+func withAge(status ContainerStatus, now time.Time, ttl time.Duration) ContainerStatus {
+	age := now.Sub(status.FetchedAt)
+	status.AgeSeconds = int64(age / time.Second)
+	status.TTLSeconds = int64(ttl / time.Second)
+	status.Fresh = age <= ttl
+	return status
+}
+
+// subdirectories returns the names of the directories in dir, or nil if dir doesn't exist
+func subdirectories(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// readCacheFile decodes a JSON cache file. Missing files return an os.IsNotExist error.
+func readCacheFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return err
+		}
+		return fmt.Errorf("failed to read cache file: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse cache file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	now := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	raw := filepath.Join(home, ".threadmine", "raw")
+	slackDir := filepath.Join(raw, "slack", "workspaces", "T1", "channels")
+	githubDir := filepath.Join(raw, "github", "repos")
+
+	// Fresh channel: two days cached, the latest fetched an hour ago
+	writeJSON(t, filepath.Join(slackDir, "C1", "messages", "2024-06-01.json"), MessageCache{
+		FetchedAt: now.Add(-30 * time.Hour),
+		Messages:  []interface{}{"a", "b"},
+	})
+	writeJSON(t, filepath.Join(slackDir, "C1", "messages", "2024-06-02.json"), MessageCache{
+		FetchedAt: now.Add(-time.Hour),
+		Messages:  []interface{}{"c"},
+	})
+	// Stale channel
+	writeJSON(t, filepath.Join(slackDir, "C2", "messages", "2024-05-01.json"), MessageCache{
+		FetchedAt: now.Add(-48 * time.Hour),
+		Messages:  []interface{}{"d"},
+	})
+	// Channel with no cached messages is skipped
+	if err := os.MkdirAll(filepath.Join(slackDir, "C3"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fresh repo: issue indexes overlap and are deduplicated by number
+	writeJSON(t, filepath.Join(githubDir, "octo-fresh", "issues", "_index.json"), map[string]interface{}{
+		"fetched_at": now.Add(-10 * time.Minute),
+		"issues":     []map[string]int{{"number": 1}, {"number": 2}},
+	})
+	writeJSON(t, filepath.Join(githubDir, "octo-fresh", "issues", "_index_creator-alice.json"), map[string]interface{}{
+		"fetched_at": now.Add(-2 * time.Hour),
+		"issues":     []map[string]int{{"number": 2}, {"number": 3}},
+	})
+	writeJSON(t, filepath.Join(githubDir, "octo-fresh", "pull_requests", "_index.json"), map[string]interface{}{
+		"fetched_at":    now.Add(-20 * time.Minute),
+		"pull_requests": []map[string]int{{"number": 4}},
+	})
+	// Stale repo with only pull requests
+	writeJSON(t, filepath.Join(githubDir, "octo-stale", "pull_requests", "_index.json"), map[string]interface{}{
+		"fetched_at":    now.Add(-3 * time.Hour),
+		"pull_requests": []map[string]int{{"number": 7}},
+	})
+
+	statuses, err := Status(now)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+
+	tests := []struct {
+		source    string
+		container string
+		age       time.Duration
+		fresh     bool
+		messages  int
+	}{
+		{"slack", "C1", time.Hour, true, 3},
+		{"slack", "C2", 48 * time.Hour, false, 1},
+		{"github", "octo-fresh", 10 * time.Minute, true, 4},
+		{"github", "octo-stale", 3 * time.Hour, false, 1},
+	}
+
+	if len(statuses) != len(tests) {
+		t.Fatalf("expected %d statuses, got %d: %+v", len(tests), len(statuses), statuses)
+	}
+
+	for i, tt := range tests {
+		got := statuses[i]
+		if got.Source != tt.source || got.Container != tt.container {
+			t.Errorf("status %d: expected %s/%s, got %s/%s", i, tt.source, tt.container, got.Source, got.Container)
+			continue
+		}
+		if got.AgeSeconds != int64(tt.age/time.Second) {
+			t.Errorf("%s: expected age %v, got %ds", tt.container, tt.age, got.AgeSeconds)
+		}
+		if got.Fresh != tt.fresh {
+			t.Errorf("%s: expected fresh=%v, got %v", tt.container, tt.fresh, got.Fresh)
+		}
+		if got.Messages != tt.messages {
+			t.Errorf("%s: expected %d messages, got %d", tt.container, tt.messages, got.Messages)
+		}
+	}
+
+	if statuses[0].Workspace != "T1" {
+		t.Errorf("expected workspace T1, got %q", statuses[0].Workspace)
+	}
+}
+
+func TestStatus_EmptyCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	statuses, err := Status(time.Now())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 0 {
+		t.Errorf("expected no statuses, got %d", len(statuses))
+	}
+}
@@ -0,0 +1,764 @@
+package classify
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// Classification represents a heuristic label applied to a message
+type Classification struct {
+	Type       string   `json:"type"`       // "question", "answer", "solution", "acknowledgment", "seen"
+	Confidence float64  `json:"confidence"` // 0.0 - 1.0
+	Signals    []string `json:"signals"`    // Signals that contributed to the classification
+}
+
+// ThreadContext describes where a message sits within its conversation
+type ThreadContext struct {
+	HasQuestion    bool   // Thread root (or an earlier message) was classified as a question
+	QuestionAuthor string // Author ID of that question, if HasQuestion
+	RootAuthor     string // Author ID of the thread root, such as the issue's
+	IsThreadRoot   bool   // Message is the root of its thread
+	Position       int    // Zero-based position of the message within the thread
+
+	// ParticipantCount is the number of distinct authors in the thread.
+	// Zero means the count is unknown and is not used to filter answers.
+	ParticipantCount int
+
+	// References are the IDs of corpus messages and channels outside the
+	// thread that the message links to (see ResolveReferences)
+	References []string
+}
+
+var (
+	thanksPattern       = regexp.MustCompile(`(?i)\b(thanks|thank you|thx|ty|tysm|cheers|much appreciated|appreciate it)\b`)
+	numberedStepPattern = regexp.MustCompile(`(?m)^\s*\d+[.)]\s+\S`)
+	questionMarkPattern = regexp.MustCompile(`\?`)
+	// emojiShortcodePattern matches Slack emoji shortcodes such as :thinking_face:
+	// and :+1:. Names start with a letter, so times like 10:30:45 aren't matched.
+	emojiShortcodePattern = regexp.MustCompile(`:(?:[a-z][a-z0-9_+'-]*|[+-]1):`)
+)
+
+// phraseText returns content as phrases are matched against: lowercased, with
+// emoji (unicode and :shortcodes:) removed and whitespace collapsed, so an
+// emoji between words ("how do i 🤔 fix this") doesn't break up a phrase.
+// It's only used for matching; the message content is left as it is.
+func phraseText(content string) string {
+	content = emojiShortcodePattern.ReplaceAllString(strings.ToLower(content), " ")
+	content = strings.Map(func(r rune) rune {
+		if isEmojiRune(r) {
+			return ' '
+		}
+		return r
+	}, content)
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// isEmojiRune reports whether r is an emoji or a part of one: a pictographic
+// symbol, a skin tone modifier, a variation selector, a keycap, or the joiner
+// between the emoji of a sequence
+func isEmojiRune(r rune) bool {
+	switch {
+	case r == '\u200d', r == '\ufe0e', r == '\ufe0f', r == '\u20e3':
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff:
+		return true
+	}
+	return unicode.Is(unicode.So, r)
+}
+
+// instructionPhrases suggest a message is telling someone how to do something
+var instructionPhrases = []string{
+	"try this", "try the", "try running", "try using", "you can", "you need to",
+	"you should", "here's how", "here is how", "the fix", "fix:", "solution",
+	"workaround", "make sure",
+}
+
+// successPhrases confirm that a suggestion worked
+var successPhrases = []string{
+	"that worked", "it worked", "worked perfectly", "works now", "working now",
+	"fixed it", "that fixed", "solved it", "that solved", "that did it",
+	"did the trick", "all good now",
+}
+
+// Emoji meanings recognized in EmojiMeanings
+const (
+	EmojiAcknowledgment = "acknowledgment" // Thanks or agreement
+	EmojiResolved       = "resolved"       // The problem is solved
+	EmojiCelebration    = "celebration"    // Something went well
+	EmojiSeen           = "seen"           // Read, but no reaction to the content
+)
+
+// EmojiMeanings maps emoji (unicode or Slack :shortcodes:) to what they convey.
+// Acknowledgment, resolved, and celebration emoji count toward acknowledgment;
+// seen emoji mark a message as seen. Override with SetEmojiMeaning.
+var EmojiMeanings = map[string]string{
+	"👍":              EmojiAcknowledgment,
+	"🙏":              EmojiAcknowledgment,
+	"🙌":              EmojiAcknowledgment,
+	":+1:":           EmojiAcknowledgment,
+	":thumbsup:":     EmojiAcknowledgment,
+	":pray:":         EmojiAcknowledgment,
+	":raised_hands:": EmojiAcknowledgment,
+
+	"✅":                  EmojiResolved,
+	"✔️":                 EmojiResolved,
+	":white_check_mark:": EmojiResolved,
+	":heavy_check_mark:": EmojiResolved,
+
+	"🎉":               EmojiCelebration,
+	"🥳":               EmojiCelebration,
+	":tada:":          EmojiCelebration,
+	":partying_face:": EmojiCelebration,
+
+	"👀":      EmojiSeen,
+	":eyes:": EmojiSeen,
+}
+
+// SetEmojiMeaning replaces the emoji that convey meaning with emoji
+func SetEmojiMeaning(meaning string, emoji []string) {
+	for e, m := range EmojiMeanings {
+		if m == meaning {
+			delete(EmojiMeanings, e)
+		}
+	}
+	for _, e := range emoji {
+		if e = strings.TrimSpace(e); e != "" {
+			EmojiMeanings[e] = meaning
+		}
+	}
+}
+
+// emojiMeaningsIn returns the set of emoji meanings present in content
+func emojiMeaningsIn(content string) map[string]bool {
+	meanings := make(map[string]bool)
+	for emoji, meaning := range EmojiMeanings {
+		if strings.Contains(content, strings.ToLower(emoji)) {
+			meanings[meaning] = true
+		}
+	}
+	return meanings
+}
+
+// docURLMarkers identify URLs that point at documentation
+var docURLMarkers = []string{
+	"docs.", "/docs", "/doc/", "documentation", "/wiki", "readme", "/guide", "/manual",
+}
+
+// MinContentLength is the minimum content length (in characters, ignoring
+// surrounding whitespace) a message needs before question, answer, and solution
+// heuristics are applied. Shorter messages can still be acknowledgments, so
+// reactions and explicit thanks are kept. Zero disables the check.
+var MinContentLength = 0
+
+// ClassifyMessage applies all heuristic classifiers to a message.
+// ctx may be nil, in which case thread-dependent classifications (answer) are skipped.
+func ClassifyMessage(msg *normalize.NormalizedMessage, ctx *ThreadContext) []Classification {
+	var classifications []Classification
+
+	if MinContentLength > 0 && utf8.RuneCountInString(strings.TrimSpace(msg.Content)) < MinContentLength {
+		return append(classifications, classifyReaction(withLanguage(msg), ctx)...)
+	}
+
+	// Detect the language once for the classifiers' English phrase lists
+	msg = withLanguage(msg)
+
+	if c := classifyQuestion(msg); c != nil {
+		classifications = append(classifications, *c)
+	}
+	if c := classifyAnswer(msg, ctx); c != nil {
+		classifications = append(classifications, *c)
+	}
+	if c := classifySolution(msg); c != nil {
+		classifications = append(classifications, *c)
+	}
+
+	return append(classifications, classifyReaction(msg, ctx)...)
+}
+
+// classifyReaction returns the acknowledgment classification, or seen when the
+// message reacts without acknowledging (e.g. a lone 👀)
+func classifyReaction(msg *normalize.NormalizedMessage, ctx *ThreadContext) []Classification {
+	if c := classifyAcknowledgment(msg, ctx); c != nil {
+		return []Classification{*c}
+	}
+	if c := classifySeen(msg); c != nil {
+		return []Classification{*c}
+	}
+	return nil
+}
+
+// ThreadContextWindow is the number of messages at the start of a thread,
+// root first, searched for the thread's question. The first question is
+// usually the one that matters, and a window bounds the cost of classifying
+// megathreads with thousands of messages. Zero searches the whole thread.
+var ThreadContextWindow = 0
+
+// BuildThreadContext builds the ThreadContext for msg within thread.
+// thread should be ordered by timestamp with the root message first.
+func BuildThreadContext(thread []*normalize.NormalizedMessage, msg *normalize.NormalizedMessage) *ThreadContext {
+	position := -1
+	for i, m := range thread {
+		if m.ID == msg.ID {
+			position = i
+			break
+		}
+	}
+	return scanThread(thread).context(msg, position)
+}
+
+// threadScan is what thread contexts take from the thread as a whole, so
+// classifying a thread scans it once rather than once per message
+type threadScan struct {
+	thread       []*normalize.NormalizedMessage
+	participants int
+	question     int // Position of the first question in the window, -1 if none
+}
+
+// scanThread counts the participants of thread and finds its first question
+// within ThreadContextWindow
+func scanThread(thread []*normalize.NormalizedMessage) threadScan {
+	scan := threadScan{thread: thread, question: -1}
+
+	participants := make(map[string]bool)
+	for i, m := range thread {
+		if m.Author != nil && m.Author.ID != "" {
+			participants[m.Author.ID] = true
+		}
+		if scan.question < 0 && (ThreadContextWindow <= 0 || i < ThreadContextWindow) && classifyQuestion(m) != nil {
+			scan.question = i
+		}
+	}
+	scan.participants = len(participants)
+
+	return scan
+}
+
+// context returns the ThreadContext of msg at position in the scanned thread,
+// or -1 if msg isn't in it
+func (scan threadScan) context(msg *normalize.NormalizedMessage, position int) *ThreadContext {
+	ctx := &ThreadContext{
+		IsThreadRoot:     msg.IsThreadRoot || position == 0,
+		Position:         max(position, 0),
+		ParticipantCount: scan.participants,
+	}
+
+	if len(scan.thread) > 0 && scan.thread[0].Author != nil {
+		ctx.RootAuthor = scan.thread[0].Author.ID
+	}
+
+	// A question at the root applies to the whole thread; a later one to the
+	// messages after it
+	if scan.question == 0 || (scan.question > 0 && scan.question < position) {
+		ctx.HasQuestion = true
+		if author := scan.thread[scan.question].Author; author != nil {
+			ctx.QuestionAuthor = author.ID
+		}
+	}
+
+	return ctx
+}
+
+// Unclassified is the type CountTypes reports for messages no classifier matched
+const Unclassified = "unclassified"
+
+// ClassifyThreads classifies messages within the threads they belong to and
+// returns message ID -> classification types. Messages are grouped by ThreadID
+// (a message without one is its own thread) and ordered root first, then by
+// timestamp, before classification. References resolve against messages.
+func ClassifyThreads(messages []*normalize.NormalizedMessage) map[string][]string {
+	return ClassifyThreadsWithMinConfidence(messages, 0)
+}
+
+// ClassifyThreadsWithMinConfidence is ClassifyThreads keeping only the
+// classifications with at least minConfidence
+func ClassifyThreadsWithMinConfidence(messages []*normalize.NormalizedMessage, minConfidence float64) map[string][]string {
+	result := make(map[string][]string)
+	classifyInThreads(messages, NewReferenceIndex(messages), func(msg *normalize.NormalizedMessage, _ *ThreadContext, classifications []Classification) {
+		for _, c := range FilterByConfidence(classifications, minConfidence) {
+			result[msg.ID] = append(result[msg.ID], c.Type)
+		}
+	})
+	return result
+}
+
+// FilterByConfidence returns the classifications with at least minConfidence
+func FilterByConfidence(classifications []Classification, minConfidence float64) []Classification {
+	kept := make([]Classification, 0, len(classifications))
+	for _, c := range classifications {
+		if c.Confidence >= minConfidence {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// Relation is a link between a message and another message or channel
+type Relation struct {
+	FromID     string
+	ToID       string
+	Type       string
+	Confidence float64
+}
+
+// ResolvesViaRelations classifies messages within their threads and returns a
+// RelationResolvesVia relation from each answer by reference to every message
+// or channel it points to, with the answer's confidence
+func ResolvesViaRelations(messages []*normalize.NormalizedMessage, resolver ReferenceResolver) []Relation {
+	var relations []Relation
+	classifyInThreads(messages, resolver, func(msg *normalize.NormalizedMessage, ctx *ThreadContext, classifications []Classification) {
+		for _, c := range classifications {
+			if c.Type != "answer" || !hasSignal(c, "answer_by_reference") {
+				continue
+			}
+			for _, id := range ctx.References {
+				relations = append(relations, Relation{FromID: msg.ID, ToID: id, Type: RelationResolvesVia, Confidence: c.Confidence})
+			}
+		}
+	})
+	return relations
+}
+
+// classifyInThreads groups messages into threads, classifies each message with
+// its thread context, and passes the results to fn
+func classifyInThreads(messages []*normalize.NormalizedMessage, resolver ReferenceResolver, fn func(*normalize.NormalizedMessage, *ThreadContext, []Classification)) {
+	threads := make(map[string][]*normalize.NormalizedMessage)
+	var order []string
+	for _, msg := range messages {
+		key := msg.ThreadID
+		if key == "" {
+			key = msg.ID
+		}
+		if _, exists := threads[key]; !exists {
+			order = append(order, key)
+		}
+		threads[key] = append(threads[key], msg)
+	}
+
+	for _, key := range order {
+		thread := threads[key]
+		sort.SliceStable(thread, func(i, j int) bool {
+			if thread[i].IsThreadRoot != thread[j].IsThreadRoot {
+				return thread[i].IsThreadRoot
+			}
+			return thread[i].Timestamp.Before(thread[j].Timestamp)
+		})
+
+		scan := scanThread(thread)
+		for i, msg := range thread {
+			ctx := scan.context(msg, i)
+			ctx.References = ResolveReferences(msg, resolver)
+			fn(msg, ctx, ClassifyMessage(msg, ctx))
+		}
+	}
+}
+
+// hasSignal reports whether a classification includes signal
+func hasSignal(c Classification, signal string) bool {
+	for _, s := range c.Signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// CountTypes classifies messages with ClassifyThreads and counts the messages
+// of each type. A message with several types counts once for each; messages
+// with none count as Unclassified.
+func CountTypes(messages []*normalize.NormalizedMessage) map[string]int {
+	return CountTypesWithMinConfidence(messages, 0)
+}
+
+// CountTypesWithMinConfidence is CountTypes counting only the classifications
+// with at least minConfidence. Messages with none left count as Unclassified.
+func CountTypesWithMinConfidence(messages []*normalize.NormalizedMessage, minConfidence float64) map[string]int {
+	types := ClassifyThreadsWithMinConfidence(messages, minConfidence)
+
+	counts := make(map[string]int)
+	for _, msg := range messages {
+		if len(types[msg.ID]) == 0 {
+			counts[Unclassified]++
+			continue
+		}
+		for _, t := range types[msg.ID] {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+// classifyQuestion detects messages asking for help or information
+func classifyQuestion(msg *normalize.NormalizedMessage) *Classification {
+	content := phraseText(msg.Content)
+	if content == "" {
+		return nil
+	}
+
+	var weights []float64
+	var signals []string
+
+	if questionMarkPattern.MatchString(content) {
+		weights = append(weights, 0.5)
+		signals = append(signals, "question_mark")
+	}
+
+	if usesEnglishPhrases(msg) {
+		for _, starter := range questionStarters {
+			if strings.HasPrefix(content, starter) {
+				weights = append(weights, 0.4)
+				signals = append(signals, "question_starter:"+starter)
+				break
+			}
+		}
+
+		for _, phrase := range helpPhrases {
+			if strings.Contains(content, phrase) {
+				weights = append(weights, 0.3)
+				signals = append(signals, "help_phrase:"+phrase)
+				break
+			}
+		}
+	}
+
+	if len(signals) == 0 {
+		return nil
+	}
+
+	return &Classification{
+		Type:       "question",
+		Confidence: aggregateConfidence(weights),
+		Signals:    signals,
+	}
+}
+
+// classifyAnswer detects replies that respond to a question earlier in the thread
+func classifyAnswer(msg *normalize.NormalizedMessage, ctx *ThreadContext) *Classification {
+	if ctx == nil || ctx.IsThreadRoot || !ctx.HasQuestion {
+		return nil
+	}
+
+	// A thread with a single author is a monologue, not Q&A
+	if ctx.ParticipantCount == 1 {
+		return nil
+	}
+
+	content := phraseText(msg.Content)
+	weights := []float64{0.4}
+	signals := []string{"reply_in_question_thread"}
+
+	if ctx.Position > 0 && ctx.Position <= 2 {
+		weights = append(weights, 0.1)
+		signals = append(signals, "early_reply")
+	}
+
+	if usesEnglishPhrases(msg) {
+		for _, phrase := range instructionPhrases {
+			if strings.Contains(content, phrase) {
+				weights = append(weights, 0.2)
+				signals = append(signals, "instruction_phrase:"+phrase)
+				break
+			}
+		}
+	}
+
+	if len(msg.CodeBlocks) > 0 {
+		weights = append(weights, 0.1)
+		signals = append(signals, "code_block")
+	}
+
+	// "Duplicate, see #123" answers by pointing at an existing thread
+	if len(ctx.References) > 0 {
+		weights = append(weights, 0.3)
+		signals = append(signals, "answer_by_reference")
+	}
+
+	// Follow-up questions are less likely to be answers
+	if questionMarkPattern.MatchString(content) {
+		weights = append(weights, -0.2)
+		signals = append(signals, "contains_question")
+	}
+
+	if ctx.ParticipantCount >= 2 {
+		signals = append(signals, "multiple_participants")
+	}
+
+	return &Classification{
+		Type:       "answer",
+		Confidence: aggregateConfidence(weights),
+		Signals:    signals,
+	}
+}
+
+// classifySolution detects messages that provide a fix, instructions, or references
+func classifySolution(msg *normalize.NormalizedMessage) *Classification {
+	content := phraseText(msg.Content)
+
+	var weights []float64
+	var signals []string
+
+	if len(msg.CodeBlocks) > 0 {
+		weights = append(weights, 0.4)
+		signals = append(signals, "code_block")
+	}
+
+	if usesEnglishPhrases(msg) {
+		for _, phrase := range instructionPhrases {
+			if strings.Contains(content, phrase) {
+				weights = append(weights, 0.3)
+				signals = append(signals, "instruction_phrase:"+phrase)
+				break
+			}
+		}
+	}
+
+	if len(numberedStepPattern.FindAllString(msg.Content, -1)) >= 2 {
+		weights = append(weights, 0.4)
+		signals = append(signals, "numbered_steps")
+	}
+
+	for _, url := range msg.URLs {
+		if isDocumentationURL(url) {
+			weights = append(weights, 0.3)
+			signals = append(signals, "documentation_link")
+			break
+		}
+	}
+
+	confidence := aggregateConfidence(weights)
+	if confidence < 0.25 {
+		return nil
+	}
+
+	return &Classification{
+		Type:       "solution",
+		Confidence: confidence,
+		Signals:    signals,
+	}
+}
+
+// classifyAcknowledgment detects thanks and confirmations that a suggestion
+// worked. ctx may be nil; with it, an acknowledgment from the thread's asker
+// or owner is more confident, and says so in its signals (see ResolvesThread).
+func classifyAcknowledgment(msg *normalize.NormalizedMessage, ctx *ThreadContext) *Classification {
+	content := phraseText(msg.Content)
+
+	var weights []float64
+	var signals []string
+
+	if usesEnglishPhrases(msg) {
+		if thanksPattern.MatchString(content) {
+			weights = append(weights, 0.4)
+			signals = append(signals, "thanks")
+		}
+
+		for _, phrase := range successPhrases {
+			if strings.Contains(content, phrase) {
+				weights = append(weights, 0.4)
+				signals = append(signals, "success_confirmation:"+phrase)
+				break
+			}
+		}
+	}
+
+	meanings := emojiMeaningsIn(strings.ToLower(msg.Content))
+	if meanings[EmojiResolved] {
+		weights = append(weights, 0.4)
+		signals = append(signals, "resolved_emoji")
+	}
+	if meanings[EmojiAcknowledgment] {
+		weights = append(weights, 0.3)
+		signals = append(signals, "positive_emoji")
+	}
+	if meanings[EmojiCelebration] {
+		weights = append(weights, 0.3)
+		signals = append(signals, "celebration_emoji")
+	}
+
+	if len(signals) == 0 {
+		return nil
+	}
+
+	if by := acknowledgerSignals(msg, ctx); len(by) > 0 {
+		weights = append(weights, 0.3)
+		signals = append(signals, by...)
+	}
+
+	return &Classification{
+		Type:       "acknowledgment",
+		Confidence: aggregateConfidence(weights),
+		Signals:    signals,
+	}
+}
+
+// Who an acknowledgment comes from, as AcknowledgmentResolvers names them
+const (
+	AcknowledgerAsker  = "asker"  // The author of the thread's question (signal by_asker)
+	AcknowledgerOwner  = "owner"  // The author of the thread root, such as the issue's (signal by_owner)
+	AcknowledgerAnyone = "anyone" // Any author, bystanders included
+)
+
+// AcknowledgmentResolvers are who an acknowledgment must come from to resolve
+// its thread (classify.acknowledgment_resolvers). A bystander's "thanks, this
+// helped me too" doesn't mean someone else's question was answered.
+var AcknowledgmentResolvers = []string{AcknowledgerAsker, AcknowledgerOwner}
+
+// SetAcknowledgmentResolvers sets AcknowledgmentResolvers, rejecting unknown authors
+func SetAcknowledgmentResolvers(resolvers []string) error {
+	var set []string
+	for _, r := range resolvers {
+		switch r = strings.TrimSpace(r); r {
+		case "":
+		case AcknowledgerAsker, AcknowledgerOwner, AcknowledgerAnyone:
+			set = append(set, r)
+		default:
+			return fmt.Errorf("unknown acknowledgment resolver %q (expected %s, %s, or %s)", r, AcknowledgerAsker, AcknowledgerOwner, AcknowledgerAnyone)
+		}
+	}
+	AcknowledgmentResolvers = set
+	return nil
+}
+
+// acknowledgerSignals returns the by_asker and by_owner signals of an
+// acknowledgment by the author of the thread's question or root. A thread
+// root can't acknowledge its own thread.
+func acknowledgerSignals(msg *normalize.NormalizedMessage, ctx *ThreadContext) []string {
+	if ctx == nil || ctx.IsThreadRoot || msg.Author == nil || msg.Author.ID == "" {
+		return nil
+	}
+	var signals []string
+	if ctx.HasQuestion && msg.Author.ID == ctx.QuestionAuthor {
+		signals = append(signals, "by_asker")
+	}
+	if msg.Author.ID == ctx.RootAuthor {
+		signals = append(signals, "by_owner")
+	}
+	return signals
+}
+
+// ResolvesThread reports whether c is an acknowledgment from one of the
+// AcknowledgmentResolvers, which resolves its thread
+func ResolvesThread(c Classification) bool {
+	if c.Type != "acknowledgment" {
+		return false
+	}
+	for _, r := range AcknowledgmentResolvers {
+		switch {
+		case r == AcknowledgerAnyone,
+			r == AcknowledgerAsker && hasSignal(c, "by_asker"),
+			r == AcknowledgerOwner && hasSignal(c, "by_owner"):
+			return true
+		}
+	}
+	return false
+}
+
+// classifySeen detects messages whose only reaction is a "seen" emoji such as 👀
+func classifySeen(msg *normalize.NormalizedMessage) *Classification {
+	content := strings.ToLower(strings.TrimSpace(msg.Content))
+	if !emojiMeaningsIn(content)[EmojiSeen] {
+		return nil
+	}
+
+	weights := []float64{0.5}
+	signals := []string{"seen_emoji"}
+
+	// Nothing but seen emoji (and whitespace)
+	remaining := content
+	for emoji, meaning := range EmojiMeanings {
+		if meaning == EmojiSeen {
+			remaining = strings.ReplaceAll(remaining, strings.ToLower(emoji), "")
+		}
+	}
+	if strings.TrimSpace(remaining) == "" {
+		weights = append(weights, 0.3)
+		signals = append(signals, "emoji_only")
+	}
+
+	return &Classification{
+		Type:       "seen",
+		Confidence: aggregateConfidence(weights),
+		Signals:    signals,
+	}
+}
+
+// isDocumentationURL checks whether a URL looks like it points at documentation
+func isDocumentationURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, marker := range docURLMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ways to combine the weights of a classification's signals into its confidence
+const (
+	AggregateSum           = "sum"           // Add the weights, capped at 1
+	AggregateMax           = "max"           // The strongest signal alone
+	AggregateProbabilistic = "probabilistic" // 1-∏(1-w), treating signals as independent evidence
+)
+
+// Aggregation is how signal weights combine (classify.aggregation). With sum,
+// several weak signals can reach the confidence of one definitive signal; max
+// and probabilistic keep weak signals from adding up as quickly.
+var Aggregation = AggregateSum
+
+// SetAggregation sets Aggregation, rejecting unknown modes
+func SetAggregation(mode string) error {
+	switch mode {
+	case AggregateSum, AggregateMax, AggregateProbabilistic:
+		Aggregation = mode
+		return nil
+	default:
+		return fmt.Errorf("unknown aggregation mode %q (expected %s, %s, or %s)", mode, AggregateSum, AggregateMax, AggregateProbabilistic)
+	}
+}
+
+// aggregateConfidence combines signal weights into a confidence score with
+// Aggregation. Negative weights are penalties: with max and probabilistic
+// they are subtracted after the positive weights are combined.
+func aggregateConfidence(weights []float64) float64 {
+	if Aggregation == AggregateSum {
+		var sum float64
+		for _, w := range weights {
+			sum += w
+		}
+		return capConfidence(sum)
+	}
+
+	var combined, penalty float64
+	remaining := 1.0
+	for _, w := range weights {
+		switch {
+		case w < 0:
+			penalty += w
+		case Aggregation == AggregateMax:
+			combined = math.Max(combined, w)
+		default:
+			remaining *= 1 - capConfidence(w)
+		}
+	}
+	if Aggregation == AggregateProbabilistic {
+		combined = 1 - remaining
+	}
+	return capConfidence(combined + penalty)
+}
+
+// capConfidence clamps a confidence score to the range [0, 1]
+func capConfidence(confidence float64) float64 {
+	if confidence > 1.0 {
+		return 1.0
+	}
+	if confidence < 0 {
+		return 0
+	}
+	return confidence
+}
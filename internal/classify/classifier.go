@@ -17,47 +17,69 @@ type Enrichment struct {
 	HasCode    bool   `json:"has_code"`
 	HasLinks   bool   `json:"has_links"`
 	HasQuotes  bool   `json:"has_quotes"`
+	IsCodeOnly bool   `json:"is_code_only"` // Nothing but code (see normalize.IsCodeOnly)
+	IsLinkOnly bool   `json:"is_link_only"` // Nothing but links (see normalize.IsLinkOnly)
+	Language   string `json:"language"`     // ISO 639-1 code, "" if undetermined
 }
 
-// EnrichMessage analyzes a message and returns basic enrichment metadata
+// EnrichMessage analyzes a message and returns basic enrichment metadata.
+// Pastes of code or links aren't questions, whatever they contain.
 func EnrichMessage(msg *normalize.NormalizedMessage) *Enrichment {
+	msg = withLanguage(msg)
+	codeOnly, linkOnly := normalize.IsCodeOnly(msg.Content), normalize.IsLinkOnly(msg.Content)
 	return &Enrichment{
 		MessageID:  msg.ID,
-		IsQuestion: detectQuestion(msg),
+		IsQuestion: !codeOnly && !linkOnly && detectQuestion(msg),
 		CharCount:  len(msg.Content),
 		WordCount:  countWords(msg.Content),
 		HasCode:    len(msg.CodeBlocks) > 0,
 		HasLinks:   len(msg.URLs) > 0,
 		HasQuotes:  detectQuotes(msg.Content),
+		IsCodeOnly: codeOnly,
+		IsLinkOnly: linkOnly,
+		Language:   msg.Language,
 	}
 }
 
+// questionStarters are phrases that mark a message as a question when it begins with them
+var questionStarters = []string{
+	"how do i", "how can i", "how to", "how would",
+	"what is", "what's", "what are", "what if",
+	"where is", "where can", "where do",
+	"when should", "when do", "when is",
+	"why does", "why is", "why would",
+	"who can", "who is", "who knows",
+	"can someone", "can anyone", "could someone",
+	"is there", "are there",
+	"does anyone", "does someone",
+	"has anyone", "has someone",
+	"should i", "would it",
+	"any ideas", "anyone know",
+}
+
+// helpPhrases are help-seeking phrases that suggest a question anywhere in a message
+var helpPhrases = []string{
+	"help me", "stuck on", "stuck trying", "having trouble", "problem with",
+	"error with", "not working", "doesn't work", "can't get",
+	"unable to", "trying to figure", "need help",
+}
+
 // detectQuestion checks if a message looks like a question
 // Uses existing patterns: question marks, question words, help-seeking phrases
 func detectQuestion(msg *normalize.NormalizedMessage) bool {
-	content := strings.ToLower(msg.Content)
+	content := phraseText(msg.Content)
 
 	// Strong signal: Contains question mark
 	if strings.Contains(content, "?") {
 		return true
 	}
 
-	// Question words at start
-	questionStarters := []string{
-		"how do i", "how can i", "how to", "how would",
-		"what is", "what's", "what are", "what if",
-		"where is", "where can", "where do",
-		"when should", "when do", "when is",
-		"why does", "why is", "why would",
-		"who can", "who is", "who knows",
-		"can someone", "can anyone", "could someone",
-		"is there", "are there",
-		"does anyone", "does someone",
-		"has anyone", "has someone",
-		"should i", "would it",
-		"any ideas", "anyone know",
+	// The phrases below are English
+	if !usesEnglishPhrases(msg) {
+		return false
 	}
 
+	// Question words at start
 	for _, starter := range questionStarters {
 		if strings.HasPrefix(content, starter) {
 			return true
@@ -66,12 +88,6 @@ func detectQuestion(msg *normalize.NormalizedMessage) bool {
 
 	// Help-seeking phrases (require both the phrase and reasonable message length)
 	if len(msg.Content) > 20 {
-		helpPhrases := []string{
-			"help me", "stuck on", "having trouble", "problem with",
-			"error with", "not working", "doesn't work", "can't get",
-			"unable to", "trying to figure", "need help",
-		}
-
 		for _, phrase := range helpPhrases {
 			if strings.Contains(content, phrase) {
 				return true
@@ -1,7 +1,12 @@
 package classify
 
 import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/solvaholic/threadmine/internal/normalize"
 )
@@ -184,7 +189,7 @@ func TestClassifyAcknowledgment(t *testing.T) {
 				Content: tt.content,
 			}
 
-			result := classifyAcknowledgment(msg)
+			result := classifyAcknowledgment(msg, nil)
 
 			if tt.expectAcknowledgment && result == nil {
 				t.Errorf("expected acknowledgment classification, got nil")
@@ -208,6 +213,96 @@ func TestClassifyAcknowledgment(t *testing.T) {
 	}
 }
 
+func TestClassifyAcknowledgment_Author(t *testing.T) {
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+	carol := &normalize.User{ID: "user_carol"}
+
+	question := &normalize.NormalizedMessage{ID: "q", Author: alice, IsThreadRoot: true, Content: "How do I rotate the deploy key?"}
+	answer := &normalize.NormalizedMessage{ID: "a", Author: bob, Content: "You can run keys rotate --env prod"}
+
+	tests := []struct {
+		name        string
+		author      *normalize.User
+		thread      []*normalize.NormalizedMessage
+		wantSignals []string // Author signals
+		resolves    bool
+	}{
+		{"asker", alice, []*normalize.NormalizedMessage{question, answer}, []string{"by_asker", "by_owner"}, true},
+		{"bystander", carol, []*normalize.NormalizedMessage{question, answer}, nil, false},
+		{"answerer", bob, []*normalize.NormalizedMessage{question, answer}, nil, false},
+		{"owner without a question", alice, []*normalize.NormalizedMessage{
+			{ID: "q", Author: alice, IsThreadRoot: true, Content: "Deploy key rotation is failing"}, answer,
+		}, []string{"by_owner"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ack := &normalize.NormalizedMessage{ID: "ack", Author: tt.author, Content: "Thanks, that worked!"}
+			thread := append(append([]*normalize.NormalizedMessage{}, tt.thread...), ack)
+
+			base := classifyAcknowledgment(ack, nil)
+			c := classifyAcknowledgment(ack, BuildThreadContext(thread, ack))
+			if base == nil || c == nil {
+				t.Fatalf("expected acknowledgments, got %v and %v", base, c)
+			}
+
+			var got []string
+			for _, s := range c.Signals {
+				if strings.HasPrefix(s, "by_") {
+					got = append(got, s)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantSignals, ",") {
+				t.Errorf("author signals = %v, want %v", got, tt.wantSignals)
+			}
+			if boosted := c.Confidence > base.Confidence; boosted != (len(tt.wantSignals) > 0) {
+				t.Errorf("confidence %.2f, %.2f without context", c.Confidence, base.Confidence)
+			}
+			if ResolvesThread(*c) != tt.resolves {
+				t.Errorf("ResolvesThread() = %v, want %v", !tt.resolves, tt.resolves)
+			}
+		})
+	}
+
+	t.Run("thread root", func(t *testing.T) {
+		root := &normalize.NormalizedMessage{ID: "r", Author: alice, IsThreadRoot: true, Content: "Thanks in advance, how do I rotate keys?"}
+		c := classifyAcknowledgment(root, BuildThreadContext([]*normalize.NormalizedMessage{root}, root))
+		if c == nil || ResolvesThread(*c) {
+			t.Errorf("root acknowledgment = %v, want one that doesn't resolve", c)
+		}
+	})
+}
+
+func TestSetAcknowledgmentResolvers(t *testing.T) {
+	saved := AcknowledgmentResolvers
+	t.Cleanup(func() { AcknowledgmentResolvers = saved })
+
+	bystander := Classification{Type: "acknowledgment", Signals: []string{"thanks"}}
+	asker := Classification{Type: "acknowledgment", Signals: []string{"thanks", "by_asker"}}
+
+	if err := SetAcknowledgmentResolvers([]string{"anyone"}); err != nil {
+		t.Fatalf("SetAcknowledgmentResolvers failed: %v", err)
+	}
+	if !ResolvesThread(bystander) {
+		t.Errorf("with anyone, a bystander's acknowledgment should resolve")
+	}
+
+	if err := SetAcknowledgmentResolvers([]string{" owner ", ""}); err != nil {
+		t.Fatalf("SetAcknowledgmentResolvers failed: %v", err)
+	}
+	if ResolvesThread(asker) {
+		t.Errorf("with owner only, the asker's acknowledgment shouldn't resolve")
+	}
+
+	if err := SetAcknowledgmentResolvers([]string{"bystander"}); err == nil {
+		t.Errorf("expected an error for an unknown resolver")
+	}
+	if len(AcknowledgmentResolvers) != 1 || AcknowledgmentResolvers[0] != AcknowledgerOwner {
+		t.Errorf("a rejected value changed AcknowledgmentResolvers to %v", AcknowledgmentResolvers)
+	}
+}
+
 func TestClassifyAnswer(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -316,3 +411,616 @@ func TestClassifyMessage_Multiple(t *testing.T) {
 		t.Errorf("expected solution classification")
 	}
 }
+
+func TestClassifyAnswer_ParticipantCount(t *testing.T) {
+	tests := []struct {
+		name             string
+		participantCount int
+		expectAnswer     bool
+	}{
+		{
+			name:             "single participant self-thread",
+			participantCount: 1,
+			expectAnswer:     false,
+		},
+		{
+			name:             "two participants",
+			participantCount: 2,
+			expectAnswer:     true,
+		},
+		{
+			name:             "many participants",
+			participantCount: 5,
+			expectAnswer:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &normalize.NormalizedMessage{
+				Content: "You can fix this by updating your config file",
+			}
+			ctx := &ThreadContext{
+				HasQuestion:      true,
+				IsThreadRoot:     false,
+				Position:         1,
+				ParticipantCount: tt.participantCount,
+			}
+
+			result := classifyAnswer(msg, ctx)
+
+			if tt.expectAnswer && result == nil {
+				t.Errorf("expected answer classification, got nil")
+			}
+			if !tt.expectAnswer && result != nil {
+				t.Errorf("expected no classification, got %v", result)
+			}
+		})
+	}
+}
+
+func TestBuildThreadContext(t *testing.T) {
+	alice := &normalize.User{ID: "user_slack_alice"}
+	bob := &normalize.User{ID: "user_slack_bob"}
+
+	root := &normalize.NormalizedMessage{ID: "msg_1", Author: alice, Content: "How do I configure this?", IsThreadRoot: true}
+	selfReply := &normalize.NormalizedMessage{ID: "msg_2", Author: alice, Content: "You can fix this by updating your config file"}
+	otherReply := &normalize.NormalizedMessage{ID: "msg_3", Author: bob, Content: "You can fix this by updating your config file"}
+
+	t.Run("single participant", func(t *testing.T) {
+		thread := []*normalize.NormalizedMessage{root, selfReply}
+		ctx := BuildThreadContext(thread, selfReply)
+
+		if ctx.ParticipantCount != 1 {
+			t.Errorf("expected 1 participant, got %d", ctx.ParticipantCount)
+		}
+		if !ctx.HasQuestion {
+			t.Errorf("expected HasQuestion to be true")
+		}
+		if ctx.Position != 1 {
+			t.Errorf("expected position 1, got %d", ctx.Position)
+		}
+		if c := classifyAnswer(selfReply, ctx); c != nil {
+			t.Errorf("expected no answer in self-thread, got %v", c)
+		}
+	})
+
+	t.Run("multiple participants", func(t *testing.T) {
+		thread := []*normalize.NormalizedMessage{root, selfReply, otherReply}
+		ctx := BuildThreadContext(thread, otherReply)
+
+		if ctx.ParticipantCount != 2 {
+			t.Errorf("expected 2 participants, got %d", ctx.ParticipantCount)
+		}
+		if ctx.Position != 2 {
+			t.Errorf("expected position 2, got %d", ctx.Position)
+		}
+		if c := classifyAnswer(otherReply, ctx); c == nil {
+			t.Errorf("expected answer classification, got nil")
+		}
+	})
+
+	t.Run("thread root", func(t *testing.T) {
+		thread := []*normalize.NormalizedMessage{root, otherReply}
+		ctx := BuildThreadContext(thread, root)
+
+		if !ctx.IsThreadRoot {
+			t.Errorf("expected IsThreadRoot to be true")
+		}
+	})
+}
+
+func TestClassifyMessage_MinContentLength(t *testing.T) {
+	original := MinContentLength
+	defer func() { MinContentLength = original }()
+
+	ctx := &ThreadContext{
+		HasQuestion:  true,
+		IsThreadRoot: false,
+		Position:     1,
+	}
+
+	tests := []struct {
+		name      string
+		minLength int
+		content   string
+		expected  []string
+	}{
+		{
+			name:      "disabled by default",
+			minLength: 0,
+			content:   "ok?",
+			expected:  []string{"question", "answer"},
+		},
+		{
+			name:      "short question skipped",
+			minLength: 5,
+			content:   "ok?",
+			expected:  nil,
+		},
+		{
+			name:      "short filler skipped",
+			minLength: 5,
+			content:   "hm",
+			expected:  nil,
+		},
+		{
+			name:      "short thanks still acknowledged",
+			minLength: 5,
+			content:   "ty",
+			expected:  []string{"acknowledgment"},
+		},
+		{
+			name:      "reaction still acknowledged",
+			minLength: 5,
+			content:   "👍",
+			expected:  []string{"acknowledgment"},
+		},
+		{
+			name:      "long enough message classified normally",
+			minLength: 5,
+			content:   "How do I configure this?",
+			expected:  []string{"question", "answer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			MinContentLength = tt.minLength
+			msg := &normalize.NormalizedMessage{Content: tt.content}
+
+			classifications := ClassifyMessage(msg, ctx)
+
+			var types []string
+			for _, c := range classifications {
+				types = append(types, c.Type)
+			}
+			if len(types) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, types)
+			}
+			for i := range types {
+				if types[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, types)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyMessage_EmojiMeanings(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantType    string
+		wantSignals []string
+	}{
+		{"eyes only is seen", "👀", "seen", []string{"seen_emoji", "emoji_only"}},
+		{"eyes shortcode is seen", ":eyes:", "seen", []string{"seen_emoji", "emoji_only"}},
+		{"eyes with text is seen", "👀 looking into it", "seen", []string{"seen_emoji"}},
+		{"check mark is resolved", "✅", "acknowledgment", []string{"resolved_emoji"}},
+		{"check mark shortcode is resolved", ":white_check_mark:", "acknowledgment", []string{"resolved_emoji"}},
+		{"tada is celebration", "🎉", "acknowledgment", []string{"celebration_emoji"}},
+		{"thumbs up is acknowledgment", ":+1:", "acknowledgment", []string{"positive_emoji"}},
+		{"thanks with eyes is acknowledgment", "👀 thanks!", "acknowledgment", []string{"thanks"}},
+		{"resolved and celebration", "✅ 🎉", "acknowledgment", []string{"resolved_emoji", "celebration_emoji"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &normalize.NormalizedMessage{Content: tt.content}
+			classifications := ClassifyMessage(msg, nil)
+
+			if len(classifications) != 1 {
+				t.Fatalf("expected 1 classification, got %+v", classifications)
+			}
+			c := classifications[0]
+			if c.Type != tt.wantType {
+				t.Errorf("expected type %s, got %s", tt.wantType, c.Type)
+			}
+			if strings.Join(c.Signals, ",") != strings.Join(tt.wantSignals, ",") {
+				t.Errorf("expected signals %v, got %v", tt.wantSignals, c.Signals)
+			}
+		})
+	}
+}
+
+func TestClassifyMessage_EmojiBetweenWords(t *testing.T) {
+	// Emoji used to break up the phrases around them
+	tests := []struct {
+		content    string
+		wantType   string
+		wantSignal string
+	}{
+		{"🤔 how do i fix this", "question", "question_starter:how do i"},
+		{":thinking_face: what's the process for deploying", "question", "question_starter:what's"},
+		{"that 🙌 worked", "acknowledgment", "success_confirmation:that worked"},
+		{"👍🏽 works now", "acknowledgment", "success_confirmation:works now"},
+		{"you 👉 need to restart the pod", "solution", "instruction_phrase:you need to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			msg := &normalize.NormalizedMessage{Content: tt.content}
+			classifications := ClassifyMessage(msg, nil)
+
+			var found *Classification
+			for i := range classifications {
+				if classifications[i].Type == tt.wantType {
+					found = &classifications[i]
+				}
+			}
+			if found == nil {
+				t.Fatalf("expected %s, got %+v", tt.wantType, classifications)
+			}
+			if !hasSignal(*found, tt.wantSignal) {
+				t.Errorf("expected signal %s, got %v", tt.wantSignal, found.Signals)
+			}
+			if msg.Content != tt.content {
+				t.Errorf("content changed to %q", msg.Content)
+			}
+		})
+	}
+}
+
+func TestPhraseText(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"How do I 🤔 fix this?", "how do i fix this?"},
+		{"Thanks 🙏🏽, that :tada: did it", "thanks , that did it"},
+		{"👨\u200d💻 works 1️⃣", "works 1"},
+		{"no emoji\nhere", "no emoji here"},
+		{"Deployed at 10:30:45 :+1:", "deployed at 10:30:45"},
+		{"Ratio is 1:2:3, see :eyes:", "ratio is 1:2:3, see"},
+	}
+
+	for _, tt := range tests {
+		if got := phraseText(tt.content); got != tt.want {
+			t.Errorf("phraseText(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestSetEmojiMeaning(t *testing.T) {
+	saved := make(map[string]string, len(EmojiMeanings))
+	for k, v := range EmojiMeanings {
+		saved[k] = v
+	}
+	defer func() { EmojiMeanings = saved }()
+
+	// Treat 🙏 as seen instead of acknowledgment; 👀 is no longer seen
+	SetEmojiMeaning(EmojiSeen, []string{"🙏", " :mag: "})
+
+	tests := []struct {
+		content  string
+		wantType string
+	}{
+		{"🙏", "seen"},
+		{":mag:", "seen"},
+		{"👍", "acknowledgment"},
+	}
+	for _, tt := range tests {
+		classifications := ClassifyMessage(&normalize.NormalizedMessage{Content: tt.content}, nil)
+		if len(classifications) != 1 || classifications[0].Type != tt.wantType {
+			t.Errorf("%s: expected %s, got %+v", tt.content, tt.wantType, classifications)
+		}
+	}
+
+	if got := ClassifyMessage(&normalize.NormalizedMessage{Content: "👀"}, nil); len(got) != 0 {
+		t.Errorf("expected 👀 to have no meaning after override, got %+v", got)
+	}
+}
+
+func TestClassifyMessage_NormalizedSmartQuotes(t *testing.T) {
+	// Slack sends typographic apostrophes and the occasional zero-width space;
+	// after normalization they must not hide the "what's" question starter
+	raw := &normalize.SlackMessage{
+		Type:      "message",
+		User:      "U123",
+		Text:      "\u200BWhat\u2019s the process for deploying to staging",
+		Timestamp: "1234567890.123456",
+	}
+	channel := &normalize.SlackChannel{ID: "C123", Name: "general", IsChannel: true}
+	msg, err := normalize.SlackToNormalized(raw, channel, nil, "T123", time.Now())
+	if err != nil {
+		t.Fatalf("failed to normalize message: %v", err)
+	}
+
+	classifications := ClassifyMessage(msg, nil)
+	if len(classifications) == 0 || classifications[0].Type != "question" {
+		t.Fatalf("expected question, got %+v", classifications)
+	}
+	if !strings.Contains(strings.Join(classifications[0].Signals, ","), "question_starter:what's") {
+		t.Errorf("expected question_starter:what's signal, got %v", classifications[0].Signals)
+	}
+}
+
+func TestClassifyThreads(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+
+	// Out of order on purpose: the reply comes before its root
+	messages := []*normalize.NormalizedMessage{
+		{ID: "reply", ThreadID: "root", Author: bob, Timestamp: base.Add(time.Minute), Content: "You can set the timeout in config.yaml under deploy.timeout"},
+		{ID: "root", ThreadID: "root", IsThreadRoot: true, Author: alice, Timestamp: base, Content: "How do I change the deploy timeout?"},
+		{ID: "thanks", ThreadID: "root", Author: alice, Timestamp: base.Add(2 * time.Minute), Content: "Thanks, that worked!"},
+		{ID: "standalone", Author: bob, Timestamp: base, Content: "Lunch is here"},
+	}
+
+	types := ClassifyThreads(messages)
+
+	if !containsType(types["root"], "question") {
+		t.Errorf("expected root to be a question, got %v", types["root"])
+	}
+	if !containsType(types["reply"], "answer") {
+		t.Errorf("expected reply to be an answer in the question thread, got %v", types["reply"])
+	}
+	if !containsType(types["thanks"], "acknowledgment") {
+		t.Errorf("expected thanks to be an acknowledgment, got %v", types["thanks"])
+	}
+	if len(types["standalone"]) != 0 {
+		t.Errorf("expected standalone message to be unclassified, got %v", types["standalone"])
+	}
+
+	counts := CountTypes(messages)
+	if counts[Unclassified] != 1 {
+		t.Errorf("expected 1 unclassified message, got %d", counts[Unclassified])
+	}
+	total := 0
+	for _, typ := range []string{"question", "answer", "acknowledgment"} {
+		if counts[typ] == 0 {
+			t.Errorf("expected a %s count, got %v", typ, counts)
+		}
+		total += counts[typ]
+	}
+	if total < 3 {
+		t.Errorf("expected every classified message to be counted, got %v", counts)
+	}
+}
+
+// containsType reports whether types includes typ
+func containsType(types []string, typ string) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClassifyThreadsWithMinConfidence(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+
+	// The reply is a strong answer, a weaker solution, and a weak acknowledgment
+	messages := []*normalize.NormalizedMessage{
+		{ID: "root", ThreadID: "root", IsThreadRoot: true, Author: alice, Timestamp: base, Content: "How do I change the deploy timeout?"},
+		{ID: "reply", ThreadID: "root", Author: bob, Timestamp: base.Add(time.Minute), Content: "Try this, it fixed it for me:",
+			CodeBlocks: []normalize.CodeBlock{{Language: "bash", Code: "deploy --timeout 600"}}},
+	}
+
+	tests := []struct {
+		name          string
+		minConfidence float64
+		want          []string
+		unclassified  int
+	}{
+		{"no threshold", 0, []string{"answer", "solution", "acknowledgment"}, 0},
+		{"drops weak acknowledgment", 0.5, []string{"answer", "solution"}, 0},
+		{"answer only", 0.75, []string{"answer"}, 0},
+		{"nothing left", 0.95, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types := ClassifyThreadsWithMinConfidence(messages, tt.minConfidence)
+			got := types["reply"]
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for _, typ := range tt.want {
+				if !containsType(got, typ) {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			}
+
+			counts := CountTypesWithMinConfidence(messages, tt.minConfidence)
+			if counts[Unclassified] != tt.unclassified {
+				t.Errorf("expected %d unclassified, got %v", tt.unclassified, counts)
+			}
+		})
+	}
+}
+
+func TestAggregateConfidence(t *testing.T) {
+	defer func() { Aggregation = AggregateSum }()
+
+	// Three weak signals against one definitive one, and a penalty
+	weak := []float64{0.3, 0.3, 0.3}
+	strong := []float64{0.9}
+	penalized := []float64{0.4, 0.3, -0.2}
+
+	tests := []struct {
+		mode      string
+		weak      float64
+		strong    float64
+		penalized float64
+	}{
+		{AggregateSum, 0.9, 0.9, 0.5},
+		{AggregateMax, 0.3, 0.9, 0.2},
+		{AggregateProbabilistic, 0.657, 0.9, 0.38},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			if err := SetAggregation(tt.mode); err != nil {
+				t.Fatalf("SetAggregation failed: %v", err)
+			}
+			for _, c := range []struct {
+				name    string
+				weights []float64
+				want    float64
+			}{
+				{"weak", weak, tt.weak},
+				{"strong", strong, tt.strong},
+				{"penalized", penalized, tt.penalized},
+			} {
+				if got := aggregateConfidence(c.weights); math.Abs(got-c.want) > 1e-9 {
+					t.Errorf("%s: aggregateConfidence(%v) = %v, want %v", c.name, c.weights, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyQuestion_Aggregation(t *testing.T) {
+	defer func() { Aggregation = AggregateSum }()
+
+	// question_mark (0.5), question_starter (0.4), and help_phrase (0.3)
+	msg := &normalize.NormalizedMessage{Content: "How do I deploy this? I am stuck on step 2"}
+
+	tests := []struct {
+		mode string
+		want float64
+	}{
+		{AggregateSum, 1.0},
+		{AggregateMax, 0.5},
+		{AggregateProbabilistic, 0.79},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			if err := SetAggregation(tt.mode); err != nil {
+				t.Fatalf("SetAggregation failed: %v", err)
+			}
+			c := classifyQuestion(msg)
+			if c == nil {
+				t.Fatal("expected a question")
+			}
+			if len(c.Signals) != 3 {
+				t.Fatalf("expected 3 signals, got %v", c.Signals)
+			}
+			if math.Abs(c.Confidence-tt.want) > 1e-9 {
+				t.Errorf("confidence = %v, want %v", c.Confidence, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetAggregation_Unknown(t *testing.T) {
+	if err := SetAggregation("average"); err == nil {
+		t.Error("expected error for unknown aggregation mode")
+	}
+	if Aggregation != AggregateSum {
+		t.Errorf("Aggregation changed to %q by an invalid mode", Aggregation)
+	}
+}
+
+func TestClassifyThreads_ContextWindow(t *testing.T) {
+	original := ThreadContextWindow
+	defer func() { ThreadContextWindow = original }()
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	authors := []*normalize.User{{ID: "user_alice"}, {ID: "user_bob"}, {ID: "user_carol"}}
+
+	// A megathread whose root announces, and whose question comes in reply
+	// questionAt, answered by the next reply
+	megathread := func(questionAt int) []*normalize.NormalizedMessage {
+		thread := []*normalize.NormalizedMessage{
+			{ID: "msg_0000", ThreadID: "msg_0000", IsThreadRoot: true, Author: authors[0], Timestamp: base, Content: "Release 2.0 is out, notes are on the wiki"},
+		}
+		for i := 1; i < 2000; i++ {
+			content := "Upgraded our staging cluster today"
+			switch i {
+			case questionAt:
+				content = "How do I enable the new cache?"
+			case questionAt + 1:
+				content = "You can set cache.enabled in the config file"
+			}
+			thread = append(thread, &normalize.NormalizedMessage{
+				ID:        fmt.Sprintf("msg_%04d", i),
+				ThreadID:  "msg_0000",
+				Author:    authors[i%len(authors)],
+				Timestamp: base.Add(time.Duration(i) * time.Minute),
+				Content:   content,
+			})
+		}
+		return thread
+	}
+
+	tests := []struct {
+		name       string
+		questionAt int
+		window     int
+		wantAnswer bool
+	}{
+		{"whole thread", 1500, 0, true},
+		{"question inside the window", 1500, 1600, true},
+		{"question outside the window", 1500, 100, false},
+		{"early question with a window", 10, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread := megathread(tt.questionAt)
+			answerID := fmt.Sprintf("msg_%04d", tt.questionAt+1)
+
+			ThreadContextWindow = tt.window
+			windowed := ClassifyThreads(thread)
+			ThreadContextWindow = 0
+			full := ClassifyThreads(thread)
+
+			if got := containsType(windowed[answerID], "answer"); got != tt.wantAnswer {
+				t.Errorf("reply to the question is an answer = %v, want %v (types %v)", got, tt.wantAnswer, windowed[answerID])
+			}
+			// The question is still found on its own, outside the window too
+			if questionID := fmt.Sprintf("msg_%04d", tt.questionAt); !containsType(windowed[questionID], "question") {
+				t.Errorf("expected %s to be a question, got %v", questionID, windowed[questionID])
+			}
+			// Within the window, it changes nothing
+			if tt.wantAnswer && !reflect.DeepEqual(windowed, full) {
+				t.Errorf("windowed classification differs from the whole thread's")
+			}
+		})
+	}
+
+	t.Run("question author", func(t *testing.T) {
+		ThreadContextWindow = 0
+		thread := megathread(20)
+		if ctx := BuildThreadContext(thread, thread[21]); !ctx.HasQuestion || ctx.QuestionAuthor != "user_carol" {
+			t.Errorf("reply after the question: HasQuestion = %v, QuestionAuthor = %q; want true, user_carol", ctx.HasQuestion, ctx.QuestionAuthor)
+		}
+		if ctx := BuildThreadContext(thread, thread[19]); ctx.HasQuestion || ctx.QuestionAuthor != "" {
+			t.Errorf("reply before the question: HasQuestion = %v, QuestionAuthor = %q; want false", ctx.HasQuestion, ctx.QuestionAuthor)
+		}
+	})
+}
+
+func TestEnrichMessage_Pastes(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		codeOnly   bool
+		linkOnly   bool
+		isQuestion bool
+	}{
+		{"code paste", "```\nif err != nil { return fmt.Errorf(\"what? %w\", err) }\n```", true, false, false},
+		{"link dump", "https://example.com/search?q=deploy\nhttps://example.com/faq", false, true, false},
+		{"question with code", "Why does this fail?\n```\nmake build\n```", false, false, true},
+		{"question with a link", "Is https://example.com/docs still current?", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enrich := EnrichMessage(&normalize.NormalizedMessage{ID: "msg", Content: tt.content})
+			if enrich.IsCodeOnly != tt.codeOnly || enrich.IsLinkOnly != tt.linkOnly || enrich.IsQuestion != tt.isQuestion {
+				t.Errorf("EnrichMessage(%q) = code only %v, link only %v, question %v; want %v, %v, %v", tt.content,
+					enrich.IsCodeOnly, enrich.IsLinkOnly, enrich.IsQuestion, tt.codeOnly, tt.linkOnly, tt.isQuestion)
+			}
+		})
+	}
+}
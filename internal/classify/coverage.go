@@ -0,0 +1,63 @@
+package classify
+
+import "github.com/solvaholic/threadmine/internal/normalize"
+
+// Coverage summarizes how much of a set of messages the classifiers cover,
+// as a quick check of data quality
+type Coverage struct {
+	Messages            int     `json:"messages"`
+	ClassifiedMessages  int     `json:"classified_messages"` // Messages with at least one classification
+	ClassifiedRatio     float64 `json:"classified_ratio"`    // ClassifiedMessages / Messages, 0 without messages
+	Threads             int     `json:"threads"`
+	ThreadsWithQuestion int     `json:"threads_with_question"` // Threads with a message classified as a question
+	ResolvedThreads     int     `json:"resolved_threads"`      // Threads with a solution or an acknowledgment that resolves them, or resolved by their source
+}
+
+// ComputeCoverage classifies messages within their threads, like
+// ClassifyThreads, and summarizes the coverage. Threads are keyed like
+// ClassifyThreads groups them; resolved marks threads their source resolved
+// (e.g. issues closed as completed), which count as resolved without a
+// message classified as a solution or an acknowledgment from one of the
+// AcknowledgmentResolvers.
+func ComputeCoverage(messages []*normalize.NormalizedMessage, resolved map[string]bool) Coverage {
+	coverage := Coverage{Messages: len(messages)}
+
+	threads := make(map[string]bool)
+	withQuestion := make(map[string]bool)
+	solved := make(map[string]bool)
+	classifyInThreads(messages, NewReferenceIndex(messages), func(msg *normalize.NormalizedMessage, _ *ThreadContext, classifications []Classification) {
+		key := msg.ThreadID
+		if key == "" {
+			key = msg.ID
+		}
+		threads[key] = true
+
+		if len(classifications) > 0 {
+			coverage.ClassifiedMessages++
+		}
+		for _, c := range classifications {
+			switch c.Type {
+			case "question":
+				withQuestion[key] = true
+			case "solution":
+				solved[key] = true
+			case "acknowledgment":
+				if ResolvesThread(c) {
+					solved[key] = true
+				}
+			}
+		}
+	})
+
+	coverage.Threads = len(threads)
+	coverage.ThreadsWithQuestion = len(withQuestion)
+	for key := range threads {
+		if solved[key] || resolved[key] {
+			coverage.ResolvedThreads++
+		}
+	}
+	if coverage.Messages > 0 {
+		coverage.ClassifiedRatio = float64(coverage.ClassifiedMessages) / float64(coverage.Messages)
+	}
+	return coverage
+}
@@ -0,0 +1,82 @@
+package classify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestComputeCoverage(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+
+	messages := []*normalize.NormalizedMessage{
+		// A question with a solution: resolved
+		{ID: "q1", ThreadID: "q1", IsThreadRoot: true, Author: alice, Timestamp: base, Content: "How do I change the deploy timeout?"},
+		{ID: "q1_reply", ThreadID: "q1", Author: bob, Timestamp: base.Add(time.Minute), Content: "Try this, it fixed it for me:",
+			CodeBlocks: []normalize.CodeBlock{{Language: "bash", Code: "deploy --timeout 600"}}},
+		// A question nobody answered
+		{ID: "q2", ThreadID: "q2", IsThreadRoot: true, Author: alice, Timestamp: base, Content: "Why is the build slow today?"},
+		// No question, but resolved by its source
+		{ID: "issue", ThreadID: "issue", IsThreadRoot: true, Author: bob, Timestamp: base, Content: "Rename the deploy job"},
+		// A message without a thread is its own thread
+		{ID: "standalone", Author: bob, Timestamp: base, Content: "Lunch is here"},
+	}
+
+	got := ComputeCoverage(messages, map[string]bool{"issue": true, "q2": false})
+	want := Coverage{
+		Messages:            5,
+		ClassifiedMessages:  3, // q1, q1_reply, q2
+		ClassifiedRatio:     0.6,
+		Threads:             4,
+		ThreadsWithQuestion: 2,
+		ResolvedThreads:     2, // q1 by its solution, issue by its source
+	}
+	if got != want {
+		t.Errorf("ComputeCoverage() = %+v, want %+v", got, want)
+	}
+
+	if empty := ComputeCoverage(nil, nil); empty != (Coverage{}) {
+		t.Errorf("ComputeCoverage(nil) = %+v, want zero coverage", empty)
+	}
+}
+
+func TestComputeCoverage_Acknowledgments(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+	carol := &normalize.User{ID: "user_carol"}
+
+	messages := []*normalize.NormalizedMessage{
+		// The asker thanks the answerer: resolved
+		{ID: "q1", ThreadID: "q1", IsThreadRoot: true, Author: alice, Timestamp: base, Content: "Why is the build slow today?"},
+		{ID: "q1_ack", ThreadID: "q1", Author: alice, Timestamp: base.Add(time.Minute), Content: "Thanks, that worked!"},
+		// A bystander's thanks doesn't resolve someone else's question
+		{ID: "q2", ThreadID: "q2", IsThreadRoot: true, Author: alice, Timestamp: base, Content: "Why is the cache cold?"},
+		{ID: "q2_ack", ThreadID: "q2", Author: carol, Timestamp: base.Add(time.Minute), Content: "Thanks, this helped me too"},
+		{ID: "q2_ack2", ThreadID: "q2", Author: bob, Timestamp: base.Add(2 * time.Minute), Content: "👍"},
+	}
+
+	tests := []struct {
+		name      string
+		resolvers []string
+		want      int
+	}{
+		{"asker or owner", []string{AcknowledgerAsker, AcknowledgerOwner}, 1},
+		{"anyone", []string{AcknowledgerAnyone}, 2},
+		{"none", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := AcknowledgmentResolvers
+			AcknowledgmentResolvers = tt.resolvers
+			t.Cleanup(func() { AcknowledgmentResolvers = saved })
+
+			if got := ComputeCoverage(messages, nil).ResolvedThreads; got != tt.want {
+				t.Errorf("ResolvedThreads = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package classify
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// RelationDuplicateOf links a copy of a message cross-posted to several
+// channels to the copy posted first
+const RelationDuplicateOf = "duplicate_of"
+
+// CrossPostWindow is how far apart the copies of a cross-posted message can be
+var CrossPostWindow = 10 * time.Minute
+
+// crossPostMinLength is the shortest content that counts as cross-posted.
+// Short messages like "thanks!" or "+1" are often identical by coincidence.
+const crossPostMinLength = 20
+
+// IsCrossPost reports whether a and b are copies of one message: posted by the
+// same author in different channels within CrossPostWindow, with the same
+// content apart from whitespace
+func IsCrossPost(a, b *normalize.NormalizedMessage) bool {
+	if a.ID == b.ID || a.Author == nil || b.Author == nil || a.Author.ID != b.Author.ID {
+		return false
+	}
+	if a.Channel == nil || b.Channel == nil || a.Channel.ID == b.Channel.ID {
+		return false
+	}
+	if gap := a.Timestamp.Sub(b.Timestamp); gap > CrossPostWindow || gap < -CrossPostWindow {
+		return false
+	}
+	content := crossPostContent(a)
+	return len(content) >= crossPostMinLength && content == crossPostContent(b)
+}
+
+// crossPostContent returns the content of msg with runs of whitespace collapsed
+func crossPostContent(msg *normalize.NormalizedMessage) string {
+	return strings.Join(strings.Fields(msg.Content), " ")
+}
+
+// DuplicateRelations returns a RelationDuplicateOf relation to msg's first
+// copy from each of its other copies among candidates (see IsCrossPost).
+// Copies posted at the same time are ordered by ID.
+func DuplicateRelations(msg *normalize.NormalizedMessage, candidates []*normalize.NormalizedMessage) []Relation {
+	copies := []*normalize.NormalizedMessage{msg}
+	for _, candidate := range candidates {
+		if IsCrossPost(msg, candidate) {
+			copies = append(copies, candidate)
+		}
+	}
+	if len(copies) == 1 {
+		return nil
+	}
+
+	sort.Slice(copies, func(i, j int) bool {
+		if !copies[i].Timestamp.Equal(copies[j].Timestamp) {
+			return copies[i].Timestamp.Before(copies[j].Timestamp)
+		}
+		return copies[i].ID < copies[j].ID
+	})
+	var relations []Relation
+	for _, dup := range copies[1:] {
+		relations = append(relations, Relation{FromID: dup.ID, ToID: copies[0].ID, Type: RelationDuplicateOf, Confidence: 1.0})
+	}
+	return relations
+}
@@ -0,0 +1,89 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestDuplicateRelations(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	announcement := "Deploys are frozen until Monday for the datacenter move."
+	message := func(id, channel, author, content string, at time.Duration) *normalize.NormalizedMessage {
+		return &normalize.NormalizedMessage{ID: id, SourceType: "slack", Timestamp: base.Add(at), Content: content,
+			Author: &normalize.User{ID: author}, Channel: &normalize.Channel{ID: channel}}
+	}
+
+	tests := []struct {
+		name       string
+		msg        *normalize.NormalizedMessage
+		candidates []*normalize.NormalizedMessage
+		want       []Relation
+	}{
+		{
+			name: "cross-posted to two more channels",
+			msg:  message("msg_general", "chan_general", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{
+				message("msg_eng", "chan_eng", "user_alice", announcement, 2*time.Minute),
+				message("msg_ops", "chan_ops", "user_alice", "Deploys are frozen  until Monday\nfor the datacenter move.", time.Minute),
+			},
+			want: []Relation{
+				{FromID: "msg_ops", ToID: "msg_general", Type: RelationDuplicateOf, Confidence: 1.0},
+				{FromID: "msg_eng", ToID: "msg_general", Type: RelationDuplicateOf, Confidence: 1.0},
+			},
+		},
+		{
+			name:       "a later copy points to the first",
+			msg:        message("msg_eng", "chan_eng", "user_alice", announcement, 2*time.Minute),
+			candidates: []*normalize.NormalizedMessage{message("msg_general", "chan_general", "user_alice", announcement, 0)},
+			want:       []Relation{{FromID: "msg_eng", ToID: "msg_general", Type: RelationDuplicateOf, Confidence: 1.0}},
+		},
+		{
+			name:       "copies at the same time are ordered by ID",
+			msg:        message("msg_b", "chan_b", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_a", "chan_a", "user_alice", announcement, 0)},
+			want:       []Relation{{FromID: "msg_b", ToID: "msg_a", Type: RelationDuplicateOf, Confidence: 1.0}},
+		},
+		{
+			name:       "short messages are identical by coincidence",
+			msg:        message("msg_general", "chan_general", "user_alice", "thanks!", 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_eng", "chan_eng", "user_alice", "thanks!", time.Minute)},
+		},
+		{
+			name:       "same content by another author",
+			msg:        message("msg_general", "chan_general", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_eng", "chan_eng", "user_bob", announcement, time.Minute)},
+		},
+		{
+			name:       "same content much later",
+			msg:        message("msg_general", "chan_general", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_eng", "chan_eng", "user_alice", announcement, 2*time.Hour)},
+		},
+		{
+			name:       "similar but not identical content",
+			msg:        message("msg_general", "chan_general", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_eng", "chan_eng", "user_alice", "Deploys are frozen until Tuesday for the datacenter move.", time.Minute)},
+		},
+		{
+			name:       "reposted in the same channel",
+			msg:        message("msg_general", "chan_general", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_general_2", "chan_general", "user_alice", announcement, time.Minute)},
+		},
+		{
+			name:       "the message itself",
+			msg:        message("msg_general", "chan_general", "user_alice", announcement, 0),
+			candidates: []*normalize.NormalizedMessage{message("msg_general", "chan_general", "user_alice", announcement, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DuplicateRelations(tt.msg, tt.candidates)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DuplicateRelations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package classify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// signalExplanations maps signal codes to sentence-form explanations
+var signalExplanations = map[string]string{
+	"question_mark":            "The message contains a question mark.",
+	"reply_in_question_thread": "The message replies to a thread that starts with a question.",
+	"early_reply":              "The message is one of the first replies in the thread.",
+	"code_block":               "The message includes a code block.",
+	"answer_by_reference":      "The message links to another thread or channel that has the answer.",
+	"contains_question":        "The message asks a question of its own, which makes it less likely to be an answer.",
+	"multiple_participants":    "More than one person is participating in the thread.",
+	"numbered_steps":           "The message lists numbered steps.",
+	"documentation_link":       "The message links to documentation.",
+	"thanks":                   "The message thanks someone.",
+	"by_asker":                 "The message comes from the person who asked the thread's question.",
+	"by_owner":                 "The message comes from the person who started the thread.",
+	"positive_emoji":           "The message includes a positive reaction emoji.",
+	"resolved_emoji":           "The message includes an emoji that marks the problem as resolved.",
+	"celebration_emoji":        "The message includes a celebration emoji.",
+	"seen_emoji":               "The message includes an emoji that shows it was seen.",
+	"emoji_only":               "The message contains nothing but seen emoji.",
+}
+
+// parameterizedExplanations maps signal prefixes (the part before ":") to
+// explanation templates; %q is replaced with the matched phrase
+var parameterizedExplanations = map[string]string{
+	"question_starter":     "The message starts like a question (%q).",
+	"help_phrase":          "The message asks for help (%q).",
+	"instruction_phrase":   "The message gives instructions (%q).",
+	"success_confirmation": "The message confirms that something worked (%q).",
+}
+
+// ExplainSignal returns a human-readable sentence describing a classification signal.
+// Unknown signals are described generically rather than dropped.
+func ExplainSignal(signal string) string {
+	if explanation, ok := signalExplanations[signal]; ok {
+		return explanation
+	}
+
+	if prefix, phrase, ok := strings.Cut(signal, ":"); ok {
+		if template, ok := parameterizedExplanations[prefix]; ok {
+			return fmt.Sprintf(template, phrase)
+		}
+	}
+
+	return fmt.Sprintf("Matched signal %q.", signal)
+}
+
+// Explain returns an explanation for each signal of a classification, in order
+func Explain(c Classification) []string {
+	explanations := make([]string, 0, len(c.Signals))
+	for _, signal := range c.Signals {
+		explanations = append(explanations, ExplainSignal(signal))
+	}
+	return explanations
+}
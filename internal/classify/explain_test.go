@@ -0,0 +1,59 @@
+package classify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestExplainSignal(t *testing.T) {
+	tests := []struct {
+		signal string
+		want   string
+	}{
+		{"question_mark", "The message contains a question mark."},
+		{"question_starter:how do i", `The message starts like a question ("how do i").`},
+		{"help_phrase:stuck trying", `The message asks for help ("stuck trying").`},
+		{"instruction_phrase:try this", `The message gives instructions ("try this").`},
+		{"success_confirmation:that worked", `The message confirms that something worked ("that worked").`},
+		{"early_reply", "The message is one of the first replies in the thread."},
+		{"contains_question", "The message asks a question of its own, which makes it less likely to be an answer."},
+		{"documentation_link", "The message links to documentation."},
+		{"by_asker", "The message comes from the person who asked the thread's question."},
+		{"by_owner", "The message comes from the person who started the thread."},
+		{"unknown_signal", `Matched signal "unknown_signal".`},
+		{"unknown_prefix:value", `Matched signal "unknown_prefix:value".`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.signal, func(t *testing.T) {
+			if got := ExplainSignal(tt.signal); got != tt.want {
+				t.Errorf("ExplainSignal(%q) = %q, want %q", tt.signal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainSignal_CoversClassifierSignals(t *testing.T) {
+	// Every signal the classifiers emit should have a specific explanation
+	asker := &normalize.User{ID: "user_slack_U1"}
+	root := &normalize.NormalizedMessage{ID: "root", Author: asker, Content: "How do I fix this? I'm stuck trying to deploy", IsThreadRoot: true}
+	messages := []*normalize.NormalizedMessage{
+		root,
+		{ID: "reply", Content: "You can try this:\n1. Restart\n2. Redeploy\nSee https://docs.example.com. Does that help?",
+			CodeBlocks: []normalize.CodeBlock{{Code: "make deploy"}}},
+		{ID: "ack", Author: asker, Content: "Thanks, that worked 👍"},
+	}
+	ctx := &ThreadContext{HasQuestion: true, Position: 1, ParticipantCount: 2, QuestionAuthor: asker.ID, RootAuthor: asker.ID}
+
+	for _, msg := range messages {
+		for _, c := range ClassifyMessage(msg, ctx) {
+			for _, explanation := range Explain(c) {
+				if strings.HasPrefix(explanation, "Matched signal") {
+					t.Errorf("%s classification of %s has no specific explanation: %s", c.Type, msg.ID, explanation)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package classify
+
+import "github.com/solvaholic/threadmine/internal/normalize"
+
+// messageLanguage returns the language of msg: its Language, or the language
+// detected from its content for messages normalized before detection existed
+func messageLanguage(msg *normalize.NormalizedMessage) string {
+	if msg.Language != "" {
+		return msg.Language
+	}
+	return normalize.DetectLanguage(msg.Content)
+}
+
+// withLanguage returns msg with its Language set (see messageLanguage),
+// copying msg rather than changing it
+func withLanguage(msg *normalize.NormalizedMessage) *normalize.NormalizedMessage {
+	if msg.Language != "" {
+		return msg
+	}
+	detected := *msg
+	detected.Language = messageLanguage(msg)
+	return &detected
+}
+
+// usesEnglishPhrases reports whether the English phrase lists (questionStarters,
+// instructionPhrases, ...) apply to msg. They'd only match other languages by
+// accident, so they apply to English messages and to messages whose language
+// is unknown, such as short ones. Language-neutral signals (question marks,
+// code, emoji, ...) apply to every message.
+func usesEnglishPhrases(msg *normalize.NormalizedMessage) bool {
+	return msg.Language == "" || msg.Language == "en"
+}
@@ -0,0 +1,48 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestClassifyMessage_NonEnglish(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		// "solution" is an English instruction phrase
+		{"french mentioning a solution", "La solution est dans le fichier de configuration du projet", nil},
+		// Question marks and code mean the same in any language
+		{"spanish question", "¿Alguien sabe por qué falla el despliegue en la rama principal?", []string{"question"}},
+		{"english", "The solution is in the project's config file", []string{"solution"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &normalize.NormalizedMessage{ID: "msg", Content: tt.content}
+			var got []string
+			for _, c := range ClassifyMessage(msg, nil) {
+				got = append(got, c.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyMessage(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+			if msg.Language != "" {
+				t.Errorf("ClassifyMessage changed the message's language to %q", msg.Language)
+			}
+		})
+	}
+
+	// A stored language wins over detection
+	msg := &normalize.NormalizedMessage{ID: "msg", Content: "The solution is in the config file", Language: "de"}
+	if got := ClassifyMessage(msg, nil); len(got) != 0 {
+		t.Errorf("expected no English phrase classifications for a German message, got %v", got)
+	}
+
+	if enrich := EnrichMessage(&normalize.NormalizedMessage{Content: "Wie kann ich den Build neu starten, wenn er hängt"}); enrich.Language != "de" || enrich.IsQuestion {
+		t.Errorf("EnrichMessage() = %+v, want a German non-question", enrich)
+	}
+}
@@ -0,0 +1,224 @@
+package classify
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// RelationResolvesVia links an answer to the message or channel it points the
+// asker to, e.g. "duplicate, see #123"
+const RelationResolvesVia = "resolves_via"
+
+// RelationShares links a message to a message shared (forwarded) into it,
+// e.g. a Slack message shared from another channel
+const RelationShares = "shares"
+
+// RelationReferences links a message to a message it links to by permalink,
+// e.g. a Slack message pointing to a discussion in another channel
+const RelationReferences = "references"
+
+// Reference is a pointer from message content to another GitHub issue or pull
+// request, Slack message, or Slack channel
+type Reference struct {
+	Source  string // "github" or "slack"
+	Repo    string // GitHub owner/repo
+	Number  int    // GitHub issue or pull request number
+	Channel string // Slack channel ID
+	TS      string // Slack message timestamp; empty for a channel link
+}
+
+// Key returns a string that identifies the referenced item
+func (r Reference) Key() string {
+	if r.Source == "github" {
+		return fmt.Sprintf("github:%s#%d", strings.ToLower(r.Repo), r.Number)
+	}
+	if r.TS == "" {
+		return "slack:" + r.Channel
+	}
+	return "slack:" + r.Channel + "/" + r.TS
+}
+
+// ReferenceResolver finds the corpus message or channel a reference points to
+type ReferenceResolver interface {
+	ResolveReference(ref Reference) (id string, ok bool)
+}
+
+var (
+	// https://github.com/owner/repo/issues/123 or /pull/123
+	githubURLPattern = regexp.MustCompile(`(?i)github\.com/([\w.-]+/[\w.-]+)/(?:issues|pull)/(\d+)`)
+	// owner/repo#123 or a bare #123 (the message's own repository)
+	issueRefPattern = regexp.MustCompile(`(?:^|[^\w/#])((?:[\w.-]+/[\w.-]+)?)#(\d+)\b`)
+	// https://team.slack.com/archives/C123 or /archives/C123/p1700000000123456
+	slackURLPattern = regexp.MustCompile(`slack\.com/archives/([A-Z0-9]+)(?:/p(\d{16}))?`)
+	// GitHub source IDs start with owner/repo followed by the issue number:
+	// owner/repo#123-comment-1 (fetch) or owner/repo/issues/123#... (normalize)
+	githubSourceIDPattern = regexp.MustCompile(`^([\w.-]+/[\w.-]+?)(?:#|/issues/|/pull/)(\d+)`)
+)
+
+// ExtractReferences returns the issues, pull requests, and Slack messages or
+// channels a message points to, from its URLs and issue references in content
+func ExtractReferences(msg *normalize.NormalizedMessage) []Reference {
+	var refs []Reference
+	seen := make(map[string]bool)
+	add := func(ref Reference) {
+		if !seen[ref.Key()] {
+			seen[ref.Key()] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	for _, url := range msg.URLs {
+		if m := githubURLPattern.FindStringSubmatch(url); m != nil {
+			number, _ := strconv.Atoi(m[2])
+			add(Reference{Source: "github", Repo: m[1], Number: number})
+		} else if m := slackURLPattern.FindStringSubmatch(url); m != nil {
+			ref := Reference{Source: "slack", Channel: m[1]}
+			if m[2] != "" {
+				ref.TS = m[2][:10] + "." + m[2][10:]
+			}
+			add(ref)
+		}
+	}
+
+	ownRepo, _ := githubIssue(msg)
+	for _, m := range issueRefPattern.FindAllStringSubmatch(msg.Content, -1) {
+		repo := m[1]
+		if repo == "" {
+			// A bare #123 is only an issue reference on GitHub
+			if ownRepo == "" {
+				continue
+			}
+			repo = ownRepo
+		}
+		number, _ := strconv.Atoi(m[2])
+		add(Reference{Source: "github", Repo: repo, Number: number})
+	}
+
+	return refs
+}
+
+// githubIssue returns the repository and issue number a GitHub message belongs to
+func githubIssue(msg *normalize.NormalizedMessage) (string, int) {
+	if msg.SourceType != "github" {
+		return "", 0
+	}
+	m := githubSourceIDPattern.FindStringSubmatch(msg.SourceID)
+	if m == nil {
+		return "", 0
+	}
+	number, _ := strconv.Atoi(m[2])
+	return m[1], number
+}
+
+// slackMessage returns the channel ID and timestamp of a Slack message.
+// Source IDs are C123_1700000000.000100 (fetch) or T1:C123:1700000000.000100 (normalize).
+func slackMessage(msg *normalize.NormalizedMessage) (string, string) {
+	if msg.SourceType != "slack" {
+		return "", ""
+	}
+	parts := strings.FieldsFunc(msg.SourceID, func(r rune) bool { return r == '_' || r == ':' })
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+// ReferenceIndex resolves references to messages in a fixed set of messages.
+// GitHub references resolve to the issue or pull request's root message; Slack
+// channel links resolve to the channel of any indexed message in it.
+type ReferenceIndex map[string]string
+
+// NewReferenceIndex indexes the items in messages that references can point to
+func NewReferenceIndex(messages []*normalize.NormalizedMessage) ReferenceIndex {
+	index := make(ReferenceIndex)
+	for _, msg := range messages {
+		switch msg.SourceType {
+		case "github":
+			if repo, number := githubIssue(msg); repo != "" && msg.IsThreadRoot {
+				index[Reference{Source: "github", Repo: repo, Number: number}.Key()] = msg.ID
+			}
+		case "slack":
+			channel, ts := slackMessage(msg)
+			if channel == "" {
+				continue
+			}
+			index[Reference{Source: "slack", Channel: channel, TS: ts}.Key()] = msg.ID
+			if msg.Channel != nil && msg.Channel.ID != "" {
+				index[Reference{Source: "slack", Channel: channel}.Key()] = msg.Channel.ID
+			}
+		}
+	}
+	return index
+}
+
+// ResolveReference implements ReferenceResolver
+func (idx ReferenceIndex) ResolveReference(ref Reference) (string, bool) {
+	id, ok := idx[ref.Key()]
+	return id, ok
+}
+
+// ResolveReferences returns the IDs of corpus messages and channels msg refers
+// to, leaving out msg itself and its own thread
+func ResolveReferences(msg *normalize.NormalizedMessage, resolver ReferenceResolver) []string {
+	if resolver == nil {
+		return nil
+	}
+
+	var ids []string
+	for _, ref := range ExtractReferences(msg) {
+		id, ok := resolver.ResolveReference(ref)
+		if !ok || id == msg.ID || id == msg.ThreadID || id == msg.ParentID {
+			continue
+		}
+		if msg.Channel != nil && id == msg.Channel.ID {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// SharesRelations returns a RelationShares relation from each message to every
+// message shared into it that is in the corpus
+func SharesRelations(messages []*normalize.NormalizedMessage, resolver ReferenceResolver) []Relation {
+	var relations []Relation
+	for _, msg := range messages {
+		for _, shared := range msg.SharedMessages {
+			id, ok := resolver.ResolveReference(Reference{Source: msg.SourceType, Channel: shared.ChannelID, TS: shared.TS})
+			if !ok || id == msg.ID {
+				continue
+			}
+			relations = append(relations, Relation{FromID: msg.ID, ToID: id, Type: RelationShares, Confidence: 1.0})
+		}
+	}
+	return relations
+}
+
+// ReferencesRelations returns a RelationReferences relation from each message
+// to every message in the corpus it links to by Slack permalink, leaving out
+// links within its own thread and to messages shared into it
+func ReferencesRelations(messages []*normalize.NormalizedMessage, resolver ReferenceResolver) []Relation {
+	var relations []Relation
+	for _, msg := range messages {
+		shared := make(map[string]bool)
+		for _, s := range msg.SharedMessages {
+			shared[Reference{Source: "slack", Channel: s.ChannelID, TS: s.TS}.Key()] = true
+		}
+
+		for _, ref := range ExtractReferences(msg) {
+			if ref.Source != "slack" || ref.TS == "" || shared[ref.Key()] {
+				continue
+			}
+			id, ok := resolver.ResolveReference(ref)
+			if !ok || id == msg.ID || id == msg.ThreadID || id == msg.ParentID {
+				continue
+			}
+			relations = append(relations, Relation{FromID: msg.ID, ToID: id, Type: RelationReferences, Confidence: 1.0})
+		}
+	}
+	return relations
+}
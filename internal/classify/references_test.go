@@ -0,0 +1,230 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+func TestExtractReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *normalize.NormalizedMessage
+		want []string
+	}{
+		{
+			name: "bare issue number in a GitHub comment",
+			msg:  &normalize.NormalizedMessage{SourceType: "github", SourceID: "acme/widgets#7-comment-1", Content: "Duplicate, see #123"},
+			want: []string{"github:acme/widgets#123"},
+		},
+		{
+			name: "cross-repo issue reference",
+			msg:  &normalize.NormalizedMessage{SourceType: "github", SourceID: "acme/widgets/issues/7", Content: "Fixed by acme/Gadgets#9."},
+			want: []string{"github:acme/gadgets#9"},
+		},
+		{
+			name: "bare issue number in Slack is not a reference",
+			msg:  &normalize.NormalizedMessage{SourceType: "slack", SourceID: "C1_1700000000.000100", Content: "we're #1"},
+			want: nil,
+		},
+		{
+			name: "GitHub and Slack URLs",
+			msg: &normalize.NormalizedMessage{SourceType: "slack", SourceID: "C1_1700000000.000100", URLs: []string{
+				"https://github.com/acme/widgets/pull/42",
+				"https://acme.slack.com/archives/C2/p1700000000000200",
+				"https://acme.slack.com/archives/C3",
+				"https://example.com/docs",
+			}},
+			want: []string{"github:acme/widgets#42", "slack:C2/1700000000.000200", "slack:C3"},
+		},
+		{
+			name: "duplicates are collapsed",
+			msg: &normalize.NormalizedMessage{SourceType: "github", SourceID: "acme/widgets#7", Content: "see #5 and #5",
+				URLs: []string{"https://github.com/acme/widgets/issues/5"}},
+			want: []string{"github:acme/widgets#5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := ExtractReferences(tt.msg)
+			var got []string
+			for _, ref := range refs {
+				got = append(got, ref.Key())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyAnswer_ByReference(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+
+	// An earlier, already-answered issue in the same repository
+	existing := &normalize.NormalizedMessage{
+		ID: "msg_github_acme_widgets_5", SourceType: "github", SourceID: "acme/widgets#5",
+		ThreadID: "msg_github_acme_widgets_5", IsThreadRoot: true, Author: alice, Timestamp: base.Add(-time.Hour),
+		Content: "Build fails on arm64",
+	}
+
+	tests := []struct {
+		name         string
+		reply        string
+		urls         []string
+		wantRelation string
+	}{
+		{name: "issue reference", reply: "Duplicate, see #5", wantRelation: existing.ID},
+		{name: "issue link", reply: "This was answered here", urls: []string{"https://github.com/acme/widgets/issues/5"}, wantRelation: existing.ID},
+		{name: "reference outside the corpus", reply: "Duplicate, see #99"},
+		{name: "no reference", reply: "Try running make clean first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &normalize.NormalizedMessage{
+				ID: "msg_github_acme_widgets_8", SourceType: "github", SourceID: "acme/widgets#8",
+				ThreadID: "msg_github_acme_widgets_8", IsThreadRoot: true, Author: alice, Timestamp: base,
+				Content: "Why does the arm64 build fail?",
+			}
+			reply := &normalize.NormalizedMessage{
+				ID: "reply", SourceType: "github", SourceID: "acme/widgets#8-comment-1",
+				ThreadID: root.ID, ParentID: root.ID, Author: bob, Timestamp: base.Add(time.Minute),
+				Content: tt.reply, URLs: tt.urls,
+			}
+			messages := []*normalize.NormalizedMessage{existing, root, reply}
+
+			ctx := BuildThreadContext([]*normalize.NormalizedMessage{root, reply}, reply)
+			ctx.References = ResolveReferences(reply, NewReferenceIndex(messages))
+			answer := classifyAnswer(reply, ctx)
+			if answer == nil {
+				t.Fatal("expected reply to be classified as an answer")
+			}
+
+			byReference := hasSignal(*answer, "answer_by_reference")
+			if byReference != (tt.wantRelation != "") {
+				t.Errorf("expected answer_by_reference=%v, got signals %v", tt.wantRelation != "", answer.Signals)
+			}
+
+			relations := ResolvesViaRelations(messages, NewReferenceIndex(messages))
+			if tt.wantRelation == "" {
+				if len(relations) != 0 {
+					t.Errorf("expected no relations, got %+v", relations)
+				}
+				return
+			}
+			if len(relations) != 1 {
+				t.Fatalf("expected 1 relation, got %+v", relations)
+			}
+			rel := relations[0]
+			if rel.FromID != reply.ID || rel.ToID != tt.wantRelation || rel.Type != RelationResolvesVia {
+				t.Errorf("unexpected relation %+v", rel)
+			}
+			if rel.Confidence != answer.Confidence {
+				t.Errorf("expected relation confidence %.2f, got %.2f", answer.Confidence, rel.Confidence)
+			}
+		})
+	}
+}
+
+func TestClassifyAnswer_ByReferenceBoostsConfidence(t *testing.T) {
+	ctx := &ThreadContext{HasQuestion: true, Position: 1, ParticipantCount: 2}
+	msg := &normalize.NormalizedMessage{Content: "Answered in the other thread"}
+
+	plain := classifyAnswer(msg, ctx)
+	ctx.References = []string{"msg_slack_C2_1700000000.000200"}
+	linked := classifyAnswer(msg, ctx)
+
+	if linked.Confidence <= plain.Confidence {
+		t.Errorf("expected reference to raise confidence above %.2f, got %.2f", plain.Confidence, linked.Confidence)
+	}
+}
+
+func TestResolveReferences_SkipsOwnThread(t *testing.T) {
+	root := &normalize.NormalizedMessage{
+		ID: "msg_slack_C1_1700000000.000100", SourceType: "slack", SourceID: "C1_1700000000.000100",
+		ThreadID: "msg_slack_C1_1700000000.000100", IsThreadRoot: true,
+		Channel: &normalize.Channel{ID: "chan_slack_C1"},
+	}
+	reply := &normalize.NormalizedMessage{
+		ID: "msg_slack_C1_1700000001.000100", SourceType: "slack", SourceID: "C1_1700000001.000100",
+		ThreadID: root.ID, Channel: &normalize.Channel{ID: "chan_slack_C1"},
+		URLs: []string{
+			"https://acme.slack.com/archives/C1/p1700000000000100",
+			"https://acme.slack.com/archives/C1",
+		},
+	}
+
+	if got := ResolveReferences(reply, NewReferenceIndex([]*normalize.NormalizedMessage{root, reply})); len(got) != 0 {
+		t.Errorf("expected links to the message's own thread and channel to be ignored, got %v", got)
+	}
+}
+
+func TestSharesRelations(t *testing.T) {
+	original := &normalize.NormalizedMessage{
+		ID: "msg_slack_C1_1700000000.000100", SourceType: "slack", SourceID: "C1_1700000000.000100",
+		IsThreadRoot: true, Channel: &normalize.Channel{ID: "chan_slack_C1"},
+	}
+	share := &normalize.NormalizedMessage{
+		ID: "msg_slack_C2_1700000100.000100", SourceType: "slack", SourceID: "C2_1700000100.000100",
+		IsThreadRoot: true, Channel: &normalize.Channel{ID: "chan_slack_C2"},
+		SharedMessages: []normalize.SharedMessage{
+			{ChannelID: "C1", TS: "1700000000.000100"},
+			// Shared from outside the corpus
+			{ChannelID: "C3", TS: "1700000000.000300"},
+		},
+	}
+	messages := []*normalize.NormalizedMessage{original, share}
+
+	got := SharesRelations(messages, NewReferenceIndex(messages))
+	want := []Relation{{FromID: share.ID, ToID: original.ID, Type: RelationShares, Confidence: 1.0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SharesRelations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReferencesRelations(t *testing.T) {
+	original := &normalize.NormalizedMessage{
+		ID: "msg_slack_C1_1700000000.000100", SourceType: "slack", SourceID: "C1_1700000000.000100",
+		IsThreadRoot: true, Channel: &normalize.Channel{ID: "chan_slack_C1"},
+	}
+	reply := &normalize.NormalizedMessage{
+		ID: "msg_slack_C1_1700000050.000100", SourceType: "slack", SourceID: "C1_1700000050.000100",
+		ThreadID: original.ID, ParentID: original.ID, Channel: &normalize.Channel{ID: "chan_slack_C1"},
+		// A link to its own thread
+		URLs: []string{"https://acme.slack.com/archives/C1/p1700000000000100"},
+	}
+	link := &normalize.NormalizedMessage{
+		ID: "msg_slack_C2_1700000100.000100", SourceType: "slack", SourceID: "C2_1700000100.000100",
+		IsThreadRoot: true, Channel: &normalize.Channel{ID: "chan_slack_C2"},
+		URLs: []string{
+			"https://acme.slack.com/archives/C1/p1700000000000100?thread_ts=1700000000.000100&cid=C1",
+			// A message outside the corpus and an external page
+			"https://acme.slack.com/archives/C3/p1700000000000300",
+			"https://example.com/runbook",
+		},
+	}
+	messages := []*normalize.NormalizedMessage{original, reply, link}
+
+	got := ReferencesRelations(messages, NewReferenceIndex(messages))
+	want := []Relation{{FromID: link.ID, ToID: original.ID, Type: RelationReferences, Confidence: 1.0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReferencesRelations() = %+v, want %+v", got, want)
+	}
+
+	// A permalink to a message shared into the same message is a shares relation
+	link.SharedMessages = []normalize.SharedMessage{{ChannelID: "C1", TS: "1700000000.000100"}}
+	if got := ReferencesRelations(messages, NewReferenceIndex(messages)); len(got) != 0 {
+		t.Errorf("ReferencesRelations() with the message shared = %+v, want none", got)
+	}
+}
@@ -0,0 +1,24 @@
+// Package clock is where ThreadMine gets the current time: cache freshness,
+// normalization timestamps, relative dates, and rate limit windows all read
+// it from Now, so tests can freeze it.
+package clock
+
+import "time"
+
+// Now returns the current time. Tests replace it with Freeze.
+var Now = time.Now
+
+// Since returns the time elapsed since t, like time.Since but by Now
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Freeze makes Now return t, and returns a function that restores the
+// previous Now. It's meant for tests, which aren't run in parallel:
+//
+//	t.Cleanup(clock.Freeze(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)))
+func Freeze(t time.Time) (restore func()) {
+	previous := Now
+	Now = func() time.Time { return t }
+	return func() { Now = previous }
+}
@@ -0,0 +1,29 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	frozen := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	restore := Freeze(frozen)
+	if got := Now(); !got.Equal(frozen) {
+		t.Errorf("Now() = %v, want %v", got, frozen)
+	}
+	if got := Since(frozen.Add(-time.Hour)); got != time.Hour {
+		t.Errorf("Since() = %v, want %v", got, time.Hour)
+	}
+
+	// Freezing again and restoring goes back to the first frozen time
+	Freeze(frozen.Add(time.Hour))()
+	if got := Now(); !got.Equal(frozen) {
+		t.Errorf("Now() after a nested restore = %v, want %v", got, frozen)
+	}
+
+	restore()
+	if got := Now(); got.Equal(frozen) {
+		t.Errorf("Now() still frozen after restore")
+	}
+}
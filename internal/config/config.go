@@ -22,8 +22,11 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	configPath := filepath.Join(home, ".threadmine", "config")
+	return LoadFile(filepath.Join(home, ".threadmine", "config"))
+}
 
+// LoadFile reads the configuration file at configPath
+func LoadFile(configPath string) (*Config, error) {
 	// If config file doesn't exist, return empty config (not an error)
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return &Config{file: ini.Empty()}, nil
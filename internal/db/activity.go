@@ -0,0 +1,95 @@
+package db
+
+import "fmt"
+
+// ChannelActivity summarizes the messages and threads of a channel (a GitHub
+// repository, for GitHub messages)
+type ChannelActivity struct {
+	ChannelID       string  `json:"channel_id"`
+	ChannelName     string  `json:"channel_name"` // Empty if the channel wasn't stored
+	Messages        int     `json:"messages"`
+	Participants    int     `json:"participants"`     // Distinct authors
+	Questions       int     `json:"questions"`        // Messages enriched as questions
+	Threads         int     `json:"threads"`          // A message outside any thread is a thread of its own
+	ResolvedThreads int     `json:"resolved_threads"` // Threads their summary marks resolved
+	ResolutionRate  float64 `json:"resolution_rate"`  // ResolvedThreads / Threads
+	AvgThreadDepth  float64 `json:"avg_thread_depth"`
+}
+
+// ChannelActivity returns the activity of each channel with messages matching
+// the filters in opts, busiest first. Threads count in the channel of their
+// matching messages. A thread's depth comes from its summary; an unsummarized
+// thread has depth 1 if it has replies, 0 if not. Limit and Offset page
+// through the channels.
+func (db *DB) ChannelActivity(opts SelectMessagesOptions) ([]*ChannelActivity, error) {
+	filters, args, err := messageFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH scoped AS (
+			SELECT m.id, m.channel_id, m.author_id, COALESCE(m.thread_id, m.id) AS thread_key
+			FROM messages m` + filters + `
+		),
+		message_stats AS (
+			SELECT s.channel_id, COUNT(*) AS messages, COUNT(DISTINCT s.author_id) AS participants,
+			       COALESCE(SUM(q.is_question), 0) AS questions
+			FROM scoped s
+			LEFT JOIN enrichments q ON q.message_id = s.id
+			GROUP BY s.channel_id
+		),
+		thread_stats AS (
+			SELECT k.channel_id, COUNT(*) AS threads, COALESCE(SUM(t.is_resolved), 0) AS resolved,
+			       AVG(COALESCE(t.max_depth, MIN(k.messages - 1, 1))) AS depth
+			FROM (
+				SELECT channel_id, thread_key, COUNT(*) AS messages
+				FROM scoped
+				GROUP BY channel_id, thread_key
+			) k
+			LEFT JOIN threads t ON t.id = k.thread_key
+			GROUP BY k.channel_id
+		)
+		SELECT ms.channel_id, COALESCE(c.name, ''), ms.messages, ms.participants, ms.questions,
+		       ts.threads, ts.resolved, ts.depth
+		FROM message_stats ms
+		JOIN thread_stats ts ON ts.channel_id = ms.channel_id
+		LEFT JOIN channels c ON c.id = ms.channel_id
+		ORDER BY ms.messages DESC, ms.channel_id`
+
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	if opts.Offset > 0 {
+		if opts.Limit <= 0 {
+			query += " LIMIT -1"
+		}
+		query += " OFFSET ?"
+		args = append(args, opts.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize channel activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := []*ChannelActivity{}
+	for rows.Next() {
+		a := &ChannelActivity{}
+		if err := rows.Scan(&a.ChannelID, &a.ChannelName, &a.Messages, &a.Participants, &a.Questions,
+			&a.Threads, &a.ResolvedThreads, &a.AvgThreadDepth); err != nil {
+			return nil, fmt.Errorf("failed to scan channel activity: %w", err)
+		}
+		if a.Threads > 0 {
+			a.ResolutionRate = float64(a.ResolvedThreads) / float64(a.Threads)
+		}
+		activity = append(activity, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating channel activity: %w", err)
+	}
+
+	return activity, nil
+}
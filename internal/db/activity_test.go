@@ -0,0 +1,80 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChannelActivity(t *testing.T) {
+	database := openTestDB(t)
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	save := func(id, channelID, authorID string, threadID string, at time.Time) {
+		msg := &Message{ID: id, SourceType: "slack", SourceID: id, Timestamp: at, AuthorID: authorID, Content: "content",
+			ChannelID: channelID, NormalizedAt: at, SchemaVersion: "2.0"}
+		if threadID != "" {
+			msg.ThreadID = &threadID
+			msg.IsThreadRoot = threadID == id
+		}
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("failed to save message %s: %v", id, err)
+		}
+	}
+
+	// general: a resolved thread of depth 2 that starts with a question, and
+	// a message outside any thread
+	save("msg_q", "chan_general", "user_alice", "msg_q", base)
+	save("msg_q_1", "chan_general", "user_bob", "msg_q", base.Add(time.Minute))
+	save("msg_q_2", "chan_general", "user_carol", "msg_q", base.Add(2*time.Minute))
+	save("msg_solo", "chan_general", "user_alice", "", base.Add(time.Hour))
+	if err := database.SaveEnrichment(&Enrichment{MessageID: "msg_q", IsQuestion: true}); err != nil {
+		t.Fatalf("SaveEnrichment failed: %v", err)
+	}
+	if err := database.SaveThread(&Thread{ID: "msg_q", RootMessageID: "msg_q", ChannelID: "chan_general", ReplyCount: 2,
+		MaxDepth: 2, StartedAt: base, LastActivityAt: base.Add(2 * time.Minute), Resolved: true}); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+	now := time.Now()
+	if err := database.SaveChannel(&Channel{ID: "chan_general", SourceType: "slack", SourceID: "C1", Name: "general", FetchedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("SaveChannel failed: %v", err)
+	}
+
+	// random (not stored as a channel): an unsummarized thread with a reply
+	save("msg_r", "chan_random", "user_dave", "msg_r", base)
+	save("msg_r_1", "chan_random", "user_erin", "msg_r", base.Add(time.Minute))
+
+	// old: a message from long ago
+	save("msg_old", "chan_old", "user_alice", "", base.AddDate(-1, 0, 0))
+
+	general := &ChannelActivity{ChannelID: "chan_general", ChannelName: "general", Messages: 4, Participants: 3, Questions: 1,
+		Threads: 2, ResolvedThreads: 1, ResolutionRate: 0.5, AvgThreadDepth: 1}
+	random := &ChannelActivity{ChannelID: "chan_random", Messages: 2, Participants: 2, Threads: 1, AvgThreadDepth: 1}
+	old := &ChannelActivity{ChannelID: "chan_old", Messages: 1, Participants: 1, Threads: 1}
+
+	since := base.AddDate(0, -1, 0)
+	tests := []struct {
+		name string
+		opts SelectMessagesOptions
+		want []*ChannelActivity
+	}{
+		{"all", SelectMessagesOptions{}, []*ChannelActivity{general, random, old}},
+		{"since", SelectMessagesOptions{Since: &since}, []*ChannelActivity{general, random}},
+		{"limit", SelectMessagesOptions{Limit: 1}, []*ChannelActivity{general}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := database.ChannelActivity(tt.opts)
+			if err != nil {
+				t.Fatalf("ChannelActivity failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				for _, a := range got {
+					t.Logf("got %+v", a)
+				}
+				t.Errorf("unexpected activity")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 )
@@ -14,14 +15,18 @@ type Enrichment struct {
 	HasCode    bool
 	HasLinks   bool
 	HasQuotes  bool
+	IsCodeOnly bool   // Nothing but code (see normalize.IsCodeOnly)
+	IsLinkOnly bool   // Nothing but links (see normalize.IsLinkOnly)
+	Language   string // ISO 639-1 code, "" if undetermined
 	EnrichedAt time.Time
 }
 
 // SaveEnrichment saves message enrichment metadata
 func (db *DB) SaveEnrichment(enrich *Enrichment) error {
 	_, err := db.Exec(`
-		INSERT INTO enrichments (message_id, is_question, char_count, word_count, has_code, has_links, has_quotes)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO enrichments (message_id, is_question, char_count, word_count, has_code, has_links, has_quotes,
+		                         is_code_only, is_link_only, language)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(message_id) DO UPDATE SET
 			is_question = excluded.is_question,
 			char_count = excluded.char_count,
@@ -29,9 +34,12 @@ func (db *DB) SaveEnrichment(enrich *Enrichment) error {
 			has_code = excluded.has_code,
 			has_links = excluded.has_links,
 			has_quotes = excluded.has_quotes,
+			is_code_only = excluded.is_code_only,
+			is_link_only = excluded.is_link_only,
+			language = excluded.language,
 			enriched_at = CURRENT_TIMESTAMP
 	`, enrich.MessageID, enrich.IsQuestion, enrich.CharCount, enrich.WordCount,
-	   enrich.HasCode, enrich.HasLinks, enrich.HasQuotes)
+	   enrich.HasCode, enrich.HasLinks, enrich.HasQuotes, enrich.IsCodeOnly, enrich.IsLinkOnly, enrich.Language)
 
 	if err != nil {
 		return fmt.Errorf("failed to save enrichment: %w", err)
@@ -43,30 +51,33 @@ func (db *DB) SaveEnrichment(enrich *Enrichment) error {
 // GetEnrichment retrieves enrichment metadata for a message
 func (db *DB) GetEnrichment(messageID string) (*Enrichment, error) {
 	enrich := &Enrichment{}
+	var language sql.NullString
 
 	err := db.QueryRow(`
-		SELECT message_id, is_question, char_count, word_count, has_code, has_links, has_quotes, enriched_at
+		SELECT message_id, is_question, char_count, word_count, has_code, has_links, has_quotes,
+		       is_code_only, is_link_only, language, enriched_at
 		FROM enrichments
 		WHERE message_id = ?
 	`, messageID).Scan(&enrich.MessageID, &enrich.IsQuestion, &enrich.CharCount, &enrich.WordCount,
-		&enrich.HasCode, &enrich.HasLinks, &enrich.HasQuotes, &enrich.EnrichedAt)
+		&enrich.HasCode, &enrich.HasLinks, &enrich.HasQuotes, &enrich.IsCodeOnly, &enrich.IsLinkOnly, &language, &enrich.EnrichedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to query enrichment: %w", err)
 	}
+	enrich.Language = language.String
 
 	return enrich, nil
 }
 
 // Entity represents an extracted entity
 type Entity struct {
-	ID        int64
-	MessageID string
-	Type      string
-	Value     string
-	StartPos  *int
-	EndPos    *int
-	Metadata  *string
+	ID        int64   `json:"id"`
+	MessageID string  `json:"message_id"`
+	Type      string  `json:"type"`
+	Value     string  `json:"value"`
+	StartPos  *int    `json:"start_pos,omitempty"`
+	EndPos    *int    `json:"end_pos,omitempty"`
+	Metadata  *string `json:"metadata,omitempty"`
 }
 
 // SaveEntity saves an extracted entity
@@ -88,6 +99,49 @@ func (db *DB) SaveEntity(entity *Entity) error {
 	return nil
 }
 
+// Entity types for users attached to a GitHub issue or PR root message
+const (
+	EntityTypeAssignee          = "assignee"
+	EntityTypeRequestedReviewer = "requested_reviewer"
+)
+
+// Entity types for the files a GitHub PR changes, and their directories
+// ("auth/", "auth/oauth/"), attached to the PR's root message
+const (
+	EntityTypeFilePath  = "file_path"
+	EntityTypeDirectory = "directory"
+)
+
+// ReplaceEntities replaces all entities of one type for a message with the given values.
+// Use this for entities that reflect current state (e.g. assignees) so refetching
+// a message doesn't accumulate stale or duplicate rows.
+func (db *DB) ReplaceEntities(messageID, entityType string, values []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM entities WHERE message_id = ? AND type = ?`, messageID, entityType); err != nil {
+		return fmt.Errorf("failed to delete entities: %w", err)
+	}
+
+	for _, value := range values {
+		if _, err := tx.Exec(`
+			INSERT INTO entities (message_id, type, value)
+			VALUES (?, ?, ?)
+		`, messageID, entityType, value); err != nil {
+			return fmt.Errorf("failed to save entity: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit entities: %w", err)
+	}
+
+	return nil
+}
+
 // GetEntities retrieves all entities for a message
 func (db *DB) GetEntities(messageID string) ([]*Entity, error) {
 	rows, err := db.Query(`
@@ -118,6 +172,11 @@ func (db *DB) GetEntities(messageID string) ([]*Entity, error) {
 	return entities, nil
 }
 
+// RelationDuplicateOf is the relation type linking a copy of a message
+// cross-posted to several channels to the first copy (see
+// classify.RelationDuplicateOf)
+const RelationDuplicateOf = "duplicate_of"
+
 // MessageRelation represents a relationship between messages
 type MessageRelation struct {
 	FromMessageID string
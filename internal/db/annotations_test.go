@@ -0,0 +1,228 @@
+package db
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestReplaceEntities(t *testing.T) {
+	database := openTestDB(t)
+
+	rootID := "msg_github_owner_repo_1"
+	saveTestMessage(t, database, rootID, "user_github_alice", "Issue body", &rootID)
+
+	tests := []struct {
+		name     string
+		values   []string
+		expected []string
+	}{
+		{"no assignees", nil, nil},
+		{"multiple assignees", []string{"user_github_alice", "user_github_bob"}, []string{"user_github_alice", "user_github_bob"}},
+		{"refetch replaces previous assignees", []string{"user_github_carol"}, []string{"user_github_carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := database.ReplaceEntities(rootID, EntityTypeAssignee, tt.values); err != nil {
+				t.Fatalf("ReplaceEntities failed: %v", err)
+			}
+
+			entities, err := database.GetEntities(rootID)
+			if err != nil {
+				t.Fatalf("GetEntities failed: %v", err)
+			}
+
+			var values []string
+			for _, e := range entities {
+				if e.Type == EntityTypeAssignee {
+					values = append(values, e.Value)
+				}
+			}
+			if len(values) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, values)
+			}
+			for i := range values {
+				if values[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, values)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceEntities_KeepsOtherTypes(t *testing.T) {
+	database := openTestDB(t)
+
+	rootID := "msg_github_owner_repo_2"
+	saveTestMessage(t, database, rootID, "user_github_alice", "PR body", &rootID)
+
+	if err := database.ReplaceEntities(rootID, EntityTypeAssignee, []string{"user_github_alice"}); err != nil {
+		t.Fatalf("ReplaceEntities failed: %v", err)
+	}
+	if err := database.ReplaceEntities(rootID, EntityTypeRequestedReviewer, []string{"user_github_bob", "user_github_carol"}); err != nil {
+		t.Fatalf("ReplaceEntities failed: %v", err)
+	}
+
+	entities, err := database.GetEntities(rootID)
+	if err != nil {
+		t.Fatalf("GetEntities failed: %v", err)
+	}
+	if len(entities) != 3 {
+		t.Errorf("expected 3 entities, got %d", len(entities))
+	}
+}
+
+func TestSelectMessages_Assignee(t *testing.T) {
+	database := openTestDB(t)
+
+	assignedRoot := "msg_github_owner_repo_10"
+	otherRoot := "msg_github_owner_repo_11"
+	saveTestMessage(t, database, assignedRoot, "user_github_alice", "Assigned issue", &assignedRoot)
+	saveTestMessage(t, database, assignedRoot+"_comment_1", "user_github_bob", "A reply", &assignedRoot)
+	saveTestMessage(t, database, otherRoot, "user_github_alice", "Unassigned issue", &otherRoot)
+
+	if err := database.ReplaceEntities(assignedRoot, EntityTypeAssignee, []string{"user_github_carol"}); err != nil {
+		t.Fatalf("ReplaceEntities failed: %v", err)
+	}
+
+	assignee := "user_github_carol"
+	messages, err := database.SelectMessages(SelectMessagesOptions{AssigneeID: &assignee})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages in the assigned thread, got %d", len(messages))
+	}
+	for _, m := range messages {
+		if m.ThreadID == nil || *m.ThreadID != assignedRoot {
+			t.Errorf("unexpected message %s from another thread", m.ID)
+		}
+	}
+
+	nobody := "user_github_nobody"
+	messages, err = database.SelectMessages(SelectMessagesOptions{AssigneeID: &nobody})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected no messages, got %d", len(messages))
+	}
+}
+
+func TestSelectMessages_Language(t *testing.T) {
+	database := openTestDB(t)
+
+	languages := map[string]string{"msg_en": "en", "msg_es": "es", "msg_unknown": ""}
+	for id, lang := range languages {
+		saveTestMessage(t, database, id, "user_github_alice", "content of "+id, nil)
+		if err := database.SaveEnrichment(&Enrichment{MessageID: id, Language: lang}); err != nil {
+			t.Fatalf("SaveEnrichment failed: %v", err)
+		}
+	}
+	saveTestMessage(t, database, "msg_unenriched", "user_github_alice", "not enriched", nil)
+
+	spanish := "es"
+	messages, err := database.SelectMessages(SelectMessagesOptions{Language: &spanish})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != "msg_es" {
+		t.Errorf("expected only msg_es, got %v", messages)
+	}
+
+	if enrich, err := database.GetEnrichment("msg_en"); err != nil || enrich.Language != "en" {
+		t.Errorf("GetEnrichment(msg_en) = %+v, %v; want language en", enrich, err)
+	}
+}
+
+func TestOpen_MigratesV2EnrichmentLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	database := openTestDBAt(t, path)
+
+	// A database created before enrichments had a language, with an old enrichment
+	saveTestMessage(t, database, "msg_old", "user_github_alice", "old message", nil)
+	downgradeToV2(t, database)
+	if _, err := database.conn.Exec(`INSERT INTO enrichments (message_id, char_count, word_count) VALUES ('msg_old', 11, 2)`); err != nil {
+		t.Fatalf("failed to save old enrichment: %v", err)
+	}
+	database.Close()
+
+	database = openTestDBAt(t, path)
+	if enrich, err := database.GetEnrichment("msg_old"); err != nil || enrich.Language != "" {
+		t.Errorf("GetEnrichment(msg_old) = %+v, %v; want no language", enrich, err)
+	}
+	if err := database.SaveEnrichment(&Enrichment{MessageID: "msg_old", Language: "en"}); err != nil {
+		t.Fatalf("SaveEnrichment failed: %v", err)
+	}
+	if enrich, err := database.GetEnrichment("msg_old"); err != nil || enrich.Language != "en" {
+		t.Errorf("GetEnrichment(msg_old) = %+v, %v; want language en", enrich, err)
+	}
+}
+
+func TestSelectMessages_CodeAndLinkOnly(t *testing.T) {
+	database := openTestDB(t)
+
+	enrichments := []*Enrichment{
+		{MessageID: "msg_paste", HasCode: true, IsCodeOnly: true},
+		{MessageID: "msg_links", HasLinks: true, IsLinkOnly: true},
+		{MessageID: "msg_mixed", HasCode: true, HasLinks: true},
+	}
+	for _, enrich := range enrichments {
+		saveTestMessage(t, database, enrich.MessageID, "user_github_alice", "content of "+enrich.MessageID, nil)
+		if err := database.SaveEnrichment(enrich); err != nil {
+			t.Fatalf("SaveEnrichment failed: %v", err)
+		}
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name string
+		opts SelectMessagesOptions
+		want []string
+	}{
+		{"code only", SelectMessagesOptions{IsCodeOnly: &yes}, []string{"msg_paste"}},
+		{"link only", SelectMessagesOptions{IsLinkOnly: &yes}, []string{"msg_links"}},
+		{"no pastes", SelectMessagesOptions{IsCodeOnly: &no, IsLinkOnly: &no}, []string{"msg_mixed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := database.SelectMessages(tt.opts)
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			var got []string
+			for _, msg := range messages {
+				got = append(got, msg.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if enrich, err := database.GetEnrichment("msg_paste"); err != nil || !enrich.IsCodeOnly || enrich.IsLinkOnly {
+		t.Errorf("GetEnrichment(msg_paste) = %+v, %v; want code only", enrich, err)
+	}
+}
+
+func TestOpen_MigratesV2EnrichmentCodeAndLinkOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	database := openTestDBAt(t, path)
+
+	// A database created before enrichments flagged pastes
+	saveTestMessage(t, database, "msg_old", "user_github_alice", "old message", nil)
+	downgradeToV2(t, database)
+	database.Close()
+
+	database = openTestDBAt(t, path)
+	if err := database.SaveEnrichment(&Enrichment{MessageID: "msg_old", IsLinkOnly: true}); err != nil {
+		t.Fatalf("SaveEnrichment failed: %v", err)
+	}
+	if enrich, err := database.GetEnrichment("msg_old"); err != nil || enrich.IsCodeOnly || !enrich.IsLinkOnly {
+		t.Errorf("GetEnrichment(msg_old) = %+v, %v; want link only", enrich, err)
+	}
+}
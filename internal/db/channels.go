@@ -22,22 +22,26 @@ type Channel struct {
 	UpdatedAt   time.Time
 }
 
+// upsertChannelSQL inserts or updates a channel. Optional fields the new
+// record lacks keep their stored value.
+const upsertChannelSQL = `
+	INSERT INTO channels (
+		id, source_type, source_id, workspace_id, name, display_name, type,
+		is_private, parent_space, metadata
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(source_type, source_id, workspace_id) DO UPDATE SET
+		name = excluded.name,
+		display_name = COALESCE(excluded.display_name, channels.display_name),
+		type = COALESCE(excluded.type, channels.type),
+		is_private = excluded.is_private,
+		parent_space = COALESCE(excluded.parent_space, channels.parent_space),
+		metadata = COALESCE(excluded.metadata, channels.metadata),
+		updated_at = CURRENT_TIMESTAMP
+`
+
 // SaveChannel saves or updates a channel
 func (db *DB) SaveChannel(channel *Channel) error {
-	_, err := db.Exec(`
-		INSERT INTO channels (
-			id, source_type, source_id, workspace_id, name, display_name, type,
-			is_private, parent_space, metadata
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(source_type, source_id, workspace_id) DO UPDATE SET
-			name = excluded.name,
-			display_name = excluded.display_name,
-			type = excluded.type,
-			is_private = excluded.is_private,
-			parent_space = excluded.parent_space,
-			metadata = excluded.metadata,
-			updated_at = CURRENT_TIMESTAMP
-	`, channel.ID, channel.SourceType, channel.SourceID, channel.WorkspaceID,
+	_, err := db.Exec(upsertChannelSQL, channel.ID, channel.SourceType, channel.SourceID, channel.WorkspaceID,
 		channel.Name, channel.DisplayName, channel.Type, channel.IsPrivate,
 		channel.ParentSpace, channel.Metadata)
 
@@ -48,6 +52,29 @@ func (db *DB) SaveChannel(channel *Channel) error {
 	return nil
 }
 
+// SaveChannels saves or updates channels in a single transaction
+func (db *DB) SaveChannels(channels []*Channel) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, channel := range channels {
+		if _, err := tx.Exec(upsertChannelSQL, channel.ID, channel.SourceType, channel.SourceID, channel.WorkspaceID,
+			channel.Name, channel.DisplayName, channel.Type, channel.IsPrivate,
+			channel.ParentSpace, channel.Metadata); err != nil {
+			return fmt.Errorf("failed to save channel %s: %w", channel.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit channels: %w", err)
+	}
+
+	return nil
+}
+
 // GetChannel retrieves a channel by ID
 func (db *DB) GetChannel(id string) (*Channel, error) {
 	channel := &Channel{}
@@ -168,14 +195,46 @@ func (db *DB) GetWorkspacesBySource(sourceType string) ([]*Workspace, error) {
 	return workspaces, nil
 }
 
-// FindChannelsByName finds channels by name, display name, or source ID
-func (db *DB) FindChannelsByName(name string) ([]*Channel, error) {
+// AuthenticatedUserIDs returns the IDs of the users fetches authenticated as
+// in every workspace, the "me" of each source
+func (db *DB) AuthenticatedUserIDs() ([]string, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT authenticated_user_id
+		FROM workspaces
+		WHERE authenticated_user_id IS NOT NULL AND authenticated_user_id != ''
+		ORDER BY authenticated_user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authenticated users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan authenticated user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating authenticated users: %w", err)
+	}
+
+	return ids, nil
+}
+
+// FindChannelsByName finds channels by name, display name, or source ID.
+// Unless caseSensitive, names match regardless of (ASCII) case, and channels
+// whose name matches with the same case come first.
+func (db *DB) FindChannelsByName(name string, caseSensitive bool) ([]*Channel, error) {
 	rows, err := db.Query(`
 		SELECT id, source_type, source_id, workspace_id, name, display_name, type,
 		       is_private, parent_space, metadata, fetched_at, updated_at
 		FROM channels
-		WHERE name = ? OR display_name = ? OR source_id = ?
-	`, name, name, name)
+		WHERE `+nameMatchClause(caseSensitive, "name", "display_name", "source_id"),
+		nameMatchArgs(name, caseSensitive, 3)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query channels by name: %w", err)
 	}
@@ -0,0 +1,60 @@
+package db
+
+import "strings"
+
+// Channel types for SelectMessagesOptions.ChannelTypes. Slack messages have
+// the type of their channel. GitHub channels are whole repositories, so GitHub
+// messages have the type of the conversation they belong to instead.
+const (
+	ChannelTypeChannel    = "channel"    // Slack channel
+	ChannelTypeDM         = "dm"         // Slack direct message
+	ChannelTypeIssue      = "issue"      // GitHub issue
+	ChannelTypePR         = "pr"         // GitHub pull request
+	ChannelTypeDiscussion = "discussion" // GitHub discussion
+	ChannelTypeCommit     = "commit"     // GitHub commit comments
+)
+
+// ChannelTypes lists the channel types messages can be filtered by
+var ChannelTypes = []string{
+	ChannelTypeChannel,
+	ChannelTypeDM,
+	ChannelTypeIssue,
+	ChannelTypePR,
+	ChannelTypeDiscussion,
+	ChannelTypeCommit,
+}
+
+// IsChannelType reports whether t is one of ChannelTypes
+func IsChannelType(t string) bool {
+	for _, known := range ChannelTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// messageChannelType is the SQL expression for the channel type of message m.
+// GitHub conversations are told apart by source ID (discussions/3, @sha,
+// #12-review-...), and pull requests from issues by the pull_request link in
+// the raw data of the thread root.
+const messageChannelType = `CASE
+		WHEN m.source_type != 'github' THEN (SELECT c.type FROM channels c WHERE c.id = m.channel_id)
+		WHEN m.source_id LIKE '%/discussions/%' THEN 'discussion'
+		WHEN m.source_id LIKE '%@%' THEN 'commit'
+		WHEN m.source_id LIKE '%-review-%' OR EXISTS (
+			SELECT 1 FROM raw_messages r
+			WHERE r.id = COALESCE(m.thread_id, m.id) AND json_extract(r.raw_data, '$.pull_request') IS NOT NULL
+		) THEN 'pr'
+		ELSE 'issue'
+	END`
+
+// channelTypeFilterClause returns the WHERE condition matching messages of any
+// of types, and its arguments
+func channelTypeFilterClause(types []string) (string, []interface{}) {
+	args := make([]interface{}, len(types))
+	for i, t := range types {
+		args[i] = strings.TrimSpace(t)
+	}
+	return " AND (" + messageChannelType + ") IN (" + placeholders(len(types)) + ")", args
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectMessages_ChannelTypes(t *testing.T) {
+	database := openTestDB(t)
+
+	now := time.Now()
+	for id, typ := range map[string]string{"chan_slack_C1": ChannelTypeChannel, "chan_slack_D1": ChannelTypeDM} {
+		typ := typ
+		if err := database.SaveChannel(&Channel{ID: id, SourceType: "slack", SourceID: id, Name: id, Type: &typ, FetchedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to save channel: %v", err)
+		}
+	}
+
+	save := func(msg *Message) {
+		msg.Timestamp = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		msg.AuthorID = "user_a"
+		msg.Content = "content"
+		msg.NormalizedAt = now
+		msg.SchemaVersion = "2.0"
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("failed to save message %s: %v", msg.ID, err)
+		}
+	}
+	saveSlack := func(id, channelID string) {
+		save(&Message{ID: id, SourceType: "slack", SourceID: id, ChannelID: channelID})
+	}
+	saveSlack("msg_slack_C1_1", "chan_slack_C1")
+	saveSlack("msg_slack_D1_1", "chan_slack_D1")
+
+	// saveGitHub saves a GitHub message under its source ID, with raw data
+	// for the thread roots
+	saveGitHub := func(id, sourceID, threadID, rawData string) {
+		save(&Message{ID: id, SourceType: "github", SourceID: sourceID, ChannelID: "chan_github_owner_repo", ThreadID: &threadID})
+		if rawData != "" {
+			if err := database.SaveRawMessage(id, "github", sourceID, "org_owner", "chan_github_owner_repo", rawData, ""); err != nil {
+				t.Fatalf("failed to save raw message: %v", err)
+			}
+		}
+	}
+	saveGitHub("msg_issue", "owner/repo#1", "msg_issue", `{"number": 1}`)
+	saveGitHub("msg_issue_comment", "owner/repo#1-comment-10", "msg_issue", "")
+	saveGitHub("msg_pr", "owner/repo#2", "msg_pr", `{"number": 2, "pull_request": {"url": "https://api.github.com/repos/owner/repo/pulls/2"}}`)
+	saveGitHub("msg_pr_comment", "owner/repo#2-comment-20", "msg_pr", "")
+	// Review comments are on pull requests even if the PR itself wasn't stored
+	saveGitHub("msg_review_comment", "owner/repo#3-review-comment-30", "msg_unstored_pr", "")
+	saveGitHub("msg_review", "owner/repo#3-review-31", "msg_unstored_pr", "")
+	saveGitHub("msg_discussion", "owner/repo/discussions/4", "msg_discussion", `{"number": 4}`)
+	saveGitHub("msg_discussion_comment", "owner/repo/discussions/4#DC_1", "msg_discussion", "")
+	saveGitHub("msg_commit_comment", "owner/repo@abc123-comment-50", "msg_commit", "")
+
+	tests := []struct {
+		name  string
+		types []string
+		want  []string
+	}{
+		{name: "slack channel", types: []string{ChannelTypeChannel}, want: []string{"msg_slack_C1_1"}},
+		{name: "slack dm", types: []string{ChannelTypeDM}, want: []string{"msg_slack_D1_1"}},
+		{name: "issue", types: []string{ChannelTypeIssue}, want: []string{"msg_issue", "msg_issue_comment"}},
+		{name: "pr", types: []string{ChannelTypePR}, want: []string{"msg_pr", "msg_pr_comment", "msg_review_comment", "msg_review"}},
+		{name: "discussion", types: []string{ChannelTypeDiscussion}, want: []string{"msg_discussion", "msg_discussion_comment"}},
+		{name: "commit", types: []string{ChannelTypeCommit}, want: []string{"msg_commit_comment"}},
+		{name: "several types", types: []string{ChannelTypeIssue, ChannelTypeDM}, want: []string{"msg_issue", "msg_issue_comment", "msg_slack_D1_1"}},
+		{name: "unknown type", types: []string{"ticket"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SelectMessagesOptions{ChannelTypes: tt.types}
+			messages, err := database.SelectMessages(opts)
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+
+			got := make(map[string]bool)
+			for _, m := range messages {
+				got[m.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("expected %s in %v", id, got)
+				}
+			}
+
+			counts, err := database.CountMessages(opts, CountBySource)
+			if err != nil {
+				t.Fatalf("CountMessages failed: %v", err)
+			}
+			total := 0
+			for _, c := range counts {
+				total += c.Count
+			}
+			if total != len(tt.want) {
+				t.Errorf("expected counts to honor the filter (%d), got %d", len(tt.want), total)
+			}
+		})
+	}
+}
+
+func TestIsChannelType(t *testing.T) {
+	for _, typ := range ChannelTypes {
+		if !IsChannelType(typ) {
+			t.Errorf("IsChannelType(%q) = false, want true", typ)
+		}
+	}
+	for _, typ := range []string{"", "repository", "PR", "ticket"} {
+		if IsChannelType(typ) {
+			t.Errorf("IsChannelType(%q) = true, want false", typ)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"fmt"
+)
+
+// Dimensions CountMessages can group by
+const (
+	CountByAuthor  = "author"
+	CountByChannel = "channel"
+	CountBySource  = "source"
+	CountByDay     = "day" // UTC calendar date, YYYY-MM-DD
+)
+
+// countByColumns maps each dimension to the expression it groups on
+var countByColumns = map[string]string{
+	CountByAuthor:  "m.author_id",
+	CountByChannel: "m.channel_id",
+	CountBySource:  "m.source_type",
+	CountByDay:     "date(m.timestamp)",
+}
+
+// MessageCount is the number of messages sharing a value of a dimension
+type MessageCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// CountMessages counts the messages matching the filters in opts, grouped by
+// groupBy. Days are returned in date order; other groups are returned largest
+// first. Limit and Offset page through the groups rather than the messages.
+func (db *DB) CountMessages(opts SelectMessagesOptions, groupBy string) ([]MessageCount, error) {
+	column, ok := countByColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown count dimension: %s", groupBy)
+	}
+
+	query := "SELECT " + column + " AS key, COUNT(*) AS count FROM messages m"
+	filters, args, err := messageFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+	query += filters
+	query += " GROUP BY key"
+
+	if groupBy == CountByDay {
+		query += " ORDER BY key"
+	} else {
+		query += " ORDER BY count DESC, key"
+	}
+
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	if opts.Offset > 0 {
+		if opts.Limit <= 0 {
+			query += " LIMIT -1"
+		}
+		query += " OFFSET ?"
+		args = append(args, opts.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []MessageCount{}
+	for rows.Next() {
+		var c MessageCount
+		if err := rows.Scan(&c.Key, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// PageCounts returns the page of counts selected by limit and offset, like
+// LIMIT and OFFSET in SQL. A limit of zero or less means no limit.
+func PageCounts(counts []MessageCount, limit, offset int) []MessageCount {
+	if offset > 0 {
+		if offset >= len(counts) {
+			return []MessageCount{}
+		}
+		counts = counts[offset:]
+	}
+	if limit > 0 && len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCountMessages(t *testing.T) {
+	database := openTestDB(t)
+
+	messages := []struct {
+		id      string
+		source  string
+		author  string
+		channel string
+		ts      time.Time
+	}{
+		{"msg_1", "slack", "user_alice", "chan_general", time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{"msg_2", "slack", "user_bob", "chan_general", time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC)},
+		{"msg_3", "slack", "user_alice", "chan_random", time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"msg_4", "github", "user_alice", "chan_repo", time.Date(2024, 1, 17, 8, 0, 0, 0, time.UTC)},
+		{"msg_5", "github", "user_carol", "chan_repo", time.Date(2024, 1, 17, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, m := range messages {
+		msg := &Message{
+			ID:            m.id,
+			SourceType:    m.source,
+			SourceID:      m.id,
+			Timestamp:     m.ts,
+			AuthorID:      m.author,
+			Content:       "message " + m.id,
+			ChannelID:     m.channel,
+			Mentions:      []string{},
+			URLs:          []string{},
+			CodeBlocks:    []CodeBlock{},
+			Attachments:   []Attachment{},
+			NormalizedAt:  time.Now(),
+			SchemaVersion: "2.0",
+		}
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("failed to save message: %v", err)
+		}
+	}
+
+	slack := "slack"
+	since := time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		groupBy string
+		opts    SelectMessagesOptions
+		want    string
+	}{
+		{"author", CountByAuthor, SelectMessagesOptions{}, "[user_alice:3 user_bob:1 user_carol:1]"},
+		{"channel", CountByChannel, SelectMessagesOptions{}, "[chan_general:2 chan_repo:2 chan_random:1]"},
+		{"source", CountBySource, SelectMessagesOptions{}, "[slack:3 github:2]"},
+		{"day in date order", CountByDay, SelectMessagesOptions{}, "[2024-01-15:2 2024-01-16:1 2024-01-17:2]"},
+		{"author with source filter", CountByAuthor, SelectMessagesOptions{SourceType: &slack}, "[user_alice:2 user_bob:1]"},
+		{"day with since filter", CountByDay, SelectMessagesOptions{Since: &since}, "[2024-01-16:1 2024-01-17:2]"},
+		{"channel with exclusion", CountByChannel, SelectMessagesOptions{ExcludeAuthorIDs: []string{"user_alice"}}, "[chan_general:1 chan_repo:1]"},
+		{"limit pages groups", CountByAuthor, SelectMessagesOptions{Limit: 1}, "[user_alice:3]"},
+		{"offset without limit", CountByAuthor, SelectMessagesOptions{Offset: 1}, "[user_bob:1 user_carol:1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, err := database.CountMessages(tt.opts, tt.groupBy)
+			if err != nil {
+				t.Fatalf("CountMessages failed: %v", err)
+			}
+			if got := formatCounts(counts); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := database.CountMessages(SelectMessagesOptions{}, "mood"); err == nil {
+		t.Error("expected error for unknown dimension")
+	}
+}
+
+func TestPageCounts(t *testing.T) {
+	counts := []MessageCount{{"a", 3}, {"b", 2}, {"c", 1}}
+
+	tests := []struct {
+		limit, offset int
+		want          string
+	}{
+		{0, 0, "[a:3 b:2 c:1]"},
+		{2, 0, "[a:3 b:2]"},
+		{2, 2, "[c:1]"},
+		{0, 5, "[]"},
+	}
+	for _, tt := range tests {
+		if got := formatCounts(PageCounts(counts, tt.limit, tt.offset)); got != tt.want {
+			t.Errorf("PageCounts(limit=%d, offset=%d) = %s, want %s", tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+// formatCounts renders counts compactly for comparison
+func formatCounts(counts []MessageCount) string {
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s:%d", c.Key, c.Count)
+	}
+	return fmt.Sprint(parts)
+}
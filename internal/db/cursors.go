@@ -0,0 +1,59 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FetchCursor is the saved progress of a paginated history backfill
+type FetchCursor struct {
+	SourceType  string
+	WorkspaceID string
+	ChannelID   string
+	OldestTS    string
+	Complete    bool
+	UpdatedAt   time.Time
+}
+
+// GetFetchCursor retrieves the backfill progress for a channel.
+// Returns nil if the channel has not been backfilled.
+func (db *DB) GetFetchCursor(sourceType, workspaceID, channelID string) (*FetchCursor, error) {
+	cursor := &FetchCursor{}
+
+	err := db.QueryRow(`
+		SELECT source_type, workspace_id, channel_id, oldest_ts, complete, updated_at
+		FROM fetch_cursors
+		WHERE source_type = ? AND workspace_id = ? AND channel_id = ?
+	`, sourceType, workspaceID, channelID).Scan(
+		&cursor.SourceType, &cursor.WorkspaceID, &cursor.ChannelID,
+		&cursor.OldestTS, &cursor.Complete, &cursor.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fetch cursor: %w", err)
+	}
+
+	return cursor, nil
+}
+
+// SaveFetchCursor saves (upserts) the backfill progress for a channel
+func (db *DB) SaveFetchCursor(cursor *FetchCursor) error {
+	_, err := db.Exec(`
+		INSERT INTO fetch_cursors (source_type, workspace_id, channel_id, oldest_ts, complete)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(source_type, workspace_id, channel_id) DO UPDATE SET
+			oldest_ts = excluded.oldest_ts,
+			complete = excluded.complete,
+			updated_at = CURRENT_TIMESTAMP
+	`, cursor.SourceType, cursor.WorkspaceID, cursor.ChannelID, cursor.OldestTS, cursor.Complete)
+
+	if err != nil {
+		return fmt.Errorf("failed to save fetch cursor: %w", err)
+	}
+
+	return nil
+}
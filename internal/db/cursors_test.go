@@ -0,0 +1,62 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchCursor_SaveAndGet(t *testing.T) {
+	database := openTestDB(t)
+
+	cursor, err := database.GetFetchCursor("slack", "ws_slack_T1", "C1")
+	if err != nil {
+		t.Fatalf("GetFetchCursor failed: %v", err)
+	}
+	if cursor != nil {
+		t.Fatalf("expected no cursor before backfill, got %+v", cursor)
+	}
+
+	steps := []FetchCursor{
+		{OldestTS: "1700000300.000100"},
+		{OldestTS: "1700000100.000100"},
+		{OldestTS: "1700000000.000100", Complete: true},
+	}
+	for _, step := range steps {
+		step.SourceType, step.WorkspaceID, step.ChannelID = "slack", "ws_slack_T1", "C1"
+		if err := database.SaveFetchCursor(&step); err != nil {
+			t.Fatalf("SaveFetchCursor failed: %v", err)
+		}
+
+		got, err := database.GetFetchCursor("slack", "ws_slack_T1", "C1")
+		if err != nil {
+			t.Fatalf("GetFetchCursor failed: %v", err)
+		}
+		if got == nil || got.OldestTS != step.OldestTS || got.Complete != step.Complete {
+			t.Errorf("expected cursor %s complete=%v, got %+v", step.OldestTS, step.Complete, got)
+		}
+	}
+
+	// Cursors are kept per channel
+	other, err := database.GetFetchCursor("slack", "ws_slack_T1", "C2")
+	if err != nil {
+		t.Fatalf("GetFetchCursor failed: %v", err)
+	}
+	if other != nil {
+		t.Errorf("expected no cursor for another channel, got %+v", other)
+	}
+}
+
+func TestFetchCursor_ExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	database := openTestDBAt(t, path)
+
+	// A database created before fetch_cursors existed
+	downgradeToV2(t, database)
+	database.Close()
+
+	reopened := openTestDBAt(t, path)
+	cursor := &FetchCursor{SourceType: "slack", WorkspaceID: "ws_slack_T1", ChannelID: "C1", OldestTS: "1700000000.000100"}
+	if err := reopened.SaveFetchCursor(cursor); err != nil {
+		t.Fatalf("expected fetch_cursors to be created by the migration: %v", err)
+	}
+}
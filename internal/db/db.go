@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -14,12 +15,15 @@ import (
 //go:embed schema.sql
 var schemaSQL string
 
-const SchemaVersion = 2
+const SchemaVersion = 3
 
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
 	path string
+
+	busyRetries int
+	busyBackoff time.Duration
 }
 
 // Open opens or creates the ThreadMine database at the given path
@@ -31,7 +35,7 @@ func Open(dbPath string) (*DB, error) {
 	}
 
 	// Open database
-	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_timeout=5000", dbPath))
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_timeout=%d", dbPath, BusyTimeout.Milliseconds()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -42,8 +46,10 @@ func Open(dbPath string) (*DB, error) {
 	conn.SetConnMaxLifetime(time.Hour)
 
 	db := &DB{
-		conn: conn,
-		path: dbPath,
+		conn:        conn,
+		path:        dbPath,
+		busyRetries: BusyRetries,
+		busyBackoff: defaultBusyBackoff,
 	}
 
 	// Initialize schema if needed
@@ -63,51 +69,123 @@ func (db *DB) Close() error {
 	return nil
 }
 
-// initSchema initializes the database schema if not already present
+// initSchema initializes the database schema if not already present. A
+// database locked by another connection (another mine process initializing
+// it, say) is retried like any busy statement.
 func (db *DB) initSchema() error {
-	// Check current schema version
-	var currentVersion int
-	err := db.conn.QueryRow("SELECT version FROM schema_version ORDER BY version DESC LIMIT 1").Scan(&currentVersion)
+	if err := db.withBusyRetry(db.initSchemaOnce); err != nil {
+		return err
+	}
 
-	// If table doesn't exist or is empty, create schema
-	if err == sql.ErrNoRows || (err != nil && (err.Error() == "no such table: schema_version" || err.Error() == "SQL logic error: no such table: schema_version")) {
-		if _, err := db.conn.Exec(schemaSQL); err != nil {
-			return fmt.Errorf("failed to execute schema: %w", err)
-		}
-		return nil
+	// A database without a schema version would be initialized again, and fail
+	currentVersion, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if currentVersion == 0 {
+		return fmt.Errorf("schema initialization incomplete: no schema_version row")
 	}
+	return nil
+}
 
-	// If other error, fail
+// initSchemaOnce creates the schema if the database has none, or migrates it
+// to the current version, and creates tables added since
+func (db *DB) initSchemaOnce() error {
+	currentVersion, err := db.schemaVersion()
 	if err != nil {
-		return fmt.Errorf("failed to check schema version: %w", err)
+		return err
 	}
 
-	// Check if migration is needed
+	if currentVersion == 0 {
+		if err := db.createSchema(); err != nil {
+			return err
+		}
+		return db.ensureFTSTokenizer()
+	}
+
+	// Bring older databases up to date; see migrate.go
 	if currentVersion < SchemaVersion {
-		return fmt.Errorf("schema migration needed from version %d to %d (not implemented)", currentVersion, SchemaVersion)
+		if err := db.migrate(currentVersion); err != nil {
+			return err
+		}
 	}
 
+	return db.ensureFTSTokenizer()
+}
+
+// schemaVersion returns the database's schema version, or 0 if it has no schema yet
+func (db *DB) schemaVersion() (int, error) {
+	var currentVersion int
+	err := db.conn.QueryRow("SELECT version FROM schema_version ORDER BY version DESC LIMIT 1").Scan(&currentVersion)
+	if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no such table: schema_version")) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to check schema version: %w", err)
+	}
+	return currentVersion, nil
+}
+
+// createSchema runs schema.sql in one transaction, so a failure part way
+// (a locked database, a full disk) leaves no half-created schema behind
+func (db *DB) createSchema() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin schema transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(schemaSQL); err != nil {
+		return fmt.Errorf("failed to execute schema: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schema: %w", err)
+	}
 	return nil
 }
 
 // Begin starts a new transaction
 func (db *DB) Begin() (*sql.Tx, error) {
-	return db.conn.Begin()
+	var tx *sql.Tx
+	err := db.withBusyRetry(func() error {
+		var err error
+		tx, err = db.conn.Begin()
+		return err
+	})
+	return tx, err
 }
 
-// Exec executes a query without returning rows
+// Exec executes a query without returning rows, retrying if the database is busy
 func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return db.conn.Exec(query, args...)
+	var result sql.Result
+	err := db.withBusyRetry(func() error {
+		var err error
+		result, err = db.conn.Exec(query, args...)
+		return err
+	})
+	return result, err
 }
 
-// Query executes a query that returns rows
+// Query executes a query that returns rows, retrying if the database is busy
 func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return db.conn.Query(query, args...)
+	var rows *sql.Rows
+	err := db.withBusyRetry(func() error {
+		var err error
+		rows, err = db.conn.Query(query, args...)
+		return err
+	})
+	return rows, err
 }
 
-// QueryRow executes a query that returns at most one row
+// QueryRow executes a query that returns at most one row, retrying if the database is busy.
+// Errors that only surface during Scan are not retried.
 func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
-	return db.conn.QueryRow(query, args...)
+	var row *sql.Row
+	db.withBusyRetry(func() error {
+		row = db.conn.QueryRow(query, args...)
+		return row.Err()
+	})
+	return row
 }
 
 // DefaultDBPath returns the default database path
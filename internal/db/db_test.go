@@ -0,0 +1,59 @@
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestDB opens a fresh database in a temporary directory.
+// The schema needs FTS5, so tests are skipped unless built with -tags fts5 (see Makefile).
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	return openTestDBAt(t, filepath.Join(t.TempDir(), "test.db"))
+}
+
+// openTestDBAt opens the database at path, so several connections can share one file
+func openTestDBAt(t *testing.T, path string) *DB {
+	t.Helper()
+
+	database, err := Open(path)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			t.Skip("SQLite built without FTS5; run tests with -tags fts5")
+		}
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return database
+}
+
+// saveTestMessage saves a minimal message for use in tests
+func saveTestMessage(t *testing.T, database *DB, id, authorID, content string, threadID *string) *Message {
+	t.Helper()
+
+	msg := &Message{
+		ID:            id,
+		SourceType:    "github",
+		SourceID:      id,
+		Timestamp:     time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		AuthorID:      authorID,
+		Content:       content,
+		ChannelID:     "chan_github_owner_repo",
+		ThreadID:      threadID,
+		IsThreadRoot:  threadID != nil && *threadID == id,
+		Mentions:      []string{},
+		URLs:          []string{},
+		CodeBlocks:    []CodeBlock{},
+		Attachments:   []Attachment{},
+		NormalizedAt:  time.Now(),
+		SchemaVersion: "2.0",
+	}
+	if err := database.SaveMessage(msg); err != nil {
+		t.Fatalf("failed to save message %s: %v", id, err)
+	}
+
+	return msg
+}
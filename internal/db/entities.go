@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntityFilter matches messages with an entity of Type, and with Value when
+// it isn't empty, e.g. every message referencing a version, or the ones
+// referencing v1.2.3
+type EntityFilter struct {
+	Type  string
+	Value string
+}
+
+// ParseEntityFilter parses a "type" or "type=value" filter
+func ParseEntityFilter(spec string) (EntityFilter, error) {
+	entityType, value, _ := strings.Cut(spec, "=")
+	entityType = strings.TrimSpace(entityType)
+	if entityType == "" {
+		return EntityFilter{}, fmt.Errorf("invalid entity filter %q: expected type or type=value", spec)
+	}
+	return EntityFilter{Type: entityType, Value: value}, nil
+}
+
+// entityFilterClause returns the WHERE condition matching f against the
+// message's entities, and its arguments
+func entityFilterClause(f EntityFilter) (string, []interface{}) {
+	clause := ` AND EXISTS (
+			SELECT 1 FROM entities e
+			WHERE e.message_id = m.id AND e.type = ?`
+	args := []interface{}{f.Type}
+	if f.Value != "" {
+		clause += ` AND e.value = ?`
+		args = append(args, f.Value)
+	}
+	return clause + `
+		)`, args
+}
+
+// ListEntitiesOptions defines options for listing entities
+type ListEntitiesOptions struct {
+	Type  string // Entities of this type, any if empty
+	Value string // Entities with this value, any if empty
+	Limit int
+}
+
+// ListEntities returns the stored entities matching opts, ordered by type,
+// value, and message
+func (db *DB) ListEntities(opts ListEntitiesOptions) ([]*Entity, error) {
+	query := `
+		SELECT id, message_id, type, value, start_pos, end_pos, metadata
+		FROM entities
+		WHERE 1=1`
+	args := []interface{}{}
+	if opts.Type != "" {
+		query += " AND type = ?"
+		args = append(args, opts.Type)
+	}
+	if opts.Value != "" {
+		query += " AND value = ?"
+		args = append(args, opts.Value)
+	}
+	query += " ORDER BY type, value, message_id, id"
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+	defer rows.Close()
+
+	entities := []*Entity{}
+	for rows.Next() {
+		entity := &Entity{}
+		err := rows.Scan(&entity.ID, &entity.MessageID, &entity.Type, &entity.Value,
+			&entity.StartPos, &entity.EndPos, &entity.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entity: %w", err)
+		}
+		entities = append(entities, entity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating entities: %w", err)
+	}
+
+	return entities, nil
+}
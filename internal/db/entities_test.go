@@ -0,0 +1,145 @@
+package db
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseEntityFilter(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    EntityFilter
+		wantErr bool
+	}{
+		{spec: "issue_ref", want: EntityFilter{Type: "issue_ref"}},
+		{spec: "version=v1.2.3", want: EntityFilter{Type: "version", Value: "v1.2.3"}},
+		{spec: " sha =abc=def", want: EntityFilter{Type: "sha", Value: "abc=def"}},
+		{spec: "", wantErr: true},
+		{spec: "=123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseEntityFilter(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// saveTestEntities saves messages with the given entities, each "type=value"
+func saveTestEntities(t *testing.T, database *DB, entities map[string][]string) {
+	t.Helper()
+	for id, specs := range entities {
+		saveTestMessage(t, database, id, "user_a", "content", nil)
+		for _, spec := range specs {
+			entityType, value, _ := strings.Cut(spec, "=")
+			if err := database.SaveEntity(&Entity{MessageID: id, Type: entityType, Value: value}); err != nil {
+				t.Fatalf("SaveEntity failed: %v", err)
+			}
+		}
+	}
+}
+
+func TestSelectMessages_Entities(t *testing.T) {
+	database := openTestDB(t)
+	saveTestEntities(t, database, map[string][]string{
+		"msg_release": {"version=v1.2.3", "issue_ref=123", "sha=a1b2c3d"},
+		"msg_bug":     {"issue_ref=123", "version=v1.2.2"},
+		"msg_other":   {"issue_ref=456"},
+		"msg_plain":   nil,
+	})
+
+	tests := []struct {
+		name    string
+		filters []EntityFilter
+		want    []string
+	}{
+		{"any value of a type", []EntityFilter{{Type: "issue_ref"}}, []string{"msg_bug", "msg_other", "msg_release"}},
+		{"one value", []EntityFilter{{Type: "version", Value: "v1.2.3"}}, []string{"msg_release"}},
+		{"same value, other type", []EntityFilter{{Type: "version", Value: "123"}}, nil},
+		{"all filters must match", []EntityFilter{{Type: "issue_ref", Value: "123"}, {Type: "sha"}}, []string{"msg_release"}},
+		{"no such type", []EntityFilter{{Type: "url"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SelectMessagesOptions{Entities: tt.filters}
+			messages, err := database.SelectMessages(opts)
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			var ids []string
+			for _, m := range messages {
+				ids = append(ids, m.ID)
+			}
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+
+			counts, err := database.CountMessages(opts, CountBySource)
+			if err != nil {
+				t.Fatalf("CountMessages failed: %v", err)
+			}
+			total := 0
+			for _, c := range counts {
+				total += c.Count
+			}
+			if total != len(tt.want) {
+				t.Errorf("CountMessages counted %d messages, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestListEntities(t *testing.T) {
+	database := openTestDB(t)
+	saveTestEntities(t, database, map[string][]string{
+		"msg_release": {"version=v1.2.3", "issue_ref=123", "sha=a1b2c3d"},
+		"msg_bug":     {"issue_ref=123", "version=v1.2.2"},
+		"msg_other":   {"issue_ref=456"},
+	})
+
+	tests := []struct {
+		name string
+		opts ListEntitiesOptions
+		want []string // type=value@message, in order
+	}{
+		{"all, by type and value", ListEntitiesOptions{}, []string{
+			"issue_ref=123@msg_bug", "issue_ref=123@msg_release", "issue_ref=456@msg_other",
+			"sha=a1b2c3d@msg_release", "version=v1.2.2@msg_bug", "version=v1.2.3@msg_release",
+		}},
+		{"one type", ListEntitiesOptions{Type: "version"}, []string{"version=v1.2.2@msg_bug", "version=v1.2.3@msg_release"}},
+		{"one value", ListEntitiesOptions{Type: "issue_ref", Value: "123"}, []string{"issue_ref=123@msg_bug", "issue_ref=123@msg_release"}},
+		{"limit", ListEntitiesOptions{Type: "issue_ref", Limit: 1}, []string{"issue_ref=123@msg_bug"}},
+		{"no match", ListEntitiesOptions{Type: "url"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities, err := database.ListEntities(tt.opts)
+			if err != nil {
+				t.Fatalf("ListEntities failed: %v", err)
+			}
+			var got []string
+			for _, e := range entities {
+				got = append(got, e.Type+"="+e.Value+"@"+e.MessageID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// FetchFailure is a message that failed to normalize or store after its raw
+// data was saved
+type FetchFailure struct {
+	MessageID  string    `json:"message_id"`
+	SourceType string    `json:"source_type"`
+	SourceID   string    `json:"source_id"`
+	Error      string    `json:"error"`
+	Attempts   int       `json:"attempts"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+// SaveFetchFailure records a failed attempt to store a message. Failing
+// again updates the error and counts the attempt.
+func (db *DB) SaveFetchFailure(failure *FetchFailure) error {
+	_, err := db.Exec(`
+		INSERT INTO fetch_failures (message_id, source_type, source_id, error)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(message_id) DO UPDATE SET
+			error = excluded.error,
+			attempts = attempts + 1,
+			failed_at = CURRENT_TIMESTAMP
+	`, failure.MessageID, failure.SourceType, failure.SourceID, failure.Error)
+
+	if err != nil {
+		return fmt.Errorf("failed to save fetch failure: %w", err)
+	}
+
+	return nil
+}
+
+// SelectFetchFailures returns the recorded failures, oldest first, of
+// sourceType (all sources if empty)
+func (db *DB) SelectFetchFailures(sourceType string) ([]*FetchFailure, error) {
+	query := `
+		SELECT message_id, source_type, source_id, error, attempts, failed_at
+		FROM fetch_failures`
+	args := []interface{}{}
+	if sourceType != "" {
+		query += " WHERE source_type = ?"
+		args = append(args, sourceType)
+	}
+	query += " ORDER BY failed_at, rowid"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fetch failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []*FetchFailure{}
+	for rows.Next() {
+		failure := &FetchFailure{}
+		if err := rows.Scan(&failure.MessageID, &failure.SourceType, &failure.SourceID, &failure.Error, &failure.Attempts, &failure.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan fetch failure: %w", err)
+		}
+		failures = append(failures, failure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fetch failures: %w", err)
+	}
+
+	return failures, nil
+}
+
+// DeleteFetchFailure forgets the failure of a message, once it was stored
+func (db *DB) DeleteFetchFailure(messageID string) error {
+	if _, err := db.Exec("DELETE FROM fetch_failures WHERE message_id = ?", messageID); err != nil {
+		return fmt.Errorf("failed to delete fetch failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import "testing"
+
+func TestFetchFailures(t *testing.T) {
+	database := openTestDB(t)
+
+	failures := []*FetchFailure{
+		{MessageID: "msg_github_acme_widgets_1", SourceType: "github", SourceID: "acme/widgets#1", Error: "failed to save message: boom"},
+		{MessageID: "msg_slack_C1_1.0", SourceType: "slack", SourceID: "C1_1.0", Error: "failed to normalize message: boom"},
+	}
+	for _, failure := range failures {
+		if err := database.SaveFetchFailure(failure); err != nil {
+			t.Fatalf("SaveFetchFailure failed: %v", err)
+		}
+	}
+
+	// Failing again updates the error and counts the attempt
+	again := *failures[0]
+	again.Error = "failed to save message: still broken"
+	if err := database.SaveFetchFailure(&again); err != nil {
+		t.Fatalf("SaveFetchFailure failed: %v", err)
+	}
+
+	got, err := database.SelectFetchFailures("")
+	if err != nil {
+		t.Fatalf("SelectFetchFailures failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 failures, got %d", len(got))
+	}
+	if got[0].MessageID != again.MessageID || got[0].Error != again.Error || got[0].Attempts != 2 {
+		t.Errorf("expected the retried failure with 2 attempts, got %+v", got[0])
+	}
+
+	slackOnly, err := database.SelectFetchFailures("slack")
+	if err != nil {
+		t.Fatalf("SelectFetchFailures failed: %v", err)
+	}
+	if len(slackOnly) != 1 || slackOnly[0].MessageID != "msg_slack_C1_1.0" || slackOnly[0].Attempts != 1 {
+		t.Errorf("expected the slack failure only, got %+v", slackOnly)
+	}
+
+	if err := database.DeleteFetchFailure(again.MessageID); err != nil {
+		t.Fatalf("DeleteFetchFailure failed: %v", err)
+	}
+	remaining, err := database.SelectFetchFailures("github")
+	if err != nil {
+		t.Fatalf("SelectFetchFailures failed: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected no github failures after deleting, got %+v", remaining)
+	}
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultFTSTokenizer stems English words (searching "run" finds "running")
+// and ignores accents (searching "cafe" finds "café")
+const DefaultFTSTokenizer = "porter unicode61 remove_diacritics 2"
+
+// FTSTokenizer is the FTS5 tokenizer of the full-text search index
+// (store.fts_tokenizer). When it differs from the tokenizer of an existing
+// database, the index is recreated and rebuilt from the messages on open.
+var FTSTokenizer = DefaultFTSTokenizer
+
+// ftsTable returns the statement creating the full-text search index with tokenizer
+func ftsTable(tokenizer string) string {
+	return fmt.Sprintf(`CREATE VIRTUAL TABLE messages_fts USING fts5(
+    id UNINDEXED,
+    content,
+    content=messages,
+    content_rowid=rowid,
+    tokenize = '%s'
+)`, strings.ReplaceAll(tokenizer, "'", "''"))
+}
+
+// ftsTokenizer returns the tokenizer the full-text search index was created
+// with, or "" for the FTS5 default
+func (db *DB) ftsTokenizer() (string, error) {
+	var createSQL string
+	err := db.conn.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'messages_fts'").Scan(&createSQL)
+	if err != nil {
+		return "", fmt.Errorf("failed to read full-text search table: %w", err)
+	}
+
+	_, rest, ok := strings.Cut(createSQL, "tokenize")
+	if !ok {
+		return "", nil
+	}
+	_, rest, ok = strings.Cut(rest, "'")
+	if !ok {
+		return "", nil
+	}
+	tokenizer, _, _ := strings.Cut(strings.ReplaceAll(rest, "''", "\x00"), "'")
+	return strings.ReplaceAll(tokenizer, "\x00", "'"), nil
+}
+
+// ensureFTSTokenizer recreates the full-text search index with FTSTokenizer,
+// if it was created with another tokenizer, and rebuilds it from the messages.
+// The sync triggers belong to the messages table, so they are kept.
+func (db *DB) ensureFTSTokenizer() error {
+	current, err := db.ftsTokenizer()
+	if err != nil {
+		return err
+	}
+	if current == FTSTokenizer {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DROP TABLE messages_fts"); err != nil {
+		return fmt.Errorf("failed to drop full-text search table: %w", err)
+	}
+	if _, err := tx.Exec(ftsTable(FTSTokenizer)); err != nil {
+		return fmt.Errorf("failed to create full-text search table with tokenizer %q: %w", FTSTokenizer, err)
+	}
+	if _, err := tx.Exec("INSERT INTO messages_fts(messages_fts) VALUES('rebuild')"); err != nil {
+		return fmt.Errorf("failed to rebuild full-text search index: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit full-text search index: %w", err)
+	}
+
+	return nil
+}
+
+// searchTermPattern matches quoted phrases or single words in a search query
+var searchTermPattern = regexp.MustCompile(`"([^"]*)"|(\S+)`)
+
+// SearchTerms splits a full-text search query into its words and quoted
+// phrases, all of which a matching message contains. "AND" is dropped and
+// prefix queries (deploy*) lose their "*". The other FTS5 operators (OR, NOT,
+// NEAR) are rejected, since with them not every term has to match.
+func SearchTerms(query string) ([]string, error) {
+	var terms []string
+	for _, match := range searchTermPattern.FindAllStringSubmatch(query, -1) {
+		if match[1] != "" {
+			terms = append(terms, match[1])
+			continue
+		}
+
+		word := match[2]
+		switch word {
+		case "AND":
+			continue
+		case "OR", "NOT", "NEAR":
+			return nil, fmt.Errorf("search operator %s is not supported", word)
+		}
+		// Prefix queries (deploy*) match as substrings anyway
+		word = strings.TrimSuffix(word, "*")
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms, nil
+}
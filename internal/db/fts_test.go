@@ -0,0 +1,85 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// searchIDs returns the IDs of the messages matching an FTS5 query
+func searchIDs(t *testing.T, database *DB, query string) []string {
+	t.Helper()
+
+	messages, err := database.SelectMessages(SelectMessagesOptions{SearchText: &query})
+	if err != nil {
+		t.Fatalf("search %q failed: %v", query, err)
+	}
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestFTSTokenizer_Migration(t *testing.T) {
+	saved := FTSTokenizer
+	defer func() { FTSTokenizer = saved }()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// A database indexed without stemming or diacritic folding
+	FTSTokenizer = "unicode61 remove_diacritics 0"
+	database := openTestDBAt(t, path)
+	saveTestMessage(t, database, "msg_1", "user_github_alice", "The tests keep running forever", nil)
+	saveTestMessage(t, database, "msg_2", "user_github_bob", "Meet at the café", nil)
+
+	tests := []struct {
+		query  string
+		before int
+		after  int
+	}{
+		{query: "run", before: 0, after: 1},
+		{query: "running", before: 1, after: 1},
+		{query: "test", before: 0, after: 1},
+		{query: "cafe", before: 0, after: 1},
+		{query: "café", before: 1, after: 1},
+	}
+	for _, tt := range tests {
+		if got := searchIDs(t, database, tt.query); len(got) != tt.before {
+			t.Errorf("before migration: search %q = %v, want %d match(es)", tt.query, got, tt.before)
+		}
+	}
+	database.Close()
+
+	// Reopening with the default tokenizer recreates and rebuilds the index
+	FTSTokenizer = DefaultFTSTokenizer
+	database = openTestDBAt(t, path)
+	tokenizer, err := database.ftsTokenizer()
+	if err != nil {
+		t.Fatalf("ftsTokenizer() failed: %v", err)
+	}
+	if tokenizer != DefaultFTSTokenizer {
+		t.Errorf("tokenizer = %q, want %q", tokenizer, DefaultFTSTokenizer)
+	}
+	for _, tt := range tests {
+		if got := searchIDs(t, database, tt.query); len(got) != tt.after {
+			t.Errorf("after migration: search %q = %v, want %d match(es)", tt.query, got, tt.after)
+		}
+	}
+
+	// The sync triggers still index new messages
+	saveTestMessage(t, database, "msg_3", "user_github_alice", "She runs the deploys", nil)
+	if got := searchIDs(t, database, "run"); len(got) != 2 {
+		t.Errorf("search %q after insert = %v, want 2 matches", "run", got)
+	}
+}
+
+func TestFTSTokenizer_Default(t *testing.T) {
+	database := openTestDB(t)
+	tokenizer, err := database.ftsTokenizer()
+	if err != nil {
+		t.Fatalf("ftsTokenizer() failed: %v", err)
+	}
+	if tokenizer != DefaultFTSTokenizer {
+		t.Errorf("tokenizer = %q, want %q", tokenizer, DefaultFTSTokenizer)
+	}
+}
@@ -4,28 +4,32 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
 // Message represents a normalized message in the database
 type Message struct {
-	ID          string
-	SourceType  string
-	SourceID    string
-	Timestamp   time.Time
-	AuthorID    string
-	Content     string
-	ContentHTML *string
-	ChannelID   string
-	ThreadID    *string
-	ParentID    *string
-	IsThreadRoot bool
-	Mentions    []string
-	URLs        []string
-	CodeBlocks  []CodeBlock
-	Attachments []Attachment
-	NormalizedAt time.Time
-	SchemaVersion string
+	ID              string
+	SourceType      string
+	SourceID        string
+	Timestamp       time.Time
+	SourceTimestamp string // Timestamp exactly as the source wrote it, "" if unknown
+	AuthorID        string
+	Content         string
+	ContentHTML     *string
+	ChannelID       string
+	ThreadID        *string
+	ParentID        *string
+	IsThreadRoot    bool
+	Mentions        []string
+	URLs            []string
+	CodeBlocks      []CodeBlock
+	Attachments     []Attachment
+	EditedAt        *time.Time // When the message was last edited at the source, nil if never
+	EditedBy        *string    // Who made that edit, if the source says
+	NormalizedAt    time.Time
+	SchemaVersion   string
 }
 
 // CodeBlock represents a code snippet
@@ -42,7 +46,40 @@ type Attachment struct {
 	MimeType string `json:"mime_type,omitempty"`
 }
 
-// SaveMessage saves a normalized message to the database
+// Merge policy for refetched messages. When a message is saved again, e.g.
+// after an edit or a thread change upstream:
+//   - Identity fields never change: ID, SourceType, SourceID, Timestamp (when
+//     the message was posted), SourceTimestamp (once known), and AuthorID.
+//   - Source state is replaced: Content, ContentHTML, ChannelID, ThreadID,
+//     ParentID, IsThreadRoot, Attachments, EditedAt, and EditedBy, so edits,
+//     moves, and changes in thread membership are reflected.
+//   - Derived fields are recomputed from the refetched content: Mentions,
+//     URLs, CodeBlocks, NormalizedAt, and SchemaVersion.
+//
+// SaveMessage applies this policy in SQL; MergeRefetchedMessage applies it to
+// messages held in memory.
+
+// MergeRefetchedMessage returns the message to store when refetched replaces
+// existing, following the merge policy above. existing may be nil.
+func MergeRefetchedMessage(existing, refetched *Message) *Message {
+	merged := *refetched
+	if existing == nil {
+		return &merged
+	}
+
+	merged.ID = existing.ID
+	merged.SourceType = existing.SourceType
+	merged.SourceID = existing.SourceID
+	merged.Timestamp = existing.Timestamp
+	if existing.SourceTimestamp != "" {
+		merged.SourceTimestamp = existing.SourceTimestamp
+	}
+	merged.AuthorID = existing.AuthorID
+	return &merged
+}
+
+// SaveMessage saves a normalized message to the database. Saving an existing
+// message merges it according to the merge policy above.
 func (db *DB) SaveMessage(msg *Message) error {
 	// Encode JSON fields
 	mentions, err := json.Marshal(msg.Mentions)
@@ -69,21 +106,29 @@ func (db *DB) SaveMessage(msg *Message) error {
 		INSERT INTO messages (
 			id, source_type, source_id, timestamp, author_id, content, content_html,
 			channel_id, thread_id, parent_id, is_thread_root,
-			mentions, urls, code_blocks, attachments,
-			normalized_at, schema_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			mentions, urls, code_blocks, attachments, edited_at, edited_by,
+			source_timestamp, normalized_at, schema_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
+			source_timestamp = COALESCE(messages.source_timestamp, excluded.source_timestamp),
 			content = excluded.content,
 			content_html = excluded.content_html,
+			channel_id = excluded.channel_id,
+			thread_id = excluded.thread_id,
+			parent_id = excluded.parent_id,
+			is_thread_root = excluded.is_thread_root,
 			mentions = excluded.mentions,
 			urls = excluded.urls,
 			code_blocks = excluded.code_blocks,
 			attachments = excluded.attachments,
-			normalized_at = excluded.normalized_at
+			edited_at = excluded.edited_at,
+			edited_by = excluded.edited_by,
+			normalized_at = excluded.normalized_at,
+			schema_version = excluded.schema_version
 	`, msg.ID, msg.SourceType, msg.SourceID, msg.Timestamp, msg.AuthorID,
 		msg.Content, msg.ContentHTML, msg.ChannelID, msg.ThreadID, msg.ParentID,
-		msg.IsThreadRoot, mentions, urls, codeBlocks, attachments,
-		msg.NormalizedAt, msg.SchemaVersion)
+		msg.IsThreadRoot, mentions, urls, codeBlocks, attachments, msg.EditedAt, msg.EditedBy,
+		msg.SourceTimestamp, msg.NormalizedAt, msg.SchemaVersion)
 
 	if err != nil {
 		return fmt.Errorf("failed to save message: %w", err)
@@ -100,15 +145,15 @@ func (db *DB) GetMessage(id string) (*Message, error) {
 	err := db.QueryRow(`
 		SELECT id, source_type, source_id, timestamp, author_id, content, content_html,
 		       channel_id, thread_id, parent_id, is_thread_root,
-		       mentions, urls, code_blocks, attachments,
-		       normalized_at, schema_version
+		       mentions, urls, code_blocks, attachments, edited_at, edited_by,
+		       COALESCE(source_timestamp, ''), normalized_at, schema_version
 		FROM messages
 		WHERE id = ?
 	`, id).Scan(
 		&msg.ID, &msg.SourceType, &msg.SourceID, &msg.Timestamp, &msg.AuthorID,
 		&msg.Content, &msg.ContentHTML, &msg.ChannelID, &msg.ThreadID, &msg.ParentID,
-		&msg.IsThreadRoot, &mentions, &urls, &codeBlocks, &attachments,
-		&msg.NormalizedAt, &msg.SchemaVersion,
+		&msg.IsThreadRoot, &mentions, &urls, &codeBlocks, &attachments, &msg.EditedAt, &msg.EditedBy,
+		&msg.SourceTimestamp, &msg.NormalizedAt, &msg.SchemaVersion,
 	)
 
 	if err == sql.ErrNoRows {
@@ -137,33 +182,137 @@ func (db *DB) GetMessage(id string) (*Message, error) {
 
 // SelectMessagesOptions defines options for selecting messages
 type SelectMessagesOptions struct {
-	SourceType  *string
-	AuthorID    *string
-	ChannelID   *string
-	ThreadID    *string
-	Since       *time.Time
-	Until       *time.Time
-	SearchText  *string
-	Limit       int
-	Offset      int
+	SourceType         *string
+	SourceTypes        []string // Messages from any of these sources; replaces SourceType when set
+	AuthorID           *string
+	ChannelID          *string
+	ThreadID           *string
+	Since              *time.Time
+	Until              *time.Time
+	SearchText         *string
+	AssigneeID         *string          // Messages in threads whose root is assigned to this user
+	ThreadState        string           // Messages in threads in this state (ThreadStateOpen, ...), any if empty
+	PRState            string           // Messages of pull requests in this state (PRStateMerged, ...), any if empty
+	CollapseDuplicates bool             // Leave out copies of cross-posted messages (RelationDuplicateOf), keeping the first
+	ExcludeChannelIDs  []string         // Messages in none of these channels
+	AuthorIDs          []string         // Messages by any of these authors
+	ExcludeAuthorIDs   []string         // Messages by none of these authors
+	MentionsAnyOf      []string         // Messages mentioning any of these users (IDs compared regardless of case)
+	ChannelTypes       []string         // Messages in channels of any of these types (see ChannelTypes)
+	Metadata           []MetadataFilter // Messages whose source data matches every filter
+	Entities           []EntityFilter   // Messages with an entity matching every filter
+	Sort               string           // SortTimestamp (default) or SortLastActivity
+	Limit              int
+	Offset             int
 
 	// Enrichment filters
 	IsQuestion *bool
 	HasCode    *bool
 	HasLinks   *bool
 	HasQuotes  *bool
+	IsCodeOnly *bool   // Nothing but code (see normalize.IsCodeOnly)
+	IsLinkOnly *bool   // Nothing but links (see normalize.IsLinkOnly)
+	Language   *string // ISO 639-1 code (see normalize.DetectLanguage)
+
+	// CaseSensitive requires the words and phrases of SearchText to match
+	// with the same case; full-text search otherwise ignores case
+	CaseSensitive bool
 }
 
+// Orders for SelectMessagesOptions.Sort
+const (
+	SortTimestamp    = "timestamp"     // Newest messages first
+	SortLastActivity = "last-activity" // Messages in the most recently active threads first
+)
+
+// lastActivityOrder orders messages by their thread's last activity (from the
+// threads table), falling back to the message's own timestamp
+const lastActivityOrder = `COALESCE((SELECT t.last_activity_at FROM threads t WHERE t.id = COALESCE(m.thread_id, m.id)), m.timestamp) DESC, m.timestamp DESC`
+
 // SelectMessages queries messages with filters
 func (db *DB) SelectMessages(opts SelectMessagesOptions) ([]*Message, error) {
 	query := `
 		SELECT m.id, m.source_type, m.source_id, m.timestamp, m.author_id, m.content, m.content_html,
 		       m.channel_id, m.thread_id, m.parent_id, m.is_thread_root,
-		       m.mentions, m.urls, m.code_blocks, m.attachments,
-		       m.normalized_at, m.schema_version
+		       m.mentions, m.urls, m.code_blocks, m.attachments, m.edited_at, m.edited_by,
+		       COALESCE(m.source_timestamp, ''), m.normalized_at, m.schema_version
 		FROM messages m
 	`
 
+	filters, args, err := messageFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+	query += filters
+
+	switch opts.Sort {
+	case "", SortTimestamp:
+		query += " ORDER BY m.timestamp DESC"
+	case SortLastActivity:
+		query += " ORDER BY " + lastActivityOrder
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", opts.Sort)
+	}
+
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	if opts.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, opts.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*Message{}
+	for rows.Next() {
+		msg := &Message{}
+		var mentions, urls, codeBlocks, attachments string
+
+		err := rows.Scan(
+			&msg.ID, &msg.SourceType, &msg.SourceID, &msg.Timestamp, &msg.AuthorID,
+			&msg.Content, &msg.ContentHTML, &msg.ChannelID, &msg.ThreadID, &msg.ParentID,
+			&msg.IsThreadRoot, &mentions, &urls, &codeBlocks, &attachments, &msg.EditedAt, &msg.EditedBy,
+			&msg.SourceTimestamp, &msg.NormalizedAt, &msg.SchemaVersion,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+
+		// Decode JSON fields
+		if err := json.Unmarshal([]byte(mentions), &msg.Mentions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal mentions: %w", err)
+		}
+		if err := json.Unmarshal([]byte(urls), &msg.URLs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal urls: %w", err)
+		}
+		if err := json.Unmarshal([]byte(codeBlocks), &msg.CodeBlocks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal code_blocks: %w", err)
+		}
+		if err := json.Unmarshal([]byte(attachments), &msg.Attachments); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attachments: %w", err)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// messageFilters returns the joins and WHERE clause for the filters in opts,
+// shared by SelectMessages and CountMessages, and their arguments
+func messageFilters(opts SelectMessagesOptions) (string, []interface{}, error) {
+	query := ""
+
 	// Add INNER JOIN with FTS5 if full-text search is specified
 	needsFTSJoin := opts.SearchText != nil
 	if needsFTSJoin {
@@ -172,7 +321,8 @@ func (db *DB) SelectMessages(opts SelectMessagesOptions) ([]*Message, error) {
 
 	// Add LEFT JOIN with enrichments if any enrichment filters are specified
 	needsEnrichmentJoin := opts.IsQuestion != nil || opts.HasCode != nil ||
-	                       opts.HasLinks != nil || opts.HasQuotes != nil
+		opts.HasLinks != nil || opts.HasQuotes != nil || opts.IsCodeOnly != nil ||
+		opts.IsLinkOnly != nil || opts.Language != nil
 	if needsEnrichmentJoin {
 		query += " LEFT JOIN enrichments e ON m.id = e.message_id"
 	}
@@ -180,7 +330,12 @@ func (db *DB) SelectMessages(opts SelectMessagesOptions) ([]*Message, error) {
 	query += " WHERE 1=1"
 	args := []interface{}{}
 
-	if opts.SourceType != nil {
+	if len(opts.SourceTypes) > 0 {
+		query += " AND m.source_type IN (" + placeholders(len(opts.SourceTypes)) + ")"
+		for _, source := range opts.SourceTypes {
+			args = append(args, source)
+		}
+	} else if opts.SourceType != nil {
 		query += " AND m.source_type = ?"
 		args = append(args, *opts.SourceType)
 	}
@@ -204,12 +359,90 @@ func (db *DB) SelectMessages(opts SelectMessagesOptions) ([]*Message, error) {
 		query += " AND m.timestamp <= ?"
 		args = append(args, *opts.Until)
 	}
+	if len(opts.ExcludeChannelIDs) > 0 {
+		query += " AND m.channel_id NOT IN (" + placeholders(len(opts.ExcludeChannelIDs)) + ")"
+		for _, id := range opts.ExcludeChannelIDs {
+			args = append(args, id)
+		}
+	}
+	if len(opts.AuthorIDs) > 0 {
+		query += " AND m.author_id IN (" + placeholders(len(opts.AuthorIDs)) + ")"
+		for _, id := range opts.AuthorIDs {
+			args = append(args, id)
+		}
+	}
+	if len(opts.ExcludeAuthorIDs) > 0 {
+		query += " AND m.author_id NOT IN (" + placeholders(len(opts.ExcludeAuthorIDs)) + ")"
+		for _, id := range opts.ExcludeAuthorIDs {
+			args = append(args, id)
+		}
+	}
+	if len(opts.MentionsAnyOf) > 0 {
+		// GitHub logins are case-insensitive, and Slack IDs are all uppercase
+		query += " AND EXISTS (SELECT 1 FROM json_each(m.mentions) WHERE lower(value) IN (" + placeholders(len(opts.MentionsAnyOf)) + "))"
+		for _, id := range opts.MentionsAnyOf {
+			args = append(args, strings.ToLower(id))
+		}
+	}
+	if len(opts.ChannelTypes) > 0 {
+		clause, typeArgs := channelTypeFilterClause(opts.ChannelTypes)
+		query += clause
+		args = append(args, typeArgs...)
+	}
+	if opts.ThreadState != "" {
+		clause, err := threadStateFilterClause(opts.ThreadState)
+		if err != nil {
+			return "", nil, err
+		}
+		query += clause
+	}
+	if opts.PRState != "" {
+		clause, stateArgs := prStateFilterClause(opts.PRState)
+		query += clause
+		args = append(args, stateArgs...)
+	}
+	if opts.CollapseDuplicates {
+		query += ` AND NOT EXISTS (
+			SELECT 1 FROM message_relations d
+			WHERE d.from_message_id = m.id AND d.relation_type = ?
+		)`
+		args = append(args, RelationDuplicateOf)
+	}
+	if opts.AssigneeID != nil {
+		query += ` AND EXISTS (
+			SELECT 1 FROM entities a
+			WHERE a.message_id = COALESCE(m.thread_id, m.id) AND a.type = ? AND a.value = ?
+		)`
+		args = append(args, EntityTypeAssignee, *opts.AssigneeID)
+	}
+	for _, filter := range opts.Metadata {
+		clause, filterArgs := metadataFilterClause(filter)
+		query += clause
+		args = append(args, filterArgs...)
+	}
+	for _, filter := range opts.Entities {
+		clause, filterArgs := entityFilterClause(filter)
+		query += clause
+		args = append(args, filterArgs...)
+	}
 	if opts.SearchText != nil {
 		// Use FTS5 full-text search with MATCH operator
 		// Supports: boolean queries (AND, OR, NOT), phrase matching ("exact phrase"),
 		// prefix matching (word*), and relevance ranking
 		query += " AND fts.content MATCH ?"
 		args = append(args, *opts.SearchText)
+
+		// FTS5 ignores case, so case-sensitive terms are also checked in the content
+		if opts.CaseSensitive {
+			terms, err := SearchTerms(*opts.SearchText)
+			if err != nil {
+				return "", nil, fmt.Errorf("%w with case-sensitive search", err)
+			}
+			for _, term := range terms {
+				query += " AND instr(m.content, ?) > 0"
+				args = append(args, term)
+			}
+		}
 	}
 
 	// Enrichment filters
@@ -229,64 +462,30 @@ func (db *DB) SelectMessages(opts SelectMessagesOptions) ([]*Message, error) {
 		query += " AND e.has_quotes = ?"
 		args = append(args, *opts.HasQuotes)
 	}
-
-	query += " ORDER BY m.timestamp DESC"
-
-	if opts.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, opts.Limit)
+	if opts.IsCodeOnly != nil {
+		query += " AND e.is_code_only = ?"
+		args = append(args, *opts.IsCodeOnly)
 	}
-	if opts.Offset > 0 {
-		query += " OFFSET ?"
-		args = append(args, opts.Offset)
+	if opts.IsLinkOnly != nil {
+		query += " AND e.is_link_only = ?"
+		args = append(args, *opts.IsLinkOnly)
 	}
-
-	rows, err := db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to select messages: %w", err)
+	if opts.Language != nil {
+		query += " AND e.language = ?"
+		args = append(args, *opts.Language)
 	}
-	defer rows.Close()
 
-	messages := []*Message{}
-	for rows.Next() {
-		msg := &Message{}
-		var mentions, urls, codeBlocks, attachments string
-
-		err := rows.Scan(
-			&msg.ID, &msg.SourceType, &msg.SourceID, &msg.Timestamp, &msg.AuthorID,
-			&msg.Content, &msg.ContentHTML, &msg.ChannelID, &msg.ThreadID, &msg.ParentID,
-			&msg.IsThreadRoot, &mentions, &urls, &codeBlocks, &attachments,
-			&msg.NormalizedAt, &msg.SchemaVersion,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan message: %w", err)
-		}
-
-		// Decode JSON fields
-		if err := json.Unmarshal([]byte(mentions), &msg.Mentions); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal mentions: %w", err)
-		}
-		if err := json.Unmarshal([]byte(urls), &msg.URLs); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal urls: %w", err)
-		}
-		if err := json.Unmarshal([]byte(codeBlocks), &msg.CodeBlocks); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal code_blocks: %w", err)
-		}
-		if err := json.Unmarshal([]byte(attachments), &msg.Attachments); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal attachments: %w", err)
-		}
-
-		messages = append(messages, msg)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating messages: %w", err)
-	}
+	return query, args, nil
+}
 
-	return messages, nil
+// placeholders returns n comma-separated SQL parameter placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
 }
 
-// SaveRawMessage saves a raw message to the database
+// SaveRawMessage saves a raw message to the database. fetched_at records when
+// the saved data was fetched, so saving identical data again (as reprocessing
+// does) keeps it.
 func (db *DB) SaveRawMessage(id, sourceType, sourceID, workspaceID, containerID, rawData, fetchQuery string) error {
 	_, err := db.Exec(`
 		INSERT INTO raw_messages (
@@ -294,7 +493,8 @@ func (db *DB) SaveRawMessage(id, sourceType, sourceID, workspaceID, containerID,
 		) VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(source_type, source_id, workspace_id) DO UPDATE SET
 			raw_data = excluded.raw_data,
-			fetched_at = CURRENT_TIMESTAMP,
+			fetched_at = CASE WHEN raw_messages.raw_data = excluded.raw_data
+				THEN raw_messages.fetched_at ELSE CURRENT_TIMESTAMP END,
 			fetch_query = excluded.fetch_query
 	`, id, sourceType, sourceID, workspaceID, containerID, rawData, fetchQuery)
 
@@ -0,0 +1,340 @@
+package db
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "?"},
+		{3, "?, ?, ?"},
+	}
+	for _, tt := range tests {
+		if got := placeholders(tt.n); got != tt.want {
+			t.Errorf("placeholders(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSelectMessages_Exclude(t *testing.T) {
+	database := openTestDB(t)
+
+	saveTestMessage(t, database, "msg_1", "user_alice", "From alice", nil)
+	saveTestMessage(t, database, "msg_2", "user_bot", "Automated update", nil)
+	saveTestMessage(t, database, "msg_3", "user_bob", "From bob", nil)
+	saveTestMessage(t, database, "msg_4", "user_alice", "Alice in the noisy channel", nil)
+	if _, err := database.Exec("UPDATE messages SET channel_id = ? WHERE id = ?", "chan_noisy", "msg_4"); err != nil {
+		t.Fatalf("failed to update channel: %v", err)
+	}
+
+	alice := "user_alice"
+	channel := "chan_github_owner_repo"
+
+	tests := []struct {
+		name string
+		opts SelectMessagesOptions
+		want []string
+	}{
+		{
+			name: "exclude author",
+			opts: SelectMessagesOptions{ExcludeAuthorIDs: []string{"user_bot"}},
+			want: []string{"msg_1", "msg_3", "msg_4"},
+		},
+		{
+			name: "exclude several authors",
+			opts: SelectMessagesOptions{ExcludeAuthorIDs: []string{"user_bot", "user_bob"}},
+			want: []string{"msg_1", "msg_4"},
+		},
+		{
+			name: "exclude channel",
+			opts: SelectMessagesOptions{ExcludeChannelIDs: []string{"chan_noisy"}},
+			want: []string{"msg_1", "msg_2", "msg_3"},
+		},
+		{
+			name: "include author, exclude channel",
+			opts: SelectMessagesOptions{AuthorID: &alice, ExcludeChannelIDs: []string{"chan_noisy"}},
+			want: []string{"msg_1"},
+		},
+		{
+			name: "include channel, exclude author",
+			opts: SelectMessagesOptions{ChannelID: &channel, ExcludeAuthorIDs: []string{"user_bot"}},
+			want: []string{"msg_1", "msg_3"},
+		},
+		{
+			name: "exclude author and channel",
+			opts: SelectMessagesOptions{ExcludeAuthorIDs: []string{"user_bob"}, ExcludeChannelIDs: []string{"chan_noisy"}},
+			want: []string{"msg_1", "msg_2"},
+		},
+		{
+			name: "any of several authors",
+			opts: SelectMessagesOptions{AuthorIDs: []string{"user_bot", "user_bob"}},
+			want: []string{"msg_2", "msg_3"},
+		},
+		{
+			name: "include authors, exclude one",
+			opts: SelectMessagesOptions{AuthorIDs: []string{"user_alice", "user_bob"}, ExcludeAuthorIDs: []string{"user_bob"}},
+			want: []string{"msg_1", "msg_4"},
+		},
+		{
+			name: "excluding the included author matches nothing",
+			opts: SelectMessagesOptions{AuthorID: &alice, ExcludeAuthorIDs: []string{"user_alice"}},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := database.SelectMessages(tt.opts)
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			ids := make([]string, len(messages))
+			for i, m := range messages {
+				ids[i] = m.ID
+			}
+			// All test messages share a timestamp, so compare as sets
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+}
+
+func TestSelectMessages_SourceTypes(t *testing.T) {
+	database := openTestDB(t)
+
+	saveTestMessage(t, database, "msg_github", "user_alice", "From GitHub", nil)
+	saveTestMessage(t, database, "msg_slack", "user_alice", "From Slack", nil)
+	saveTestMessage(t, database, "msg_email", "user_alice", "From email", nil)
+	for id, source := range map[string]string{"msg_slack": "slack", "msg_email": "email"} {
+		if _, err := database.Exec("UPDATE messages SET source_type = ? WHERE id = ?", source, id); err != nil {
+			t.Fatalf("failed to update source: %v", err)
+		}
+	}
+
+	slack := "slack"
+	tests := []struct {
+		name string
+		opts SelectMessagesOptions
+		want []string
+	}{
+		{"one source", SelectMessagesOptions{SourceTypes: []string{"slack"}}, []string{"msg_slack"}},
+		{"any of several", SelectMessagesOptions{SourceTypes: []string{"slack", "github"}}, []string{"msg_github", "msg_slack"}},
+		{"single SourceType", SelectMessagesOptions{SourceType: &slack}, []string{"msg_slack"}},
+		{"SourceTypes over SourceType", SelectMessagesOptions{SourceType: &slack, SourceTypes: []string{"github", "email"}}, []string{"msg_email", "msg_github"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := database.SelectMessages(tt.opts)
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			var ids []string
+			for _, m := range messages {
+				ids = append(ids, m.ID)
+			}
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+}
+
+func TestSelectMessages_MentionsAnyOf(t *testing.T) {
+	database := openTestDB(t)
+
+	mentions := map[string][]string{
+		"msg_1": {"user_github_octocat"},
+		"msg_2": {"user_github_hubot", "user_slack_U123"},
+		"msg_3": {"user_github_Octocat"},
+		"msg_4": {},
+	}
+	for id, ids := range mentions {
+		msg := saveTestMessage(t, database, id, "user_github_mona", "Hello", nil)
+		msg.Mentions = ids
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{"one user, regardless of case", []string{"user_github_octocat"}, []string{"msg_1", "msg_3"}},
+		{"any of several users", []string{"user_slack_U123", "user_github_octocat"}, []string{"msg_1", "msg_2", "msg_3"}},
+		{"nobody mentions them", []string{"user_slack_U999"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := database.SelectMessages(SelectMessagesOptions{MentionsAnyOf: tt.ids})
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			ids := make([]string, len(messages))
+			for i, m := range messages {
+				ids[i] = m.ID
+			}
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+}
+
+func TestSaveMessage_RefetchChangesContent(t *testing.T) {
+	database := openTestDB(t)
+
+	original := saveTestMessage(t, database, "msg_1", "user_alice", "Deploys fail on staging", nil)
+
+	refetched := *original
+	refetched.Content = "Deploys fail on staging, see @bob"
+	refetched.Mentions = []string{"user_bob"}
+	refetched.Timestamp = original.Timestamp.Add(time.Hour) // edit time, not post time
+	refetched.AuthorID = "user_mallory"
+	refetched.SchemaVersion = "2.1"
+	if err := database.SaveMessage(&refetched); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+
+	got, err := database.GetMessage("msg_1")
+	if err != nil {
+		t.Fatalf("GetMessage failed: %v", err)
+	}
+	if got.Content != refetched.Content {
+		t.Errorf("expected updated content, got %q", got.Content)
+	}
+	if len(got.Mentions) != 1 || got.Mentions[0] != "user_bob" {
+		t.Errorf("expected recomputed mentions, got %v", got.Mentions)
+	}
+	if got.SchemaVersion != "2.1" {
+		t.Errorf("expected schema version 2.1, got %q", got.SchemaVersion)
+	}
+	if !got.Timestamp.Equal(original.Timestamp) {
+		t.Errorf("expected timestamp to stay %v, got %v", original.Timestamp, got.Timestamp)
+	}
+	if got.AuthorID != "user_alice" {
+		t.Errorf("expected author to stay user_alice, got %s", got.AuthorID)
+	}
+
+	// The full-text index follows the new content
+	search := "bob"
+	messages, err := database.SelectMessages(SelectMessagesOptions{SearchText: &search})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected search to find the edited message, got %d results", len(messages))
+	}
+}
+
+func TestSaveMessage_RefetchChangesThread(t *testing.T) {
+	database := openTestDB(t)
+
+	oldRoot := "msg_root_old"
+	newRoot := "msg_root_new"
+	saveTestMessage(t, database, oldRoot, "user_alice", "Old thread", &oldRoot)
+	saveTestMessage(t, database, newRoot, "user_alice", "New thread", &newRoot)
+	reply := saveTestMessage(t, database, "msg_reply", "user_bob", "A reply", &oldRoot)
+	reply.ParentID = &oldRoot
+	if err := database.SaveMessage(reply); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+
+	// The reply was moved to another thread upstream
+	moved := *reply
+	moved.ThreadID = &newRoot
+	moved.ParentID = &newRoot
+	moved.ChannelID = "chan_github_owner_other"
+	if err := database.SaveMessage(&moved); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+
+	got, err := database.GetMessage("msg_reply")
+	if err != nil {
+		t.Fatalf("GetMessage failed: %v", err)
+	}
+	if got.ThreadID == nil || *got.ThreadID != newRoot || got.ParentID == nil || *got.ParentID != newRoot {
+		t.Errorf("expected reply in %s, got thread %v parent %v", newRoot, got.ThreadID, got.ParentID)
+	}
+	if got.ChannelID != "chan_github_owner_other" {
+		t.Errorf("expected channel to update, got %s", got.ChannelID)
+	}
+
+	for _, tt := range []struct {
+		thread string
+		want   int
+	}{{oldRoot, 1}, {newRoot, 2}} {
+		thread := tt.thread
+		messages, err := database.SelectMessages(SelectMessagesOptions{ThreadID: &thread})
+		if err != nil {
+			t.Fatalf("SelectMessages failed: %v", err)
+		}
+		if len(messages) != tt.want {
+			t.Errorf("expected %d messages in %s, got %d", tt.want, thread, len(messages))
+		}
+	}
+}
+
+func TestSaveMessage_SourceTimestamp(t *testing.T) {
+	database := openTestDB(t)
+
+	// Saved before source timestamps were kept
+	msg := saveTestMessage(t, database, "msg_1", "user_alice", "Deploys fail", nil)
+
+	for _, tt := range []struct {
+		saved string
+		want  string
+	}{
+		{"2024-01-15T10:00:00Z", "2024-01-15T10:00:00Z"},      // Filled in by the refetch
+		{"2024-01-15T11:00:00+01:00", "2024-01-15T10:00:00Z"}, // Then never changes
+		{"", "2024-01-15T10:00:00Z"},
+	} {
+		refetched := *msg
+		refetched.SourceTimestamp = tt.saved
+		if err := database.SaveMessage(&refetched); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+		got, err := database.GetMessage("msg_1")
+		if err != nil || got == nil {
+			t.Fatalf("GetMessage = %v, %v", got, err)
+		}
+		if got.SourceTimestamp != tt.want {
+			t.Errorf("after saving %q: source timestamp = %q, want %q", tt.saved, got.SourceTimestamp, tt.want)
+		}
+	}
+}
+
+func TestMergeRefetchedMessage(t *testing.T) {
+	posted := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	thread := "msg_root"
+	existing := &Message{ID: "msg_1", SourceType: "slack", SourceID: "C1:1.0", Timestamp: posted, AuthorID: "user_alice", Content: "old"}
+	refetched := &Message{ID: "msg_1", SourceType: "slack", SourceID: "C1:1.0", Timestamp: posted.Add(time.Hour), AuthorID: "user_bob", Content: "new", ThreadID: &thread}
+
+	merged := MergeRefetchedMessage(existing, refetched)
+	if merged.Content != "new" || merged.ThreadID != &thread {
+		t.Errorf("expected source state from the refetch, got %+v", merged)
+	}
+	if !merged.Timestamp.Equal(posted) || merged.AuthorID != "user_alice" {
+		t.Errorf("expected identity fields from the existing message, got %+v", merged)
+	}
+	if refetched.AuthorID != "user_bob" {
+		t.Error("MergeRefetchedMessage modified its input")
+	}
+
+	if got := MergeRefetchedMessage(nil, refetched); got.AuthorID != "user_bob" || got == refetched {
+		t.Errorf("expected a copy of the refetched message, got %+v", got)
+	}
+}
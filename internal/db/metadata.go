@@ -0,0 +1,71 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// metadataKeyPattern matches metadata keys: field names joined by dots
+// for nested fields, e.g. "state" or "user.login"
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// MetadataFilter matches messages whose source data has Key set to Value.
+// Source data is the raw API response stored for each message (GitHub issue
+// and pull request state, Slack message subtype, ...).
+type MetadataFilter struct {
+	Key   string
+	Value string
+}
+
+// ParseMetadataFilter parses a "key=value" filter
+func ParseMetadataFilter(spec string) (MetadataFilter, error) {
+	key, value, ok := strings.Cut(spec, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return MetadataFilter{}, fmt.Errorf("invalid metadata filter %q: expected key=value", spec)
+	}
+	if !metadataKeyPattern.MatchString(key) {
+		return MetadataFilter{}, fmt.Errorf("invalid metadata key %q: use field names separated by dots", key)
+	}
+	return MetadataFilter{Key: key, Value: value}, nil
+}
+
+// JSONPath returns the SQLite JSON path for the filter's key
+func (f MetadataFilter) JSONPath() string {
+	return "$." + f.Key
+}
+
+// Values returns the JSON values the filter matches: the value as a string,
+// and as a number or boolean when it parses as one, so "number=42" matches
+// 42 and "locked=false" matches false
+func (f MetadataFilter) Values() []interface{} {
+	values := []interface{}{f.Value}
+	if n, err := strconv.ParseInt(f.Value, 10, 64); err == nil {
+		return append(values, n)
+	}
+	if n, err := strconv.ParseFloat(f.Value, 64); err == nil {
+		return append(values, n)
+	}
+	// SQLite's json_extract returns booleans as 1 and 0
+	switch f.Value {
+	case "true":
+		values = append(values, 1)
+	case "false":
+		values = append(values, 0)
+	}
+	return values
+}
+
+// metadataFilterClause returns the WHERE condition matching f against the
+// message's stored raw source data, and its arguments
+func metadataFilterClause(f MetadataFilter) (string, []interface{}) {
+	values := f.Values()
+	clause := ` AND EXISTS (
+			SELECT 1 FROM raw_messages r
+			WHERE r.id = m.id AND json_extract(r.raw_data, ?) IN (` + placeholders(len(values)) + `)
+		)`
+	args := append([]interface{}{f.JSONPath()}, values...)
+	return clause, args
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestParseMetadataFilter(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    MetadataFilter
+		wantErr bool
+	}{
+		{spec: "state=closed", want: MetadataFilter{Key: "state", Value: "closed"}},
+		{spec: "user.login=alice", want: MetadataFilter{Key: "user.login", Value: "alice"}},
+		{spec: "title=a=b", want: MetadataFilter{Key: "title", Value: "a=b"}},
+		{spec: "body=", want: MetadataFilter{Key: "body", Value: ""}},
+		{spec: "state", wantErr: true},
+		{spec: "=closed", wantErr: true},
+		{spec: "state') OR 1=1 --=x", wantErr: true},
+		{spec: "labels[0]=bug", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseMetadataFilter(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectMessages_Metadata(t *testing.T) {
+	database := openTestDB(t)
+
+	raw := map[string]string{
+		"msg_open":   `{"number": 1, "state": "open", "comments": 3, "locked": false, "user": {"login": "alice"}}`,
+		"msg_closed": `{"number": 2, "state": "closed", "comments": 0, "locked": true, "user": {"login": "bob"}}`,
+		"msg_title":  `{"number": 3, "state": "closed", "title": "42", "comments": 1.5, "user": {"login": "bob"}}`,
+	}
+	for id, data := range raw {
+		saveTestMessage(t, database, id, "user_a", "content", nil)
+		if err := database.SaveRawMessage(id, "github", id, "org_acme", "chan_github_owner_repo", data, ""); err != nil {
+			t.Fatalf("failed to save raw message: %v", err)
+		}
+	}
+	// A message without raw data never matches
+	saveTestMessage(t, database, "msg_no_raw", "user_a", "content", nil)
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{name: "string", filters: []string{"state=closed"}, want: []string{"msg_closed", "msg_title"}},
+		{name: "integer", filters: []string{"number=2"}, want: []string{"msg_closed"}},
+		{name: "zero", filters: []string{"comments=0"}, want: []string{"msg_closed"}},
+		{name: "float", filters: []string{"comments=1.5"}, want: []string{"msg_title"}},
+		{name: "numeric-looking string", filters: []string{"title=42"}, want: []string{"msg_title"}},
+		{name: "boolean", filters: []string{"locked=true"}, want: []string{"msg_closed"}},
+		{name: "nested", filters: []string{"user.login=alice"}, want: []string{"msg_open"}},
+		{name: "all filters must match", filters: []string{"state=closed", "user.login=bob", "number=3"}, want: []string{"msg_title"}},
+		{name: "no match", filters: []string{"state=merged"}, want: nil},
+		{name: "missing key", filters: []string{"milestone=v1"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts SelectMessagesOptions
+			for _, spec := range tt.filters {
+				filter, err := ParseMetadataFilter(spec)
+				if err != nil {
+					t.Fatalf("ParseMetadataFilter(%q) failed: %v", spec, err)
+				}
+				opts.Metadata = append(opts.Metadata, filter)
+			}
+
+			messages, err := database.SelectMessages(opts)
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+
+			got := make(map[string]bool)
+			for _, m := range messages {
+				got[m.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("expected %s in %v", id, got)
+				}
+			}
+
+			counts, err := database.CountMessages(opts, CountBySource)
+			if err != nil {
+				t.Fatalf("CountMessages failed: %v", err)
+			}
+			total := 0
+			for _, c := range counts {
+				total += c.Count
+			}
+			if total != len(tt.want) {
+				t.Errorf("expected counts to honor the filter (%d), got %d", len(tt.want), total)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// migrationFiles holds the numbered migrations, one per schema version:
+// migrations/001_to_002.sql upgrades a version 1 database to version 2
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrations is where migrate reads the migrations from; tests swap it
+var migrations fs.FS = migrationFiles
+
+// migrationName returns the file that upgrades a database from version to version+1
+func migrationName(version int) string {
+	return fmt.Sprintf("migrations/%03d_to_%03d.sql", version, version+1)
+}
+
+// migrate upgrades the schema from version from to SchemaVersion, applying
+// each migration in order and recording each version in schema_version. It
+// all runs in one transaction, so a failing statement leaves the database at
+// version from, untouched.
+func (db *DB) migrate(from int) error {
+	// Read them all first, so a missing migration fails before any runs
+	var steps []string
+	for version := from; version < SchemaVersion; version++ {
+		stmts, err := fs.ReadFile(migrations, migrationName(version))
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("no schema migration from version %d to %d", version, version+1)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read schema migration from version %d: %w", version, err)
+		}
+		steps = append(steps, string(stmts))
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, stmts := range steps {
+		version := from + i
+		if _, err := tx.Exec(stmts); err != nil {
+			return fmt.Errorf("failed to migrate schema from version %d to %d: %w", version, version+1, err)
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO schema_version (version) VALUES (?)", version+1); err != nil {
+			return fmt.Errorf("failed to record schema version %d: %w", version+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schema migration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// createV1DB creates a database at schema version 1, as an older mine left
+// it, from testdata/schema_v1.sql
+func createV1DB(t *testing.T) string {
+	t.Helper()
+
+	schema, err := os.ReadFile(filepath.Join("testdata", "schema_v1.sql"))
+	if err != nil {
+		t.Fatalf("failed to read v1 schema: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "v1.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(string(schema)); err != nil {
+		t.Fatalf("failed to create v1 schema: %v", err)
+	}
+	return path
+}
+
+// downgradeToV2 turns database, created at the current version, into a
+// version 2 database, as mine left it before the version 3 columns and tables
+func downgradeToV2(t *testing.T, database *DB) {
+	t.Helper()
+
+	for _, stmt := range []string{
+		"ALTER TABLE messages DROP COLUMN edited_at",
+		"ALTER TABLE messages DROP COLUMN edited_by",
+		"ALTER TABLE messages DROP COLUMN source_timestamp",
+		"ALTER TABLE threads DROP COLUMN is_dismissed",
+		"ALTER TABLE threads DROP COLUMN title",
+		"ALTER TABLE enrichments DROP COLUMN language",
+		"ALTER TABLE enrichments DROP COLUMN is_code_only",
+		"ALTER TABLE enrichments DROP COLUMN is_link_only",
+		"DROP TABLE fetch_cursors",
+		"DROP TABLE fetch_failures",
+		"DELETE FROM schema_version",
+		"INSERT INTO schema_version (version) VALUES (2)",
+	} {
+		if _, err := database.conn.Exec(stmt); err != nil {
+			t.Fatalf("failed to downgrade to version 2 (%s): %v", stmt, err)
+		}
+	}
+}
+
+// versionsOf returns the versions recorded in the database's schema_version table
+func versionsOf(t *testing.T, path string) []int {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("SELECT version FROM schema_version ORDER BY version")
+	if err != nil {
+		t.Fatalf("failed to read schema versions: %v", err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("failed to read schema versions: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func TestOpen_MigratesV1(t *testing.T) {
+	path := createV1DB(t)
+
+	database := openTestDBAt(t, path)
+	if v, err := database.schemaVersion(); err != nil || v != SchemaVersion {
+		t.Fatalf("schemaVersion() = %d, %v; want %d", v, err, SchemaVersion)
+	}
+
+	// The version 1 rows are kept
+	root, err := database.GetMessage("msg_slack_C1_1.0")
+	if err != nil || root == nil || root.Content != "How do I rotate the deploy key?" {
+		t.Fatalf("GetMessage = %+v, %v; want the v1 question", root, err)
+	}
+	if thread, err := database.GetThread("msg_slack_C1_1.0"); err != nil || thread == nil || thread.ReplyCount != 1 || !thread.Resolved {
+		t.Errorf("GetThread = %+v, %v; want the v1 summary", thread, err)
+	}
+	if enrichment, err := database.GetEnrichment("msg_slack_C1_1.0"); err != nil || enrichment == nil || !enrichment.IsQuestion {
+		t.Errorf("GetEnrichment = %+v, %v; want the v1 enrichment", enrichment, err)
+	}
+
+	// Full-text search covers the messages stored before it existed
+	search := "deploy"
+	found, err := database.SelectMessages(SelectMessagesOptions{SearchText: &search})
+	if err != nil || len(found) != 1 || found[0].ID != "msg_slack_C1_1.0" {
+		t.Errorf("search for %q = %v, %v; want the v1 question", search, found, err)
+	}
+
+	// The version 2 tables and columns work
+	canonicalID := "identity_alice"
+	if err := database.SaveIdentity(&Identity{CanonicalID: canonicalID}); err != nil {
+		t.Errorf("SaveIdentity failed: %v", err)
+	}
+	if err := database.SaveUser(&User{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1", CanonicalID: &canonicalID}); err != nil {
+		t.Errorf("SaveUser failed: %v", err)
+	}
+	if users, err := database.GetUsersByIdentity(canonicalID); err != nil || len(users) != 1 {
+		t.Errorf("GetUsersByIdentity = %v, %v; want user_slack_U1", users, err)
+	}
+	if err := database.SaveMessageRelation(&MessageRelation{FromMessageID: "msg_slack_C1_2.0", ToMessageID: "msg_slack_C1_1.0",
+		RelationType: "answers_to", Confidence: 1}); err != nil {
+		t.Errorf("SaveMessageRelation failed: %v", err)
+	}
+	saveTestMessage(t, database, "msg_github_owner_repo_1", "user_a", "migrated", nil)
+	search = "migrated"
+	if found, err := database.SelectMessages(SelectMessagesOptions{SearchText: &search}); err != nil || len(found) != 1 {
+		t.Errorf("search for a message saved after migrating = %v, %v", found, err)
+	}
+	database.Close()
+
+	// Opening a migrated database again changes nothing
+	database = openTestDBAt(t, path)
+	if _, err := database.GetMessage("msg_github_owner_repo_1"); err != nil {
+		t.Errorf("message lost after reopening: %v", err)
+	}
+	database.Close()
+	if got := versionsOf(t, path); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != SchemaVersion {
+		t.Errorf("schema versions = %v, want [1 2 %d]", got, SchemaVersion)
+	}
+}
+
+func TestOpen_MigratesV2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v2.db")
+	database := openTestDBAt(t, path)
+	saveTestMessage(t, database, "msg_old", "user_github_alice", "old message", nil)
+	downgradeToV2(t, database)
+	database.Close()
+
+	database = openTestDBAt(t, path)
+	if v, err := database.schemaVersion(); err != nil || v != SchemaVersion {
+		t.Fatalf("schemaVersion() = %d, %v; want %d", v, err, SchemaVersion)
+	}
+
+	// The version 2 rows are kept, without the version 3 fields
+	old, err := database.GetMessage("msg_old")
+	if err != nil || old == nil || old.EditedAt != nil || old.SourceTimestamp != "" {
+		t.Fatalf("GetMessage(msg_old) = %+v, %v; want the v2 message", old, err)
+	}
+
+	// The version 3 columns and tables work
+	edited, editor := time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC), "user_github_alice"
+	old.EditedAt, old.EditedBy, old.SourceTimestamp = &edited, &editor, "2024-01-15T10:00:00Z"
+	if err := database.SaveMessage(old); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+	if got, err := database.GetMessage("msg_old"); err != nil || got.EditedAt == nil || !got.EditedAt.Equal(edited) || got.SourceTimestamp != "2024-01-15T10:00:00Z" {
+		t.Errorf("GetMessage(msg_old) = %+v, %v; want the edit and source timestamp", got, err)
+	}
+	if err := database.SaveFetchFailure(&FetchFailure{MessageID: "msg_bad", SourceType: "github", SourceID: "bad", Error: "boom"}); err != nil {
+		t.Errorf("SaveFetchFailure failed: %v", err)
+	}
+	database.Close()
+
+	if got := versionsOf(t, path); len(got) != 2 || got[0] != 2 || got[1] != SchemaVersion {
+		t.Errorf("schema versions = %v, want [2 %d]", got, SchemaVersion)
+	}
+}
+
+func TestOpen_MigrationFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations fstest.MapFS
+		wantErr    string
+	}{
+		{"failing statement", fstest.MapFS{
+			"migrations/001_to_002.sql": {Data: []byte("CREATE TABLE widgets (id TEXT);\nSELECT * FROM no_such_table;")},
+			"migrations/002_to_003.sql": {Data: []byte("CREATE TABLE gadgets (id TEXT);")},
+		}, "failed to migrate schema from version 1 to 2"},
+		{"missing migration", fstest.MapFS{}, "no schema migration from version 1 to 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := migrations
+			migrations = tt.migrations
+			t.Cleanup(func() { migrations = saved })
+
+			path := createV1DB(t)
+			database, err := Open(path)
+			if err == nil {
+				database.Close()
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			// Rolled back: still version 1, and nothing half-created
+			if got := versionsOf(t, path); len(got) != 1 || got[0] != 1 {
+				t.Errorf("schema versions = %v, want [1]", got)
+			}
+			conn, err := sql.Open("sqlite3", path)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer conn.Close()
+			var n int
+			if err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'widgets'").Scan(&n); err != nil || n != 0 {
+				t.Errorf("widgets table left behind (count %d, err %v)", n, err)
+			}
+		})
+	}
+}
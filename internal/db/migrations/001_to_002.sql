@@ -0,0 +1,176 @@
+-- Migration from schema version 1 to 2 (the fetch/select redesign)
+--
+-- A version 1 database has the core tables: raw_messages, messages, users,
+-- channels, threads, and enrichments. Version 2 adds identity resolution,
+-- the workspace and metadata caches, extracted entities and relations, API
+-- rate limit tracking, and full-text search, which is built from the
+-- messages already stored. Columns and tables added since version 2 come
+-- with 002_to_003.sql. The runner records version 2 in schema_version in the
+-- same transaction.
+
+-- ============================================================================
+-- Identity resolution
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN canonical_id TEXT;  -- Links to identities.canonical_id
+
+CREATE INDEX IF NOT EXISTS idx_users_canonical ON users(canonical_id);
+
+CREATE TABLE IF NOT EXISTS identities (
+    canonical_id TEXT PRIMARY KEY,    -- identity_*
+    canonical_name TEXT,
+    primary_email TEXT,
+    confidence REAL DEFAULT 0.0,      -- 0.0 - 1.0
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_identities_email ON identities(primary_email);
+
+-- ============================================================================
+-- Workspaces
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id TEXT PRIMARY KEY,              -- ws_slack_T123, org_github_myorg
+    source_type TEXT NOT NULL,
+    source_id TEXT NOT NULL,          -- Original ID from source
+
+    -- Workspace info
+    name TEXT NOT NULL,
+    domain TEXT,
+
+    -- Auth context
+    authenticated_user_id TEXT,       -- The "me" for this workspace
+
+    -- Metadata
+    metadata TEXT,                    -- JSON blob
+
+    -- TTL management
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+
+    UNIQUE(source_type, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspaces_expires ON workspaces(expires_at);
+
+-- ============================================================================
+-- Full-text search, built from the messages already stored
+-- ============================================================================
+
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+    id UNINDEXED,
+    content,
+    content=messages,
+    content_rowid=rowid,
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+    INSERT INTO messages_fts(rowid, id, content) VALUES (new.rowid, new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, id, content) VALUES('delete', old.rowid, old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, id, content) VALUES('delete', old.rowid, old.id, old.content);
+    INSERT INTO messages_fts(rowid, id, content) VALUES (new.rowid, new.id, new.content);
+END;
+
+INSERT INTO messages_fts(messages_fts) VALUES('rebuild');
+
+-- ============================================================================
+-- Entities and relations
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS entities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT NOT NULL,
+    type TEXT NOT NULL,               -- user_mention, url, code_reference, technical_term, assignee, requested_reviewer
+    value TEXT NOT NULL,
+    start_pos INTEGER,
+    end_pos INTEGER,
+    metadata TEXT,                    -- JSON blob for additional data
+
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_entities_message ON entities(message_id);
+CREATE INDEX IF NOT EXISTS idx_entities_type ON entities(type);
+
+CREATE TABLE IF NOT EXISTS message_relations (
+    from_message_id TEXT NOT NULL,
+    to_message_id TEXT NOT NULL,
+    relation_type TEXT NOT NULL,      -- answers_to, solution_for, acknowledges, resolves_via, shares, duplicate_of
+    confidence REAL DEFAULT 1.0,
+
+    PRIMARY KEY (from_message_id, to_message_id, relation_type),
+    FOREIGN KEY (from_message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_relations_from ON message_relations(from_message_id);
+CREATE INDEX IF NOT EXISTS idx_relations_to ON message_relations(to_message_id);
+CREATE INDEX IF NOT EXISTS idx_relations_type ON message_relations(relation_type);
+
+-- ============================================================================
+-- Metadata cache and user interactions
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS metadata_cache (
+    cache_key TEXT PRIMARY KEY,       -- e.g., "slack_user_U123", "github_org_details"
+    source_type TEXT NOT NULL,
+    cache_type TEXT NOT NULL,         -- user_profile, channel_info, team_info
+    value TEXT NOT NULL,              -- JSON blob
+
+    -- TTL management
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+
+    -- Validation
+    validated_at TIMESTAMP,
+    is_valid BOOLEAN DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_metadata_expires ON metadata_cache(expires_at);
+CREATE INDEX IF NOT EXISTS idx_metadata_type ON metadata_cache(source_type, cache_type);
+
+CREATE TABLE IF NOT EXISTS user_interactions (
+    from_user_id TEXT NOT NULL,
+    to_user_id TEXT NOT NULL,
+    interaction_count INTEGER DEFAULT 1,
+    last_interaction TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (from_user_id, to_user_id),
+    FOREIGN KEY (from_user_id) REFERENCES users(id),
+    FOREIGN KEY (to_user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interactions_from ON user_interactions(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_interactions_to ON user_interactions(to_user_id);
+
+-- ============================================================================
+-- Rate limiting
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+    source_type TEXT NOT NULL,        -- slack, github
+    workspace_id TEXT,                -- For per-workspace limits
+    endpoint TEXT NOT NULL,           -- API endpoint or category
+
+    -- Limit tracking
+    requests_made INTEGER DEFAULT 0,
+    window_start TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    window_duration_seconds INTEGER,  -- e.g., 60 for per-minute limits
+    max_requests INTEGER,             -- e.g., 20 for Slack tier 2
+
+    -- Self-imposed safety limit (1/2 or 1/3 of max)
+    safety_limit INTEGER,
+
+    PRIMARY KEY (source_type, workspace_id, endpoint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_window ON rate_limits(window_start);
@@ -0,0 +1,55 @@
+-- Migration from schema version 2 to 3 (edits, thread titles, richer
+-- enrichments, and fetch bookkeeping)
+--
+-- Version 3 adds columns to the messages, threads, and enrichments tables,
+-- and the fetch_cursors and fetch_failures tables. Rows already stored keep
+-- NULL (or the column default) in the new columns until their messages are
+-- fetched or reprocessed again. The runner records version 3 in
+-- schema_version in the same transaction.
+
+-- ============================================================================
+-- Messages: edits and the source's own timestamp
+-- ============================================================================
+
+ALTER TABLE messages ADD COLUMN edited_at TIMESTAMP;      -- Last edit at the source, NULL if never edited
+ALTER TABLE messages ADD COLUMN edited_by TEXT;           -- users.id of whoever made that edit
+ALTER TABLE messages ADD COLUMN source_timestamp TEXT;    -- Timestamp exactly as the source wrote it
+
+-- ============================================================================
+-- Threads: titles and dismissal
+-- ============================================================================
+
+ALTER TABLE threads ADD COLUMN is_dismissed BOOLEAN DEFAULT 0;  -- Closed without resolution
+ALTER TABLE threads ADD COLUMN title TEXT;                      -- Derived from the root message
+
+-- ============================================================================
+-- Enrichments: language and content shape
+-- ============================================================================
+
+ALTER TABLE enrichments ADD COLUMN language TEXT;                   -- ISO 639-1 code
+ALTER TABLE enrichments ADD COLUMN is_code_only BOOLEAN DEFAULT 0;  -- Nothing but code
+ALTER TABLE enrichments ADD COLUMN is_link_only BOOLEAN DEFAULT 0;  -- Nothing but links
+
+-- ============================================================================
+-- Fetch bookkeeping
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS fetch_cursors (
+    source_type TEXT NOT NULL,        -- slack
+    workspace_id TEXT NOT NULL,       -- ws_slack_T123
+    channel_id TEXT NOT NULL,         -- Source-native channel ID (C123)
+    oldest_ts TEXT NOT NULL,          -- Oldest message fetched; backfill resumes before it
+    complete BOOLEAN DEFAULT 0,       -- The start of the channel's history was reached
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (source_type, workspace_id, channel_id)
+);
+
+CREATE TABLE IF NOT EXISTS fetch_failures (
+    message_id TEXT PRIMARY KEY,      -- Same ID as raw_messages
+    source_type TEXT NOT NULL,        -- slack, github
+    source_id TEXT NOT NULL,          -- Original source identifier
+    error TEXT NOT NULL,              -- Error of the last attempt
+    attempts INTEGER DEFAULT 1,       -- Failed attempts, including retries
+    failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,35 @@
+package db
+
+import "strings"
+
+// nameMatchClause returns a WHERE condition matching rows where any of columns
+// equals a name, followed by an ORDER BY. Unless caseSensitive, the name
+// matches regardless of (ASCII) case, and rows matching with the same case
+// sort first. Its arguments come from nameMatchArgs.
+func nameMatchClause(caseSensitive bool, columns ...string) string {
+	exact := make([]string, len(columns))
+	for i, column := range columns {
+		exact[i] = column + " = ?"
+	}
+	if caseSensitive {
+		return strings.Join(exact, " OR ")
+	}
+
+	folded := make([]string, len(columns))
+	for i, column := range columns {
+		folded[i] = column + " = ? COLLATE NOCASE"
+	}
+	return strings.Join(folded, " OR ") + " ORDER BY (" + strings.Join(exact, " OR ") + ") DESC"
+}
+
+// nameMatchArgs returns the arguments of nameMatchClause for n columns
+func nameMatchArgs(name string, caseSensitive bool, n int) []interface{} {
+	if !caseSensitive {
+		n *= 2
+	}
+	args := make([]interface{}, n)
+	for i := range args {
+		args[i] = name
+	}
+	return args
+}
@@ -0,0 +1,41 @@
+package db
+
+// Pull request states for SelectMessagesOptions.PRState
+const (
+	PRStateOpen   = "open"   // Not closed yet
+	PRStateMerged = "merged" // Closed by merging
+	PRStateClosed = "closed" // Closed without merging
+)
+
+// PRStates lists the pull request states messages can be filtered by
+var PRStates = []string{PRStateOpen, PRStateMerged, PRStateClosed}
+
+// IsPRState reports whether s is one of PRStates
+func IsPRState(s string) bool {
+	for _, known := range PRStates {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// messagePRState is the SQL expression for the state of the pull request
+// message m belongs to, NULL outside of pull requests. It comes from the raw
+// data of the thread root: the issues API marks pull requests with a
+// pull_request link, which has merged_at once they're merged.
+const messagePRState = `(
+		SELECT CASE
+			WHEN json_extract(r.raw_data, '$.pull_request.merged_at') IS NOT NULL THEN 'merged'
+			WHEN json_extract(r.raw_data, '$.state') = 'closed' THEN 'closed'
+			ELSE 'open'
+		END
+		FROM raw_messages r
+		WHERE r.id = COALESCE(m.thread_id, m.id) AND json_extract(r.raw_data, '$.pull_request') IS NOT NULL
+	)`
+
+// prStateFilterClause returns the WHERE condition matching messages of pull
+// requests in state, and its argument
+func prStateFilterClause(state string) (string, []interface{}) {
+	return " AND " + messagePRState + " = ?", []interface{}{state}
+}
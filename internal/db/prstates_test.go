@@ -0,0 +1,61 @@
+package db
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSelectMessages_PRState(t *testing.T) {
+	database := openTestDB(t)
+
+	// saveThread saves a thread root with raw data and a comment on it
+	saveThread := func(id, rawData string) {
+		threadID := id
+		for _, msgID := range []string{id, id + "_comment"} {
+			msg := &Message{ID: msgID, SourceType: "github", SourceID: msgID, ChannelID: "chan_github_owner_repo", ThreadID: &threadID,
+				Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), AuthorID: "user_a", Content: "content", NormalizedAt: time.Now(), SchemaVersion: "2.0"}
+			if err := database.SaveMessage(msg); err != nil {
+				t.Fatalf("failed to save message %s: %v", msgID, err)
+			}
+		}
+		if err := database.SaveRawMessage(id, "github", id, "org_owner", "chan_github_owner_repo", rawData, ""); err != nil {
+			t.Fatalf("failed to save raw message: %v", err)
+		}
+	}
+	saveThread("msg_open_pr", `{"number": 1, "state": "open", "pull_request": {"url": "u"}}`)
+	saveThread("msg_merged_pr", `{"number": 2, "state": "closed", "pull_request": {"url": "u", "merged_at": "2024-01-16T10:00:00Z"}}`)
+	saveThread("msg_closed_pr", `{"number": 3, "state": "closed", "pull_request": {"url": "u"}}`)
+	saveThread("msg_closed_issue", `{"number": 4, "state": "closed"}`)
+
+	tests := []struct {
+		name  string
+		state string
+		want  []string
+	}{
+		{"any", "", []string{"msg_closed_issue", "msg_closed_issue_comment", "msg_closed_pr", "msg_closed_pr_comment",
+			"msg_merged_pr", "msg_merged_pr_comment", "msg_open_pr", "msg_open_pr_comment"}},
+		{"open", PRStateOpen, []string{"msg_open_pr", "msg_open_pr_comment"}},
+		{"merged", PRStateMerged, []string{"msg_merged_pr", "msg_merged_pr_comment"}},
+		{"closed", PRStateClosed, []string{"msg_closed_pr", "msg_closed_pr_comment"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := database.SelectMessages(SelectMessagesOptions{PRState: tt.state})
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+
+			var got []string
+			for _, m := range messages {
+				got = append(got, m.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
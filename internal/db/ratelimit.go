@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
 // RateLimit represents API rate limiting information
@@ -47,7 +49,7 @@ func (db *DB) CheckRateLimit(sourceType string, workspaceID *string, endpoint st
 	}
 
 	// Check if window has expired
-	now := time.Now()
+	now := clock.Now()
 	windowEnd := rl.WindowStart.Add(time.Duration(rl.WindowDurationSeconds) * time.Second)
 	if now.After(windowEnd) {
 		// Reset window
@@ -97,7 +99,7 @@ func (db *DB) InitRateLimit(sourceType string, workspaceID *string, endpoint str
 			window_duration_seconds, max_requests, safety_limit
 		) VALUES (?, ?, ?, 0, ?, ?, ?, ?)
 		ON CONFLICT(source_type, workspace_id, endpoint) DO NOTHING
-	`, sourceType, wsID, endpoint, time.Now(), windowDuration, maxRequests, safetyLimit)
+	`, sourceType, wsID, endpoint, clock.Now(), windowDuration, maxRequests, safetyLimit)
 
 	if err != nil {
 		return fmt.Errorf("failed to init rate limit: %w", err)
@@ -118,7 +120,7 @@ func (db *DB) ResetRateLimitWindow(sourceType string, workspaceID *string, endpo
 		UPDATE rate_limits
 		SET requests_made = 0, window_start = ?
 		WHERE source_type = ? AND workspace_id IS ? AND endpoint = ?
-	`, time.Now(), sourceType, wsID, endpoint)
+	`, clock.Now(), sourceType, wsID, endpoint)
 
 	if err != nil {
 		return fmt.Errorf("failed to reset rate limit window: %w", err)
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RawMessage is a message as received from its source API, kept so
+// normalized data can be derived again without refetching
+type RawMessage struct {
+	ID          string
+	SourceType  string
+	SourceID    string
+	WorkspaceID string
+	ContainerID string
+	RawData     string
+	FetchedAt   time.Time
+}
+
+// RawMessagesOptions filters SelectRawMessages. Empty fields match everything.
+type RawMessagesOptions struct {
+	SourceType      string
+	ContainerID     string // Exact container (chan_github_<owner>_<repo>, Slack channel ID)
+	ContainerPrefix string // Containers starting with this (chan_github_<owner>_)
+}
+
+// SelectRawMessages returns the raw messages matching opts in the order they
+// were first saved, so thread roots come before the replies fetched with them
+func (db *DB) SelectRawMessages(opts RawMessagesOptions) ([]*RawMessage, error) {
+	query := `
+		SELECT id, source_type, source_id, COALESCE(workspace_id, ''), COALESCE(container_id, ''), raw_data, fetched_at
+		FROM raw_messages
+		WHERE 1=1`
+	args := []interface{}{}
+
+	if opts.SourceType != "" {
+		query += " AND source_type = ?"
+		args = append(args, opts.SourceType)
+	}
+	if opts.ContainerID != "" {
+		query += " AND container_id = ?"
+		args = append(args, opts.ContainerID)
+	}
+	if opts.ContainerPrefix != "" {
+		// substr rather than LIKE, where the underscores in IDs are wildcards
+		query += " AND substr(container_id, 1, ?) = ?"
+		args = append(args, len(opts.ContainerPrefix), opts.ContainerPrefix)
+	}
+	query += " ORDER BY rowid"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query raw messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*RawMessage
+	for rows.Next() {
+		msg := &RawMessage{}
+		if err := rows.Scan(&msg.ID, &msg.SourceType, &msg.SourceID, &msg.WorkspaceID, &msg.ContainerID, &msg.RawData, &msg.FetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan raw message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating raw messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetRawMessage retrieves a raw message by ID. Returns nil if it wasn't cached.
+func (db *DB) GetRawMessage(id string) (*RawMessage, error) {
+	msg := &RawMessage{}
+	err := db.QueryRow(`
+		SELECT id, source_type, source_id, COALESCE(workspace_id, ''), COALESCE(container_id, ''), raw_data, fetched_at
+		FROM raw_messages
+		WHERE id = ?
+	`, id).Scan(&msg.ID, &msg.SourceType, &msg.SourceID, &msg.WorkspaceID, &msg.ContainerID, &msg.RawData, &msg.FetchedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raw message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// LatestRawUpdate returns the latest updated_at of the raw messages from
+// sourceType whose source ID starts with sourceIDPrefix, such as the comments
+// of a GitHub issue (acme/widgets#1-comment-). It returns the zero time if
+// there are none.
+func (db *DB) LatestRawUpdate(sourceType, sourceIDPrefix string) (time.Time, error) {
+	var latest sql.NullString
+	err := db.QueryRow(`
+		SELECT MAX(json_extract(raw_data, '$.updated_at'))
+		FROM raw_messages
+		WHERE source_type = ? AND substr(source_id, 1, ?) = ?
+	`, sourceType, len(sourceIDPrefix), sourceIDPrefix).Scan(&latest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest raw message update: %w", err)
+	}
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339, latest.String)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse raw message update time %q: %w", latest.String, err)
+	}
+	return updatedAt, nil
+}
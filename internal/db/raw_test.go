@@ -0,0 +1,129 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectRawMessages(t *testing.T) {
+	database := openTestDB(t)
+
+	raws := []struct {
+		id, source, container string
+	}{
+		{"msg_github_acme_widgets_1", "github", "chan_github_acme_widgets"},
+		{"msg_slack_C1_1.0", "slack", "C1"},
+		{"msg_github_acme_my_tools_2", "github", "chan_github_acme_my_tools"},
+		{"msg_github_acmex_tools_3", "github", "chan_github_acmex_tools"},
+	}
+	for _, raw := range raws {
+		if err := database.SaveRawMessage(raw.id, raw.source, raw.id, "ws", raw.container, `{}`, ""); err != nil {
+			t.Fatalf("SaveRawMessage failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts RawMessagesOptions
+		want []string
+	}{
+		{"all, in saved order", RawMessagesOptions{}, []string{"msg_github_acme_widgets_1", "msg_slack_C1_1.0", "msg_github_acme_my_tools_2", "msg_github_acmex_tools_3"}},
+		{"source", RawMessagesOptions{SourceType: "slack"}, []string{"msg_slack_C1_1.0"}},
+		{"container", RawMessagesOptions{ContainerID: "chan_github_acme_my_tools"}, []string{"msg_github_acme_my_tools_2"}},
+		{"container prefix", RawMessagesOptions{ContainerPrefix: "chan_github_acme_"}, []string{"msg_github_acme_widgets_1", "msg_github_acme_my_tools_2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := database.SelectRawMessages(tt.opts)
+			if err != nil {
+				t.Fatalf("SelectRawMessages failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d raw messages, got %d", len(tt.want), len(got))
+			}
+			for i, raw := range got {
+				if raw.ID != tt.want[i] {
+					t.Errorf("raw message %d: expected %s, got %s", i, tt.want[i], raw.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveRawMessage_FetchedAt(t *testing.T) {
+	database := openTestDB(t)
+
+	fetched := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	if err := database.SaveRawMessage("msg_1", "github", "acme/widgets#1", "ws", "chan", `{"body":"a"}`, ""); err != nil {
+		t.Fatalf("SaveRawMessage failed: %v", err)
+	}
+	if _, err := database.Exec("UPDATE raw_messages SET fetched_at = ?", fetched); err != nil {
+		t.Fatalf("failed to backdate: %v", err)
+	}
+
+	fetchedAt := func() time.Time {
+		t.Helper()
+		raws, err := database.SelectRawMessages(RawMessagesOptions{})
+		if err != nil || len(raws) != 1 {
+			t.Fatalf("SelectRawMessages = %v, %v", raws, err)
+		}
+		return raws[0].FetchedAt
+	}
+
+	// Saving the same data again keeps when it was fetched
+	if err := database.SaveRawMessage("msg_1", "github", "acme/widgets#1", "ws", "chan", `{"body":"a"}`, ""); err != nil {
+		t.Fatalf("SaveRawMessage failed: %v", err)
+	}
+	if got := fetchedAt(); !got.Equal(fetched) {
+		t.Errorf("fetched_at = %v after identical save, want %v", got, fetched)
+	}
+
+	// Changed data is a new fetch
+	if err := database.SaveRawMessage("msg_1", "github", "acme/widgets#1", "ws", "chan", `{"body":"b"}`, ""); err != nil {
+		t.Fatalf("SaveRawMessage failed: %v", err)
+	}
+	if got := fetchedAt(); got.Equal(fetched) {
+		t.Error("fetched_at unchanged after saving new data")
+	}
+}
+
+func TestLatestRawUpdate(t *testing.T) {
+	database := openTestDB(t)
+
+	raws := []struct {
+		id, sourceID, data string
+	}{
+		{"msg_github_acme_widgets_1", "acme/widgets#1", `{"updated_at": "2024-03-05T00:00:00Z"}`},
+		{"msg_github_acme_widgets_1_comment_100", "acme/widgets#1-comment-100", `{"updated_at": "2024-03-01T00:00:00Z"}`},
+		{"msg_github_acme_widgets_1_comment_101", "acme/widgets#1-comment-101", `{"updated_at": "2024-03-02T08:30:00Z"}`},
+		{"msg_github_acme_widgets_12_comment_102", "acme/widgets#12-comment-102", `{"updated_at": "2024-03-09T00:00:00Z"}`},
+		{"msg_github_acme_widgets_2_comment_103", "acme/widgets#2-comment-103", `{}`},
+	}
+	for _, raw := range raws {
+		if err := database.SaveRawMessage(raw.id, "github", raw.sourceID, "ws", "chan_github_acme_widgets", raw.data, ""); err != nil {
+			t.Fatalf("SaveRawMessage failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   time.Time
+	}{
+		{"acme/widgets#1-comment-", time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC)},
+		{"acme/widgets#2-comment-", time.Time{}},
+		{"acme/widgets#3-comment-", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got, err := database.LatestRawUpdate("github", tt.prefix)
+			if err != nil {
+				t.Fatalf("LatestRawUpdate failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("LatestRawUpdate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// topResponderCount is how many responders ResponseTimes lists per channel
+const topResponderCount = 3
+
+// FirstResponse is the first reply to a thread's root by someone other than
+// its author
+type FirstResponse struct {
+	ThreadID    string
+	ChannelID   string
+	ChannelName string // Empty if the channel wasn't stored
+	AskerID     string
+	AskedAt     time.Time
+	ResponderID string    // Empty if nobody else replied
+	RespondedAt time.Time // Zero if nobody else replied
+}
+
+// Answered reports whether someone other than the asker replied
+func (r *FirstResponse) Answered() bool {
+	return r.ResponderID != ""
+}
+
+// ResponseTime returns the time from the root to the first response, zero if
+// nobody else replied
+func (r *FirstResponse) ResponseTime() time.Duration {
+	if !r.Answered() {
+		return 0
+	}
+	return r.RespondedAt.Sub(r.AskedAt)
+}
+
+// ResponderCount is the number of threads a user replied to first
+type ResponderCount struct {
+	UserID    string `json:"user_id"`
+	Responses int    `json:"responses"`
+}
+
+// ChannelResponseTimes summarizes how quickly the threads of a channel get a
+// first response
+type ChannelResponseTimes struct {
+	ChannelID             string           `json:"channel_id"`
+	ChannelName           string           `json:"channel_name"`
+	Threads               int              `json:"threads"`
+	Answered              int              `json:"answered"`
+	MedianResponseSeconds float64          `json:"median_response_seconds"` // Over answered threads
+	TopResponders         []ResponderCount `json:"top_responders"`          // Who replied first most often
+}
+
+// FirstResponses returns the first response to each thread whose root matches
+// the filters in opts, ordered by channel and then by when the thread started.
+// Replies are ordered by timestamp, then ID.
+func (db *DB) FirstResponses(opts SelectMessagesOptions) ([]*FirstResponse, error) {
+	filters, args, err := messageFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH roots AS (
+			SELECT m.id, m.channel_id, m.author_id, m.timestamp
+			FROM messages m` + filters + ` AND m.is_thread_root
+		),
+		replies AS (
+			SELECT roots.id AS root_id, a.author_id, a.timestamp,
+			       ROW_NUMBER() OVER (PARTITION BY roots.id ORDER BY a.timestamp, a.id) AS n
+			FROM roots
+			JOIN messages a ON a.thread_id = roots.id AND a.id != roots.id AND a.author_id != roots.author_id
+		)
+		SELECT roots.id, roots.channel_id, COALESCE(c.name, ''), roots.author_id, roots.timestamp,
+		       replies.author_id, replies.timestamp
+		FROM roots
+		LEFT JOIN replies ON replies.root_id = roots.id AND replies.n = 1
+		LEFT JOIN channels c ON c.id = roots.channel_id
+		ORDER BY roots.channel_id, roots.timestamp, roots.id`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select first responses: %w", err)
+	}
+	defer rows.Close()
+
+	responses := []*FirstResponse{}
+	for rows.Next() {
+		r := &FirstResponse{}
+		var responderID sql.NullString
+		var respondedAt sql.NullTime
+		if err := rows.Scan(&r.ThreadID, &r.ChannelID, &r.ChannelName, &r.AskerID, &r.AskedAt,
+			&responderID, &respondedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan first response: %w", err)
+		}
+		r.ResponderID = responderID.String
+		r.RespondedAt = respondedAt.Time
+		responses = append(responses, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating first responses: %w", err)
+	}
+
+	return responses, nil
+}
+
+// ResponseTimes returns, for each channel with thread roots matching the
+// filters in opts, how many of its threads got a response from someone other
+// than the asker, how long the first response took (the median), and who
+// responded first most often. Channels with the most threads come first.
+func (db *DB) ResponseTimes(opts SelectMessagesOptions) ([]*ChannelResponseTimes, error) {
+	responses, err := db.FirstResponses(opts)
+	if err != nil {
+		return nil, err
+	}
+	return SummarizeResponseTimes(responses), nil
+}
+
+// SummarizeResponseTimes aggregates first responses per channel (see
+// ResponseTimes)
+func SummarizeResponseTimes(responses []*FirstResponse) []*ChannelResponseTimes {
+	var channels []*ChannelResponseTimes
+	byChannel := make(map[string]*ChannelResponseTimes)
+	times := make(map[string][]time.Duration)
+	responders := make(map[string]map[string]int)
+	for _, r := range responses {
+		summary, ok := byChannel[r.ChannelID]
+		if !ok {
+			summary = &ChannelResponseTimes{ChannelID: r.ChannelID, ChannelName: r.ChannelName, TopResponders: []ResponderCount{}}
+			byChannel[r.ChannelID] = summary
+			responders[r.ChannelID] = make(map[string]int)
+			channels = append(channels, summary)
+		}
+		summary.Threads++
+		if r.Answered() {
+			summary.Answered++
+			times[r.ChannelID] = append(times[r.ChannelID], r.ResponseTime())
+			responders[r.ChannelID][r.ResponderID]++
+		}
+	}
+
+	for _, summary := range channels {
+		summary.MedianResponseSeconds = medianDuration(times[summary.ChannelID]).Seconds()
+		for userID, n := range responders[summary.ChannelID] {
+			summary.TopResponders = append(summary.TopResponders, ResponderCount{UserID: userID, Responses: n})
+		}
+		sort.Slice(summary.TopResponders, func(i, j int) bool {
+			a, b := summary.TopResponders[i], summary.TopResponders[j]
+			if a.Responses != b.Responses {
+				return a.Responses > b.Responses
+			}
+			return a.UserID < b.UserID
+		})
+		if len(summary.TopResponders) > topResponderCount {
+			summary.TopResponders = summary.TopResponders[:topResponderCount]
+		}
+	}
+
+	sort.SliceStable(channels, func(i, j int) bool {
+		return channels[i].Threads > channels[j].Threads
+	})
+	return channels
+}
+
+// medianDuration returns the median of durations, zero if there are none
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
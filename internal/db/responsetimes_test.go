@@ -0,0 +1,131 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResponseTimes(t *testing.T) {
+	database := openTestDB(t)
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	save := func(id, channelID, authorID string, threadID string, at time.Time) {
+		msg := &Message{ID: id, SourceType: "slack", SourceID: id, Timestamp: at, AuthorID: authorID, Content: "content",
+			ChannelID: channelID, NormalizedAt: at, SchemaVersion: "2.0"}
+		if threadID != "" {
+			msg.ThreadID = &threadID
+			msg.IsThreadRoot = threadID == id
+		}
+		if err := database.SaveMessage(msg); err != nil {
+			t.Fatalf("failed to save message %s: %v", id, err)
+		}
+	}
+
+	// help: answered right away by bob, answered an hour later by carol after
+	// the asker followed up, answered by bob in 3 minutes, and never answered
+	save("msg_fast", "chan_help", "user_alice", "msg_fast", base)
+	save("msg_fast_1", "chan_help", "user_bob", "msg_fast", base.Add(30*time.Second))
+	save("msg_fast_2", "chan_help", "user_carol", "msg_fast", base.Add(time.Minute))
+	save("msg_slow", "chan_help", "user_dave", "msg_slow", base.Add(time.Hour))
+	save("msg_slow_1", "chan_help", "user_dave", "msg_slow", base.Add(time.Hour+time.Minute))
+	save("msg_slow_2", "chan_help", "user_carol", "msg_slow", base.Add(2*time.Hour))
+	save("msg_mid", "chan_help", "user_erin", "msg_mid", base.Add(3*time.Hour))
+	save("msg_mid_1", "chan_help", "user_bob", "msg_mid", base.Add(3*time.Hour+3*time.Minute))
+	save("msg_alone", "chan_help", "user_alice", "msg_alone", base.Add(4*time.Hour))
+	save("msg_alone_1", "chan_help", "user_alice", "msg_alone", base.Add(5*time.Hour))
+	save("msg_solo", "chan_help", "user_alice", "", base.Add(6*time.Hour))
+	now := time.Now()
+	if err := database.SaveChannel(&Channel{ID: "chan_help", SourceType: "slack", SourceID: "C1", Name: "help", FetchedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("SaveChannel failed: %v", err)
+	}
+
+	// old (not stored as a channel): a thread from long ago
+	save("msg_old", "chan_old", "user_alice", "msg_old", base.AddDate(-1, 0, 0))
+	save("msg_old_1", "chan_old", "user_bob", "msg_old", base.AddDate(-1, 0, 0).Add(10*time.Second))
+
+	helpID, oldID := "chan_help", "chan_old"
+	t.Run("first responses", func(t *testing.T) {
+		got, err := database.FirstResponses(SelectMessagesOptions{ChannelID: &helpID})
+		if err != nil {
+			t.Fatalf("FirstResponses failed: %v", err)
+		}
+		want := map[string]struct {
+			responder string
+			took      time.Duration
+		}{
+			"msg_fast":  {"user_bob", 30 * time.Second},
+			"msg_slow":  {"user_carol", time.Hour},
+			"msg_mid":   {"user_bob", 3 * time.Minute},
+			"msg_alone": {"", 0},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d first responses, want %d", len(got), len(want))
+		}
+		for _, r := range got {
+			w, ok := want[r.ThreadID]
+			if !ok {
+				t.Errorf("unexpected thread %s", r.ThreadID)
+				continue
+			}
+			if r.ResponderID != w.responder || r.ResponseTime() != w.took {
+				t.Errorf("thread %s: first response by %q after %v, want %q after %v",
+					r.ThreadID, r.ResponderID, r.ResponseTime(), w.responder, w.took)
+			}
+			if r.ChannelName != "help" {
+				t.Errorf("thread %s: ChannelName = %q, want help", r.ThreadID, r.ChannelName)
+			}
+		}
+	})
+
+	help := &ChannelResponseTimes{ChannelID: "chan_help", ChannelName: "help", Threads: 4, Answered: 3,
+		MedianResponseSeconds: 180, TopResponders: []ResponderCount{{"user_bob", 2}, {"user_carol", 1}}}
+	old := &ChannelResponseTimes{ChannelID: "chan_old", Threads: 1, Answered: 1,
+		MedianResponseSeconds: 10, TopResponders: []ResponderCount{{"user_bob", 1}}}
+
+	since := base.AddDate(0, -1, 0)
+	tests := []struct {
+		name string
+		opts SelectMessagesOptions
+		want []*ChannelResponseTimes
+	}{
+		{"all", SelectMessagesOptions{}, []*ChannelResponseTimes{help, old}},
+		{"since", SelectMessagesOptions{Since: &since}, []*ChannelResponseTimes{help}},
+		{"channel", SelectMessagesOptions{ChannelID: &oldID}, []*ChannelResponseTimes{old}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := database.ResponseTimes(tt.opts)
+			if err != nil {
+				t.Fatalf("ResponseTimes failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				for _, r := range got {
+					t.Logf("got %+v", r)
+				}
+				t.Errorf("unexpected response times")
+			}
+		})
+	}
+}
+
+func TestMedianDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{"none", nil, 0},
+		{"odd", []time.Duration{3 * time.Minute, time.Second, time.Hour}, 3 * time.Minute},
+		{"even", []time.Duration{time.Hour, time.Minute, 3 * time.Minute, time.Second}, 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := medianDuration(tt.durations); got != tt.want {
+				t.Errorf("medianDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Retry settings for SQLITE_BUSY and SQLITE_LOCKED errors. These apply after
+// the driver's own busy timeout (see BusyTimeout) has been exhausted, e.g. when
+// another mine process holds a long write transaction.
+const (
+	defaultBusyRetries = 6
+	defaultBusyBackoff = 50 * time.Millisecond
+	maxBusyBackoff     = 2 * time.Second
+)
+
+// BusyTimeout is how long SQLite waits on a locked database before returning
+// SQLITE_BUSY. It applies to databases opened after it's set.
+var BusyTimeout = 5 * time.Second
+
+// BusyRetries is how many times a statement (or schema initialization) that
+// failed with SQLITE_BUSY is retried, with exponential backoff, by databases
+// opened after it's set
+var BusyRetries = defaultBusyRetries
+
+// isBusyError reports whether err means the database was locked by another connection
+func isBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
+
+// withBusyRetry calls fn, retrying with exponential backoff while it fails with a busy error
+func (db *DB) withBusyRetry(fn func() error) error {
+	backoff := db.busyBackoff
+	err := fn()
+	for attempt := 0; attempt < db.busyRetries && isBusyError(err); attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxBusyBackoff {
+			backoff = maxBusyBackoff
+		}
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestIsBusyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"busy", sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{"locked", sqlite3.Error{Code: sqlite3.ErrLocked}, true},
+		{"wrapped busy", fmt.Errorf("failed to save: %w", sqlite3.Error{Code: sqlite3.ErrBusy}), true},
+		{"constraint", sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{"message", errors.New("database is locked"), true},
+		{"other", errors.New("no such table: messages"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBusyError(tt.err); got != tt.want {
+				t.Errorf("isBusyError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithBusyRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		failErr   error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", 0, nil, 1, false},
+		{"succeeds after busy", 2, sqlite3.Error{Code: sqlite3.ErrBusy}, 3, false},
+		{"gives up after cap", 10, sqlite3.Error{Code: sqlite3.ErrBusy}, 4, true},
+		{"does not retry other errors", 1, errors.New("syntax error"), 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &DB{busyRetries: 3, busyBackoff: time.Millisecond}
+
+			calls := 0
+			err := db.withBusyRetry(func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failErr
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error result: %v", err)
+			}
+		})
+	}
+}
+
+func TestExec_RetriesUnderContention(t *testing.T) {
+	// Use a short driver timeout so contention surfaces as SQLITE_BUSY quickly
+	saved := BusyTimeout
+	BusyTimeout = 10 * time.Millisecond
+	defer func() { BusyTimeout = saved }()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	writer := openTestDBAt(t, path)
+	reader := openTestDBAt(t, path)
+
+	// Hold the write lock from one connection
+	tx, err := writer.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO users (id, source_type, source_id, display_name) VALUES ('u1', 'slack', 'U1', 'alice')`); err != nil {
+		t.Fatalf("failed to write in transaction: %v", err)
+	}
+
+	// Without retries, a concurrent write fails
+	reader.busyRetries = 0
+	if _, err := reader.Exec(`INSERT INTO users (id, source_type, source_id, display_name) VALUES ('u2', 'slack', 'U2', 'bob')`); !isBusyError(err) {
+		t.Fatalf("expected busy error without retries, got %v", err)
+	}
+
+	// Release the lock while the second connection is retrying
+	reader.busyRetries = defaultBusyRetries
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		tx.Commit()
+	}()
+
+	if _, err := reader.Exec(`INSERT INTO users (id, source_type, source_id, display_name) VALUES ('u2', 'slack', 'U2', 'bob')`); err != nil {
+		t.Fatalf("expected write to succeed after retry, got %v", err)
+	}
+
+	var count int
+	if err := reader.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		t.Fatalf("failed to count users: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 users, got %d", count)
+	}
+}
+
+func TestOpen_RetriesLockedSchemaInit(t *testing.T) {
+	saved, savedRetries := BusyTimeout, BusyRetries
+	BusyTimeout = 10 * time.Millisecond
+	defer func() { BusyTimeout, BusyRetries = saved, savedRetries }()
+
+	// Another connection holds the write lock on a database without a schema
+	path := filepath.Join(t.TempDir(), "test.db")
+	locker, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL", path))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer locker.Close()
+	ctx := context.Background()
+	conn, err := locker.Conn(ctx)
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("failed to lock database: %v", err)
+	}
+
+	// Without retries, initialization fails and leaves no tables behind
+	BusyRetries = 0
+	if _, err := Open(path); !isBusyError(err) {
+		t.Fatalf("expected busy error without retries, got %v", err)
+	}
+	var tables int
+	if err := locker.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+		t.Fatalf("failed to count tables: %v", err)
+	}
+	if tables != 0 {
+		t.Errorf("failed initialization left %d tables", tables)
+	}
+
+	// Release the lock while Open is retrying
+	BusyRetries = defaultBusyRetries
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		conn.ExecContext(ctx, "COMMIT")
+	}()
+
+	database := openTestDBAt(t, path)
+	version, err := database.schemaVersion()
+	if err != nil {
+		t.Fatalf("schemaVersion failed: %v", err)
+	}
+	if version != SchemaVersion {
+		t.Errorf("schema version = %d, want %d", version, SchemaVersion)
+	}
+}
@@ -40,6 +40,7 @@ CREATE TABLE IF NOT EXISTS messages (
 
     -- Temporal info
     timestamp TIMESTAMP NOT NULL,
+    source_timestamp TEXT,            -- Timestamp exactly as the source wrote it
 
     -- Author
     author_id TEXT NOT NULL,          -- Foreign key to users.id
@@ -60,6 +61,10 @@ CREATE TABLE IF NOT EXISTS messages (
     code_blocks TEXT,                 -- JSON array of code blocks
     attachments TEXT,                 -- JSON array of attachments
 
+    -- Edits
+    edited_at TIMESTAMP,              -- Last edit at the source, NULL if never edited
+    edited_by TEXT,                   -- users.id of whoever made that edit
+
     -- Provenance
     normalized_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     schema_version TEXT DEFAULT '2.0',
@@ -76,11 +81,13 @@ CREATE INDEX idx_messages_source ON messages(source_type);
 
 -- Full-text search on message content (FTS5)
 -- Build with: go build -tags "fts5"
+-- The tokenizer is replaced on open when store.fts_tokenizer differs (see fts.go)
 CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
     id UNINDEXED,
     content,
     content=messages,
-    content_rowid=rowid
+    content_rowid=rowid,
+    tokenize = 'porter unicode61 remove_diacritics 2'
 );
 
 -- Triggers to keep FTS index in sync
@@ -203,6 +210,7 @@ CREATE INDEX idx_workspaces_expires ON workspaces(expires_at);
 CREATE TABLE IF NOT EXISTS threads (
     id TEXT PRIMARY KEY,              -- thread_*
     root_message_id TEXT NOT NULL,   -- Foreign key to messages.id
+    title TEXT,                       -- Derived from the root message
     channel_id TEXT NOT NULL,
 
     -- Structure
@@ -218,6 +226,7 @@ CREATE TABLE IF NOT EXISTS threads (
     has_question BOOLEAN DEFAULT 0,
     has_answer BOOLEAN DEFAULT 0,
     is_resolved BOOLEAN DEFAULT 0,
+    is_dismissed BOOLEAN DEFAULT 0,   -- Closed without resolution (issue closed as not planned)
 
     -- Metadata
     participants TEXT,                -- JSON array of user IDs
@@ -252,6 +261,11 @@ CREATE TABLE IF NOT EXISTS enrichments (
     has_code BOOLEAN DEFAULT 0,
     has_links BOOLEAN DEFAULT 0,
     has_quotes BOOLEAN DEFAULT 0,
+    is_code_only BOOLEAN DEFAULT 0,   -- Nothing but code, like a pasted log
+    is_link_only BOOLEAN DEFAULT 0,   -- Nothing but links
+
+    -- Language (ISO 639-1 code, empty if undetermined, NULL if enriched before detection)
+    language TEXT,
 
     -- Provenance
     enriched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -266,7 +280,7 @@ CREATE INDEX idx_enrichments_has_code ON enrichments(has_code);
 CREATE TABLE IF NOT EXISTS entities (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     message_id TEXT NOT NULL,
-    type TEXT NOT NULL,               -- user_mention, url, code_reference, technical_term
+    type TEXT NOT NULL,               -- user_mention, url, code_reference, technical_term, assignee, requested_reviewer
     value TEXT NOT NULL,
     start_pos INTEGER,
     end_pos INTEGER,
@@ -282,7 +296,7 @@ CREATE INDEX idx_entities_type ON entities(type);
 CREATE TABLE IF NOT EXISTS message_relations (
     from_message_id TEXT NOT NULL,
     to_message_id TEXT NOT NULL,
-    relation_type TEXT NOT NULL,      -- answers_to, solution_for, acknowledges
+    relation_type TEXT NOT NULL,      -- answers_to, solution_for, acknowledges, resolves_via, shares, duplicate_of
     confidence REAL DEFAULT 1.0,
 
     PRIMARY KEY (from_message_id, to_message_id, relation_type),
@@ -355,5 +369,32 @@ CREATE TABLE IF NOT EXISTS rate_limits (
 
 CREATE INDEX idx_rate_limits_window ON rate_limits(window_start);
 
+-- ============================================================================
+-- Fetch bookkeeping
+-- ============================================================================
+
+-- How far back each channel's history has been fetched (see cursors.go)
+CREATE TABLE IF NOT EXISTS fetch_cursors (
+    source_type TEXT NOT NULL,        -- slack
+    workspace_id TEXT NOT NULL,       -- ws_slack_T123
+    channel_id TEXT NOT NULL,         -- Source-native channel ID (C123)
+    oldest_ts TEXT NOT NULL,          -- Oldest message fetched; backfill resumes before it
+    complete BOOLEAN DEFAULT 0,       -- The start of the channel's history was reached
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (source_type, workspace_id, channel_id)
+);
+
+-- Messages whose raw data was saved but that failed to normalize or store,
+-- to retry from the raw cache (see failures.go)
+CREATE TABLE IF NOT EXISTS fetch_failures (
+    message_id TEXT PRIMARY KEY,      -- Same ID as raw_messages
+    source_type TEXT NOT NULL,        -- slack, github
+    source_id TEXT NOT NULL,          -- Original source identifier
+    error TEXT NOT NULL,              -- Error of the last attempt
+    attempts INTEGER DEFAULT 1,       -- Failed attempts, including retries
+    failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Insert initial schema version
-INSERT INTO schema_version (version) VALUES (2);
+INSERT INTO schema_version (version) VALUES (3);
@@ -0,0 +1,149 @@
+-- A version 1 database, as an older mine left it: the core tables, without
+-- full-text search, identity resolution, or the caches, holding one Slack
+-- thread (a question and its answer).
+
+CREATE TABLE schema_version (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO schema_version (version) VALUES (1);
+
+CREATE TABLE raw_messages (
+    id TEXT PRIMARY KEY,
+    source_type TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    workspace_id TEXT,
+    container_id TEXT,
+    raw_data TEXT NOT NULL,
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    fetch_query TEXT,
+    UNIQUE(source_type, source_id, workspace_id)
+);
+
+CREATE INDEX idx_raw_messages_source ON raw_messages(source_type, workspace_id, container_id);
+CREATE INDEX idx_raw_messages_fetched ON raw_messages(fetched_at);
+
+CREATE TABLE messages (
+    id TEXT PRIMARY KEY,
+    source_type TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    author_id TEXT NOT NULL,
+    content TEXT NOT NULL,
+    content_html TEXT,
+    channel_id TEXT NOT NULL,
+    thread_id TEXT,
+    parent_id TEXT,
+    is_thread_root BOOLEAN DEFAULT 0,
+    mentions TEXT,
+    urls TEXT,
+    code_blocks TEXT,
+    attachments TEXT,
+    normalized_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    schema_version TEXT DEFAULT '1.0',
+    FOREIGN KEY (author_id) REFERENCES users(id),
+    FOREIGN KEY (channel_id) REFERENCES channels(id)
+);
+
+CREATE INDEX idx_messages_timestamp ON messages(timestamp);
+CREATE INDEX idx_messages_author ON messages(author_id);
+CREATE INDEX idx_messages_channel ON messages(channel_id);
+CREATE INDEX idx_messages_thread ON messages(thread_id);
+CREATE INDEX idx_messages_source ON messages(source_type);
+
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    source_type TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    display_name TEXT,
+    real_name TEXT,
+    email TEXT,
+    avatar_url TEXT,
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_type, source_id)
+);
+
+CREATE INDEX idx_users_email ON users(email);
+CREATE INDEX idx_users_source ON users(source_type, source_id);
+
+CREATE TABLE channels (
+    id TEXT PRIMARY KEY,
+    source_type TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    workspace_id TEXT,
+    name TEXT NOT NULL,
+    display_name TEXT,
+    type TEXT,
+    is_private BOOLEAN DEFAULT 0,
+    parent_space TEXT,
+    metadata TEXT,
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_type, source_id, workspace_id)
+);
+
+CREATE INDEX idx_channels_workspace ON channels(workspace_id);
+CREATE INDEX idx_channels_source ON channels(source_type);
+
+CREATE TABLE threads (
+    id TEXT PRIMARY KEY,
+    root_message_id TEXT NOT NULL,
+    channel_id TEXT NOT NULL,
+    message_count INTEGER DEFAULT 0,
+    participant_count INTEGER DEFAULT 0,
+    max_depth INTEGER DEFAULT 0,
+    started_at TIMESTAMP NOT NULL,
+    last_activity_at TIMESTAMP NOT NULL,
+    has_question BOOLEAN DEFAULT 0,
+    has_answer BOOLEAN DEFAULT 0,
+    is_resolved BOOLEAN DEFAULT 0,
+    participants TEXT,
+    analyzed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (root_message_id) REFERENCES messages(id),
+    FOREIGN KEY (channel_id) REFERENCES channels(id)
+);
+
+CREATE INDEX idx_threads_channel ON threads(channel_id);
+CREATE INDEX idx_threads_resolved ON threads(is_resolved);
+CREATE INDEX idx_threads_activity ON threads(last_activity_at);
+
+CREATE TABLE enrichments (
+    message_id TEXT PRIMARY KEY,
+    is_question BOOLEAN DEFAULT 0,
+    char_count INTEGER NOT NULL,
+    word_count INTEGER NOT NULL,
+    has_code BOOLEAN DEFAULT 0,
+    has_links BOOLEAN DEFAULT 0,
+    has_quotes BOOLEAN DEFAULT 0,
+    enriched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_enrichments_is_question ON enrichments(is_question);
+CREATE INDEX idx_enrichments_has_code ON enrichments(has_code);
+
+INSERT INTO users (id, source_type, source_id, display_name) VALUES
+    ('user_slack_U1', 'slack', 'U1', 'alice'),
+    ('user_slack_U2', 'slack', 'U2', 'bob');
+
+INSERT INTO channels (id, source_type, source_id, workspace_id, name, type) VALUES
+    ('chan_slack_C1', 'slack', 'C1', 'ws_slack_T1', 'help', 'channel');
+
+INSERT INTO raw_messages (id, source_type, source_id, workspace_id, container_id, raw_data) VALUES
+    ('msg_slack_C1_1.0', 'slack', 'C1_1.0', 'ws_slack_T1', 'C1', '{"user": "U1", "text": "How do I rotate the deploy key?", "ts": "1.0", "thread_ts": "1.0"}');
+
+INSERT INTO messages (id, source_type, source_id, timestamp, author_id, content, channel_id, thread_id, parent_id,
+                      is_thread_root, mentions, urls, code_blocks, attachments) VALUES
+    ('msg_slack_C1_1.0', 'slack', 'C1_1.0', '2024-01-15 10:00:00+00:00', 'user_slack_U1', 'How do I rotate the deploy key?',
+     'chan_slack_C1', 'msg_slack_C1_1.0', NULL, 1, '[]', '[]', '[]', '[]'),
+    ('msg_slack_C1_2.0', 'slack', 'C1_2.0', '2024-01-15 10:05:00+00:00', 'user_slack_U2', 'Run keys rotate with the prod environment',
+     'chan_slack_C1', 'msg_slack_C1_1.0', 'msg_slack_C1_1.0', 0, '[]', '[]', '[]', '[]');
+
+INSERT INTO threads (id, root_message_id, channel_id, message_count, participant_count, max_depth,
+                     started_at, last_activity_at, is_resolved, participants) VALUES
+    ('msg_slack_C1_1.0', 'msg_slack_C1_1.0', 'chan_slack_C1', 2, 2, 1,
+     '2024-01-15 10:00:00+00:00', '2024-01-15 10:05:00+00:00', 1, '["user_slack_U1","user_slack_U2"]');
+
+INSERT INTO enrichments (message_id, is_question, char_count, word_count) VALUES
+    ('msg_slack_C1_1.0', 1, 31, 7);
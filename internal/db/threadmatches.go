@@ -0,0 +1,128 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ThreadMatch is a thread with messages matching a query: its root message and
+// aggregates over the whole thread, including messages that don't match
+type ThreadMatch struct {
+	ThreadID         string
+	Root             *Message  // The thread's root, or its earliest message if the root wasn't fetched
+	MatchCount       int       // Messages in the thread matching the query
+	ReplyCount       int       // Messages in the thread besides the root
+	ParticipantCount int       // Distinct authors in the thread
+	LastActivityAt   time.Time // Latest message in the thread
+	Resolved         bool      // From the thread summary, if the thread was summarized
+	Dismissed        bool      // From the thread summary, if the thread was summarized
+}
+
+// SelectThreads returns one ThreadMatch for each thread with messages matching
+// the filters in opts. A message outside any thread is a thread of its own.
+// Threads are ordered newest first by when they started, or by their latest
+// message with SortLastActivity; Limit and Offset page through the threads.
+// Resolved and Dismissed are left for the caller (see GetThread).
+func (db *DB) SelectThreads(opts SelectMessagesOptions) ([]*ThreadMatch, error) {
+	filters, args, err := messageFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH matched AS (
+			SELECT COALESCE(m.thread_id, m.id) AS thread_key, COUNT(*) AS matches
+			FROM messages m` + filters + `
+			GROUP BY thread_key
+		)
+		SELECT matched.thread_key, matched.matches,
+		       COALESCE(
+		           (SELECT r.id FROM messages r WHERE r.id = matched.thread_key),
+		           (SELECT r.id FROM messages r WHERE r.thread_id = matched.thread_key ORDER BY r.timestamp, r.id LIMIT 1)
+		       ) AS root_id,
+		       COUNT(a.id), COUNT(DISTINCT a.author_id), MAX(a.timestamp)
+		FROM matched
+		JOIN messages a ON a.thread_id = matched.thread_key OR (a.thread_id IS NULL AND a.id = matched.thread_key)
+		GROUP BY matched.thread_key`
+
+	switch opts.Sort {
+	case "", SortTimestamp:
+		query += " ORDER BY MIN(a.timestamp) DESC, matched.thread_key"
+	case SortLastActivity:
+		query += " ORDER BY MAX(a.timestamp) DESC, matched.thread_key"
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", opts.Sort)
+	}
+
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	if opts.Offset > 0 {
+		if opts.Limit <= 0 {
+			query += " LIMIT -1"
+		}
+		query += " OFFSET ?"
+		args = append(args, opts.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select threads: %w", err)
+	}
+
+	type threadRow struct {
+		match  *ThreadMatch
+		rootID sql.NullString
+	}
+	var found []threadRow
+	for rows.Next() {
+		var row threadRow
+		var messageCount int
+		var lastActivity string
+		row.match = &ThreadMatch{}
+		if err := rows.Scan(&row.match.ThreadID, &row.match.MatchCount, &row.rootID,
+			&messageCount, &row.match.ParticipantCount, &lastActivity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan thread: %w", err)
+		}
+		row.match.ReplyCount = messageCount - 1
+		if row.match.LastActivityAt, err = parseSQLiteTime(lastActivity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found = append(found, row)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating threads: %w", err)
+	}
+	rows.Close()
+
+	// Load the roots once the query is done with its connection
+	threads := make([]*ThreadMatch, 0, len(found))
+	for _, row := range found {
+		if row.rootID.Valid {
+			if row.match.Root, err = db.GetMessage(row.rootID.String); err != nil {
+				return nil, err
+			}
+		}
+		threads = append(threads, row.match)
+	}
+
+	return threads, nil
+}
+
+// parseSQLiteTime parses a timestamp the SQLite driver stored, for aggregates
+// like MAX(timestamp) that the driver returns as text
+func parseSQLiteTime(value string) (time.Time, error) {
+	for _, format := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp: %s", value)
+}
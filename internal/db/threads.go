@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Thread is the stored summary of a conversation thread, derived from its
+// messages at ingestion so thread views don't need to walk the reply graph
+type Thread struct {
+	ID               string
+	RootMessageID    string
+	Title            string // Short title derived from the root (normalize.DeriveThreadTitle)
+	ChannelID        string
+	ReplyCount       int
+	ParticipantCount int
+	MaxDepth         int
+	StartedAt        time.Time
+	LastActivityAt   time.Time
+	Participants     []string
+	Resolved         bool // The source marks the conversation resolved (e.g. a resolved PR review thread)
+	Dismissed        bool // The source closed the conversation without resolving it (e.g. an issue closed as not planned)
+}
+
+// Thread states for SelectMessagesOptions.ThreadState, from the thread
+// summaries. Threads without a summary are open.
+const (
+	ThreadStateOpen      = "open"      // Neither resolved nor dismissed
+	ThreadStateResolved  = "resolved"  // See Thread.Resolved
+	ThreadStateDismissed = "dismissed" // See Thread.Dismissed
+)
+
+// threadStateFilterClause returns the SQL condition matching messages (m)
+// whose thread is in state
+func threadStateFilterClause(state string) (string, error) {
+	const summary = "SELECT 1 FROM threads t WHERE t.id = COALESCE(m.thread_id, m.id)"
+	switch state {
+	case ThreadStateOpen:
+		return " AND NOT EXISTS (" + summary + " AND (t.is_resolved OR t.is_dismissed))", nil
+	case ThreadStateResolved:
+		return " AND EXISTS (" + summary + " AND t.is_resolved)", nil
+	case ThreadStateDismissed:
+		return " AND EXISTS (" + summary + " AND t.is_dismissed AND NOT t.is_resolved)", nil
+	default:
+		return "", fmt.Errorf("unknown thread state: %s", state)
+	}
+}
+
+// SaveThread saves (upserts) a thread summary
+func (db *DB) SaveThread(thread *Thread) error {
+	participants, err := json.Marshal(thread.Participants)
+	if err != nil {
+		return fmt.Errorf("failed to marshal participants: %w", err)
+	}
+
+	// message_count includes the root
+	_, err = db.Exec(`
+		INSERT INTO threads (id, root_message_id, title, channel_id, message_count, participant_count,
+		                     max_depth, started_at, last_activity_at, participants, is_resolved, is_dismissed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			root_message_id = excluded.root_message_id,
+			title = excluded.title,
+			channel_id = excluded.channel_id,
+			message_count = excluded.message_count,
+			participant_count = excluded.participant_count,
+			max_depth = excluded.max_depth,
+			started_at = excluded.started_at,
+			last_activity_at = excluded.last_activity_at,
+			participants = excluded.participants,
+			is_resolved = excluded.is_resolved,
+			is_dismissed = excluded.is_dismissed,
+			analyzed_at = CURRENT_TIMESTAMP
+	`, thread.ID, thread.RootMessageID, thread.Title, thread.ChannelID, thread.ReplyCount+1, thread.ParticipantCount,
+		thread.MaxDepth, thread.StartedAt, thread.LastActivityAt, string(participants), thread.Resolved, thread.Dismissed)
+
+	if err != nil {
+		return fmt.Errorf("failed to save thread: %w", err)
+	}
+
+	return nil
+}
+
+// GetThread retrieves a thread summary by ID.
+// Returns nil if the thread has not been summarized.
+func (db *DB) GetThread(id string) (*Thread, error) {
+	thread := &Thread{}
+	var messageCount int
+	var title, participants sql.NullString
+
+	err := db.QueryRow(`
+		SELECT id, root_message_id, title, channel_id, message_count, participant_count,
+		       max_depth, started_at, last_activity_at, participants, is_resolved, is_dismissed
+		FROM threads
+		WHERE id = ?
+	`, id).Scan(
+		&thread.ID, &thread.RootMessageID, &title, &thread.ChannelID, &messageCount, &thread.ParticipantCount,
+		&thread.MaxDepth, &thread.StartedAt, &thread.LastActivityAt, &participants, &thread.Resolved, &thread.Dismissed,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	thread.Title = title.String
+	thread.ReplyCount = messageCount - 1
+	thread.Participants = []string{}
+	if participants.Valid && participants.String != "" {
+		if err := json.Unmarshal([]byte(participants.String), &thread.Participants); err != nil {
+			return nil, fmt.Errorf("failed to parse participants: %w", err)
+		}
+	}
+
+	return thread, nil
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestThread_SaveAndGet(t *testing.T) {
+	database := openTestDB(t)
+
+	thread, err := database.GetThread("missing")
+	if err != nil {
+		t.Fatalf("GetThread failed: %v", err)
+	}
+	if thread != nil {
+		t.Fatalf("expected nil for unknown thread, got %+v", thread)
+	}
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	want := &Thread{
+		ID:               "msg_root",
+		RootMessageID:    "msg_root",
+		ChannelID:        "chan_github_owner_repo",
+		ReplyCount:       2,
+		ParticipantCount: 2,
+		MaxDepth:         1,
+		StartedAt:        start,
+		LastActivityAt:   start.Add(2 * time.Hour),
+		Participants:     []string{"user_alice", "user_bob"},
+	}
+	if err := database.SaveThread(want); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+
+	// A later reply updates the summary in place
+	want.ReplyCount = 3
+	want.LastActivityAt = start.Add(5 * time.Hour)
+	if err := database.SaveThread(want); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+
+	got, err := database.GetThread("msg_root")
+	if err != nil {
+		t.Fatalf("GetThread failed: %v", err)
+	}
+	if got.ReplyCount != 3 {
+		t.Errorf("expected 3 replies, got %d", got.ReplyCount)
+	}
+	if !got.LastActivityAt.Equal(want.LastActivityAt) || !got.StartedAt.Equal(start) {
+		t.Errorf("expected activity %v..%v, got %v..%v", start, want.LastActivityAt, got.StartedAt, got.LastActivityAt)
+	}
+	if len(got.Participants) != 2 || got.Participants[1] != "user_bob" {
+		t.Errorf("expected participants to round-trip, got %v", got.Participants)
+	}
+	if got.Resolved {
+		t.Error("expected thread to be unresolved")
+	}
+
+	want.Resolved = true
+	if err := database.SaveThread(want); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+	if got, err = database.GetThread("msg_root"); err != nil || !got.Resolved {
+		t.Errorf("expected thread to be marked resolved, got %+v (err %v)", got, err)
+	}
+
+	want.Resolved = false
+	want.Dismissed = true
+	if err := database.SaveThread(want); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+	if got, err = database.GetThread("msg_root"); err != nil || got.Resolved || !got.Dismissed {
+		t.Errorf("expected thread to be marked dismissed, got %+v (err %v)", got, err)
+	}
+}
+
+func TestOpen_MigratesV2ThreadDismissed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	database := openTestDBAt(t, path)
+
+	// A database created before threads had is_dismissed
+	downgradeToV2(t, database)
+	database.Close()
+
+	database = openTestDBAt(t, path)
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	thread := &Thread{ID: "msg_root", RootMessageID: "msg_root", ChannelID: "chan_github_owner_repo",
+		StartedAt: start, LastActivityAt: start, Participants: []string{}, Dismissed: true}
+	if err := database.SaveThread(thread); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+	if got, err := database.GetThread("msg_root"); err != nil || !got.Dismissed {
+		t.Errorf("expected thread to be marked dismissed, got %+v (err %v)", got, err)
+	}
+}
+
+func TestSelectMessages_SortLastActivity(t *testing.T) {
+	database := openTestDB(t)
+
+	// The older thread has the most recent reply
+	older, newer := "msg_older", "msg_newer"
+	olderRoot := saveTestMessage(t, database, older, "user_alice", "older root", &older)
+	newerRoot := saveTestMessage(t, database, newer, "user_alice", "newer root", &newer)
+	olderRoot.Timestamp = olderRoot.Timestamp.Add(-time.Hour)
+	if err := database.SaveMessage(olderRoot); err != nil {
+		t.Fatalf("failed to save message: %v", err)
+	}
+	if err := database.SaveThread(&Thread{
+		ID: older, RootMessageID: older, ChannelID: olderRoot.ChannelID, ReplyCount: 1,
+		StartedAt: olderRoot.Timestamp, LastActivityAt: newerRoot.Timestamp.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("SaveThread failed: %v", err)
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "", want: []string{newer, older}},
+		{sort: SortTimestamp, want: []string{newer, older}},
+		{sort: SortLastActivity, want: []string{older, newer}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			messages, err := database.SelectMessages(SelectMessagesOptions{Sort: tt.sort})
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			if len(messages) != 2 || messages[0].ID != tt.want[0] || messages[1].ID != tt.want[1] {
+				var got []string
+				for _, m := range messages {
+					got = append(got, m.ID)
+				}
+				t.Errorf("expected order %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := database.SelectMessages(SelectMessagesOptions{Sort: "bogus"}); err == nil {
+		t.Error("expected an error for an unknown sort order")
+	}
+}
+
+func TestSelectMessages_ThreadState(t *testing.T) {
+	database := openTestDB(t)
+
+	// One thread in each state, plus open ones with and without a summary
+	summaries := []*Thread{
+		{ID: "msg_resolved", Resolved: true},
+		{ID: "msg_dismissed", Dismissed: true},
+		{ID: "msg_open"},
+	}
+	for _, summary := range summaries {
+		root := saveTestMessage(t, database, summary.ID, "user_alice", "root", &summary.ID)
+		saveTestMessage(t, database, summary.ID+"_reply", "user_bob", "reply", &summary.ID)
+		summary.RootMessageID, summary.ChannelID = root.ID, root.ChannelID
+		summary.StartedAt, summary.LastActivityAt = root.Timestamp, root.Timestamp
+		if err := database.SaveThread(summary); err != nil {
+			t.Fatalf("SaveThread failed: %v", err)
+		}
+	}
+	unsummarized := "msg_unsummarized"
+	saveTestMessage(t, database, unsummarized, "user_alice", "root", &unsummarized)
+	saveTestMessage(t, database, "msg_standalone", "user_bob", "no thread", nil)
+
+	tests := []struct {
+		state string
+		want  []string
+	}{
+		{"", []string{"msg_dismissed", "msg_dismissed_reply", "msg_open", "msg_open_reply", "msg_resolved", "msg_resolved_reply", "msg_standalone", "msg_unsummarized"}},
+		{ThreadStateOpen, []string{"msg_open", "msg_open_reply", "msg_standalone", "msg_unsummarized"}},
+		{ThreadStateResolved, []string{"msg_resolved", "msg_resolved_reply"}},
+		{ThreadStateDismissed, []string{"msg_dismissed", "msg_dismissed_reply"}},
+	}
+	for _, tt := range tests {
+		t.Run("state "+tt.state, func(t *testing.T) {
+			messages, err := database.SelectMessages(SelectMessagesOptions{ThreadState: tt.state})
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			got := make([]string, 0, len(messages))
+			for _, m := range messages {
+				got = append(got, m.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			// The filter composes with the others
+			author := "user_bob"
+			messages, err = database.SelectMessages(SelectMessagesOptions{ThreadState: tt.state, AuthorID: &author})
+			if err != nil {
+				t.Fatalf("SelectMessages failed: %v", err)
+			}
+			for _, m := range messages {
+				if m.AuthorID != author {
+					t.Errorf("got message %s by %s with the author filter", m.ID, m.AuthorID)
+				}
+			}
+		})
+	}
+
+	if _, err := database.SelectMessages(SelectMessagesOptions{ThreadState: "pending"}); err == nil {
+		t.Error("expected an error for an unknown thread state")
+	}
+}
@@ -20,20 +20,25 @@ type User struct {
 	UpdatedAt    time.Time
 }
 
+// upsertUserSQL inserts or updates a user. Fields the new record lacks keep
+// their stored value, so a message that only carries a user ID doesn't erase
+// the name an earlier message provided.
+const upsertUserSQL = `
+	INSERT INTO users (
+		id, source_type, source_id, display_name, real_name, email, avatar_url, canonical_id
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(source_type, source_id) DO UPDATE SET
+		display_name = COALESCE(excluded.display_name, users.display_name),
+		real_name = COALESCE(excluded.real_name, users.real_name),
+		email = COALESCE(excluded.email, users.email),
+		avatar_url = COALESCE(excluded.avatar_url, users.avatar_url),
+		canonical_id = COALESCE(excluded.canonical_id, users.canonical_id),
+		updated_at = CURRENT_TIMESTAMP
+`
+
 // SaveUser saves or updates a user
 func (db *DB) SaveUser(user *User) error {
-	_, err := db.Exec(`
-		INSERT INTO users (
-			id, source_type, source_id, display_name, real_name, email, avatar_url, canonical_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(source_type, source_id) DO UPDATE SET
-			display_name = excluded.display_name,
-			real_name = excluded.real_name,
-			email = excluded.email,
-			avatar_url = excluded.avatar_url,
-			canonical_id = excluded.canonical_id,
-			updated_at = CURRENT_TIMESTAMP
-	`, user.ID, user.SourceType, user.SourceID, user.DisplayName, user.RealName,
+	_, err := db.Exec(upsertUserSQL, user.ID, user.SourceType, user.SourceID, user.DisplayName, user.RealName,
 		user.Email, user.AvatarURL, user.CanonicalID)
 
 	if err != nil {
@@ -43,6 +48,28 @@ func (db *DB) SaveUser(user *User) error {
 	return nil
 }
 
+// SaveUsers saves or updates users in a single transaction
+func (db *DB) SaveUsers(users []*User) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, user := range users {
+		if _, err := tx.Exec(upsertUserSQL, user.ID, user.SourceType, user.SourceID, user.DisplayName, user.RealName,
+			user.Email, user.AvatarURL, user.CanonicalID); err != nil {
+			return fmt.Errorf("failed to save user %s: %w", user.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit users: %w", err)
+	}
+
+	return nil
+}
+
 // GetUser retrieves a user by ID
 func (db *DB) GetUser(id string) (*User, error) {
 	user := &User{}
@@ -169,14 +196,16 @@ func (db *DB) GetUsersByIdentity(canonicalID string) ([]*User, error) {
 	return users, nil
 }
 
-// FindUsersByName finds users by display name, real name, or source ID
-func (db *DB) FindUsersByName(name string) ([]*User, error) {
+// FindUsersByName finds users by display name, real name, or source ID.
+// Unless caseSensitive, names match regardless of (ASCII) case, and users
+// whose name matches with the same case come first.
+func (db *DB) FindUsersByName(name string, caseSensitive bool) ([]*User, error) {
 	rows, err := db.Query(`
 		SELECT id, source_type, source_id, display_name, real_name, email, avatar_url,
 		       canonical_id, fetched_at, updated_at
 		FROM users
-		WHERE display_name = ? OR real_name = ? OR source_id = ?
-	`, name, name, name)
+		WHERE `+nameMatchClause(caseSensitive, "display_name", "real_name", "source_id"),
+		nameMatchArgs(name, caseSensitive, 3)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users by name: %w", err)
 	}
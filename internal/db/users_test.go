@@ -0,0 +1,168 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSaveUsers_KeepsKnownNames(t *testing.T) {
+	database := openTestDB(t)
+
+	alice := "alice"
+	real := "Alice Example"
+	if err := database.SaveUsers([]*User{
+		{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1", DisplayName: &alice, RealName: &real},
+		{ID: "user_github_bob", SourceType: "github", SourceID: "bob"},
+	}); err != nil {
+		t.Fatalf("SaveUsers failed: %v", err)
+	}
+
+	// A later message that only carries the user ID doesn't erase the name
+	if err := database.SaveUser(&User{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1"}); err != nil {
+		t.Fatalf("SaveUser failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"alice", "user_slack_U1"},
+		{"Alice Example", "user_slack_U1"},
+		{"U1", "user_slack_U1"},
+		{"bob", "user_github_bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := database.FindUsersByName(tt.name, false)
+			if err != nil {
+				t.Fatalf("FindUsersByName failed: %v", err)
+			}
+			if len(users) != 1 || users[0].ID != tt.want {
+				t.Errorf("FindUsersByName(%q) = %v, want %s", tt.name, users, tt.want)
+			}
+		})
+	}
+
+	// A new name does replace the stored one
+	renamed := "alice2"
+	if err := database.SaveUser(&User{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1", DisplayName: &renamed}); err != nil {
+		t.Fatalf("SaveUser failed: %v", err)
+	}
+	user, err := database.GetUser("user_slack_U1")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if user.DisplayName == nil || *user.DisplayName != "alice2" || user.RealName == nil || *user.RealName != real {
+		t.Errorf("expected display name alice2 and real name kept, got %+v", user)
+	}
+}
+
+func TestSaveChannels(t *testing.T) {
+	database := openTestDB(t)
+
+	workspace := "ws_slack_T1"
+	display := "#general"
+	if err := database.SaveChannels([]*Channel{
+		{ID: "chan_slack_C1", SourceType: "slack", SourceID: "C1", WorkspaceID: &workspace, Name: "general", DisplayName: &display},
+		{ID: "chan_slack_C2", SourceType: "slack", SourceID: "C2", WorkspaceID: &workspace, Name: "random"},
+	}); err != nil {
+		t.Fatalf("SaveChannels failed: %v", err)
+	}
+
+	for _, name := range []string{"general", "#general", "random"} {
+		channels, err := database.FindChannelsByName(name, false)
+		if err != nil {
+			t.Fatalf("FindChannelsByName failed: %v", err)
+		}
+		if len(channels) != 1 {
+			t.Errorf("FindChannelsByName(%q) returned %d channels, want 1", name, len(channels))
+		}
+	}
+}
+
+func TestAuthenticatedUserIDs(t *testing.T) {
+	database := openTestDB(t)
+
+	ids, err := database.AuthenticatedUserIDs()
+	if err != nil {
+		t.Fatalf("AuthenticatedUserIDs failed: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("expected no authenticated users before any fetch, got %v", ids)
+	}
+
+	slackMe, githubMe := "user_slack_U123", "user_github_octocat"
+	for _, workspace := range []*Workspace{
+		{ID: "ws_slack_T1", SourceType: "slack", SourceID: "T1", Name: "Acme", AuthenticatedUserID: &slackMe},
+		{ID: "org_github_acme", SourceType: "github", SourceID: "acme", Name: "acme", AuthenticatedUserID: &githubMe},
+		{ID: "org_github_widgets", SourceType: "github", SourceID: "widgets", Name: "widgets", AuthenticatedUserID: &githubMe},
+		{ID: "org_github_other", SourceType: "github", SourceID: "other", Name: "other"},
+	} {
+		if err := database.SaveWorkspace(workspace); err != nil {
+			t.Fatalf("SaveWorkspace failed: %v", err)
+		}
+	}
+
+	ids, err = database.AuthenticatedUserIDs()
+	if err != nil {
+		t.Fatalf("AuthenticatedUserIDs failed: %v", err)
+	}
+	if want := []string{githubMe, slackMe}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("AuthenticatedUserIDs = %v, want %v", ids, want)
+	}
+}
+
+func TestFindByName_Case(t *testing.T) {
+	database := openTestDB(t)
+
+	upper, lower := "Alice", "alice"
+	if err := database.SaveUsers([]*User{
+		{ID: "user_slack_U1", SourceType: "slack", SourceID: "U1", DisplayName: &upper},
+		{ID: "user_github_alice", SourceType: "github", SourceID: "alice", DisplayName: &lower},
+	}); err != nil {
+		t.Fatalf("SaveUsers failed: %v", err)
+	}
+	if err := database.SaveChannels([]*Channel{
+		{ID: "chan_github_acme_Widgets", SourceType: "github", SourceID: "acme/Widgets", Name: "acme/Widgets"},
+	}); err != nil {
+		t.Fatalf("SaveChannels failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		caseSensitive bool
+		wantUsers     []string // Matching user IDs, in order
+		wantChannels  int
+	}{
+		{"Alice", false, []string{"user_slack_U1", "user_github_alice"}, 0},
+		{"alice", false, []string{"user_github_alice", "user_slack_U1"}, 0},
+		{"ALICE", false, []string{"user_slack_U1", "user_github_alice"}, 0},
+		{"Alice", true, []string{"user_slack_U1"}, 0},
+		{"ALICE", true, []string{}, 0},
+		{"acme/widgets", false, []string{}, 1},
+		{"acme/widgets", true, []string{}, 0},
+		{"acme/Widgets", true, []string{}, 1},
+	}
+	for _, tt := range tests {
+		users, err := database.FindUsersByName(tt.name, tt.caseSensitive)
+		if err != nil {
+			t.Fatalf("FindUsersByName failed: %v", err)
+		}
+		ids := make([]string, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.wantUsers) {
+			t.Errorf("FindUsersByName(%q, %v) = %v, want %v", tt.name, tt.caseSensitive, ids, tt.wantUsers)
+		}
+
+		channels, err := database.FindChannelsByName(tt.name, tt.caseSensitive)
+		if err != nil {
+			t.Fatalf("FindChannelsByName failed: %v", err)
+		}
+		if len(channels) != tt.wantChannels {
+			t.Errorf("FindChannelsByName(%q, %v) returned %d channels, want %d", tt.name, tt.caseSensitive, len(channels), tt.wantChannels)
+		}
+	}
+}
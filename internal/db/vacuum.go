@@ -0,0 +1,77 @@
+package db
+
+import (
+	"fmt"
+	"os"
+)
+
+// VacuumResult reports the size on disk of the database before and after
+// Vacuum, counting its write-ahead log
+type VacuumResult struct {
+	Path       string `json:"path"`
+	SizeBefore int64  `json:"size_before"`
+	SizeAfter  int64  `json:"size_after"`
+	Analyzed   bool   `json:"analyzed"`
+}
+
+// Reclaimed returns the number of bytes Vacuum freed
+func (r *VacuumResult) Reclaimed() int64 {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// Vacuum rebuilds the database file without the free pages deletes and
+// updates leave behind, so it shrinks. In WAL mode VACUUM writes the rebuilt
+// pages to the write-ahead log, so the log is checkpointed and truncated
+// before (to measure the size fairly) and after (to shrink the file). With
+// analyze, it also refreshes the statistics the query planner uses.
+func (db *DB) Vacuum(analyze bool) (*VacuumResult, error) {
+	result := &VacuumResult{Path: db.path, Analyzed: analyze}
+
+	if err := db.Checkpoint(); err != nil {
+		return nil, err
+	}
+	result.SizeBefore = db.sizeOnDisk()
+
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if analyze {
+		if _, err := db.Exec("ANALYZE"); err != nil {
+			return nil, fmt.Errorf("failed to analyze database: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA optimize"); err != nil {
+			return nil, fmt.Errorf("failed to optimize database: %w", err)
+		}
+	}
+
+	if err := db.Checkpoint(); err != nil {
+		return nil, err
+	}
+	result.SizeAfter = db.sizeOnDisk()
+
+	return result, nil
+}
+
+// Checkpoint copies the write-ahead log into the database file and truncates
+// the log, so the database file alone holds all of the data
+func (db *DB) Checkpoint() error {
+	var busy, logPages, checkpointed int
+	if err := db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logPages, &checkpointed); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("failed to checkpoint database: another connection is using it")
+	}
+	return nil
+}
+
+// sizeOnDisk returns the size of the database file and its write-ahead log
+func (db *DB) sizeOnDisk() int64 {
+	var size int64
+	for _, path := range []string{db.path, db.path + "-wal"} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
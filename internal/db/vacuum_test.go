@@ -0,0 +1,43 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestVacuum(t *testing.T) {
+	database := openTestDB(t)
+
+	content := strings.Repeat("lorem ipsum ", 200)
+	for i := 0; i < 500; i++ {
+		saveTestMessage(t, database, fmt.Sprintf("msg_%03d", i), "user_github_octocat", content, nil)
+	}
+	if _, err := database.Exec("DELETE FROM messages"); err != nil {
+		t.Fatalf("failed to delete messages: %v", err)
+	}
+
+	for _, analyze := range []bool{false, true} {
+		t.Run(fmt.Sprintf("analyze=%v", analyze), func(t *testing.T) {
+			result, err := database.Vacuum(analyze)
+			if err != nil {
+				t.Fatalf("Vacuum() failed: %v", err)
+			}
+			if result.Analyzed != analyze {
+				t.Errorf("Analyzed = %v, want %v", result.Analyzed, analyze)
+			}
+
+			info, err := os.Stat(database.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.SizeAfter != info.Size() {
+				t.Errorf("SizeAfter = %d, want the file size %d with an empty log", result.SizeAfter, info.Size())
+			}
+			if !analyze && result.Reclaimed() <= 0 {
+				t.Errorf("expected the database to shrink, went from %d to %d bytes", result.SizeBefore, result.SizeAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/classify"
+)
+
+// Event records a single fetch operation
+type Event struct {
+	Timestamp  time.Time          `json:"timestamp"`
+	Command    string             `json:"command"` // e.g. "fetch slack", "fetch github"
+	Source     string             `json:"source"`  // "slack", "github"
+	Params     map[string]string  `json:"params,omitempty"`
+	Query      string             `json:"query,omitempty"`
+	Messages   int                `json:"messages"`
+	Threads    int                `json:"threads"`
+	Coverage   *classify.Coverage `json:"coverage,omitempty"` // Classification coverage of the fetched threads
+	Skipped    []string           `json:"skipped,omitempty"`  // Stages skipped with --no-classify or --no-graph
+	Failed     int                `json:"failed,omitempty"`   // Messages that failed to normalize or store
+	Failures   []Failure          `json:"failures,omitempty"`
+	DurationMS int64              `json:"duration_ms"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// Failure is a message a fetch failed to normalize or store
+type Failure struct {
+	MessageID string `json:"message_id,omitempty"` // Empty if the message failed before it was cached
+	SourceID  string `json:"source_id,omitempty"`
+	Error     string `json:"error"`
+}
+
+// LogDir returns the directory for event logs
+func LogDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".threadmine", "logs"), nil
+}
+
+// DefaultLogPath returns the path of the fetch event log
+func DefaultLogPath() (string, error) {
+	dir, err := LogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "fetch.jsonl"), nil
+}
+
+// Append writes an event as a single JSON line at the end of the log file
+func Append(path string, event *Event) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
+
+// Tail returns the last n events from the log file, oldest first.
+// A missing log file yields no events. n <= 0 returns all events.
+func Tail(path string, n int) ([]*Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Event{}, nil
+		}
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var events []*Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse log line %d: %w", lineNum, err)
+		}
+		events = append(events, &event)
+
+		if n > 0 && len(events) > n {
+			events = events[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	if events == nil {
+		events = []*Event{}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,106 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "fetch.jsonl")
+
+	event := &Event{
+		Timestamp:  time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Command:    "fetch slack",
+		Source:     "slack",
+		Params:     map[string]string{"workspace": "myteam"},
+		Messages:   12,
+		Threads:    3,
+		DurationMS: 1500,
+	}
+
+	if err := Append(path, event); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := Append(path, &Event{Command: "fetch github", Source: "github", Error: "boom"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("log file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected file mode 0600, got %o", info.Mode().Perm())
+	}
+
+	events, err := Tail(path, 0)
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	first := events[0]
+	if first.Command != "fetch slack" || first.Messages != 12 || first.Threads != 3 || first.DurationMS != 1500 {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+	if first.Params["workspace"] != "myteam" {
+		t.Errorf("expected workspace param, got %v", first.Params)
+	}
+	if !first.Timestamp.Equal(event.Timestamp) {
+		t.Errorf("expected timestamp %v, got %v", event.Timestamp, first.Timestamp)
+	}
+	if events[1].Error != "boom" {
+		t.Errorf("expected error to be preserved, got %q", events[1].Error)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fetch.jsonl")
+
+	for i := 1; i <= 5; i++ {
+		if err := Append(path, &Event{Command: "fetch slack", Messages: i}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		n        int
+		expected []int
+	}{
+		{"last two", 2, []int{4, 5}},
+		{"more than available", 10, []int{1, 2, 3, 4, 5}},
+		{"all", 0, []int{1, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Tail(path, tt.n)
+			if err != nil {
+				t.Fatalf("Tail failed: %v", err)
+			}
+			if len(events) != len(tt.expected) {
+				t.Fatalf("expected %d events, got %d", len(tt.expected), len(events))
+			}
+			for i, e := range events {
+				if e.Messages != tt.expected[i] {
+					t.Errorf("event %d: expected messages %d, got %d", i, tt.expected[i], e.Messages)
+				}
+			}
+		})
+	}
+}
+
+func TestTail_MissingFile(t *testing.T) {
+	events, err := Tail(filepath.Join(t.TempDir(), "missing.jsonl"), 10)
+	if err != nil {
+		t.Fatalf("expected no error for missing file, got %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+}
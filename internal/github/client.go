@@ -8,8 +8,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
 // Client wraps GitHub CLI for API access
@@ -28,21 +31,21 @@ type AuthResult struct {
 func Authenticate() (*AuthResult, error) {
 	// Check if gh is installed
 	if _, err := exec.LookPath("gh"); err != nil {
-		return nil, fmt.Errorf("GitHub CLI (gh) not found. Install it from https://cli.github.com/")
+		return nil, fmt.Errorf("%w: GitHub CLI (gh) not found. Install it from https://cli.github.com/", ErrNotAuthenticated)
 	}
 
 	// Verify authentication status
 	cmd := exec.Command("gh", "auth", "status")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("GitHub CLI authentication failed. Run 'gh auth login' to authenticate.\n  Error: %v\n  Output: %s", err, string(output))
+		return nil, fmt.Errorf("%w: run 'gh auth login' to authenticate.\n  Error: %v\n  Output: %s", ErrNotAuthenticated, err, string(output))
 	}
 
 	// Extract username
 	cmd = exec.Command("gh", "api", "user", "--jq", ".login")
 	userOutput, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get GitHub user: %w", err)
+		return nil, apiError("failed to get GitHub user", err, ErrNotFound)
 	}
 
 	username := strings.TrimSpace(string(userOutput))
@@ -74,10 +77,7 @@ func (c *Client) SearchIssues(ctx context.Context, query string, limit int) ([]I
 	cmd := exec.CommandContext(ctx, "gh", "api", apiURL, "-H", "Accept: application/vnd.github+json")
 	output, err := cmd.Output()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("gh api failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("failed to search issues: %w", err)
+		return nil, apiError("failed to search issues", err, ErrNotFound)
 	}
 
 	// GitHub search API returns results wrapped in an object with "items" array
@@ -98,7 +98,8 @@ func (c *Client) SearchIssues(ctx context.Context, query string, limit int) ([]I
 			Labels     []struct {
 				Name string `json:"name"`
 			} `json:"labels"`
-			RepositoryURL string `json:"repository_url"`
+			RepositoryURL string           `json:"repository_url"`
+			PullRequest   *PullRequestLink `json:"pull_request"` // Set if the result is a pull request
 		} `json:"items"`
 	}
 
@@ -119,6 +120,7 @@ func (c *Client) SearchIssues(ctx context.Context, query string, limit int) ([]I
 			UpdatedAt:     r.UpdatedAt,
 			ClosedAt:      r.ClosedAt,
 			RepositoryURL: r.RepositoryURL,
+			PullRequest:   r.PullRequest,
 		}
 		issues = append(issues, issue)
 	}
@@ -133,7 +135,7 @@ func (c *Client) GetIssueTimeline(ctx context.Context, issueNumber int) ([]Timel
 		"-H", "Accept: application/vnd.github.mockingbird-preview+json")
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch timeline: %w", err)
+		return nil, apiError("failed to fetch timeline", err, ErrNotFound)
 	}
 
 	var events []TimelineEvent
@@ -150,7 +152,7 @@ func (c *Client) GetPullRequestReviewComments(ctx context.Context, prNumber int)
 		fmt.Sprintf("repos/%s/%s/pulls/%d/comments", c.owner, c.repo, prNumber))
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch review comments: %w", err)
+		return nil, apiError("failed to fetch review comments", err, ErrNotFound)
 	}
 
 	var comments []ReviewComment
@@ -161,6 +163,196 @@ func (c *Client) GetPullRequestReviewComments(ctx context.Context, prNumber int)
 	return comments, nil
 }
 
+// FetchCommitComments fetches the comments on commit sha, or on every commit in
+// the repository if sha is empty (direct, no caching)
+func (c *Client) FetchCommitComments(ctx context.Context, sha string) ([]CommitComment, error) {
+	endpoint := fmt.Sprintf("repos/%s/%s/comments", c.owner, c.repo)
+	notFound := ErrRepoNotFound
+	if sha != "" {
+		endpoint = fmt.Sprintf("repos/%s/%s/commits/%s/comments", c.owner, c.repo, sha)
+		notFound = ErrNotFound
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate", endpoint)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch commit comments", err, notFound)
+	}
+
+	var comments []CommitComment
+	if err := json.Unmarshal(output, &comments); err != nil {
+		return nil, fmt.Errorf("failed to parse commit comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// GetPullRequestReviewThreads fetches the review threads of a PR with their
+// resolved and outdated state, which the REST API doesn't expose
+func (c *Client) GetPullRequestReviewThreads(ctx context.Context, prNumber int) ([]ReviewThread, error) {
+	graphqlQuery := fmt.Sprintf(`
+query {
+  repository(owner: "%s", name: "%s") {
+    pullRequest(number: %d) {
+      reviewThreads(first: 100) {
+        nodes {
+          id
+          isResolved
+          isOutdated
+          comments(first: 100) {
+            nodes {
+              databaseId
+            }
+          }
+        }
+      }
+    }
+  }
+}`, c.owner, c.repo, prNumber)
+
+	cmd := exec.CommandContext(ctx, "gh", "api", "graphql", "-f", fmt.Sprintf("query=%s", graphqlQuery))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch review threads", err, ErrNotFound)
+	}
+
+	var response struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							ID         string `json:"id"`
+							IsResolved bool   `json:"isResolved"`
+							IsOutdated bool   `json:"isOutdated"`
+							Comments   struct {
+								Nodes []struct {
+									DatabaseID int64 `json:"databaseId"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse review threads: %w", err)
+	}
+
+	nodes := response.Data.Repository.PullRequest.ReviewThreads.Nodes
+	threads := make([]ReviewThread, 0, len(nodes))
+	for _, node := range nodes {
+		thread := ReviewThread{
+			ID:         node.ID,
+			IsResolved: node.IsResolved,
+			IsOutdated: node.IsOutdated,
+		}
+		for _, comment := range node.Comments.Nodes {
+			thread.CommentIDs = append(thread.CommentIDs, comment.DatabaseID)
+		}
+		threads = append(threads, thread)
+	}
+
+	return threads, nil
+}
+
+// GetRequestedReviewers fetches the users whose review is currently requested on a PR
+func (c *Client) GetRequestedReviewers(ctx context.Context, prNumber int) ([]User, error) {
+	cmd := exec.CommandContext(ctx, "gh", "api",
+		fmt.Sprintf("repos/%s/%s/pulls/%d/requested_reviewers", c.owner, c.repo, prNumber))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch requested reviewers", err, ErrNotFound)
+	}
+
+	var result struct {
+		Users []User `json:"users"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse requested reviewers: %w", err)
+	}
+
+	return result.Users, nil
+}
+
+// GetPullRequestFiles fetches the files a PR changes (GitHub lists at most 3000)
+func (c *Client) GetPullRequestFiles(ctx context.Context, prNumber int) ([]PullRequestFile, error) {
+	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate",
+		fmt.Sprintf("repos/%s/%s/pulls/%d/files?per_page=100", c.owner, c.repo, prNumber))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch pull request files", err, ErrNotFound)
+	}
+
+	var files []PullRequestFile
+	if err := json.Unmarshal(output, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request files: %w", err)
+	}
+
+	return files, nil
+}
+
+// PullRequestFile represents a file changed by a PR
+type PullRequestFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"` // added, removed, modified, renamed, ...
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// MaxPullRequestFiles is the number of changed files kept per PR by SetFiles
+const MaxPullRequestFiles = 300
+
+// GetPullRequestCommits fetches the commits of a PR
+func (c *Client) GetPullRequestCommits(ctx context.Context, prNumber int) ([]PullRequestCommit, error) {
+	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate",
+		fmt.Sprintf("repos/%s/%s/pulls/%d/commits", c.owner, c.repo, prNumber))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch pull request commits", err, ErrNotFound)
+	}
+
+	var commits []PullRequestCommit
+	if err := json.Unmarshal(output, &commits); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request commits: %w", err)
+	}
+
+	return commits, nil
+}
+
+// PullRequestCommit represents a commit of a PR. Commit.Author is the git
+// author; Author is the GitHub user it's attributed to, if any.
+type PullRequestCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Author struct {
+			Name  string    `json:"name"`
+			Email string    `json:"email"`
+			Date  time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *User `json:"author"`
+}
+
+// CommitAuthorEmail returns the git author email of the first of commits
+// GitHub attributes to login, or "" if there is none. GitHub's noreply
+// addresses are skipped, since they don't identify anyone elsewhere.
+func CommitAuthorEmail(commits []PullRequestCommit, login string) string {
+	for _, commit := range commits {
+		if commit.Author == nil || !strings.EqualFold(commit.Author.Login, login) {
+			continue
+		}
+		email := strings.TrimSpace(commit.Commit.Author.Email)
+		if email == "" || strings.HasSuffix(strings.ToLower(email), "@users.noreply.github.com") {
+			continue
+		}
+		return email
+	}
+	return ""
+}
+
 // TimelineEvent represents a GitHub issue timeline event
 type TimelineEvent struct {
 	ID        int64     `json:"id"`
@@ -198,13 +390,55 @@ func (e *TimelineEvent) IsSignificant() bool {
 
 // ReviewComment represents a GitHub PR review comment
 type ReviewComment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	User      User      `json:"user"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Path      string    `json:"path"`
-	Line      int       `json:"line"`
+	ID          int64     `json:"id"`
+	Body        string    `json:"body"`
+	User        User      `json:"user"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Path        string    `json:"path"`
+	Line        int       `json:"line"`
+	InReplyToID int64     `json:"in_reply_to_id,omitempty"`
+
+	// State of the review thread the comment belongs to, from GetPullRequestReviewThreads
+	ReviewThread *ReviewThreadState `json:"review_thread,omitempty"`
+}
+
+// ReviewThread is a PR review conversation: a line comment and its replies.
+// GitHub marks it resolved when someone resolves the conversation, and
+// outdated when the lines it comments on have since changed.
+type ReviewThread struct {
+	ID         string  // GraphQL node ID
+	IsResolved bool
+	IsOutdated bool
+	CommentIDs []int64 // Comment IDs (as in the REST API), oldest first
+}
+
+// ReviewThreadState is the state of a review thread as recorded on each of its comments
+type ReviewThreadState struct {
+	ID            string `json:"id"`
+	RootCommentID int64  `json:"root_comment_id"`
+	Resolved      bool   `json:"resolved"`
+	Outdated      bool   `json:"outdated"`
+}
+
+// ReviewThreadStates maps each comment ID to the state of its review thread
+func ReviewThreadStates(threads []ReviewThread) map[int64]*ReviewThreadState {
+	states := make(map[int64]*ReviewThreadState)
+	for _, thread := range threads {
+		if len(thread.CommentIDs) == 0 {
+			continue
+		}
+		state := &ReviewThreadState{
+			ID:            thread.ID,
+			RootCommentID: thread.CommentIDs[0],
+			Resolved:      thread.IsResolved,
+			Outdated:      thread.IsOutdated,
+		}
+		for _, id := range thread.CommentIDs {
+			states[id] = state
+		}
+	}
+	return states
 }
 
 // Repository represents a GitHub repository
@@ -223,12 +457,56 @@ type Issue struct {
 	Title         string     `json:"title"`
 	Body          string     `json:"body"`
 	State         string     `json:"state"`
+	StateReason   string     `json:"state_reason"` // Why it was closed: completed or not_planned (issues only)
 	User          User       `json:"user"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	ClosedAt      *time.Time `json:"closed_at"`
 	Comments      int        `json:"comments"`
 	RepositoryURL string     `json:"repository_url"` // For org-wide searches
+
+	Assignees          []User `json:"assignees"`
+	RequestedReviewers []User `json:"requested_reviewers,omitempty"` // PRs only; not included in search results
+
+	// Set when the issue is a pull request
+	PullRequest *PullRequestLink `json:"pull_request,omitempty"`
+
+	// Changed files of a pull request, when fetched (see SetFiles)
+	Files        []PullRequestFile `json:"files,omitempty"`
+	ChangedFiles int               `json:"changed_files,omitempty"`
+	Additions    int               `json:"additions,omitempty"`
+	Deletions    int               `json:"deletions,omitempty"`
+}
+
+// PullRequestLink links an issue to the pull request it is
+type PullRequestLink struct {
+	URL      string     `json:"url"`
+	MergedAt *time.Time `json:"merged_at,omitempty"` // Set once the pull request is merged
+}
+
+// State reasons of a closed issue
+const (
+	StateReasonCompleted  = "completed"
+	StateReasonNotPlanned = "not_planned"
+)
+
+// IsPullRequest reports whether the issue is a pull request
+func (i *Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+// SetFiles records the files a pull request changes: the first
+// MaxPullRequestFiles of them, and the totals of all of them
+func (i *Issue) SetFiles(files []PullRequestFile) {
+	i.ChangedFiles, i.Additions, i.Deletions = len(files), 0, 0
+	for _, f := range files {
+		i.Additions += f.Additions
+		i.Deletions += f.Deletions
+	}
+	if len(files) > MaxPullRequestFiles {
+		files = files[:MaxPullRequestFiles]
+	}
+	i.Files = files
 }
 
 // PullRequest represents a GitHub pull request
@@ -243,6 +521,14 @@ type PullRequest struct {
 	ClosedAt  *time.Time `json:"closed_at"`
 	MergedAt  *time.Time `json:"merged_at"`
 	Comments  int        `json:"comments"`
+
+	Assignees          []User `json:"assignees"`
+	RequestedReviewers []User `json:"requested_reviewers"`
+
+	ChangedFiles int               `json:"changed_files"`
+	Additions    int               `json:"additions"`
+	Deletions    int               `json:"deletions"`
+	Files        []PullRequestFile `json:"files,omitempty"` // Not part of the API's pull request; see Issue.SetFiles
 }
 
 // Comment represents a GitHub issue or PR comment
@@ -252,6 +538,21 @@ type Comment struct {
 	User      User      `json:"user"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	IssueURL  string    `json:"issue_url,omitempty"` // API URL of the issue or PR commented on
+}
+
+// IssueNumber returns the number of the issue or PR the comment is on, from
+// its IssueURL, or 0 if it isn't known
+func (c *Comment) IssueNumber() int {
+	i := strings.LastIndex(c.IssueURL, "/")
+	if i < 0 {
+		return 0
+	}
+	number, err := strconv.Atoi(c.IssueURL[i+1:])
+	if err != nil {
+		return 0
+	}
+	return number
 }
 
 // Review represents a GitHub PR review
@@ -263,6 +564,20 @@ type Review struct {
 	SubmittedAt time.Time `json:"submitted_at"`
 }
 
+// CommitComment represents a comment on a commit. Path and Line are set for
+// comments on a line of the commit's diff.
+type CommitComment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CommitID  string    `json:"commit_id"`
+	Path      string    `json:"path,omitempty"`
+	Line      int       `json:"line,omitempty"`
+	HTMLURL   string    `json:"html_url"`
+}
+
 // User represents a GitHub user
 type User struct {
 	ID        int64  `json:"id"`
@@ -277,7 +592,7 @@ func (c *Client) GetRepository(ctx context.Context) (*Repository, error) {
 	cmd := exec.CommandContext(ctx, "gh", "api", fmt.Sprintf("repos/%s/%s", c.owner, c.repo))
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch repository: %w", err)
+		return nil, apiError("failed to fetch repository", err, ErrRepoNotFound)
 	}
 
 	var repo Repository
@@ -288,22 +603,52 @@ func (c *Client) GetRepository(ctx context.Context) (*Repository, error) {
 	return &repo, nil
 }
 
+// IssueFilter narrows the issues returned by GetIssues and FetchIssues
+type IssueFilter struct {
+	Creator   string // Only issues created by this login (creator=)
+	Mentioned string // Only issues mentioning this login (mentioned=)
+}
+
+// queryParams returns the filter as issues endpoint query parameters
+func (f IssueFilter) queryParams() string {
+	params := ""
+	if f.Creator != "" {
+		params += "&creator=" + url.QueryEscape(f.Creator)
+	}
+	if f.Mentioned != "" {
+		params += "&mentioned=" + url.QueryEscape(f.Mentioned)
+	}
+	return params
+}
+
+// cacheKey distinguishes cached results for different filters
+func (f IssueFilter) cacheKey() string {
+	key := ""
+	if f.Creator != "" {
+		key += ".creator-" + f.Creator
+	}
+	if f.Mentioned != "" {
+		key += ".mentioned-" + f.Mentioned
+	}
+	return key
+}
+
 // GetIssues fetches issues with cache-aside pattern
-func (c *Client) GetIssues(ctx context.Context, since time.Time) ([]Issue, error) {
+func (c *Client) GetIssues(ctx context.Context, since time.Time, filter IssueFilter) ([]Issue, error) {
 	// Check cache first
-	cached, err := c.loadIssuesFromCache(since)
+	cached, err := c.loadIssuesFromCache(since, filter)
 	if err == nil && cached != nil {
 		return cached, nil
 	}
 
 	// Fetch from API
-	issues, err := c.FetchIssues(ctx, since)
+	issues, err := c.FetchIssues(ctx, since, filter)
 	if err != nil {
 		return nil, err
 	}
 
 	// Save to cache
-	if err := c.saveIssuesToCache(issues); err != nil {
+	if err := c.saveIssuesToCache(issues, filter); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to cache issues: %v\n", err)
 	}
 
@@ -311,17 +656,18 @@ func (c *Client) GetIssues(ctx context.Context, since time.Time) ([]Issue, error
 }
 
 // FetchIssues fetches issues from GitHub API (direct, no caching)
-func (c *Client) FetchIssues(ctx context.Context, since time.Time) ([]Issue, error) {
+func (c *Client) FetchIssues(ctx context.Context, since time.Time, filter IssueFilter) ([]Issue, error) {
 	// Build URL with query parameters
 	url := fmt.Sprintf("repos/%s/%s/issues?state=all", c.owner, c.repo)
 	if !since.IsZero() {
 		url += fmt.Sprintf("&since=%s", since.Format(time.RFC3339))
 	}
+	url += filter.queryParams()
 
 	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate", url)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch issues: %w", err)
+		return nil, apiError("failed to fetch issues", err, ErrRepoNotFound)
 	}
 
 	var rawIssues []map[string]interface{}
@@ -349,19 +695,43 @@ func (c *Client) FetchIssues(ctx context.Context, since time.Time) ([]Issue, err
 	return filtered, nil
 }
 
-// GetIssueComments fetches comments for a specific issue
-func (c *Client) GetIssueComments(ctx context.Context, issueNumber int) ([]Comment, error) {
+// GetIssue fetches a single issue or pull request by number (direct, no caching)
+func (c *Client) GetIssue(ctx context.Context, number int) (*Issue, error) {
+	cmd := exec.CommandContext(ctx, "gh", "api",
+		fmt.Sprintf("repos/%s/%s/issues/%d", c.owner, c.repo, number))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError(fmt.Sprintf("failed to fetch #%d", number), err, ErrNotFound)
+	}
+
+	var issue Issue
+	if err := json.Unmarshal(output, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse #%d: %w", number, err)
+	}
+
+	return &issue, nil
+}
+
+// GetIssueComments fetches comments for a specific issue. Cached comments
+// fetched before issueUpdatedAt are refetched, so that an issue fetched again
+// because it changed gets its comments (and their edits) again too. With a
+// non-zero since, only comments created or edited since then are returned,
+// and the partial list isn't cached.
+func (c *Client) GetIssueComments(ctx context.Context, issueNumber int, issueUpdatedAt, since time.Time) ([]Comment, error) {
 	// Check cache first
-	cached, err := c.loadIssueCommentsFromCache(issueNumber)
+	cached, err := c.loadIssueCommentsFromCache(issueNumber, issueUpdatedAt)
 	if err == nil && cached != nil {
-		return cached, nil
+		return commentsSince(cached, since), nil
 	}
 
 	// Fetch from API
-	comments, err := c.FetchIssueComments(ctx, issueNumber)
+	comments, err := c.FetchIssueComments(ctx, issueNumber, since)
 	if err != nil {
 		return nil, err
 	}
+	if !since.IsZero() {
+		return comments, nil
+	}
 
 	// Save to cache
 	if err := c.saveIssueCommentsToCache(issueNumber, comments); err != nil {
@@ -371,13 +741,58 @@ func (c *Client) GetIssueComments(ctx context.Context, issueNumber int) ([]Comme
 	return comments, nil
 }
 
-// FetchIssueComments fetches comments for an issue (direct, no caching)
-func (c *Client) FetchIssueComments(ctx context.Context, issueNumber int) ([]Comment, error) {
-	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate",
-		fmt.Sprintf("repos/%s/%s/issues/%d/comments", c.owner, c.repo, issueNumber))
+// FetchIssueComments fetches comments for an issue (direct, no caching). With
+// a non-zero since, only comments created or edited since then are returned.
+func (c *Client) FetchIssueComments(ctx context.Context, issueNumber int, since time.Time) ([]Comment, error) {
+	url := fmt.Sprintf("repos/%s/%s/issues/%d/comments", c.owner, c.repo, issueNumber)
+	if !since.IsZero() {
+		url += fmt.Sprintf("?since=%s", since.UTC().Format(time.RFC3339))
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate", url)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch issue comments", err, ErrNotFound)
+	}
+
+	var comments []Comment
+	if err := json.Unmarshal(output, &comments); err != nil {
+		return nil, fmt.Errorf("failed to parse issue comments: %w", err)
+	}
+
+	return commentsSince(comments, since), nil
+}
+
+// commentsSince returns the comments created or edited at or after since, or
+// all of them when since is zero. The API filters by since too, but to the
+// second.
+func commentsSince(comments []Comment, since time.Time) []Comment {
+	if since.IsZero() {
+		return comments
+	}
+	filtered := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		if !comment.UpdatedAt.Before(since.Truncate(time.Second)) {
+			filtered = append(filtered, comment)
+		}
+	}
+	return filtered
+}
+
+// FetchRepoIssueComments fetches the comments on all issues and pull requests
+// in the repository that were created or edited since since, oldest update
+// first (direct, no caching). Unlike an issue's comments, these include
+// edits to comments on issues that didn't otherwise change.
+func (c *Client) FetchRepoIssueComments(ctx context.Context, since time.Time) ([]Comment, error) {
+	url := fmt.Sprintf("repos/%s/%s/issues/comments?sort=updated&direction=asc", c.owner, c.repo)
+	if !since.IsZero() {
+		url += fmt.Sprintf("&since=%s", since.Format(time.RFC3339))
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate", url)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch issue comments: %w", err)
+		return nil, apiError("failed to fetch issue comments", err, ErrRepoNotFound)
 	}
 
 	var comments []Comment
@@ -418,7 +833,7 @@ func (c *Client) FetchPullRequests(ctx context.Context, since time.Time) ([]Pull
 	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate", url)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pull requests: %w", err)
+		return nil, apiError("failed to fetch pull requests", err, ErrRepoNotFound)
 	}
 
 	var prs []PullRequest
@@ -469,7 +884,7 @@ func (c *Client) FetchPullRequestComments(ctx context.Context, prNumber int) ([]
 		fmt.Sprintf("repos/%s/%s/issues/%d/comments", c.owner, c.repo, prNumber))
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch PR comments: %w", err)
+		return nil, apiError("failed to fetch PR comments", err, ErrNotFound)
 	}
 
 	var comments []Comment
@@ -508,7 +923,7 @@ func (c *Client) FetchPullRequestReviews(ctx context.Context, prNumber int) ([]R
 		fmt.Sprintf("repos/%s/%s/pulls/%d/reviews", c.owner, c.repo, prNumber))
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch PR reviews: %w", err)
+		return nil, apiError("failed to fetch PR reviews", err, ErrNotFound)
 	}
 
 	var reviews []Review
@@ -529,13 +944,13 @@ func (c *Client) getCacheDir() (string, error) {
 	return filepath.Join(home, ".threadmine", "raw", "github", "repos", fmt.Sprintf("%s-%s", c.owner, c.repo)), nil
 }
 
-func (c *Client) loadIssuesFromCache(since time.Time) ([]Issue, error) {
+func (c *Client) loadIssuesFromCache(since time.Time, filter IssueFilter) ([]Issue, error) {
 	cacheDir, err := c.getCacheDir()
 	if err != nil {
 		return nil, err
 	}
 
-	filePath := filepath.Join(cacheDir, "issues", "_index.json")
+	filePath := filepath.Join(cacheDir, "issues", "_index"+filter.cacheKey()+".json")
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, nil // Cache miss
 	}
@@ -554,14 +969,14 @@ func (c *Client) loadIssuesFromCache(since time.Time) ([]Issue, error) {
 	}
 
 	// Check if cache is recent (within last hour)
-	if time.Since(cache.FetchedAt) > time.Hour {
+	if clock.Since(cache.FetchedAt) > time.Hour {
 		return nil, nil // Cache too old
 	}
 
 	return cache.Issues, nil
 }
 
-func (c *Client) saveIssuesToCache(issues []Issue) error {
+func (c *Client) saveIssuesToCache(issues []Issue, filter IssueFilter) error {
 	cacheDir, err := c.getCacheDir()
 	if err != nil {
 		return err
@@ -577,7 +992,7 @@ func (c *Client) saveIssuesToCache(issues []Issue) error {
 		FetchedAt time.Time `json:"fetched_at"`
 		Issues    []Issue   `json:"issues"`
 	}{
-		FetchedAt: time.Now(),
+		FetchedAt: clock.Now(),
 		Issues:    issues,
 	}
 
@@ -586,7 +1001,7 @@ func (c *Client) saveIssuesToCache(issues []Issue) error {
 		return err
 	}
 
-	indexPath := filepath.Join(issuesDir, "_index.json")
+	indexPath := filepath.Join(issuesDir, "_index"+filter.cacheKey()+".json")
 	tempPath := indexPath + ".tmp"
 	if err := os.WriteFile(tempPath, data, 0600); err != nil {
 		return err
@@ -615,7 +1030,7 @@ func (c *Client) saveIssuesToCache(issues []Issue) error {
 	return nil
 }
 
-func (c *Client) loadIssueCommentsFromCache(issueNumber int) ([]Comment, error) {
+func (c *Client) loadIssueCommentsFromCache(issueNumber int, issueUpdatedAt time.Time) ([]Comment, error) {
 	cacheDir, err := c.getCacheDir()
 	if err != nil {
 		return nil, err
@@ -640,10 +1055,15 @@ func (c *Client) loadIssueCommentsFromCache(issueNumber int) ([]Comment, error)
 	}
 
 	// Check if cache is recent (within last hour)
-	if time.Since(cache.FetchedAt) > time.Hour {
+	if clock.Since(cache.FetchedAt) > time.Hour {
 		return nil, nil // Cache too old
 	}
 
+	// The issue changed since its comments were cached
+	if cache.FetchedAt.Before(issueUpdatedAt) {
+		return nil, nil
+	}
+
 	return cache.Comments, nil
 }
 
@@ -662,7 +1082,7 @@ func (c *Client) saveIssueCommentsToCache(issueNumber int, comments []Comment) e
 		FetchedAt time.Time `json:"fetched_at"`
 		Comments  []Comment `json:"comments"`
 	}{
-		FetchedAt: time.Now(),
+		FetchedAt: clock.Now(),
 		Comments:  comments,
 	}
 
@@ -710,7 +1130,7 @@ func (c *Client) loadPullRequestsFromCache(since time.Time) ([]PullRequest, erro
 	}
 
 	// Check if cache is recent (within last hour)
-	if time.Since(cache.FetchedAt) > time.Hour {
+	if clock.Since(cache.FetchedAt) > time.Hour {
 		return nil, nil // Cache too old
 	}
 
@@ -733,7 +1153,7 @@ func (c *Client) savePullRequestsToCache(prs []PullRequest) error {
 		FetchedAt    time.Time     `json:"fetched_at"`
 		PullRequests []PullRequest `json:"pull_requests"`
 	}{
-		FetchedAt:    time.Now(),
+		FetchedAt:    clock.Now(),
 		PullRequests: prs,
 	}
 
@@ -796,7 +1216,7 @@ func (c *Client) loadPRCommentsFromCache(prNumber int) ([]Comment, error) {
 	}
 
 	// Check if cache is recent (within last hour)
-	if time.Since(cache.FetchedAt) > time.Hour {
+	if clock.Since(cache.FetchedAt) > time.Hour {
 		return nil, nil // Cache too old
 	}
 
@@ -818,7 +1238,7 @@ func (c *Client) savePRCommentsToCache(prNumber int, comments []Comment) error {
 		FetchedAt time.Time `json:"fetched_at"`
 		Comments  []Comment `json:"comments"`
 	}{
-		FetchedAt: time.Now(),
+		FetchedAt: clock.Now(),
 		Comments:  comments,
 	}
 
@@ -866,7 +1286,7 @@ func (c *Client) loadPRReviewsFromCache(prNumber int) ([]Review, error) {
 	}
 
 	// Check if cache is recent (within last hour)
-	if time.Since(cache.FetchedAt) > time.Hour {
+	if clock.Since(cache.FetchedAt) > time.Hour {
 		return nil, nil // Cache too old
 	}
 
@@ -888,7 +1308,7 @@ func (c *Client) savePRReviewsToCache(prNumber int, reviews []Review) error {
 		FetchedAt time.Time `json:"fetched_at"`
 		Reviews   []Review  `json:"reviews"`
 	}{
-		FetchedAt: time.Now(),
+		FetchedAt: clock.Now(),
 		Reviews:   reviews,
 	}
 
@@ -967,10 +1387,7 @@ query {
 	cmd := exec.CommandContext(ctx, "gh", "api", "graphql", "-f", fmt.Sprintf("query=%s", graphqlQuery))
 	output, err := cmd.Output()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("gh api graphql failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("failed to search discussions: %w", err)
+		return nil, apiError("failed to search discussions", err, ErrNotFound)
 	}
 
 	var response struct {
@@ -1024,10 +1441,7 @@ query {
 	cmd := exec.CommandContext(ctx, "gh", "api", "graphql", "-f", fmt.Sprintf("query=%s", graphqlQuery))
 	output, err := cmd.Output()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("gh api graphql failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("failed to fetch discussion comments: %w", err)
+		return nil, apiError("failed to fetch discussion comments", err, ErrNotFound)
 	}
 
 	var response struct {
@@ -0,0 +1,389 @@
+package github
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
+)
+
+// stubGH installs a fake gh on PATH that records its arguments and prints output.
+// It returns the path of the file the arguments are written to, one call per line.
+func stubGH(t *testing.T, output string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	outputFile := filepath.Join(dir, "output.json")
+	if err := os.WriteFile(outputFile, []byte(output), 0600); err != nil {
+		t.Fatalf("failed to write stub output: %v", err)
+	}
+
+	script := "#!/bin/sh\necho \"$@\" >> " + argsFile + "\ncat " + outputFile + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0700); err != nil {
+		t.Fatalf("failed to write stub gh: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("HOME", t.TempDir())
+
+	return argsFile
+}
+
+// readCalls returns the recorded gh invocations
+func readCalls(t *testing.T, argsFile string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("gh was not called: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestFetchIssues_FilterQueryParams(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  IssueFilter
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "no filter",
+			filter:  IssueFilter{},
+			want:    []string{"repos/acme/widgets/issues?state=all", "since=2024-03-01T00:00:00Z"},
+			notWant: []string{"creator=", "mentioned="},
+		},
+		{
+			name:    "creator",
+			filter:  IssueFilter{Creator: "alice"},
+			want:    []string{"&creator=alice"},
+			notWant: []string{"mentioned="},
+		},
+		{
+			name:    "mentioned",
+			filter:  IssueFilter{Mentioned: "bob"},
+			want:    []string{"&mentioned=bob"},
+			notWant: []string{"creator="},
+		},
+		{
+			name:   "creator and mentioned",
+			filter: IssueFilter{Creator: "alice", Mentioned: "bob"},
+			want:   []string{"&creator=alice", "&mentioned=bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile := stubGH(t, `[{"number": 1, "title": "Bug"}, {"number": 2, "title": "PR", "pull_request": {}}]`)
+
+			client := NewClient("acme", "widgets")
+			issues, err := client.FetchIssues(context.Background(), since, tt.filter)
+			if err != nil {
+				t.Fatalf("FetchIssues failed: %v", err)
+			}
+			if len(issues) != 1 || issues[0].Number != 1 {
+				t.Errorf("expected only issue #1, got %+v", issues)
+			}
+
+			calls := readCalls(t, argsFile)
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 gh call, got %d: %v", len(calls), calls)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(calls[0], w) {
+					t.Errorf("expected gh args to contain %q, got %q", w, calls[0])
+				}
+			}
+			for _, nw := range tt.notWant {
+				if strings.Contains(calls[0], nw) {
+					t.Errorf("expected gh args not to contain %q, got %q", nw, calls[0])
+				}
+			}
+		})
+	}
+}
+
+func TestGetIssues_CacheKeyedByFilter(t *testing.T) {
+	argsFile := stubGH(t, `[{"number": 1, "title": "Bug"}]`)
+	ctx := context.Background()
+	client := NewClient("acme", "widgets")
+
+	// First call for each filter goes to the API; repeats are served from cache
+	for _, filter := range []IssueFilter{{}, {Creator: "alice"}, {Creator: "alice"}, {Mentioned: "alice"}, {}} {
+		if _, err := client.GetIssues(ctx, time.Time{}, filter); err != nil {
+			t.Fatalf("GetIssues failed: %v", err)
+		}
+	}
+
+	calls := readCalls(t, argsFile)
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 gh calls (one per distinct filter), got %d: %v", len(calls), calls)
+	}
+	if !strings.Contains(calls[1], "creator=alice") || !strings.Contains(calls[2], "mentioned=alice") {
+		t.Errorf("unexpected gh calls: %v", calls)
+	}
+}
+
+func TestGetPullRequestReviewThreads(t *testing.T) {
+	stubGH(t, `{"data": {"repository": {"pullRequest": {"reviewThreads": {"nodes": [
+		{"id": "RT_resolved", "isResolved": true, "isOutdated": false,
+		 "comments": {"nodes": [{"databaseId": 10}, {"databaseId": 11}]}},
+		{"id": "RT_open", "isResolved": false, "isOutdated": false,
+		 "comments": {"nodes": [{"databaseId": 20}]}},
+		{"id": "RT_outdated", "isResolved": false, "isOutdated": true,
+		 "comments": {"nodes": [{"databaseId": 30}, {"databaseId": 31}]}}
+	]}}}}}`)
+
+	threads, err := NewClient("acme", "widgets").GetPullRequestReviewThreads(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetPullRequestReviewThreads failed: %v", err)
+	}
+	if len(threads) != 3 {
+		t.Fatalf("expected 3 review threads, got %d", len(threads))
+	}
+
+	states := ReviewThreadStates(threads)
+	tests := []struct {
+		name      string
+		commentID int64
+		want      *ReviewThreadState
+	}{
+		{"resolved root", 10, &ReviewThreadState{ID: "RT_resolved", RootCommentID: 10, Resolved: true}},
+		{"resolved reply", 11, &ReviewThreadState{ID: "RT_resolved", RootCommentID: 10, Resolved: true}},
+		{"unresolved", 20, &ReviewThreadState{ID: "RT_open", RootCommentID: 20}},
+		{"outdated reply", 31, &ReviewThreadState{ID: "RT_outdated", RootCommentID: 30, Outdated: true}},
+		{"not in a thread", 99, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := states[tt.commentID]
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("state of comment %d = %+v, want %+v", tt.commentID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchIssues_PullRequests(t *testing.T) {
+	stubGH(t, `{"total_count": 2, "items": [
+		{"number": 1, "title": "Widgets crash", "repository_url": "https://api.github.com/repos/acme/widgets"},
+		{"number": 2, "title": "Fix the crash", "repository_url": "https://api.github.com/repos/acme/widgets",
+			"pull_request": {"url": "https://api.github.com/repos/acme/widgets/pulls/2"}}]}`)
+
+	results, err := NewClient("acme", "").SearchIssues(context.Background(), "repo:acme/widgets", 0)
+	if err != nil {
+		t.Fatalf("SearchIssues failed: %v", err)
+	}
+	if len(results) != 2 || results[0].IsPullRequest() || !results[1].IsPullRequest() {
+		t.Errorf("expected issue #1 and pull request #2, got %+v", results)
+	}
+}
+
+func TestFetchCommitComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		sha      string
+		endpoint string
+	}{
+		{"whole repository", "", "repos/acme/widgets/comments"},
+		{"one commit", "abc123", "repos/acme/widgets/commits/abc123/comments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile := stubGH(t, `[{"id": 1, "body": "Nice", "user": {"login": "octocat"}, "commit_id": "abc123",
+				"path": "main.go", "line": 3, "created_at": "2024-01-15T10:00:00Z", "updated_at": "2024-01-15T10:00:00Z"}]`)
+
+			comments, err := NewClient("acme", "widgets").FetchCommitComments(context.Background(), tt.sha)
+			if err != nil {
+				t.Fatalf("FetchCommitComments failed: %v", err)
+			}
+			if len(comments) != 1 || comments[0].CommitID != "abc123" || comments[0].Path != "main.go" || comments[0].Line != 3 {
+				t.Errorf("unexpected comments: %+v", comments)
+			}
+
+			calls := readCalls(t, argsFile)
+			if len(calls) != 1 || !strings.HasSuffix(calls[0], tt.endpoint) {
+				t.Errorf("expected a call to %s, got %v", tt.endpoint, calls)
+			}
+		})
+	}
+}
+
+func TestGetIssueComments_RefetchedAfterIssueUpdate(t *testing.T) {
+	argsFile := stubGH(t, `[{"id": 100, "body": "Fixed", "user": {"login": "hubot"}}]`)
+	ctx := context.Background()
+	client := NewClient("acme", "widgets")
+
+	// Cached comments are served for an issue that didn't change since, and
+	// refetched for one updated after they were cached
+	steps := []struct {
+		issueUpdatedAt time.Time
+		wantCalls      int
+	}{
+		{time.Time{}, 1},
+		{time.Now().Add(-time.Minute), 1},
+		{time.Now().Add(time.Minute), 2},
+	}
+	for i, step := range steps {
+		comments, err := client.GetIssueComments(ctx, 1, step.issueUpdatedAt, time.Time{})
+		if err != nil {
+			t.Fatalf("step %d: GetIssueComments failed: %v", i, err)
+		}
+		if len(comments) != 1 || comments[0].ID != 100 {
+			t.Errorf("step %d: unexpected comments: %+v", i, comments)
+		}
+		if calls := readCalls(t, argsFile); len(calls) != step.wantCalls {
+			t.Errorf("step %d: expected %d gh calls, got %d: %v", i, step.wantCalls, len(calls), calls)
+		}
+	}
+}
+
+func TestGetIssueComments_CacheExpiresAfterAnHour(t *testing.T) {
+	argsFile := stubGH(t, `[{"id": 100, "body": "Fixed", "user": {"login": "hubot"}}]`)
+	ctx := context.Background()
+	client := NewClient("acme", "widgets")
+
+	fetchedAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	steps := []struct {
+		now       time.Time
+		wantCalls int
+	}{
+		{fetchedAt, 1},
+		{fetchedAt.Add(time.Hour), 1},
+		{fetchedAt.Add(time.Hour + time.Second), 2},
+	}
+	for i, step := range steps {
+		t.Cleanup(clock.Freeze(step.now))
+		if _, err := client.GetIssueComments(ctx, 1, time.Time{}, time.Time{}); err != nil {
+			t.Fatalf("step %d: GetIssueComments failed: %v", i, err)
+		}
+		if calls := readCalls(t, argsFile); len(calls) != step.wantCalls {
+			t.Errorf("step %d: expected %d gh calls, got %d: %v", i, step.wantCalls, len(calls), calls)
+		}
+	}
+}
+
+func TestGetIssueComments_Since(t *testing.T) {
+	argsFile := stubGH(t, `[
+		{"id": 100, "body": "Fixed", "user": {"login": "hubot"}, "updated_at": "2024-02-20T00:00:00Z"},
+		{"id": 101, "body": "Thanks", "user": {"login": "octocat"}, "updated_at": "2024-03-01T00:00:00Z"},
+		{"id": 102, "body": "Reopening", "user": {"login": "octocat"}, "updated_at": "2024-03-02T12:00:00Z"}
+	]`)
+	ctx := context.Background()
+	client := NewClient("acme", "widgets")
+
+	// The API filters by since, but comments older than since are dropped
+	// either way, and the partial list isn't cached
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		comments, err := client.GetIssueComments(ctx, 1, time.Time{}, since)
+		if err != nil {
+			t.Fatalf("GetIssueComments failed: %v", err)
+		}
+
+		var ids []int64
+		for _, comment := range comments {
+			ids = append(ids, comment.ID)
+		}
+		if want := []int64{101, 102}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("call %d: comment IDs = %v, want %v", i, ids, want)
+		}
+	}
+
+	calls := readCalls(t, argsFile)
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 gh calls, got %d: %v", len(calls), calls)
+	}
+	if want := "repos/acme/widgets/issues/1/comments?since=2024-03-01T00:00:00Z"; !strings.Contains(calls[0], want) {
+		t.Errorf("expected gh args to contain %q, got %q", want, calls[0])
+	}
+
+	// Comments cached by a full fetch are served filtered
+	if _, err := client.GetIssueComments(ctx, 1, time.Time{}, time.Time{}); err != nil {
+		t.Fatalf("GetIssueComments failed: %v", err)
+	}
+	comments, err := client.GetIssueComments(ctx, 1, time.Time{}, since)
+	if err != nil {
+		t.Fatalf("GetIssueComments failed: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Errorf("expected 2 cached comments since %v, got %+v", since, comments)
+	}
+	if calls := readCalls(t, argsFile); len(calls) != 3 {
+		t.Errorf("expected the cache to be used, got %d gh calls: %v", len(calls), calls)
+	}
+}
+
+func TestFetchRepoIssueComments(t *testing.T) {
+	argsFile := stubGH(t, `[
+		{"id": 100, "body": "Fixed", "user": {"login": "hubot"}, "issue_url": "https://api.github.com/repos/acme/widgets/issues/1"},
+		{"id": 101, "body": "LGTM", "user": {"login": "octocat"}, "issue_url": "https://api.github.com/repos/acme/widgets/issues/22"},
+		{"id": 102, "body": "Odd", "user": {"login": "octocat"}}
+	]`)
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	comments, err := NewClient("acme", "widgets").FetchRepoIssueComments(context.Background(), since)
+	if err != nil {
+		t.Fatalf("FetchRepoIssueComments failed: %v", err)
+	}
+
+	var numbers []int
+	for _, comment := range comments {
+		numbers = append(numbers, comment.IssueNumber())
+	}
+	if want := []int{1, 22, 0}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("issue numbers = %v, want %v", numbers, want)
+	}
+
+	calls := readCalls(t, argsFile)
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 gh call, got %d: %v", len(calls), calls)
+	}
+	for _, want := range []string{"repos/acme/widgets/issues/comments?", "sort=updated", "since=2024-03-01T00:00:00Z"} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("expected gh args to contain %q, got %q", want, calls[0])
+		}
+	}
+}
+
+func TestCommitAuthorEmail(t *testing.T) {
+	argsFile := stubGH(t, `[
+		{"sha": "a1", "commit": {"author": {"name": "Hubot", "email": "hubot@example.com"}}, "author": {"login": "hubot"}},
+		{"sha": "b2", "commit": {"author": {"name": "Octocat", "email": "583231+octocat@users.noreply.github.com"}}, "author": {"login": "octocat"}},
+		{"sha": "c3", "commit": {"author": {"name": "Octocat", "email": "octocat@example.com"}}, "author": {"login": "Octocat"}},
+		{"sha": "d4", "commit": {"author": {"name": "Mona", "email": "mona@example.com"}}, "author": null}
+	]`)
+
+	commits, err := NewClient("acme", "widgets").GetPullRequestCommits(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetPullRequestCommits failed: %v", err)
+	}
+	if calls := readCalls(t, argsFile); len(calls) != 1 || !strings.HasSuffix(calls[0], "repos/acme/widgets/pulls/2/commits") {
+		t.Errorf("expected a call to the pull request's commits, got %v", calls)
+	}
+
+	tests := []struct {
+		login string
+		want  string
+	}{
+		{"hubot", "hubot@example.com"},
+		{"octocat", "octocat@example.com"}, // Skips the noreply address, ignores case
+		{"mona", ""},                       // Commits GitHub doesn't attribute don't count
+		{"nobody", ""},
+	}
+	for _, tt := range tests {
+		if got := CommitAuthorEmail(commits, tt.login); got != tt.want {
+			t.Errorf("CommitAuthorEmail(%s) = %q, want %q", tt.login, got, tt.want)
+		}
+	}
+}
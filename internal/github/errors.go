@@ -0,0 +1,57 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Errors returned (wrapped) by the client so callers can branch with errors.Is
+var (
+	ErrNotAuthenticated = errors.New("GitHub CLI is not authenticated")
+	ErrRateLimited      = errors.New("GitHub API rate limit exceeded")
+	ErrNotFound         = errors.New("GitHub resource not found")
+	ErrRepoNotFound     = errors.New("GitHub repository not found")
+)
+
+// apiError wraps a failed gh invocation. When gh's stderr identifies the
+// failure (bad credentials, rate limit, 404) the matching sentinel is wrapped
+// too; notFound selects which sentinel a 404 maps to.
+func apiError(op string, err error, notFound error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stderr := strings.TrimSpace(string(exitErr.Stderr))
+	kind := classifyStderr(stderr, notFound)
+	switch {
+	case kind != nil && stderr != "":
+		return fmt.Errorf("%s: %w: %s", op, kind, stderr)
+	case kind != nil:
+		return fmt.Errorf("%s: %w", op, kind)
+	case stderr != "":
+		return fmt.Errorf("%s: %s: %w", op, stderr, err)
+	default:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+// classifyStderr maps gh's error output to one of the sentinel errors, or nil
+func classifyStderr(stderr string, notFound error) error {
+	lower := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(lower, "http 401"),
+		strings.Contains(lower, "bad credentials"),
+		strings.Contains(lower, "gh auth login"):
+		return ErrNotAuthenticated
+	case strings.Contains(lower, "http 429"),
+		strings.Contains(lower, "rate limit"):
+		return ErrRateLimited
+	case strings.Contains(lower, "http 404"),
+		strings.Contains(lower, "could not resolve to a repository"):
+		return notFound
+	}
+	return nil
+}
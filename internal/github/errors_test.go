@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubGHFailure installs a fake gh on PATH that writes stderr and exits 1
+func stubGHFailure(t *testing.T, stderr string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	stderrFile := filepath.Join(dir, "stderr")
+	if err := os.WriteFile(stderrFile, []byte(stderr), 0600); err != nil {
+		t.Fatalf("failed to write stub stderr: %v", err)
+	}
+
+	script := "#!/bin/sh\ncat " + stderrFile + " >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0700); err != nil {
+		t.Fatalf("failed to write stub gh: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("HOME", t.TempDir())
+}
+
+func TestClientErrors(t *testing.T) {
+	fetchIssues := func(c *Client) error {
+		_, err := c.FetchIssues(context.Background(), time.Time{}, IssueFilter{})
+		return err
+	}
+	fetchComments := func(c *Client) error {
+		_, err := c.GetIssueComments(context.Background(), 1, time.Time{}, time.Time{})
+		return err
+	}
+	fetchRepo := func(c *Client) error {
+		_, err := c.GetRepository(context.Background())
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		stderr  string
+		call    func(*Client) error
+		want    error
+		notWant error
+	}{
+		{
+			name:   "bad credentials",
+			stderr: "gh: Bad credentials (HTTP 401)\n",
+			call:   fetchIssues,
+			want:   ErrNotAuthenticated,
+		},
+		{
+			name:   "not logged in",
+			stderr: "To get started with GitHub CLI, please run:  gh auth login\n",
+			call:   fetchComments,
+			want:   ErrNotAuthenticated,
+		},
+		{
+			name:   "primary rate limit",
+			stderr: "gh: API rate limit exceeded for user ID 1. (HTTP 403)\n",
+			call:   fetchIssues,
+			want:   ErrRateLimited,
+		},
+		{
+			name:   "secondary rate limit",
+			stderr: "gh: You have exceeded a secondary rate limit. (HTTP 429)\n",
+			call:   fetchComments,
+			want:   ErrRateLimited,
+		},
+		{
+			name:    "repository not found",
+			stderr:  "gh: Not Found (HTTP 404)\n",
+			call:    fetchRepo,
+			want:    ErrRepoNotFound,
+			notWant: ErrNotFound,
+		},
+		{
+			name:    "issues of missing repository",
+			stderr:  "gh: Not Found (HTTP 404)\n",
+			call:    fetchIssues,
+			want:    ErrRepoNotFound,
+			notWant: ErrNotFound,
+		},
+		{
+			name:    "comments not found",
+			stderr:  "gh: Not Found (HTTP 404)\n",
+			call:    fetchComments,
+			want:    ErrNotFound,
+			notWant: ErrRepoNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubGHFailure(t, tt.stderr)
+
+			err := tt.call(NewClient("octo", "hello"))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+			if tt.notWant != nil && errors.Is(err, tt.notWant) {
+				t.Errorf("errors.Is(%v, %v) = true, want false", err, tt.notWant)
+			}
+			if !strings.Contains(err.Error(), strings.TrimSpace(tt.stderr)) {
+				t.Errorf("error %q does not include gh output", err)
+			}
+		})
+	}
+}
+
+func TestClientErrors_Unclassified(t *testing.T) {
+	stubGHFailure(t, "gh: Server Error (HTTP 500)\n")
+
+	_, err := NewClient("octo", "hello").GetRepository(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, sentinel := range []error{ErrNotAuthenticated, ErrRateLimited, ErrNotFound, ErrRepoNotFound} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(%v, %v) = true, want false", err, sentinel)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// RateLimit is GitHub's request budget for one API resource
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	Reset     time.Time `json:"-"` // When the budget is replenished
+}
+
+// RateLimitStatus holds the budgets fetch uses, as reported by GET /rate_limit
+type RateLimitStatus struct {
+	Core   RateLimit // REST API requests (issues, comments, reviews)
+	Search RateLimit // Search API requests
+}
+
+// RateLimitStatus reads the authoritative rate limit budgets from GitHub.
+// Calls to /rate_limit don't count against the budget.
+func (c *Client) RateLimitStatus(ctx context.Context) (*RateLimitStatus, error) {
+	cmd := exec.CommandContext(ctx, "gh", "api", "rate_limit")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, apiError("failed to fetch rate limit", err, ErrNotFound)
+	}
+
+	return parseRateLimitStatus(output)
+}
+
+// parseRateLimitStatus parses a GET /rate_limit response
+func parseRateLimitStatus(data []byte) (*RateLimitStatus, error) {
+	type resource struct {
+		Limit     int   `json:"limit"`
+		Remaining int   `json:"remaining"`
+		Used      int   `json:"used"`
+		Reset     int64 `json:"reset"` // Unix seconds
+	}
+	var response struct {
+		Resources struct {
+			Core   *resource `json:"core"`
+			Search *resource `json:"search"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit: %w", err)
+	}
+	if response.Resources.Core == nil || response.Resources.Search == nil {
+		return nil, fmt.Errorf("rate limit response is missing core or search resources")
+	}
+
+	convert := func(r *resource) RateLimit {
+		return RateLimit{
+			Limit:     r.Limit,
+			Remaining: r.Remaining,
+			Used:      r.Used,
+			Reset:     time.Unix(r.Reset, 0),
+		}
+	}
+
+	return &RateLimitStatus{
+		Core:   convert(response.Resources.Core),
+		Search: convert(response.Resources.Search),
+	}, nil
+}
+
+// PauseDuration returns how long to wait before making requests against r.
+// It is zero while more than reserve requests remain or once the reset time has passed.
+func (r RateLimit) PauseDuration(now time.Time, reserve int) time.Duration {
+	if r.Remaining > reserve {
+		return 0
+	}
+	if wait := r.Reset.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// PauseDuration returns how long to wait until both the core and search
+// budgets have more than reserve requests available
+func (s *RateLimitStatus) PauseDuration(now time.Time, reserve int) time.Duration {
+	wait := s.Core.PauseDuration(now, reserve)
+	if searchWait := s.Search.PauseDuration(now, reserve); searchWait > wait {
+		wait = searchWait
+	}
+	return wait
+}
@@ -0,0 +1,83 @@
+package github
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const rateLimitResponse = `{
+  "resources": {
+    "core": {"limit": 5000, "used": 4995, "remaining": 5, "reset": 1700000600},
+    "search": {"limit": 30, "used": 2, "remaining": 28, "reset": 1700000060},
+    "graphql": {"limit": 5000, "used": 0, "remaining": 5000, "reset": 1700003600}
+  },
+  "rate": {"limit": 5000, "used": 4995, "remaining": 5, "reset": 1700000600}
+}`
+
+func TestRateLimitStatus(t *testing.T) {
+	argsFile := stubGH(t, rateLimitResponse)
+
+	status, err := NewClient("", "").RateLimitStatus(context.Background())
+	if err != nil {
+		t.Fatalf("RateLimitStatus failed: %v", err)
+	}
+
+	if status.Core.Limit != 5000 || status.Core.Remaining != 5 || status.Core.Used != 4995 {
+		t.Errorf("unexpected core budget: %+v", status.Core)
+	}
+	if !status.Core.Reset.Equal(time.Unix(1700000600, 0)) {
+		t.Errorf("unexpected core reset: %v", status.Core.Reset)
+	}
+	if status.Search.Limit != 30 || status.Search.Remaining != 28 {
+		t.Errorf("unexpected search budget: %+v", status.Search)
+	}
+
+	calls := readCalls(t, argsFile)
+	if len(calls) != 1 || !strings.Contains(calls[0], "api rate_limit") {
+		t.Errorf("expected one call to gh api rate_limit, got %v", calls)
+	}
+}
+
+func TestParseRateLimitStatus_Invalid(t *testing.T) {
+	for _, data := range []string{"not json", `{"resources": {"core": {"limit": 1}}}`} {
+		if _, err := parseRateLimitStatus([]byte(data)); err == nil {
+			t.Errorf("expected error parsing %q", data)
+		}
+	}
+}
+
+func TestRateLimitStatus_PauseDuration(t *testing.T) {
+	status, err := parseRateLimitStatus([]byte(rateLimitResponse))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		reserve int
+		want    time.Duration
+	}{
+		{"core nearly exhausted", time.Unix(1700000000, 0), 10, 10 * time.Minute},
+		{"plenty remaining", time.Unix(1700000000, 0), 4, 0},
+		{"reset already passed", time.Unix(1700000700, 0), 10, 0},
+		{"search exhausted too waits for the later reset", time.Unix(1700000000, 0), 30, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.PauseDuration(tt.now, tt.reserve); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// The search budget alone can force a pause
+	status.Core.Remaining = 5000
+	status.Search.Remaining = 0
+	if got := status.PauseDuration(time.Unix(1700000000, 0), 10); got != time.Minute {
+		t.Errorf("expected 1m pause for exhausted search budget, got %v", got)
+	}
+}
@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Export formats for ReplyGraph.Export
+const (
+	ExportJSON    = "json"    // The graph as mine graph --full includes it
+	ExportDOT     = "dot"     // Graphviz
+	ExportMermaid = "mermaid" // Mermaid flowchart, for Markdown that renders it
+)
+
+// ExportFormats lists the export formats
+var ExportFormats = []string{ExportJSON, ExportDOT, ExportMermaid}
+
+// IsExportFormat reports whether format is one of ExportFormats
+func IsExportFormat(format string) bool {
+	for _, f := range ExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportFormatForPath returns the export format a file name suggests: dot for
+// .dot and .gv, mermaid for .mmd and .mermaid, and json otherwise
+func ExportFormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dot", ".gv":
+		return ExportDOT
+	case ".mmd", ".mermaid":
+		return ExportMermaid
+	default:
+		return ExportJSON
+	}
+}
+
+// Export writes the graph to w in format. Nodes are written oldest first and
+// edges point from parents to replies; replies whose parent isn't in the graph
+// have no edge.
+func (g *ReplyGraph) Export(w io.Writer, format string) error {
+	switch format {
+	case ExportJSON:
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal graph: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case ExportDOT:
+		return g.exportDOT(w)
+	case ExportMermaid:
+		return g.exportMermaid(w)
+	default:
+		return fmt.Errorf("unknown graph export format: %s (expected %s)", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+// ExportFile writes the graph to the file at path in format, creating its
+// directory if needed
+func (g *ReplyGraph) ExportFile(path, format string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := g.Export(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportDOT writes the graph in Graphviz DOT, thread roots as boxes
+func (g *ReplyGraph) exportDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph replies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	nodes := g.sortedNodes()
+	for _, node := range nodes {
+		shape := "ellipse"
+		if node.IsThreadRoot || node.Orphaned {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(node.MessageID), dotQuote(nodeLabel(node, "\n")), shape)
+	}
+	for _, edge := range g.edges(nodes) {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge[0]), dotQuote(edge[1]))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// exportMermaid writes the graph as a Mermaid flowchart, thread roots as
+// rounded boxes. Mermaid node IDs can't hold every character of message IDs,
+// so nodes are numbered in order.
+func (g *ReplyGraph) exportMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	nodes := g.sortedNodes()
+	ids := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.MessageID] = id
+		opening, closing := "[", "]"
+		if node.IsThreadRoot || node.Orphaned {
+			opening, closing = "(", ")"
+		}
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", id, opening, mermaidEscape(nodeLabel(node, "<br/>")), closing)
+	}
+	for _, edge := range g.edges(nodes) {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge[0]], ids[edge[1]])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sortedNodes returns the nodes of the graph, oldest first, then by ID
+func (g *ReplyGraph) sortedNodes() []*MessageNode {
+	nodes := make([]*MessageNode, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if !nodes[i].Timestamp.Equal(nodes[j].Timestamp) {
+			return nodes[i].Timestamp.Before(nodes[j].Timestamp)
+		}
+		return nodes[i].MessageID < nodes[j].MessageID
+	})
+	return nodes
+}
+
+// edges returns the (parent, reply) pairs of the graph whose ends are both
+// nodes, in the order of nodes' replies
+func (g *ReplyGraph) edges(nodes []*MessageNode) [][2]string {
+	var edges [][2]string
+	for _, node := range nodes {
+		if _, ok := g.Nodes[node.ParentID]; ok && node.ParentID != node.MessageID {
+			edges = append(edges, [2]string{node.ParentID, node.MessageID})
+		}
+	}
+	return edges
+}
+
+// nodeLabel returns the author and time of a node, separated by sep
+func nodeLabel(node *MessageNode, sep string) string {
+	author := node.Author
+	if author == "" {
+		author = node.MessageID
+	}
+	return author + sep + node.Timestamp.UTC().Format("2006-01-02 15:04")
+}
+
+// dotQuote quotes s as a DOT ID
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidEscape escapes the characters that end a quoted Mermaid label
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// exportTestGraph returns a thread with a reply and a nested reply, and a
+// reply whose parent wasn't fetched
+func exportTestGraph() *ReplyGraph {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	message := func(id, parentID, author string, minutes int) *normalize.NormalizedMessage {
+		return &normalize.NormalizedMessage{ID: id, ThreadID: "msg_root", ParentID: parentID, IsThreadRoot: parentID == "",
+			Author: &normalize.User{ID: author}, Timestamp: base.Add(time.Duration(minutes) * time.Minute), SourceType: "slack"}
+	}
+	return BuildFromNormalizedMessages([]*normalize.NormalizedMessage{
+		message("msg_root", "", "user_alice", 0),
+		message("msg_reply", "msg_root", "user_bob", 1),
+		message("msg_nested", "msg_reply", `user_"carol"`, 2),
+		message("msg_orphan", "msg_missing", "user_dave", 3),
+	})
+}
+
+func TestExport_JSONMatchesGraph(t *testing.T) {
+	g := exportTestGraph()
+
+	var buf bytes.Buffer
+	if err := g.Export(&buf, ExportJSON); err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+	var got ReplyGraph
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("exported JSON doesn't parse: %v", err)
+	}
+	if !reflect.DeepEqual(got.Nodes, g.Nodes) || !reflect.DeepEqual(got.Adjacency, g.Adjacency) ||
+		!reflect.DeepEqual(got.ThreadRoots, g.ThreadRoots) {
+		t.Errorf("exported graph differs from the graph:\n%s", buf.String())
+	}
+}
+
+func TestExport_DOTAndMermaid(t *testing.T) {
+	g := exportTestGraph()
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{ExportDOT, []string{
+			"digraph replies {",
+			`"msg_root" [label="user_alice\n2024-01-15 10:00", shape=box];`,
+			`"msg_nested" [label="user_\"carol\"\n2024-01-15 10:02", shape=ellipse];`,
+			`"msg_orphan" [label="user_dave\n2024-01-15 10:03", shape=box];`,
+			`"msg_root" -> "msg_reply";`,
+			`"msg_reply" -> "msg_nested";`,
+		}},
+		{ExportMermaid, []string{
+			"flowchart LR",
+			`n0("user_alice<br/>2024-01-15 10:00")`,
+			`n1["user_bob<br/>2024-01-15 10:01"]`,
+			`n2["user_#quot;carol#quot;<br/>2024-01-15 10:02"]`,
+			"n0 --> n1",
+			"n1 --> n2",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := g.Export(&buf, tt.format); err != nil {
+				t.Fatalf("Export() failed: %v", err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected %q in:\n%s", want, out)
+				}
+			}
+			// One edge per reply whose parent is in the graph
+			if edges := strings.Count(out, "->"); edges != 2 {
+				t.Errorf("got %d edges, want 2:\n%s", edges, out)
+			}
+		})
+	}
+}
+
+func TestExportFile(t *testing.T) {
+	g := exportTestGraph()
+	path := filepath.Join(t.TempDir(), "out", "graph.dot")
+
+	if err := g.ExportFile(path, ExportFormatForPath(path)); err != nil {
+		t.Fatalf("ExportFile() failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "digraph replies {") {
+		t.Errorf("expected a DOT file, got:\n%s", data)
+	}
+
+	if err := g.Export(&bytes.Buffer{}, "svg"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestExportFormatForPath(t *testing.T) {
+	tests := map[string]string{
+		"graph.json":     ExportJSON,
+		"graph":          ExportJSON,
+		"graph.dot":      ExportDOT,
+		"graph.GV":       ExportDOT,
+		"graph.mmd":      ExportMermaid,
+		"graph.mermaid":  ExportMermaid,
+		"dir.dot/graph.": ExportJSON,
+	}
+	for path, want := range tests {
+		if got := ExportFormatForPath(path); got != want {
+			t.Errorf("ExportFormatForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package graph
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/solvaholic/threadmine/internal/normalize"
@@ -20,6 +22,19 @@ type MessageNode struct {
 	Timestamp    time.Time `json:"timestamp"`
 	Channel      string    `json:"channel"`
 	SourceType   string    `json:"source_type"`
+
+	// Classifications holds classification types (question, answer, ...) when the
+	// graph was built with BuildFromClassifiedMessages. Omitted from older saved graphs.
+	Classifications []string `json:"classifications,omitempty"`
+
+	// Orphaned marks a reply whose parent isn't in the graph, listed in
+	// ThreadRoots until the parent is added (see ReconcileOrphans)
+	Orphaned bool `json:"orphaned,omitempty"`
+
+	// ReplyLatency is the time from the parent's timestamp to this reply's,
+	// set by ReconcileOrphans; zero for thread roots and replies whose parent
+	// isn't in the graph
+	ReplyLatency time.Duration `json:"reply_latency,omitempty"`
 }
 
 // ReplyGraph represents the message reply structure
@@ -75,9 +90,200 @@ func (g *ReplyGraph) AddMessage(msg *normalize.NormalizedMessage) {
 		g.Adjacency[msg.ParentID] = append(g.Adjacency[msg.ParentID], msg.ID)
 	}
 
+	// Replies added before this message stop being roots of their own
+	g.adoptOrphans(msg.ID)
+
+	g.UpdatedAt = time.Now()
+}
+
+// ReconcileOrphans promotes replies whose parent isn't in the graph, which
+// happens when a fetch got a reply but not its parent, to synthetic thread
+// roots: they're marked Orphaned and added to ThreadRoots, so their threads
+// show up in ThreadRoots and GetThread. Orphans whose parent has been added
+// since are linked to it instead. It also sets each reply's ReplyLatency,
+// which needs its parent in the graph. It returns the number of orphans left.
+func (g *ReplyGraph) ReconcileOrphans() int {
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	orphans := 0
+	for _, id := range ids {
+		node := g.Nodes[id]
+		node.ReplyLatency = 0
+		if node.ParentID == "" || node.IsThreadRoot {
+			continue
+		}
+		if parent, ok := g.Nodes[node.ParentID]; ok {
+			node.ReplyLatency = node.Timestamp.Sub(parent.Timestamp)
+			if node.Orphaned {
+				node.Orphaned = false
+				g.ThreadRoots = removeID(g.ThreadRoots, id)
+			}
+			continue
+		}
+		if !node.Orphaned {
+			node.Orphaned = true
+			g.ThreadRoots = append(g.ThreadRoots, id)
+		}
+		orphans++
+	}
+
+	g.UpdatedAt = time.Now()
+	return orphans
+}
+
+// adoptOrphans links the orphaned children of parentID, which was just added,
+// back under it
+func (g *ReplyGraph) adoptOrphans(parentID string) {
+	for _, childID := range g.Adjacency[parentID] {
+		child, ok := g.Nodes[childID]
+		if !ok || !child.Orphaned {
+			continue
+		}
+		child.Orphaned = false
+		if !child.IsThreadRoot {
+			g.ThreadRoots = removeID(g.ThreadRoots, childID)
+		}
+	}
+}
+
+// RemoveMessage removes a message and its edges from the graph. Its children
+// are re-parented to its parent when the parent is in the graph; otherwise
+// (a thread root, or a parent that was never added) they're orphaned with an
+// empty ParentID. Orphans keep their ThreadID and aren't promoted to roots.
+// Re-parented children's ReplyLatency is measured from their new parent.
+func (g *ReplyGraph) RemoveMessage(id string) {
+	node, exists := g.Nodes[id]
+	if !exists {
+		return
+	}
+	delete(g.Nodes, id)
+
+	children := g.Adjacency[id]
+	delete(g.Adjacency, id)
+
+	newParent := ""
+	if node.ParentID != "" {
+		g.Adjacency[node.ParentID] = removeID(g.Adjacency[node.ParentID], id)
+		if _, ok := g.Nodes[node.ParentID]; ok {
+			newParent = node.ParentID
+		}
+		if len(g.Adjacency[node.ParentID]) == 0 {
+			delete(g.Adjacency, node.ParentID)
+		}
+	}
+
+	for _, childID := range children {
+		child, ok := g.Nodes[childID]
+		if !ok {
+			continue
+		}
+		child.ParentID = newParent
+		child.ReplyLatency = 0
+		if newParent != "" {
+			g.Adjacency[newParent] = append(g.Adjacency[newParent], childID)
+			child.ReplyLatency = child.Timestamp.Sub(g.Nodes[newParent].Timestamp)
+		}
+	}
+
+	g.ThreadRoots = removeID(g.ThreadRoots, id)
 	g.UpdatedAt = time.Now()
 }
 
+// Merge adds the messages of other to the graph, replacing the nodes of
+// messages it already has, e.g. when a later fetch refreshes a thread. Each
+// replaced node moves to its new parent; replies whose parent is in neither
+// graph stay orphaned (see ReconcileOrphans).
+func (g *ReplyGraph) Merge(other *ReplyGraph) {
+	ids := make([]string, 0, len(other.Nodes))
+	for id := range other.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if old, exists := g.Nodes[id]; exists {
+			if old.ParentID != "" {
+				g.Adjacency[old.ParentID] = removeID(g.Adjacency[old.ParentID], id)
+				if len(g.Adjacency[old.ParentID]) == 0 {
+					delete(g.Adjacency, old.ParentID)
+				}
+			}
+			g.ThreadRoots = removeID(g.ThreadRoots, id)
+		}
+
+		node := *other.Nodes[id]
+		node.Orphaned = false
+		g.Nodes[id] = &node
+		if node.IsThreadRoot {
+			g.ThreadRoots = append(g.ThreadRoots, id)
+		}
+		if node.ParentID != "" {
+			g.Adjacency[node.ParentID] = append(g.Adjacency[node.ParentID], id)
+		}
+	}
+
+	g.ReconcileOrphans()
+}
+
+// Prune removes every message whose ID isn't in validIDs (see RemoveMessage),
+// then drops edges and thread roots that point to messages not in the graph,
+// except the edges of orphans (see ReconcileOrphans) to their missing parent.
+// It returns the number of messages removed.
+func (g *ReplyGraph) Prune(validIDs map[string]bool) int {
+	var stale []string
+	for id := range g.Nodes {
+		if !validIDs[id] {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	for _, id := range stale {
+		g.RemoveMessage(id)
+	}
+
+	for parentID, children := range g.Adjacency {
+		_, parentExists := g.Nodes[parentID]
+		kept := children[:0]
+		for _, childID := range children {
+			child, ok := g.Nodes[childID]
+			if ok && (parentExists || child.Orphaned) {
+				kept = append(kept, childID)
+			}
+		}
+		if len(kept) == 0 {
+			delete(g.Adjacency, parentID)
+			continue
+		}
+		g.Adjacency[parentID] = kept
+	}
+
+	roots := g.ThreadRoots[:0]
+	for _, rootID := range g.ThreadRoots {
+		if _, ok := g.Nodes[rootID]; ok {
+			roots = append(roots, rootID)
+		}
+	}
+	g.ThreadRoots = roots
+
+	g.UpdatedAt = time.Now()
+	return len(stale)
+}
+
+// removeID returns ids without id, reusing the backing array
+func removeID(ids []string, id string) []string {
+	kept := ids[:0]
+	for _, other := range ids {
+		if other != id {
+			kept = append(kept, other)
+		}
+	}
+	return kept
+}
+
 // GetChildren returns the direct children of a message
 func (g *ReplyGraph) GetChildren(messageID string) []string {
 	return g.Adjacency[messageID]
@@ -140,6 +346,88 @@ func (g *ReplyGraph) calculateDepth(messageID string, currentDepth int) int {
 	return maxDepth
 }
 
+// ThreadActivity summarizes a thread's size and when it was last active
+type ThreadActivity struct {
+	RootID         string    `json:"root_id"`
+	ReplyCount     int       `json:"reply_count"`
+	Participants   []string  `json:"participants"`
+	MaxDepth       int       `json:"max_depth"`
+	StartedAt      time.Time `json:"started_at"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+// GetThreadActivity returns the reply count, participants, and latest activity
+// of the thread rooted at rootID, or nil if the root is not in the graph
+func (g *ReplyGraph) GetThreadActivity(rootID string) *ThreadActivity {
+	thread := g.GetThread(rootID)
+	if len(thread) == 0 {
+		return nil
+	}
+
+	activity := &ThreadActivity{
+		RootID:         rootID,
+		ReplyCount:     len(thread) - 1,
+		Participants:   []string{},
+		MaxDepth:       g.GetThreadDepth(rootID),
+		StartedAt:      thread[0].Timestamp,
+		LastActivityAt: thread[0].Timestamp,
+	}
+
+	seen := make(map[string]bool)
+	for _, node := range thread {
+		if node.Timestamp.After(activity.LastActivityAt) {
+			activity.LastActivityAt = node.Timestamp
+		}
+		if node.Author != "" && !seen[node.Author] {
+			seen[node.Author] = true
+			activity.Participants = append(activity.Participants, node.Author)
+		}
+	}
+
+	return activity
+}
+
+// Thread orders for TopThreads
+const (
+	SortBySize   = "size"   // Most replies first
+	SortByRecent = "recent" // Most recent activity first
+)
+
+// TopThreads returns the activity of the n largest (SortBySize) or most
+// recently active (SortByRecent) threads, or of every thread if n <= 0.
+// Ties are broken by the other order, then by root ID.
+func (g *ReplyGraph) TopThreads(n int, by string) ([]*ThreadActivity, error) {
+	if by != SortBySize && by != SortByRecent {
+		return nil, fmt.Errorf("unknown thread order: %s (expected %s or %s)", by, SortBySize, SortByRecent)
+	}
+
+	threads := make([]*ThreadActivity, 0, len(g.ThreadRoots))
+	for _, rootID := range g.ThreadRoots {
+		if activity := g.GetThreadActivity(rootID); activity != nil {
+			threads = append(threads, activity)
+		}
+	}
+
+	sort.Slice(threads, func(i, j int) bool {
+		a, b := threads[i], threads[j]
+		if by == SortBySize && a.ReplyCount != b.ReplyCount {
+			return a.ReplyCount > b.ReplyCount
+		}
+		if !a.LastActivityAt.Equal(b.LastActivityAt) {
+			return a.LastActivityAt.After(b.LastActivityAt)
+		}
+		if a.ReplyCount != b.ReplyCount {
+			return a.ReplyCount > b.ReplyCount
+		}
+		return a.RootID < b.RootID
+	})
+
+	if n > 0 && len(threads) > n {
+		threads = threads[:n]
+	}
+	return threads, nil
+}
+
 // Stats returns statistics about the graph
 func (g *ReplyGraph) Stats() map[string]interface{} {
 	threadCount := len(g.ThreadRoots)
@@ -193,6 +481,24 @@ func StructureDir() (string, error) {
 	return filepath.Join(graphDir, "structure"), nil
 }
 
+// GraphFormatVersion is the version of the saved graph format, recorded in
+// metadata.json. Graphs saved before the version was recorded are version 0.
+// Bump it when a change to the saved files needs LoadReplyGraph to convert
+// older graphs; fields that can simply be missing (omitempty) don't.
+const GraphFormatVersion = 1
+
+// ErrUnsupportedGraphVersion is returned by LoadReplyGraph for graphs saved
+// in a newer format than it can read
+var ErrUnsupportedGraphVersion = errors.New("unsupported graph format version")
+
+// graphMetadata is the content of metadata.json. Unknown fields, like those of
+// newer stats, are ignored.
+type graphMetadata struct {
+	Version   int                    `json:"version"`
+	UpdatedAt string                 `json:"updated_at"`
+	Stats     map[string]interface{} `json:"stats,omitempty"`
+}
+
 // SaveReplyGraph saves the reply graph to disk
 func SaveReplyGraph(g *ReplyGraph) error {
 	dir, err := StructureDir()
@@ -221,9 +527,10 @@ func SaveReplyGraph(g *ReplyGraph) error {
 	}
 
 	// Save metadata
-	metadata := map[string]interface{}{
-		"updated_at": g.UpdatedAt.Format(time.RFC3339),
-		"stats":      g.Stats(),
+	metadata := graphMetadata{
+		Version:   GraphFormatVersion,
+		UpdatedAt: g.UpdatedAt.Format(time.RFC3339),
+		Stats:     g.Stats(),
 	}
 	if err := saveGraphFile(dir, "metadata.json", metadata); err != nil {
 		return fmt.Errorf("failed to save metadata: %w", err)
@@ -256,13 +563,29 @@ func saveGraphFile(dir, filename string, data interface{}) error {
 	return nil
 }
 
-// LoadReplyGraph loads the reply graph from disk
+// LoadReplyGraph loads the reply graph from disk. Graphs saved by older
+// versions are converted to the current format; graphs saved in a newer format
+// fail with ErrUnsupportedGraphVersion.
 func LoadReplyGraph() (*ReplyGraph, error) {
 	dir, err := StructureDir()
 	if err != nil {
 		return nil, err
 	}
 
+	// Load metadata first for the format version. Graphs without metadata
+	// are loaded like the oldest ones.
+	var metadata graphMetadata
+	metadataPath := filepath.Join(dir, "metadata.json")
+	if _, statErr := os.Stat(metadataPath); statErr == nil {
+		if err := loadGraphFile(metadataPath, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to load metadata: %w", err)
+		}
+	}
+	if metadata.Version > GraphFormatVersion {
+		return nil, fmt.Errorf("%w %d (this version of ThreadMine reads up to %d): upgrade ThreadMine, or remove %s and rebuild the graph",
+			ErrUnsupportedGraphVersion, metadata.Version, GraphFormatVersion, dir)
+	}
+
 	g := NewReplyGraph()
 
 	// Load nodes
@@ -283,15 +606,14 @@ func LoadReplyGraph() (*ReplyGraph, error) {
 		return nil, fmt.Errorf("failed to load thread roots: %w", err)
 	}
 
-	// Load metadata to get updated_at
-	metadataPath := filepath.Join(dir, "metadata.json")
-	var metadata map[string]interface{}
-	if err := loadGraphFile(metadataPath, &metadata); err == nil {
-		if updatedAtStr, ok := metadata["updated_at"].(string); ok {
-			if t, err := time.Parse(time.RFC3339, updatedAtStr); err == nil {
-				g.UpdatedAt = t
-			}
-		}
+	// Version 0 graphs left replies without their parent out of the thread
+	// roots; mark them orphaned like BuildFromNormalizedMessages does now
+	if metadata.Version < 1 {
+		g.ReconcileOrphans()
+	}
+
+	if t, err := time.Parse(time.RFC3339, metadata.UpdatedAt); err == nil {
+		g.UpdatedAt = t
 	}
 
 	return g, nil
@@ -314,11 +636,27 @@ func loadGraphFile(filePath string, v interface{}) error {
 	return nil
 }
 
-// BuildFromNormalizedMessages builds a reply graph from a slice of normalized messages
+// BuildFromNormalizedMessages builds a reply graph from a slice of normalized
+// messages. Replies whose parent isn't among them become synthetic thread
+// roots (see ReconcileOrphans).
 func BuildFromNormalizedMessages(messages []*normalize.NormalizedMessage) *ReplyGraph {
 	g := NewReplyGraph()
 	for _, msg := range messages {
 		g.AddMessage(msg)
 	}
+	g.ReconcileOrphans()
+	return g
+}
+
+// BuildFromClassifiedMessages builds a reply graph like BuildFromNormalizedMessages,
+// labeling each node with its classification types from classifications (message_id -> types).
+// Messages missing from the map are left unlabeled.
+func BuildFromClassifiedMessages(messages []*normalize.NormalizedMessage, classifications map[string][]string) *ReplyGraph {
+	g := BuildFromNormalizedMessages(messages)
+	for id, types := range classifications {
+		if node, exists := g.Nodes[id]; exists && len(types) > 0 {
+			node.Classifications = append([]string(nil), types...)
+		}
+	}
 	return g
 }
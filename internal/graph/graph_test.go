@@ -1,6 +1,13 @@
 package graph
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -149,6 +156,53 @@ func TestReplyGraph_GetThreadDepth(t *testing.T) {
 	}
 }
 
+func TestReplyGraph_GetThreadActivity(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	alice := &normalize.User{ID: "user_alice"}
+	bob := &normalize.User{ID: "user_bob"}
+
+	root := &normalize.NormalizedMessage{ID: "root", IsThreadRoot: true, ThreadID: "root", Author: alice, Timestamp: start}
+	// Added out of order: the nested reply is the latest activity
+	messages := []*normalize.NormalizedMessage{
+		root,
+		{ID: "nested", ParentID: "reply1", ThreadID: "root", Author: alice, Timestamp: start.Add(3 * time.Hour)},
+		{ID: "reply1", ParentID: "root", ThreadID: "root", Author: bob, Timestamp: start.Add(time.Hour)},
+		{ID: "reply2", ParentID: "root", ThreadID: "root", Author: bob, Timestamp: start.Add(2 * time.Hour)},
+		{ID: "other", IsThreadRoot: true, ThreadID: "other", Author: bob, Timestamp: start.Add(5 * time.Hour)},
+	}
+	g := BuildFromNormalizedMessages(messages)
+
+	activity := g.GetThreadActivity("root")
+	if activity == nil {
+		t.Fatal("expected activity for root")
+	}
+	if activity.ReplyCount != 3 {
+		t.Errorf("expected 3 replies, got %d", activity.ReplyCount)
+	}
+	if !activity.LastActivityAt.Equal(start.Add(3 * time.Hour)) {
+		t.Errorf("expected last activity at the nested reply, got %v", activity.LastActivityAt)
+	}
+	if !activity.StartedAt.Equal(start) {
+		t.Errorf("expected thread to start at the root, got %v", activity.StartedAt)
+	}
+	if activity.MaxDepth != 2 {
+		t.Errorf("expected depth 2, got %d", activity.MaxDepth)
+	}
+	if len(activity.Participants) != 2 {
+		t.Errorf("expected 2 participants, got %v", activity.Participants)
+	}
+
+	// A root without replies was last active when it was posted
+	lone := g.GetThreadActivity("other")
+	if lone.ReplyCount != 0 || !lone.LastActivityAt.Equal(start.Add(5*time.Hour)) {
+		t.Errorf("unexpected activity for thread without replies: %+v", lone)
+	}
+
+	if g.GetThreadActivity("missing") != nil {
+		t.Error("expected nil activity for a root not in the graph")
+	}
+}
+
 func TestReplyGraph_Stats(t *testing.T) {
 	g := NewReplyGraph()
 
@@ -228,3 +282,453 @@ func TestBuildFromNormalizedMessages(t *testing.T) {
 		t.Errorf("Expected 1 child, got %d", len(children))
 	}
 }
+
+func TestBuildFromClassifiedMessages(t *testing.T) {
+	messages := []*normalize.NormalizedMessage{
+		{ID: "root", IsThreadRoot: true, ThreadID: "thread"},
+		{ID: "reply", ParentID: "root", ThreadID: "thread"},
+		{ID: "ack", ParentID: "reply", ThreadID: "thread"},
+	}
+	classifications := map[string][]string{
+		"root":    {"question"},
+		"reply":   {"answer", "solution"},
+		"missing": {"question"},
+	}
+
+	g := BuildFromClassifiedMessages(messages, classifications)
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"root", []string{"question"}},
+		{"reply", []string{"answer", "solution"}},
+		{"ack", nil},
+	}
+	for _, tt := range tests {
+		got := g.Nodes[tt.id].Classifications
+		if len(got) != len(tt.want) {
+			t.Errorf("node %s: expected classifications %v, got %v", tt.id, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("node %s: expected classifications %v, got %v", tt.id, tt.want, got)
+			}
+		}
+	}
+
+	if _, exists := g.Nodes["missing"]; exists {
+		t.Error("classification for unknown message should not create a node")
+	}
+
+	// Structure matches an unlabeled build
+	if len(g.GetThread("root")) != 3 {
+		t.Errorf("expected 3 messages in thread, got %d", len(g.GetThread("root")))
+	}
+}
+
+func TestMessageNodeClassificationsOptional(t *testing.T) {
+	// Saved graphs from before classifications existed still load
+	var node MessageNode
+	if err := json.Unmarshal([]byte(`{"message_id":"m1","thread_id":"t1","is_thread_root":true}`), &node); err != nil {
+		t.Fatalf("failed to unmarshal node: %v", err)
+	}
+	if node.Classifications != nil {
+		t.Errorf("expected no classifications, got %v", node.Classifications)
+	}
+
+	// Unlabeled nodes don't write the field
+	data, err := json.Marshal(&MessageNode{MessageID: "m1"})
+	if err != nil {
+		t.Fatalf("failed to marshal node: %v", err)
+	}
+	if strings.Contains(string(data), "classifications") {
+		t.Errorf("expected classifications to be omitted, got %s", data)
+	}
+}
+
+// removalGraph builds root -> middle -> {leaf1, leaf2} in one thread, plus
+// another thread root
+func removalGraph() *ReplyGraph {
+	return BuildFromNormalizedMessages([]*normalize.NormalizedMessage{
+		{ID: "root", IsThreadRoot: true, ThreadID: "thread"},
+		{ID: "middle", ParentID: "root", ThreadID: "thread"},
+		{ID: "leaf1", ParentID: "middle", ThreadID: "thread"},
+		{ID: "leaf2", ParentID: "middle", ThreadID: "thread"},
+		{ID: "other", IsThreadRoot: true, ThreadID: "other"},
+	})
+}
+
+func TestReplyGraph_RemoveMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		remove    string
+		adjacency map[string][]string
+		parents   map[string]string // remaining node -> ParentID
+		roots     []string
+	}{
+		{
+			name:      "root orphans its children",
+			remove:    "root",
+			adjacency: map[string][]string{"middle": {"leaf1", "leaf2"}},
+			parents:   map[string]string{"middle": "", "leaf1": "middle", "leaf2": "middle", "other": ""},
+			roots:     []string{"other"},
+		},
+		{
+			name:      "middle re-parents its children",
+			remove:    "middle",
+			adjacency: map[string][]string{"root": {"leaf1", "leaf2"}},
+			parents:   map[string]string{"root": "", "leaf1": "root", "leaf2": "root", "other": ""},
+			roots:     []string{"root", "other"},
+		},
+		{
+			name:      "leaf",
+			remove:    "leaf1",
+			adjacency: map[string][]string{"root": {"middle"}, "middle": {"leaf2"}},
+			parents:   map[string]string{"root": "", "middle": "root", "leaf2": "middle", "other": ""},
+			roots:     []string{"root", "other"},
+		},
+		{
+			name:      "unknown message",
+			remove:    "missing",
+			adjacency: map[string][]string{"root": {"middle"}, "middle": {"leaf1", "leaf2"}},
+			parents:   map[string]string{"root": "", "middle": "root", "leaf1": "middle", "leaf2": "middle", "other": ""},
+			roots:     []string{"root", "other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := removalGraph()
+			g.RemoveMessage(tt.remove)
+
+			if _, exists := g.Nodes[tt.remove]; exists {
+				t.Errorf("node %s still in graph", tt.remove)
+			}
+			if !reflect.DeepEqual(g.Adjacency, tt.adjacency) {
+				t.Errorf("adjacency = %v, want %v", g.Adjacency, tt.adjacency)
+			}
+			if len(g.Nodes) != len(tt.parents) {
+				t.Errorf("expected %d nodes, got %d", len(tt.parents), len(g.Nodes))
+			}
+			for id, parent := range tt.parents {
+				node, exists := g.Nodes[id]
+				if !exists {
+					t.Errorf("node %s missing", id)
+					continue
+				}
+				if node.ParentID != parent {
+					t.Errorf("node %s: ParentID = %q, want %q", id, node.ParentID, parent)
+				}
+			}
+			if !reflect.DeepEqual(g.ThreadRoots, tt.roots) {
+				t.Errorf("ThreadRoots = %v, want %v", g.ThreadRoots, tt.roots)
+			}
+		})
+	}
+}
+
+func TestReplyGraph_Prune(t *testing.T) {
+	g := removalGraph()
+	// A saved graph can hold edges to messages that were never added
+	g.Adjacency["ghost"] = []string{"other"}
+	g.Adjacency["other"] = []string{"ghost_reply"}
+	g.ThreadRoots = append(g.ThreadRoots, "ghost")
+
+	removed := g.Prune(map[string]bool{"root": true, "leaf2": true, "other": true})
+	if removed != 2 {
+		t.Errorf("expected 2 messages removed, got %d", removed)
+	}
+
+	wantAdjacency := map[string][]string{"root": {"leaf2"}}
+	if !reflect.DeepEqual(g.Adjacency, wantAdjacency) {
+		t.Errorf("adjacency = %v, want %v", g.Adjacency, wantAdjacency)
+	}
+	if len(g.Nodes) != 3 || g.Nodes["leaf2"].ParentID != "root" {
+		t.Errorf("expected root, leaf2 (under root), other; got %v", g.Nodes)
+	}
+	if !reflect.DeepEqual(g.ThreadRoots, []string{"root", "other"}) {
+		t.Errorf("ThreadRoots = %v, want [root other]", g.ThreadRoots)
+	}
+	if got := len(g.GetThread("root")); got != 2 {
+		t.Errorf("expected 2 messages in thread, got %d", got)
+	}
+}
+
+func TestReplyGraph_Merge(t *testing.T) {
+	g := removalGraph()
+	g.AddMessage(&normalize.NormalizedMessage{ID: "early", ParentID: "p", ThreadID: "p"})
+	g.ReconcileOrphans()
+
+	// A later fetch moves leaf1 under the root, adds a reply to leaf2, and
+	// brings early's parent and a reply whose parent is still missing
+	g.Merge(BuildFromNormalizedMessages([]*normalize.NormalizedMessage{
+		{ID: "leaf1", ParentID: "root", ThreadID: "thread"},
+		{ID: "leaf3", ParentID: "leaf2", ThreadID: "thread"},
+		{ID: "p", IsThreadRoot: true, ThreadID: "p"},
+		{ID: "late", ParentID: "missing", ThreadID: "missing"},
+	}))
+
+	wantAdjacency := map[string][]string{
+		"root":    {"middle", "leaf1"},
+		"middle":  {"leaf2"},
+		"leaf2":   {"leaf3"},
+		"p":       {"early"},
+		"missing": {"late"},
+	}
+	if !reflect.DeepEqual(g.Adjacency, wantAdjacency) {
+		t.Errorf("adjacency = %v, want %v", g.Adjacency, wantAdjacency)
+	}
+	if !reflect.DeepEqual(g.ThreadRoots, []string{"root", "other", "p", "late"}) {
+		t.Errorf("ThreadRoots = %v, want [root other p late]", g.ThreadRoots)
+	}
+	if g.Nodes["early"].Orphaned || !g.Nodes["late"].Orphaned {
+		t.Errorf("expected early adopted and late orphaned, got %+v and %+v", g.Nodes["early"], g.Nodes["late"])
+	}
+	if got := len(g.GetThread("root")); got != 5 {
+		t.Errorf("expected 5 messages in thread, got %d", got)
+	}
+}
+
+func TestReplyGraph_TopThreads(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	// big: 3 replies, last active +3m; recent: 1 reply, last active +1h;
+	// quiet: no replies, started +5m; tie: 1 reply, last active +1h (same as recent)
+	messages := []*normalize.NormalizedMessage{
+		{ID: "big", IsThreadRoot: true, Timestamp: base},
+		{ID: "big_1", ParentID: "big", Timestamp: base.Add(time.Minute)},
+		{ID: "big_2", ParentID: "big", Timestamp: base.Add(2 * time.Minute)},
+		{ID: "big_3", ParentID: "big_2", Timestamp: base.Add(3 * time.Minute)},
+		{ID: "recent", IsThreadRoot: true, Timestamp: base},
+		{ID: "recent_1", ParentID: "recent", Timestamp: base.Add(time.Hour)},
+		{ID: "quiet", IsThreadRoot: true, Timestamp: base.Add(5 * time.Minute)},
+		{ID: "tie", IsThreadRoot: true, Timestamp: base},
+		{ID: "tie_1", ParentID: "tie", Timestamp: base.Add(time.Hour)},
+	}
+	g := BuildFromNormalizedMessages(messages)
+
+	tests := []struct {
+		name string
+		n    int
+		by   string
+		want []string
+	}{
+		{"all by size", 0, SortBySize, []string{"big", "recent", "tie", "quiet"}},
+		{"top 2 by size", 2, SortBySize, []string{"big", "recent"}},
+		{"all by recent", 0, SortByRecent, []string{"recent", "tie", "quiet", "big"}},
+		{"top 1 by recent", 1, SortByRecent, []string{"recent"}},
+		{"limit above thread count", 10, SortBySize, []string{"big", "recent", "tie", "quiet"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threads, err := g.TopThreads(tt.n, tt.by)
+			if err != nil {
+				t.Fatalf("TopThreads failed: %v", err)
+			}
+			got := make([]string, len(threads))
+			for i, thread := range threads {
+				got[i] = thread.RootID
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopThreads(%d, %s) = %v, want %v", tt.n, tt.by, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := g.TopThreads(1, "oldest"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+func TestReplyGraph_OrphanedReplies(t *testing.T) {
+	root := &normalize.NormalizedMessage{ID: "root", IsThreadRoot: true, ThreadID: "thread"}
+	reply := &normalize.NormalizedMessage{ID: "reply", ParentID: "root", ThreadID: "thread"}
+	nested := &normalize.NormalizedMessage{ID: "nested", ParentID: "reply", ThreadID: "thread"}
+
+	threadIDs := func(g *ReplyGraph, rootID string) []string {
+		var ids []string
+		for _, node := range g.GetThread(rootID) {
+			ids = append(ids, node.MessageID)
+		}
+		return ids
+	}
+
+	// A partial fetch got the replies but not their parent: the first reply
+	// stands in as the thread's root
+	g := BuildFromNormalizedMessages([]*normalize.NormalizedMessage{nested, reply})
+	if !reflect.DeepEqual(g.ThreadRoots, []string{"reply"}) {
+		t.Errorf("ThreadRoots = %v, want [reply]", g.ThreadRoots)
+	}
+	if !g.Nodes["reply"].Orphaned || g.Nodes["nested"].Orphaned {
+		t.Errorf("expected only reply to be orphaned, got reply %v, nested %v", g.Nodes["reply"].Orphaned, g.Nodes["nested"].Orphaned)
+	}
+	if got := threadIDs(g, "reply"); !reflect.DeepEqual(got, []string{"reply", "nested"}) {
+		t.Errorf("GetThread(reply) = %v, want [reply nested]", got)
+	}
+
+	// Pruning keeps the orphan's edge to its missing parent
+	g.Prune(map[string]bool{"reply": true, "nested": true})
+
+	// The parent arrives later: the reply is linked under it
+	g.AddMessage(root)
+	if !reflect.DeepEqual(g.ThreadRoots, []string{"root"}) {
+		t.Errorf("after adding the parent: ThreadRoots = %v, want [root]", g.ThreadRoots)
+	}
+	if g.Nodes["reply"].Orphaned {
+		t.Error("expected reply to be linked to its parent")
+	}
+	if got := threadIDs(g, "root"); !reflect.DeepEqual(got, []string{"root", "reply", "nested"}) {
+		t.Errorf("GetThread(root) = %v, want [root reply nested]", got)
+	}
+	if orphans := g.ReconcileOrphans(); orphans != 0 {
+		t.Errorf("expected no orphans left, got %d", orphans)
+	}
+
+	// A rebuild with every message, in any order, has no orphans
+	g = BuildFromNormalizedMessages([]*normalize.NormalizedMessage{nested, reply, root})
+	if !reflect.DeepEqual(g.ThreadRoots, []string{"root"}) {
+		t.Errorf("rebuild: ThreadRoots = %v, want [root]", g.ThreadRoots)
+	}
+	if got := threadIDs(g, "root"); len(got) != 3 {
+		t.Errorf("rebuild: GetThread(root) = %v, want 3 messages", got)
+	}
+
+	// Orphans in a loaded graph are linked by reconciling after the parent is added
+	g = BuildFromNormalizedMessages([]*normalize.NormalizedMessage{reply})
+	g.Nodes["root"] = &MessageNode{MessageID: "root", ThreadID: "thread", IsThreadRoot: true}
+	g.ThreadRoots = append(g.ThreadRoots, "root")
+	if orphans := g.ReconcileOrphans(); orphans != 0 || !reflect.DeepEqual(g.ThreadRoots, []string{"root"}) {
+		t.Errorf("ReconcileOrphans() = %d with roots %v, want 0 with [root]", orphans, g.ThreadRoots)
+	}
+}
+
+// writeGraphFiles writes saved graph files, keyed by name, to the structure
+// directory under a temporary HOME
+func writeGraphFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	dir, err := StructureDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadReplyGraph_Versions(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		if err := SaveReplyGraph(removalGraph()); err != nil {
+			t.Fatalf("SaveReplyGraph failed: %v", err)
+		}
+		dir, _ := StructureDir()
+		data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var metadata graphMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil || metadata.Version != GraphFormatVersion {
+			t.Errorf("metadata version = %d (%v), want %d", metadata.Version, err, GraphFormatVersion)
+		}
+
+		g, err := LoadReplyGraph()
+		if err != nil {
+			t.Fatalf("LoadReplyGraph failed: %v", err)
+		}
+		if len(g.Nodes) != 5 || len(g.ThreadRoots) != 2 {
+			t.Errorf("loaded %d nodes and roots %v, want 5 nodes and 2 roots", len(g.Nodes), g.ThreadRoots)
+		}
+	})
+
+	t.Run("version 0", func(t *testing.T) {
+		// Saved before versioning, orphans, and classifications: the reply
+		// to a missing parent isn't a thread root, and a node has a field
+		// that no longer exists
+		writeGraphFiles(t, map[string]string{
+			"nodes.json": `{
+				"root": {"message_id": "root", "thread_id": "t1", "is_thread_root": true, "reactions": 3},
+				"reply": {"message_id": "reply", "thread_id": "t1", "parent_id": "root"},
+				"stray": {"message_id": "stray", "thread_id": "t2", "parent_id": "missing"}
+			}`,
+			"adjacency.json":    `{"root": ["reply"], "missing": ["stray"]}`,
+			"thread_roots.json": `["root"]`,
+			"metadata.json":     `{"updated_at": "2025-01-02T03:04:05Z", "stats": {"total_messages": 3}}`,
+		})
+
+		g, err := LoadReplyGraph()
+		if err != nil {
+			t.Fatalf("LoadReplyGraph failed: %v", err)
+		}
+		if !reflect.DeepEqual(g.ThreadRoots, []string{"root", "stray"}) || !g.Nodes["stray"].Orphaned {
+			t.Errorf("expected the stray reply to become an orphaned thread root, got roots %v", g.ThreadRoots)
+		}
+		if g.Nodes["reply"].Orphaned || g.Nodes["root"].Classifications != nil {
+			t.Errorf("expected missing fields to default, got %+v and %+v", g.Nodes["reply"], g.Nodes["root"])
+		}
+		if want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC); !g.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want %v", g.UpdatedAt, want)
+		}
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		writeGraphFiles(t, map[string]string{
+			"nodes.json":        `{}`,
+			"adjacency.json":    `{}`,
+			"thread_roots.json": `[]`,
+			"metadata.json":     fmt.Sprintf(`{"version": %d, "updated_at": "2025-01-02T03:04:05Z"}`, GraphFormatVersion+1),
+		})
+
+		_, err := LoadReplyGraph()
+		if !errors.Is(err, ErrUnsupportedGraphVersion) || !strings.Contains(err.Error(), "rebuild") {
+			t.Errorf("expected an unsupported version error with rebuild guidance, got %v", err)
+		}
+	})
+}
+
+func TestReplyGraph_ReplyLatency(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	messages := []*normalize.NormalizedMessage{
+		// Replies before their parents, as a fetch may return them
+		{ID: "nested", ParentID: "first", ThreadID: "root", Timestamp: at(50 * time.Minute)},
+		{ID: "second", ParentID: "root", ThreadID: "root", Timestamp: at(2 * time.Hour)},
+		{ID: "root", IsThreadRoot: true, ThreadID: "root", Timestamp: at(0)},
+		{ID: "first", ParentID: "root", ThreadID: "root", Timestamp: at(5 * time.Minute)},
+		{ID: "orphan", ParentID: "missing", ThreadID: "missing", Timestamp: at(time.Hour)},
+	}
+
+	g := BuildFromNormalizedMessages(messages)
+	want := map[string]time.Duration{
+		"root":   0,
+		"first":  5 * time.Minute,
+		"nested": 45 * time.Minute,
+		"second": 2 * time.Hour,
+		"orphan": 0,
+	}
+	for id, latency := range want {
+		if got := g.Nodes[id].ReplyLatency; got != latency {
+			t.Errorf("%s: ReplyLatency = %v, want %v", id, got, latency)
+		}
+	}
+
+	// Removing a reply measures its replies from their new parent
+	g.RemoveMessage("first")
+	if got := g.Nodes["nested"].ReplyLatency; got != 50*time.Minute {
+		t.Errorf("nested after removing its parent: ReplyLatency = %v, want %v", got, 50*time.Minute)
+	}
+
+	// Adding the missing parent and reconciling measures the orphan
+	g.AddMessage(&normalize.NormalizedMessage{ID: "missing", IsThreadRoot: true, ThreadID: "missing", Timestamp: at(30 * time.Minute)})
+	g.ReconcileOrphans()
+	if got := g.Nodes["orphan"].ReplyLatency; got != 30*time.Minute {
+		t.Errorf("orphan after adding its parent: ReplyLatency = %v, want %v", got, 30*time.Minute)
+	}
+}
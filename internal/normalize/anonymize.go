@@ -0,0 +1,43 @@
+package normalize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// RedactedContent replaces the content of messages anonymized with
+// mine select --anonymize --redact-content
+const RedactedContent = "[redacted]"
+
+// Pseudonyms assigns a pseudonym (user_0001, user_0002, ...) to each distinct ID.
+// Numbers are assigned in order of each ID's SHA-256 hash, so the mapping depends
+// only on the set of IDs, not on the order they were encountered.
+func Pseudonyms(ids []string) map[string]string {
+	unique := make(map[string]string)
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(id))
+		unique[id] = hex.EncodeToString(sum[:])
+	}
+
+	sorted := make([]string, 0, len(unique))
+	for id := range unique {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if unique[sorted[i]] != unique[sorted[j]] {
+			return unique[sorted[i]] < unique[sorted[j]]
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	pseudonyms := make(map[string]string, len(sorted))
+	for i, id := range sorted {
+		pseudonyms[id] = fmt.Sprintf("user_%04d", i+1)
+	}
+	return pseudonyms
+}
@@ -0,0 +1,33 @@
+package normalize
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPseudonyms_Stable(t *testing.T) {
+	ids := []string{"user_slack_T1_U1", "user_slack_T1_U2", "user_github_alice"}
+	reversed := []string{"user_github_alice", "user_slack_T1_U2", "user_slack_T1_U1", "user_slack_T1_U1"}
+
+	first := Pseudonyms(ids)
+	second := Pseudonyms(reversed)
+
+	if len(first) != 3 {
+		t.Fatalf("expected 3 pseudonyms, got %d", len(first))
+	}
+
+	format := regexp.MustCompile(`^user_\d{4}$`)
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if first[id] != second[id] {
+			t.Errorf("pseudonym for %s changed with input order: %s vs %s", id, first[id], second[id])
+		}
+		if !format.MatchString(first[id]) {
+			t.Errorf("unexpected pseudonym format %q", first[id])
+		}
+		if seen[first[id]] {
+			t.Errorf("duplicate pseudonym %s", first[id])
+		}
+		seen[first[id]] = true
+	}
+}
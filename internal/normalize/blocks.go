@@ -0,0 +1,64 @@
+package normalize
+
+// Attachment types of media in Slack Block Kit blocks
+const (
+	AttachmentTypeImage = "image" // URL is the image, title its title or alt text
+	AttachmentTypeFile  = "file"  // Title is the remote file's external ID; there's no URL
+)
+
+// SlackBlockAttachments returns the images and files in the Block Kit blocks
+// of a raw Slack message ("blocks"): image and file blocks, and image elements
+// of other blocks, like section accessories and context elements. Media
+// appears once per URL (or file ID), in block order.
+func SlackBlockAttachments(blocks []map[string]interface{}) []Attachment {
+	var attachments []Attachment
+	seen := make(map[string]bool)
+	add := func(att Attachment, key string) {
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		attachments = append(attachments, att)
+	}
+
+	var visit func(element map[string]interface{})
+	visit = func(element map[string]interface{}) {
+		switch blockString(element, "type") {
+		case "image":
+			url := blockString(element, "image_url")
+			if file, ok := element["slack_file"].(map[string]interface{}); ok && url == "" {
+				url = blockString(file, "url")
+			}
+			title := blockString(element, "alt_text")
+			if t, ok := element["title"].(map[string]interface{}); ok && blockString(t, "text") != "" {
+				title = blockString(t, "text")
+			}
+			add(Attachment{Type: AttachmentTypeImage, URL: url, Title: title}, url)
+		case "file":
+			id := blockString(element, "external_id")
+			add(Attachment{Type: AttachmentTypeFile, Title: id}, id)
+		}
+
+		if accessory, ok := element["accessory"].(map[string]interface{}); ok {
+			visit(accessory)
+		}
+		if elements, ok := element["elements"].([]interface{}); ok {
+			for _, e := range elements {
+				if child, ok := e.(map[string]interface{}); ok {
+					visit(child)
+				}
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		visit(block)
+	}
+	return attachments
+}
+
+// blockString returns the string field key of a block element, or ""
+func blockString(element map[string]interface{}, key string) string {
+	s, _ := element[key].(string)
+	return s
+}
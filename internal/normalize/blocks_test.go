@@ -0,0 +1,88 @@
+package normalize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// blockMessage is a Block Kit message with an image block, a section with an
+// image accessory, a context image, a remote file, and the uploaded file the
+// first image shows
+const blockMessage = `{
+	"type": "message", "user": "U123", "ts": "1700000000.000100",
+	"text": "Dashboard after the deploy",
+	"files": [{"filetype": "png", "mimetype": "image/png", "title": "dashboard.png",
+		"url_private": "https://files.slack.com/files-pri/T1-F1/dashboard.png"}],
+	"blocks": [
+		{"type": "rich_text", "elements": [{"type": "rich_text_section", "elements": [
+			{"type": "text", "text": "Dashboard after the deploy"}]}]},
+		{"type": "image", "image_url": "https://example.com/latency.png", "alt_text": "p99 latency",
+			"title": {"type": "plain_text", "text": "Latency"}},
+		{"type": "image", "slack_file": {"url": "https://files.slack.com/files-pri/T1-F1/dashboard.png"}, "alt_text": "dashboard"},
+		{"type": "section", "text": {"type": "mrkdwn", "text": "Build *passed*"},
+			"accessory": {"type": "image", "image_url": "https://example.com/badge.png", "alt_text": "build badge"}},
+		{"type": "context", "elements": [
+			{"type": "image", "image_url": "https://example.com/avatar.png", "alt_text": "ci bot"},
+			{"type": "mrkdwn", "text": "by CI"}]},
+		{"type": "file", "external_id": "runbook-42", "source": "remote"},
+		{"type": "image", "image_url": "https://example.com/latency.png", "alt_text": "again"}
+	]
+}`
+
+func TestSlackBlockAttachments(t *testing.T) {
+	var msg SlackMessage
+	if err := json.Unmarshal([]byte(blockMessage), &msg); err != nil {
+		t.Fatalf("invalid test message: %v", err)
+	}
+
+	got := SlackBlockAttachments(msg.Blocks)
+	want := []Attachment{
+		{Type: AttachmentTypeImage, URL: "https://example.com/latency.png", Title: "Latency"},
+		{Type: AttachmentTypeImage, URL: "https://files.slack.com/files-pri/T1-F1/dashboard.png", Title: "dashboard"},
+		{Type: AttachmentTypeImage, URL: "https://example.com/badge.png", Title: "build badge"},
+		{Type: AttachmentTypeImage, URL: "https://example.com/avatar.png", Title: "ci bot"},
+		{Type: AttachmentTypeFile, Title: "runbook-42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SlackBlockAttachments() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := SlackBlockAttachments(nil); got != nil {
+		t.Errorf("SlackBlockAttachments(nil) = %+v, want none", got)
+	}
+}
+
+func TestSlackToNormalized_BlockMedia(t *testing.T) {
+	var msg SlackMessage
+	if err := json.Unmarshal([]byte(blockMessage), &msg); err != nil {
+		t.Fatalf("invalid test message: %v", err)
+	}
+	channel := &SlackChannel{ID: "C123", Name: "deploys", IsChannel: true}
+	user := &SlackUser{ID: "U123", Name: "testuser"}
+
+	normalized, err := SlackToNormalized(&msg, channel, user, "T123", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to normalize message: %v", err)
+	}
+
+	// The uploaded file comes first, and its image block doesn't repeat it
+	var urls []string
+	for _, att := range normalized.Attachments {
+		urls = append(urls, att.URL)
+	}
+	want := []string{
+		"https://files.slack.com/files-pri/T1-F1/dashboard.png",
+		"https://example.com/latency.png",
+		"https://example.com/badge.png",
+		"https://example.com/avatar.png",
+		"",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("attachment URLs = %v, want %v", urls, want)
+	}
+	if normalized.Attachments[0].MimeType != "image/png" {
+		t.Errorf("uploaded file lost its details: %+v", normalized.Attachments[0])
+	}
+}
@@ -0,0 +1,238 @@
+package normalize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressBySource enables storing the by_source JSONL files zstd-compressed,
+// as <source>.jsonl.zst (normalize.compress_by_source). It applies to
+// SaveNormalizedMessage callers, such as the mine CLI's fs store.
+var CompressBySource = false
+
+// compactEvery is how many appends to a compressed by_source file trigger its
+// recompression. Each append adds a zstd frame of its own, which compresses
+// poorly, so the file is periodically rewritten as a single frame.
+const compactEvery = 1000
+
+var (
+	bySourceMu      sync.Mutex
+	bySourceAppends = make(map[string]int) // Appends per compressed file since its last compaction
+)
+
+// bySourcePaths returns the plain and compressed by_source files of sourceType
+func bySourcePaths(sourceType string) (plain, compressed string, err error) {
+	dir, err := MessagesBySourceDir()
+	if err != nil {
+		return "", "", err
+	}
+	plain = filepath.Join(dir, sourceType+".jsonl")
+	return plain, plain + ".zst", nil
+}
+
+// appendCompressedBySource appends data as a zstd frame of its own to the
+// compressed by_source file of sourceType, compacting it every compactEvery appends
+func appendCompressedBySource(sourceType string, data []byte) error {
+	_, filePath, err := bySourcePaths(sourceType)
+	if err != nil {
+		return err
+	}
+
+	bySourceMu.Lock()
+	defer bySourceMu.Unlock()
+
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to compress: %w", err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		zw.Close()
+		f.Close()
+		return fmt.Errorf("failed to write to file: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write to file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	bySourceAppends[filePath]++
+	if bySourceAppends[filePath] >= compactEvery {
+		return compactBySource(sourceType)
+	}
+	return nil
+}
+
+// CompactMessagesBySource rewrites the by_source messages of sourceType as a
+// single zstd frame in <source>.jsonl.zst, including those of an uncompressed
+// <source>.jsonl, which is removed. It does nothing if there are no messages.
+func CompactMessagesBySource(sourceType string) error {
+	bySourceMu.Lock()
+	defer bySourceMu.Unlock()
+	return compactBySource(sourceType)
+}
+
+// compactBySource implements CompactMessagesBySource; bySourceMu must be held
+func compactBySource(sourceType string) error {
+	plain, compressed, err := bySourcePaths(sourceType)
+	if err != nil {
+		return err
+	}
+
+	tempPath := compressed + ".tmp"
+	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		f.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to compress: %w", err)
+	}
+	found, err := copyBySource([]string{plain, compressed}, zw)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || !found {
+		os.Remove(tempPath)
+		if err != nil {
+			return fmt.Errorf("failed to compact %s messages: %w", sourceType, err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(tempPath, compressed); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	if err := os.Remove(plain); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", plain, err)
+	}
+	delete(bySourceAppends, compressed)
+	return nil
+}
+
+// copyBySource copies the decompressed contents of the existing files among
+// paths to w, and reports whether any of them existed
+func copyBySource(paths []string, w io.Writer) (bool, error) {
+	found := false
+	for _, path := range paths {
+		r, err := openBySource(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return found, err
+		}
+		found = true
+		_, err = io.Copy(w, r)
+		r.Close()
+		if err != nil {
+			return found, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	return found, nil
+}
+
+// openBySource opens a by_source file for reading, decompressing it if its
+// name ends in .zst. Consecutive zstd frames are read as one stream.
+func openBySource(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) != ".zst" {
+		return f, nil
+	}
+	zr, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return &zstdFile{Decoder: zr, file: f}, nil
+}
+
+// zstdFile closes both a zstd decoder and the file it reads
+type zstdFile struct {
+	*zstd.Decoder
+	file *os.File
+}
+
+func (z *zstdFile) Close() error {
+	z.Decoder.Close()
+	return z.file.Close()
+}
+
+// ReadMessagesBySource streams the by_source messages of sourceType to fn, in
+// the order they were appended, without loading the file into memory. It reads
+// <source>.jsonl and then <source>.jsonl.zst, so messages appended before and
+// after enabling CompressBySource are both read. An error from fn stops the
+// read and is returned.
+func ReadMessagesBySource(sourceType string, fn func(*NormalizedMessage) error) error {
+	plain, compressed, err := bySourcePaths(sourceType)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range []string{plain, compressed} {
+		r, err := openBySource(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		err = decodeMessages(r, fn)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// LoadMessagesBySource loads all by_source messages of sourceType (see ReadMessagesBySource)
+func LoadMessagesBySource(sourceType string) ([]*NormalizedMessage, error) {
+	messages := []*NormalizedMessage{}
+	err := ReadMessagesBySource(sourceType, func(msg *NormalizedMessage) error {
+		messages = append(messages, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// decodeMessages decodes a stream of JSON messages from r, passing each to fn
+func decodeMessages(r io.Reader, fn func(*NormalizedMessage) error) error {
+	decoder := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var msg NormalizedMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to unmarshal message %d: %w", i, err)
+		}
+		if err := fn(&msg); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package normalize
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompressBySource_RoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { CompressBySource = false })
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	save := func(i int) {
+		t.Helper()
+		msg := &NormalizedMessage{
+			ID:         fmt.Sprintf("msg_test_%03d", i),
+			SourceType: "slack",
+			Timestamp:  start.Add(time.Duration(i) * time.Minute),
+			Content:    fmt.Sprintf("message %d", i),
+		}
+		if err := SaveNormalizedMessage(msg); err != nil {
+			t.Fatalf("failed to save message: %v", err)
+		}
+	}
+	loadIDs := func() []string {
+		t.Helper()
+		messages, err := LoadMessagesBySource("slack")
+		if err != nil {
+			t.Fatalf("LoadMessagesBySource() failed: %v", err)
+		}
+		ids := make([]string, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.ID
+		}
+		return ids
+	}
+	var want []string
+	for i := 0; i < 5; i++ {
+		want = append(want, fmt.Sprintf("msg_test_%03d", i))
+	}
+
+	// Uncompressed messages, then compressed ones: both files are read
+	save(0)
+	save(1)
+	CompressBySource = true
+	save(2)
+	save(3)
+	save(4)
+
+	dir, err := MessagesBySourceDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "slack.jsonl")
+	compressed := plain + ".zst"
+	for _, path := range []string{plain, compressed} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s: %v", filepath.Base(path), err)
+		}
+	}
+	if got := loadIDs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("before compaction: got %v, want %v", got, want)
+	}
+	if data, err := os.ReadFile(compressed); err != nil || !bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		t.Errorf("expected slack.jsonl.zst to start with the zstd magic number (err %v)", err)
+	}
+
+	// Compaction folds both files into one compressed file
+	if err := CompactMessagesBySource("slack"); err != nil {
+		t.Fatalf("CompactMessagesBySource() failed: %v", err)
+	}
+	if _, err := os.Stat(plain); !os.IsNotExist(err) {
+		t.Errorf("expected slack.jsonl to be removed, got %v", err)
+	}
+	if got := loadIDs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after compaction: got %v, want %v", got, want)
+	}
+
+	// Appends after compaction follow the compacted messages
+	save(5)
+	want = append(want, "msg_test_005")
+	if got := loadIDs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after appending: got %v, want %v", got, want)
+	}
+
+	// An empty compressed file, as a crash before the first frame leaves it, has no messages
+	if err := os.WriteFile(filepath.Join(dir, "email.jsonl.zst"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if messages, err := LoadMessagesBySource("email"); err != nil || len(messages) != 0 {
+		t.Errorf("LoadMessagesBySource(email) = %v, %v; want no messages", messages, err)
+	}
+
+	// A source without messages has none, and nothing to compact
+	if messages, err := LoadMessagesBySource("github"); err != nil || len(messages) != 0 {
+		t.Errorf("LoadMessagesBySource(github) = %v, %v; want no messages", messages, err)
+	}
+	if err := CompactMessagesBySource("github"); err != nil {
+		t.Errorf("CompactMessagesBySource(github) failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "github.jsonl.zst")); !os.IsNotExist(err) {
+		t.Errorf("expected no github.jsonl.zst, got %v", err)
+	}
+}
+
+func TestReadMessagesBySource_Streaming(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	CompressBySource = true
+	t.Cleanup(func() { CompressBySource = false })
+
+	dir, err := MessagesBySourceDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Enough appends to trigger a compaction, and some after it
+	count := compactEvery + 10
+	for i := 0; i < count; i++ {
+		data := fmt.Sprintf(`{"id":"msg_test_%05d","source_type":"github"}`+"\n", i)
+		if err := appendCompressedBySource("github", []byte(data)); err != nil {
+			t.Fatalf("failed to append message %d: %v", i, err)
+		}
+	}
+
+	if appends := bySourceAppends[filepath.Join(dir, "github.jsonl.zst")]; appends != 10 {
+		t.Errorf("expected 10 appends since the compaction, got %d", appends)
+	}
+
+	seen := 0
+	err = ReadMessagesBySource("github", func(msg *NormalizedMessage) error {
+		if want := fmt.Sprintf("msg_test_%05d", seen); msg.ID != want {
+			return fmt.Errorf("message %d is %s, want %s", seen, msg.ID, want)
+		}
+		seen++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadMessagesBySource() failed: %v", err)
+	}
+	if seen != count {
+		t.Errorf("read %d messages, want %d", seen, count)
+	}
+
+	// An error from the callback stops the read
+	stop := errors.New("stop")
+	seen = 0
+	err = ReadMessagesBySource("github", func(msg *NormalizedMessage) error {
+		seen++
+		if seen == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || seen != 3 {
+		t.Errorf("ReadMessagesBySource() = %v after %d messages, want stop after 3", err, seen)
+	}
+}
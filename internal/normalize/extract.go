@@ -1,6 +1,7 @@
 package normalize
 
 import (
+	"html"
 	"regexp"
 	"strings"
 )
@@ -102,3 +103,47 @@ func ExtractURLs(content string) []string {
 
 	return urls
 }
+
+// dedupeStrings returns items without repeats, keeping the first of each in order
+func dedupeStrings(items []string) []string {
+	unique := items[:0]
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
+
+// zeroWidthCharacters are invisible characters that break prefix and phrase matching
+var zeroWidthCharacters = []string{
+	"\u200B", // zero width space
+	"\u200C", // zero width non-joiner
+	"\u200D", // zero width joiner
+	"\u2060", // word joiner
+	"\uFEFF", // zero width no-break space (BOM)
+}
+
+// smartQuoteReplacer maps typographic quotes to their ASCII equivalents
+var smartQuoteReplacer = strings.NewReplacer(
+	"\u2018", "'", // left single quote
+	"\u2019", "'", // right single quote / apostrophe
+	"\u201A", "'", // single low-9 quote
+	"\u201B", "'", // single high-reversed-9 quote
+	"\u201C", `"`, // left double quote
+	"\u201D", `"`, // right double quote
+	"\u201E", `"`, // double low-9 quote
+	"\u201F", `"`, // double high-reversed-9 quote
+)
+
+// CleanText decodes HTML entities, removes zero-width characters, and replaces
+// smart quotes with ASCII quotes so content matches plain-text phrases
+func CleanText(text string) string {
+	text = html.UnescapeString(text)
+	for _, ch := range zeroWidthCharacters {
+		text = strings.ReplaceAll(text, ch, "")
+	}
+	return smartQuoteReplacer.Replace(text)
+}
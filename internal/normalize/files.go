@@ -0,0 +1,38 @@
+package normalize
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/solvaholic/threadmine/internal/github"
+)
+
+// ChangedFilePaths returns the paths of the files a pull request changes
+func ChangedFilePaths(files []github.PullRequestFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Filename)
+	}
+	return paths
+}
+
+// ChangedDirectories returns the directories that hold the changed files,
+// with their parent directories, each with a trailing slash and in order:
+// "auth/login/handler.go" gives "auth/" and "auth/login/". Files at the root
+// of the repository have none.
+func ChangedDirectories(paths []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, p := range paths {
+		for dir := path.Dir(strings.Trim(p, "/")); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if seen[dir] {
+				break
+			}
+			seen[dir] = true
+			dirs = append(dirs, dir+"/")
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
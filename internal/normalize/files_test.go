@@ -0,0 +1,29 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedDirectories(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{"none", nil, nil},
+		{"root files only", []string{"README.md", "go.mod"}, nil},
+		{"nested", []string{"auth/oauth/token.go"}, []string{"auth/", "auth/oauth/"}},
+		{"shared parents once", []string{"auth/login.go", "auth/oauth/token.go", "auth/oauth/refresh.go", "docs/auth.md"},
+			[]string{"auth/", "auth/oauth/", "docs/"}},
+		{"leading slash", []string{"/cmd/mine/main.go"}, []string{"cmd/", "cmd/mine/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChangedDirectories(tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChangedDirectories(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/solvaholic/threadmine/internal/clock"
 	"github.com/solvaholic/threadmine/internal/github"
 )
 
@@ -15,8 +16,6 @@ var (
 	// Match @username but not in email addresses (require word boundary before @)
 	githubMentionPattern = regexp.MustCompile(`(?:^|[^a-zA-Z0-9.])@([a-zA-Z0-9][-a-zA-Z0-9]*)`)
 	githubURLPattern     = regexp.MustCompile(`https?://[^\s\)]+`)
-	// Match code blocks with optional language (case-insensitive) and optional newline
-	githubCodeBlockPattern = regexp.MustCompile("(?s)```([a-zA-Z0-9_+-]*)\n?(.+?)```")
 	githubInlineCodePattern = regexp.MustCompile("`([^`]+)`")
 )
 
@@ -38,6 +37,7 @@ func GitHubIssueToNormalized(issue *github.Issue, repo, owner string, fetchedAt
 		SourceType: "github",
 		SourceID:   fmt.Sprintf("%s/%s/issues/%d", owner, repo, issue.Number),
 		Timestamp:  issue.CreatedAt,
+		SourceTimestamp: GitHubSourceTimestamp(issue.CreatedAt),
 		Author:     convertGitHubUser(&issue.User, owner, repo),
 		Content:    normalizeGitHubMarkdown(issue.Body),
 		ContentHTML: "", // Could use GitHub's rendering API in the future
@@ -55,12 +55,19 @@ func GitHubIssueToNormalized(issue *github.Issue, repo, owner string, fetchedAt
 			"issue_number": issue.Number,
 			"title":      issue.Title,
 			"state":      issue.State,
+			"state_reason": issue.StateReason,
 			"closed_at":  issue.ClosedAt,
+			"assignees":  githubUserLogins(issue.Assignees),
+			"requested_reviewers": githubUserLogins(issue.RequestedReviewers),
 		},
 		FetchedAt:    fetchedAt,
-		NormalizedAt: time.Now(),
+		NormalizedAt: clock.Now(),
 		SchemaVersion: SchemaVersion,
 	}
+	normalized.Language = DetectLanguage(normalized.Content)
+	if progress := ExtractTaskProgress(issue.Body); progress != nil {
+		normalized.SourceMetadata["task_progress"] = progress
+	}
 
 	return normalized, nil
 }
@@ -82,6 +89,7 @@ func GitHubIssueCommentToNormalized(comment *github.Comment, issue *github.Issue
 		SourceType: "github",
 		SourceID:   fmt.Sprintf("%s/%s/issues/%d#issuecomment-%d", owner, repo, issue.Number, comment.ID),
 		Timestamp:  comment.CreatedAt,
+		SourceTimestamp: GitHubSourceTimestamp(comment.CreatedAt),
 		Author:     convertGitHubUser(&comment.User, owner, repo),
 		Content:    normalizeGitHubMarkdown(comment.Body),
 		ContentHTML: "",
@@ -101,9 +109,10 @@ func GitHubIssueCommentToNormalized(comment *github.Comment, issue *github.Issue
 			"updated_at":   comment.UpdatedAt,
 		},
 		FetchedAt:    fetchedAt,
-		NormalizedAt: time.Now(),
+		NormalizedAt: clock.Now(),
 		SchemaVersion: SchemaVersion,
 	}
+	normalized.Language = DetectLanguage(normalized.Content)
 
 	return normalized, nil
 }
@@ -124,6 +133,7 @@ func GitHubPRToNormalized(pr *github.PullRequest, repo, owner string, fetchedAt
 		SourceType: "github",
 		SourceID:   fmt.Sprintf("%s/%s/pull/%d", owner, repo, pr.Number),
 		Timestamp:  pr.CreatedAt,
+		SourceTimestamp: GitHubSourceTimestamp(pr.CreatedAt),
 		Author:     convertGitHubUser(&pr.User, owner, repo),
 		Content:    normalizeGitHubMarkdown(pr.Body),
 		ContentHTML: "",
@@ -143,11 +153,23 @@ func GitHubPRToNormalized(pr *github.PullRequest, repo, owner string, fetchedAt
 			"state":      pr.State,
 			"merged_at":  pr.MergedAt,
 			"closed_at":  pr.ClosedAt,
+			"assignees":  githubUserLogins(pr.Assignees),
+			"requested_reviewers": githubUserLogins(pr.RequestedReviewers),
 		},
 		FetchedAt:    fetchedAt,
-		NormalizedAt: time.Now(),
+		NormalizedAt: clock.Now(),
 		SchemaVersion: SchemaVersion,
 	}
+	normalized.Language = DetectLanguage(normalized.Content)
+	if progress := ExtractTaskProgress(pr.Body); progress != nil {
+		normalized.SourceMetadata["task_progress"] = progress
+	}
+	if len(pr.Files) > 0 {
+		normalized.SourceMetadata["files"] = ChangedFilePaths(pr.Files)
+		normalized.SourceMetadata["changed_files"] = pr.ChangedFiles
+		normalized.SourceMetadata["additions"] = pr.Additions
+		normalized.SourceMetadata["deletions"] = pr.Deletions
+	}
 
 	return normalized, nil
 }
@@ -167,6 +189,7 @@ func GitHubPRCommentToNormalized(comment *github.Comment, pr *github.PullRequest
 		SourceType: "github",
 		SourceID:   fmt.Sprintf("%s/%s/pull/%d#issuecomment-%d", owner, repo, pr.Number, comment.ID),
 		Timestamp:  comment.CreatedAt,
+		SourceTimestamp: GitHubSourceTimestamp(comment.CreatedAt),
 		Author:     convertGitHubUser(&comment.User, owner, repo),
 		Content:    normalizeGitHubMarkdown(comment.Body),
 		ContentHTML: "",
@@ -186,9 +209,10 @@ func GitHubPRCommentToNormalized(comment *github.Comment, pr *github.PullRequest
 			"updated_at": comment.UpdatedAt,
 		},
 		FetchedAt:    fetchedAt,
-		NormalizedAt: time.Now(),
+		NormalizedAt: clock.Now(),
 		SchemaVersion: SchemaVersion,
 	}
+	normalized.Language = DetectLanguage(normalized.Content)
 
 	return normalized, nil
 }
@@ -208,6 +232,7 @@ func GitHubPRReviewToNormalized(review *github.Review, pr *github.PullRequest, r
 		SourceType: "github",
 		SourceID:   fmt.Sprintf("%s/%s/pull/%d#pullrequestreview-%d", owner, repo, pr.Number, review.ID),
 		Timestamp:  review.SubmittedAt,
+		SourceTimestamp: GitHubSourceTimestamp(review.SubmittedAt),
 		Author:     convertGitHubUser(&review.User, owner, repo),
 		Content:    normalizeGitHubMarkdown(review.Body),
 		ContentHTML: "",
@@ -227,9 +252,56 @@ func GitHubPRReviewToNormalized(review *github.Review, pr *github.PullRequest, r
 			"state":      review.State,
 		},
 		FetchedAt:    fetchedAt,
-		NormalizedAt: time.Now(),
+		NormalizedAt: clock.Now(),
 		SchemaVersion: SchemaVersion,
 	}
+	normalized.Language = DetectLanguage(normalized.Content)
+
+	return normalized, nil
+}
+
+// GitHubCommitCommentToNormalized converts a GitHub commit comment to a
+// normalized message. Comments on the same commit share a thread and a
+// commit channel; there's no root message, since the commit isn't one.
+func GitHubCommitCommentToNormalized(comment *github.CommitComment, repo, owner string, fetchedAt time.Time) (*NormalizedMessage, error) {
+	if comment.CommitID == "" {
+		return nil, fmt.Errorf("commit comment %d has no commit ID", comment.ID)
+	}
+
+	msgID := fmt.Sprintf("msg_github_%s_%s_commit_%s_comment_%d", owner, repo, comment.CommitID, comment.ID)
+	threadID := fmt.Sprintf("thread_github_%s_%s_commit_%s", owner, repo, comment.CommitID)
+
+	metadata := map[string]interface{}{
+		"owner":      owner,
+		"repo":       repo,
+		"commit_id":  comment.CommitID,
+		"comment_id": comment.ID,
+		"updated_at": comment.UpdatedAt,
+	}
+	if comment.Path != "" {
+		metadata["path"] = comment.Path
+		metadata["line"] = comment.Line
+	}
+
+	normalized := &NormalizedMessage{
+		ID:             msgID,
+		SourceType:     "github",
+		SourceID:       fmt.Sprintf("%s/%s/commit/%s#commitcomment-%d", owner, repo, comment.CommitID, comment.ID),
+		Timestamp:      comment.CreatedAt,
+		SourceTimestamp: GitHubSourceTimestamp(comment.CreatedAt),
+		Author:         convertGitHubUser(&comment.User, owner, repo),
+		Content:        normalizeGitHubMarkdown(comment.Body),
+		Channel:        convertGitHubCommitToChannel(comment.CommitID, repo, owner),
+		ThreadID:       threadID,
+		Mentions:       extractGitHubMentions(comment.Body),
+		URLs:           extractGitHubURLs(comment.Body),
+		CodeBlocks:     extractGitHubCodeBlocks(comment.Body),
+		SourceMetadata: metadata,
+		FetchedAt:      fetchedAt,
+		NormalizedAt:   clock.Now(),
+		SchemaVersion:  SchemaVersion,
+	}
+	normalized.Language = DetectLanguage(normalized.Content)
 
 	return normalized, nil
 }
@@ -252,6 +324,22 @@ func convertGitHubUser(user *github.User, owner, repo string) *User {
 	}
 }
 
+// githubUserLogins returns the logins of a list of GitHub users (never nil)
+func githubUserLogins(users []github.User) []string {
+	logins := make([]string, 0, len(users))
+	for _, user := range users {
+		logins = append(logins, user.Login)
+	}
+	return logins
+}
+
+// GitHubSourceTimestamp returns a GitHub timestamp as the API wrote it. JSON
+// decoding keeps the zone offset it was written with, and GitHub writes whole
+// seconds, so RFC 3339 formatting gives back the original string.
+func GitHubSourceTimestamp(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
 // convertGitHubIssueToChannel converts a GitHub issue to the normalized Channel schema
 func convertGitHubIssueToChannel(issue *github.Issue, repo, owner string) *Channel {
 	if issue == nil {
@@ -288,6 +376,25 @@ func convertGitHubPRToChannel(pr *github.PullRequest, repo, owner string) *Chann
 	}
 }
 
+// convertGitHubCommitToChannel returns the normalized Channel for a commit
+func convertGitHubCommitToChannel(sha, repo, owner string) *Channel {
+	short := sha
+	if len(short) > 7 {
+		short = short[:7]
+	}
+
+	return &Channel{
+		ID:          fmt.Sprintf("chan_github_%s_%s_commit_%s", owner, repo, sha),
+		SourceType:  "github",
+		SourceID:    fmt.Sprintf("%s/%s/commit/%s", owner, repo, sha),
+		Name:        short,
+		DisplayName: fmt.Sprintf("%s/%s@%s", owner, repo, short),
+		Type:        "commit",
+		IsPrivate:   false,
+		ParentSpace: fmt.Sprintf("%s/%s", owner, repo),
+	}
+}
+
 // extractGitHubMentions extracts user mentions from GitHub Markdown text
 func extractGitHubMentions(text string) []string {
 	matches := githubMentionPattern.FindAllStringSubmatch(text, -1)
@@ -297,28 +404,19 @@ func extractGitHubMentions(text string) []string {
 			mentions = append(mentions, match[1])
 		}
 	}
-	return mentions
+	return dedupeStrings(mentions)
 }
 
 // extractGitHubURLs extracts URLs from GitHub Markdown text
 func extractGitHubURLs(text string) []string {
 	matches := githubURLPattern.FindAllString(text, -1)
-	return matches
+	return dedupeStrings(matches)
 }
 
-// extractGitHubCodeBlocks extracts code blocks from GitHub Markdown
+// extractGitHubCodeBlocks extracts fenced and indented code blocks from
+// GitHub Markdown
 func extractGitHubCodeBlocks(text string) []CodeBlock {
-	matches := githubCodeBlockPattern.FindAllStringSubmatch(text, -1)
-	blocks := make([]CodeBlock, 0, len(matches))
-	for _, match := range matches {
-		if len(match) > 2 {
-			blocks = append(blocks, CodeBlock{
-				Language: match[1],
-				Code:     match[2],
-			})
-		}
-	}
-	return blocks
+	return parseMarkdownCodeBlocks(text)
 }
 
 // normalizeGitHubMarkdown converts GitHub Markdown to plain text
@@ -334,5 +432,6 @@ func normalizeGitHubMarkdown(text string) string {
 	text = strings.ReplaceAll(text, "*", "")
 	text = strings.ReplaceAll(text, "_", "")
 	
-	return text
+	// Unescape HTML entities and clean up unicode
+	return CleanText(text)
 }
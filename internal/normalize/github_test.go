@@ -1,6 +1,9 @@
 package normalize
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -170,6 +173,99 @@ func TestGitHubPRToNormalized(t *testing.T) {
 	}
 }
 
+func TestGitHubTaskProgress(t *testing.T) {
+	now := time.Now()
+	issue := &github.Issue{Number: 1, Title: "Release 1.2", Body: "- [x] tag\n- [ ] changelog\n- [ ] announce", User: github.User{Login: "alice"}, CreatedAt: now}
+
+	normalized, err := GitHubIssueToNormalized(issue, "testrepo", "testowner", now)
+	if err != nil {
+		t.Fatalf("GitHubIssueToNormalized failed: %v", err)
+	}
+	want := &TaskProgress{Done: 1, Total: 3, Ratio: 1.0 / 3}
+	if got := normalized.SourceMetadata["task_progress"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("task_progress = %+v, want %+v", got, want)
+	}
+
+	// Refetching an edited body recomputes it
+	issue.Body = "- [x] tag\n- [x] changelog\n- [ ] announce"
+	normalized, err = GitHubIssueToNormalized(issue, "testrepo", "testowner", now)
+	if err != nil {
+		t.Fatalf("GitHubIssueToNormalized failed: %v", err)
+	}
+	want = &TaskProgress{Done: 2, Total: 3, Ratio: 2.0 / 3}
+	if got := normalized.SourceMetadata["task_progress"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("task_progress after edit = %+v, want %+v", got, want)
+	}
+
+	// Bodies without a task list have none
+	issue.Body = "No tasks here"
+	normalized, err = GitHubIssueToNormalized(issue, "testrepo", "testowner", now)
+	if err != nil {
+		t.Fatalf("GitHubIssueToNormalized failed: %v", err)
+	}
+	if got, ok := normalized.SourceMetadata["task_progress"]; ok {
+		t.Errorf("task_progress = %+v, want none", got)
+	}
+
+	pr := &github.PullRequest{Number: 2, Title: "Add feature", Body: "- [x] tests\n- [x] docs", User: github.User{Login: "bob"}, CreatedAt: now}
+	normalized, err = GitHubPRToNormalized(pr, "testrepo", "testowner", now)
+	if err != nil {
+		t.Fatalf("GitHubPRToNormalized failed: %v", err)
+	}
+	want = &TaskProgress{Done: 2, Total: 2, Ratio: 1}
+	if got := normalized.SourceMetadata["task_progress"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("PR task_progress = %+v, want %+v", got, want)
+	}
+}
+
+func TestGitHubPRFiles(t *testing.T) {
+	now := time.Now()
+	issue := &github.Issue{Number: 2, PullRequest: &github.PullRequestLink{}}
+	issue.SetFiles([]github.PullRequestFile{
+		{Filename: "auth/login.go", Status: "modified", Additions: 10, Deletions: 2},
+		{Filename: "auth/oauth/token.go", Status: "added", Additions: 40},
+		{Filename: "README.md", Status: "modified", Additions: 1, Deletions: 1},
+	})
+	pr := &github.PullRequest{Number: 2, Title: "Refresh tokens", User: github.User{Login: "bob"}, CreatedAt: now,
+		Files: issue.Files, ChangedFiles: issue.ChangedFiles, Additions: issue.Additions, Deletions: issue.Deletions}
+
+	normalized, err := GitHubPRToNormalized(pr, "testrepo", "testowner", now)
+	if err != nil {
+		t.Fatalf("GitHubPRToNormalized failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"files":         []string{"auth/login.go", "auth/oauth/token.go", "README.md"},
+		"changed_files": 3,
+		"additions":     51,
+		"deletions":     3,
+	}
+	for key, value := range want {
+		if got := normalized.SourceMetadata[key]; !reflect.DeepEqual(got, value) {
+			t.Errorf("%s = %v, want %v", key, got, value)
+		}
+	}
+
+	// Huge pull requests keep the first files, and the totals of all of them
+	files := make([]github.PullRequestFile, github.MaxPullRequestFiles+50)
+	for i := range files {
+		files[i] = github.PullRequestFile{Filename: fmt.Sprintf("gen/file%d.go", i), Additions: 1}
+	}
+	issue.SetFiles(files)
+	if len(issue.Files) != github.MaxPullRequestFiles || issue.ChangedFiles != len(files) || issue.Additions != len(files) {
+		t.Errorf("SetFiles kept %d files of %d (%d additions)", len(issue.Files), issue.ChangedFiles, issue.Additions)
+	}
+
+	// Pull requests fetched without their files have none
+	pr.Files = nil
+	normalized, err = GitHubPRToNormalized(pr, "testrepo", "testowner", now)
+	if err != nil {
+		t.Fatalf("GitHubPRToNormalized failed: %v", err)
+	}
+	if got, ok := normalized.SourceMetadata["files"]; ok {
+		t.Errorf("files = %v, want none", got)
+	}
+}
+
 func TestGitHubPRReviewToNormalized(t *testing.T) {
 	now := time.Now()
 	pr := &github.PullRequest{
@@ -230,6 +326,7 @@ func TestExtractGitHubMentions(t *testing.T) {
 		{"No mentions here", 0},
 		{"@test-user with dash", 1},
 		{"Email test@example.com should not match", 0},
+		{"@user1 thanks, cc @user2 @user1", 2},
 	}
 
 	for _, tt := range tests {
@@ -249,6 +346,7 @@ func TestExtractGitHubURLs(t *testing.T) {
 		{"Two URLs: https://a.com and https://b.com", 2},
 		{"No URLs here", 0},
 		{"http://insecure.com also works", 1},
+		{"See https://a.com then https://b.com and https://a.com again", 2},
 	}
 
 	for _, tt := range tests {
@@ -293,6 +391,9 @@ func TestNormalizeGitHubMarkdown(t *testing.T) {
 		{"*italic* text", "italic text"},
 		{"`inline code`", "inline code"},
 		{"__underline__", "underline"},
+		{"Use &quot;--force&quot; &amp; retry", `Use "--force" & retry`},
+		{"It&#39;s broken &#x2014; again", "It's broken \u2014 again"},
+		{"Why doesn\u2019t this\u200B work", "Why doesn't this work"},
 	}
 
 	for _, tt := range tests {
@@ -302,3 +403,261 @@ func TestNormalizeGitHubMarkdown(t *testing.T) {
 		}
 	}
 }
+
+func TestGitHubAssigneesAndReviewers(t *testing.T) {
+	now := time.Now()
+	alice := github.User{ID: 1, Login: "alice"}
+	bob := github.User{ID: 2, Login: "bob"}
+	carol := github.User{ID: 3, Login: "carol"}
+
+	tests := []struct {
+		name              string
+		assignees         []github.User
+		reviewers         []github.User
+		expectedAssignees []string
+		expectedReviewers []string
+	}{
+		{
+			name:              "no assignees or reviewers",
+			assignees:         nil,
+			reviewers:         nil,
+			expectedAssignees: []string{},
+			expectedReviewers: []string{},
+		},
+		{
+			name:              "multiple assignees and reviewers",
+			assignees:         []github.User{alice, bob},
+			reviewers:         []github.User{bob, carol},
+			expectedAssignees: []string{"alice", "bob"},
+			expectedReviewers: []string{"bob", "carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/issue", func(t *testing.T) {
+			issue := &github.Issue{
+				Number:    1,
+				User:      alice,
+				CreatedAt: now,
+				Assignees: tt.assignees,
+			}
+
+			normalized, err := GitHubIssueToNormalized(issue, "repo", "owner", now)
+			if err != nil {
+				t.Fatalf("GitHubIssueToNormalized failed: %v", err)
+			}
+
+			assertLogins(t, normalized.SourceMetadata["assignees"], tt.expectedAssignees)
+		})
+
+		t.Run(tt.name+"/pr", func(t *testing.T) {
+			pr := &github.PullRequest{
+				Number:             2,
+				User:               alice,
+				CreatedAt:          now,
+				Assignees:          tt.assignees,
+				RequestedReviewers: tt.reviewers,
+			}
+
+			normalized, err := GitHubPRToNormalized(pr, "repo", "owner", now)
+			if err != nil {
+				t.Fatalf("GitHubPRToNormalized failed: %v", err)
+			}
+
+			assertLogins(t, normalized.SourceMetadata["assignees"], tt.expectedAssignees)
+			assertLogins(t, normalized.SourceMetadata["requested_reviewers"], tt.expectedReviewers)
+		})
+	}
+}
+
+func assertLogins(t *testing.T, value interface{}, expected []string) {
+	t.Helper()
+
+	logins, ok := value.([]string)
+	if !ok {
+		t.Fatalf("expected []string, got %T", value)
+	}
+	if len(logins) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, logins)
+	}
+	for i := range logins {
+		if logins[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, logins)
+			break
+		}
+	}
+}
+
+func TestGitHubCommitCommentToNormalized(t *testing.T) {
+	now := time.Now()
+	sha := "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+
+	tests := []struct {
+		name     string
+		comment  *github.CommitComment
+		wantID   string
+		metadata map[string]interface{}
+	}{
+		{
+			name: "commit comment",
+			comment: &github.CommitComment{
+				ID: 1, Body: "Why was this reverted? cc @octocat", User: github.User{Login: "hubot"},
+				CreatedAt: now, UpdatedAt: now, CommitID: sha,
+			},
+			wantID:   "msg_github_testowner_testrepo_commit_" + sha + "_comment_1",
+			metadata: map[string]interface{}{"commit_id": sha, "comment_id": int64(1)},
+		},
+		{
+			name: "line comment",
+			comment: &github.CommitComment{
+				ID: 2, Body: "Off by one here", User: github.User{Login: "octocat"},
+				CreatedAt: now, UpdatedAt: now, CommitID: sha, Path: "file1.txt", Line: 14,
+			},
+			wantID:   "msg_github_testowner_testrepo_commit_" + sha + "_comment_2",
+			metadata: map[string]interface{}{"commit_id": sha, "comment_id": int64(2), "path": "file1.txt", "line": 14},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := GitHubCommitCommentToNormalized(tt.comment, "testrepo", "testowner", now)
+			if err != nil {
+				t.Fatalf("GitHubCommitCommentToNormalized failed: %v", err)
+			}
+
+			if normalized.ID != tt.wantID {
+				t.Errorf("Expected ID '%s', got '%s'", tt.wantID, normalized.ID)
+			}
+			if normalized.ThreadID != "thread_github_testowner_testrepo_commit_"+sha {
+				t.Errorf("Expected ThreadID keyed by commit, got '%s'", normalized.ThreadID)
+			}
+			if normalized.IsThreadRoot || normalized.ParentID != "" {
+				t.Errorf("Expected a comment without a root or parent, got root=%v parent=%q", normalized.IsThreadRoot, normalized.ParentID)
+			}
+			if normalized.Channel == nil || normalized.Channel.ID != "chan_github_testowner_testrepo_commit_"+sha ||
+				normalized.Channel.Type != "commit" || normalized.Channel.Name != "6dcb09b" {
+				t.Errorf("Expected commit channel, got %+v", normalized.Channel)
+			}
+			if normalized.Author == nil || normalized.Author.DisplayName != tt.comment.User.Login {
+				t.Errorf("Expected author %s, got %+v", tt.comment.User.Login, normalized.Author)
+			}
+			for key, want := range tt.metadata {
+				if got := normalized.SourceMetadata[key]; got != want {
+					t.Errorf("SourceMetadata[%s] = %v, want %v", key, got, want)
+				}
+			}
+			if _, ok := normalized.SourceMetadata["path"]; ok && tt.comment.Path == "" {
+				t.Error("Expected no path for a comment on the whole commit")
+			}
+		})
+	}
+
+	if _, err := GitHubCommitCommentToNormalized(&github.CommitComment{ID: 3}, "testrepo", "testowner", now); err == nil {
+		t.Error("Expected an error for a comment without a commit ID")
+	}
+}
+
+func TestGitHubSourceTimestamp(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"UTC", "2024-03-05T14:22:10Z"},
+		{"zone offset", "2024-03-05T09:22:10-05:00"},
+		{"fractional seconds", "2024-03-05T14:22:10.25Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issue github.Issue
+			data := fmt.Sprintf(`{"number": 1, "user": {"login": "octocat"}, "created_at": %q}`, tt.raw)
+			if err := json.Unmarshal([]byte(data), &issue); err != nil {
+				t.Fatalf("failed to decode issue: %v", err)
+			}
+
+			normalized, err := GitHubIssueToNormalized(&issue, "widgets", "acme", time.Now())
+			if err != nil {
+				t.Fatalf("GitHubIssueToNormalized failed: %v", err)
+			}
+			if normalized.SourceTimestamp != tt.raw {
+				t.Errorf("SourceTimestamp = %q, want %q", normalized.SourceTimestamp, tt.raw)
+			}
+			if !normalized.Timestamp.Equal(issue.CreatedAt) {
+				t.Errorf("Timestamp = %v, want %v", normalized.Timestamp, issue.CreatedAt)
+			}
+		})
+	}
+}
+
+func TestExtractGitHubCodeBlocks_FencesAndIndentation(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []CodeBlock
+	}{
+		{
+			name: "backticks inside a block",
+			text: "Run this:\n```sh\necho \"today is `date`\"\nls `pwd`\n```\nthen retry",
+			want: []CodeBlock{{Language: "sh", Code: "echo \"today is `date`\"\nls `pwd`\n"}},
+		},
+		{
+			name: "longer fence around a shorter one",
+			text: "````markdown\n```go\nfmt.Println(\"hi\")\n```\n````",
+			want: []CodeBlock{{Language: "markdown", Code: "```go\nfmt.Println(\"hi\")\n```\n"}},
+		},
+		{
+			name: "tilde fence",
+			text: "~~~python title=\"example\"\nprint('```')\n~~~",
+			want: []CodeBlock{{Language: "python", Code: "print('```')\n"}},
+		},
+		{
+			name: "closing fence must match the opening character",
+			text: "~~~\ncode\n```\nmore code\n~~~",
+			want: []CodeBlock{{Code: "code\n```\nmore code\n"}},
+		},
+		{
+			name: "unclosed fence runs to the end",
+			text: "```yaml\nkey: value\nother: 1",
+			want: []CodeBlock{{Language: "yaml", Code: "key: value\nother: 1\n"}},
+		},
+		{
+			name: "indented code",
+			text: "The config:\n\n    [server]\n      port = 8080\n\n    [client]\n\nAnd that's it.",
+			want: []CodeBlock{{Code: "[server]\n  port = 8080\n\n[client]\n"}},
+		},
+		{
+			name: "tab-indented code",
+			text: "Try:\n\n\tgo test ./...\n",
+			want: []CodeBlock{{Code: "go test ./...\n"}},
+		},
+		{
+			name: "indented lines continuing a paragraph",
+			text: "This is a paragraph\n    that happens to be indented",
+			want: []CodeBlock{},
+		},
+		{
+			name: "indented list continuation",
+			text: "1. First step\n\n    More about the first step\n2. Second step",
+			want: []CodeBlock{},
+		},
+		{
+			name: "fence indented in a list item",
+			text: "- Install it:\n  ```\n  brew install widgets\n  ```",
+			want: []CodeBlock{{Code: "brew install widgets\n"}},
+		},
+		{
+			name: "inline triple backticks",
+			text: "Wrap it in ```code``` like this",
+			want: []CodeBlock{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractGitHubCodeBlocks(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractGitHubCodeBlocks(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package normalize
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/github"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// goldenFetchedAt is the fetch time used for all golden fixtures
+var goldenFetchedAt = time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+
+// slackFixture is a raw Slack message with the channel and user it was fetched with
+type slackFixture struct {
+	TeamID  string       `json:"team_id"`
+	Channel SlackChannel `json:"channel"`
+	User    SlackUser    `json:"user"`
+	Message SlackMessage `json:"message"`
+}
+
+// githubFixture is a raw GitHub issue or pull request with the repository it came from
+type githubFixture struct {
+	Owner       string              `json:"owner"`
+	Repo        string              `json:"repo"`
+	Issue       *github.Issue       `json:"issue"`
+	PullRequest *github.PullRequest `json:"pull_request"`
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	tests := []struct {
+		name      string
+		fixture   string
+		normalize func(t *testing.T, path string) *NormalizedMessage
+	}{
+		{"slack_thread_reply", "slack/thread_reply.json", normalizeSlackFixture},
+		{"slack_shared_message", "slack/shared_message.json", normalizeSlackFixture},
+		{"github_issue_with_labels", "github/issue_with_labels.json", normalizeGitHubFixture},
+		{"github_pr_with_code_block", "github/pr_with_code_block.json", normalizeGitHubFixture},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.normalize(t, filepath.Join("testdata", tt.fixture))
+			assertGolden(t, tt.name, msg)
+		})
+	}
+}
+
+func normalizeSlackFixture(t *testing.T, path string) *NormalizedMessage {
+	t.Helper()
+
+	var fixture slackFixture
+	loadFixture(t, path, &fixture)
+
+	msg, err := SlackToNormalized(&fixture.Message, &fixture.Channel, &fixture.User, fixture.TeamID, goldenFetchedAt)
+	if err != nil {
+		t.Fatalf("SlackToNormalized failed: %v", err)
+	}
+	return msg
+}
+
+func normalizeGitHubFixture(t *testing.T, path string) *NormalizedMessage {
+	t.Helper()
+
+	var fixture githubFixture
+	loadFixture(t, path, &fixture)
+
+	var msg *NormalizedMessage
+	var err error
+	switch {
+	case fixture.Issue != nil:
+		msg, err = GitHubIssueToNormalized(fixture.Issue, fixture.Repo, fixture.Owner, goldenFetchedAt)
+	case fixture.PullRequest != nil:
+		msg, err = GitHubPRToNormalized(fixture.PullRequest, fixture.Repo, fixture.Owner, goldenFetchedAt)
+	default:
+		t.Fatalf("fixture %s has neither issue nor pull_request", path)
+	}
+	if err != nil {
+		t.Fatalf("failed to normalize %s: %v", path, err)
+	}
+	return msg
+}
+
+// loadFixture decodes a JSON fixture from testdata into v
+func loadFixture(t *testing.T, path string, v interface{}) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to decode fixture %s: %v", path, err)
+	}
+}
+
+// assertGolden compares msg against testdata/golden/<name>.json.
+// Run with -update to rewrite the golden file from the current output.
+func assertGolden(t *testing.T, name string, msg *NormalizedMessage) {
+	t.Helper()
+
+	// Fields that vary between runs or machines
+	msg.NormalizedAt = time.Time{}
+	msg.Timestamp = msg.Timestamp.UTC()
+
+	got, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal message: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("normalized message does not match %s (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package normalize
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect used by JSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaDefinitions lists the types described under $defs, in output order
+var schemaDefinitions = []reflect.Type{
+	reflect.TypeOf(User{}),
+	reflect.TypeOf(Channel{}),
+	reflect.TypeOf(Attachment{}),
+	reflect.TypeOf(SharedMessage{}),
+	reflect.TypeOf(CodeBlock{}),
+}
+
+// JSONSchema returns a JSON Schema document describing NormalizedMessage and
+// the types it references. The schema is generated from the Go structs so it
+// always matches what SaveNormalizedMessage writes.
+func JSONSchema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	for _, t := range schemaDefinitions {
+		defs[t.Name()] = structSchema(t)
+	}
+
+	root := structSchema(reflect.TypeOf(NormalizedMessage{}))
+	root["$schema"] = JSONSchemaDraft
+	root["$id"] = "https://github.com/solvaholic/threadmine/schema/normalized-message/" + SchemaVersion
+	root["title"] = "NormalizedMessage"
+	root["description"] = "A message in ThreadMine's common schema across all sources (schema version " + SchemaVersion + ")"
+	root["x-schema-version"] = SchemaVersion
+	root["$defs"] = defs
+
+	return root
+}
+
+// structSchema builds an object schema from a struct's JSON-tagged fields
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		properties[name] = typeSchema(field.Type)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema maps a Go type to its JSON Schema representation
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	for _, def := range schemaDefinitions {
+		if t == def {
+			return map[string]interface{}{"$ref": "#/$defs/" + def.Name()}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{
+			"anyOf": []interface{}{typeSchema(t.Elem()), map[string]interface{}{"type": "null"}},
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		// nil slices are encoded as null
+		return map[string]interface{}{
+			"type":  []interface{}{"array", "null"},
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []interface{}{"object", "null"},
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		// interface{} and anything else accepts any value
+		return map[string]interface{}{}
+	}
+}
+
+// jsonFieldName returns the JSON name of a struct field, or "" if it is not encoded
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
@@ -0,0 +1,258 @@
+package normalize
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONSchema_Valid(t *testing.T) {
+	data, err := json.Marshal(JSONSchema())
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	if schema["$schema"] != JSONSchemaDraft {
+		t.Errorf("expected $schema %q, got %v", JSONSchemaDraft, schema["$schema"])
+	}
+	if schema["x-schema-version"] != SchemaVersion {
+		t.Errorf("expected x-schema-version %q, got %v", SchemaVersion, schema["x-schema-version"])
+	}
+
+	defs, ok := schema["$defs"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected $defs object, got %T", schema["$defs"])
+	}
+
+	// Every keyword must be well-formed and every $ref must resolve
+	checkSchemaNode(t, "#", schema, defs)
+}
+
+func TestJSONSchema_IncludesAllFields(t *testing.T) {
+	schema := JSONSchema()
+	defs := schema["$defs"].(map[string]interface{})
+
+	types := map[string]reflect.Type{
+		"NormalizedMessage": reflect.TypeOf(NormalizedMessage{}),
+		"User":              reflect.TypeOf(User{}),
+		"Channel":           reflect.TypeOf(Channel{}),
+		"Attachment":        reflect.TypeOf(Attachment{}),
+		"SharedMessage":     reflect.TypeOf(SharedMessage{}),
+		"CodeBlock":         reflect.TypeOf(CodeBlock{}),
+	}
+
+	for name, typ := range types {
+		t.Run(name, func(t *testing.T) {
+			node := schema
+			if name != "NormalizedMessage" {
+				def, ok := defs[name].(map[string]interface{})
+				if !ok {
+					t.Fatalf("missing $defs/%s", name)
+				}
+				node = def
+			}
+
+			properties := node["properties"].(map[string]interface{})
+			if len(properties) != typ.NumField() {
+				t.Errorf("expected %d properties, got %d", typ.NumField(), len(properties))
+			}
+			for i := 0; i < typ.NumField(); i++ {
+				jsonName := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+				if _, ok := properties[jsonName]; !ok {
+					t.Errorf("missing property %q for field %s", jsonName, typ.Field(i).Name)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONSchema_ValidatesMessage(t *testing.T) {
+	msg := &SlackMessage{
+		Type:      "message",
+		User:      "U123",
+		Text:      "Check ```go\nfmt.Println()\n``` and https://example.com",
+		Timestamp: "1234567890.123456",
+		Files: []map[string]interface{}{
+			{"name": "log.txt", "mimetype": "text/plain", "url_private": "https://files.slack.com/log.txt"},
+		},
+	}
+	channel := &SlackChannel{ID: "C123", Name: "general", IsChannel: true}
+	user := &SlackUser{ID: "U123", Name: "alice"}
+
+	normalized, err := SlackToNormalized(msg, channel, user, "T123", time.Now())
+	if err != nil {
+		t.Fatalf("failed to normalize message: %v", err)
+	}
+
+	var doc interface{}
+	data, _ := json.Marshal(normalized)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to round-trip message: %v", err)
+	}
+
+	schemaData, _ := json.Marshal(JSONSchema())
+	var schema map[string]interface{}
+	json.Unmarshal(schemaData, &schema)
+	defs := schema["$defs"].(map[string]interface{})
+
+	if err := validateAgainst(schema, doc, defs); err != nil {
+		t.Errorf("normalized message does not match schema: %v", err)
+	}
+}
+
+var jsonSchemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// checkSchemaNode verifies the keywords used by JSONSchema are well-formed
+func checkSchemaNode(t *testing.T, path string, node map[string]interface{}, defs map[string]interface{}) {
+	t.Helper()
+
+	if typ, ok := node["type"]; ok {
+		var names []interface{}
+		switch v := typ.(type) {
+		case string:
+			names = []interface{}{v}
+		case []interface{}:
+			names = v
+		default:
+			t.Errorf("%s: type must be a string or array, got %T", path, typ)
+		}
+		for _, n := range names {
+			if s, ok := n.(string); !ok || !jsonSchemaTypes[s] {
+				t.Errorf("%s: invalid type %v", path, n)
+			}
+		}
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		if _, ok := defs[name]; !ok || name == ref {
+			t.Errorf("%s: unresolved $ref %q", path, ref)
+		}
+	}
+
+	if props, ok := node["properties"]; ok {
+		propMap, ok := props.(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: properties must be an object", path)
+		}
+		for name, p := range propMap {
+			checkSchemaNode(t, path+"/properties/"+name, p.(map[string]interface{}), defs)
+		}
+	}
+
+	for _, key := range []string{"items", "additionalProperties"} {
+		if sub, ok := node[key].(map[string]interface{}); ok {
+			checkSchemaNode(t, path+"/"+key, sub, defs)
+		}
+	}
+
+	if anyOf, ok := node["anyOf"].([]interface{}); ok {
+		for i, sub := range anyOf {
+			checkSchemaNode(t, fmt.Sprintf("%s/anyOf/%d", path, i), sub.(map[string]interface{}), defs)
+		}
+	}
+
+	if d, ok := node["$defs"].(map[string]interface{}); ok {
+		for name, sub := range d {
+			checkSchemaNode(t, path+"/$defs/"+name, sub.(map[string]interface{}), defs)
+		}
+	}
+}
+
+// validateAgainst is a minimal validator for the subset of JSON Schema emitted by JSONSchema
+func validateAgainst(node map[string]interface{}, value interface{}, defs map[string]interface{}) error {
+	if ref, ok := node["$ref"].(string); ok {
+		return validateAgainst(defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{}), value, defs)
+	}
+
+	if anyOf, ok := node["anyOf"].([]interface{}); ok {
+		for _, sub := range anyOf {
+			if validateAgainst(sub.(map[string]interface{}), value, defs) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %v matches no anyOf branch", value)
+	}
+
+	if typ, ok := node["type"]; ok {
+		allowed := []interface{}{typ}
+		if list, ok := typ.([]interface{}); ok {
+			allowed = list
+		}
+		matched := false
+		for _, a := range allowed {
+			if jsonTypeMatches(a.(string), value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("value %v is not of type %v", value, typ)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := node["properties"].(map[string]interface{})
+		for key, fieldValue := range v {
+			if sub, ok := props[key].(map[string]interface{}); ok {
+				if err := validateAgainst(sub, fieldValue, defs); err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+			} else if node["additionalProperties"] == false {
+				return fmt.Errorf("unexpected property %q", key)
+			} else if sub, ok := node["additionalProperties"].(map[string]interface{}); ok {
+				if err := validateAgainst(sub, fieldValue, defs); err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateAgainst(items, item, defs); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func jsonTypeMatches(typ string, value interface{}) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// stopwords are common words of the Latin-script languages DetectLanguage
+// recognizes, by ISO 639-1 code
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "to", "of", "in", "it", "that", "this", "for", "with", "you", "on", "not", "have", "be", "but", "what", "can", "do", "does", "how", "my", "we", "i", "if", "there", "from"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "es", "en", "un", "una", "por", "con", "para", "no", "se", "lo", "como", "pero", "del", "al", "está", "son", "mi", "hay", "cómo", "qué", "puedo", "también", "esto"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "que", "qui", "pour", "dans", "pas", "ne", "sur", "avec", "je", "vous", "il", "ce", "cette", "mais", "du", "au", "sont", "comment", "mon", "nous", "être"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "von", "ich", "sie", "es", "auf", "für", "wie", "auch", "dem", "sich", "wir", "aber", "kann", "noch", "wenn", "mein", "habe", "bei", "funktioniert"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "é", "em", "um", "uma", "para", "com", "não", "do", "da", "no", "na", "por", "mas", "como", "eu", "meu", "isso", "está", "são", "tem", "você", "também", "ao"},
+	"it": {"il", "lo", "la", "gli", "le", "di", "che", "e", "è", "un", "una", "per", "con", "non", "del", "della", "in", "ma", "come", "sono", "mi", "questo", "anche", "ho", "si", "al", "nel", "funziona", "perché", "cosa"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "in", "op", "te", "met", "voor", "ik", "je", "zijn", "maar", "ook", "er", "hoe", "wat", "mijn", "kan", "dit", "bij", "werkt", "wel", "nog", "heb", "om"},
+}
+
+// stopwordSets indexes stopwords by language
+var stopwordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(stopwords))
+	for lang, words := range stopwords {
+		sets[lang] = make(map[string]bool, len(words))
+		for _, word := range words {
+			sets[lang][word] = true
+		}
+	}
+	return sets
+}()
+
+// Scripts that identify a language on their own
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+var (
+	// languageNoisePattern matches code, URLs, and mentions, which don't say
+	// which language a message is written in
+	languageNoisePattern = regexp.MustCompile("(?s)```.*?```|`[^`]*`|https?://\\S+|<[^>]*>|@\\S+")
+	languageWordPattern  = regexp.MustCompile(`[\p{L}]+`)
+)
+
+const (
+	// minLanguageWords is the fewest words DetectLanguage decides on
+	minLanguageWords = 3
+	// minStopwordRatio is the share of a text's words that must be stopwords
+	// of its language
+	minStopwordRatio = 0.15
+)
+
+// DetectLanguage guesses the language content is written in, as an ISO 639-1
+// code ("en", "es", ...), or returns "" when the content is too short or
+// ambiguous to tell. Latin-script languages are told apart by the share of
+// their stopwords; languages with a script of their own (Japanese, Russian,
+// ...) by their script. Code, URLs, and mentions are ignored.
+func DetectLanguage(content string) string {
+	text := strings.ToLower(languageNoisePattern.ReplaceAllString(content, " "))
+
+	// A script of its own decides when most letters are in it
+	letters := 0
+	scripts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scriptLanguages {
+			if unicode.Is(script.table, r) {
+				scripts[script.lang]++
+				break
+			}
+		}
+	}
+	// Japanese mixes kana and Han
+	if scripts["ja"] > 0 {
+		scripts["ja"] += scripts["zh"]
+		delete(scripts, "zh")
+	}
+	for lang, count := range scripts {
+		if count*2 > letters {
+			return lang
+		}
+	}
+
+	words := languageWordPattern.FindAllString(text, -1)
+	if len(words) < minLanguageWords {
+		return ""
+	}
+
+	best, bestHits, tied := "", 0, false
+	for lang, set := range stopwordSets {
+		hits := 0
+		for _, word := range words {
+			if set[word] {
+				hits++
+			}
+		}
+		switch {
+		case hits > bestHits:
+			best, bestHits, tied = lang, hits, false
+		case hits == bestHits:
+			tied = true
+		}
+	}
+	if tied || float64(bestHits) < minStopwordRatio*float64(len(words)) {
+		return ""
+	}
+	return best
+}
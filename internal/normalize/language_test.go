@@ -0,0 +1,30 @@
+package normalize
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"english", "The deploy is failing on main and I can't see why", "en"},
+		{"english with code and links", "Try this:\n```\nkubectl rollout restart deploy/api\n```\nIt is in the docs at https://example.com/es/la-guia", "en"},
+		{"spanish", "No puedo configurar el proxy en la terminal, ¿alguien sabe cómo hacerlo?", "es"},
+		{"french", "Le déploiement ne fonctionne pas depuis la mise à jour de la configuration", "fr"},
+		{"german", "Der Build ist seit gestern kaputt und ich weiß nicht, wie ich das beheben kann", "de"},
+		{"japanese", "デプロイが失敗しています。設定を確認してください。", "ja"},
+		{"russian", "Сборка падает после обновления, кто-нибудь знает почему?", "ru"},
+		{"too short", "LGTM!", ""},
+		{"no stopwords", "kubectl helm terraform ansible", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.content); got != tt.want {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
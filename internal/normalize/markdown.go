@@ -0,0 +1,126 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownFencePattern matches the opening line of a fenced code block: a
+	// run of three or more backticks or tildes, indented up to three spaces,
+	// followed by an info string whose first word is the language
+	markdownFencePattern = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	// markdownListItemPattern matches the first line of a list item, whose
+	// indented continuation lines aren't code
+	markdownListItemPattern = regexp.MustCompile(`^\s*([-*+]|\d{1,9}[.)])\s`)
+)
+
+// parseMarkdownCodeBlocks returns the fenced and indented code blocks of
+// Markdown text, in order. Fences follow CommonMark: a block opened by a run of
+// backticks or tildes only closes at a run of the same character at least as
+// long, so it can contain shorter fences and backticks, and a block left
+// open runs to the end of the text. A block whose closing fence ends its last
+// line of code, as people often type it, is accepted too. Indented code (four
+// spaces or a tab) starts after a blank line, outside of lists.
+func parseMarkdownCodeBlocks(text string) []CodeBlock {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	blocks := []CodeBlock{}
+
+	afterBlank, inList := true, false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Backtick fences can't have backticks in their info string
+		if m := markdownFencePattern.FindStringSubmatch(line); m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`")) {
+			indent, fence := len(m[1]), m[2]
+			language := ""
+			if fields := strings.Fields(m[3]); len(fields) > 0 {
+				language = fields[0]
+			}
+
+			var code strings.Builder
+			for i++; i < len(lines); i++ {
+				content := trimIndent(lines[i], indent)
+				if isClosingFence(content, fence) {
+					break
+				}
+				trimmed := strings.TrimRight(content, " \t")
+				if run := trailingRun(trimmed, fence[0]); run >= len(fence) && run < len(trimmed) {
+					code.WriteString(trimmed[:len(trimmed)-run])
+					break
+				}
+				code.WriteString(content)
+				code.WriteString("\n")
+			}
+
+			if strings.TrimSpace(code.String()) != "" {
+				blocks = append(blocks, CodeBlock{Language: language, Code: code.String()})
+			}
+			afterBlank, inList = false, false
+			continue
+		}
+
+		if afterBlank && !inList && isIndentedCode(line) {
+			var code []string
+			for ; i < len(lines) && (isIndentedCode(lines[i]) || strings.TrimSpace(lines[i]) == ""); i++ {
+				if strings.HasPrefix(lines[i], "\t") {
+					code = append(code, lines[i][1:])
+				} else {
+					code = append(code, trimIndent(lines[i], 4))
+				}
+			}
+			i-- // The line that ended the block is read again
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, CodeBlock{Code: strings.Join(code, "\n") + "\n"})
+			afterBlank = false
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			afterBlank = true
+			continue
+		}
+		afterBlank = false
+		if markdownListItemPattern.MatchString(line) {
+			inList = true
+		} else if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inList = false
+		}
+	}
+
+	return blocks
+}
+
+// isIndentedCode reports whether line is indented enough to be code
+func isIndentedCode(line string) bool {
+	return (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) && strings.TrimSpace(line) != ""
+}
+
+// isClosingFence reports whether line closes a block opened by fence: a run
+// of the same character at least as long, with nothing but spaces around it
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 || len(trimmed) < len(fence) {
+		return false
+	}
+	return strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// trailingRun returns the number of times c repeats at the end of s
+func trailingRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[len(s)-1-n] == c {
+		n++
+	}
+	return n
+}
+
+// trimIndent removes up to n leading spaces from line
+func trimIndent(line string, n int) string {
+	for i := 0; i < n && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
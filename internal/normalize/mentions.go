@@ -0,0 +1,40 @@
+package normalize
+
+import "regexp"
+
+// ResolveMentionsInContent enables rewriting user mentions in message content
+// to display names after fetching (normalize.resolve_mentions_in_content)
+var ResolveMentionsInContent = false
+
+// contentMentionPattern matches a Slack mention (<@U123> or <@U123|label>) or a
+// plain @token (@U123, @login) that isn't part of an email address
+var contentMentionPattern = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|[^>]+)?>|(^|[^a-zA-Z0-9.])@([a-zA-Z0-9][-a-zA-Z0-9]*)`)
+
+// MentionTokens returns the user IDs and logins mentioned in content, in order
+// of first appearance
+func MentionTokens(content string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, match := range contentMentionPattern.FindAllStringSubmatch(content, -1) {
+		token := match[1] + match[3]
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// ResolveMentions rewrites each mention in content whose token (Slack user ID
+// or GitHub login) is in names to @DisplayName. Mentions that can't be
+// resolved are left as they are.
+func ResolveMentions(content string, names map[string]string) string {
+	return contentMentionPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := contentMentionPattern.FindStringSubmatch(match)
+		name := names[parts[1]+parts[3]]
+		if name == "" {
+			return match
+		}
+		return parts[2] + "@" + name
+	})
+}
@@ -0,0 +1,78 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveMentions(t *testing.T) {
+	names := map[string]string{
+		"U123":    "alice",
+		"U456":    "bob",
+		"octocat": "The Octocat",
+		"hubot":   "",
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "slack mention without label",
+			content:  "thanks <@U123>!",
+			expected: "thanks @alice!",
+		},
+		{
+			name:     "slack mention with stale label",
+			content:  "cc <@U456|robert>",
+			expected: "cc @bob",
+		},
+		{
+			name:     "plain slack ID",
+			content:  "@U123 can you look?",
+			expected: "@alice can you look?",
+		},
+		{
+			name:     "github login",
+			content:  "Fixed by @octocat in #12",
+			expected: "Fixed by @The Octocat in #12",
+		},
+		{
+			name:     "unresolvable mentions unchanged",
+			content:  "<@U999> and @someone else",
+			expected: "<@U999> and @someone else",
+		},
+		{
+			name:     "empty display name unchanged",
+			content:  "ping @hubot",
+			expected: "ping @hubot",
+		},
+		{
+			name:     "email address untouched",
+			content:  "mail octocat@octocat.com",
+			expected: "mail octocat@octocat.com",
+		},
+		{
+			name:     "mixed",
+			content:  "<@U123> asked @octocat and @nobody",
+			expected: "@alice asked @The Octocat and @nobody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveMentions(tt.content, names); got != tt.expected {
+				t.Errorf("ResolveMentions(%q) = %q, want %q", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMentionTokens(t *testing.T) {
+	got := MentionTokens("<@U123|alice> asked @octocat, then <@U123> again and @octocat. me@example.com")
+	want := []string{"U123", "octocat"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MentionTokens() = %v, want %v", got, want)
+	}
+}
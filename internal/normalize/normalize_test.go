@@ -1,6 +1,8 @@
 package normalize
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -154,6 +156,26 @@ func TestSlackMarkupNormalization(t *testing.T) {
 			input:    "Use &lt;div&gt; tags &amp; styles",
 			expected: "Use <div> tags & styles",
 		},
+		{
+			name:     "quote entities",
+			input:    "Set &quot;debug&quot; to &#39;true&#39;",
+			expected: "Set \"debug\" to 'true'",
+		},
+		{
+			name:     "numeric entities",
+			input:    "Build &#8212; deploy &#x2192; done",
+			expected: "Build \u2014 deploy \u2192 done",
+		},
+		{
+			name:     "zero-width characters",
+			input:    "\u200Bhow do\u200D I \uFEFFdeploy",
+			expected: "how do I deploy",
+		},
+		{
+			name:     "smart quotes",
+			input:    "\u201CWhat\u2019s wrong?\u201D she asked",
+			expected: "\"What's wrong?\" she asked",
+		},
 	}
 	
 	for _, tt := range tests {
@@ -200,6 +222,25 @@ func TestExtractURLs(t *testing.T) {
 	}
 }
 
+func TestExtractMentionsAndURLs_Repeated(t *testing.T) {
+	text := "<@U456> see <https://example.com>, <@U123|john> too. <@U456> again: <https://example.com|example> <http://test.com>"
+
+	mentions := extractMentions(text)
+	if !reflect.DeepEqual(mentions, []string{"U456", "U123"}) {
+		t.Errorf("Expected mentions [U456 U123], got %v", mentions)
+	}
+
+	urls := extractURLs(text)
+	if !reflect.DeepEqual(urls, []string{"https://example.com", "http://test.com"}) {
+		t.Errorf("Expected URLs [https://example.com http://test.com], got %v", urls)
+	}
+
+	githubMentions := extractGitHubMentions("@bob please review, @alice FYI. Thanks @bob!")
+	if !reflect.DeepEqual(githubMentions, []string{"bob", "alice"}) {
+		t.Errorf("Expected GitHub mentions [bob alice], got %v", githubMentions)
+	}
+}
+
 func TestExtractCodeBlocks(t *testing.T) {
 	text := "Here's some code:\n```python\nprint('hello')\n```\nAnd more:\n```\nplain text\n```"
 	blocks := extractCodeBlocks(text)
@@ -241,3 +282,76 @@ func TestParseSlackTimestamp(t *testing.T) {
 		t.Errorf("Expected nanoseconds around %d, got %d (diff: %d)", expectedNano, actualNano, diff)
 	}
 }
+
+func TestSlackToNormalized_Edited(t *testing.T) {
+	channel := &SlackChannel{ID: "C123", Name: "general", IsChannel: true}
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantEdited bool
+		wantBy     string
+		wantAt     time.Time
+	}{
+		{
+			name:    "not edited",
+			payload: `{"type":"message","user":"U123","text":"Hello","ts":"1234567890.123456"}`,
+		},
+		{
+			name:       "edited",
+			payload:    `{"type":"message","user":"U123","text":"Hello again","ts":"1234567890.123456","edited":{"user":"U456","ts":"1234567990.000000"}}`,
+			wantEdited: true,
+			wantBy:     "U456",
+			wantAt:     time.Unix(1234567990, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg SlackMessage
+			if err := json.Unmarshal([]byte(tt.payload), &msg); err != nil {
+				t.Fatalf("failed to parse payload: %v", err)
+			}
+
+			normalized, err := SlackToNormalized(&msg, channel, nil, "T123", time.Now())
+			if err != nil {
+				t.Fatalf("SlackToNormalized failed: %v", err)
+			}
+			if normalized.IsEdited != tt.wantEdited {
+				t.Errorf("IsEdited = %v, want %v", normalized.IsEdited, tt.wantEdited)
+			}
+
+			editedBy, hasBy := normalized.SourceMetadata["edited_by"]
+			editedAt, hasAt := normalized.SourceMetadata["edited_at"]
+			if !tt.wantEdited {
+				if hasBy || hasAt {
+					t.Errorf("expected no edit metadata, got edited_by=%v edited_at=%v", editedBy, editedAt)
+				}
+				return
+			}
+			if editedBy != tt.wantBy {
+				t.Errorf("edited_by = %v, want %s", editedBy, tt.wantBy)
+			}
+			if at, ok := editedAt.(time.Time); !ok || !at.Equal(tt.wantAt) {
+				t.Errorf("edited_at = %v, want %v", editedAt, tt.wantAt)
+			}
+		})
+	}
+}
+
+func TestSlackToNormalized_SourceTimestamp(t *testing.T) {
+	// Trailing zeros don't survive a round trip through time.Time
+	for _, ts := range []string{"1234567890.123456", "1700000000.000100", "1700000000.100000"} {
+		msg := &SlackMessage{Type: "message", User: "U123", Text: "hi", Timestamp: ts}
+		normalized, err := SlackToNormalized(msg, &SlackChannel{ID: "C123"}, nil, "T123", time.Now())
+		if err != nil {
+			t.Fatalf("Failed to normalize message %s: %v", ts, err)
+		}
+		if normalized.SourceTimestamp != ts {
+			t.Errorf("Expected source_timestamp %q, got %q", ts, normalized.SourceTimestamp)
+		}
+		if normalized.SourceMetadata["ts"] != ts {
+			t.Errorf("Expected source_metadata ts %q, got %v", ts, normalized.SourceMetadata["ts"])
+		}
+	}
+}
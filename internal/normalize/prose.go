@@ -0,0 +1,40 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// codePattern matches the code in message content, as ExtractCodeBlocks
+	// finds it: fenced blocks, inline code, and <code> tags
+	codePattern = regexp.MustCompile("(?s)```.*?```|~~~.*?~~~|<code[^>]*>.*?</code>|`[^`\n]+`")
+	// linkPattern matches the URLs in message content, as ExtractURLs finds them
+	linkPattern = regexp.MustCompile(`<?https?://[^\s<>]+>?`)
+)
+
+// IsCodeOnly reports whether content is a paste of code with no prose: it has
+// code, and nothing outside the code but whitespace and punctuation
+func IsCodeOnly(content string) bool {
+	return isOnly(content, codePattern)
+}
+
+// IsLinkOnly reports whether content is a dump of links with no prose: it has
+// URLs, and nothing besides them but whitespace and punctuation (like list
+// bullets). Labels of Slack and Markdown links are prose.
+func IsLinkOnly(content string) bool {
+	return isOnly(content, linkPattern)
+}
+
+// isOnly reports whether content has matches of pattern and no letters or
+// digits outside them
+func isOnly(content string, pattern *regexp.Regexp) bool {
+	if !pattern.MatchString(content) {
+		return false
+	}
+	rest := pattern.ReplaceAllString(content, " ")
+	return strings.IndexFunc(rest, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) < 0
+}
@@ -0,0 +1,39 @@
+package normalize
+
+import "testing"
+
+func TestIsCodeOnlyAndIsLinkOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		codeOnly bool
+		linkOnly bool
+	}{
+		{"fenced block", "```\npanic: runtime error\ngoroutine 1 [running]:\n```", true, false},
+		{"tilde block with spacing", "\n  ~~~\nmake build\n~~~  \n", true, false},
+		{"several blocks", "```\nfoo()\n```\n\n```\nbar()\n```", true, false},
+		{"inline code", "`kubectl get pods -A`", true, false},
+		{"code tag", "<code>SELECT 1</code>", true, false},
+		{"one link", "https://example.com/docs", false, true},
+		{"slack link", "<https://example.com/docs>", false, true},
+		{"bulleted links", "- https://example.com/a\n- https://example.com/b?page=2", false, true},
+		{"prose", "Has anyone seen this before?", false, false},
+		{"prose and code", "I get this error:\n```\npanic: runtime error\n```", false, false},
+		{"prose and link", "See https://example.com/docs for details", false, false},
+		{"labeled link", "this link (https://example.com)", false, false},
+		{"code and link", "```\nfoo()\n```\nhttps://example.com", false, false},
+		{"punctuation only", "...", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCodeOnly(tt.content); got != tt.codeOnly {
+				t.Errorf("IsCodeOnly(%q) = %v, want %v", tt.content, got, tt.codeOnly)
+			}
+			if got := IsLinkOnly(tt.content); got != tt.linkOnly {
+				t.Errorf("IsLinkOnly(%q) = %v, want %v", tt.content, got, tt.linkOnly)
+			}
+		})
+	}
+}
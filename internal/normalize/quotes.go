@@ -0,0 +1,85 @@
+package normalize
+
+import (
+	"sort"
+	"strings"
+)
+
+// minQuoteLength is the shortest quote, in normalized characters, that
+// InferQuoteParents matches; shorter quotes ("> yes") match too many messages
+const minQuoteLength = 12
+
+// LeadingQuote returns the text of the block quote content starts with,
+// without its "> " markers, or "" if content doesn't start with a quote
+func LeadingQuote(content string) string {
+	var quoted []string
+	for _, line := range strings.Split(strings.TrimLeft(content, " \t\r\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, ">") {
+			break
+		}
+		quoted = append(quoted, strings.TrimSpace(strings.TrimLeft(line, ">")))
+	}
+	return strings.Join(quoted, "\n")
+}
+
+// InferQuoteParents infers reply structure in threads whose replies are all
+// attached to the thread root, like GitHub issue comments. A reply that starts
+// by quoting an earlier message is taken to reply to it: the result maps the
+// reply's ID to the ID of the latest earlier message containing the quoted
+// text. Quotes in the earlier messages themselves are ignored, so a reply
+// quoting a quote is attached to the quote's author. Replies that already
+// have a parent other than a thread root are left alone.
+func InferQuoteParents(messages []*NormalizedMessage) map[string]string {
+	sorted := make([]*NormalizedMessage, len(messages))
+	copy(sorted, messages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	roots := make(map[string]bool)
+	for _, msg := range sorted {
+		if msg.IsThreadRoot {
+			roots[msg.ID] = true
+		}
+	}
+
+	parents := make(map[string]string)
+	for i, msg := range sorted {
+		if msg.IsThreadRoot || (msg.ParentID != "" && !roots[msg.ParentID]) {
+			continue
+		}
+		quote := quoteMatchText(LeadingQuote(msg.Content))
+		if len(quote) < minQuoteLength {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			earlier := sorted[j]
+			if earlier.ThreadID != msg.ThreadID {
+				continue
+			}
+			if strings.Contains(quoteMatchText(withoutQuotes(earlier.Content)), quote) {
+				parents[msg.ID] = earlier.ID
+				break
+			}
+		}
+	}
+	return parents
+}
+
+// withoutQuotes returns content without its block quote lines
+func withoutQuotes(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), ">") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// quoteMatchText normalizes text for matching quotes against what they
+// quote: Markdown emphasis is dropped, and case and whitespace are ignored
+func quoteMatchText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(normalizeGitHubMarkdown(text)), " "))
+}
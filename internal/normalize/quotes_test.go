@@ -0,0 +1,82 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLeadingQuote(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"single line", "> Does it work on Windows?\n\nYes, since 2.0", "Does it work on Windows?"},
+		{"several lines", "> first line\n>second line\n> \n\nreply", "first line\nsecond line\n"},
+		{"leading blank lines", "\n  > quoted\nreply", "quoted"},
+		{"no quote", "Thanks, that fixed it", ""},
+		{"quote later on", "I agree\n> with this", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LeadingQuote(tt.content); got != tt.want {
+				t.Errorf("LeadingQuote(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferQuoteParents(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	message := func(id string, minutes int, content string) *NormalizedMessage {
+		return &NormalizedMessage{
+			ID:        id,
+			ThreadID:  "thread_1",
+			ParentID:  "root",
+			Timestamp: start.Add(time.Duration(minutes) * time.Minute),
+			Content:   content,
+		}
+	}
+
+	root := message("root", 0, "Crash on start\n\nThe app crashes when the config file is missing.")
+	root.IsThreadRoot = true
+	root.ParentID = ""
+	messages := []*NormalizedMessage{
+		root,
+		message("c1", 1, "Does it also crash with an empty config file?"),
+		message("c2", 2, "Which version are you running? Please attach the **full log**."),
+		// Quotes c2, with different case, spacing, and Markdown
+		message("c3", 3, "> which version are you running?\n> Please attach the full log.\n\nVersion 2.1, log attached."),
+		// Doesn't quote anything
+		message("c4", 4, "Same here on 2.1."),
+		// Quotes c3's quote of c2: attached to c2, who wrote it
+		message("c5", 5, "> Which version are you running?\n\n2.0, also crashes."),
+		// Quotes c1, not the latest message
+		message("c6", 6, "> Does it also crash with an empty config file?\n\nNo, only when it's missing."),
+		// Too short to match reliably
+		message("c7", 7, "> Same\n\n+1"),
+		// Quotes text nobody wrote
+		message("c8", 8, "> It works on my machine\n\nNot on mine."),
+		// Quotes the issue itself: the root, which is already its parent
+		message("c9", 9, "> The app crashes when the config file is missing.\n\nConfirmed."),
+		// Quotes a later message
+		message("c10", 10, "> Fixed by reinstalling the app from scratch\n\nWill try."),
+		message("c11", 11, "Fixed by reinstalling the app from scratch"),
+	}
+	// Already nested replies keep their parent
+	nested := message("c12", 12, "> Which version are you running?\n\n2.2")
+	nested.ParentID = "c4"
+	messages = append(messages, nested)
+
+	want := map[string]string{
+		"c3": "c2",
+		"c5": "c2",
+		"c6": "c1",
+		"c9": "root",
+	}
+	if got := InferQuoteParents(messages); !reflect.DeepEqual(got, want) {
+		t.Errorf("InferQuoteParents() = %v, want %v", got, want)
+	}
+}
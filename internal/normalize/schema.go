@@ -10,22 +10,26 @@ type NormalizedMessage struct {
 	SourceID   string `json:"source_id"`   // Original source identifier
 
 	// Common fields
-	Timestamp   time.Time `json:"timestamp"`
-	Author      *User     `json:"author"`
-	Content     string    `json:"content"`      // Normalized text
-	ContentHTML string    `json:"content_html"` // Rich format if available
+	Timestamp       time.Time `json:"timestamp"`
+	SourceTimestamp string    `json:"source_timestamp,omitempty"` // Timestamp exactly as the source wrote it
+	Author          *User     `json:"author"`
+	Content         string    `json:"content"`      // Normalized text
+	ContentHTML     string    `json:"content_html"` // Rich format if available
 
 	// Conversation context
 	Channel      *Channel `json:"channel"`
 	ThreadID     string   `json:"thread_id"`
 	ParentID     string   `json:"parent_id"`
 	IsThreadRoot bool     `json:"is_thread_root"`
+	IsEdited     bool     `json:"is_edited"` // The content was changed after posting
 
 	// Metadata
-	Attachments []Attachment `json:"attachments"`
-	Mentions    []string     `json:"mentions"`
-	URLs        []string     `json:"urls"`
-	CodeBlocks  []CodeBlock  `json:"code_blocks"`
+	Attachments    []Attachment    `json:"attachments"`
+	SharedMessages []SharedMessage `json:"shared_messages,omitempty"` // Messages shared (forwarded) into this one
+	Mentions       []string        `json:"mentions"`
+	URLs           []string        `json:"urls"`
+	CodeBlocks     []CodeBlock     `json:"code_blocks"`
+	Language       string          `json:"language,omitempty"` // ISO 639-1 code from DetectLanguage, "" if undetermined
 
 	// Source-specific (preserved as-is)
 	SourceMetadata map[string]interface{} `json:"source_metadata"`
@@ -76,6 +80,19 @@ type Attachment struct {
 	MimeType string `json:"mime_type"`
 }
 
+// SharedMessage represents a message shared (forwarded) into another message,
+// such as a Slack message shared from another channel. The original may or may
+// not be in the corpus.
+type SharedMessage struct {
+	MessageID  string `json:"message_id"`  // Universal ID the original has, or would have, when fetched
+	URL        string `json:"url"`         // Permalink of the original
+	ChannelID  string `json:"channel_id"`  // Source-native channel ID of the original
+	TS         string `json:"ts"`          // Source-native timestamp of the original
+	AuthorID   string `json:"author_id"`   // Universal ID of the original's author
+	AuthorName string `json:"author_name"` // Display name of the original's author
+	Content    string `json:"content"`     // Normalized text of the original
+}
+
 // CodeBlock represents a code snippet in a message
 type CodeBlock struct {
 	Language string `json:"language"`
@@ -0,0 +1,84 @@
+package normalize
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// AttachmentTypeMessage is the Attachment type of a shared message where only
+// attachments are kept: its URL is the original's permalink and its title the
+// original's author
+const AttachmentTypeMessage = "message"
+
+// SlackAttachment represents a raw Slack message attachment (the "attachments"
+// field, as opposed to uploaded "files"). Shared messages and message link
+// unfurls are attachments with is_share or is_msg_unfurl set.
+type SlackAttachment struct {
+	IsShare     bool        `json:"is_share,omitempty"`
+	IsMsgUnfurl bool        `json:"is_msg_unfurl,omitempty"`
+	FromURL     string      `json:"from_url,omitempty"`
+	ChannelID   string      `json:"channel_id,omitempty"`
+	ChannelName string      `json:"channel_name,omitempty"`
+	TS          json.Number `json:"ts,omitempty"` // A string for messages, a number for other attachments
+	AuthorID    string      `json:"author_id,omitempty"`
+	AuthorName  string      `json:"author_name,omitempty"`
+	Text        string      `json:"text,omitempty"`
+	Fallback    string      `json:"fallback,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	TitleLink   string      `json:"title_link,omitempty"`
+}
+
+// slackPermalinkPattern matches Slack message permalinks:
+// https://team.slack.com/archives/C123/p1700000000000100
+var slackPermalinkPattern = regexp.MustCompile(`slack\.com/archives/([A-Z0-9]+)/p(\d{10})(\d{6})`)
+
+// ParseSlackPermalink returns the channel ID and timestamp of the message a
+// Slack permalink points to
+func ParseSlackPermalink(url string) (channelID, ts string, ok bool) {
+	m := slackPermalinkPattern.FindStringSubmatch(url)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2] + "." + m[3], true
+}
+
+// SlackSharedMessages returns the messages shared into a Slack message by its
+// attachments, with the IDs they have when normalized with teamID. The channel
+// and timestamp of the original come from the attachment, or else from its
+// permalink. Other attachments, like link previews, are left out.
+func SlackSharedMessages(attachments []SlackAttachment, teamID string) []SharedMessage {
+	var shared []SharedMessage
+	for _, att := range attachments {
+		if !att.IsShare && !att.IsMsgUnfurl {
+			continue
+		}
+
+		channelID, ts := att.ChannelID, att.TS.String()
+		if linkChannel, linkTS, ok := ParseSlackPermalink(att.FromURL); ok {
+			if channelID == "" {
+				channelID = linkChannel
+			}
+			if ts == "" {
+				ts = linkTS
+			}
+		}
+		if channelID == "" || ts == "" {
+			continue
+		}
+
+		msg := SharedMessage{
+			MessageID:  fmt.Sprintf("msg_slack_%s_%s_%s", teamID, channelID, ts),
+			URL:        att.FromURL,
+			ChannelID:  channelID,
+			TS:         ts,
+			AuthorName: att.AuthorName,
+			Content:    normalizeSlackText(att.Text),
+		}
+		if att.AuthorID != "" {
+			msg.AuthorID = fmt.Sprintf("user_slack_%s_%s", teamID, att.AuthorID)
+		}
+		shared = append(shared, msg)
+	}
+	return shared
+}
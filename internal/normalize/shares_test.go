@@ -0,0 +1,60 @@
+package normalize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSlackSharedMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments string
+		want        []SharedMessage
+	}{
+		{
+			name:        "share from another channel",
+			attachments: `[{"is_share":true,"from_url":"https://example.slack.com/archives/C1/p1700000000000100","channel_id":"C1","ts":"1700000000.000100","author_id":"U1","author_name":"Dana","text":"restart it &amp; see"}]`,
+			want: []SharedMessage{{
+				MessageID:  "msg_slack_T1_C1_1700000000.000100",
+				URL:        "https://example.slack.com/archives/C1/p1700000000000100",
+				ChannelID:  "C1",
+				TS:         "1700000000.000100",
+				AuthorID:   "user_slack_T1_U1",
+				AuthorName: "Dana",
+				Content:    "restart it & see",
+			}},
+		},
+		{
+			name:        "channel and timestamp from the permalink",
+			attachments: `[{"is_msg_unfurl":true,"from_url":"https://example.slack.com/archives/C2/p1700000000000200","text":"hi"}]`,
+			want: []SharedMessage{{
+				MessageID: "msg_slack_T1_C2_1700000000.000200",
+				URL:       "https://example.slack.com/archives/C2/p1700000000000200",
+				ChannelID: "C2",
+				TS:        "1700000000.000200",
+				Content:   "hi",
+			}},
+		},
+		{
+			name:        "link preview",
+			attachments: `[{"title":"Docs","from_url":"https://example.com/docs","ts":1699990000}]`,
+		},
+		{
+			name:        "share without a location",
+			attachments: `[{"is_share":true,"text":"lost"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attachments []SlackAttachment
+			if err := json.Unmarshal([]byte(tt.attachments), &attachments); err != nil {
+				t.Fatalf("failed to parse attachments: %v", err)
+			}
+			if got := SlackSharedMessages(attachments, "T1"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SlackSharedMessages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
-	"strings"
 	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
 // SlackMessage represents the raw Slack message structure
@@ -18,9 +19,18 @@ type SlackMessage struct {
 	BotID     string                 `json:"bot_id,omitempty"`
 	Subtype   string                 `json:"subtype,omitempty"`
 	Files     []map[string]interface{} `json:"files,omitempty"`
+	Attachments []SlackAttachment      `json:"attachments,omitempty"`
+	Blocks    []map[string]interface{} `json:"blocks,omitempty"`
+	Edited    *SlackEdited           `json:"edited,omitempty"`
 	Metadata  map[string]interface{} `json:"-"` // Catch-all for other fields
 }
 
+// SlackEdited represents the raw Slack record of a message's last edit
+type SlackEdited struct {
+	User      string `json:"user"`
+	Timestamp string `json:"ts"`
+}
+
 // SlackChannel represents the raw Slack channel structure
 type SlackChannel struct {
 	ID        string `json:"id"`
@@ -79,8 +89,13 @@ func SlackToNormalized(msg *SlackMessage, channel *SlackChannel, user *SlackUser
 	// Convert text to normalized format (remove Slack markup)
 	normalizedText := normalizeSlackText(msg.Text)
 
-	// Convert attachments
+	// Convert attachments: uploaded files, then media in blocks not among them
 	attachments := convertSlackAttachments(msg.Files)
+	for _, att := range SlackBlockAttachments(msg.Blocks) {
+		if !hasAttachmentURL(attachments, att.URL) {
+			attachments = append(attachments, att)
+		}
+	}
 
 	// Build normalized message
 	normalized := &NormalizedMessage{
@@ -88,6 +103,7 @@ func SlackToNormalized(msg *SlackMessage, channel *SlackChannel, user *SlackUser
 		SourceType: "slack",
 		SourceID:   fmt.Sprintf("%s:%s:%s", teamID, channel.ID, msg.Timestamp),
 		Timestamp:  ts,
+		SourceTimestamp: msg.Timestamp,
 		Author:     convertSlackUser(user, teamID),
 		Content:    normalizedText,
 		ContentHTML: "", // Slack doesn't provide HTML
@@ -96,6 +112,7 @@ func SlackToNormalized(msg *SlackMessage, channel *SlackChannel, user *SlackUser
 		ParentID:   parentID,
 		IsThreadRoot: isThreadRoot,
 		Attachments: attachments,
+		SharedMessages: SlackSharedMessages(msg.Attachments, teamID),
 		Mentions:   mentions,
 		URLs:       urls,
 		CodeBlocks: codeBlocks,
@@ -109,9 +126,24 @@ func SlackToNormalized(msg *SlackMessage, channel *SlackChannel, user *SlackUser
 			"bot_id": msg.BotID,
 		},
 		FetchedAt:    fetchedAt,
-		NormalizedAt: time.Now(),
+		NormalizedAt: clock.Now(),
 		SchemaVersion: SchemaVersion,
 	}
+	normalized.Language = DetectLanguage(normalized.Content)
+
+	// Edited messages record who last changed them and when
+	if msg.Edited != nil {
+		normalized.IsEdited = true
+		normalized.SourceMetadata["edited_by"] = msg.Edited.User
+		if editedAt, err := parseSlackTimestamp(msg.Edited.Timestamp); err == nil {
+			normalized.SourceMetadata["edited_at"] = editedAt
+		}
+	}
+
+	// Slack threads have no title of their own, so derive one from the root
+	if msg.ThreadTS != "" && isThreadRoot {
+		normalized.SourceMetadata["thread_title"] = DeriveThreadTitle(normalized)
+	}
 
 	return normalized, nil
 }
@@ -178,7 +210,7 @@ func extractMentions(text string) []string {
 			mentions = append(mentions, match[1])
 		}
 	}
-	return mentions
+	return dedupeStrings(mentions)
 }
 
 // extractURLs extracts URLs from Slack text
@@ -190,7 +222,7 @@ func extractURLs(text string) []string {
 			urls = append(urls, match[1])
 		}
 	}
-	return urls
+	return dedupeStrings(urls)
 }
 
 // extractCodeBlocks extracts code blocks from text
@@ -246,14 +278,22 @@ func normalizeSlackText(text string) string {
 		return match
 	})
 	
-	// Unescape HTML entities
-	text = strings.ReplaceAll(text, "&lt;", "<")
-	text = strings.ReplaceAll(text, "&gt;", ">")
-	text = strings.ReplaceAll(text, "&amp;", "&")
+	// Unescape HTML entities and clean up unicode
+	text = CleanText(text)
 	
 	return text
 }
 
+// hasAttachmentURL reports whether one of attachments has url, if it isn't empty
+func hasAttachmentURL(attachments []Attachment, url string) bool {
+	for _, att := range attachments {
+		if url != "" && att.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
 // convertSlackAttachments converts Slack file attachments
 func convertSlackAttachments(files []map[string]interface{}) []Attachment {
 	if len(files) == 0 {
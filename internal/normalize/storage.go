@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -44,10 +45,34 @@ func MessagesBySourceDir() (string, error) {
 	return filepath.Join(normalizedDir, "messages", "by_source"), nil
 }
 
-// SaveNormalizedMessage saves a normalized message to all necessary indexes
+// MergeRefetched returns the message to store when refetched replaces existing.
+// It follows the same policy as the database: identity fields (ID, SourceType,
+// SourceID, Timestamp, and the author's ID) never change; everything else,
+// including content, channel, thread membership, and the fields derived from
+// content, comes from the refetch. The author's profile is refreshed only when
+// the refetch has the same author. existing may be nil.
+func MergeRefetched(existing, refetched *NormalizedMessage) *NormalizedMessage {
+	merged := *refetched
+	if existing == nil {
+		return &merged
+	}
+
+	merged.ID = existing.ID
+	merged.SourceType = existing.SourceType
+	merged.SourceID = existing.SourceID
+	merged.Timestamp = existing.Timestamp
+	if existing.Author != nil && (refetched.Author == nil || refetched.Author.ID != existing.Author.ID) {
+		merged.Author = existing.Author
+	}
+	return &merged
+}
+
+// SaveNormalizedMessage saves a normalized message to all necessary indexes.
+// A message that was saved before is merged with the stored copy (see MergeRefetched).
 func SaveNormalizedMessage(msg *NormalizedMessage) error {
 	// Save by ID
-	if err := saveMessageByID(msg); err != nil {
+	msg, err := saveMessageByID(msg)
+	if err != nil {
 		return fmt.Errorf("failed to save message by ID: %w", err)
 	}
 	
@@ -64,39 +89,52 @@ func SaveNormalizedMessage(msg *NormalizedMessage) error {
 	return nil
 }
 
-// saveMessageByID saves a message as an individual JSON file indexed by message ID
-func saveMessageByID(msg *NormalizedMessage) error {
+// saveMessageByID saves a message as an individual JSON file indexed by message ID,
+// merging it with any existing file, and returns the message as saved
+func saveMessageByID(msg *NormalizedMessage) (*NormalizedMessage, error) {
 	dir, err := MessagesByIDDir()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	
 	// Create directory with restrictive permissions
 	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 	
 	// Build file path
 	filePath := filepath.Join(dir, msg.ID+".json")
 	
+	// Merge a refetched message into the stored copy
+	var existing *NormalizedMessage
+	if data, err := os.ReadFile(filePath); err == nil {
+		existing = &NormalizedMessage{}
+		if err := json.Unmarshal(data, existing); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal existing message: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read existing message: %w", err)
+	}
+	msg = MergeRefetched(existing, msg)
+	
 	// Marshal to JSON with indentation for human readability
 	data, err := json.MarshalIndent(msg, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
 	}
 	
 	// Write to temp file first, then rename (atomic write)
 	tempPath := filePath + ".tmp"
 	if err := os.WriteFile(tempPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 	
 	if err := os.Rename(tempPath, filePath); err != nil {
 		os.Remove(tempPath) // Clean up temp file
-		return fmt.Errorf("failed to rename file: %w", err)
+		return nil, fmt.Errorf("failed to rename file: %w", err)
 	}
 	
-	return nil
+	return msg, nil
 }
 
 // appendMessageByDate appends a message to the date-indexed JSONL file
@@ -138,7 +176,8 @@ func appendMessageByDate(msg *NormalizedMessage) error {
 	return nil
 }
 
-// appendMessageBySource appends a message to the source-indexed JSONL file
+// appendMessageBySource appends a message to the source-indexed JSONL file,
+// compressed if CompressBySource is set
 func appendMessageBySource(msg *NormalizedMessage) error {
 	dir, err := MessagesBySourceDir()
 	if err != nil {
@@ -158,6 +197,10 @@ func appendMessageBySource(msg *NormalizedMessage) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 	
+	if CompressBySource {
+		return appendCompressedBySource(msg.SourceType, append(data, '\n'))
+	}
+	
 	// Append to file (create if doesn't exist)
 	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
@@ -197,6 +240,97 @@ func LoadMessageByID(id string) (*NormalizedMessage, error) {
 	return &msg, nil
 }
 
+// loadWorkers bounds the number of message files LoadMessagesByIDs reads at once
+const loadWorkers = 8
+
+// LoadMessagesByIDs loads the normalized messages with the given IDs, reading
+// their files concurrently. The result is keyed by message ID; IDs without a
+// stored message are left out rather than treated as errors.
+func LoadMessagesByIDs(ids []string) (map[string]*NormalizedMessage, error) {
+	dir, err := MessagesByIDDir()
+	if err != nil {
+		return nil, err
+	}
+	
+	// Deduplicate so each file is read once
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	
+	type result struct {
+		id  string
+		msg *NormalizedMessage
+		err error
+	}
+	jobs := make(chan string)
+	results := make(chan result)
+	
+	workers := loadWorkers
+	if len(unique) < workers {
+		workers = len(unique)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				msg, err := readMessageFile(filepath.Join(dir, id+".json"))
+				results <- result{id: id, msg: msg, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, id := range unique {
+			jobs <- id
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	
+	messages := make(map[string]*NormalizedMessage, len(unique))
+	var firstErr error
+	for r := range results {
+		switch {
+		case r.err != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to load message %s: %w", r.id, r.err)
+			}
+		case r.msg != nil:
+			messages[r.id] = r.msg
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	
+	return messages, nil
+}
+
+// readMessageFile reads one message file, returning nil if it doesn't exist
+func readMessageFile(filePath string) (*NormalizedMessage, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	
+	var msg NormalizedMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	
+	return &msg, nil
+}
+
 // LoadMessagesByDate loads all messages from a specific date
 func LoadMessagesByDate(date time.Time) ([]*NormalizedMessage, error) {
 	dir, err := MessagesByDateDir()
@@ -204,6 +338,40 @@ func LoadMessagesByDate(date time.Time) ([]*NormalizedMessage, error) {
 		return nil, err
 	}
 	
+	return loadMessagesForDate(dir, date)
+}
+
+// LoadMessagesByDateRange loads all messages from the dates between from and to.
+// Both ends are inclusive and compared by calendar date in from's location, so
+// partial days are loaded whole. Dates without messages are skipped.
+func LoadMessagesByDateRange(from, to time.Time) ([]*NormalizedMessage, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid date range: %s is after %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	
+	dir, err := MessagesByDateDir()
+	if err != nil {
+		return nil, err
+	}
+	
+	to = to.In(from.Location())
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, from.Location())
+	
+	messages := []*NormalizedMessage{}
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		dayMessages, err := loadMessagesForDate(dir, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load messages for %s: %w", day.Format("2006-01-02"), err)
+		}
+		messages = append(messages, dayMessages...)
+	}
+	
+	return messages, nil
+}
+
+// loadMessagesForDate reads by_date/YYYY-MM/YYYY-MM-DD.jsonl under dir
+func loadMessagesForDate(dir string, date time.Time) ([]*NormalizedMessage, error) {
 	yearMonth := date.Format("2006-01")
 	dateStr := date.Format("2006-01-02")
 	filePath := filepath.Join(dir, yearMonth, dateStr+".jsonl")
@@ -0,0 +1,282 @@
+package normalize
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLoadMessagesByDateRange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Messages across two months, with gaps between days
+	days := []time.Time{
+		time.Date(2024, 1, 30, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 30, 17, 30, 0, 0, time.UTC),
+		time.Date(2024, 2, 2, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 10, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+	}
+	for i, ts := range days {
+		msg := &NormalizedMessage{
+			ID:         "msg_test_" + ts.Format("20060102T1504"),
+			SourceType: "slack",
+			Timestamp:  ts,
+			Content:    "message " + string(rune('a'+i)),
+		}
+		if err := SaveNormalizedMessage(msg); err != nil {
+			t.Fatalf("failed to save message: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		to      time.Time
+		wantIDs []string
+	}{
+		{
+			name: "multi-month range with gaps",
+			from: time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+			wantIDs: []string{
+				"msg_test_20240130T0900", "msg_test_20240130T1730",
+				"msg_test_20240202T1200", "msg_test_20240210T0800",
+			},
+		},
+		{
+			name:    "partial days are inclusive",
+			from:    time.Date(2024, 2, 2, 23, 0, 0, 0, time.UTC),
+			to:      time.Date(2024, 2, 10, 1, 0, 0, 0, time.UTC),
+			wantIDs: []string{"msg_test_20240202T1200", "msg_test_20240210T0800"},
+		},
+		{
+			name:    "single day",
+			from:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantIDs: []string{"msg_test_20240301T0800"},
+		},
+		{
+			name:    "no messages in range",
+			from:    time.Date(2024, 2, 11, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			wantIDs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := LoadMessagesByDateRange(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("LoadMessagesByDateRange failed: %v", err)
+			}
+			if messages == nil {
+				t.Fatal("expected empty slice, got nil")
+			}
+			if len(messages) != len(tt.wantIDs) {
+				t.Fatalf("expected %d messages, got %d", len(tt.wantIDs), len(messages))
+			}
+			for i, msg := range messages {
+				if msg.ID != tt.wantIDs[i] {
+					t.Errorf("message %d: expected %s, got %s", i, tt.wantIDs[i], msg.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMessagesByDateRange_InvalidRange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := LoadMessagesByDateRange(from, from.AddDate(0, 0, -1)); err == nil {
+		t.Error("expected error when to is before from")
+	}
+}
+
+func TestSaveNormalizedMessage_RefetchChangesContent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	posted := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	alice := &User{ID: "user_slack_T1_U1", DisplayName: "alice"}
+	original := &NormalizedMessage{
+		ID:         "msg_slack_T1_C1_1.0",
+		SourceType: "slack",
+		SourceID:   "T1:C1:1.0",
+		Timestamp:  posted,
+		Author:     alice,
+		Content:    "Deploys fail",
+	}
+	if err := SaveNormalizedMessage(original); err != nil {
+		t.Fatalf("failed to save message: %v", err)
+	}
+
+	refetched := *original
+	refetched.Content = "Deploys fail on staging @bob"
+	refetched.Mentions = []string{"bob"}
+	refetched.Timestamp = posted.Add(time.Hour)
+	refetched.Author = &User{ID: "user_slack_T1_U1", DisplayName: "alice.smith"}
+	if err := SaveNormalizedMessage(&refetched); err != nil {
+		t.Fatalf("failed to save refetched message: %v", err)
+	}
+
+	got, err := LoadMessageByID(original.ID)
+	if err != nil {
+		t.Fatalf("failed to load message: %v", err)
+	}
+	if got.Content != refetched.Content || len(got.Mentions) != 1 {
+		t.Errorf("expected refetched content and mentions, got %q %v", got.Content, got.Mentions)
+	}
+	if !got.Timestamp.Equal(posted) {
+		t.Errorf("expected timestamp to stay %v, got %v", posted, got.Timestamp)
+	}
+	if got.Author == nil || got.Author.DisplayName != "alice.smith" {
+		t.Errorf("expected refreshed author profile, got %+v", got.Author)
+	}
+
+	// The merged message is indexed under its original date
+	messages, err := LoadMessagesByDate(posted)
+	if err != nil {
+		t.Fatalf("failed to load by date: %v", err)
+	}
+	if len(messages) == 0 || messages[len(messages)-1].Content != refetched.Content {
+		t.Errorf("expected refetched message in the %s index", posted.Format("2006-01-02"))
+	}
+}
+
+func TestSaveNormalizedMessage_RefetchChangesThread(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	reply := &NormalizedMessage{
+		ID:         "msg_slack_T1_C1_2.0",
+		SourceType: "slack",
+		SourceID:   "T1:C1:2.0",
+		Timestamp:  time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC),
+		Author:     &User{ID: "user_slack_T1_U2"},
+		Content:    "A reply",
+		ThreadID:   "thread_slack_T1_C1_1.0",
+		ParentID:   "msg_slack_T1_C1_1.0",
+	}
+	if err := SaveNormalizedMessage(reply); err != nil {
+		t.Fatalf("failed to save message: %v", err)
+	}
+
+	// Broadcast out of the thread, now a root of its own; the refetch also
+	// carries a different author, which must not replace the original
+	moved := *reply
+	moved.ThreadID = ""
+	moved.ParentID = ""
+	moved.IsThreadRoot = true
+	moved.Author = &User{ID: "user_slack_T1_U9"}
+	if err := SaveNormalizedMessage(&moved); err != nil {
+		t.Fatalf("failed to save refetched message: %v", err)
+	}
+
+	got, err := LoadMessageByID(reply.ID)
+	if err != nil {
+		t.Fatalf("failed to load message: %v", err)
+	}
+	if got.ThreadID != "" || got.ParentID != "" || !got.IsThreadRoot {
+		t.Errorf("expected message to leave the thread, got thread %q parent %q root %v", got.ThreadID, got.ParentID, got.IsThreadRoot)
+	}
+	if got.Author == nil || got.Author.ID != "user_slack_T1_U2" {
+		t.Errorf("expected original author to be kept, got %+v", got.Author)
+	}
+}
+
+func TestLoadMessagesByIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// More messages than loadWorkers, so the pool is saturated
+	var stored []string
+	for i := 0; i < 20; i++ {
+		msg := &NormalizedMessage{
+			ID:         "msg_test_" + string(rune('a'+i)),
+			SourceType: "slack",
+			Timestamp:  time.Date(2024, 5, 1, 9, i, 0, 0, time.UTC),
+			Content:    "message " + string(rune('a'+i)),
+		}
+		if err := SaveNormalizedMessage(msg); err != nil {
+			t.Fatalf("failed to save message: %v", err)
+		}
+		stored = append(stored, msg.ID)
+	}
+
+	tests := []struct {
+		name    string
+		ids     []string
+		wantIDs []string
+	}{
+		{
+			name:    "all present",
+			ids:     stored,
+			wantIDs: stored,
+		},
+		{
+			name:    "all missing",
+			ids:     []string{"msg_test_missing1", "msg_test_missing2"},
+			wantIDs: []string{},
+		},
+		{
+			name:    "mixed with duplicates",
+			ids:     []string{"msg_test_a", "msg_test_missing", "msg_test_c", "msg_test_a"},
+			wantIDs: []string{"msg_test_a", "msg_test_c"},
+		},
+		{
+			name:    "no IDs",
+			ids:     nil,
+			wantIDs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := LoadMessagesByIDs(tt.ids)
+			if err != nil {
+				t.Fatalf("LoadMessagesByIDs failed: %v", err)
+			}
+			if messages == nil {
+				t.Fatal("expected empty map, got nil")
+			}
+
+			gotIDs := make([]string, 0, len(messages))
+			for id, msg := range messages {
+				if msg.ID != id {
+					t.Errorf("message keyed %s has ID %s", id, msg.ID)
+				}
+				gotIDs = append(gotIDs, id)
+			}
+			sort.Strings(gotIDs)
+			if len(gotIDs) != len(tt.wantIDs) {
+				t.Fatalf("expected %v, got %v", tt.wantIDs, gotIDs)
+			}
+			for i := range gotIDs {
+				if gotIDs[i] != tt.wantIDs[i] {
+					t.Errorf("expected %v, got %v", tt.wantIDs, gotIDs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMessagesByIDs_CorruptFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	dir, err := MessagesByIDDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "msg_test_bad.json"), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadMessagesByIDs([]string{"msg_test_missing", "msg_test_bad"}); err == nil {
+		t.Error("expected error for unreadable message file")
+	}
+}
@@ -0,0 +1,54 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// taskItemPattern matches a task list item ("- [ ] todo", "1. [x] done"),
+// also inside block quotes; the submatch is the checkbox's mark
+var taskItemPattern = regexp.MustCompile(`^\s*(?:>\s*)*(?:[-*+]|\d+[.)])\s+\[([ xX])\](?:\s|$)`)
+
+// TaskProgress is the completion of the task list in a GitHub issue or pull
+// request body, stored in SourceMetadata["task_progress"]
+type TaskProgress struct {
+	Done  int     `json:"done"`
+	Total int     `json:"total"`
+	Ratio float64 `json:"ratio"` // Done / Total
+}
+
+// ExtractTaskProgress counts the checked and unchecked task list items in a
+// Markdown body, or returns nil if it has none. Items in fenced code blocks
+// don't count.
+func ExtractTaskProgress(body string) *TaskProgress {
+	progress := &TaskProgress{}
+	fence := ""
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		match := taskItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		progress.Total++
+		if match[1] != " " {
+			progress.Done++
+		}
+	}
+
+	if progress.Total == 0 {
+		return nil
+	}
+	progress.Ratio = float64(progress.Done) / float64(progress.Total)
+	return progress
+}
@@ -0,0 +1,33 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTaskProgress(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *TaskProgress
+	}{
+		{"no task list", "Deploys fail on staging.\n\n- step one\n- step two", nil},
+		{"empty body", "", nil},
+		{"none done", "- [ ] write docs\n- [ ] add tests", &TaskProgress{Done: 0, Total: 2, Ratio: 0}},
+		{"some done", "Plan:\n- [x] design\n- [X] build\n* [ ] test\n+ [ ] ship", &TaskProgress{Done: 2, Total: 4, Ratio: 0.5}},
+		{"all done", "- [x] one\n- [x] two\n- [x] three", &TaskProgress{Done: 3, Total: 3, Ratio: 1}},
+		{"numbered and nested", "1. [x] parent\n   - [ ] child\n2) [x] sibling", &TaskProgress{Done: 2, Total: 3, Ratio: 2.0 / 3}},
+		{"quoted", "> - [x] from the original issue\n- [ ] follow up", &TaskProgress{Done: 1, Total: 2, Ratio: 0.5}},
+		{"empty item", "- [x]\n- [ ] todo", &TaskProgress{Done: 1, Total: 2, Ratio: 0.5}},
+		{"not checkboxes", "- [link](https://example.com)\n- [y] maybe\n-[x] no space\nsee [x] here", nil},
+		{"in code blocks", "```\n- [x] example\n```\n~~~md\n- [ ] example\n~~~\n- [ ] real", &TaskProgress{Done: 0, Total: 1, Ratio: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractTaskProgress(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractTaskProgress(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxThreadTitleLength is the longest title DeriveThreadTitle returns, in characters
+const MaxThreadTitleLength = 80
+
+var (
+	// Fenced code blocks, which make poor titles
+	titleFencedCodePattern = regexp.MustCompile("(?s)```.*?```|~~~.*?~~~")
+	// Inline code, whose backticks are dropped but whose text is kept
+	titleInlineCodePattern = regexp.MustCompile("`([^`\n]*)`")
+	// End of the first sentence: terminal punctuation followed by whitespace
+	sentenceEndPattern = regexp.MustCompile(`[.!?](\s|$)`)
+)
+
+// DeriveThreadTitle produces a short title for a thread from its root message,
+// for sources like Slack whose threads have no title of their own. It uses the
+// root's first sentence with code blocks removed and whitespace collapsed, truncated
+// at a word boundary to MaxThreadTitleLength. A root that is only code falls
+// back to the first line of its first code block.
+func DeriveThreadTitle(root *NormalizedMessage) string {
+	if root == nil {
+		return ""
+	}
+
+	text := titleFencedCodePattern.ReplaceAllString(root.Content, "\n")
+	text = titleInlineCodePattern.ReplaceAllString(text, "$1")
+
+	title := firstSentence(text)
+	if title == "" {
+		title = firstCodeLine(root)
+	}
+
+	return truncateTitle(title)
+}
+
+// firstSentence returns the first sentence or line of text with whitespace collapsed.
+// Questions and exclamations keep their punctuation; a trailing period is dropped.
+func firstSentence(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if loc := sentenceEndPattern.FindStringIndex(line); loc != nil {
+			line = line[:loc[0]+1]
+		}
+		return strings.TrimSuffix(line, ".")
+	}
+	return ""
+}
+
+// firstCodeLine returns the first non-empty line of the message's code
+func firstCodeLine(msg *NormalizedMessage) string {
+	blocks := msg.CodeBlocks
+	if len(blocks) == 0 {
+		blocks = ExtractCodeBlocks(msg.Content)
+	}
+	for _, block := range blocks {
+		for _, line := range strings.Split(block.Code, "\n") {
+			if line = strings.Join(strings.Fields(line), " "); line != "" {
+				return line
+			}
+		}
+	}
+	return ""
+}
+
+// truncateTitle shortens title to MaxThreadTitleLength, breaking between words
+// where possible and marking the cut with an ellipsis
+func truncateTitle(title string) string {
+	if utf8.RuneCountInString(title) <= MaxThreadTitleLength {
+		return title
+	}
+
+	runes := []rune(title)
+	cut := string(runes[:MaxThreadTitleLength-1])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
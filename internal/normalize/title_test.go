@@ -0,0 +1,107 @@
+package normalize
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestDeriveThreadTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "short root",
+			content:  "Deploys are failing",
+			expected: "Deploys are failing",
+		},
+		{
+			name:     "first sentence only",
+			content:  "Deploys are failing on staging. I tried restarting the runner but nothing changed.",
+			expected: "Deploys are failing on staging",
+		},
+		{
+			name:     "question keeps its mark",
+			content:  "How do I rotate the API key? The docs are out of date.",
+			expected: "How do I rotate the API key?",
+		},
+		{
+			name:     "first non-empty line",
+			content:  "\n\n  Build broken   on main\nsee logs below",
+			expected: "Build broken on main",
+		},
+		{
+			name:     "long root truncated at a word boundary",
+			content:  "When I run the migration against the production replica it hangs for several minutes and then the connection pool is exhausted",
+			expected: "When I run the migration against the production replica it hangs for several…",
+		},
+		{
+			name:     "inline code unquoted",
+			content:  "Why does `make test` hang on CI?",
+			expected: "Why does make test hang on CI?",
+		},
+		{
+			name:     "code block skipped",
+			content:  "```\npanic: nil map\n```\nAnyone seen this panic before?",
+			expected: "Anyone seen this panic before?",
+		},
+		{
+			name:     "code-only root uses first code line",
+			content:  "```\n\n  kubectl get pods -n   staging\nkubectl logs\n```",
+			expected: "kubectl get pods -n staging",
+		},
+		{
+			name:     "empty root",
+			content:  "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title := DeriveThreadTitle(&NormalizedMessage{Content: tt.content})
+			if title != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, title)
+			}
+			if utf8.RuneCountInString(title) > MaxThreadTitleLength {
+				t.Errorf("title exceeds %d characters: %q", MaxThreadTitleLength, title)
+			}
+		})
+	}
+
+	if title := DeriveThreadTitle(nil); title != "" {
+		t.Errorf("expected empty title for nil root, got %q", title)
+	}
+}
+
+func TestDeriveThreadTitle_LongWord(t *testing.T) {
+	title := DeriveThreadTitle(&NormalizedMessage{Content: strings.Repeat("a", 200)})
+	if utf8.RuneCountInString(title) != MaxThreadTitleLength || !strings.HasSuffix(title, "…") {
+		t.Errorf("expected %d-character title ending in an ellipsis, got %q", MaxThreadTitleLength, title)
+	}
+}
+
+func TestSlackToNormalized_ThreadTitle(t *testing.T) {
+	channel := &SlackChannel{ID: "C123", Name: "general", IsChannel: true}
+
+	root := &SlackMessage{Type: "message", Text: "Staging is down. Looking into it.", Timestamp: "1234567890.000100", ThreadTS: "1234567890.000100"}
+	normalized, err := SlackToNormalized(root, channel, nil, "T123", time.Now())
+	if err != nil {
+		t.Fatalf("failed to normalize root: %v", err)
+	}
+	if got := normalized.SourceMetadata["thread_title"]; got != "Staging is down" {
+		t.Errorf("expected thread_title %q, got %v", "Staging is down", got)
+	}
+
+	reply := &SlackMessage{Type: "message", Text: "Fixed now.", Timestamp: "1234567890.000200", ThreadTS: "1234567890.000100"}
+	normalized, err = SlackToNormalized(reply, channel, nil, "T123", time.Now())
+	if err != nil {
+		t.Fatalf("failed to normalize reply: %v", err)
+	}
+	if _, ok := normalized.SourceMetadata["thread_title"]; ok {
+		t.Error("expected no thread_title on a reply")
+	}
+}
@@ -0,0 +1,145 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultHistoryPageSize is the number of messages requested per conversations.history page
+const DefaultHistoryPageSize = 200
+
+// HistoryPage is one page of a channel's history, newest message first
+type HistoryPage struct {
+	Messages []Message
+	HasMore  bool // Older messages remain before this page
+}
+
+// HistoryPager fetches pages of channel history older than latest
+type HistoryPager interface {
+	FetchHistoryPage(ctx context.Context, channelID, latest string, limit int) (*HistoryPage, error)
+}
+
+// FetchHistoryPage retrieves up to limit messages posted before latest
+// (exclusive). An empty latest starts from the newest message.
+func (c *Client) FetchHistoryPage(ctx context.Context, channelID, latest string, limit int) (*HistoryPage, error) {
+	params := map[string]string{
+		"channel": channelID,
+		"limit":   strconv.Itoa(limit),
+	}
+	if latest != "" {
+		params["latest"] = latest
+	}
+
+	bs, err := c.client.API(ctx, "GET", "conversations.history", params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+
+	var response struct {
+		OK       bool      `json:"ok"`
+		Messages []Message `json:"messages"`
+		HasMore  bool      `json:"has_more"`
+		Error    string    `json:"error"`
+	}
+
+	if err := json.Unmarshal(bs, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse history: %w", err)
+	}
+
+	if !response.OK {
+		return nil, &APIError{Code: response.Error}
+	}
+
+	return &HistoryPage{Messages: response.Messages, HasMore: response.HasMore}, nil
+}
+
+// BackfillCursor is how far back a channel's history has been fetched
+type BackfillCursor struct {
+	OldestTS string // Oldest message fetched; the next page ends before it
+	Complete bool   // The start of the channel's history was reached
+}
+
+// BackfillOptions controls a backfill run
+type BackfillOptions struct {
+	PageSize    int // Messages per page (default DefaultHistoryPageSize)
+	MaxMessages int // Stop after this many messages; 0 means no limit
+}
+
+// Backfill walks a channel's history backward from cursor one page at a time.
+// Each page is passed to handle along with the cursor that includes it; handle
+// should store the messages and then persist the cursor, so an interrupted
+// backfill resumes after the last page that was handled. Backfill returns the
+// cursor of the last handled page.
+func Backfill(ctx context.Context, pager HistoryPager, channelID string, cursor BackfillCursor, opts BackfillOptions, handle func(messages []Message, cursor BackfillCursor) error) (BackfillCursor, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultHistoryPageSize
+	}
+
+	fetched := 0
+	for !cursor.Complete {
+		limit := pageSize
+		if opts.MaxMessages > 0 {
+			remaining := opts.MaxMessages - fetched
+			if remaining <= 0 {
+				break
+			}
+			if remaining < limit {
+				limit = remaining
+			}
+		}
+
+		page, err := pager.FetchHistoryPage(ctx, channelID, cursor.OldestTS, limit)
+		if err != nil {
+			return cursor, err
+		}
+
+		next := BackfillCursor{OldestTS: cursor.OldestTS, Complete: !page.HasMore || len(page.Messages) == 0}
+		for _, msg := range page.Messages {
+			if next.OldestTS == "" || CompareTimestamps(msg.Timestamp, next.OldestTS) < 0 {
+				next.OldestTS = msg.Timestamp
+			}
+		}
+
+		if err := handle(page.Messages, next); err != nil {
+			return cursor, err
+		}
+
+		cursor = next
+		fetched += len(page.Messages)
+	}
+
+	return cursor, nil
+}
+
+// CompareTimestamps compares two Slack timestamps ("1234567890.123456"),
+// returning -1, 0, or 1. Timestamps are compared numerically, not as floats,
+// so microseconds are never rounded away.
+func CompareTimestamps(a, b string) int {
+	aSec, aMicro := splitTimestamp(a)
+	bSec, bMicro := splitTimestamp(b)
+	switch {
+	case aSec < bSec:
+		return -1
+	case aSec > bSec:
+		return 1
+	case aMicro < bMicro:
+		return -1
+	case aMicro > bMicro:
+		return 1
+	}
+	return 0
+}
+
+// splitTimestamp parses the seconds and microseconds of a Slack timestamp
+func splitTimestamp(ts string) (int64, int64) {
+	secPart, microPart, _ := strings.Cut(ts, ".")
+	sec, _ := strconv.ParseInt(secPart, 10, 64)
+	// Pad or trim the fraction to six digits
+	microPart = (microPart + "000000")[:6]
+	micro, _ := strconv.ParseInt(microPart, 10, 64)
+	return sec, micro
+}
@@ -0,0 +1,203 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakePager serves a channel's history, newest first, like conversations.history
+type fakePager struct {
+	history []Message // newest first
+	calls   []string  // latest value of each call
+	failAt  int       // fail the call with this index (1-based); 0 never fails
+}
+
+func (p *fakePager) FetchHistoryPage(ctx context.Context, channelID, latest string, limit int) (*HistoryPage, error) {
+	p.calls = append(p.calls, latest)
+	if p.failAt == len(p.calls) {
+		return nil, errors.New("rate limited")
+	}
+
+	var older []Message
+	for _, msg := range p.history {
+		if latest == "" || CompareTimestamps(msg.Timestamp, latest) < 0 {
+			older = append(older, msg)
+		}
+	}
+	if len(older) > limit {
+		return &HistoryPage{Messages: older[:limit], HasMore: true}, nil
+	}
+	return &HistoryPage{Messages: older}, nil
+}
+
+// testHistory returns n messages, newest first, with timestamps n..1
+func testHistory(n int) []Message {
+	history := make([]Message, n)
+	for i := range history {
+		history[i] = Message{Type: "message", Text: fmt.Sprintf("message %d", n-i), Timestamp: testTS(n - i)}
+	}
+	return history
+}
+
+func testTS(i int) string {
+	return fmt.Sprintf("17000000%02d.000100", i)
+}
+
+func TestBackfill_AdvancesCursor(t *testing.T) {
+	pager := &fakePager{history: testHistory(5)}
+
+	var cursors []BackfillCursor
+	var seen []string
+	final, err := Backfill(context.Background(), pager, "C1", BackfillCursor{}, BackfillOptions{PageSize: 2},
+		func(messages []Message, cursor BackfillCursor) error {
+			for _, msg := range messages {
+				seen = append(seen, msg.Timestamp)
+			}
+			cursors = append(cursors, cursor)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+
+	wantCursors := []BackfillCursor{
+		{OldestTS: testTS(4)},
+		{OldestTS: testTS(2)},
+		{OldestTS: testTS(1), Complete: true},
+	}
+	if fmt.Sprint(cursors) != fmt.Sprint(wantCursors) {
+		t.Errorf("expected cursors %v, got %v", wantCursors, cursors)
+	}
+	if final != wantCursors[len(wantCursors)-1] {
+		t.Errorf("expected final cursor %v, got %v", wantCursors[len(wantCursors)-1], final)
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected all 5 messages, got %v", seen)
+	}
+
+	wantCalls := []string{"", testTS(4), testTS(2)}
+	if fmt.Sprint(pager.calls) != fmt.Sprint(wantCalls) {
+		t.Errorf("expected latest values %v, got %v", wantCalls, pager.calls)
+	}
+}
+
+func TestBackfill_Resume(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstRun  BackfillOptions
+		failAt    int
+		wantFirst BackfillCursor
+	}{
+		{
+			name:      "stopped by message limit",
+			firstRun:  BackfillOptions{PageSize: 2, MaxMessages: 3},
+			wantFirst: BackfillCursor{OldestTS: testTS(5)},
+		},
+		{
+			name:      "interrupted by error",
+			firstRun:  BackfillOptions{PageSize: 2},
+			failAt:    3,
+			wantFirst: BackfillCursor{OldestTS: testTS(4)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := testHistory(7)
+			stored := make(map[string]bool)
+			store := func(messages []Message, cursor BackfillCursor) error {
+				for _, msg := range messages {
+					stored[msg.Timestamp] = true
+				}
+				return nil
+			}
+
+			pager := &fakePager{history: history, failAt: tt.failAt}
+			cursor, err := Backfill(context.Background(), pager, "C1", BackfillCursor{}, tt.firstRun, store)
+			if tt.failAt > 0 && err == nil {
+				t.Fatal("expected error from interrupted backfill")
+			}
+			if tt.failAt == 0 && err != nil {
+				t.Fatalf("Backfill failed: %v", err)
+			}
+			if cursor != tt.wantFirst {
+				t.Fatalf("expected cursor %v after first run, got %v", tt.wantFirst, cursor)
+			}
+
+			// A second run picks up where the first stopped
+			pager = &fakePager{history: history}
+			cursor, err = Backfill(context.Background(), pager, "C1", cursor, BackfillOptions{PageSize: 2}, store)
+			if err != nil {
+				t.Fatalf("resumed Backfill failed: %v", err)
+			}
+			if pager.calls[0] != tt.wantFirst.OldestTS {
+				t.Errorf("expected resume before %s, got latest=%q", tt.wantFirst.OldestTS, pager.calls[0])
+			}
+			if !cursor.Complete || cursor.OldestTS != testTS(1) {
+				t.Errorf("expected complete cursor at %s, got %v", testTS(1), cursor)
+			}
+			if len(stored) != len(history) {
+				t.Errorf("expected all %d messages stored, got %d", len(history), len(stored))
+			}
+		})
+	}
+}
+
+func TestBackfill_CompleteCursorFetchesNothing(t *testing.T) {
+	pager := &fakePager{history: testHistory(3)}
+	cursor := BackfillCursor{OldestTS: testTS(1), Complete: true}
+
+	got, err := Backfill(context.Background(), pager, "C1", cursor, BackfillOptions{}, func([]Message, BackfillCursor) error {
+		t.Error("handle should not be called")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if got != cursor || len(pager.calls) != 0 {
+		t.Errorf("expected no fetches for a complete cursor, got %v after %d calls", got, len(pager.calls))
+	}
+}
+
+func TestBackfill_HandleErrorKeepsCursor(t *testing.T) {
+	pager := &fakePager{history: testHistory(4)}
+
+	calls := 0
+	cursor, err := Backfill(context.Background(), pager, "C1", BackfillCursor{}, BackfillOptions{PageSize: 2},
+		func([]Message, BackfillCursor) error {
+			calls++
+			if calls == 2 {
+				return errors.New("disk full")
+			}
+			return nil
+		})
+	if err == nil {
+		t.Fatal("expected handle error to be returned")
+	}
+	// The failed page must be fetched again on resume
+	if cursor != (BackfillCursor{OldestTS: testTS(3)}) {
+		t.Errorf("expected cursor from the last handled page, got %v", cursor)
+	}
+}
+
+func TestCompareTimestamps(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1700000000.000100", "1700000000.000100", 0},
+		{"1700000000.000100", "1700000000.000200", -1},
+		{"1700000001.000000", "1700000000.999999", 1},
+		{"999999999.000000", "1000000000.000000", -1},
+		{"1700000000.1", "1700000000.000100", 1},
+		{"1700000000", "1700000000.000000", 0},
+	}
+
+	for _, tt := range tests {
+		if got := CompareTimestamps(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareTimestamps(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -9,11 +9,12 @@ import (
 	"time"
 
 	"github.com/rneatherway/slack"
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
 // Client wraps the Slack API client
 type Client struct {
-	client *slack.Client
+	client apiCaller
 	teamID string
 }
 
@@ -58,7 +59,7 @@ func Authenticate(team string) (*AuthResult, error) {
 	}
 
 	if !authResponse.OK {
-		return nil, fmt.Errorf("Slack API returned error: %s", authResponse.Error)
+		return nil, &APIError{Code: authResponse.Error}
 	}
 
 	return &AuthResult{
@@ -81,6 +82,9 @@ type SearchResult struct {
 	Timestamp string `json:"ts"`
 	ThreadTS  string `json:"thread_ts,omitempty"`
 	Permalink string `json:"permalink"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks    []map[string]interface{} `json:"blocks,omitempty"`
+	Edited    *Edited `json:"edited,omitempty"`
 }
 
 // SearchResponse represents the response from search.messages
@@ -114,7 +118,7 @@ func (c *Client) SearchMessages(ctx context.Context, query string, count int) (*
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, &APIError{Code: response.Error}
 	}
 
 	return &response, nil
@@ -128,6 +132,9 @@ type ThreadMessage struct {
 	Timestamp string `json:"ts"`
 	ThreadTS  string `json:"thread_ts,omitempty"`
 	ParentUserID string `json:"parent_user_id,omitempty"`
+	Edited    *Edited `json:"edited,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks    []map[string]interface{} `json:"blocks,omitempty"`
 }
 
 // GetThreadReplies fetches all replies in a thread
@@ -154,7 +161,7 @@ func (c *Client) GetThreadReplies(ctx context.Context, channelID, threadTS strin
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, &APIError{Code: response.Error}
 	}
 
 	return response.Messages, nil
@@ -182,7 +189,7 @@ func (c *Client) GetUserInfo(ctx context.Context, userID string) (*UserInfo, err
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, &APIError{Code: response.Error}
 	}
 
 	return &response.User, nil
@@ -216,6 +223,32 @@ type Message struct {
 	Text      string `json:"text"`
 	Timestamp string `json:"ts"`
 	ThreadTS  string `json:"thread_ts,omitempty"`
+	Edited    *Edited `json:"edited,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks    []map[string]interface{} `json:"blocks,omitempty"` // Block Kit blocks, for their images and files
+}
+
+// Attachment is a message attachment: a link preview, or a message shared
+// from elsewhere (is_share) or unfurled from its permalink (is_msg_unfurl)
+type Attachment struct {
+	IsShare     bool        `json:"is_share,omitempty"`
+	IsMsgUnfurl bool        `json:"is_msg_unfurl,omitempty"`
+	FromURL     string      `json:"from_url,omitempty"`
+	ChannelID   string      `json:"channel_id,omitempty"`
+	ChannelName string      `json:"channel_name,omitempty"`
+	TS          json.Number `json:"ts,omitempty"`
+	AuthorID    string      `json:"author_id,omitempty"`
+	AuthorName  string      `json:"author_name,omitempty"`
+	Text        string      `json:"text,omitempty"`
+	Fallback    string      `json:"fallback,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	TitleLink   string      `json:"title_link,omitempty"`
+}
+
+// Edited records the last edit of a message: who made it and when
+type Edited struct {
+	User      string `json:"user"`
+	Timestamp string `json:"ts"`
 }
 
 // ListChannels fetches all channels the user is a member of
@@ -239,7 +272,7 @@ func (c *Client) ListChannels(ctx context.Context) ([]Channel, error) {
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, &APIError{Code: response.Error}
 	}
 
 	// Filter to only channels the user is a member of
@@ -253,6 +286,32 @@ func (c *Client) ListChannels(ctx context.Context) ([]Channel, error) {
 	return memberChannels, nil
 }
 
+// GetChannelInfo fetches a channel by ID, without listing every channel
+func (c *Client) GetChannelInfo(ctx context.Context, channelID string) (*Channel, error) {
+	bs, err := c.client.API(ctx, "GET", "conversations.info", map[string]string{
+		"channel": channelID,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel info: %w", err)
+	}
+
+	var response struct {
+		OK      bool    `json:"ok"`
+		Channel Channel `json:"channel"`
+		Error   string  `json:"error"`
+	}
+
+	if err := json.Unmarshal(bs, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse channel info: %w", err)
+	}
+
+	if !response.OK {
+		return nil, &APIError{Code: response.Error}
+	}
+
+	return &response.Channel, nil
+}
+
 // GetMessages implements cache-aside pattern for message retrieval
 // Checks cache first, fetches from API on miss, and stores in cache
 func (c *Client) GetMessages(ctx context.Context, channelID string, oldest time.Time, cacheDir string) ([]Message, error) {
@@ -309,7 +368,7 @@ func (c *Client) FetchMessages(ctx context.Context, channelID string, oldest tim
 	}
 	
 	if !oldest.IsZero() {
-		params["oldest"] = fmt.Sprintf("%d.000000", oldest.Unix())
+		params["oldest"] = fmt.Sprintf("%d.%06d", oldest.Unix(), oldest.Nanosecond()/1000)
 	}
 
 	bs, err := c.client.API(ctx, "GET", "conversations.history", params, nil)
@@ -328,7 +387,7 @@ func (c *Client) FetchMessages(ctx context.Context, channelID string, oldest tim
 	}
 
 	if !response.OK {
-		return nil, fmt.Errorf("Slack API error: %s", response.Error)
+		return nil, &APIError{Code: response.Error}
 	}
 
 	return response.Messages, nil
@@ -342,7 +401,7 @@ func loadMessagesFromCache(teamID, channelID string, since time.Time) (*messageC
 	}
 	
 	msgDir := filepath.Join(home, ".threadmine", "raw", "slack", "workspaces", teamID, "channels", channelID, "messages")
-	date := time.Now().Format("2006-01-02")
+	date := clock.Now().Format("2006-01-02")
 	filePath := filepath.Join(msgDir, fmt.Sprintf("%s.json", date))
 
 	// Check if file exists
@@ -388,14 +447,14 @@ func saveMessagesToCache(teamID, channelID string, messages []interface{}) error
 	}
 
 	// Use today's date for the filename
-	date := time.Now().Format("2006-01-02")
+	date := clock.Now().Format("2006-01-02")
 	filePath := filepath.Join(msgDir, fmt.Sprintf("%s.json", date))
 
 	cache := messageCache{
 		TeamID:    teamID,
 		ChannelID: channelID,
 		Date:      date,
-		FetchedAt: time.Now(),
+		FetchedAt: clock.Now(),
 		Messages:  messages,
 	}
 
@@ -434,15 +493,15 @@ func formatAuthError(err error) error {
 	
 	// Check for common error patterns and provide helpful guidance
 	if contains(errMsg, "no Slack cookie database found") || contains(errMsg, "could not access Slack cookie database") {
-		return fmt.Errorf("Slack cookie database not found. Are you logged into the Slack desktop app?\n  Original error: %v", err)
+		return fmt.Errorf("%w: Slack cookie database not found. Are you logged into the Slack desktop app?\n  Original error: %v", ErrNotAuthenticated, err)
 	}
 	
 	if contains(errMsg, "no matching unlocked items found") {
-		return fmt.Errorf("Slack cookie not found in keychain. Try logging out and back into the Slack desktop app.\n  Original error: %v", err)
+		return fmt.Errorf("%w: Slack cookie not found in keychain. Try logging out and back into the Slack desktop app.\n  Original error: %v", ErrNotAuthenticated, err)
 	}
 	
 	if contains(errMsg, "failed to get cookie password") {
-		return fmt.Errorf("could not retrieve Slack cookie password from keychain. Check that the Slack app has keychain access.\n  Original error: %v", err)
+		return fmt.Errorf("%w: could not retrieve Slack cookie password from keychain. Check that the Slack app has keychain access.\n  Original error: %v", ErrNotAuthenticated, err)
 	}
 	
 	if contains(errMsg, "status code") {
@@ -450,7 +509,7 @@ func formatAuthError(err error) error {
 	}
 	
 	// Default: return the original error with context
-	return fmt.Errorf("Slack authentication failed: %w", err)
+	return fmt.Errorf("%w: %w", ErrNotAuthenticated, err)
 }
 
 // contains checks if a string contains a substring (case-sensitive)
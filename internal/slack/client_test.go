@@ -0,0 +1,46 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// recordingAPI answers every Slack API call with body and records the params
+// of the last call
+type recordingAPI struct {
+	body   string
+	params map[string]string
+}
+
+func (r *recordingAPI) API(ctx context.Context, verb, path string, params map[string]string, body []byte) ([]byte, error) {
+	r.params = params
+	return []byte(r.body), nil
+}
+
+func TestFetchMessages_Oldest(t *testing.T) {
+	tests := []struct {
+		name   string
+		oldest time.Time
+		want   string
+	}{
+		{"start of day", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "1705276800.000000"},
+		{"within the day", time.Date(2024, 1, 15, 15, 4, 5, 0, time.UTC), "1705331045.000000"},
+		{"fraction of a second", time.Date(2024, 1, 15, 15, 4, 5, 123456789, time.UTC), "1705331045.123456"},
+		{"none", time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &recordingAPI{body: `{"ok": true, "messages": []}`}
+			c := &Client{client: api}
+
+			if _, err := c.FetchMessages(context.Background(), "C1", tt.oldest); err != nil {
+				t.Fatalf("FetchMessages failed: %v", err)
+			}
+			if got := api.params["oldest"]; got != tt.want {
+				t.Errorf("oldest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
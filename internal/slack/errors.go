@@ -0,0 +1,40 @@
+package slack
+
+import (
+	"context"
+	"errors"
+)
+
+// Errors returned (wrapped) by the client so callers can branch with errors.Is
+var (
+	ErrNotAuthenticated = errors.New("Slack is not authenticated")
+	ErrRateLimited      = errors.New("Slack API rate limit exceeded")
+	ErrNotFound         = errors.New("Slack resource not found")
+)
+
+// APIError is an error response ("ok": false) from the Slack Web API
+type APIError struct {
+	Code string // Slack's error code, e.g. "channel_not_found"
+}
+
+func (e *APIError) Error() string {
+	return "Slack API error: " + e.Code
+}
+
+// Unwrap maps Slack's error codes onto the package's sentinel errors
+func (e *APIError) Unwrap() error {
+	switch e.Code {
+	case "not_authed", "invalid_auth", "token_revoked", "token_expired", "account_inactive":
+		return ErrNotAuthenticated
+	case "ratelimited", "rate_limited":
+		return ErrRateLimited
+	case "channel_not_found", "thread_not_found", "message_not_found", "user_not_found":
+		return ErrNotFound
+	}
+	return nil
+}
+
+// apiCaller is the subset of the underlying Slack client used here; tests stub it
+type apiCaller interface {
+	API(ctx context.Context, verb, path string, params map[string]string, body []byte) ([]byte, error)
+}
@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stubAPI answers every Slack API call with the same response body
+type stubAPI struct {
+	body string
+	err  error
+}
+
+func (s stubAPI) API(ctx context.Context, verb, path string, params map[string]string, body []byte) ([]byte, error) {
+	return []byte(s.body), s.err
+}
+
+func TestClientErrors(t *testing.T) {
+	calls := map[string]func(*Client) error{
+		"SearchMessages": func(c *Client) error {
+			_, err := c.SearchMessages(context.Background(), "q", 10)
+			return err
+		},
+		"GetThreadReplies": func(c *Client) error {
+			_, err := c.GetThreadReplies(context.Background(), "C1", "1.0")
+			return err
+		},
+		"GetUserInfo": func(c *Client) error {
+			_, err := c.GetUserInfo(context.Background(), "U1")
+			return err
+		},
+		"ListChannels": func(c *Client) error {
+			_, err := c.ListChannels(context.Background())
+			return err
+		},
+		"FetchMessages": func(c *Client) error {
+			_, err := c.FetchMessages(context.Background(), "C1", time.Time{})
+			return err
+		},
+		"FetchHistoryPage": func(c *Client) error {
+			_, err := c.FetchHistoryPage(context.Background(), "C1", "", 10)
+			return err
+		},
+	}
+
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"not_authed", ErrNotAuthenticated},
+		{"invalid_auth", ErrNotAuthenticated},
+		{"token_revoked", ErrNotAuthenticated},
+		{"ratelimited", ErrRateLimited},
+		{"channel_not_found", ErrNotFound},
+		{"thread_not_found", ErrNotFound},
+		{"user_not_found", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		for name, call := range calls {
+			t.Run(tt.code+"/"+name, func(t *testing.T) {
+				c := &Client{client: stubAPI{body: fmt.Sprintf(`{"ok":false,"error":%q}`, tt.code)}}
+
+				err := call(c)
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("errors.Is(%v, %v) = false", err, tt.want)
+				}
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+					t.Errorf("expected *APIError with code %q, got %v", tt.code, err)
+				}
+			})
+		}
+	}
+}
+
+func TestAPIError_Unclassified(t *testing.T) {
+	err := error(&APIError{Code: "missing_scope"})
+	for _, sentinel := range []error{ErrNotAuthenticated, ErrRateLimited, ErrNotFound} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(%v, %v) = true, want false", err, sentinel)
+		}
+	}
+	if err.Error() != "Slack API error: missing_scope" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestFormatAuthError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("no Slack cookie database found"), true},
+		{errors.New("no matching unlocked items found"), true},
+		{errors.New("failed to get cookie password"), true},
+		{errors.New("something else"), true},
+		{errors.New("unexpected status code 502"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if got := errors.Is(formatAuthError(tt.err), ErrNotAuthenticated); got != tt.want {
+				t.Errorf("errors.Is(ErrNotAuthenticated) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+)
+
+// DBStore is a Store backed by the SQLite database
+type DBStore struct {
+	db *db.DB
+}
+
+// NewDBStore returns a Store that reads and writes database
+func NewDBStore(database *db.DB) *DBStore {
+	return &DBStore{db: database}
+}
+
+// SaveMessage saves a message, merging it into any stored copy
+func (s *DBStore) SaveMessage(msg *db.Message) error {
+	return s.db.SaveMessage(msg)
+}
+
+// LoadMessage retrieves a message by ID
+func (s *DBStore) LoadMessage(id string) (*db.Message, error) {
+	return s.db.GetMessage(id)
+}
+
+// SelectMessages queries messages with filters, newest first
+func (s *DBStore) SelectMessages(opts db.SelectMessagesOptions) ([]*db.Message, error) {
+	return s.db.SelectMessages(opts)
+}
+
+// CountMessages counts messages matching the filters, grouped by a db.CountBy* dimension
+func (s *DBStore) CountMessages(opts db.SelectMessagesOptions, groupBy string) ([]db.MessageCount, error) {
+	return s.db.CountMessages(opts, groupBy)
+}
+
+// SelectThreads returns the threads with messages matching the filters, one per thread
+func (s *DBStore) SelectThreads(opts db.SelectMessagesOptions) ([]*db.ThreadMatch, error) {
+	return s.db.SelectThreads(opts)
+}
+
+// ChannelActivity returns the activity of each channel with messages matching the filters
+func (s *DBStore) ChannelActivity(opts db.SelectMessagesOptions) ([]*db.ChannelActivity, error) {
+	return s.db.ChannelActivity(opts)
+}
+
+// ResponseTimes returns how quickly the threads of each channel with roots matching the filters get a first response
+func (s *DBStore) ResponseTimes(opts db.SelectMessagesOptions) ([]*db.ChannelResponseTimes, error) {
+	return s.db.ResponseTimes(opts)
+}
+
+// SaveEnrichment saves or updates a message's enrichment
+func (s *DBStore) SaveEnrichment(enrich *db.Enrichment) error {
+	return s.db.SaveEnrichment(enrich)
+}
+
+// LoadEnrichment retrieves a message's enrichment
+func (s *DBStore) LoadEnrichment(messageID string) (*db.Enrichment, error) {
+	enrich, err := s.db.GetEnrichment(messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return enrich, err
+}
+
+// SaveReplyGraph does nothing: the database derives threads from the messages
+// it stores, and keeps their summaries (see db.SaveThread)
+func (s *DBStore) SaveReplyGraph(g *graph.ReplyGraph) error {
+	return nil
+}
@@ -0,0 +1,639 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// FSStore is a Store that keeps messages in the normalized storage of the
+// normalize package (~/.threadmine/normalized/messages/by_id, by_date, and
+// by_source), enrichments as one JSON file per message beside it
+// (~/.threadmine/normalized/enrichments/<message_id>.json), and the reply
+// graph where the graph package saves it (~/.threadmine/graph).
+//
+// SelectMessages scans every message, so it suits small or exported datasets
+// better than the database does. Search matches words and quoted phrases
+// case-insensitively, unless CaseSensitive is set; FTS5 boolean operators, the assignee filter, and
+// metadata filters (which read raw source data) require the db store.
+type FSStore struct{}
+
+// NewFSStore returns a Store backed by the normalized storage
+func NewFSStore() *FSStore {
+	return &FSStore{}
+}
+
+// SaveMessage saves a message to every normalized index, merging it into any
+// stored copy according to the database's merge policy (see
+// db.MergeRefetchedMessage)
+func (s *FSStore) SaveMessage(msg *db.Message) error {
+	existing, err := s.LoadMessage(msg.ID)
+	if err != nil {
+		return err
+	}
+	if err := normalize.SaveNormalizedMessage(toNormalized(db.MergeRefetchedMessage(existing, msg))); err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+	return nil
+}
+
+// LoadMessage retrieves a message by ID
+func (s *FSStore) LoadMessage(id string) (*db.Message, error) {
+	path, err := messagePath(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	msg, err := normalize.LoadMessageByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load message: %w", err)
+	}
+	return fromNormalized(msg), nil
+}
+
+// SelectMessages queries messages with filters, newest first
+func (s *FSStore) SelectMessages(opts db.SelectMessagesOptions) ([]*db.Message, error) {
+	if opts.AssigneeID != nil {
+		return nil, fmt.Errorf("the assignee filter is not supported by the %s store", BackendFS)
+	}
+	if opts.ThreadState != "" {
+		return nil, fmt.Errorf("the thread state filter is not supported by the %s store", BackendFS)
+	}
+	if opts.PRState != "" {
+		return nil, fmt.Errorf("the pull request state filter is not supported by the %s store", BackendFS)
+	}
+	if opts.CollapseDuplicates {
+		return nil, fmt.Errorf("collapsing duplicates is not supported by the %s store", BackendFS)
+	}
+	if len(opts.Metadata) > 0 {
+		return nil, fmt.Errorf("metadata filters are not supported by the %s store", BackendFS)
+	}
+	if len(opts.Entities) > 0 {
+		return nil, fmt.Errorf("entity filters are not supported by the %s store", BackendFS)
+	}
+	if len(opts.ChannelTypes) > 0 {
+		return nil, fmt.Errorf("the channel type filter is not supported by the %s store", BackendFS)
+	}
+
+	var terms []string
+	if opts.SearchText != nil {
+		var err error
+		if terms, err = parseSearchTerms(*opts.SearchText, opts.CaseSensitive); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := normalize.MessagesByIDDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	needsEnrichment := opts.IsQuestion != nil || opts.HasCode != nil ||
+		opts.HasLinks != nil || opts.HasQuotes != nil || opts.IsCodeOnly != nil ||
+		opts.IsLinkOnly != nil || opts.Language != nil
+
+	switch opts.Sort {
+	case "", db.SortTimestamp, db.SortLastActivity:
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", opts.Sort)
+	}
+
+	// Latest message timestamp in each thread, for SortLastActivity
+	lastActivity := make(map[string]time.Time)
+
+	messages := []*db.Message{}
+	for _, file := range files {
+		var normalized normalize.NormalizedMessage
+		if _, err := readJSONFile(file, &normalized); err != nil {
+			return nil, fmt.Errorf("failed to load message: %w", err)
+		}
+		msg := fromNormalized(&normalized)
+		if thread := threadKey(msg); msg.Timestamp.After(lastActivity[thread]) {
+			lastActivity[thread] = msg.Timestamp
+		}
+		if !matchesMessage(msg, opts, terms) {
+			continue
+		}
+
+		if needsEnrichment {
+			enrich, err := s.LoadEnrichment(msg.ID)
+			if err != nil {
+				return nil, err
+			}
+			// Like the database's join, messages without enrichments never match
+			if enrich == nil || !matchesEnrichment(enrich, opts) {
+				continue
+			}
+		}
+
+		messages = append(messages, msg)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if opts.Sort == db.SortLastActivity {
+			ai, aj := lastActivity[threadKey(messages[i])], lastActivity[threadKey(messages[j])]
+			if !ai.Equal(aj) {
+				return ai.After(aj)
+			}
+		}
+		if !messages[i].Timestamp.Equal(messages[j].Timestamp) {
+			return messages[i].Timestamp.After(messages[j].Timestamp)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+
+	if opts.Offset > 0 {
+		if opts.Offset >= len(messages) {
+			return []*db.Message{}, nil
+		}
+		messages = messages[opts.Offset:]
+	}
+	if opts.Limit > 0 && len(messages) > opts.Limit {
+		messages = messages[:opts.Limit]
+	}
+
+	return messages, nil
+}
+
+// threadKey returns the thread a message belongs to; a message outside any
+// thread is its own
+func threadKey(msg *db.Message) string {
+	if msg.ThreadID != nil && *msg.ThreadID != "" {
+		return *msg.ThreadID
+	}
+	return msg.ID
+}
+
+// SelectThreads returns the threads with messages matching the filters, one
+// per thread, in the same order as the database returns them
+func (s *FSStore) SelectThreads(opts db.SelectMessagesOptions) ([]*db.ThreadMatch, error) {
+	switch opts.Sort {
+	case "", db.SortTimestamp, db.SortLastActivity:
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", opts.Sort)
+	}
+
+	// Limit and Offset apply to the threads, not the messages
+	all := opts
+	all.Limit, all.Offset = 0, 0
+	matching, err := s.SelectMessages(all)
+	if err != nil {
+		return nil, err
+	}
+	matches := make(map[string]int)
+	for _, msg := range matching {
+		matches[threadKey(msg)]++
+	}
+
+	// Aggregate over every message of the matching threads
+	messages, err := s.SelectMessages(db.SelectMessagesOptions{})
+	if err != nil {
+		return nil, err
+	}
+	threads := make(map[string]*db.ThreadMatch)
+	started := make(map[string]time.Time)
+	authors := make(map[string]map[string]bool)
+	// Oldest first, so the earliest message stands in for a missing root
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		key := threadKey(msg)
+		if matches[key] == 0 {
+			continue
+		}
+		thread, ok := threads[key]
+		if !ok {
+			thread = &db.ThreadMatch{ThreadID: key, MatchCount: matches[key], ReplyCount: -1, Root: msg}
+			threads[key] = thread
+			started[key] = msg.Timestamp
+			authors[key] = make(map[string]bool)
+		}
+		if msg.ID == key {
+			thread.Root = msg
+		}
+		thread.ReplyCount++
+		authors[key][msg.AuthorID] = true
+		if msg.Timestamp.After(thread.LastActivityAt) {
+			thread.LastActivityAt = msg.Timestamp
+		}
+	}
+
+	result := make([]*db.ThreadMatch, 0, len(threads))
+	for key, thread := range threads {
+		thread.ParticipantCount = len(authors[key])
+		result = append(result, thread)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ti, tj := started[result[i].ThreadID], started[result[j].ThreadID]
+		if opts.Sort == db.SortLastActivity {
+			ti, tj = result[i].LastActivityAt, result[j].LastActivityAt
+		}
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return result[i].ThreadID < result[j].ThreadID
+	})
+
+	if opts.Offset > 0 {
+		if opts.Offset >= len(result) {
+			return []*db.ThreadMatch{}, nil
+		}
+		result = result[opts.Offset:]
+	}
+	if opts.Limit > 0 && len(result) > opts.Limit {
+		result = result[:opts.Limit]
+	}
+
+	return result, nil
+}
+
+// ChannelActivity isn't supported: it needs the thread summaries, which only
+// the database has
+func (s *FSStore) ChannelActivity(opts db.SelectMessagesOptions) ([]*db.ChannelActivity, error) {
+	return nil, fmt.Errorf("channel activity is not supported by the %s store", BackendFS)
+}
+
+// ResponseTimes isn't supported: finding each thread's first reply by someone
+// other than the asker is a query over every thread, which only the database
+// runs
+func (s *FSStore) ResponseTimes(opts db.SelectMessagesOptions) ([]*db.ChannelResponseTimes, error) {
+	return nil, fmt.Errorf("response times are not supported by the %s store", BackendFS)
+}
+
+// CountMessages counts messages matching the filters, grouped by a db.CountBy*
+// dimension, in the same order as the database returns them
+func (s *FSStore) CountMessages(opts db.SelectMessagesOptions, groupBy string) ([]db.MessageCount, error) {
+	var key func(msg *db.Message) string
+	switch groupBy {
+	case db.CountByAuthor:
+		key = func(msg *db.Message) string { return msg.AuthorID }
+	case db.CountByChannel:
+		key = func(msg *db.Message) string { return msg.ChannelID }
+	case db.CountBySource:
+		key = func(msg *db.Message) string { return msg.SourceType }
+	case db.CountByDay:
+		key = func(msg *db.Message) string { return msg.Timestamp.UTC().Format("2006-01-02") }
+	default:
+		return nil, fmt.Errorf("unknown count dimension: %s", groupBy)
+	}
+
+	// Limit and Offset apply to the groups, not the messages
+	all := opts
+	all.Limit, all.Offset = 0, 0
+	messages, err := s.SelectMessages(all)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]int)
+	for _, msg := range messages {
+		byKey[key(msg)]++
+	}
+
+	counts := make([]db.MessageCount, 0, len(byKey))
+	for k, n := range byKey {
+		counts = append(counts, db.MessageCount{Key: k, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if groupBy != db.CountByDay && counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+
+	return db.PageCounts(counts, opts.Limit, opts.Offset), nil
+}
+
+// SaveEnrichment saves or updates a message's enrichment
+func (s *FSStore) SaveEnrichment(enrich *db.Enrichment) error {
+	path, err := enrichmentPath(enrich.MessageID)
+	if err != nil {
+		return err
+	}
+	saved := *enrich
+	saved.EnrichedAt = time.Now().UTC()
+	if err := writeJSONFile(path, &saved); err != nil {
+		return fmt.Errorf("failed to save enrichment: %w", err)
+	}
+	return nil
+}
+
+// LoadEnrichment retrieves a message's enrichment
+func (s *FSStore) LoadEnrichment(messageID string) (*db.Enrichment, error) {
+	path, err := enrichmentPath(messageID)
+	if err != nil {
+		return nil, err
+	}
+	var enrich db.Enrichment
+	found, err := readJSONFile(path, &enrich)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load enrichment: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &enrich, nil
+}
+
+// SaveReplyGraph merges g into the saved reply graph (see graph.ReplyGraph.Merge)
+func (s *FSStore) SaveReplyGraph(g *graph.ReplyGraph) error {
+	saved := graph.NewReplyGraph()
+	dir, err := graph.StructureDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nodes.json")); err == nil {
+		if saved, err = graph.LoadReplyGraph(); err != nil {
+			return err
+		}
+	}
+	saved.Merge(g)
+	if err := graph.SaveReplyGraph(saved); err != nil {
+		return fmt.Errorf("failed to save reply graph: %w", err)
+	}
+	return nil
+}
+
+// messagePath returns the by_id file of the message with id
+func messagePath(id string) (string, error) {
+	if err := checkID(id); err != nil {
+		return "", err
+	}
+	dir, err := normalize.MessagesByIDDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+// enrichmentPath returns the enrichment file of the message with id
+func enrichmentPath(id string) (string, error) {
+	if err := checkID(id); err != nil {
+		return "", err
+	}
+	dir, err := normalize.NormalizedDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "enrichments", id+".json"), nil
+}
+
+// checkID rejects message IDs that can't be file names
+func checkID(id string) error {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return fmt.Errorf("invalid message ID: %q", id)
+	}
+	return nil
+}
+
+// toNormalized returns msg in the normalized schema. Edits, which the schema
+// has no fields for, are kept in SourceMetadata like the normalizers keep them.
+func toNormalized(msg *db.Message) *normalize.NormalizedMessage {
+	normalized := &normalize.NormalizedMessage{
+		ID:              msg.ID,
+		SourceType:      msg.SourceType,
+		SourceID:        msg.SourceID,
+		Timestamp:       msg.Timestamp,
+		SourceTimestamp: msg.SourceTimestamp,
+		Author:          &normalize.User{ID: msg.AuthorID, SourceType: msg.SourceType},
+		Content:         msg.Content,
+		Channel:         &normalize.Channel{ID: msg.ChannelID, SourceType: msg.SourceType},
+		IsThreadRoot:    msg.IsThreadRoot,
+		IsEdited:        msg.EditedAt != nil,
+		Mentions:        msg.Mentions,
+		URLs:            msg.URLs,
+		CodeBlocks:      make([]normalize.CodeBlock, len(msg.CodeBlocks)),
+		Attachments:     make([]normalize.Attachment, len(msg.Attachments)),
+		SourceMetadata:  map[string]interface{}{},
+		NormalizedAt:    msg.NormalizedAt,
+		SchemaVersion:   msg.SchemaVersion,
+	}
+	if msg.ContentHTML != nil {
+		normalized.ContentHTML = *msg.ContentHTML
+	}
+	if msg.ThreadID != nil {
+		normalized.ThreadID = *msg.ThreadID
+	}
+	if msg.ParentID != nil {
+		normalized.ParentID = *msg.ParentID
+	}
+	for i, cb := range msg.CodeBlocks {
+		normalized.CodeBlocks[i] = normalize.CodeBlock{Language: cb.Language, Code: cb.Code}
+	}
+	for i, att := range msg.Attachments {
+		normalized.Attachments[i] = normalize.Attachment{Type: att.Type, URL: att.URL, Title: att.Title, MimeType: att.MimeType}
+	}
+	if msg.EditedAt != nil {
+		normalized.SourceMetadata["edited_at"] = msg.EditedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if msg.EditedBy != nil {
+		normalized.SourceMetadata["edited_by"] = *msg.EditedBy
+	}
+	return normalized
+}
+
+// fromNormalized returns a message saved by toNormalized
+func fromNormalized(normalized *normalize.NormalizedMessage) *db.Message {
+	msg := &db.Message{
+		ID:              normalized.ID,
+		SourceType:      normalized.SourceType,
+		SourceID:        normalized.SourceID,
+		Timestamp:       normalized.Timestamp,
+		SourceTimestamp: normalized.SourceTimestamp,
+		Content:         normalized.Content,
+		IsThreadRoot:    normalized.IsThreadRoot,
+		Mentions:        normalized.Mentions,
+		URLs:            normalized.URLs,
+		CodeBlocks:      make([]db.CodeBlock, len(normalized.CodeBlocks)),
+		Attachments:     make([]db.Attachment, len(normalized.Attachments)),
+		NormalizedAt:    normalized.NormalizedAt,
+		SchemaVersion:   normalized.SchemaVersion,
+	}
+	if normalized.Author != nil {
+		msg.AuthorID = normalized.Author.ID
+	}
+	if normalized.Channel != nil {
+		msg.ChannelID = normalized.Channel.ID
+	}
+	if normalized.ContentHTML != "" {
+		msg.ContentHTML = &normalized.ContentHTML
+	}
+	if normalized.ThreadID != "" {
+		msg.ThreadID = &normalized.ThreadID
+	}
+	if normalized.ParentID != "" {
+		msg.ParentID = &normalized.ParentID
+	}
+	for i, cb := range normalized.CodeBlocks {
+		msg.CodeBlocks[i] = db.CodeBlock{Language: cb.Language, Code: cb.Code}
+	}
+	for i, att := range normalized.Attachments {
+		msg.Attachments[i] = db.Attachment{Type: att.Type, URL: att.URL, Title: att.Title, MimeType: att.MimeType}
+	}
+	if editedAt, ok := normalized.SourceMetadata["edited_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, editedAt); err == nil {
+			msg.EditedAt = &t
+		}
+	}
+	if editedBy, ok := normalized.SourceMetadata["edited_by"].(string); ok {
+		msg.EditedBy = &editedBy
+	}
+	return msg
+}
+
+// matchesMessage reports whether msg passes the message-level filters in opts
+func matchesMessage(msg *db.Message, opts db.SelectMessagesOptions, terms []string) bool {
+	if len(opts.SourceTypes) > 0 {
+		if !isAnyOf(msg.SourceType, opts.SourceTypes) {
+			return false
+		}
+	} else if opts.SourceType != nil && msg.SourceType != *opts.SourceType {
+		return false
+	}
+	if opts.AuthorID != nil && msg.AuthorID != *opts.AuthorID {
+		return false
+	}
+	if opts.ChannelID != nil && msg.ChannelID != *opts.ChannelID {
+		return false
+	}
+	if opts.ThreadID != nil && (msg.ThreadID == nil || *msg.ThreadID != *opts.ThreadID) {
+		return false
+	}
+	for _, id := range opts.ExcludeChannelIDs {
+		if msg.ChannelID == id {
+			return false
+		}
+	}
+	if len(opts.AuthorIDs) > 0 && !isAnyOf(msg.AuthorID, opts.AuthorIDs) {
+		return false
+	}
+	for _, id := range opts.ExcludeAuthorIDs {
+		if msg.AuthorID == id {
+			return false
+		}
+	}
+	if len(opts.MentionsAnyOf) > 0 && !mentionsAny(msg, opts.MentionsAnyOf) {
+		return false
+	}
+	if opts.Since != nil && msg.Timestamp.Before(*opts.Since) {
+		return false
+	}
+	if opts.Until != nil && msg.Timestamp.After(*opts.Until) {
+		return false
+	}
+
+	content := msg.Content
+	if !opts.CaseSensitive {
+		content = strings.ToLower(content)
+	}
+	for _, term := range terms {
+		if !strings.Contains(content, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAnyOf reports whether value is one of values
+func isAnyOf(value string, values []string) bool {
+	for _, other := range values {
+		if value == other {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsAny reports whether msg mentions any of the users with ids,
+// regardless of case
+func mentionsAny(msg *db.Message, ids []string) bool {
+	for _, mention := range msg.Mentions {
+		for _, id := range ids {
+			if strings.EqualFold(mention, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesEnrichment reports whether enrich passes the enrichment filters in opts
+func matchesEnrichment(enrich *db.Enrichment, opts db.SelectMessagesOptions) bool {
+	if opts.IsQuestion != nil && enrich.IsQuestion != *opts.IsQuestion {
+		return false
+	}
+	if opts.HasCode != nil && enrich.HasCode != *opts.HasCode {
+		return false
+	}
+	if opts.HasLinks != nil && enrich.HasLinks != *opts.HasLinks {
+		return false
+	}
+	if opts.HasQuotes != nil && enrich.HasQuotes != *opts.HasQuotes {
+		return false
+	}
+	if opts.IsCodeOnly != nil && enrich.IsCodeOnly != *opts.IsCodeOnly {
+		return false
+	}
+	if opts.IsLinkOnly != nil && enrich.IsLinkOnly != *opts.IsLinkOnly {
+		return false
+	}
+	if opts.Language != nil && enrich.Language != *opts.Language {
+		return false
+	}
+	return true
+}
+
+// parseSearchTerms splits a search query into the words and phrases that must
+// all match (see db.SearchTerms), lowercased unless caseSensitive
+func parseSearchTerms(query string, caseSensitive bool) ([]string, error) {
+	terms, err := db.SearchTerms(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w by the %s store", err, BackendFS)
+	}
+	if !caseSensitive {
+		for i, term := range terms {
+			terms[i] = strings.ToLower(term)
+		}
+	}
+	return terms, nil
+}
+
+// writeJSONFile writes v to path, creating parent directories as needed
+func writeJSONFile(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// readJSONFile decodes path into v. It returns false if the file doesn't exist.
+func readJSONFile(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return true, nil
+}
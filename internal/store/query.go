@@ -0,0 +1,134 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// queryTokenPattern matches, in order: a qualifier (optionally negated, with a
+// quoted or bare value), a quoted phrase, or a single word
+var queryTokenPattern = regexp.MustCompile(`(-?)([a-z]+):(?:"([^"]*)"|(\S+))|"([^"]*)"|(\S+)`)
+
+// Query is a parsed search query. Field qualifiers fill the filter fields;
+// everything else is kept in Text for full-text search.
+//
+//	author:alice -channel:random has:code is:question "exact phrase" kubernetes
+//
+// Qualifiers: author, channel, source, thread, since, until (values as for the
+// matching select flags), has:code|links|quotes, and
+// is:question|code-only|link-only. author and channel can be negated with a
+// leading "-". Values with spaces are quoted: author:"Jane Doe". Words that
+// look like qualifiers but aren't (https://...) are search text.
+type Query struct {
+	Authors         []string
+	ExcludeAuthors  []string
+	Channels        []string
+	ExcludeChannels []string
+	Sources         []string
+	ThreadID        string
+	Since           string
+	Until           string
+	HasCode         bool
+	HasLinks        bool
+	HasQuotes       bool
+	IsQuestion      bool
+	IsCodeOnly      bool
+	IsLinkOnly      bool
+	Text            string // Remaining words and quoted phrases, for SearchText
+}
+
+// ParseQuery parses a search query with field qualifiers
+func ParseQuery(query string) (Query, error) {
+	var q Query
+	if strings.Count(query, `"`)%2 != 0 {
+		return q, fmt.Errorf("unterminated quote in search query: %s", query)
+	}
+
+	var text []string
+	for _, match := range queryTokenPattern.FindAllStringSubmatch(query, -1) {
+		switch {
+		case match[2] != "":
+			value := match[3] + match[4]
+			handled, err := q.applyQualifier(match[1] == "-", match[2], value)
+			if err != nil {
+				return q, err
+			}
+			if !handled {
+				text = append(text, match[0])
+			}
+		case match[6] != "":
+			text = append(text, match[6])
+		default:
+			if phrase := strings.TrimSpace(match[5]); phrase != "" {
+				text = append(text, `"`+phrase+`"`)
+			}
+		}
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// applyQualifier records a key:value qualifier. It reports false when key
+// isn't a qualifier, so the token is treated as search text instead.
+func (q *Query) applyQualifier(negate bool, key, value string) (bool, error) {
+	switch key {
+	case "author", "channel":
+	case "source", "thread", "since", "until", "has", "is":
+		if negate {
+			return true, fmt.Errorf("search qualifier %s: cannot be negated", key)
+		}
+	default:
+		return false, nil
+	}
+	if value == "" {
+		return true, fmt.Errorf("search qualifier %s: needs a value", key)
+	}
+
+	switch key {
+	case "author":
+		if negate {
+			q.ExcludeAuthors = append(q.ExcludeAuthors, value)
+		} else {
+			q.Authors = append(q.Authors, value)
+		}
+	case "channel":
+		if negate {
+			q.ExcludeChannels = append(q.ExcludeChannels, value)
+		} else {
+			q.Channels = append(q.Channels, value)
+		}
+	case "source":
+		q.Sources = append(q.Sources, value)
+	case "thread":
+		q.ThreadID = value
+	case "since":
+		q.Since = value
+	case "until":
+		q.Until = value
+	case "has":
+		switch value {
+		case "code":
+			q.HasCode = true
+		case "links", "link":
+			q.HasLinks = true
+		case "quotes", "quote":
+			q.HasQuotes = true
+		default:
+			return true, fmt.Errorf("unknown has: value %q (expected code, links, or quotes)", value)
+		}
+	case "is":
+		switch value {
+		case "question":
+			q.IsQuestion = true
+		case "code-only":
+			q.IsCodeOnly = true
+		case "link-only":
+			q.IsLinkOnly = true
+		default:
+			return true, fmt.Errorf("unknown is: value %q (expected question, code-only, or link-only)", value)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solvaholic/threadmine/internal/db"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Query
+	}{
+		{"plain words", "deploy timeout", Query{Text: "deploy timeout"}},
+		{"quoted phrase", `"exact phrase" kubernetes`, Query{Text: `"exact phrase" kubernetes`}},
+		{"author", "author:alice", Query{Authors: []string{"alice"}}},
+		{"quoted author", `author:"Jane Doe" deploy`, Query{Authors: []string{"Jane Doe"}, Text: "deploy"}},
+		{"repeated author", "author:alice author:bob", Query{Authors: []string{"alice", "bob"}}},
+		{"exclude author", "-author:deploybot", Query{ExcludeAuthors: []string{"deploybot"}}},
+		{"channel", "channel:general", Query{Channels: []string{"general"}}},
+		{"exclude channel", "-channel:alerts", Query{ExcludeChannels: []string{"alerts"}}},
+		{"source", "source:github", Query{Sources: []string{"github"}}},
+		{"thread", "thread:msg_slack_C1_1.0", Query{ThreadID: "msg_slack_C1_1.0"}},
+		{"since and until", "since:7d until:2024-02-01", Query{Since: "7d", Until: "2024-02-01"}},
+		{"has code", "has:code", Query{HasCode: true}},
+		{"has links", "has:links", Query{HasLinks: true}},
+		{"has link", "has:link", Query{HasLinks: true}},
+		{"has quotes", "has:quotes", Query{HasQuotes: true}},
+		{"is question", "is:question", Query{IsQuestion: true}},
+		{"is code only", "is:code-only", Query{IsCodeOnly: true}},
+		{"is link only", "is:link-only", Query{IsLinkOnly: true}},
+		{
+			"mixed",
+			`author:alice has:code "exact phrase" kubernetes`,
+			Query{Authors: []string{"alice"}, HasCode: true, Text: `"exact phrase" kubernetes`},
+		},
+		{"qualifier inside phrase", `"author:alice said"`, Query{Text: `"author:alice said"`}},
+		{"url is text", "see https://example.com/x", Query{Text: "see https://example.com/x"}},
+		{"boolean operators kept", "deploy OR release", Query{Text: "deploy OR release"}},
+		{"empty phrase dropped", `"" deploy`, Query{Text: "deploy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) failed: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`"unterminated phrase`, "unterminated quote"},
+		{"has:pictures", "unknown has: value"},
+		{"is:answer", "unknown is: value"},
+		{"-source:slack", "cannot be negated"},
+		{`author:""`, "needs a value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseQuery(%q) error = %v, want %q", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery_TextSearchesEveryStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		for _, msg := range []*db.Message{
+			testMessage("msg_1", "slack", "user_a", "chan_1", "Run the deploy script", 1, nil),
+			testMessage("msg_2", "slack", "user_a", "chan_1", "The script failed after deploy", 2, nil),
+		} {
+			if err := s.SaveMessage(msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+
+		q, err := ParseQuery(`author:alice "deploy script"`)
+		if err != nil {
+			t.Fatalf("ParseQuery failed: %v", err)
+		}
+		got, err := s.SelectMessages(db.SelectMessagesOptions{SearchText: &q.Text})
+		if err != nil {
+			t.Fatalf("SelectMessages failed: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != "msg_1" {
+			t.Errorf("expected only msg_1 to match the phrase, got %d messages", len(got))
+		}
+	})
+}
@@ -0,0 +1,44 @@
+// Package store provides interchangeable backends for normalized messages and
+// their enrichments, so fetch and query commands read and write the same data
+// whichever backend is selected.
+package store
+
+import (
+	"fmt"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+)
+
+// Supported backends
+const (
+	BackendDB = "db" // SQLite database (default)
+	BackendFS = "fs" // Normalized JSON storage on the local filesystem
+)
+
+// Store persists normalized messages, their enrichments, and the reply graph.
+// Load methods return nil, nil when the requested item doesn't exist.
+type Store interface {
+	SaveMessage(msg *db.Message) error
+	LoadMessage(id string) (*db.Message, error)
+	SelectMessages(opts db.SelectMessagesOptions) ([]*db.Message, error)
+	CountMessages(opts db.SelectMessagesOptions, groupBy string) ([]db.MessageCount, error)
+	SelectThreads(opts db.SelectMessagesOptions) ([]*db.ThreadMatch, error)
+	ChannelActivity(opts db.SelectMessagesOptions) ([]*db.ChannelActivity, error)
+	ResponseTimes(opts db.SelectMessagesOptions) ([]*db.ChannelResponseTimes, error)
+	SaveEnrichment(enrich *db.Enrichment) error
+	LoadEnrichment(messageID string) (*db.Enrichment, error)
+	SaveReplyGraph(g *graph.ReplyGraph) error
+}
+
+// Open returns the Store for backend. The database backs the db store.
+func Open(backend string, database *db.DB) (Store, error) {
+	switch backend {
+	case BackendDB, "":
+		return NewDBStore(database), nil
+	case BackendFS:
+		return NewFSStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s (expected %s or %s)", backend, BackendDB, BackendFS)
+	}
+}
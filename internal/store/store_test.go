@@ -0,0 +1,489 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solvaholic/threadmine/internal/db"
+	"github.com/solvaholic/threadmine/internal/graph"
+	"github.com/solvaholic/threadmine/internal/normalize"
+)
+
+// forEachStore runs test against a fresh instance of every backend
+func forEachStore(t *testing.T, test func(t *testing.T, s Store)) {
+	t.Run(BackendDB, func(t *testing.T) {
+		database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				t.Skip("SQLite built without FTS5; run tests with -tags fts5")
+			}
+			t.Fatalf("failed to open database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+
+		s, err := Open(BackendDB, database)
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		test(t, s)
+	})
+
+	t.Run(BackendFS, func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		s, err := Open(BackendFS, nil)
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		test(t, s)
+	})
+}
+
+func strPtr(s string) *string { return &s }
+func boolPtr(b bool) *bool    { return &b }
+
+// testMessage returns a message posted hours after a fixed base time
+func testMessage(id, source, author, channel, content string, hours int, threadID *string) *db.Message {
+	return &db.Message{
+		ID:            id,
+		SourceType:    source,
+		SourceID:      id,
+		Timestamp:     time.Date(2024, 1, 15, hours, 0, 0, 0, time.UTC),
+		AuthorID:      author,
+		Content:       content,
+		ChannelID:     channel,
+		ThreadID:      threadID,
+		IsThreadRoot:  threadID == nil,
+		Mentions:      []string{},
+		URLs:          []string{},
+		CodeBlocks:    []db.CodeBlock{},
+		Attachments:   []db.Attachment{},
+		NormalizedAt:  time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+		SchemaVersion: "1.0",
+	}
+}
+
+func TestStore_MessageRoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		msg := testMessage("msg_1", "slack", "user_a", "chan_1", "hello world", 1, strPtr("msg_0"))
+		msg.Mentions = []string{"user_b"}
+		msg.URLs = []string{"https://example.com"}
+		msg.CodeBlocks = []db.CodeBlock{{Language: "go", Code: "fmt.Println()"}}
+		msg.Attachments = []db.Attachment{{Type: "file", URL: "https://example.com/a.txt"}}
+
+		if err := s.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+
+		got, err := s.LoadMessage("msg_1")
+		if err != nil {
+			t.Fatalf("LoadMessage failed: %v", err)
+		}
+		if got == nil {
+			t.Fatal("expected message, got nil")
+		}
+		if got.ID != msg.ID || got.Content != msg.Content || got.AuthorID != msg.AuthorID || got.ChannelID != msg.ChannelID {
+			t.Errorf("message fields not preserved: %+v", got)
+		}
+		if !got.Timestamp.Equal(msg.Timestamp) {
+			t.Errorf("expected timestamp %v, got %v", msg.Timestamp, got.Timestamp)
+		}
+		if got.ThreadID == nil || *got.ThreadID != "msg_0" {
+			t.Errorf("expected thread ID msg_0, got %v", got.ThreadID)
+		}
+		if len(got.Mentions) != 1 || len(got.URLs) != 1 || len(got.CodeBlocks) != 1 || len(got.Attachments) != 1 {
+			t.Errorf("list fields not preserved: %+v", got)
+		}
+
+		// Saving again merges: content and thread update, identity doesn't
+		refetched := *msg
+		refetched.Content = "hello again"
+		refetched.ThreadID = strPtr("msg_9")
+		refetched.AuthorID = "user_z"
+		refetched.Timestamp = msg.Timestamp.Add(time.Hour)
+		if err := s.SaveMessage(&refetched); err != nil {
+			t.Fatalf("SaveMessage (update) failed: %v", err)
+		}
+		got, _ = s.LoadMessage("msg_1")
+		if got.Content != "hello again" {
+			t.Errorf("expected updated content, got %q", got.Content)
+		}
+		if got.ThreadID == nil || *got.ThreadID != "msg_9" {
+			t.Errorf("expected updated thread ID, got %v", got.ThreadID)
+		}
+		if got.AuthorID != "user_a" || !got.Timestamp.Equal(msg.Timestamp) {
+			t.Errorf("expected author and timestamp to be kept, got %s at %v", got.AuthorID, got.Timestamp)
+		}
+
+		missing, err := s.LoadMessage("msg_missing")
+		if err != nil || missing != nil {
+			t.Errorf("expected nil, nil for missing message, got %v, %v", missing, err)
+		}
+	})
+}
+
+func TestStore_EnrichmentRoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		if err := s.SaveMessage(testMessage("msg_1", "slack", "user_a", "chan_1", "how?", 1, nil)); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+
+		enrich := &db.Enrichment{MessageID: "msg_1", IsQuestion: true, CharCount: 4, WordCount: 1, HasCode: true}
+		if err := s.SaveEnrichment(enrich); err != nil {
+			t.Fatalf("SaveEnrichment failed: %v", err)
+		}
+
+		got, err := s.LoadEnrichment("msg_1")
+		if err != nil {
+			t.Fatalf("LoadEnrichment failed: %v", err)
+		}
+		if got == nil || !got.IsQuestion || !got.HasCode || got.HasLinks || got.CharCount != 4 || got.WordCount != 1 {
+			t.Errorf("enrichment not preserved: %+v", got)
+		}
+		if got != nil && got.EnrichedAt.IsZero() {
+			t.Error("expected EnrichedAt to be set")
+		}
+
+		missing, err := s.LoadEnrichment("msg_missing")
+		if err != nil || missing != nil {
+			t.Errorf("expected nil, nil for missing enrichment, got %v, %v", missing, err)
+		}
+	})
+}
+
+func TestStore_SelectMessages(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		messages := []*db.Message{
+			testMessage("msg_1", "slack", "user_a", "chan_1", "How do I deploy the service", 1, nil),
+			testMessage("msg_2", "slack", "user_b", "chan_1", "Run the deploy script", 2, strPtr("msg_1")),
+			testMessage("msg_3", "github", "user_a", "chan_2", "Deploy fails with a timeout error", 3, nil),
+			testMessage("msg_4", "github", "user_c", "chan_2", "Thanks, fixed", 4, strPtr("msg_3")),
+		}
+		for _, msg := range messages {
+			if err := s.SaveMessage(msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+		enrichments := []*db.Enrichment{
+			{MessageID: "msg_1", IsQuestion: true},
+			{MessageID: "msg_2", HasCode: true},
+			{MessageID: "msg_3", IsQuestion: false},
+		}
+		for _, enrich := range enrichments {
+			if err := s.SaveEnrichment(enrich); err != nil {
+				t.Fatalf("SaveEnrichment failed: %v", err)
+			}
+		}
+
+		since := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+		until := time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC)
+
+		tests := []struct {
+			name string
+			opts db.SelectMessagesOptions
+			want []string
+		}{
+			{"all, newest first", db.SelectMessagesOptions{}, []string{"msg_4", "msg_3", "msg_2", "msg_1"}},
+			{"source", db.SelectMessagesOptions{SourceType: strPtr("github")}, []string{"msg_4", "msg_3"}},
+			{"any of several sources", db.SelectMessagesOptions{SourceTypes: []string{"slack", "github"}}, []string{"msg_4", "msg_3", "msg_2", "msg_1"}},
+			{"sources over source", db.SelectMessagesOptions{SourceType: strPtr("github"), SourceTypes: []string{"slack"}}, []string{"msg_2", "msg_1"}},
+			{"author", db.SelectMessagesOptions{AuthorID: strPtr("user_a")}, []string{"msg_3", "msg_1"}},
+			{"channel", db.SelectMessagesOptions{ChannelID: strPtr("chan_1")}, []string{"msg_2", "msg_1"}},
+			{"thread", db.SelectMessagesOptions{ThreadID: strPtr("msg_3")}, []string{"msg_4"}},
+			{"any of several authors", db.SelectMessagesOptions{AuthorIDs: []string{"user_b", "user_c"}}, []string{"msg_4", "msg_2"}},
+			{"exclude author", db.SelectMessagesOptions{ExcludeAuthorIDs: []string{"user_a", "user_c"}}, []string{"msg_2"}},
+			{"exclude channel", db.SelectMessagesOptions{ExcludeChannelIDs: []string{"chan_2"}}, []string{"msg_2", "msg_1"}},
+			{"include source, exclude author", db.SelectMessagesOptions{SourceType: strPtr("github"), ExcludeAuthorIDs: []string{"user_c"}}, []string{"msg_3"}},
+			{"time range", db.SelectMessagesOptions{Since: &since, Until: &until}, []string{"msg_3", "msg_2"}},
+			{"search word", db.SelectMessagesOptions{SearchText: strPtr("deploy")}, []string{"msg_3", "msg_2", "msg_1"}},
+			{"search words", db.SelectMessagesOptions{SearchText: strPtr("deploy timeout")}, []string{"msg_3"}},
+			{"search phrase", db.SelectMessagesOptions{SearchText: strPtr(`"deploy script"`)}, []string{"msg_2"}},
+			{"is question", db.SelectMessagesOptions{IsQuestion: boolPtr(true)}, []string{"msg_1"}},
+			{"not question skips unenriched", db.SelectMessagesOptions{IsQuestion: boolPtr(false)}, []string{"msg_3", "msg_2"}},
+			{"has code", db.SelectMessagesOptions{HasCode: boolPtr(true)}, []string{"msg_2"}},
+			{"limit", db.SelectMessagesOptions{Limit: 2}, []string{"msg_4", "msg_3"}},
+			{"limit and offset", db.SelectMessagesOptions{Limit: 2, Offset: 1}, []string{"msg_3", "msg_2"}},
+			{"offset past end", db.SelectMessagesOptions{Limit: 10, Offset: 10}, []string{}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := s.SelectMessages(tt.opts)
+				if err != nil {
+					t.Fatalf("SelectMessages failed: %v", err)
+				}
+				ids := make([]string, len(got))
+				for i, msg := range got {
+					ids[i] = msg.ID
+				}
+				if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("expected %v, got %v", tt.want, ids)
+				}
+			})
+		}
+	})
+}
+
+func TestStore_SelectMessages_CaseSensitive(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		messages := []*db.Message{
+			testMessage("msg_1", "slack", "user_a", "chan_1", "Call getUserID to look them up", 1, nil),
+			testMessage("msg_2", "slack", "user_b", "chan_1", "getuserid is deprecated", 2, nil),
+			testMessage("msg_3", "github", "user_a", "chan_2", "GETUSERID fails with Not Found", 3, nil),
+			testMessage("msg_4", "github", "user_c", "chan_2", "The page was not found", 4, nil),
+		}
+		for _, msg := range messages {
+			if err := s.SaveMessage(msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+
+		tests := []struct {
+			search        string
+			insensitive   []string
+			caseSensitive []string
+		}{
+			{"getUserID", []string{"msg_3", "msg_2", "msg_1"}, []string{"msg_1"}},
+			{"getuserid", []string{"msg_3", "msg_2", "msg_1"}, []string{"msg_2"}},
+			{`"not found"`, []string{"msg_4", "msg_3"}, []string{"msg_4"}},
+			{`"Not Found" GETUSERID`, []string{"msg_3"}, []string{"msg_3"}},
+			{"GetUserId", []string{"msg_3", "msg_2", "msg_1"}, []string{}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.search, func(t *testing.T) {
+				for _, caseSensitive := range []bool{false, true} {
+					got, err := s.SelectMessages(db.SelectMessagesOptions{SearchText: strPtr(tt.search), CaseSensitive: caseSensitive})
+					if err != nil {
+						t.Fatalf("SelectMessages failed: %v", err)
+					}
+					ids := make([]string, len(got))
+					for i, msg := range got {
+						ids[i] = msg.ID
+					}
+					want := tt.insensitive
+					if caseSensitive {
+						want = tt.caseSensitive
+					}
+					if strings.Join(ids, ",") != strings.Join(want, ",") {
+						t.Errorf("case-sensitive=%v: expected %v, got %v", caseSensitive, want, ids)
+					}
+				}
+			})
+		}
+
+		// Operators that don't require every term can't be matched case-sensitively
+		if _, err := s.SelectMessages(db.SelectMessagesOptions{SearchText: strPtr("getUserID OR deploy"), CaseSensitive: true}); err == nil {
+			t.Error("expected an error for OR with case-sensitive search")
+		}
+	})
+}
+
+func TestStore_CountMessages(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		messages := []*db.Message{
+			testMessage("msg_1", "slack", "user_a", "chan_1", "one", 1, nil),
+			testMessage("msg_2", "slack", "user_b", "chan_1", "two", 2, nil),
+			testMessage("msg_3", "github", "user_a", "chan_2", "three", 3, nil),
+		}
+		messages[2].Timestamp = messages[2].Timestamp.AddDate(0, 0, 1)
+		for _, msg := range messages {
+			if err := s.SaveMessage(msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+
+		tests := []struct {
+			groupBy string
+			opts    db.SelectMessagesOptions
+			want    string
+		}{
+			{db.CountByAuthor, db.SelectMessagesOptions{}, "user_a:2,user_b:1"},
+			{db.CountByChannel, db.SelectMessagesOptions{}, "chan_1:2,chan_2:1"},
+			{db.CountBySource, db.SelectMessagesOptions{ExcludeAuthorIDs: []string{"user_b"}}, "github:1,slack:1"},
+			{db.CountByDay, db.SelectMessagesOptions{}, "2024-01-15:2,2024-01-16:1"},
+			{db.CountByAuthor, db.SelectMessagesOptions{Limit: 1, Offset: 1}, "user_b:1"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.groupBy, func(t *testing.T) {
+				counts, err := s.CountMessages(tt.opts, tt.groupBy)
+				if err != nil {
+					t.Fatalf("CountMessages failed: %v", err)
+				}
+				parts := make([]string, len(counts))
+				for i, c := range counts {
+					parts[i] = fmt.Sprintf("%s:%d", c.Key, c.Count)
+				}
+				if got := strings.Join(parts, ","); got != tt.want {
+					t.Errorf("expected %s, got %s", tt.want, got)
+				}
+			})
+		}
+
+		if _, err := s.CountMessages(db.SelectMessagesOptions{}, "mood"); err == nil {
+			t.Error("expected error for unknown dimension")
+		}
+	})
+}
+
+func TestStore_SelectThreads(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		root := testMessage("msg_a", "slack", "user_a", "chan_1", "deploys fail", 1, strPtr("msg_a"))
+		root.IsThreadRoot = true
+		messages := []*db.Message{
+			root,
+			testMessage("msg_a1", "slack", "user_b", "chan_1", "which version?", 2, strPtr("msg_a")),
+			testMessage("msg_a2", "slack", "user_a", "chan_1", "4.2", 5, strPtr("msg_a")),
+			// Replies whose root wasn't fetched
+			testMessage("msg_b1", "slack", "user_c", "chan_1", "same here", 3, strPtr("msg_b")),
+			testMessage("msg_b2", "slack", "user_a", "chan_1", "fixed now", 4, strPtr("msg_b")),
+			// Outside any thread
+			testMessage("msg_c", "slack", "user_b", "chan_2", "lunch?", 6, nil),
+		}
+		for _, msg := range messages {
+			if err := s.SaveMessage(msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+
+		tests := []struct {
+			name string
+			opts db.SelectMessagesOptions
+			want string
+		}{
+			{"newest first", db.SelectMessagesOptions{}, "msg_c/msg_c/1/0/1/6 msg_b/msg_b1/2/1/2/4 msg_a/msg_a/3/2/2/5"},
+			{"last activity", db.SelectMessagesOptions{Sort: db.SortLastActivity}, "msg_c/msg_c/1/0/1/6 msg_a/msg_a/3/2/2/5 msg_b/msg_b1/2/1/2/4"},
+			{"aggregates cover whole threads", db.SelectMessagesOptions{AuthorID: strPtr("user_b")}, "msg_c/msg_c/1/0/1/6 msg_a/msg_a/1/2/2/5"},
+			{"paged", db.SelectMessagesOptions{Limit: 1, Offset: 1}, "msg_b/msg_b1/2/1/2/4"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				threads, err := s.SelectThreads(tt.opts)
+				if err != nil {
+					t.Fatalf("SelectThreads failed: %v", err)
+				}
+				// thread/root/matches/replies/participants/last activity hour
+				parts := make([]string, len(threads))
+				for i, th := range threads {
+					rootID := ""
+					if th.Root != nil {
+						rootID = th.Root.ID
+					}
+					parts[i] = fmt.Sprintf("%s/%s/%d/%d/%d/%d", th.ThreadID, rootID, th.MatchCount, th.ReplyCount, th.ParticipantCount, th.LastActivityAt.UTC().Hour())
+				}
+				if got := strings.Join(parts, " "); got != tt.want {
+					t.Errorf("expected %s, got %s", tt.want, got)
+				}
+			})
+		}
+	})
+}
+
+func TestOpen_UnknownBackend(t *testing.T) {
+	if _, err := Open("s3", nil); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestFSStore_Unsupported(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := NewFSStore()
+
+	if _, err := s.SelectMessages(db.SelectMessagesOptions{AssigneeID: strPtr("user_a")}); err == nil {
+		t.Error("expected error for assignee filter")
+	}
+	if _, err := s.SelectMessages(db.SelectMessagesOptions{SearchText: strPtr("deploy OR release")}); err == nil {
+		t.Error("expected error for OR search")
+	}
+	if err := s.SaveMessage(&db.Message{ID: "../escape"}); err == nil {
+		t.Error("expected error for message ID with a path separator")
+	}
+}
+
+func TestFSStore_SortLastActivity(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s, err := Open(BackendFS, nil)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	// msg_a's thread starts first but has the latest reply
+	for _, msg := range []*db.Message{
+		testMessage("msg_a", "slack", "user_a", "chan_1", "first thread", 1, nil),
+		testMessage("msg_b", "slack", "user_a", "chan_1", "second thread", 2, nil),
+		testMessage("msg_a_reply", "slack", "user_b", "chan_1", "late reply", 5, strPtr("msg_a")),
+	} {
+		if err := s.SaveMessage(msg); err != nil {
+			t.Fatalf("SaveMessage failed: %v", err)
+		}
+	}
+
+	messages, err := s.SelectMessages(db.SelectMessagesOptions{Sort: db.SortLastActivity})
+	if err != nil {
+		t.Fatalf("SelectMessages failed: %v", err)
+	}
+	want := []string{"msg_a_reply", "msg_a", "msg_b"}
+	for i, msg := range messages {
+		if msg.ID != want[i] {
+			t.Fatalf("expected order %v, got %s at %d", want, msg.ID, i)
+		}
+	}
+}
+
+func TestFSStore_NormalizedStorage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := NewFSStore()
+
+	editedAt := time.Date(2024, 1, 15, 2, 30, 0, 0, time.UTC)
+	msg := testMessage("msg_1", "slack", "user_a", "chan_1", "hello world", 1, nil)
+	msg.SourceTimestamp = "1705280400.000100"
+	msg.ContentHTML = strPtr("<p>hello world</p>")
+	msg.EditedAt, msg.EditedBy = &editedAt, strPtr("user_a")
+	if err := s.SaveMessage(msg); err != nil {
+		t.Fatalf("SaveMessage failed: %v", err)
+	}
+
+	got, err := s.LoadMessage("msg_1")
+	if err != nil {
+		t.Fatalf("LoadMessage failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, msg) {
+		t.Errorf("message not preserved:\ngot  %+v\nwant %+v", got, msg)
+	}
+
+	// The message is in every normalized index
+	byID, err := normalize.LoadMessageByID("msg_1")
+	if err != nil || byID.Content != "hello world" || !byID.IsEdited {
+		t.Errorf("LoadMessageByID = %+v, %v", byID, err)
+	}
+	byDate, err := normalize.LoadMessagesByDate(msg.Timestamp)
+	if err != nil || len(byDate) != 1 || byDate[0].ID != "msg_1" {
+		t.Errorf("LoadMessagesByDate = %v, %v", byDate, err)
+	}
+	bySource, err := normalize.LoadMessagesBySource("slack")
+	if err != nil || len(bySource) != 1 || bySource[0].ID != "msg_1" {
+		t.Errorf("LoadMessagesBySource = %v, %v", bySource, err)
+	}
+
+	// Reply graphs saved by separate fetches are merged
+	reply := testMessage("msg_2", "slack", "user_b", "chan_1", "hi", 2, strPtr("msg_1"))
+	reply.ParentID = strPtr("msg_1")
+	for _, batch := range []*db.Message{msg, reply} {
+		g := graph.BuildFromNormalizedMessages([]*normalize.NormalizedMessage{toNormalized(batch)})
+		if err := s.SaveReplyGraph(g); err != nil {
+			t.Fatalf("SaveReplyGraph failed: %v", err)
+		}
+	}
+	saved, err := graph.LoadReplyGraph()
+	if err != nil {
+		t.Fatalf("LoadReplyGraph failed: %v", err)
+	}
+	if thread := saved.GetThread("msg_1"); len(thread) != 2 || !reflect.DeepEqual(saved.ThreadRoots, []string{"msg_1"}) {
+		t.Errorf("expected thread msg_1 with its reply, got %v (roots %v)", thread, saved.ThreadRoots)
+	}
+}
@@ -2,12 +2,32 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
-// ParseSinceDate parses a date string that can be in two formats:
-// - Relative: "7d" (days ago)
-// - Absolute: "2025-12-15" (YYYY-MM-DD)
+// relativeDatePattern matches relative dates: a count and a unit letter
+var relativeDatePattern = regexp.MustCompile(`^(-?\d*)([hdwmy])$`)
+
+// relativeDateUnits names the units of relative dates
+var relativeDateUnits = map[string]string{"h": "hours", "d": "days", "w": "weeks", "m": "months", "y": "years"}
+
+// absoluteDateFormats are the absolute formats ParseSinceDate accepts, most
+// precise first. Times without a zone are UTC.
+var absoluteDateFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseSinceDate parses a date string that can be in these formats:
+// - Relative: "7d" (days ago), also "3h" (hours), "2w" (weeks), "6m"
+// (months), and "1y" (years)
+// - Absolute: "2025-12-15" (YYYY-MM-DD), "2025-12-15T15:00:00" (UTC), or
+// RFC3339 like "2025-12-15T15:00:00-05:00"
 //
 // Returns the parsed time or an error if the format is invalid.
 func ParseSinceDate(since string) (time.Time, error) {
@@ -16,23 +36,35 @@ func ParseSinceDate(since string) (time.Time, error) {
 	}
 
 	// Check for relative format (e.g., "7d")
-	if since[len(since)-1] == 'd' {
-		days := 0
-		_, err := fmt.Sscanf(since, "%dd", &days)
+	if m := relativeDatePattern.FindStringSubmatch(since); m != nil {
+		count, err := strconv.Atoi(m[1])
 		if err != nil {
 			return time.Time{}, fmt.Errorf("invalid relative date format '%s': expected format like '7d'", since)
 		}
-		if days < 0 {
-			return time.Time{}, fmt.Errorf("days cannot be negative: %d", days)
+		if count < 0 {
+			return time.Time{}, fmt.Errorf("%s cannot be negative: %d", relativeDateUnits[m[2]], count)
+		}
+
+		now := clock.Now()
+		switch m[2] {
+		case "h":
+			return now.Add(-time.Duration(count) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, -count), nil
+		case "w":
+			return now.AddDate(0, 0, -count*7), nil
+		case "m":
+			return now.AddDate(0, -count, 0), nil
+		default:
+			return now.AddDate(-count, 0, 0), nil
 		}
-		return time.Now().AddDate(0, 0, -days), nil
 	}
 
-	// Try absolute format (YYYY-MM-DD)
-	parsed, err := time.Parse("2006-01-02", since)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid date format '%s': expected 'YYYY-MM-DD' or relative format like '7d'", since)
+	for _, format := range absoluteDateFormats {
+		if parsed, err := time.Parse(format, since); err == nil {
+			return parsed, nil
+		}
 	}
 
-	return parsed, nil
+	return time.Time{}, fmt.Errorf("invalid date format '%s': expected 'YYYY-MM-DD', 'YYYY-MM-DDTHH:MM:SS', RFC3339, or relative format like '7d'", since)
 }
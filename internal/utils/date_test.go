@@ -3,9 +3,14 @@ package utils
 import (
 	"testing"
 	"time"
+
+	"github.com/solvaholic/threadmine/internal/clock"
 )
 
 func TestParseSinceDate(t *testing.T) {
+	now := time.Date(2026, 1, 10, 15, 4, 5, 0, time.UTC)
+	t.Cleanup(clock.Freeze(now))
+
 	tests := []struct {
 		name        string
 		input       string
@@ -18,11 +23,9 @@ func TestParseSinceDate(t *testing.T) {
 			input:   "7d",
 			wantErr: false,
 			checkFunc: func(t *testing.T, got time.Time) {
-				expected := time.Now().AddDate(0, 0, -7)
-				// Allow 1 second tolerance for test execution time
-				diff := expected.Sub(got)
-				if diff > time.Second || diff < -time.Second {
-					t.Errorf("expected time around %v, got %v", expected, got)
+				expected := now.AddDate(0, 0, -7)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
 				}
 			},
 		},
@@ -31,10 +34,9 @@ func TestParseSinceDate(t *testing.T) {
 			input:   "1d",
 			wantErr: false,
 			checkFunc: func(t *testing.T, got time.Time) {
-				expected := time.Now().AddDate(0, 0, -1)
-				diff := expected.Sub(got)
-				if diff > time.Second || diff < -time.Second {
-					t.Errorf("expected time around %v, got %v", expected, got)
+				expected := now.AddDate(0, 0, -1)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
 				}
 			},
 		},
@@ -43,10 +45,9 @@ func TestParseSinceDate(t *testing.T) {
 			input:   "30d",
 			wantErr: false,
 			checkFunc: func(t *testing.T, got time.Time) {
-				expected := time.Now().AddDate(0, 0, -30)
-				diff := expected.Sub(got)
-				if diff > time.Second || diff < -time.Second {
-					t.Errorf("expected time around %v, got %v", expected, got)
+				expected := now.AddDate(0, 0, -30)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
 				}
 			},
 		},
@@ -72,6 +73,73 @@ func TestParseSinceDate(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:    "relative hours",
+			input:   "3h",
+			wantErr: false,
+			checkFunc: func(t *testing.T, got time.Time) {
+				expected := now.Add(-3 * time.Hour)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			},
+		},
+		{
+			name:    "relative weeks",
+			input:   "2w",
+			wantErr: false,
+			checkFunc: func(t *testing.T, got time.Time) {
+				expected := now.AddDate(0, 0, -14)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			},
+		},
+		{
+			name:    "timestamp without zone",
+			input:   "2025-12-15T15:04:05",
+			wantErr: false,
+			checkFunc: func(t *testing.T, got time.Time) {
+				expected := time.Date(2025, 12, 15, 15, 4, 5, 0, time.UTC)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			},
+		},
+		{
+			name:    "RFC3339 in UTC",
+			input:   "2025-12-15T15:00:00Z",
+			wantErr: false,
+			checkFunc: func(t *testing.T, got time.Time) {
+				expected := time.Date(2025, 12, 15, 15, 0, 0, 0, time.UTC)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			},
+		},
+		{
+			name:    "RFC3339 with offset and fraction",
+			input:   "2025-12-15T15:00:00.25-05:00",
+			wantErr: false,
+			checkFunc: func(t *testing.T, got time.Time) {
+				expected := time.Date(2025, 12, 15, 20, 0, 0, 250000000, time.UTC)
+				if !got.Equal(expected) {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			},
+		},
+		{
+			name:        "invalid format - timestamp without seconds",
+			input:       "2025-12-15T15:00",
+			wantErr:     true,
+			errContains: "invalid date format",
+		},
+		{
+			name:        "negative hours",
+			input:       "-3h",
+			wantErr:     true,
+			errContains: "hours cannot be negative",
+		},
 		{
 			name:        "empty string",
 			input:       "",
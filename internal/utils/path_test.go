@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TM_DATA", "/srv/threadmine")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"home only", "~", home},
+		{"home prefix", "~/data/tm.db", filepath.Join(home, "data", "tm.db")},
+		{"env var", "$TM_DATA/tm.db", "/srv/threadmine/tm.db"},
+		{"braced env var", "${TM_DATA}/tm.db", "/srv/threadmine/tm.db"},
+		{"HOME var", "$HOME/tm.db", filepath.Join(home, "tm.db")},
+		{"relative", "data/tm.db", filepath.Join(cwd, "data", "tm.db")},
+		{"dot relative", "./tm.db", filepath.Join(cwd, "tm.db")},
+		{"absolute", "/var/lib/tm.db", "/var/lib/tm.db"},
+		{"tilde user not expanded", "~bob/tm.db", filepath.Join(cwd, "~bob", "tm.db")},
+		{"cleaned", "/var/lib/../tm.db", "/var/tm.db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandPath(tt.input)
+			if err != nil {
+				t.Fatalf("ExpandPath(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandPath(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// Map calls fn on each of items, at most concurrency at a time (one at a time
+// if concurrency is less than one), and returns the results in the order of
+// items. Every item is tried even if others fail: failed items leave the zero
+// R in the results, and their errors are joined in the order of items. Once
+// ctx is done, items that haven't started aren't tried, and ctx's error is
+// joined too.
+func Map[T, R any](ctx context.Context, items []T, concurrency int, fn func(ctx context.Context, item T) (R, error)) ([]R, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]R, len(items))
+	errs := make([]error, len(items), len(items)+1)
+
+	next := make(chan int)
+	var skipped atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					skipped.Store(true)
+					continue
+				}
+				results[i], errs[i] = fn(ctx, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if skipped.Load() {
+		errs = append(errs, ctx.Err())
+	}
+	return results, errors.Join(errs...)
+}
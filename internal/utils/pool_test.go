@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap_Order(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		wantMax     int32
+	}{
+		{"sequential", 1, 1},
+		{"zero is sequential", 0, 1},
+		{"concurrent", 3, 3},
+		{"more workers than items", 20, int32(len(items))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning atomic.Int32
+			got, err := Map(context.Background(), items, tt.concurrency, func(ctx context.Context, n int) (string, error) {
+				now := running.Add(1)
+				defer running.Add(-1)
+				for {
+					seen := maxRunning.Load()
+					if now <= seen || maxRunning.CompareAndSwap(seen, now) {
+						break
+					}
+				}
+				// Later items finish first
+				time.Sleep(time.Duration(len(items)-n) * time.Millisecond)
+				return fmt.Sprintf("item %d", n), nil
+			})
+			if err != nil {
+				t.Fatalf("Map() failed: %v", err)
+			}
+
+			want := []string{"item 1", "item 2", "item 3", "item 4", "item 5", "item 6", "item 7", "item 8"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Map() = %v, want %v", got, want)
+			}
+			if maxRunning.Load() > tt.wantMax {
+				t.Errorf("%d items ran at once, want at most %d", maxRunning.Load(), tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestMap_Errors(t *testing.T) {
+	errTwo := errors.New("two failed")
+	errFour := errors.New("four failed")
+
+	got, err := Map(context.Background(), []int{1, 2, 3, 4, 5}, 2, func(ctx context.Context, n int) (int, error) {
+		switch n {
+		case 2:
+			return 0, errTwo
+		case 4:
+			return 0, errFour
+		}
+		return n * 10, nil
+	})
+	if !errors.Is(err, errTwo) || !errors.Is(err, errFour) {
+		t.Errorf("Map() error = %v, want both failures", err)
+	}
+	if want := []int{10, 0, 30, 0, 50}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %v, want %v (every item tried)", got, want)
+	}
+}
+
+func TestMap_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var tried []int
+	got, err := Map(ctx, []int{1, 2, 3, 4, 5}, 1, func(ctx context.Context, n int) (int, error) {
+		mu.Lock()
+		tried = append(tried, n)
+		mu.Unlock()
+		if n == 2 {
+			cancel()
+			return 0, ctx.Err()
+		}
+		return n, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Map() error = %v, want context.Canceled", err)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(tried, want) {
+		t.Errorf("tried %v, want %v", tried, want)
+	}
+	if want := []int{1, 0, 0, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %v, want %v", got, want)
+	}
+}
+
+func TestMap_AlreadyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err := Map(ctx, []int{1, 2}, 2, func(ctx context.Context, n int) (int, error) {
+		called = true
+		return n, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Map() error = %v, want context.Canceled", err)
+	}
+	if called {
+		t.Error("fn was called after ctx was done")
+	}
+}